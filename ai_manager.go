@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 )
@@ -37,6 +38,8 @@ type AIManager struct {
 	config          *AIConfig
 	mutex           sync.RWMutex
 	rateLimiter     *RateLimiter
+	// generationLimiter caps the heavier profile generation requests separately
+	generationLimiter *RateLimiter
 }
 
 // RateLimiter provides basic rate limiting for AI requests
@@ -86,9 +89,10 @@ func (rl *RateLimiter) Allow() bool {
 // NewAIManager creates a new AI manager
 func NewAIManager(config *AIConfig) *AIManager {
 	am := &AIManager{
-		providers:   make(map[string]AIProvider),
-		config:      config,
-		rateLimiter: NewRateLimiter(10, time.Minute), // 10 requests per minute
+		providers:         make(map[string]AIProvider),
+		config:            config,
+		rateLimiter:       NewRateLimiter(10, time.Minute), // 10 requests per minute
+		generationLimiter: NewRateLimiter(5, time.Minute),  // 5 profile generations per minute
 	}
 
 	// Register providers
@@ -164,6 +168,38 @@ func (am *AIManager) SendRequest(ctx context.Context, prompt string) (*AIRespons
 	return response, nil
 }
 
+// SendGenerationRequest sends a prompt with its own system message, for features that
+// need structured output rather than chat. It has a separate, lower rate limit.
+func (am *AIManager) SendGenerationRequest(ctx context.Context, prompt, systemMessage string) (string, error) {
+	am.mutex.RLock()
+	defer am.mutex.RUnlock()
+
+	if !am.config.Enabled {
+		return "", fmt.Errorf("AI features are disabled")
+	}
+
+	if am.currentProvider == nil {
+		return "", fmt.Errorf("no AI provider configured")
+	}
+
+	if !am.generationLimiter.Allow() {
+		return "", fmt.Errorf("rate limit exceeded, please try again later")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	content, err := am.currentProvider.SendRequest(ctx, prompt, systemMessage)
+	if err != nil {
+		fmt.Printf("AI generation request failed: %v\n", err)
+		return "", err
+	}
+	if strings.TrimSpace(content) == "" {
+		return "", fmt.Errorf("AI provider returned empty response")
+	}
+	return content, nil
+}
+
 // TestConnection tests the connection to the current AI provider
 func (am *AIManager) TestConnection(ctx context.Context) error {
 	am.mutex.RLock()
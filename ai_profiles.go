@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxAIProfileDescription caps the length of a generated profile description
+const maxAIProfileDescription = 500
+
+// sshProfileGenerationPrompt asks for an SSHConfig as bare JSON
+const sshProfileGenerationPrompt = `You turn a description of an SSH server into a connection config.
+Reply with a single JSON object and nothing else, using only these fields:
+{"host": string, "port": number, "username": string, "keyPath": string, "certPath": string, "allowKeyAutoDiscovery": boolean}
+"host", "port" and "username" are required; use port 22 when none is given. Leave "keyPath" and
+"certPath" empty unless the description names a key or certificate file. Never include a password.`
+
+// profileDescriptionPrompt asks for a one or two sentence description
+const profileDescriptionPrompt = `You write short descriptions for saved SSH connections in a terminal app.
+Given the connection's settings, reply with one or two plain sentences saying what the connection is
+for and how it connects. No markdown, no quotes, and never repeat secrets.`
+
+// extractJSONObject returns the outermost {...} of an AI reply, which may wrap it in prose or a code fence
+func extractJSONObject(reply string) (string, error) {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start == -1 || end < start {
+		return "", fmt.Errorf("AI response does not contain a JSON object")
+	}
+	return reply[start : end+1], nil
+}
+
+// parseGeneratedSSHConfig parses and validates the SSH config in an AI reply
+func parseGeneratedSSHConfig(reply string) (*SSHConfig, error) {
+	object, err := extractJSONObject(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	var sshConfig SSHConfig
+	if err := json.Unmarshal([]byte(object), &sshConfig); err != nil {
+		return nil, fmt.Errorf("AI response is not a valid SSH config: %w", err)
+	}
+	// Only the fields the prompt asks for are taken
+	sshConfig.Password = ""
+	sshConfig.Proxy = nil
+	sshConfig.TitleFormat = ""
+	sshConfig.JumpHosts = nil
+	sshConfig.Host = strings.TrimSpace(sshConfig.Host)
+	sshConfig.Username = strings.TrimSpace(sshConfig.Username)
+	if sshConfig.Port == 0 {
+		sshConfig.Port = 22
+	}
+
+	if err := sshConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("AI generated an invalid SSH config: %w", err)
+	}
+	return &sshConfig, nil
+}
+
+// GenerateSSHProfileFromDescription asks the AI for an SSH profile matching a description such as
+// "the staging box at 10.0.3.7 as deploy with my work key". The profile is not saved: the caller
+// reviews it and passes it to SaveProfile.
+func (a *App) GenerateSSHProfileFromDescription(description string) (*Profile, error) {
+	if a.ai == nil {
+		return nil, fmt.Errorf("AI manager not initialized")
+	}
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return nil, fmt.Errorf("description cannot be empty")
+	}
+
+	reply, err := a.ai.SendGenerationRequest(context.Background(), description, sshProfileGenerationPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate SSH profile: %w", err)
+	}
+	sshConfig, err := parseGeneratedSSHConfig(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &Profile{
+		ID:           generateID(),
+		Name:         fmt.Sprintf("%s@%s", sshConfig.Username, sshConfig.Host),
+		Icon:         "🖥️",
+		Type:         ProfileTypeSSH,
+		Environment:  make(map[string]string),
+		SSHConfig:    sshConfig,
+		Description:  description,
+		Created:      now,
+		LastModified: now,
+	}, nil
+}
+
+// ImproveProfileDescription asks the AI for a clear description of an SSH profile. The description
+// is returned for review, not saved. The password is never sent.
+func (a *App) ImproveProfileDescription(profileID string) (string, error) {
+	if a.ai == nil {
+		return "", fmt.Errorf("AI manager not initialized")
+	}
+
+	a.profiles.mutex.RLock()
+	profile, exists := a.profiles.profiles[profileID]
+	if !exists {
+		a.profiles.mutex.RUnlock()
+		return "", newNotFoundError(ErrCategoryProfile, "ImproveProfileDescription", "profile not found: %s", profileID)
+	}
+	if profile.Type != ProfileTypeSSH || profile.SSHConfig == nil {
+		a.profiles.mutex.RUnlock()
+		return "", fmt.Errorf("profile %s is not an SSH profile", profile.Name)
+	}
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Name: %s\n", profile.Name)
+	fmt.Fprintf(&prompt, "Host: %s\nPort: %d\nUsername: %s\n", profile.SSHConfig.Host, profile.SSHConfig.Port, profile.SSHConfig.Username)
+	if profile.SSHConfig.KeyPath != "" {
+		fmt.Fprintf(&prompt, "Authentication: key %s\n", profile.SSHConfig.KeyPath)
+	} else if profile.SSHConfig.Password != "" {
+		prompt.WriteString("Authentication: password\n")
+	}
+	if profile.SSHConfig.Proxy != nil && profile.SSHConfig.Proxy.Mode != "" {
+		fmt.Fprintf(&prompt, "Proxy: %s\n", profile.SSHConfig.Proxy.Mode)
+	}
+	if len(profile.Tags) > 0 {
+		fmt.Fprintf(&prompt, "Tags: %s\n", strings.Join(profile.Tags, ", "))
+	}
+	if profile.Description != "" {
+		fmt.Fprintf(&prompt, "Current description: %s\n", profile.Description)
+	}
+	a.profiles.mutex.RUnlock()
+
+	reply, err := a.ai.SendGenerationRequest(context.Background(), prompt.String(), profileDescriptionPrompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate description: %w", err)
+	}
+
+	description := strings.Trim(strings.TrimSpace(reply), `"`)
+	if runes := []rune(description); len(runes) > maxAIProfileDescription {
+		description = string(runes[:maxAIProfileDescription])
+	}
+	return description, nil
+}
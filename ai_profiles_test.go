@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// stubAIProvider answers every request with a fixed reply
+type stubAIProvider struct{ reply string }
+
+func (p *stubAIProvider) SendRequest(ctx context.Context, prompt, systemMessage string) (string, error) {
+	return p.reply, nil
+}
+func (p *stubAIProvider) TestConnection(ctx context.Context) error { return nil }
+func (p *stubAIProvider) GetProviderName() string                  { return "stub" }
+
+func TestParseGeneratedSSHConfig(t *testing.T) {
+	reply := "Here you go:\n```json\n{\"host\": \"10.0.3.7\", \"username\": \"deploy\", \"password\": \"hunter2\", \"keyPath\": \"~/.ssh/work\"}\n```"
+	sshConfig, err := parseGeneratedSSHConfig(reply)
+	if err != nil {
+		t.Fatalf("parseGeneratedSSHConfig() returned error: %v", err)
+	}
+	if sshConfig.Host != "10.0.3.7" || sshConfig.Port != 22 || sshConfig.KeyPath != "~/.ssh/work" || sshConfig.Password != "" {
+		t.Errorf("parseGeneratedSSHConfig() = %+v", sshConfig)
+	}
+
+	for _, reply := range []string{"no json here", `{"host": "example.com"}`, `{"host": "a", "port": 70000, "username": "b"}`} {
+		if _, err := parseGeneratedSSHConfig(reply); err == nil {
+			t.Errorf("parseGeneratedSSHConfig(%q) accepted an invalid config", reply)
+		}
+	}
+}
+
+func TestGenerateSSHProfileRateLimit(t *testing.T) {
+	app := NewApp()
+	app.ai = NewAIManager(&AIConfig{Enabled: true})
+	app.ai.currentProvider = &stubAIProvider{reply: `{"host": "db.internal", "port": 2222, "username": "ops"}`}
+
+	profile, err := app.GenerateSSHProfileFromDescription("the database box")
+	if err != nil || profile.Type != ProfileTypeSSH || profile.SSHConfig.Port != 2222 {
+		t.Fatalf("GenerateSSHProfileFromDescription() = %+v, %v", profile, err)
+	}
+	if _, saved := app.profiles.profiles[profile.ID]; saved {
+		t.Error("generated profile was saved")
+	}
+
+	for i := 1; i < 5; i++ {
+		if _, err := app.GenerateSSHProfileFromDescription("the database box"); err != nil {
+			t.Fatalf("request %d returned error: %v", i+1, err)
+		}
+	}
+	if _, err := app.GenerateSSHProfileFromDescription("the database box"); err == nil {
+		t.Error("sixth request within a minute was not rate limited")
+	}
+}
@@ -25,6 +25,10 @@ func (e *DialogError) Error() string {
 	return fmt.Sprintf("dialog %s failed: %v", e.Operation, e.Err)
 }
 
+func (e *DialogError) Unwrap() error {
+	return e.Err
+}
+
 // validateContext ensures application context is available for dialog operations
 func (a *App) validateContext() error {
 	if a.ctx == nil {
@@ -37,7 +37,9 @@ func (a *App) SetAIConfig(config *AIConfig) error {
 	}
 
 	// Update the configuration
+	a.config.mutex.Lock()
 	a.config.config.AI = *config
+	a.config.mutex.Unlock()
 	a.markConfigDirty()
 
 	// Update AI manager with new config
@@ -94,7 +96,9 @@ func (a *App) SetAIProvider(providerName string) error {
 	}
 
 	// Update config to persist the change
+	a.config.mutex.Lock()
 	a.config.config.AI.Provider = providerName
+	a.config.mutex.Unlock()
 	a.markConfigDirty()
 
 	return nil
@@ -127,7 +131,9 @@ func (a *App) EnableAI(enabled bool) error {
 		return fmt.Errorf("config not available")
 	}
 
+	a.config.mutex.Lock()
 	a.config.config.AI.Enabled = enabled
+	a.config.mutex.Unlock()
 	a.markConfigDirty()
 
 	return nil
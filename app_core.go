@@ -27,16 +27,26 @@ func linuxGpuPolicy() linux.WebviewGpuPolicy {
 	return linux.WebviewGpuPolicyOnDemand
 }
 
+// appEmitter emits through the Wails runtime once the app has started; events sent
+// before then are dropped
+type appEmitter struct {
+	app *App
+}
+
+func (e appEmitter) Emit(eventName string, data ...interface{}) {
+	if e.app.ctx == nil {
+		return
+	}
+	wailsRuntime.EventsEmit(e.app.ctx, eventName, data...)
+}
+
 // startup is called when the app starts. The context is saved
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 
-	// Config loading logic
-	if err := a.loadConfig(); err != nil {
-		fmt.Println("Error loading config:", err)
-		// Fallback or handle error appropriately
-	}
+	// Normally already loaded by createAppOptions
+	a.ensureConfigLoaded()
 
 	// Update AI manager with loaded config
 	if a.ai != nil && a.config != nil && a.config.config != nil {
@@ -47,19 +57,17 @@ func (a *App) startup(ctx context.Context) {
 		}
 	}
 
-	// Set initial window size and state using loaded/default config
-	if a.config != nil && a.config.config != nil { // Ensure config is not nil
-		wailsRuntime.WindowSetSize(a.ctx, a.config.config.WindowWidth, a.config.config.WindowHeight)
-		fmt.Printf("Initial window size set to: %d x %d\n", a.config.config.WindowWidth, a.config.config.WindowHeight)
-
-		// Restore window maximized state if it was saved as maximized
-		if a.config.config.WindowMaximized {
+	// Size and maximized state were applied at window creation; position can only be set once the window exists
+	if a.config != nil && a.config.config != nil {
+		cfg := a.config.config
+		if !cfg.WindowMaximized {
+			a.restoreWindowPosition()
+		} else if cfg.WindowX != nil && cfg.WindowY != nil {
+			// Started unmaximized (see windowStartState) - move to the saved display, then maximize there
+			a.restoreWindowPosition()
 			wailsRuntime.WindowMaximise(a.ctx)
 			fmt.Println("Window restored to maximized state")
 		}
-	} else {
-		// This case should ideally not be reached if NewApp initializes config correctly
-		fmt.Println("Config is nil, cannot set initial window size.")
 	}
 
 	// Initialize profile management system
@@ -68,22 +76,51 @@ func (a *App) startup(ctx context.Context) {
 		// Continue without profiles - they're not critical for basic functionality
 	}
 
+	// Bring back maintenance windows that were active when the app last closed
+	a.restoreMaintenanceWindows()
+
+	// Tabs closed before the app last quit can still be reopened
+	a.restoreRecentlyClosedTabs()
+
+	// Start the idle watcher for the terminal privacy lock
+	a.startPrivacyLockWatcher()
+
+	// Start the sweeper that releases state left behind by closed sessions
+	a.startSessionSweeper()
+
+	// Start the aggregate transfer speed events for the network activity indicator
+	a.startTransferThroughputTicker()
+
+	// Start the opt-in reachability prober for the profile tree
+	a.startProfileProber()
+
+	// Start the monitor that validates SSH sessions after a network change
+	a.startNetworkMonitor()
+
 	// Listen for frontend resize events
 	wailsRuntime.EventsOn(a.ctx, "frontend:window:resized", a.handleFrontendResizeEvent)
 	fmt.Println("Registered listener for window resize events.")
+
+	// Listen for answers to first-connection host key prompts
+	wailsRuntime.EventsOn(a.ctx, "host-key-trust-decision", a.handleHostKeyTrustDecision)
 }
 
 // shutdown is called during application shutdown (including auto-restart)
 func (a *App) shutdown(ctx context.Context) {
 	fmt.Println("Shutdown initiated...")
 
+	// Tabs closed from here on go with the app rather than onto the recently closed stack
+	a.terminal.mutex.Lock()
+	a.terminal.closingApp = true
+	a.terminal.mutex.Unlock()
+
 	// Stop the debounce timer if it's running
-	a.mutex.Lock()
+	a.config.mutex.Lock()
 	if a.config.debounceTimer != nil {
 		a.config.debounceTimer.Stop()
 		fmt.Println("Debounce timer stopped.")
 	}
-	a.mutex.Unlock()
+	a.config.mutex.Unlock()
 
 	// Final update and save of window state before shutdown
 	// We'll use defer/recover for additional safety during shutdown
@@ -96,9 +133,11 @@ func (a *App) shutdown(ctx context.Context) {
 	// Update final window state if possible
 	if a.ctx != nil && a.config != nil && a.config.config != nil { // Added check for a.config.config
 		// Capture previous state for comparison
+		a.config.mutex.RLock()
 		prevWidth := a.config.config.WindowWidth
 		prevHeight := a.config.config.WindowHeight
 		prevMaximized := a.config.config.WindowMaximized
+		a.config.mutex.RUnlock()
 
 		// Safe window size retrieval with validation and panic recovery
 		var width, height int
@@ -115,14 +154,11 @@ func (a *App) shutdown(ctx context.Context) {
 		}()
 
 		if width > 0 && height > 0 {
-			a.config.config.WindowWidth = width
-			a.config.config.WindowHeight = height
 			fmt.Printf("Final window size captured: %dx%d\n", width, height)
 		} else {
 			fmt.Printf("Invalid window dimensions during shutdown: %dx%d - keeping previous values (%dx%d)\n", width, height, prevWidth, prevHeight)
-			// Restore previous valid values
-			a.config.config.WindowWidth = prevWidth
-			a.config.config.WindowHeight = prevHeight
+			// Keep previous valid values
+			width, height = prevWidth, prevHeight
 		}
 
 		// Safe maximized state retrieval with panic recovery
@@ -138,19 +174,30 @@ func (a *App) shutdown(ctx context.Context) {
 			isMaximized = wailsRuntime.WindowIsMaximised(a.ctx)
 		}()
 
-		a.config.config.WindowMaximized = isMaximized
 		fmt.Printf("Final maximized state: %t\n", isMaximized)
 
-		// Check if the state actually changed during this shutdown capture
-		// and if so, mark configDirty = true to ensure it's saved by saveConfigIfDirty()
-		if a.config.config.WindowWidth != prevWidth ||
-			a.config.config.WindowHeight != prevHeight ||
-			a.config.config.WindowMaximized != prevMaximized {
-			a.mutex.Lock() // App's main mutex, which protects config.configDirty
+		// Safe position retrieval with panic recovery
+		positionChanged := false
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					fmt.Printf("Recovered from panic during WindowGetPosition in shutdown: %v\n", r)
+				}
+			}()
+			positionChanged = a.captureWindowPosition()
+		}()
+
+		// Store the final state and, if it changed during this shutdown capture,
+		// mark configDirty = true to ensure it's saved by saveConfigIfDirty()
+		a.config.mutex.Lock()
+		a.config.config.WindowWidth = width
+		a.config.config.WindowHeight = height
+		a.config.config.WindowMaximized = isMaximized
+		if width != prevWidth || height != prevHeight || isMaximized != prevMaximized || positionChanged {
 			a.config.configDirty = true
-			a.mutex.Unlock()
 			fmt.Println("Window state changed during shutdown, explicitly marked config dirty for final save.")
 		}
+		a.config.mutex.Unlock()
 	}
 
 	// Force save any pending config changes
@@ -159,6 +206,11 @@ func (a *App) shutdown(ctx context.Context) {
 	// Flush in-memory folder expanded states to disk before stopping watcher
 	a.SaveAllFolderStates()
 
+	// Keep the transfer totals moved since the last metrics save
+	if err := a.saveMetrics(); err != nil {
+		fmt.Printf("Warning: Failed to save metrics: %v\n", err)
+	}
+
 	// Stop profile watcher
 	a.StopProfileWatcher()
 
@@ -191,6 +243,9 @@ func (a *App) shutdown(ctx context.Context) {
 		}
 	}
 
+	// Files downloaded for an external application
+	cleanupExternalOpenFiles()
+
 	fmt.Println("Shutdown completed.")
 }
 
@@ -255,19 +310,31 @@ func (a *App) Greet(name string) string {
 
 // createAppOptions creates the Wails application options with platform-specific frameless setting
 func createAppOptions(app *App, assets embed.FS, isFrameless bool) *options.App {
+	// Load the saved window state now so the window opens at its last size instead of resizing after startup
+	app.ensureConfigLoaded()
+	width, height := DefaultWindowWidth, DefaultWindowHeight
+	startState := options.Normal
+	if app.config != nil && app.config.config != nil {
+		width, height = app.config.config.WindowWidth, app.config.config.WindowHeight
+		startState = windowStartState(app.config.config)
+	}
+
 	return &options.App{
-		Title:     "Thermic",
-		Width:     1024,
-		Height:    768,
-		MinWidth:  800,
-		MinHeight: 600,
-		Frameless: isFrameless,
+		Title:            "Thermic",
+		Width:            width,
+		Height:           height,
+		MinWidth:         MinWindowWidth,
+		MinHeight:        MinWindowHeight,
+		WindowStartState: startState,
+		Frameless:        isFrameless,
 		AssetServer: &assetserver.Options{
-			Assets: assets,
+			Assets:     assets,
+			Middleware: app.sftpStreamMiddleware,
 		},
 		BackgroundColour: &options.RGBA{R: 12, G: 12, B: 12, A: 1},
 		OnStartup:        app.startup,
 		OnShutdown:       app.shutdown,
+		ErrorFormatter:   formatBindingError,
 		Bind: []interface{}{
 			app,
 		},
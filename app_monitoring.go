@@ -61,16 +61,26 @@ func (mh *MetricHistory) GetData() ([]int64, []float64) {
 
 // InitSessionMetrics initializes metric histories for a session
 func (a *App) InitSessionMetrics(sessionID string) {
-	a.monitoring.mutex.Lock()
-	defer a.monitoring.mutex.Unlock()
+	a.monitoring.InitSessionMetrics(sessionID)
+}
+
+// RecordMetric records a metric value in the history
+func (a *App) RecordMetric(sessionID, metricName string, value float64) {
+	a.monitoring.RecordMetric(sessionID, metricName, value)
+}
+
+// InitSessionMetrics initializes metric histories for a session
+func (mm *MonitoringManager) InitSessionMetrics(sessionID string) {
+	mm.mutex.Lock()
+	defer mm.mutex.Unlock()
 
 	// Check if already initialized
-	if _, exists := a.monitoring.sessionHistories[sessionID]; exists {
+	if _, exists := mm.sessionHistories[sessionID]; exists {
 		return
 	}
 
 	// Create new session metrics with 120 data points (60 seconds at 500ms intervals)
-	a.monitoring.sessionHistories[sessionID] = &SessionMetrics{
+	mm.sessionHistories[sessionID] = &SessionMetrics{
 		CPU:       NewMetricHistory(120),
 		Memory:    NewMetricHistory(120),
 		Load:      NewMetricHistory(120),
@@ -83,21 +93,21 @@ func (a *App) InitSessionMetrics(sessionID string) {
 	}
 
 	// Set default update rate (3 seconds)
-	a.monitoring.updateRates[sessionID] = 3000
+	mm.updateRates[sessionID] = 3000
 }
 
-// RecordMetric records a metric value in the history
-func (a *App) RecordMetric(sessionID, metricName string, value float64) {
-	a.monitoring.mutex.RLock()
-	metrics, exists := a.monitoring.sessionHistories[sessionID]
-	a.monitoring.mutex.RUnlock()
+// RecordMetric records a metric value in the history (StatsCollector)
+func (mm *MonitoringManager) RecordMetric(sessionID, metricName string, value float64) {
+	mm.mutex.RLock()
+	metrics, exists := mm.sessionHistories[sessionID]
+	mm.mutex.RUnlock()
 
 	if !exists {
 		// Initialize if not exists
-		a.InitSessionMetrics(sessionID)
-		a.monitoring.mutex.RLock()
-		metrics = a.monitoring.sessionHistories[sessionID]
-		a.monitoring.mutex.RUnlock()
+		mm.InitSessionMetrics(sessionID)
+		mm.mutex.RLock()
+		metrics = mm.sessionHistories[sessionID]
+		mm.mutex.RUnlock()
 	}
 
 	timestamp := time.Now().UnixMilli()
@@ -129,13 +139,18 @@ func (a *App) RecordMetric(sessionID, metricName string, value float64) {
 
 // RecordStats records all stats from a stats map
 func (a *App) RecordStats(sessionID string, stats map[string]interface{}) {
+	recordStats(a.monitoring, sessionID, stats)
+}
+
+// recordStats parses a stats map from the monitoring panel and records each metric in collector
+func recordStats(collector StatsCollector, sessionID string, stats map[string]interface{}) {
 	fmt.Printf("RecordStats called for session: %s with %d stats\n", sessionID, len(stats))
 
 	// Parse and record each metric
 	if cpu, ok := stats["cpu"].(string); ok {
 		if val := parsePercentage(cpu); val >= 0 {
 			fmt.Printf("Recording CPU: %.1f%% for session %s\n", val, sessionID)
-			a.RecordMetric(sessionID, "cpu", val)
+			collector.RecordMetric(sessionID, "cpu", val)
 		}
 	}
 
@@ -150,20 +165,20 @@ func (a *App) RecordStats(sessionID string, stats map[string]interface{}) {
 				if unit == "GB" {
 					memoryMB = val * 1024
 				}
-				a.RecordMetric(sessionID, "memory", memoryMB)
+				collector.RecordMetric(sessionID, "memory", memoryMB)
 			}
 		}
 	}
 
 	if load, ok := stats["load"].(string); ok {
 		if val, err := strconv.ParseFloat(load, 64); err == nil && val >= 0 {
-			a.RecordMetric(sessionID, "load", val)
+			collector.RecordMetric(sessionID, "load", val)
 		}
 	}
 
 	if diskUsage, ok := stats["disk_usage"].(string); ok {
 		if val := parsePercentage(diskUsage); val >= 0 {
-			a.RecordMetric(sessionID, "disk_usage", val)
+			collector.RecordMetric(sessionID, "disk_usage", val)
 		}
 	}
 
@@ -174,29 +189,29 @@ func (a *App) RecordStats(sessionID string, stats map[string]interface{}) {
 	if diskRead, ok := stats["disk_read"].(string); ok {
 		if val := parseMBps(diskRead); val >= 0 {
 			diskReadVal = val
-			a.RecordMetric(sessionID, "disk_read", val)
+			collector.RecordMetric(sessionID, "disk_read", val)
 		}
 	}
 
 	if diskWrite, ok := stats["disk_write"].(string); ok {
 		if val := parseMBps(diskWrite); val >= 0 {
 			diskWriteVal = val
-			a.RecordMetric(sessionID, "disk_write", val)
+			collector.RecordMetric(sessionID, "disk_write", val)
 		}
 	}
 
 	// Record combined disk I/O
-	a.RecordMetric(sessionID, "disk_io", diskReadVal+diskWriteVal)
+	collector.RecordMetric(sessionID, "disk_io", diskReadVal+diskWriteVal)
 
 	if networkRX, ok := stats["network_rx"].(string); ok {
 		if val := parseMBps(networkRX); val >= 0 {
-			a.RecordMetric(sessionID, "network_rx", val)
+			collector.RecordMetric(sessionID, "network_rx", val)
 		}
 	}
 
 	if networkTX, ok := stats["network_tx"].(string); ok {
 		if val := parseMBps(networkTX); val >= 0 {
-			a.RecordMetric(sessionID, "network_tx", val)
+			collector.RecordMetric(sessionID, "network_tx", val)
 		}
 	}
 }
@@ -306,6 +321,9 @@ func (a *App) CleanupSessionMetrics(sessionID string) {
 	delete(a.monitoring.sessionHistories, sessionID)
 	delete(a.monitoring.updateRates, sessionID)
 	delete(a.monitoring.diskIOTracking, sessionID)
+	delete(a.monitoring.cpuSamples, sessionID)
+	a.clearDiskAlertsLockFree(sessionID)
+	a.clearLeakDetectorsLockFree(sessionID)
 }
 
 // Helper functions
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/aymanbagabas/go-pty"
+)
+
+// Nomad integration constants
+const (
+	NomadAPITimeout    = 15 * time.Second
+	NomadDefaultAddr   = "http://127.0.0.1:4646"
+	NomadExecShell     = "/bin/sh"
+	NomadProfileIcon   = "🟩"
+	NomadTokenHeader   = "X-Nomad-Token"
+	NomadDriverExec    = "exec"
+	NomadDriverRawExec = "raw_exec"
+)
+
+// nomadJobStub is the subset of /v1/jobs entries we use
+type nomadJobStub struct {
+	ID     string `json:"ID"`
+	Name   string `json:"Name"`
+	Status string `json:"Status"`
+}
+
+// nomadJob is the subset of /v1/job/{id} we use to find task drivers
+type nomadJob struct {
+	TaskGroups []struct {
+		Name  string `json:"Name"`
+		Tasks []struct {
+			Name   string `json:"Name"`
+			Driver string `json:"Driver"`
+		} `json:"Tasks"`
+	} `json:"TaskGroups"`
+}
+
+// nomadAllocStub is the subset of /v1/job/{id}/allocations entries we use
+type nomadAllocStub struct {
+	ID           string `json:"ID"`
+	Name         string `json:"Name"`
+	JobID        string `json:"JobID"`
+	TaskGroup    string `json:"TaskGroup"`
+	ClientStatus string `json:"ClientStatus"`
+}
+
+// nomadClient is a minimal Nomad HTTP API client
+type nomadClient struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+// get fetches a Nomad API path and decodes the JSON response into out
+func (c *nomadClient) get(path string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, c.addr+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build Nomad request: %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set(NomadTokenHeader, c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Nomad request %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Nomad API %s returned status %d", path, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode Nomad response for %s: %w", path, err)
+	}
+	return nil
+}
+
+// ImportNomadExecTasks creates a profile for every running allocation task that uses
+// the exec or raw_exec driver. Allocation/task pairs that already have a profile are skipped.
+func (a *App) ImportNomadExecTasks(nomadAddr, nomadToken string) ([]Profile, error) {
+	if nomadAddr == "" {
+		nomadAddr = os.Getenv("NOMAD_ADDR")
+	}
+	if nomadAddr == "" {
+		nomadAddr = NomadDefaultAddr
+	}
+	// An explicit token is kept on the profiles for exec; NOMAD_TOKEN is picked up again then
+	clientToken := nomadToken
+	if clientToken == "" {
+		clientToken = os.Getenv("NOMAD_TOKEN")
+	}
+
+	client := &nomadClient{
+		addr:   strings.TrimSuffix(nomadAddr, "/"),
+		token:  clientToken,
+		client: &http.Client{Timeout: NomadAPITimeout},
+	}
+
+	var jobs []nomadJobStub
+	if err := client.get("/v1/jobs", &jobs); err != nil {
+		return nil, err
+	}
+
+	var discovered []*Profile
+	for _, job := range jobs {
+		if job.Status == "dead" {
+			continue
+		}
+
+		jobPath := "/v1/job/" + url.PathEscape(job.ID)
+
+		var spec nomadJob
+		if err := client.get(jobPath, &spec); err != nil {
+			fmt.Printf("Warning: Failed to read Nomad job %s: %v\n", job.ID, err)
+			continue
+		}
+
+		// Collect exec-capable tasks per task group
+		execTasks := make(map[string][]string)
+		for _, group := range spec.TaskGroups {
+			for _, task := range group.Tasks {
+				if task.Driver == NomadDriverExec || task.Driver == NomadDriverRawExec {
+					execTasks[group.Name] = append(execTasks[group.Name], task.Name)
+				}
+			}
+		}
+		if len(execTasks) == 0 {
+			continue
+		}
+
+		var allocs []nomadAllocStub
+		if err := client.get(jobPath+"/allocations", &allocs); err != nil {
+			fmt.Printf("Warning: Failed to list allocations for Nomad job %s: %v\n", job.ID, err)
+			continue
+		}
+
+		for _, alloc := range allocs {
+			if alloc.ClientStatus != "running" {
+				continue
+			}
+			for _, taskName := range execTasks[alloc.TaskGroup] {
+				profile := newNomadExecProfile(nomadAddr, nomadToken, job.ID, alloc.ID, taskName)
+				if err := profile.NomadConfig.Validate(); err != nil {
+					fmt.Printf("Warning: Skipping Nomad allocation %s task %s: %v\n", alloc.ID, taskName, err)
+					continue
+				}
+				discovered = append(discovered, profile)
+			}
+		}
+	}
+
+	a.profiles.mutex.Lock()
+	defer a.profiles.mutex.Unlock()
+
+	// Skip allocation tasks that were imported before
+	existing := make(map[string]bool)
+	for _, profile := range a.profiles.profiles {
+		if profile.Type == ProfileTypeNomadExec && profile.NomadConfig != nil {
+			existing[profile.NomadConfig.AllocID+"/"+profile.NomadConfig.TaskName] = true
+		}
+	}
+
+	imported := make([]Profile, 0, len(discovered))
+	for _, profile := range discovered {
+		key := profile.NomadConfig.AllocID + "/" + profile.NomadConfig.TaskName
+		if existing[key] {
+			continue
+		}
+
+		if len(a.profiles.profiles) >= MaxProfiles {
+			return imported, fmt.Errorf("profile limit reached (%d)", MaxProfiles)
+		}
+
+		if err := a.saveProfileInternal(profile); err != nil {
+			return imported, &ProfileError{
+				Op:        "import",
+				ProfileID: profile.ID,
+				Err:       err,
+			}
+		}
+		existing[key] = true
+		imported = append(imported, *profile)
+	}
+
+	fmt.Printf("Imported %d Nomad exec profiles from %s\n", len(imported), nomadAddr)
+	return imported, nil
+}
+
+// newNomadExecProfile builds an unsaved profile for a Nomad allocation task
+func newNomadExecProfile(nomadAddr, nomadToken, jobID, allocID, taskName string) *Profile {
+	now := time.Now()
+	shortAlloc := allocID
+	if len(shortAlloc) > 8 {
+		shortAlloc = shortAlloc[:8]
+	}
+
+	return &Profile{
+		ID:          generateID(),
+		Name:        fmt.Sprintf("%s/%s (%s)", jobID, taskName, shortAlloc),
+		Icon:        NomadProfileIcon,
+		Type:        ProfileTypeNomadExec,
+		Environment: make(map[string]string),
+		NomadConfig: &NomadConfig{
+			Address:  nomadAddr,
+			JobID:    jobID,
+			AllocID:  allocID,
+			TaskName: taskName,
+			Token:    nomadToken,
+		},
+		Tags:         []string{"nomad", jobID},
+		Description:  fmt.Sprintf("Nomad allocation %s, task %s", allocID, taskName),
+		Created:      now,
+		LastModified: now,
+	}
+}
+
+// NomadExecSession starts `nomad alloc exec` for the given allocation task in a PTY session.
+// The Nomad address and token are taken from the owning tab's config, falling back to
+// NOMAD_ADDR and NOMAD_TOKEN.
+func (a *App) NomadExecSession(sessionID string, allocID, taskName string) error {
+	config := &NomadConfig{AllocID: allocID, TaskName: taskName}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	nomadPath, err := exec.LookPath("nomad")
+	if err != nil {
+		return fmt.Errorf("nomad CLI not found in PATH: %w", err)
+	}
+
+	// Pick up the Nomad address and token from the tab that owns this session, if any
+	a.terminal.mutex.RLock()
+	for _, tab := range a.terminal.tabs {
+		if tab.SessionID == sessionID && tab.NomadConfig != nil {
+			config.Address = tab.NomadConfig.Address
+			config.Token = tab.NomadConfig.Token
+			break
+		}
+	}
+	a.terminal.mutex.RUnlock()
+
+	return a.startPtySession(sessionID, func(ptty pty.Pty) (*pty.Cmd, error) {
+		cmd := ptty.Command(nomadPath, "alloc", "exec", "-i", "-t", "-task", taskName, allocID, NomadExecShell)
+		cmd.Env = nomadExecEnv(config)
+		configurePtyProcess(cmd)
+		return cmd, nil
+	})
+}
+
+// nomadExecEnv returns the environment for the nomad CLI, or nil to inherit ours unchanged.
+// The token goes in the environment rather than on the command line, where other local
+// users could read it.
+func nomadExecEnv(config *NomadConfig) []string {
+	if config.Address == "" && config.Token == "" {
+		return nil
+	}
+	env := os.Environ()
+	if config.Address != "" {
+		env = append(env, "NOMAD_ADDR="+config.Address)
+	}
+	if config.Token != "" {
+		env = append(env, "NOMAD_TOKEN="+config.Token)
+	}
+	return env
+}
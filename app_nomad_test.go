@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestNomadConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  NomadConfig
+		wantErr bool
+	}{
+		{"valid", NomadConfig{AllocID: "5a1b2c3d", TaskName: "web"}, false},
+		{"no allocation", NomadConfig{TaskName: "web"}, true},
+		{"no task", NomadConfig{AllocID: "5a1b2c3d"}, true},
+		{"allocation looks like a flag", NomadConfig{AllocID: "-address=http://evil", TaskName: "web"}, true},
+		{"task looks like a flag", NomadConfig{AllocID: "5a1b2c3d", TaskName: "-h"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestImportNomadExecTasks(t *testing.T) {
+	t.Setenv("NOMAD_TOKEN", "")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(NomadTokenHeader) != "acl-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var body interface{}
+		switch r.URL.Path {
+		case "/v1/jobs":
+			body = []nomadJobStub{{ID: "api", Status: "running"}}
+		case "/v1/job/api":
+			body = map[string]interface{}{"TaskGroups": []map[string]interface{}{
+				{"Name": "web", "Tasks": []map[string]string{{"Name": "server", "Driver": NomadDriverExec}, {"Name": "-h", "Driver": NomadDriverExec}}},
+			}}
+		case "/v1/job/api/allocations":
+			body = []nomadAllocStub{
+				{ID: "5a1b2c3d-0000", JobID: "api", TaskGroup: "web", ClientStatus: "running"},
+				{ID: "-address=http://evil", JobID: "api", TaskGroup: "web", ClientStatus: "running"},
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(body)
+	}))
+	defer server.Close()
+
+	app := NewApp()
+	app.config.config.ProfilesPath = t.TempDir()
+
+	imported, err := app.ImportNomadExecTasks(server.URL, "acl-token")
+	if err != nil {
+		t.Fatalf("ImportNomadExecTasks() returned error: %v", err)
+	}
+	// Tasks and allocations that would be read as nomad CLI flags are skipped
+	if len(imported) != 1 {
+		t.Fatalf("imported %d profiles, want 1: %+v", len(imported), imported)
+	}
+	config := imported[0].NomadConfig
+	if config.AllocID != "5a1b2c3d-0000" || config.TaskName != "server" || config.Token != "acl-token" {
+		t.Errorf("imported Nomad config = %+v", config)
+	}
+
+	env := nomadExecEnv(config)
+	if !slices.Contains(env, "NOMAD_TOKEN=acl-token") || !slices.Contains(env, "NOMAD_ADDR="+server.URL) {
+		t.Error("exec environment lacks the profile's Nomad address or token")
+	}
+	if nomadExecEnv(&NomadConfig{AllocID: "5a1b2c3d", TaskName: "server"}) != nil {
+		t.Error("exec environment set without an address or token")
+	}
+}
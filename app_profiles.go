@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -51,25 +50,24 @@ func (a *App) DeleteProfileAPI(id string) error {
 	a.profiles.mutex.Lock()
 	defer a.profiles.mutex.Unlock()
 
-	profile, exists := a.profiles.profiles[id]
+	_, exists := a.profiles.profiles[id]
 	if !exists {
-		return fmt.Errorf("profile not found: %s", id)
+		return newNotFoundError(ErrCategoryProfile, "DeleteProfileAPI", "profile not found: %s", id)
 	}
 
-	profilesDir, err := a.GetProfilesDirectory()
+	profilesDir, err := a.writableProfileDirLockFree(id)
 	if err != nil {
 		return err
 	}
 
-	filename := fmt.Sprintf("%s-%s.yaml", profile.Name, id)
-	filename = sanitizeFilename(filename)
-	filePath := filepath.Join(profilesDir, filename)
+	filePath := a.profileFilePath(profilesDir, id)
 
 	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to delete profile file %s: %w", filePath, err)
 	}
 
 	delete(a.profiles.profiles, id)
+	delete(a.profiles.profileSources, id)
 	return nil
 }
 
@@ -78,25 +76,24 @@ func (a *App) DeleteProfileFolderAPI(id string) error {
 	a.profiles.mutex.Lock()
 	defer a.profiles.mutex.Unlock()
 
-	folder, exists := a.profiles.profileFolders[id]
+	_, exists := a.profiles.profileFolders[id]
 	if !exists {
-		return fmt.Errorf("profile folder not found: %s", id)
+		return newNotFoundError(ErrCategoryProfile, "DeleteProfileFolderAPI", "profile folder not found: %s", id)
 	}
 
-	profilesDir, err := a.GetProfilesDirectory()
+	profilesDir, err := a.writableFolderDirLockFree(id)
 	if err != nil {
 		return err
 	}
 
-	filename := fmt.Sprintf("folder-%s-%s.yaml", folder.Name, id)
-	filename = sanitizeFilename(filename)
-	filePath := filepath.Join(profilesDir, filename)
+	filePath := a.folderFilePath(profilesDir, id)
 
 	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to delete profile folder file %s: %w", filePath, err)
 	}
 
 	delete(a.profiles.profileFolders, id)
+	delete(a.profiles.folderSources, id)
 	return nil
 }
 
@@ -107,7 +104,7 @@ func (a *App) GetProfile(id string) (*Profile, error) {
 
 	profile, exists := a.profiles.profiles[id]
 	if !exists {
-		return nil, fmt.Errorf("profile not found: %s", id)
+		return nil, newNotFoundError(ErrCategoryProfile, "GetProfile", "profile not found: %s", id)
 	}
 	return profile, nil
 }
@@ -119,7 +116,7 @@ func (a *App) GetProfileFolder(id string) (*ProfileFolder, error) {
 
 	folder, exists := a.profiles.profileFolders[id]
 	if !exists {
-		return nil, fmt.Errorf("profile folder not found: %s", id)
+		return nil, newNotFoundError(ErrCategoryProfile, "GetProfileFolder", "profile folder not found: %s", id)
 	}
 	return folder, nil
 }
@@ -131,7 +128,7 @@ func (a *App) MoveProfile(profileID, newFolderPath string) error {
 
 	profile, exists := a.profiles.profiles[profileID]
 	if !exists {
-		return fmt.Errorf("profile not found: %s", profileID)
+		return newNotFoundError(ErrCategoryProfile, "MoveProfile", "profile not found: %s", profileID)
 	}
 
 	// Update both path and ID references
@@ -156,13 +153,13 @@ func (a *App) MoveProfileByID(profileID, targetFolderID string) error {
 
 	profile, exists := a.profiles.profiles[profileID]
 	if !exists {
-		return fmt.Errorf("profile with ID %s not found", profileID)
+		return newNotFoundError(ErrCategoryProfile, "MoveProfileByID", "profile with ID %s not found", profileID)
 	}
 
 	// Validate target folder exists (empty string means root level)
 	if targetFolderID != "" {
 		if _, exists := a.profiles.profileFolders[targetFolderID]; !exists {
-			return fmt.Errorf("target folder with ID %s not found", targetFolderID)
+			return newNotFoundError(ErrCategoryProfile, "MoveProfileByID", "target folder with ID %s not found", targetFolderID)
 		}
 	}
 
@@ -192,7 +189,7 @@ func (a *App) DuplicateProfile(profileID string) (*Profile, error) {
 
 	original, exists := a.profiles.profiles[profileID]
 	if !exists {
-		return nil, fmt.Errorf("profile not found: %s", profileID)
+		return nil, newNotFoundError(ErrCategoryProfile, "DuplicateProfile", "profile not found: %s", profileID)
 	}
 
 	// Create a copy with new ID and name
@@ -201,6 +198,7 @@ func (a *App) DuplicateProfile(profileID string) (*Profile, error) {
 	duplicate.Name = original.Name + " (Copy)"
 	duplicate.Created = time.Now()
 	duplicate.LastModified = time.Now()
+	duplicate.Discovery = nil // The copy is the user's own, not the provider's
 
 	if err := a.saveProfileInternal(&duplicate); err != nil {
 		return nil, err
@@ -214,12 +212,12 @@ func (a *App) DeleteProfileFolderWithContentsAPI(id string) error {
 	a.profiles.mutex.Lock()
 	defer a.profiles.mutex.Unlock()
 
-	folder, exists := a.profiles.profileFolders[id]
+	_, exists := a.profiles.profileFolders[id]
 	if !exists {
-		return fmt.Errorf("profile folder not found: %s", id)
+		return newNotFoundError(ErrCategoryProfile, "DeleteProfileFolderWithContentsAPI", "profile folder not found: %s", id)
 	}
 
-	profilesDir, err := a.GetProfilesDirectory()
+	profilesDir, err := a.writableFolderDirLockFree(id)
 	if err != nil {
 		return err
 	}
@@ -239,30 +237,33 @@ func (a *App) DeleteProfileFolderWithContentsAPI(id string) error {
 	for _, profileID := range profilesToDelete {
 		profile := a.profiles.profiles[profileID]
 
-		// Delete profile file
-		filename := fmt.Sprintf("%s-%s.yaml", profile.Name, profileID)
-		filename = sanitizeFilename(filename)
+		// Profiles live in their own source, which may differ from the folder's
+		profileDir, err := a.writableProfileDirLockFree(profileID)
+		if err != nil {
+			fmt.Printf("Warning: Keeping profile %s: %v\n", profile.Name, err)
+			continue
+		}
 
-		filePath := filepath.Join(profilesDir, filename)
+		// Delete profile file
+		filePath := a.profileFilePath(profileDir, profileID)
 		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
 			fmt.Printf("Warning: Failed to delete profile file %s: %v\n", filePath, err)
 		}
 
 		// Remove from memory
 		delete(a.profiles.profiles, profileID)
+		delete(a.profiles.profileSources, profileID)
 	}
 
 	// Delete the folder file
-	folderFilename := fmt.Sprintf("folder-%s-%s.yaml", folder.Name, id)
-	folderFilename = sanitizeFilename(folderFilename)
-
-	folderFilePath := filepath.Join(profilesDir, folderFilename)
+	folderFilePath := a.folderFilePath(profilesDir, id)
 	if err := os.Remove(folderFilePath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to delete profile folder file: %w", err)
 	}
 
 	// Remove folder from memory
 	delete(a.profiles.profileFolders, id)
+	delete(a.profiles.folderSources, id)
 
 	return nil
 }
@@ -293,7 +294,7 @@ func (a *App) ToggleFavoriteAPI(profileID string) error {
 
 	profile, exists := a.profiles.profiles[profileID]
 	if !exists {
-		return fmt.Errorf("profile not found: %s", profileID)
+		return newNotFoundError(ErrCategoryProfile, "ToggleFavoriteAPI", "profile not found: %s", profileID)
 	}
 
 	profile.IsFavorite = !profile.IsFavorite
@@ -310,7 +311,7 @@ func (a *App) UpdateProfileTagsAPI(profileID string, tags []string) error {
 
 	profile, exists := a.profiles.profiles[profileID]
 	if !exists {
-		return fmt.Errorf("profile not found: %s", profileID)
+		return newNotFoundError(ErrCategoryProfile, "UpdateProfileTagsAPI", "profile not found: %s", profileID)
 	}
 
 	// Validate tag limits
@@ -327,13 +328,10 @@ func (a *App) UpdateProfileTagsAPI(profileID string, tags []string) error {
 }
 
 func (a *App) SearchProfilesAPI(query string, tags []string) []*Profile {
-	a.profiles.mutex.RLock()
-	defer a.profiles.mutex.RUnlock()
-
 	var results []*Profile
 	query = strings.ToLower(query)
 
-	for _, profile := range a.profiles.profiles {
+	for _, profile := range a.SnapshotProfiles() {
 		// Text search
 		if query != "" {
 			nameMatch := strings.Contains(strings.ToLower(profile.Name), query)
@@ -453,7 +451,7 @@ func (a *App) SetFolderExpandedAPI(folderID string, expanded bool) error {
 
 	folder, exists := a.profiles.profileFolders[folderID]
 	if !exists {
-		return fmt.Errorf("profile folder not found: %s", folderID)
+		return newNotFoundError(ErrCategoryProfile, "SetFolderExpandedAPI", "profile folder not found: %s", folderID)
 	}
 
 	folder.Expanded = expanded
@@ -466,7 +464,11 @@ func (a *App) SaveAllFolderStates() {
 	a.profiles.mutex.Lock()
 	defer a.profiles.mutex.Unlock()
 
-	for _, folder := range a.profiles.profileFolders {
+	for id, folder := range a.profiles.profileFolders {
+		// Read-only sources keep their expanded state in memory only
+		if source, exists := a.profiles.folderSources[id]; exists && source.ReadOnly {
+			continue
+		}
 		if err := a.saveProfileFolderInternal(folder); err != nil {
 			fmt.Printf("Warning: Failed to save folder %s state: %v\n", folder.Name, err)
 		}
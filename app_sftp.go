@@ -2,16 +2,17 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/pkg/sftp"
@@ -68,6 +69,7 @@ type TransferJob struct {
 	TotalFiles int
 	IsUpload   bool
 	FileSize   int64
+	Mode       uint32 // Permissions set on an uploaded file; 0 keeps the server's default
 }
 
 // TransferResult represents the result of a file transfer
@@ -76,6 +78,16 @@ type TransferResult struct {
 	Error error
 }
 
+// SFTPPartialSkipEvent is sent for each subdirectory a directory download leaves out because it
+// can't be read
+const SFTPPartialSkipEvent = "sftp-partial-skip"
+
+// SkippedDirectory is a remote directory a download couldn't read, and why
+type SkippedDirectory struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
 // TransferState tracks active transfers for cancellation
 type TransferState struct {
 	cancelled bool
@@ -135,6 +147,7 @@ func (pr *progressReader) Read(p []byte) (int, error) {
 	n, err := pr.reader.Read(p)
 	if n > 0 {
 		pr.readBytes += int64(n)
+		recordTransferBytes(pr.sessionID, pr.direction, n)
 		now := time.Now()
 		if pr.readBytes == pr.totalBytes || now.Sub(pr.lastEmitted) >= 150*time.Millisecond {
 			percent := float64(0)
@@ -148,7 +161,7 @@ func (pr *progressReader) Read(p []byte) (int, error) {
 				bytesPerSec = int64(float64(pr.readBytes) / elapsed)
 			}
 			if pr.app != nil && pr.app.ctx != nil {
-				wailsRuntime.EventsEmit(pr.app.ctx, pr.eventName, map[string]interface{}{
+				event := map[string]interface{}{
 					"sessionId":   pr.sessionID,
 					"phase":       "progress",
 					"direction":   pr.direction,
@@ -159,7 +172,12 @@ func (pr *progressReader) Read(p []byte) (int, error) {
 					"total":       pr.totalBytes,
 					"percent":     percent,
 					"bytesPerSec": bytesPerSec,
-				})
+				}
+				// Auto-tuned transfers also report the settings in use
+				for k, v := range sftpTuningFields(pr.sessionID) {
+					event[k] = v
+				}
+				wailsRuntime.EventsEmit(pr.app.ctx, pr.eventName, event)
 			}
 			pr.lastEmitted = now
 		}
@@ -211,6 +229,7 @@ func (pw *progressWriter) Write(p []byte) (int, error) {
 	n, err := pw.writer.Write(p)
 	if n > 0 {
 		pw.writtenBytes += int64(n)
+		recordTransferBytes(pw.sessionID, pw.direction, n)
 		now := time.Now()
 		if pw.writtenBytes == pw.totalBytes || now.Sub(pw.lastEmitted) >= 150*time.Millisecond {
 			percent := float64(0)
@@ -224,7 +243,7 @@ func (pw *progressWriter) Write(p []byte) (int, error) {
 				bytesPerSec = int64(float64(pw.writtenBytes) / elapsed)
 			}
 			if pw.app != nil && pw.app.ctx != nil {
-				wailsRuntime.EventsEmit(pw.app.ctx, pw.eventName, map[string]interface{}{
+				event := map[string]interface{}{
 					"sessionId":   pw.sessionID,
 					"phase":       "progress",
 					"direction":   pw.direction,
@@ -235,7 +254,12 @@ func (pw *progressWriter) Write(p []byte) (int, error) {
 					"total":       pw.totalBytes,
 					"percent":     percent,
 					"bytesPerSec": bytesPerSec,
-				})
+				}
+				// Auto-tuned transfers also report the settings in use
+				for k, v := range sftpTuningFields(pw.sessionID) {
+					event[k] = v
+				}
+				wailsRuntime.EventsEmit(pw.app.ctx, pw.eventName, event)
 			}
 			pw.lastEmitted = now
 		}
@@ -252,22 +276,11 @@ func (a *App) emitDownloadEvent(sessionID string, phase string, payload map[stri
 }
 
 func (a *App) emitTransferEvent(sessionID string, phase string, direction string, payload map[string]interface{}) {
-	if a == nil || a.ctx == nil {
+	if a == nil {
 		return
 	}
-	data := map[string]interface{}{
-		"sessionId": sessionID,
-		"phase":     phase,
-		"direction": direction,
-	}
-	for k, v := range payload {
-		data[k] = v
-	}
-	eventName := "sftp-upload-progress"
-	if direction == "download" {
-		eventName = "sftp-download-progress"
-	}
-	wailsRuntime.EventsEmit(a.ctx, eventName, data)
+	eventName, data := transferEvent(sessionID, phase, direction, payload)
+	appEmitter{a}.Emit(eventName, data)
 }
 
 // getSFTPConfig returns the current SFTP configuration with defaults
@@ -287,6 +300,15 @@ func (a *App) getSFTPConfig() SFTPConfig {
 		if cfg.ParallelTransfers == 0 {
 			cfg.ParallelTransfers = DefaultSFTPParallelTransfers
 		}
+		if cfg.ClientPoolSize == 0 {
+			cfg.ClientPoolSize = DefaultSFTPClientPoolSize
+		}
+		if cfg.MaxPreviewSize == 0 {
+			cfg.MaxPreviewSize = DefaultSFTPMaxPreviewSize
+		}
+		if cfg.DownloadMargin == 0 {
+			cfg.DownloadMargin = DefaultSFTPDownloadMargin
+		}
 		return cfg
 	}
 	return SFTPConfig{
@@ -294,7 +316,10 @@ func (a *App) getSFTPConfig() SFTPConfig {
 		BufferSize:         DefaultSFTPBufferSize,
 		ConcurrentRequests: DefaultSFTPConcurrentRequests,
 		ParallelTransfers:  DefaultSFTPParallelTransfers,
+		ClientPoolSize:     DefaultSFTPClientPoolSize,
 		UseConcurrentIO:    true,
+		MaxPreviewSize:     DefaultSFTPMaxPreviewSize,
+		DownloadMargin:     DefaultSFTPDownloadMargin,
 	}
 }
 
@@ -305,6 +330,8 @@ func (a *App) startTransfer(sessionID string) *TransferState {
 
 	state := &TransferState{cancelled: false}
 	activeTransfers[sessionID] = state
+	// A title format may show {transfers}
+	a.scheduleTabTitleRefresh(sessionID)
 	return state
 }
 
@@ -312,7 +339,21 @@ func (a *App) startTransfer(sessionID string) *TransferState {
 func (a *App) endTransfer(sessionID string) {
 	activeTransfersMu.Lock()
 	defer activeTransfersMu.Unlock()
-	delete(activeTransfers, sessionID)
+	if _, exists := activeTransfers[sessionID]; exists {
+		delete(activeTransfers, sessionID)
+		a.scheduleTabTitleRefresh(sessionID)
+	}
+}
+
+// activeTransferCount returns how many SFTP transfers are running in a session. A session
+// runs one transfer (of one or more files) at a time.
+func activeTransferCount(sessionID string) int {
+	activeTransfersMu.RLock()
+	defer activeTransfersMu.RUnlock()
+	if _, exists := activeTransfers[sessionID]; exists {
+		return 1
+	}
+	return 0
 }
 
 // isTransferCancelled checks if a transfer has been cancelled
@@ -348,6 +389,26 @@ func (a *App) CancelSFTPTransfer(sessionID string) error {
 	return nil
 }
 
+// SFTPHealthCheckTimeout bounds the liveness probe run before reusing an SFTP client
+const SFTPHealthCheckTimeout = 3 * time.Second
+
+// checkSFTPClientHealth sends a single SSH_FXP_REALPATH request for "." and waits at most
+// SFTPHealthCheckTimeout for the reply, so a dead connection can't stall the caller.
+func checkSFTPClientHealth(client *sftp.Client) error {
+	result := make(chan error, 1)
+	go func() {
+		_, err := client.RealPath(".")
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(SFTPHealthCheckTimeout):
+		return fmt.Errorf("SFTP health check timed out after %v", SFTPHealthCheckTimeout)
+	}
+}
+
 // getOrReconnectSFTPClient gets the SFTP client, reconnecting if connection was lost
 func (a *App) getOrReconnectSFTPClient(sessionID string) (*sftp.Client, error) {
 	a.ssh.sftpClientsMutex.RLock()
@@ -358,35 +419,113 @@ func (a *App) getOrReconnectSFTPClient(sessionID string) (*sftp.Client, error) {
 		return nil, fmt.Errorf("SFTP client not initialized for session %s", sessionID)
 	}
 
-	// Test if connection is still alive by trying a simple operation
-	_, err := sftpClient.Getwd()
+	if err := checkSFTPClientHealth(sftpClient); err != nil {
+		fmt.Printf("SFTP connection lost for session %s (%v), attempting reconnect...\n", sessionID, err)
+		return a.reconnectSFTPClientNoLock(sessionID, sftpClient)
+	}
+
+	return sftpClient, nil
+}
+
+// reconnectSFTPClientNoLock replaces failed, the session's SFTP client that stopped working,
+// and must be called without holding sftpClientsMutex. The new client is opened outside the
+// lock, so other readers aren't held up by a slow reconnect, and swapped in under it. When
+// another caller replaced failed first, the new client is closed and theirs is returned.
+func (a *App) reconnectSFTPClientNoLock(sessionID string, failed *sftp.Client) (*sftp.Client, error) {
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+
+	if !exists || sshSession == nil {
+		return nil, newNotFoundError(ErrCategorySFTP, "reconnectSFTPClientNoLock", "SSH session %s not found", sessionID)
+	}
+
+	if sshSession.client == nil {
+		return nil, fmt.Errorf("SSH session %s is not connected", sessionID)
+	}
+
+	a.ssh.sftpClientsMutex.RLock()
+	current := a.ssh.sftpClients[sessionID]
+	a.ssh.sftpClientsMutex.RUnlock()
+	if current != failed {
+		return replacedSFTPClient(sessionID, current)
+	}
+
+	newClient, err := a.newSFTPClient(sessionID, sshSession)
 	if err != nil {
-		fmt.Printf("SFTP connection lost for session %s, attempting reconnect...\n", sessionID)
+		return nil, fmt.Errorf("failed to reconnect SFTP: %w", err)
+	}
 
-		// Close the old client
-		a.ssh.sftpClientsMutex.Lock()
-		if oldClient, exists := a.ssh.sftpClients[sessionID]; exists {
-			oldClient.Close()
-			delete(a.ssh.sftpClients, sessionID)
-		}
+	a.ssh.sftpClientsMutex.Lock()
+	oldClient := a.ssh.sftpClients[sessionID]
+	if oldClient != failed {
 		a.ssh.sftpClientsMutex.Unlock()
+		newClient.Close()
+		return replacedSFTPClient(sessionID, oldClient)
+	}
 
-		// Reinitialize the SFTP client
-		if err := a.InitializeFileExplorerSession(sessionID); err != nil {
-			return nil, fmt.Errorf("failed to reconnect SFTP: %w", err)
-		}
+	a.ssh.sftpClients[sessionID] = newClient
+	a.ssh.resourceManager.Register(&SFTPClientWrapper{
+		client:    newClient,
+		sessionID: sessionID,
+	})
+	// The extra channels most likely went down with the old client; the next transfer reopens them
+	a.dropSFTPPoolLocked(sessionID)
+	a.ssh.sftpClientsMutex.Unlock()
+
+	// Closing a dead client can block on the broken transport - don't hold up the caller
+	if oldClient != nil {
+		go oldClient.Close()
+	}
+
+	fmt.Printf("SFTP reconnected successfully for session %s\n", sessionID)
+
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, "sftp-reconnected", map[string]interface{}{
+			"sessionId": sessionID,
+		})
+	}
 
-		// Get the new client
-		a.ssh.sftpClientsMutex.RLock()
-		sftpClient = a.ssh.sftpClients[sessionID]
-		a.ssh.sftpClientsMutex.RUnlock()
+	return newClient, nil
+}
 
-		fmt.Printf("SFTP reconnected successfully for session %s\n", sessionID)
+// replacedSFTPClient returns the client another caller installed in place of a failed one
+func replacedSFTPClient(sessionID string, client *sftp.Client) (*sftp.Client, error) {
+	if client == nil {
+		return nil, fmt.Errorf("SFTP client not initialized for session %s", sessionID)
 	}
+	return client, nil
+}
 
+// newSFTPClient creates an SFTP client over the session's SSH connection with the session's tuning
+func (a *App) newSFTPClient(sessionID string, sshSession *SSHSession) (*sftp.Client, error) {
+	sftpClient, err := sftp.NewClient(sshSession.client, sftpClientOptions(a.sessionSFTPConfig(sessionID))...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SFTP client: %w", err)
+	}
 	return sftpClient, nil
 }
 
+// sftpClientOptions builds the SFTP client options for a configuration
+func sftpClientOptions(cfg SFTPConfig) []sftp.ClientOption {
+	// Build SFTP client options for optimized performance
+	var opts []sftp.ClientOption
+
+	// Increase max packet size (default is 32KB, we use 256KB for better throughput)
+	// Use MaxPacketUnchecked to bypass the 32KB safety check - modern SFTP servers support larger packets
+	opts = append(opts, sftp.MaxPacketUnchecked(cfg.MaxPacketSize))
+
+	// Set concurrent requests per file for parallel I/O within a single file transfer
+	opts = append(opts, sftp.MaxConcurrentRequestsPerFile(cfg.ConcurrentRequests))
+
+	// Enable concurrent reads and writes for better performance on high-latency connections
+	if cfg.UseConcurrentIO {
+		opts = append(opts, sftp.UseConcurrentReads(true))
+		opts = append(opts, sftp.UseConcurrentWrites(true))
+	}
+	return opts
+}
+
 // InitializeFileExplorerSession initializes an SFTP client for the given SSH session
 // Uses optimized settings for improved transfer performance
 func (a *App) InitializeFileExplorerSession(sessionID string) error {
@@ -409,36 +548,17 @@ func (a *App) InitializeFileExplorerSession(sessionID string) error {
 	a.ssh.sshSessionsMutex.RUnlock()
 
 	if !exists || sshSession == nil {
-		return fmt.Errorf("SSH session %s not found", sessionID)
+		return newNotFoundError(ErrCategorySFTP, "InitializeFileExplorerSession", "SSH session %s not found", sessionID)
 	}
 
 	if sshSession.client == nil {
 		return fmt.Errorf("SSH session %s is not connected", sessionID)
 	}
 
-	// Get optimized SFTP configuration
-	cfg := a.getSFTPConfig()
-
-	// Build SFTP client options for optimized performance
-	var opts []sftp.ClientOption
-
-	// Increase max packet size (default is 32KB, we use 256KB for better throughput)
-	// Use MaxPacketUnchecked to bypass the 32KB safety check - modern SFTP servers support larger packets
-	opts = append(opts, sftp.MaxPacketUnchecked(cfg.MaxPacketSize))
-
-	// Set concurrent requests per file for parallel I/O within a single file transfer
-	opts = append(opts, sftp.MaxConcurrentRequestsPerFile(cfg.ConcurrentRequests))
-
-	// Enable concurrent reads and writes for better performance on high-latency connections
-	if cfg.UseConcurrentIO {
-		opts = append(opts, sftp.UseConcurrentReads(true))
-		opts = append(opts, sftp.UseConcurrentWrites(true))
-	}
-
 	// Create optimized SFTP client
-	sftpClient, err := sftp.NewClient(sshSession.client, opts...)
+	sftpClient, err := a.newSFTPClient(sessionID, sshSession)
 	if err != nil {
-		return fmt.Errorf("failed to create SFTP client: %w", err)
+		return err
 	}
 
 	// Create wrapper for resource management
@@ -453,9 +573,15 @@ func (a *App) InitializeFileExplorerSession(sessionID string) error {
 	// Register for resource cleanup
 	a.ssh.resourceManager.Register(wrapper)
 
+	cfg := a.sessionSFTPConfig(sessionID)
 	fmt.Printf("SFTP client initialized for session %s (MaxPacket=%dKB, ConcurrentReqs=%d, ConcurrentIO=%v)\n",
 		sessionID, cfg.MaxPacketSize/1024, cfg.ConcurrentRequests, cfg.UseConcurrentIO)
 
+	// Measure the connection in the background so opening the explorer isn't delayed
+	if cfg.AutoTuneBuffer {
+		go a.tuneSFTPBuffer(sessionID, sftpClient)
+	}
+
 	return nil
 }
 
@@ -466,7 +592,7 @@ func (a *App) GetRemoteWorkingDirectory(sessionID string) (string, error) {
 	a.ssh.sshSessionsMutex.RUnlock()
 
 	if !exists || sshSession == nil {
-		return "", fmt.Errorf("SSH session %s not found", sessionID)
+		return "", newNotFoundError(ErrCategorySFTP, "GetRemoteWorkingDirectory", "SSH session %s not found", sessionID)
 	}
 
 	// Check if monitoring session is available
@@ -479,7 +605,7 @@ func (a *App) GetRemoteWorkingDirectory(sessionID string) (string, error) {
 	}
 
 	// Execute pwd command to get current working directory
-	output, err := a.ExecuteMonitoringCommand(sshSession, "pwd")
+	output, err := a.executeMonitoringCommand(sshSession, remoteCmdWorkingDir)
 	if err != nil {
 		return "", fmt.Errorf("failed to execute pwd command: %w", err)
 	}
@@ -503,23 +629,47 @@ func (a *App) CloseFileExplorerSession(sessionID string) error {
 		delete(a.ssh.sftpClients, sessionID)
 		fmt.Printf("SFTP client closed for session %s\n", sessionID)
 	}
+	a.dropSFTPPoolLocked(sessionID)
+	a.invalidateDirectoryCache(sessionID)
 
 	return nil
 }
 
-// ListRemoteFiles lists files and directories in the specified remote path
+// ListRemoteFiles lists files and directories in the specified remote path.
+// Listings are served from the directory cache while fresh and refreshed in the background;
+// a fresh listing also starts a prefetch of its subdirectories.
 func (a *App) ListRemoteFiles(sessionID string, remotePath string) ([]RemoteFileEntry, error) {
+	// Normalize path - use "." as fallback for empty path
+	if remotePath == "" {
+		remotePath = "."
+	}
+
+	if entries, ok := a.cachedDirectoryListing(sessionID, remotePath); ok {
+		a.refreshDirectoryListing(sessionID, remotePath)
+		return entries, nil
+	}
+
 	sftpClient, err := a.getOrReconnectSFTPClient(sessionID)
 	if err != nil {
 		return nil, err
 	}
-	_ = sftpClient // used below
 
-	// Normalize path - use "." as fallback for empty path
-	if remotePath == "" {
-		remotePath = "."
+	var entries []RemoteFileEntry
+	err = a.retryableSFTPOp(sessionID, "list "+remotePath, sftpClient, func(sftpClient *sftp.Client) error {
+		var readErr error
+		entries, readErr = a.readRemoteDirectory(sessionID, sftpClient, remotePath)
+		return readErr
+	})
+	if err != nil {
+		return nil, err
 	}
+	a.storeDirectoryListing(sessionID, remotePath, entries)
+	go a.prefetchDirectories(sessionID, subdirectoryPaths(entries), 0)
+	return entries, nil
+}
 
+// readRemoteDirectory lists a remote directory over the given SFTP client
+func (a *App) readRemoteDirectory(sessionID string, sftpClient *sftp.Client, remotePath string) ([]RemoteFileEntry, error) {
 	// Log the path being accessed for debugging
 	fmt.Printf("SFTP: Listing files for session %s, path: %s\n", sessionID, remotePath)
 
@@ -529,6 +679,8 @@ func (a *App) ListRemoteFiles(sessionID string, remotePath string) ([]RemoteFile
 		fmt.Printf("SFTP: Failed to read directory %s: %v\n", remotePath, err)
 		return nil, fmt.Errorf("failed to read directory %s: %w", remotePath, err)
 	}
+	// A listing is also the freshest entry count for the directory's badge
+	storeDirectoryCount(sessionID, remotePath, len(fileInfos))
 
 	// Get the working directory to resolve relative paths consistently
 	var baseDir string
@@ -592,6 +744,8 @@ func (a *App) ListRemoteFiles(sessionID string, remotePath string) ([]RemoteFile
 		entries = append(entries, entry)
 	}
 
+	a.markRemoteMountEntries(sessionID, entries)
+
 	fmt.Printf("SFTP: Successfully listed %d entries for path: %s\n", len(entries), remotePath)
 	return entries, nil
 }
@@ -603,27 +757,34 @@ func (a *App) ListRemoteFilesWithSudo(sessionID string, remotePath string) ([]Re
 	a.ssh.sshSessionsMutex.RUnlock()
 
 	if !exists || sshSession == nil {
-		return nil, fmt.Errorf("SSH session %s not found", sessionID)
+		return nil, newNotFoundError(ErrCategorySFTP, "ListRemoteFilesWithSudo", "SSH session %s not found", sessionID)
 	}
 
-	// Normalize path
-	if remotePath == "" {
-		remotePath = "."
+	// Resolve the default directory first; sudo only gets absolute paths
+	if remotePath == "" || remotePath == "." {
+		pwdOutput, err := a.executeMonitoringCommand(sshSession, remoteCmdWorkingDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve working directory: %w", err)
+		}
+		remotePath = strings.TrimSpace(pwdOutput)
 	}
 
 	fmt.Printf("SFTP: Listing files with sudo for session %s, path: %s\n", sessionID, remotePath)
 
 	// Use sudo ls -la to get detailed file listing
 	// Format: permissions, links, owner, group, size, month, day, time/year, name
-	cmd := fmt.Sprintf("sudo ls -la --time-style='+%%Y-%%m-%%d %%H:%%M:%%S' %q 2>&1", remotePath)
-	output, err := a.ExecuteMonitoringCommand(sshSession, cmd)
+	cmd, err := sudoListDirectoryCommand(remotePath)
+	if err != nil {
+		return nil, err
+	}
+	output, err := a.runSudoCommand(sshSession, "ListRemoteFilesWithSudo", cmd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list directory with sudo: %w", err)
 	}
 
 	// Check for error in output
 	if strings.Contains(output, "No such file or directory") {
-		return nil, fmt.Errorf("directory not found: %s", remotePath)
+		return nil, newNotFoundError(ErrCategorySFTP, "ListRemoteFilesWithSudo", "directory not found: %s", remotePath)
 	}
 	if strings.Contains(output, "Not a directory") {
 		return nil, fmt.Errorf("not a directory: %s", remotePath)
@@ -636,15 +797,7 @@ func (a *App) ListRemoteFilesWithSudo(sessionID string, remotePath string) ([]Re
 	var entries []RemoteFileEntry
 	lines := strings.Split(output, "\n")
 
-	// Resolve base directory for constructing full paths
 	baseDir := remotePath
-	if remotePath == "." {
-		// Try to get absolute path
-		pwdOutput, pwdErr := a.ExecuteMonitoringCommand(sshSession, "pwd")
-		if pwdErr == nil {
-			baseDir = strings.TrimSpace(pwdOutput)
-		}
-	}
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -759,12 +912,12 @@ func (a *App) CheckDirectoryReadPermission(sessionID string, remotePath string)
 	a.ssh.sshSessionsMutex.RUnlock()
 
 	if !exists || sshSession == nil {
-		return false, fmt.Errorf("SSH session %s not found", sessionID)
+		return false, newNotFoundError(ErrCategorySFTP, "CheckDirectoryReadPermission", "SSH session %s not found", sessionID)
 	}
 
 	// Use test -r to check if directory is readable
-	cmd := fmt.Sprintf("test -r %q && test -x %q && echo 'readable' || echo 'denied'", remotePath, remotePath)
-	output, err := a.ExecuteMonitoringCommand(sshSession, cmd)
+	cmd := buildRemoteCommand("test -r %[1]s && test -x %[1]s && echo 'readable' || echo 'denied'", remotePath)
+	output, err := a.executeMonitoringCommand(sshSession, cmd)
 	if err != nil {
 		// If monitoring session is not available, assume readable and let SFTP fail if not
 		return true, nil
@@ -773,12 +926,49 @@ func (a *App) CheckDirectoryReadPermission(sessionID string, remotePath string)
 	return strings.Contains(output, "readable"), nil
 }
 
+// shellQuote quotes s as a single word for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// CheckRemoteDirectoryWritePermission checks if the current user can create files in a directory
+func (a *App) CheckRemoteDirectoryWritePermission(sessionID string, remotePath string) (bool, error) {
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+
+	if !exists || sshSession == nil {
+		return false, newNotFoundError(ErrCategorySFTP, "CheckRemoteDirectoryWritePermission", "SSH session %s not found", sessionID)
+	}
+
+	output, err := a.executeMonitoringCommand(sshSession, writePermissionCommand(remotePath))
+	if err != nil {
+		return false, fmt.Errorf("failed to check write permission on %s: %w", remotePath, err)
+	}
+
+	return parseWritePermissionOutput(remotePath, output)
+}
+
+// parseWritePermissionOutput reads the answer of writePermissionCommand
+func parseWritePermissionOutput(remotePath, output string) (bool, error) {
+	switch strings.TrimSpace(output) {
+	case "ok":
+		return true, nil
+	case "denied":
+		return false, nil
+	case "missing":
+		return false, newNotFoundError(ErrCategorySFTP, "CheckRemoteDirectoryWritePermission", "remote directory %s not found", remotePath)
+	}
+	return false, fmt.Errorf("failed to check write permission on %s: unexpected output %q", remotePath, output)
+}
+
 // DownloadRemoteFile downloads a file from the remote server to local path with progress reporting
 func (a *App) DownloadRemoteFile(sessionID string, remotePath string, localPath string) error {
 	return a.DownloadRemoteFileWithProgress(sessionID, remotePath, localPath, 1, 1)
 }
 
-// DownloadRemoteFileWithProgress downloads a file with progress reporting for batch operations
+// DownloadRemoteFileWithProgress downloads a file with progress reporting for batch operations.
+// A download interrupted by a dropped connection starts over on a reconnected client.
 func (a *App) DownloadRemoteFileWithProgress(sessionID string, remotePath string, localPath string, fileIndex, totalFiles int) error {
 	a.ssh.sftpClientsMutex.RLock()
 	sftpClient, exists := a.ssh.sftpClients[sessionID]
@@ -788,6 +978,26 @@ func (a *App) DownloadRemoteFileWithProgress(sessionID string, remotePath string
 		return fmt.Errorf("SFTP client not initialized for session %s", sessionID)
 	}
 
+	err := a.retryableSFTPOp(sessionID, "download "+remotePath, sftpClient, func(sftpClient *sftp.Client) error {
+		return a.downloadRemoteFileOnce(sessionID, sftpClient, remotePath, localPath, fileIndex, totalFiles)
+	})
+	if errors.Is(err, ErrTransferCancelled) {
+		// Delete the partial file
+		os.Remove(localPath)
+		return fmt.Errorf("download cancelled")
+	}
+	if err != nil {
+		a.emitDownloadEvent(sessionID, "error", map[string]interface{}{
+			"fileName": filepath.Base(remotePath),
+			"error":    err.Error(),
+		})
+	}
+	return err
+}
+
+// downloadRemoteFileOnce makes one attempt at a download. Failures are reported to the
+// frontend by the caller, once retries are exhausted.
+func (a *App) downloadRemoteFileOnce(sessionID string, sftpClient *sftp.Client, remotePath string, localPath string, fileIndex, totalFiles int) error {
 	// Open remote file
 	remoteFile, err := sftpClient.Open(remotePath)
 	if err != nil {
@@ -815,16 +1025,12 @@ func (a *App) DownloadRemoteFileWithProgress(sessionID string, remotePath string
 	// Create local file
 	localFile, err := os.Create(localPath)
 	if err != nil {
-		a.emitDownloadEvent(sessionID, "error", map[string]interface{}{
-			"fileName": fileName,
-			"error":    err.Error(),
-		})
 		return fmt.Errorf("failed to create local file %s: %w", localPath, err)
 	}
 	defer localFile.Close()
 
 	// Use buffered writer for better performance
-	cfg := a.getSFTPConfig()
+	cfg := a.sessionSFTPConfig(sessionID)
 	bufferedWriter := bufio.NewWriterSize(localFile, cfg.BufferSize)
 	defer bufferedWriter.Flush()
 
@@ -833,21 +1039,9 @@ func (a *App) DownloadRemoteFileWithProgress(sessionID string, remotePath string
 
 	// Use optimized buffer for copying
 	buffer := make([]byte, cfg.BufferSize)
-	_, err = io.CopyBuffer(progressWriter, remoteFile, buffer)
+	started := time.Now()
+	err = a.copyFromRemoteFile(sessionID, progressWriter, remoteFile, totalBytes, buffer)
 	if err != nil {
-		// Close file before attempting delete
-		localFile.Close()
-
-		// If cancelled, delete the partial file
-		if errors.Is(err, ErrTransferCancelled) {
-			os.Remove(localPath)
-			return fmt.Errorf("download cancelled")
-		}
-
-		a.emitDownloadEvent(sessionID, "error", map[string]interface{}{
-			"fileName": fileName,
-			"error":    err.Error(),
-		})
 		return fmt.Errorf("failed to copy file data: %w", err)
 	}
 
@@ -855,6 +1049,7 @@ func (a *App) DownloadRemoteFileWithProgress(sessionID string, remotePath string
 	if err := bufferedWriter.Flush(); err != nil {
 		return fmt.Errorf("failed to flush file data: %w", err)
 	}
+	a.recordSFTPTransferRate(sessionID, totalBytes, time.Since(started))
 
 	// Emit download complete event
 	a.emitDownloadEvent(sessionID, "complete", map[string]interface{}{
@@ -870,8 +1065,16 @@ func (a *App) DownloadRemoteFileWithProgress(sessionID string, remotePath string
 }
 
 // DownloadRemoteDirectory downloads a directory recursively from the remote server to local path
-// Uses parallel file downloads for improved performance
+// Uses parallel file downloads for improved performance, after checking the local target
 func (a *App) DownloadRemoteDirectory(sessionID string, remotePath string, localPath string) error {
+	return a.DownloadRemoteDirectoryWithOptions(sessionID, remotePath, localPath, DownloadOptions{})
+}
+
+// DownloadRemoteDirectoryWithOptions downloads a directory recursively. Unless opts skips them,
+// the local target is checked for free space, write permission and file name lengths first,
+// failing with a DownloadPreflightError. While files download, a disk about to fill up
+// stops the batch, keeping the files already completed.
+func (a *App) DownloadRemoteDirectoryWithOptions(sessionID string, remotePath string, localPath string, opts DownloadOptions) error {
 	a.ssh.sftpClientsMutex.RLock()
 	sftpClient, exists := a.ssh.sftpClients[sessionID]
 	a.ssh.sftpClientsMutex.RUnlock()
@@ -895,17 +1098,38 @@ func (a *App) DownloadRemoteDirectory(sessionID string, remotePath string, local
 	a.startTransfer(sessionID)
 	defer a.endTransfer(sessionID)
 
-	// Create local directory
-	err = os.MkdirAll(localPath, 0755)
-	if err != nil {
-		return fmt.Errorf("failed to create local directory %s: %w", localPath, err)
-	}
-
 	// First, collect all files to download for progress tracking
 	var downloadJobs []TransferJob
-	if err := a.collectDownloadJobs(sftpClient, remotePath, localPath, &downloadJobs); err != nil {
+	localDirs := []string{localPath}
+	dirModes := map[string]os.FileMode{localPath: stat.Mode()}
+	skipped, err := a.collectDownloadJobs(sftpClient, remotePath, localPath, &downloadJobs, &localDirs, dirModes)
+	if err != nil {
 		return err
 	}
+	for _, dir := range skipped {
+		fmt.Printf("Warning: skipping unreadable directory %s in download of %s: %s\n", dir.Path, remotePath, dir.Error)
+		appEmitter{a}.Emit(SFTPPartialSkipEvent, map[string]interface{}{
+			"sessionId":  sessionID,
+			"sourcePath": remotePath,
+			"path":       dir.Path,
+			"error":      dir.Error,
+		})
+	}
+
+	cfg := a.sessionSFTPConfig(sessionID)
+	if !opts.SkipPreflight {
+		if err := preflightDownload(localPath, downloadJobs, localDirs, cfg.DownloadMargin); err != nil {
+			return err
+		}
+	}
+
+	// Create the local directories, writable until the files are in them
+	for _, dir := range localDirs {
+		if err := os.MkdirAll(localDownloadPath(dir), 0755); err != nil {
+			return fmt.Errorf("failed to create local directory %s: %w", dir, err)
+		}
+	}
+	defer applyDownloadDirModes(localDirs, dirModes)
 
 	if len(downloadJobs) == 0 {
 		return nil // Empty directory
@@ -926,9 +1150,29 @@ func (a *App) DownloadRemoteDirectory(sessionID string, remotePath string, local
 		"dirName":    dirName,
 	})
 
-	// Use parallel download worker pool
-	cfg := a.getSFTPConfig()
-	if err := a.executeParallelDownloads(sessionID, sftpClient, downloadJobs, cfg.ParallelTransfers); err != nil {
+	// Use parallel download worker pool, watching the disk fill up
+	stopWatching := make(chan struct{})
+	lowSpace := a.watchDownloadSpace(sessionID, localPath, DownloadSpaceAbortFloor, DownloadSpaceCheckInterval, stopWatching)
+	results, err := a.executeParallelDownloads(sessionID, sftpClient, downloadJobs, cfg.ParallelTransfers)
+	close(stopWatching)
+	a.emitBatchSummary(sessionID, "download", downloadJobs, results)
+	if available := lowSpace.Load(); available >= 0 {
+		var completedPaths []string
+		for _, result := range results {
+			if result.Error == nil {
+				completedPaths = append(completedPaths, result.Job.LocalPath)
+			}
+		}
+		return &DownloadPreflightError{
+			Check:     DownloadCheckSpace,
+			Path:      localPath,
+			Available: available,
+			Aborted:   true,
+			Completed: completedPaths,
+			Cause:     err,
+		}
+	}
+	if err != nil {
 		return err
 	}
 
@@ -942,26 +1186,34 @@ func (a *App) DownloadRemoteDirectory(sessionID string, remotePath string, local
 	return nil
 }
 
-// collectDownloadJobs recursively collects all files to download, creating directories as needed
-func (a *App) collectDownloadJobs(sftpClient *sftp.Client, remotePath string, localPath string, jobs *[]TransferJob) error {
+// collectDownloadJobs recursively collects all files to download, and the local directories
+// to create for them along with the modes of the remote ones. Subdirectories that can't be read,
+// such as another user's private folder, are left out and returned as skipped; only failing to
+// read remotePath itself is an error.
+func (a *App) collectDownloadJobs(sftpClient *sftp.Client, remotePath string, localPath string, jobs *[]TransferJob, dirs *[]string, dirModes map[string]os.FileMode) ([]SkippedDirectory, error) {
 	fileInfos, err := sftpClient.ReadDir(remotePath)
 	if err != nil {
-		return fmt.Errorf("failed to read directory %s: %w", remotePath, err)
+		return nil, fmt.Errorf("failed to read directory %s: %w", remotePath, err)
 	}
 
+	var skipped []SkippedDirectory
 	for _, fileInfo := range fileInfos {
 		remoteItemPath := joinRemotePath(remotePath, fileInfo.Name())
 		localItemPath := filepath.Join(localPath, fileInfo.Name())
 
 		if fileInfo.IsDir() {
-			// Create local subdirectory
-			if err := os.MkdirAll(localItemPath, 0755); err != nil {
-				return fmt.Errorf("failed to create local directory %s: %w", localItemPath, err)
-			}
+			*dirs = append(*dirs, localItemPath)
+			dirModes[localItemPath] = fileInfo.Mode()
 			// Recursively collect from subdirectory
-			if err := a.collectDownloadJobs(sftpClient, remoteItemPath, localItemPath, jobs); err != nil {
-				return err
+			subSkipped, err := a.collectDownloadJobs(sftpClient, remoteItemPath, localItemPath, jobs, dirs, dirModes)
+			if err != nil {
+				// Nothing below it was collected; don't create it locally either
+				*dirs = (*dirs)[:len(*dirs)-1]
+				delete(dirModes, localItemPath)
+				skipped = append(skipped, SkippedDirectory{Path: remoteItemPath, Error: errors.Unwrap(err).Error()})
+				continue
 			}
+			skipped = append(skipped, subSkipped...)
 		} else {
 			// Add file to download jobs
 			*jobs = append(*jobs, TransferJob{
@@ -973,13 +1225,33 @@ func (a *App) collectDownloadJobs(sftpClient *sftp.Client, remotePath string, lo
 			})
 		}
 	}
-	return nil
+	return skipped, nil
 }
 
-// executeParallelDownloads runs download jobs using a worker pool
-func (a *App) executeParallelDownloads(sessionID string, sftpClient *sftp.Client, jobs []TransferJob, workers int) error {
+// applyDownloadDirModes gives the local copies of downloaded directories the permissions of
+// the remote ones. Subdirectories go first, as a parent may lose the owner's write or search
+// bit. Windows has no POSIX permissions to copy.
+func applyDownloadDirModes(localDirs []string, dirModes map[string]os.FileMode) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	for i := len(localDirs) - 1; i >= 0; i-- {
+		dir := localDirs[i]
+		mode, exists := dirModes[dir]
+		if !exists {
+			continue
+		}
+		if err := os.Chmod(localDownloadPath(dir), mode.Perm()); err != nil {
+			fmt.Printf("Warning: failed to set permissions %#o on %s: %v\n", mode.Perm(), dir, err)
+		}
+	}
+}
+
+// executeParallelDownloads runs download jobs using a worker pool, returning the result of
+// every job along with the first error
+func (a *App) executeParallelDownloads(sessionID string, sftpClient *sftp.Client, jobs []TransferJob, workers int) ([]TransferResult, error) {
 	if len(jobs) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	// Limit workers to job count
@@ -991,19 +1263,21 @@ func (a *App) executeParallelDownloads(sessionID string, sftpClient *sftp.Client
 	resultChan := make(chan TransferResult, len(jobs))
 	var wg sync.WaitGroup
 
-	// Start worker goroutines
+	// Start worker goroutines, spread over the session's SFTP channels
+	clients := a.sftpTransferClients(sessionID, sftpClient, workers)
 	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		go func() {
+		client := clients[i%len(clients)]
+		a.goSession(sessionID, func() {
 			defer wg.Done()
-			cfg := a.getSFTPConfig()
+			cfg := a.sessionSFTPConfig(sessionID)
 			buffer := make([]byte, cfg.BufferSize)
 
 			for job := range jobChan {
-				err := a.downloadSingleFile(sessionID, sftpClient, job, buffer)
+				err := a.downloadSingleFile(sessionID, client, job, buffer)
 				resultChan <- TransferResult{Job: job, Error: err}
 			}
-		}()
+		})
 	}
 
 	// Send jobs to workers
@@ -1019,21 +1293,23 @@ func (a *App) executeParallelDownloads(sessionID string, sftpClient *sftp.Client
 	}()
 
 	// Collect results
+	var results []TransferResult
 	var firstError error
 	for result := range resultChan {
+		results = append(results, result)
 		if result.Error != nil && firstError == nil {
 			firstError = result.Error
 		}
 	}
 
-	return firstError
+	return results, firstError
 }
 
 // downloadSingleFile downloads a single file with progress reporting
 func (a *App) downloadSingleFile(sessionID string, sftpClient *sftp.Client, job TransferJob, buffer []byte) error {
 	// Check for cancellation before starting
 	if a.isTransferCancelled(sessionID) {
-		return fmt.Errorf("transfer cancelled")
+		return errTransferSkipped
 	}
 
 	// Emit start event
@@ -1056,7 +1332,7 @@ func (a *App) downloadSingleFile(sessionID string, sftpClient *sftp.Client, job
 	defer remoteFile.Close()
 
 	// Create local file
-	localFile, err := os.Create(job.LocalPath)
+	localFile, err := os.Create(localDownloadPath(job.LocalPath))
 	if err != nil {
 		a.emitDownloadEvent(sessionID, "error", map[string]interface{}{
 			"fileName": job.FileName,
@@ -1067,7 +1343,7 @@ func (a *App) downloadSingleFile(sessionID string, sftpClient *sftp.Client, job
 	defer localFile.Close()
 
 	// Use buffered writer
-	cfg := a.getSFTPConfig()
+	cfg := a.sessionSFTPConfig(sessionID)
 	bufferedWriter := bufio.NewWriterSize(localFile, cfg.BufferSize)
 	defer bufferedWriter.Flush()
 
@@ -1075,14 +1351,15 @@ func (a *App) downloadSingleFile(sessionID string, sftpClient *sftp.Client, job
 	progressWriter := newProgressWriter(bufferedWriter, a, sessionID, job.FileName, job.FileIndex, job.TotalFiles, job.FileSize, "download")
 
 	// Copy with buffer
-	_, err = io.CopyBuffer(progressWriter, remoteFile, buffer)
+	started := time.Now()
+	err = a.copyFromRemoteFile(sessionID, progressWriter, remoteFile, job.FileSize, buffer)
 	if err != nil {
 		// Close file before attempting delete
 		localFile.Close()
 
 		// If cancelled, delete the partial file
 		if errors.Is(err, ErrTransferCancelled) {
-			os.Remove(job.LocalPath)
+			os.Remove(localDownloadPath(job.LocalPath))
 			return fmt.Errorf("download cancelled")
 		}
 
@@ -1097,6 +1374,7 @@ func (a *App) downloadSingleFile(sessionID string, sftpClient *sftp.Client, job
 	if err := bufferedWriter.Flush(); err != nil {
 		return fmt.Errorf("failed to flush file data: %w", err)
 	}
+	a.recordSFTPTransferRate(sessionID, job.FileSize, time.Since(started))
 
 	// Emit complete event
 	a.emitDownloadEvent(sessionID, "complete", map[string]interface{}{
@@ -1111,8 +1389,45 @@ func (a *App) downloadSingleFile(sessionID string, sftpClient *sftp.Client, job
 	return nil
 }
 
-// UploadRemoteFiles uploads local files to the remote directory using parallel transfers
+// UploadOptions controls how UploadRemoteFilesWithOptions uploads files
+type UploadOptions struct {
+	PermissionCheck bool   `json:"permissionCheck"` // Check the target directory is writable before sending any data
+	Mode            uint32 `json:"mode"`            // Permissions for the uploaded files, e.g. 0755; 0 keeps the server's default
+}
+
+// maxUploadMode covers the permission bits plus setuid, setgid and sticky
+const maxUploadMode = 07777
+
+// validateUploadMode rejects modes with bits other than permissions
+func validateUploadMode(mode uint32) error {
+	if mode > maxUploadMode {
+		return newInvalidError(ErrCategorySFTP, "validateUploadMode", "invalid file mode %#o: only permission bits (up to %#o) can be set", mode, maxUploadMode)
+	}
+	return nil
+}
+
+// applyUploadMode sets the permissions of a just-created remote file or directory; mode 0 leaves the
+// server's default, which the remote umask decides
+func applyUploadMode(sftpClient *sftp.Client, remotePath string, mode uint32) error {
+	if mode == 0 {
+		return nil
+	}
+	if err := sftpClient.Chmod(remotePath, os.FileMode(mode)); err != nil {
+		return fmt.Errorf("failed to set permissions %#o on %s: %w", mode, remotePath, err)
+	}
+	return nil
+}
+
+// UploadRemoteFiles uploads local files to the remote directory using parallel transfers,
+// after checking the directory is writable
 func (a *App) UploadRemoteFiles(sessionID string, localFilePaths []string, remotePath string) error {
+	return a.UploadRemoteFilesWithOptions(sessionID, localFilePaths, remotePath, UploadOptions{PermissionCheck: true})
+}
+
+// UploadRemoteFilesWithOptions uploads local files to the remote directory using parallel transfers
+func (a *App) UploadRemoteFilesWithOptions(sessionID string, localFilePaths []string, remotePath string, opts UploadOptions) error {
+	defer a.invalidateDirectoryCache(sessionID)
+
 	a.ssh.sftpClientsMutex.RLock()
 	sftpClient, exists := a.ssh.sftpClients[sessionID]
 	a.ssh.sftpClientsMutex.RUnlock()
@@ -1125,6 +1440,25 @@ func (a *App) UploadRemoteFiles(sessionID string, localFilePaths []string, remot
 	if totalFiles == 0 {
 		return nil
 	}
+	if err := validateUploadMode(opts.Mode); err != nil {
+		return err
+	}
+
+	// Fail before any data is sent rather than when the first remote file is created
+	if opts.PermissionCheck {
+		writable, err := a.CheckRemoteDirectoryWritePermission(sessionID, remotePath)
+		if err != nil {
+			return err
+		}
+		if !writable {
+			return &ThermicError{
+				Code:     ErrCodePermission,
+				Category: ErrCategorySFTP,
+				Op:       "UploadRemoteFiles",
+				Message:  fmt.Sprintf("permission denied: cannot write to %s", remotePath),
+			}
+		}
+	}
 
 	// Start transfer tracking for cancellation
 	a.startTransfer(sessionID)
@@ -1150,6 +1484,7 @@ func (a *App) UploadRemoteFiles(sessionID string, localFilePaths []string, remot
 			TotalFiles: totalFiles,
 			IsUpload:   true,
 			FileSize:   fileSize,
+			Mode:       opts.Mode,
 		})
 	}
 
@@ -1160,8 +1495,10 @@ func (a *App) UploadRemoteFiles(sessionID string, localFilePaths []string, remot
 	})
 
 	// Use parallel upload worker pool
-	cfg := a.getSFTPConfig()
-	if err := a.executeParallelUploads(sessionID, sftpClient, uploadJobs, cfg.ParallelTransfers); err != nil {
+	cfg := a.sessionSFTPConfig(sessionID)
+	results, err := a.executeParallelUploads(sessionID, sftpClient, uploadJobs, cfg.ParallelTransfers)
+	a.emitBatchSummary(sessionID, "upload", uploadJobs, results)
+	if err != nil {
 		return err
 	}
 
@@ -1174,20 +1511,25 @@ func (a *App) UploadRemoteFiles(sessionID string, localFilePaths []string, remot
 	return nil
 }
 
-// executeParallelUploads runs upload jobs using a worker pool
-func (a *App) executeParallelUploads(sessionID string, sftpClient *sftp.Client, jobs []TransferJob, workers int) error {
+// executeParallelUploads runs upload jobs using a worker pool, returning the result of every
+// job run along with the first error
+func (a *App) executeParallelUploads(sessionID string, sftpClient *sftp.Client, jobs []TransferJob, workers int) ([]TransferResult, error) {
 	if len(jobs) == 0 {
-		return nil
+		return nil, nil
 	}
 
-	// For small batches, use sequential processing to maintain order
+	// For small batches, use sequential processing to maintain order; the first failure
+	// stops the batch
 	if len(jobs) <= 2 || workers == 1 {
+		var results []TransferResult
 		for _, job := range jobs {
-			if err := a.uploadSingleFile(sessionID, sftpClient, job); err != nil {
-				return err
+			err := a.uploadSingleFile(sessionID, sftpClient, job)
+			results = append(results, TransferResult{Job: job, Error: err})
+			if err != nil {
+				return results, err
 			}
 		}
-		return nil
+		return results, nil
 	}
 
 	// Limit workers to job count
@@ -1199,16 +1541,18 @@ func (a *App) executeParallelUploads(sessionID string, sftpClient *sftp.Client,
 	resultChan := make(chan TransferResult, len(jobs))
 	var wg sync.WaitGroup
 
-	// Start worker goroutines
+	// Start worker goroutines, spread over the session's SFTP channels
+	clients := a.sftpTransferClients(sessionID, sftpClient, workers)
 	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		go func() {
+		client := clients[i%len(clients)]
+		a.goSession(sessionID, func() {
 			defer wg.Done()
 			for job := range jobChan {
-				err := a.uploadSingleFile(sessionID, sftpClient, job)
+				err := a.uploadSingleFile(sessionID, client, job)
 				resultChan <- TransferResult{Job: job, Error: err}
 			}
-		}()
+		})
 	}
 
 	// Send jobs to workers
@@ -1224,25 +1568,44 @@ func (a *App) executeParallelUploads(sessionID string, sftpClient *sftp.Client,
 	}()
 
 	// Collect results
+	var results []TransferResult
 	var firstError error
-	var completedCount int32
 	for result := range resultChan {
-		atomic.AddInt32(&completedCount, 1)
+		results = append(results, result)
 		if result.Error != nil && firstError == nil {
 			firstError = result.Error
 		}
 	}
 
-	return firstError
+	return results, firstError
 }
 
-// uploadSingleFile uploads a single file with progress reporting
+// uploadSingleFile uploads a single file with progress reporting. An upload interrupted by a
+// dropped connection starts over on a reconnected client.
 func (a *App) uploadSingleFile(sessionID string, sftpClient *sftp.Client, job TransferJob) error {
 	// Check for cancellation before starting
 	if a.isTransferCancelled(sessionID) {
-		return fmt.Errorf("transfer cancelled")
+		return errTransferSkipped
+	}
+
+	err := a.retryableSFTPOp(sessionID, "upload "+job.RemotePath, sftpClient, func(sftpClient *sftp.Client) error {
+		return a.uploadSingleFileOnce(sessionID, sftpClient, job)
+	})
+	if errors.Is(err, ErrTransferCancelled) {
+		return fmt.Errorf("upload cancelled")
+	}
+	if err != nil {
+		a.emitUploadEvent(sessionID, "error", map[string]interface{}{
+			"fileName": job.FileName,
+			"error":    err.Error(),
+		})
 	}
+	return err
+}
 
+// uploadSingleFileOnce makes one attempt at an upload. Failures are reported to the frontend
+// by the caller, once retries are exhausted.
+func (a *App) uploadSingleFileOnce(sessionID string, sftpClient *sftp.Client, job TransferJob) error {
 	// Emit start event
 	a.emitUploadEvent(sessionID, "start", map[string]interface{}{
 		"fileName":   job.FileName,
@@ -1254,10 +1617,6 @@ func (a *App) uploadSingleFile(sessionID string, sftpClient *sftp.Client, job Tr
 	// Open local file
 	localFile, err := os.Open(job.LocalPath)
 	if err != nil {
-		a.emitUploadEvent(sessionID, "error", map[string]interface{}{
-			"fileName": job.FileName,
-			"error":    err.Error(),
-		})
 		return fmt.Errorf("failed to open local file %s: %w", job.LocalPath, err)
 	}
 	defer localFile.Close()
@@ -1265,16 +1624,15 @@ func (a *App) uploadSingleFile(sessionID string, sftpClient *sftp.Client, job Tr
 	// Create remote file
 	remoteFile, err := sftpClient.Create(job.RemotePath)
 	if err != nil {
-		a.emitUploadEvent(sessionID, "error", map[string]interface{}{
-			"fileName": job.FileName,
-			"error":    err.Error(),
-		})
 		return fmt.Errorf("failed to create remote file %s: %w", job.RemotePath, err)
 	}
 	defer remoteFile.Close()
+	if err := applyUploadMode(sftpClient, job.RemotePath, job.Mode); err != nil {
+		return err
+	}
 
 	// Use buffered reader for better performance
-	cfg := a.getSFTPConfig()
+	cfg := a.sessionSFTPConfig(sessionID)
 	bufferedReader := bufio.NewReaderSize(localFile, cfg.BufferSize)
 
 	// Wrap with progress reader
@@ -1282,7 +1640,8 @@ func (a *App) uploadSingleFile(sessionID string, sftpClient *sftp.Client, job Tr
 
 	// Copy with optimized buffer
 	buffer := make([]byte, cfg.BufferSize)
-	_, err = io.CopyBuffer(remoteFile, progressReader, buffer)
+	started := time.Now()
+	err = a.copyToRemoteFile(sessionID, remoteFile, progressReader, job.FileSize, buffer)
 	if err != nil {
 		// Close remote file before attempting delete
 		remoteFile.Close()
@@ -1290,15 +1649,11 @@ func (a *App) uploadSingleFile(sessionID string, sftpClient *sftp.Client, job Tr
 		// If cancelled, delete the partial remote file
 		if errors.Is(err, ErrTransferCancelled) {
 			sftpClient.Remove(job.RemotePath)
-			return fmt.Errorf("upload cancelled")
+			return fmt.Errorf("upload cancelled: %w", err)
 		}
-
-		a.emitUploadEvent(sessionID, "error", map[string]interface{}{
-			"fileName": job.FileName,
-			"error":    err.Error(),
-		})
 		return fmt.Errorf("failed to copy file %s: %w", job.LocalPath, err)
 	}
+	a.recordSFTPTransferRate(sessionID, job.FileSize, time.Since(started))
 
 	// Emit complete event
 	a.emitUploadEvent(sessionID, "complete", map[string]interface{}{
@@ -1313,104 +1668,101 @@ func (a *App) uploadSingleFile(sessionID string, sftpClient *sftp.Client, job Tr
 	return nil
 }
 
-// CreateRemoteDirectory creates a new directory on the remote server
+// CreateRemoteDirectory creates a new directory on the remote server, with the permissions of
+// the SFTP DirectoryMode setting when it is set
 func (a *App) CreateRemoteDirectory(sessionID string, remotePath string) error {
-	a.ssh.sftpClientsMutex.RLock()
-	sftpClient, exists := a.ssh.sftpClients[sessionID]
-	a.ssh.sftpClientsMutex.RUnlock()
-
-	if !exists {
-		return fmt.Errorf("SFTP client not initialized for session %s", sessionID)
-	}
-
-	err := sftpClient.Mkdir(remotePath)
-	if err != nil {
-		return fmt.Errorf("failed to create directory %s: %w", remotePath, err)
-	}
+	return a.sftp.CreateDirectory(sessionID, remotePath)
+}
 
-	return nil
+// CreateRemoteDirectoryWithMode creates a new directory with the given permissions, e.g.
+// 0750. A mode of 0 uses the SFTP DirectoryMode setting.
+func (a *App) CreateRemoteDirectoryWithMode(sessionID string, remotePath string, mode uint32) error {
+	return a.sftp.CreateDirectoryWithMode(sessionID, remotePath, mode)
 }
 
 // CreateRemoteDirectoryWithSudo creates a new directory using sudo
 func (a *App) CreateRemoteDirectoryWithSudo(sessionID string, remotePath string) error {
+	defer a.invalidateDirectoryCache(sessionID)
+
 	a.ssh.sshSessionsMutex.RLock()
 	sshSession, exists := a.ssh.sshSessions[sessionID]
 	a.ssh.sshSessionsMutex.RUnlock()
 
 	if !exists || sshSession == nil {
-		return fmt.Errorf("SSH session %s not found", sessionID)
+		return newNotFoundError(ErrCategorySFTP, "CreateRemoteDirectoryWithSudo", "SSH session %s not found", sessionID)
 	}
 
-	cmd := fmt.Sprintf("sudo mkdir -p %q", remotePath)
-	_, err := a.ExecuteMonitoringCommand(sshSession, cmd)
+	cmd, err := sudoMkdirCommand(remotePath)
 	if err != nil {
+		return err
+	}
+	if _, err := a.runSudoCommand(sshSession, "CreateRemoteDirectoryWithSudo", cmd); err != nil {
 		return fmt.Errorf("failed to create directory with sudo: %w", err)
 	}
 
 	return nil
 }
 
-// UploadFileContentWithSudo uploads file content using sudo when regular upload fails
-func (a *App) UploadFileContentWithSudo(sessionID string, remotePath string, base64Content string) error {
+// CreateRemoteFile creates an empty file on the remote server. It fails if the path exists.
+func (a *App) CreateRemoteFile(sessionID string, remotePath string) error {
+	return a.sftp.CreateFile(sessionID, remotePath)
+}
+
+// CreateRemoteFileWithSudo creates an empty file using sudo touch. It fails if the path exists.
+func (a *App) CreateRemoteFileWithSudo(sessionID string, remotePath string) error {
+	defer a.invalidateDirectoryCache(sessionID)
+
 	a.ssh.sshSessionsMutex.RLock()
 	sshSession, exists := a.ssh.sshSessions[sessionID]
 	a.ssh.sshSessionsMutex.RUnlock()
 
 	if !exists || sshSession == nil {
-		return fmt.Errorf("SSH session %s not found", sessionID)
+		return newNotFoundError(ErrCategorySFTP, "CreateRemoteFileWithSudo", "SSH session %s not found", sessionID)
 	}
 
-	// Check if monitoring session is available
-	sshSession.monitoringMutex.RLock()
-	monitoringEnabled := sshSession.monitoringEnabled
-	monitoringClient := sshSession.monitoringClient
-	sshSession.monitoringMutex.RUnlock()
-
-	if !monitoringEnabled || monitoringClient == nil {
-		return fmt.Errorf("monitoring session not available - cannot use sudo")
+	cmd, err := sudoCreateFileCommand(remotePath)
+	if err != nil {
+		return err
 	}
-
-	// Decode base64 content
-	content, err := base64.StdEncoding.DecodeString(base64Content)
+	output, err := a.runSudoCommand(sshSession, "CreateRemoteFileWithSudo", cmd)
 	if err != nil {
-		return fmt.Errorf("failed to decode base64 content: %w", err)
+		return fmt.Errorf("failed to create file with sudo: %w", err)
 	}
 
-	// Create a new session for this command
-	session, err := monitoringClient.NewSession()
-	if err != nil {
-		return fmt.Errorf("failed to create SSH session for sudo: %w", err)
+	if strings.TrimSpace(output) == "exists" {
+		return fmt.Errorf("failed to create file %s: %w", remotePath, os.ErrExist)
+	}
+	if strings.Contains(output, "Permission denied") {
+		return fmt.Errorf("permission denied even with sudo: %s", remotePath)
 	}
-	defer session.Close()
 
-	// Capture stderr for error messages
-	var stderrBuf strings.Builder
-	session.Stderr = &stderrBuf
+	return nil
+}
 
-	// Use sudo tee to write the file content
-	stdin, err := session.StdinPipe()
-	if err != nil {
-		return fmt.Errorf("failed to create stdin pipe: %w", err)
-	}
+// UploadFileContentWithSudo uploads file content using sudo when regular upload fails
+func (a *App) UploadFileContentWithSudo(sessionID string, remotePath string, base64Content string) error {
+	defer a.invalidateDirectoryCache(sessionID)
 
-	cmd := fmt.Sprintf("sudo tee %q > /dev/null", remotePath)
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
 
-	if err := session.Start(cmd); err != nil {
-		return fmt.Errorf("failed to start sudo tee command: %w", err)
+	if !exists || sshSession == nil {
+		return newNotFoundError(ErrCategorySFTP, "UploadFileContentWithSudo", "SSH session %s not found", sessionID)
 	}
 
-	_, err = stdin.Write(content)
+	// Decode base64 content
+	content, err := base64.StdEncoding.DecodeString(base64Content)
 	if err != nil {
-		return fmt.Errorf("failed to write content via sudo: %w", err)
+		return fmt.Errorf("failed to decode base64 content: %w", err)
 	}
 
-	stdin.Close()
-
-	if err := session.Wait(); err != nil {
-		stderrOutput := strings.TrimSpace(stderrBuf.String())
-		if stderrOutput != "" {
-			return fmt.Errorf("sudo upload failed: %s", stderrOutput)
-		}
+	// Use sudo tee to write the file content
+	cmd, err := sudoWriteFileCommand(remotePath)
+	if err != nil {
+		return err
+	}
+	if _, err := a.runSudoCommandWithInput(context.Background(), sshSession, "UploadFileContentWithSudo", cmd, content); err != nil {
 		return fmt.Errorf("sudo upload failed: %w", err)
 	}
 
@@ -1419,6 +1771,8 @@ func (a *App) UploadFileContentWithSudo(sessionID string, remotePath string, bas
 
 // UploadRemoteFilesWithSudo uploads local files to remote using sudo
 func (a *App) UploadRemoteFilesWithSudo(sessionID string, localFilePaths []string, remotePath string) error {
+	defer a.invalidateDirectoryCache(sessionID)
+
 	totalFiles := len(localFilePaths)
 	if totalFiles == 0 {
 		return nil
@@ -1473,140 +1827,51 @@ func (a *App) UploadRemoteFilesWithSudo(sessionID string, localFilePaths []strin
 
 // DeleteRemotePath deletes a file or directory on the remote server (auto-detects recursion)
 func (a *App) DeleteRemotePath(sessionID string, remotePath string) error {
-	a.ssh.sftpClientsMutex.RLock()
-	sftpClient, exists := a.ssh.sftpClients[sessionID]
-	a.ssh.sftpClientsMutex.RUnlock()
-
-	if !exists {
-		return fmt.Errorf("SFTP client not initialized for session %s", sessionID)
-	}
-
-	// Check if it's a directory
-	stat, err := sftpClient.Stat(remotePath)
-	if err != nil {
-		return fmt.Errorf("failed to stat %s: %w", remotePath, err)
-	}
-
-	if stat.IsDir() {
-		// For directories, always use recursive deletion
-		return a.deleteRemoteDirectoryRecursive(sftpClient, remotePath)
-	} else {
-		// For files, simple remove
-		err := sftpClient.Remove(remotePath)
-		if err != nil {
-			return fmt.Errorf("failed to remove file %s: %w", remotePath, err)
-		}
-	}
-
-	return nil
+	return a.sftp.DeletePath(sessionID, remotePath)
 }
 
 // DeleteRemotePathAdvanced deletes a file or directory with explicit recursion control
 func (a *App) DeleteRemotePathAdvanced(sessionID string, remotePath string, isRecursive bool) error {
-	a.ssh.sftpClientsMutex.RLock()
-	sftpClient, exists := a.ssh.sftpClients[sessionID]
-	a.ssh.sftpClientsMutex.RUnlock()
-
-	if !exists {
-		return fmt.Errorf("SFTP client not initialized for session %s", sessionID)
-	}
-
-	// Check if it's a directory
-	stat, err := sftpClient.Stat(remotePath)
-	if err != nil {
-		return fmt.Errorf("failed to stat %s: %w", remotePath, err)
-	}
-
-	if stat.IsDir() {
-		if isRecursive {
-			return a.deleteRemoteDirectoryRecursive(sftpClient, remotePath)
-		} else {
-			err := sftpClient.RemoveDirectory(remotePath)
-			if err != nil {
-				return fmt.Errorf("failed to remove directory %s: %w", remotePath, err)
-			}
-		}
-	} else {
-		err := sftpClient.Remove(remotePath)
-		if err != nil {
-			return fmt.Errorf("failed to remove file %s: %w", remotePath, err)
-		}
-	}
-
-	return nil
+	return a.sftp.DeletePathAdvanced(sessionID, remotePath, isRecursive)
 }
 
-// Helper function to recursively delete a directory
-func (a *App) deleteRemoteDirectoryRecursive(sftpClient *sftp.Client, remotePath string) error {
-	// List directory contents
-	fileInfos, err := sftpClient.ReadDir(remotePath)
-	if err != nil {
-		return fmt.Errorf("failed to read directory %s: %w", remotePath, err)
-	}
-
-	// Delete each item recursively
-	for _, fileInfo := range fileInfos {
-		// Use proper remote path joining
-		fullPath := joinRemotePath(remotePath, fileInfo.Name())
-
-		if fileInfo.IsDir() {
-			if err := a.deleteRemoteDirectoryRecursive(sftpClient, fullPath); err != nil {
-				return err
-			}
-		} else {
-			if err := sftpClient.Remove(fullPath); err != nil {
-				return fmt.Errorf("failed to remove file %s: %w", fullPath, err)
-			}
-		}
-	}
-
-	// Remove the directory itself
-	err = sftpClient.RemoveDirectory(remotePath)
-	if err != nil {
-		return fmt.Errorf("failed to remove directory %s: %w", remotePath, err)
-	}
-
-	return nil
-}
-
-// RenameRemotePath renames a file or directory on the remote server
+// RenameRemotePath renames a file or directory on the remote server. An existing destination
+// is left alone and the rename fails with ErrDestinationExists.
 func (a *App) RenameRemotePath(sessionID string, oldPath string, newPath string) error {
-	a.ssh.sftpClientsMutex.RLock()
-	sftpClient, exists := a.ssh.sftpClients[sessionID]
-	a.ssh.sftpClientsMutex.RUnlock()
-
-	if !exists {
-		return fmt.Errorf("SFTP client not initialized for session %s", sessionID)
-	}
-
-	err := sftpClient.Rename(oldPath, newPath)
-	if err != nil {
-		return fmt.Errorf("failed to rename %s to %s: %w", oldPath, newPath, err)
-	}
+	return a.sftp.RenamePath(sessionID, oldPath, newPath, RenameOptions{})
+}
 
-	return nil
+// RenameRemotePathWithOptions renames a file or directory like RenameRemotePath, replacing an
+// existing destination when opts.Overwrite is set
+func (a *App) RenameRemotePathWithOptions(sessionID string, oldPath string, newPath string, opts RenameOptions) error {
+	return a.sftp.RenamePath(sessionID, oldPath, newPath, opts)
 }
 
 // DeleteRemotePathWithSudo deletes a file or directory using sudo
 func (a *App) DeleteRemotePathWithSudo(sessionID string, remotePath string) error {
+	defer a.invalidateDirectoryCache(sessionID)
+
 	a.ssh.sshSessionsMutex.RLock()
 	sshSession, exists := a.ssh.sshSessions[sessionID]
 	a.ssh.sshSessionsMutex.RUnlock()
 
 	if !exists || sshSession == nil {
-		return fmt.Errorf("SSH session %s not found", sessionID)
+		return newNotFoundError(ErrCategorySFTP, "DeleteRemotePathWithSudo", "SSH session %s not found", sessionID)
 	}
 
 	// Use sudo rm -rf for both files and directories
-	cmd := fmt.Sprintf("sudo rm -rf %q", remotePath)
-	output, err := a.ExecuteMonitoringCommand(sshSession, cmd)
+	cmd, err := sudoRemoveCommand(remotePath)
+	if err != nil {
+		return err
+	}
+	output, err := a.runSudoCommand(sshSession, "DeleteRemotePathWithSudo", cmd)
 	if err != nil {
 		return fmt.Errorf("failed to delete with sudo: %w", err)
 	}
 
 	// Check for errors in output
 	if strings.Contains(output, "No such file") {
-		return fmt.Errorf("file or directory not found: %s", remotePath)
+		return newNotFoundError(ErrCategorySFTP, "DeleteRemotePathWithSudo", "file or directory not found: %s", remotePath)
 	}
 	if strings.Contains(output, "Permission denied") {
 		return fmt.Errorf("permission denied even with sudo: %s", remotePath)
@@ -1615,26 +1880,38 @@ func (a *App) DeleteRemotePathWithSudo(sessionID string, remotePath string) erro
 	return nil
 }
 
-// RenameRemotePathWithSudo renames a file or directory using sudo
+// RenameRemotePathWithSudo renames a file or directory using sudo. Like RenameRemotePath it
+// fails with ErrDestinationExists rather than replace an existing destination.
 func (a *App) RenameRemotePathWithSudo(sessionID string, oldPath string, newPath string) error {
+	if err := validateRename(oldPath, newPath); err != nil {
+		return err
+	}
+	defer a.invalidateDirectoryCache(sessionID)
+
 	a.ssh.sshSessionsMutex.RLock()
 	sshSession, exists := a.ssh.sshSessions[sessionID]
 	a.ssh.sshSessionsMutex.RUnlock()
 
 	if !exists || sshSession == nil {
-		return fmt.Errorf("SSH session %s not found", sessionID)
+		return newNotFoundError(ErrCategorySFTP, "RenameRemotePathWithSudo", "SSH session %s not found", sessionID)
 	}
 
 	// Use sudo mv for rename
-	cmd := fmt.Sprintf("sudo mv %q %q", oldPath, newPath)
-	output, err := a.ExecuteMonitoringCommand(sshSession, cmd)
+	cmd, err := sudoMoveCommand(oldPath, newPath)
+	if err != nil {
+		return err
+	}
+	output, err := a.runSudoCommand(sshSession, "RenameRemotePathWithSudo", cmd)
 	if err != nil {
 		return fmt.Errorf("failed to rename with sudo: %w", err)
 	}
 
 	// Check for errors in output
+	if strings.TrimSpace(output) == sudoMoveDestinationExists {
+		return fmt.Errorf("cannot rename %s: %w: %s", oldPath, ErrDestinationExists, newPath)
+	}
 	if strings.Contains(output, "No such file") {
-		return fmt.Errorf("file or directory not found: %s", oldPath)
+		return newNotFoundError(ErrCategorySFTP, "RenameRemotePathWithSudo", "file or directory not found: %s", oldPath)
 	}
 	if strings.Contains(output, "Permission denied") {
 		return fmt.Errorf("permission denied even with sudo")
@@ -1643,72 +1920,56 @@ func (a *App) RenameRemotePathWithSudo(sessionID string, oldPath string, newPath
 	return nil
 }
 
-// GetRemoteFileContent reads the content of a remote file
+// GetRemoteFileContent reads the content of a remote file for the editor. Files over the
+// MaxPreviewSize setting fail with ErrFileTooLarge, and the read gives up after
+// RemoteFilePreviewTimeout.
 func (a *App) GetRemoteFileContent(sessionID string, remotePath string) (string, error) {
-	a.ssh.sftpClientsMutex.RLock()
-	sftpClient, exists := a.ssh.sftpClients[sessionID]
-	a.ssh.sftpClientsMutex.RUnlock()
-
-	if !exists {
-		return "", fmt.Errorf("SFTP client not initialized for session %s", sessionID)
-	}
-
-	// Open the remote file
-	file, err := sftpClient.Open(remotePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to open remote file %s: %w", remotePath, err)
-	}
-	defer file.Close()
-
-	// Read the file content
-	content, err := io.ReadAll(file)
-	if err != nil {
-		return "", fmt.Errorf("failed to read file content: %w", err)
-	}
-
-	// Check if it's a binary file - consider both extension and content
-	if !isTextContentWithExtension(remotePath, content) {
-		// For binary files, return base64 encoded content
-		return base64.StdEncoding.EncodeToString(content), nil
-	}
+	return a.sftp.FileContent(sessionID, remotePath)
+}
 
-	// For text files, return as string
-	return string(content), nil
+// GetRemoteFileContentWithVersion reads a remote file like GetRemoteFileContent, along with a
+// version token for UpdateRemoteFileContentIfUnchanged
+func (a *App) GetRemoteFileContentWithVersion(sessionID string, remotePath string) (RemoteFileContent, error) {
+	return a.sftp.FileContentWithVersion(sessionID, remotePath)
 }
 
-// GetRemoteFileContentWithSudo reads file content using sudo when regular access is denied
+// GetRemoteFileContentWithSudo reads file content using sudo when regular access is denied,
+// with the same size limit and timeout as GetRemoteFileContent
 func (a *App) GetRemoteFileContentWithSudo(sessionID string, remotePath string) (string, error) {
 	a.ssh.sshSessionsMutex.RLock()
 	sshSession, exists := a.ssh.sshSessions[sessionID]
 	a.ssh.sshSessionsMutex.RUnlock()
 
 	if !exists || sshSession == nil {
-		return "", fmt.Errorf("SSH session %s not found", sessionID)
+		return "", newNotFoundError(ErrCategorySFTP, "GetRemoteFileContentWithSudo", "SSH session %s not found", sessionID)
 	}
 
-	// Check if monitoring session is available
-	sshSession.monitoringMutex.RLock()
-	monitoringEnabled := sshSession.monitoringEnabled
-	monitoringClient := sshSession.monitoringClient
-	sshSession.monitoringMutex.RUnlock()
-
-	if !monitoringEnabled || monitoringClient == nil {
-		return "", fmt.Errorf("monitoring session not available - cannot use sudo")
-	}
-
-	// Create a new session for this command
-	session, err := monitoringClient.NewSession()
+	// Use sudo cat to read the file content, one byte past the preview limit to detect larger files
+	limit := a.getSFTPConfig().MaxPreviewSize
+	cmd, err := sudoReadFileHeadCommand(remotePath, limit+1)
 	if err != nil {
-		return "", fmt.Errorf("failed to create SSH session for sudo: %w", err)
-	}
-	defer session.Close()
-
-	// Use sudo cat to read the file content
-	cmd := fmt.Sprintf("sudo cat %q", remotePath)
-	output, err := session.CombinedOutput(cmd)
+		return "", err
+	}
+	// A stalled read is stopped by closing its channel rather than left running
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	output, err := readWithTimeout(RemoteFilePreviewTimeout, func() ([]byte, error) {
+		return a.runSudoCommandWithInput(ctx, sshSession, "GetRemoteFileContentWithSudo", cmd, nil)
+	}, cancel)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file with sudo: %w", err)
 	}
+	if int64(len(output)) > limit {
+		size := int64(-1)
+		if sizeCmd, err := sudoFileSizeCommand(remotePath); err == nil {
+			if sizeOutput, err := a.runSudoCommand(sshSession, "GetRemoteFileContentWithSudo", sizeCmd); err == nil {
+				if n, ok := parseWCBytes(sizeOutput); ok {
+					size = n
+				}
+			}
+		}
+		return "", newFileTooLargeError("GetRemoteFileContentWithSudo", remotePath, size, limit)
+	}
 
 	// Check if it's a binary file based on extension and content
 	if !isTextContentWithExtension(remotePath, output) {
@@ -1722,119 +1983,36 @@ func (a *App) GetRemoteFileContentWithSudo(sessionID string, remotePath string)
 
 // UpdateRemoteFileContent updates the content of a remote file
 func (a *App) UpdateRemoteFileContent(sessionID string, remotePath string, content string) error {
-	a.ssh.sftpClientsMutex.RLock()
-	sftpClient, exists := a.ssh.sftpClients[sessionID]
-	a.ssh.sftpClientsMutex.RUnlock()
-
-	if !exists {
-		return fmt.Errorf("SFTP client not initialized for session %s", sessionID)
-	}
+	return a.sftp.UpdateFileContent(sessionID, remotePath, content)
+}
 
-	// Create or truncate the remote file
-	file, err := sftpClient.Create(remotePath)
-	if err != nil {
-		return fmt.Errorf("failed to create/open remote file %s: %w", remotePath, err)
-	}
-	defer file.Close()
+// UpdateRemoteFileContentIfUnchanged saves a remote file only if it hasn't changed since it was
+// read at version, failing with a CONFLICT error otherwise. Returns the version after the save.
+func (a *App) UpdateRemoteFileContentIfUnchanged(sessionID string, remotePath string, content string, version string) (string, error) {
+	return a.sftp.UpdateFileContentIfUnchanged(sessionID, remotePath, content, version)
+}
 
-	// Write the content
-	_, err = file.Write([]byte(content))
-	if err != nil {
-		return fmt.Errorf("failed to write file content: %w", err)
-	}
+// DiffRemoteFile returns a unified diff from a remote file to a local file, for reviewing an
+// upload before it happens. Empty when they're the same; binary files only report that they differ.
+func (a *App) DiffRemoteFile(sessionID string, remotePath string, localPath string) (string, error) {
+	return a.sftp.DiffFile(sessionID, remotePath, localPath)
+}
 
-	return nil
+// AppendToRemoteFile appends content to a remote file without rewriting it, creating the file
+// if needed. Returns the file's new size.
+func (a *App) AppendToRemoteFile(sessionID string, remotePath string, content string) (int64, error) {
+	return a.sftp.AppendFileContent(sessionID, remotePath, content)
 }
 
 // UploadFileContent uploads file content from base64 string to a remote path
 func (a *App) UploadFileContent(sessionID string, remotePath string, base64Content string) error {
-	a.ssh.sftpClientsMutex.RLock()
-	sftpClient, exists := a.ssh.sftpClients[sessionID]
-	a.ssh.sftpClientsMutex.RUnlock()
-
-	if !exists {
-		return fmt.Errorf("SFTP client not initialized for session %s", sessionID)
-	}
-
-	// Decode base64 content
-	content, err := base64.StdEncoding.DecodeString(base64Content)
-	if err != nil {
-		return fmt.Errorf("failed to decode base64 content: %w", err)
-	}
-
-	// Create or truncate the remote file
-	file, err := sftpClient.Create(remotePath)
-	if err != nil {
-		return fmt.Errorf("failed to create remote file %s: %w", remotePath, err)
-	}
-	defer file.Close()
-
-	// Emit start for single file upload
-	fileName := filepath.Base(remotePath)
-	totalBytes := int64(len(content))
-	a.emitUploadEvent(sessionID, "start", map[string]interface{}{
-		"fileName":   fileName,
-		"fileIndex":  1,
-		"totalFiles": 1,
-		"total":      totalBytes,
-	})
-
-	// Get optimized chunk size from config
-	cfg := a.getSFTPConfig()
-	chunkSize := cfg.BufferSize
-	if chunkSize > len(content) {
-		chunkSize = len(content)
-	}
-	if chunkSize == 0 {
-		chunkSize = 64 * 1024 // Fallback to 64KB
-	}
-
-	startTime := time.Now()
-	var written int64
-	for written < totalBytes {
-		end := written + int64(chunkSize)
-		if end > totalBytes {
-			end = totalBytes
-		}
-		n, err := file.Write(content[written:end])
-		if n > 0 {
-			written += int64(n)
-			percent := float64(0)
-			if totalBytes > 0 {
-				percent = float64(written) * 100.0 / float64(totalBytes)
-			}
-			// Calculate transfer speed
-			elapsed := time.Since(startTime).Seconds()
-			var bytesPerSec int64
-			if elapsed > 0 {
-				bytesPerSec = int64(float64(written) / elapsed)
-			}
-			a.emitUploadEvent(sessionID, "progress", map[string]interface{}{
-				"fileName":    fileName,
-				"fileIndex":   1,
-				"totalFiles":  1,
-				"transferred": written,
-				"total":       totalBytes,
-				"percent":     percent,
-				"bytesPerSec": bytesPerSec,
-			})
-		}
-		if err != nil {
-			return fmt.Errorf("failed to write file content: %w", err)
-		}
-	}
-
-	// Emit complete
-	a.emitUploadEvent(sessionID, "complete", map[string]interface{}{
-		"fileName":    fileName,
-		"fileIndex":   1,
-		"totalFiles":  1,
-		"transferred": totalBytes,
-		"total":       totalBytes,
-		"percent":     100.0,
-	})
+	return a.sftp.UploadFileContent(sessionID, remotePath, base64Content)
+}
 
-	return nil
+// UploadFileContentWithMode uploads file content and sets its permissions, e.g. 0755 so an
+// uploaded script is executable. A mode of 0 keeps the server's default.
+func (a *App) UploadFileContentWithMode(sessionID string, remotePath string, base64Content string, mode uint32) error {
+	return a.sftp.UploadFileContentWithMode(sessionID, remotePath, base64Content, mode)
 }
 
 // isTextContentWithExtension checks if the content is likely text considering both file extension and content
@@ -1906,13 +2084,13 @@ func (a *App) CheckFileWritePermission(sessionID string, remotePath string) (boo
 	a.ssh.sshSessionsMutex.RUnlock()
 
 	if !exists || sshSession == nil {
-		return false, false, fmt.Errorf("SSH session %s not found", sessionID)
+		return false, false, newNotFoundError(ErrCategorySFTP, "CheckFileWritePermission", "SSH session %s not found", sessionID)
 	}
 
 	// Use monitoring session to check write permission
 	// Using test -w to check if file is writable, test -e to check if exists
-	cmd := fmt.Sprintf("test -e %q && echo 'exists' || echo 'notexists'; test -w %q && echo 'writable' || echo 'readonly'", remotePath, remotePath)
-	output, err := a.ExecuteMonitoringCommand(sshSession, cmd)
+	cmd := buildRemoteCommand("test -e %[1]s && echo 'exists' || echo 'notexists'; test -w %[1]s && echo 'writable' || echo 'readonly'", remotePath)
+	output, err := a.executeMonitoringCommand(sshSession, cmd)
 	if err != nil {
 		// If monitoring session is not available, try to check via SFTP stat
 		return a.checkWritePermissionViaSFTP(sessionID, remotePath)
@@ -1958,61 +2136,51 @@ func (a *App) checkWritePermissionViaSFTP(sessionID string, remotePath string) (
 
 // UpdateRemoteFileContentWithSudo updates file content using sudo when regular write fails
 func (a *App) UpdateRemoteFileContentWithSudo(sessionID string, remotePath string, content string) error {
+	defer a.invalidateDirectoryCache(sessionID)
+
 	a.ssh.sshSessionsMutex.RLock()
 	sshSession, exists := a.ssh.sshSessions[sessionID]
 	a.ssh.sshSessionsMutex.RUnlock()
 
 	if !exists || sshSession == nil {
-		return fmt.Errorf("SSH session %s not found", sessionID)
+		return newNotFoundError(ErrCategorySFTP, "UpdateRemoteFileContentWithSudo", "SSH session %s not found", sessionID)
 	}
 
-	// Check if monitoring session is available
-	sshSession.monitoringMutex.RLock()
-	monitoringEnabled := sshSession.monitoringEnabled
-	monitoringClient := sshSession.monitoringClient
-	sshSession.monitoringMutex.RUnlock()
-
-	if !monitoringEnabled || monitoringClient == nil {
-		return fmt.Errorf("monitoring session not available - cannot use sudo")
-	}
-
-	// Create a new session for this command
-	session, err := monitoringClient.NewSession()
+	// Pipe the content to sudo tee, which writes the file; its stdout goes to /dev/null
+	cmd, err := sudoWriteFileCommand(remotePath)
 	if err != nil {
-		return fmt.Errorf("failed to create SSH session for sudo: %w", err)
+		return err
 	}
-	defer session.Close()
-
-	// Use sudo tee to write the file content
-	// This approach pipes content to sudo tee which writes to the file
-	stdin, err := session.StdinPipe()
-	if err != nil {
-		return fmt.Errorf("failed to create stdin pipe: %w", err)
+	if _, err := a.runSudoCommandWithInput(context.Background(), sshSession, "UpdateRemoteFileContentWithSudo", cmd, []byte(content)); err != nil {
+		return fmt.Errorf("sudo write failed: %w", err)
 	}
 
-	// Use tee to write content, redirect stdout to /dev/null to avoid echo
-	cmd := fmt.Sprintf("sudo tee %q > /dev/null", remotePath)
+	return nil
+}
 
-	// Start the command
-	if err := session.Start(cmd); err != nil {
-		return fmt.Errorf("failed to start sudo tee command: %w", err)
-	}
+// AppendToRemoteFileWithSudo appends content to a remote file through sudo tee -a, for files
+// the user can't write. Returns the file's new size.
+func (a *App) AppendToRemoteFileWithSudo(sessionID string, remotePath string, content string) (int64, error) {
+	defer a.invalidateDirectoryCache(sessionID)
 
-	// Write content to stdin
-	_, err = stdin.Write([]byte(content))
+	sshSession, err := a.monitoringSession(sessionID, "AppendToRemoteFileWithSudo")
 	if err != nil {
-		return fmt.Errorf("failed to write content via sudo: %w", err)
+		return 0, err
 	}
 
-	// Close stdin to signal end of input
-	stdin.Close()
-
-	// Wait for command to complete
-	if err := session.Wait(); err != nil {
-		return fmt.Errorf("sudo write failed: %w", err)
+	cmd, err := sudoAppendFileCommand(remotePath)
+	if err != nil {
+		return 0, err
 	}
-
-	return nil
+	output, err := a.runSudoCommandWithInput(context.Background(), sshSession, "AppendToRemoteFileWithSudo", cmd, []byte(content))
+	if err != nil {
+		return 0, fmt.Errorf("sudo append failed: %w", err)
+	}
+	size, ok := parseWCBytes(string(output))
+	if !ok {
+		return 0, fmt.Errorf("sudo append succeeded but the new size is unknown: %q", strings.TrimSpace(string(output)))
+	}
+	return size, nil
 }
 
 // isTextContent checks if the content is likely text (not binary) - legacy function for fallback
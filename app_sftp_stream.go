@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SFTP streaming constants
+const (
+	SFTPStreamPathPrefix = "/sftp/"
+	SFTPStreamTimeout    = 60 * time.Second
+)
+
+// sftpStreamRegistration describes a one-shot streaming endpoint for a remote file
+type sftpStreamRegistration struct {
+	sessionID  string
+	remotePath string
+	timer      *time.Timer
+}
+
+// sftpStreams tracks registered stream endpoints keyed by nonce
+var sftpStreams = make(map[string]*sftpStreamRegistration)
+var sftpStreamsMu sync.Mutex
+
+// streamRemoteFileContent opens a remote file for streaming without buffering it in memory.
+// The caller is responsible for closing the returned reader.
+func (a *App) streamRemoteFileContent(sessionID string, remotePath string) (io.ReadCloser, error) {
+	a.ssh.sftpClientsMutex.RLock()
+	sftpClient, exists := a.ssh.sftpClients[sessionID]
+	a.ssh.sftpClientsMutex.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("SFTP client not initialized for session %s", sessionID)
+	}
+
+	file, err := sftpClient.Open(remotePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open remote file %s: %w", remotePath, err)
+	}
+
+	return file, nil
+}
+
+// RegisterSFTPStreamHandler registers a one-shot URL that streams a remote file through the asset server.
+// The returned path is served once and expires after SFTPStreamTimeout if never requested.
+func (a *App) RegisterSFTPStreamHandler(sessionID string, remotePath string) (string, error) {
+	a.ssh.sftpClientsMutex.RLock()
+	_, exists := a.ssh.sftpClients[sessionID]
+	a.ssh.sftpClientsMutex.RUnlock()
+
+	if !exists {
+		return "", fmt.Errorf("SFTP client not initialized for session %s", sessionID)
+	}
+
+	if remotePath == "" {
+		return "", fmt.Errorf("remote path cannot be empty")
+	}
+
+	nonce := generateID() + generateID()
+	reg := &sftpStreamRegistration{
+		sessionID:  sessionID,
+		remotePath: remotePath,
+	}
+
+	sftpStreamsMu.Lock()
+	sftpStreams[nonce] = reg
+	reg.timer = time.AfterFunc(SFTPStreamTimeout, func() {
+		if takeSFTPStream(nonce) != nil {
+			fmt.Printf("SFTP stream %s expired without being accessed\n", nonce)
+		}
+	})
+	sftpStreamsMu.Unlock()
+
+	return SFTPStreamPathPrefix + nonce, nil
+}
+
+// takeSFTPStream removes and returns a stream registration, or nil if it is unknown or already used
+func takeSFTPStream(nonce string) *sftpStreamRegistration {
+	sftpStreamsMu.Lock()
+	defer sftpStreamsMu.Unlock()
+
+	reg, exists := sftpStreams[nonce]
+	if !exists {
+		return nil
+	}
+	delete(sftpStreams, nonce)
+	if reg.timer != nil {
+		reg.timer.Stop()
+	}
+	return reg
+}
+
+// releaseSFTPStreams drops all unused stream registrations for a session
+func releaseSFTPStreams(sessionID string) {
+	sftpStreamsMu.Lock()
+	defer sftpStreamsMu.Unlock()
+
+	for nonce, reg := range sftpStreams {
+		if reg.sessionID == sessionID {
+			if reg.timer != nil {
+				reg.timer.Stop()
+			}
+			delete(sftpStreams, nonce)
+		}
+	}
+}
+
+// sftpStreamMiddleware serves registered /sftp/{nonce} paths and passes everything else to the asset server
+func (a *App) sftpStreamMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, SFTPStreamPathPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		nonce := strings.TrimPrefix(r.URL.Path, SFTPStreamPathPrefix)
+		reg := takeSFTPStream(nonce)
+		if reg == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		a.serveSFTPStream(w, reg)
+	})
+}
+
+// serveSFTPStream copies the remote file to the response without loading it into memory
+func (a *App) serveSFTPStream(w http.ResponseWriter, reg *sftpStreamRegistration) {
+	reader, err := a.streamRemoteFileContent(reg.sessionID, reg.remotePath)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer reader.Close()
+
+	fileName := path.Base(reg.remotePath)
+	contentType := mime.TypeByExtension(path.Ext(fileName))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	w.Header().Set("Cache-Control", "no-store")
+
+	if statter, ok := reader.(interface{ Stat() (os.FileInfo, error) }); ok {
+		if info, err := statter.Stat(); err == nil {
+			w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+		}
+	}
+
+	if _, err := io.Copy(w, reader); err != nil {
+		fmt.Printf("SFTP stream of %s aborted: %v\n", reg.remotePath, err)
+	}
+}
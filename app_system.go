@@ -9,12 +9,12 @@ import (
 	"sync"
 	"time"
 
-	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/shirou/gopsutil/v3/host"
 	"github.com/shirou/gopsutil/v3/load"
 	"github.com/shirou/gopsutil/v3/mem"
 	"github.com/shirou/gopsutil/v3/net"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // System Statistics and Monitoring Methods
@@ -82,8 +82,7 @@ func (a *App) getRemoteSystemMetadata(sessionID string) map[string]interface{} {
 	}
 
 	// Get CPU count - try multiple methods
-	cpuCmd := "nproc 2>/dev/null || getconf _NPROCESSORS_ONLN 2>/dev/null || grep -c ^processor /proc/cpuinfo 2>/dev/null"
-	if output, err := a.ExecuteMonitoringCommand(sshSession, cpuCmd); err == nil {
+	if output, err := a.executeMonitoringCommand(sshSession, remoteCmdCPUCount); err == nil {
 		trimmed := strings.TrimSpace(output)
 		fmt.Printf("Remote CPU count output: '%s'\n", trimmed)
 		if cpuCount, parseErr := strconv.Atoi(trimmed); parseErr == nil && cpuCount > 0 {
@@ -98,8 +97,7 @@ func (a *App) getRemoteSystemMetadata(sessionID string) map[string]interface{} {
 
 	// Get total memory - try multiple methods
 	// Method 1: /proc/meminfo (Linux) - extract just the number
-	memCmd := "grep MemTotal /proc/meminfo 2>/dev/null | awk '{print $2}' | tr -d ' '"
-	if output, err := a.ExecuteMonitoringCommand(sshSession, memCmd); err == nil {
+	if output, err := a.executeMonitoringCommand(sshSession, remoteCmdMemTotalKB); err == nil {
 		trimmed := strings.TrimSpace(output)
 		fmt.Printf("Remote memory (KB) output: '%s'\n", trimmed)
 		if memKB, parseErr := strconv.ParseFloat(trimmed, 64); parseErr == nil && memKB > 0 {
@@ -108,8 +106,7 @@ func (a *App) getRemoteSystemMetadata(sessionID string) map[string]interface{} {
 		} else {
 			fmt.Printf("Failed to parse memory: %v, trying alternative method\n", parseErr)
 			// Alternative: extract number using sed
-			altMemCmd := "cat /proc/meminfo 2>/dev/null | grep MemTotal | sed 's/[^0-9]//g'"
-			if altOutput, altErr := a.ExecuteMonitoringCommand(sshSession, altMemCmd); altErr == nil {
+			if altOutput, altErr := a.executeMonitoringCommand(sshSession, remoteCmdMemTotalKBAlt); altErr == nil {
 				altTrimmed := strings.TrimSpace(altOutput)
 				if altMemKB, altParseErr := strconv.ParseFloat(altTrimmed, 64); altParseErr == nil && altMemKB > 0 {
 					metadata["memory_total"] = altMemKB / 1024
@@ -121,27 +118,10 @@ func (a *App) getRemoteSystemMetadata(sessionID string) map[string]interface{} {
 		fmt.Printf("Failed to get memory: %v\n", err)
 	}
 
-	// Get disk capacity - try multiple methods
-	// Method 1: df -k (most universal) - extract just the total size column
-	diskCmd := "df -k / 2>/dev/null | tail -1 | awk '{print $2}' | tr -d ' '"
-	if output, err := a.ExecuteMonitoringCommand(sshSession, diskCmd); err == nil {
-		trimmed := strings.TrimSpace(output)
-		fmt.Printf("Remote disk (KB) output: '%s'\n", trimmed)
-		if diskKB, parseErr := strconv.ParseFloat(trimmed, 64); parseErr == nil && diskKB > 0 {
-			metadata["disk_capacity"] = diskKB / 1024 / 1024 // Convert KB to GB
-			fmt.Printf("Remote disk capacity: %.2f GB (%.0f KB)\n", diskKB/1024/1024, diskKB)
-		} else {
-			fmt.Printf("Failed to parse disk capacity: %v, trying alternative method\n", parseErr)
-			// Alternative: use df --output
-			altDiskCmd := "df -k / 2>/dev/null | grep -v Filesystem | head -1 | awk '{print $2}'"
-			if altOutput, altErr := a.ExecuteMonitoringCommand(sshSession, altDiskCmd); altErr == nil {
-				altTrimmed := strings.TrimSpace(altOutput)
-				if altDiskKB, altParseErr := strconv.ParseFloat(altTrimmed, 64); altParseErr == nil && altDiskKB > 0 {
-					metadata["disk_capacity"] = altDiskKB / 1024 / 1024
-					fmt.Printf("Remote disk capacity (alt): %.2f GB\n", altDiskKB/1024/1024)
-				}
-			}
-		}
+	// Get disk capacity of the root filesystem
+	if fsStats, err := a.remoteFilesystemStats(sessionID, sshSession, "/"); err == nil && fsStats.TotalBytes > 0 {
+		metadata["disk_capacity"] = float64(fsStats.TotalBytes) / 1024 / 1024 / 1024 // GB
+		fmt.Printf("Remote disk capacity (%s): %.2f GB\n", fsStats.Source, metadata["disk_capacity"])
 	} else {
 		fmt.Printf("Failed to get disk capacity: %v\n", err)
 	}
@@ -174,9 +154,9 @@ func (a *App) GetSystemStats() map[string]interface{} {
 
 	stats["timestamp"] = time.Now().Unix()
 
-	// Get real CPU usage
-	if cpuUsage, err := a.getCPUUsage(); err == nil {
-		stats["cpu"] = fmt.Sprintf("%.1f%%", cpuUsage)
+	// Get real CPU usage, measured since the previous poll
+	if cpuUsage, coreUsage, ready, err := a.sampleLocalCPU(); err == nil {
+		setCPUStats(stats, cpuUsage, coreUsage, ready)
 	}
 
 	// Get real memory usage with detailed info
@@ -214,21 +194,6 @@ func (a *App) GetSystemStats() map[string]interface{} {
 	return stats
 }
 
-// getCPUUsage returns current CPU usage percentage using gopsutil
-func (a *App) getCPUUsage() (float64, error) {
-	// Get CPU usage percentage with 1 second interval
-	percentages, err := cpu.Percent(time.Second, false)
-	if err != nil {
-		return 0, err
-	}
-
-	if len(percentages) == 0 {
-		return 0, fmt.Errorf("no CPU usage data available")
-	}
-
-	return percentages[0], nil
-}
-
 // getMemoryUsageDetailed returns memory usage using gopsutil
 func (a *App) getMemoryUsageDetailed() (percentage, total, used float64, err error) {
 	memInfo, err := mem.VirtualMemory()
@@ -245,7 +210,10 @@ func (a *App) getLoadAverage() (float64, error) {
 	if err != nil {
 		// On Windows, load average is not available, return CPU usage as approximation
 		if runtime.GOOS == "windows" {
-			return a.getCPUUsage()
+			// Reuse this poll's reading; sampling again would measure a near-empty window
+			if usage, ok := a.lastCPUUsage(localCPUSampleKey); ok {
+				return usage, nil
+			}
 		}
 		return 0, err
 	}
@@ -426,6 +394,7 @@ func (a *App) GetRemoteSystemStats(sessionID string) map[string]interface{} {
 		"memory":       "unknown",
 		"memory_total": "unknown",
 		"memory_used":  "unknown",
+		"swap":         "unknown",
 		"arch":         "unknown",
 		"kernel":       "unknown",
 		"network_rx":   "unknown",
@@ -479,110 +448,117 @@ func (a *App) GetRemoteSystemStats(sessionID string) map[string]interface{} {
 
 	// Basic system info - run in parallel
 	wg.Add(3)
-	go func() {
+	sshSession.goTracked(func() {
 		defer wg.Done()
 		// Execute and store result safely
 		if cached, exists := a.GetCachedMonitoringResult(sshSession, "hostname"); exists {
 			statsWrapper.set("hostname", strings.TrimSpace(cached))
-		} else if output, err := a.ExecuteMonitoringCommand(sshSession, "hostname"); err == nil {
+		} else if output, err := a.executeMonitoringCommand(sshSession, remoteCmdHostname); err == nil {
 			result := strings.TrimSpace(output)
 			if result != "" {
 				statsWrapper.set("hostname", result)
 				a.CacheMonitoringResult(sshSession, "hostname", result)
 			}
 		}
-	}()
-	go func() {
+	})
+	sshSession.goTracked(func() {
 		defer wg.Done()
 		if cached, exists := a.GetCachedMonitoringResult(sshSession, "uname -sr"); exists {
 			statsWrapper.set("kernel", strings.TrimSpace(cached))
-		} else if output, err := a.ExecuteMonitoringCommand(sshSession, "uname -sr"); err == nil {
+		} else if output, err := a.executeMonitoringCommand(sshSession, remoteCmdKernel); err == nil {
 			result := strings.TrimSpace(output)
 			if result != "" {
 				statsWrapper.set("kernel", result)
 				a.CacheMonitoringResult(sshSession, "uname -sr", result)
 			}
 		}
-	}()
-	go func() {
+	})
+	sshSession.goTracked(func() {
 		defer wg.Done()
 		if cached, exists := a.GetCachedMonitoringResult(sshSession, "uname -m"); exists {
 			statsWrapper.set("arch", strings.TrimSpace(cached))
-		} else if output, err := a.ExecuteMonitoringCommand(sshSession, "uname -m"); err == nil {
+		} else if output, err := a.executeMonitoringCommand(sshSession, remoteCmdArch); err == nil {
 			result := strings.TrimSpace(output)
 			if result != "" {
 				statsWrapper.set("arch", result)
 				a.CacheMonitoringResult(sshSession, "uname -m", result)
 			}
 		}
-	}()
+	})
 
 	// System stats with more complex parsing - run in parallel
 	// These functions write to the map, so we need to pass mutex-protected access
 	wg.Add(7)
-	go func() {
+	sshSession.goTracked(func() {
 		defer wg.Done()
 		localStats := make(map[string]interface{})
 		a.executeRemoteUptimeCommand(sshSession, &localStats)
 		for k, v := range localStats {
 			statsWrapper.set(k, v)
 		}
-	}()
-	go func() {
+	})
+	sshSession.goTracked(func() {
 		defer wg.Done()
-		localStats := make(map[string]interface{})
-		a.executeRemoteMemoryCommand(sshSession, &localStats)
-		for k, v := range localStats {
+		memStats, err := a.collectRemoteMemoryStats(sshSession)
+		if err != nil {
+			fmt.Printf("Failed to get remote memory stats for %s: %v\n", sessionID, err)
+			return
+		}
+		for k, v := range flattenRemoteMemoryStats(memStats) {
 			statsWrapper.set(k, v)
 		}
-	}()
-	go func() {
+		a.emitRemoteMemoryStats(sessionID, memStats)
+	})
+	sshSession.goTracked(func() {
 		defer wg.Done()
 		localStats := make(map[string]interface{})
-		a.executeRemoteCPUCommand(sshSession, &localStats)
+		a.executeRemoteCPUCommand(sessionID, sshSession, &localStats)
 		for k, v := range localStats {
 			statsWrapper.set(k, v)
 		}
-	}()
-	go func() {
+	})
+	sshSession.goTracked(func() {
 		defer wg.Done()
 		localStats := make(map[string]interface{})
 		a.executeRemoteLoadCommand(sshSession, &localStats)
 		for k, v := range localStats {
 			statsWrapper.set(k, v)
 		}
-	}()
-	go func() {
+	})
+	sshSession.goTracked(func() {
 		defer wg.Done()
 		localStats := make(map[string]interface{})
 		a.executeRemoteNetworkCommand(sshSession, &localStats)
 		for k, v := range localStats {
 			statsWrapper.set(k, v)
 		}
-	}()
-	go func() {
+	})
+	sshSession.goTracked(func() {
 		defer wg.Done()
 		localStats := make(map[string]interface{})
-		a.executeRemoteDiskUsageCommand(sshSession, &localStats)
+		a.executeRemoteDiskUsageCommand(sessionID, sshSession, &localStats)
 		for k, v := range localStats {
 			statsWrapper.set(k, v)
 		}
-	}()
-	go func() {
+	})
+
+	// Disk alerts emit their own events, so they don't hold up the stats
+	sshSession.goTracked(func() { a.checkDiskAlerts(sshSession, sessionID) })
+	sshSession.goTracked(func() {
 		defer wg.Done()
 		localStats := make(map[string]interface{})
 		a.executeRemoteDiskIOCommand(sshSession, sessionID, &localStats)
 		for k, v := range localStats {
 			statsWrapper.set(k, v)
 		}
-	}()
+	})
 
 	// Wait for all commands to complete with a timeout
 	doneChan := make(chan struct{})
-	go func() {
+	sshSession.goTracked(func() {
 		wg.Wait()
 		close(doneChan)
-	}()
+	})
 
 	select {
 	case <-doneChan:
@@ -597,15 +573,15 @@ func (a *App) GetRemoteSystemStats(sessionID string) map[string]interface{} {
 }
 
 // executeRemoteStatsCommand executes a command and stores result in stats
-func (a *App) executeRemoteStatsCommand(sshSession *SSHSession, command string, stats *map[string]interface{}, key string) {
+func (a *App) executeRemoteStatsCommand(sshSession *SSHSession, command remoteCommand, stats *map[string]interface{}, key string) {
 	// Check cache first
-	if cached, exists := a.GetCachedMonitoringResult(sshSession, command); exists {
+	if cached, exists := a.GetCachedMonitoringResult(sshSession, string(command)); exists {
 		(*stats)[key] = strings.TrimSpace(cached)
 		return
 	}
 
 	// Execute command
-	output, err := a.ExecuteMonitoringCommand(sshSession, command)
+	output, err := a.executeMonitoringCommand(sshSession, command)
 	if err != nil {
 		fmt.Printf("Failed to execute remote command '%s': %v\n", command, err)
 		return
@@ -615,14 +591,14 @@ func (a *App) executeRemoteStatsCommand(sshSession *SSHSession, command string,
 	if result != "" {
 		(*stats)[key] = result
 		// Cache the result
-		a.CacheMonitoringResult(sshSession, command, result)
+		a.CacheMonitoringResult(sshSession, string(command), result)
 	}
 }
 
 // executeRemoteUptimeCommand gets system uptime
 func (a *App) executeRemoteUptimeCommand(sshSession *SSHSession, stats *map[string]interface{}) {
 	// Try uptime -p first (prettier format) - this gives "up X days, Y hours, Z minutes"
-	output, err := a.ExecuteMonitoringCommand(sshSession, "uptime -p 2>/dev/null")
+	output, err := a.executeMonitoringCommand(sshSession, remoteCmdUptime)
 	fmt.Printf("Remote uptime command output: %q, err: %v\n", output, err)
 
 	if err == nil && strings.TrimSpace(output) != "" {
@@ -637,57 +613,177 @@ func (a *App) executeRemoteUptimeCommand(sshSession *SSHSession, stats *map[stri
 	}
 }
 
-// executeRemoteMemoryCommand gets memory usage
-func (a *App) executeRemoteMemoryCommand(sshSession *SSHSession, stats *map[string]interface{}) {
-	// Try to get memory info from /proc/meminfo (Linux)
-	output, err := a.ExecuteMonitoringCommand(sshSession, "cat /proc/meminfo 2>/dev/null | head -3")
+// GetRemoteMemoryStats returns a detailed memory breakdown for an SSH session's host
+func (a *App) GetRemoteMemoryStats(sessionID string) (*RemoteMemoryStats, error) {
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+
+	if !exists || sshSession == nil || sshSession.cleaning {
+		return nil, newNotFoundError(ErrCategoryMonitoring, "GetRemoteMemoryStats", "SSH session %s not found", sessionID)
+	}
+
+	return a.collectRemoteMemoryStats(sshSession)
+}
+
+// collectRemoteMemoryStats reads /proc/meminfo over the monitoring session, falling back to `free -m`
+func (a *App) collectRemoteMemoryStats(sshSession *SSHSession) (*RemoteMemoryStats, error) {
+	output, err := a.executeMonitoringCommand(sshSession, remoteCmdMeminfo)
 	if err == nil && strings.Contains(output, "MemTotal") {
-		lines := strings.Split(output, "\n")
-		var memTotal, memAvailable int64
-
-		for _, line := range lines {
-			if strings.HasPrefix(line, "MemTotal:") {
-				fmt.Sscanf(line, "MemTotal: %d kB", &memTotal)
-			} else if strings.HasPrefix(line, "MemAvailable:") {
-				fmt.Sscanf(line, "MemAvailable: %d kB", &memAvailable)
-			}
+		if stats, parseErr := parseMeminfo(output); parseErr == nil {
+			return stats, nil
 		}
+	}
 
-		if memTotal > 0 && memAvailable > 0 {
-			memUsed := memTotal - memAvailable
-			memPercent := float64(memUsed) / float64(memTotal) * 100
+	// Fallback: try free command
+	output, err = a.executeMonitoringCommand(sshSession, remoteCmdFree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote memory info: %w", err)
+	}
+	return parseFreeOutput(output)
+}
 
-			(*stats)["memory"] = fmt.Sprintf("%.1f%%", memPercent)
-			(*stats)["memory_total"] = fmt.Sprintf("%.0f MB", float64(memTotal)/1024)
-			(*stats)["memory_used"] = fmt.Sprintf("%.0f MB", float64(memUsed)/1024)
-			return
+// parseMeminfo parses /proc/meminfo (values in kB) into a memory breakdown
+func parseMeminfo(output string) (*RemoteMemoryStats, error) {
+	fields := make(map[string]float64)
+	for _, line := range strings.Split(output, "\n") {
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		parts := strings.Fields(value)
+		if len(parts) == 0 {
+			continue
+		}
+		if kb, err := strconv.ParseFloat(parts[0], 64); err == nil {
+			fields[strings.TrimSpace(name)] = kb / 1024
 		}
 	}
 
-	// Fallback: try free command
-	output, err = a.ExecuteMonitoringCommand(sshSession, "free -m | grep '^Mem:'")
-	if err == nil && strings.TrimSpace(output) != "" {
-		// Parse free output: "Mem:      15360      2048      1024      256      8192      13312"
-		fields := strings.Fields(output)
-		if len(fields) >= 3 {
-			var total, used int64
-			fmt.Sscanf(fields[1], "%d", &total)
-			fmt.Sscanf(fields[2], "%d", &used)
-
-			if total > 0 {
-				memPercent := float64(used) / float64(total) * 100
-				(*stats)["memory"] = fmt.Sprintf("%.1f%%", memPercent)
-				(*stats)["memory_total"] = fmt.Sprintf("%d MB", total)
-				(*stats)["memory_used"] = fmt.Sprintf("%d MB", used)
+	total := fields["MemTotal"]
+	if total <= 0 {
+		return nil, fmt.Errorf("MemTotal missing from /proc/meminfo")
+	}
+
+	available, hasAvailable := fields["MemAvailable"]
+	if !hasAvailable {
+		// Kernels before 3.14 don't report MemAvailable; approximate it the way older `free` did
+		available = fields["MemFree"] + fields["Buffers"] + fields["Cached"]
+	}
+
+	stats := &RemoteMemoryStats{
+		TotalMB:     total,
+		UsedMB:      total - available,
+		FreeMB:      fields["MemFree"],
+		BuffersMB:   fields["Buffers"],
+		CachedMB:    fields["Cached"],
+		AvailableMB: available,
+		SwapTotalMB: fields["SwapTotal"],
+		SwapUsedMB:  fields["SwapTotal"] - fields["SwapFree"],
+	}
+	stats.SwapPercent = swapPercent(stats)
+	return stats, nil
+}
+
+// parseFreeOutput parses `free -m` output into a memory breakdown.
+// Newer procps merges buffers and cache into one "buff/cache" column, which is reported as cached.
+func parseFreeOutput(output string) (*RemoteMemoryStats, error) {
+	stats := &RemoteMemoryStats{}
+	header := []string{}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if fields[0] == "total" {
+			header = fields
+			continue
+		}
+
+		values := make(map[string]float64)
+		for i, name := range header {
+			if i+1 < len(fields) {
+				if v, err := strconv.ParseFloat(fields[i+1], 64); err == nil {
+					values[name] = v
+				}
+			}
+		}
+
+		switch fields[0] {
+		case "Mem:":
+			stats.TotalMB = values["total"]
+			stats.UsedMB = values["used"]
+			stats.FreeMB = values["free"]
+			stats.BuffersMB = values["buffers"]
+			stats.CachedMB = values["cached"] + values["buff/cache"]
+			stats.AvailableMB = values["available"]
+			if _, ok := values["available"]; !ok {
+				stats.AvailableMB = stats.FreeMB + stats.BuffersMB + stats.CachedMB
 			}
+		case "Swap:":
+			stats.SwapTotalMB = values["total"]
+			stats.SwapUsedMB = values["used"]
 		}
 	}
+
+	if stats.TotalMB <= 0 {
+		return nil, fmt.Errorf("unrecognized free output")
+	}
+	stats.SwapPercent = swapPercent(stats)
+	return stats, nil
+}
+
+// swapPercent returns swap usage as a percentage, or 0 when there is no swap
+func swapPercent(stats *RemoteMemoryStats) float64 {
+	if stats.SwapTotalMB <= 0 {
+		return 0
+	}
+	return stats.SwapUsedMB / stats.SwapTotalMB * 100
+}
+
+// flattenRemoteMemoryStats converts a memory breakdown into the string values used by the stats map
+func flattenRemoteMemoryStats(stats *RemoteMemoryStats) map[string]interface{} {
+	return map[string]interface{}{
+		"memory":           fmt.Sprintf("%.1f%%", stats.UsedMB/stats.TotalMB*100),
+		"memory_total":     fmt.Sprintf("%.0f MB", stats.TotalMB),
+		"memory_used":      fmt.Sprintf("%.0f MB", stats.UsedMB),
+		"memory_free":      fmt.Sprintf("%.0f MB", stats.FreeMB),
+		"memory_buffers":   fmt.Sprintf("%.0f MB", stats.BuffersMB),
+		"memory_cached":    fmt.Sprintf("%.0f MB", stats.CachedMB),
+		"memory_available": fmt.Sprintf("%.0f MB", stats.AvailableMB),
+		"swap":             fmt.Sprintf("%.1f%%", stats.SwapPercent),
+		"swap_total":       fmt.Sprintf("%.0f MB", stats.SwapTotalMB),
+		"swap_used":        fmt.Sprintf("%.0f MB", stats.SwapUsedMB),
+	}
 }
 
-// executeRemoteCPUCommand gets CPU usage
-func (a *App) executeRemoteCPUCommand(sshSession *SSHSession, stats *map[string]interface{}) {
-	// Use top command to get current CPU usage
-	output, err := a.ExecuteMonitoringCommand(sshSession, "top -bn1 | grep '^%Cpu' | head -1")
+// emitRemoteMemoryStats publishes the memory breakdown collected during a stats poll
+func (a *App) emitRemoteMemoryStats(sessionID string, stats *RemoteMemoryStats) {
+	if a.ctx == nil {
+		return
+	}
+	wailsRuntime.EventsEmit(a.ctx, "remote-memory-stats-updated", map[string]interface{}{
+		"sessionId": sessionID,
+		"stats":     stats,
+	})
+}
+
+// executeRemoteCPUCommand gets CPU usage. On Linux it diffs /proc/stat against the
+// previous poll's snapshot, so the call doesn't wait for a sampling interval.
+func (a *App) executeRemoteCPUCommand(sessionID string, sshSession *SSHSession, stats *map[string]interface{}) {
+	output, err := a.executeMonitoringCommand(sshSession, remoteCmdProcStatCPU)
+	if err == nil {
+		if total, perCore, parseErr := parseProcStatCPU(output); parseErr == nil {
+			usage, coreUsage, ready := a.recordCPUSample(sessionID, total, perCore)
+			setCPUStats(*stats, usage, coreUsage, ready)
+			return
+		}
+	}
+
+	// No /proc (BSD, macOS): use top's own reading
+	output, err = a.executeMonitoringCommand(sshSession, remoteCmdTopCPU)
 	if err == nil && strings.TrimSpace(output) != "" {
 		// Parse top output: "%Cpu(s):  3.2 us,  1.0 sy,  0.0 ni, 95.8 id,  0.0 wa,  0.0 hi,  0.0 si,  0.0 st"
 		line := strings.TrimSpace(output)
@@ -702,32 +798,17 @@ func (a *App) executeRemoteCPUCommand(sshSession *SSHSession, stats *map[string]
 					if n, _ := fmt.Sscanf(fields[len(fields)-1], "%f", &idle); n == 1 {
 						cpuUsage := 100.0 - idle
 						(*stats)["cpu"] = fmt.Sprintf("%.1f%%", cpuUsage)
-						return
 					}
 				}
 			}
 		}
 	}
-
-	// Fallback: Try different top format or vmstat
-	output, err = a.ExecuteMonitoringCommand(sshSession, "vmstat 1 2 | tail -1")
-	if err == nil && strings.TrimSpace(output) != "" {
-		// Parse vmstat output: " 1  0      0 7982720 184392 5981632    0    0     0     0  1020  1822  1  1 98  0  0"
-		fields := strings.Fields(output)
-		if len(fields) >= 15 {
-			var idle float64
-			if n, _ := fmt.Sscanf(fields[14], "%f", &idle); n == 1 {
-				cpuUsage := 100.0 - idle
-				(*stats)["cpu"] = fmt.Sprintf("%.1f%%", cpuUsage)
-			}
-		}
-	}
 }
 
 // executeRemoteLoadCommand gets load average
 func (a *App) executeRemoteLoadCommand(sshSession *SSHSession, stats *map[string]interface{}) {
 	// Get load average from /proc/loadavg (Linux)
-	output, err := a.ExecuteMonitoringCommand(sshSession, "cat /proc/loadavg 2>/dev/null")
+	output, err := a.executeMonitoringCommand(sshSession, remoteCmdLoadavg)
 	if err == nil && strings.TrimSpace(output) != "" {
 		// Parse loadavg: "0.08 0.02 0.01 1/123 12345"
 		fields := strings.Fields(output)
@@ -738,7 +819,7 @@ func (a *App) executeRemoteLoadCommand(sshSession *SSHSession, stats *map[string
 	}
 
 	// Fallback: extract from uptime command
-	output, err = a.ExecuteMonitoringCommand(sshSession, "uptime")
+	output, err = a.executeMonitoringCommand(sshSession, remoteCmdUptimeRaw)
 	if err == nil && strings.Contains(output, "load average:") {
 		// Extract load from uptime output
 		idx := strings.Index(output, "load average:")
@@ -759,11 +840,11 @@ func (a *App) executeRemoteNetworkCommand(sshSession *SSHSession, stats *map[str
 	// Prioritize real physical/virtual interfaces, exclude management/virtual/loopback
 	// Priority order: eth*, ens*, enp*, eno* (physical), then others
 	// Exclude: lo, docker*, veth*, dummy*, tunl*, sit*, bond* (virtual/management)
-	output, err := a.ExecuteMonitoringCommand(sshSession, "cat /proc/net/dev 2>/dev/null | grep -E '(eth|ens|enp|eno)[0-9]:' | head -1")
+	output, err := a.executeMonitoringCommand(sshSession, remoteCmdPrimaryNetDev)
 
 	// If no standard interface found, try broader search but still exclude virtual
 	if err != nil || strings.TrimSpace(output) == "" {
-		output, err = a.ExecuteMonitoringCommand(sshSession, "cat /proc/net/dev 2>/dev/null | grep -vE 'lo:|docker|veth|Inter|face|dummy|tunl|sit|bond' | grep ':' | grep -E '[0-9]' | head -1")
+		output, err = a.executeMonitoringCommand(sshSession, remoteCmdAnyNetDev)
 	}
 
 	fmt.Printf("Network command output: %q\n", output)
@@ -830,7 +911,7 @@ func (a *App) executeRemoteNetworkCommand(sshSession *SSHSession, stats *map[str
 	}
 
 	// Fallback: try ifconfig or ip command (less accurate, shows totals not rates)
-	output, err = a.ExecuteMonitoringCommand(sshSession, "ip -s link 2>/dev/null | grep -A3 -E 'eth|ens|enp|wlan|wlp' | head -6")
+	output, err = a.executeMonitoringCommand(sshSession, remoteCmdIPLinkStats)
 	if err == nil && strings.TrimSpace(output) != "" {
 		// This is a simplified implementation - would need more complex parsing for ip command
 		// For now, just indicate network interface is available
@@ -839,26 +920,10 @@ func (a *App) executeRemoteNetworkCommand(sshSession *SSHSession, stats *map[str
 	}
 }
 
-// executeRemoteDiskUsageCommand gets disk usage percentage
-func (a *App) executeRemoteDiskUsageCommand(sshSession *SSHSession, stats *map[string]interface{}) {
-	// Use df to get disk usage for the root filesystem
-	output, err := a.ExecuteMonitoringCommand(sshSession, "df -h / | tail -1")
-	if err == nil && strings.TrimSpace(output) != "" {
-		// Parse df output: "Filesystem  Size  Used  Avail Use% Mounted on"
-		// Example: "/dev/sda1      50G   25G    23G  53% /"
-		fields := strings.Fields(output)
-		if len(fields) >= 5 {
-			// The Use% field is typically at index 4
-			usageStr := fields[4]
-			// Remove the % sign
-			usageStr = strings.TrimSuffix(usageStr, "%")
-
-			var usage float64
-			if n, _ := fmt.Sscanf(usageStr, "%f", &usage); n == 1 {
-				(*stats)["disk_usage"] = fmt.Sprintf("%.1f%%", usage)
-				return
-			}
-		}
+// executeRemoteDiskUsageCommand gets disk usage percentage of the root filesystem
+func (a *App) executeRemoteDiskUsageCommand(sessionID string, sshSession *SSHSession, stats *map[string]interface{}) {
+	if fsStats, err := a.remoteFilesystemStats(sessionID, sshSession, "/"); err == nil {
+		(*stats)["disk_usage"] = fmt.Sprintf("%.1f%%", fsStats.UsedPercent)
 	}
 }
 
@@ -866,7 +931,7 @@ func (a *App) executeRemoteDiskUsageCommand(sshSession *SSHSession, stats *map[s
 func (a *App) executeRemoteDiskIOCommand(sshSession *SSHSession, sessionID string, stats *map[string]interface{}) {
 	// Try to get disk I/O from /proc/diskstats (Linux)
 	// Format: major minor name reads ... sectors_read ... writes ... sectors_written ...
-	output, err := a.ExecuteMonitoringCommand(sshSession, "cat /proc/diskstats 2>/dev/null | grep -E '(sda|nvme0n1|vda|xvda|hda)\\s' | head -1")
+	output, err := a.executeMonitoringCommand(sshSession, remoteCmdDiskstats)
 	if err == nil && strings.TrimSpace(output) != "" {
 		fields := strings.Fields(output)
 		if len(fields) >= 14 {
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
@@ -39,10 +42,6 @@ func (a *App) CreateTab(shell string, sshConfig *SSHConfig) (*Tab, error) {
 			return nil, fmt.Errorf("invalid SSH config: %w", err)
 		}
 		connectionType = ConnectionTypeSSH
-		title = fmt.Sprintf("%s@%s", sshConfig.Username, sshConfig.Host)
-		if sshConfig.Port != 22 {
-			title = fmt.Sprintf("%s@%s:%d", sshConfig.Username, sshConfig.Host, sshConfig.Port)
-		}
 	}
 
 	// Create tab
@@ -58,6 +57,8 @@ func (a *App) CreateTab(shell string, sshConfig *SSHConfig) (*Tab, error) {
 		Status:         status,
 		ErrorMessage:   "",
 	}
+	// The profile isn't known yet; CreateTabFromProfile re-formats once it is
+	tab.Title = formatTabTitle(tab, "")
 
 	// Validate tab
 	if err := tab.Validate(); err != nil {
@@ -70,26 +71,9 @@ func (a *App) CreateTab(shell string, sshConfig *SSHConfig) (*Tab, error) {
 	return tab, nil
 }
 
-// GetTabs returns all tabs
+// GetTabs returns copies of all tabs, oldest first
 func (a *App) GetTabs() []*Tab {
-	a.terminal.mutex.RLock()
-	defer a.terminal.mutex.RUnlock()
-
-	tabs := make([]*Tab, 0, len(a.terminal.tabs))
-	for _, tab := range a.terminal.tabs {
-		tabs = append(tabs, tab)
-	}
-
-	// Sort by creation time
-	for i := 0; i < len(tabs)-1; i++ {
-		for j := i + 1; j < len(tabs); j++ {
-			if tabs[i].Created.After(tabs[j].Created) {
-				tabs[i], tabs[j] = tabs[j], tabs[i]
-			}
-		}
-	}
-
-	return tabs
+	return a.SnapshotTabs()
 }
 
 // SetActiveTab sets the active tab
@@ -100,7 +84,7 @@ func (a *App) SetActiveTab(tabId string) error {
 	tab, exists := a.terminal.tabs[tabId]
 	if !exists {
 		a.terminal.mutex.Unlock()
-		return fmt.Errorf("tab %s not found", tabId)
+		return newNotFoundError(ErrCategoryTerminal, "SetActiveTab", "tab %s not found", tabId)
 	}
 
 	// Deactivate current active tab
@@ -149,24 +133,17 @@ func (a *App) GetActiveTab() *Tab {
 // CloseTab closes a tab and its associated session
 func (a *App) CloseTab(tabId string) error {
 	a.terminal.mutex.Lock()
-	defer a.terminal.mutex.Unlock()
 
 	tab, exists := a.terminal.tabs[tabId]
 	if !exists {
-		return fmt.Errorf("tab %s not found", tabId)
+		a.terminal.mutex.Unlock()
+		return newNotFoundError(ErrCategoryTerminal, "CloseTab", "tab %s not found", tabId)
 	}
 
-	// Remove tab first
+	// Keep what's needed to reopen it, then remove the tab
+	remembered := a.rememberClosedTabLocked(tab)
 	delete(a.terminal.tabs, tabId)
-
-	// Close the associated session asynchronously to avoid blocking
-	if tab.SessionID != "" {
-		go func(sessionID string) {
-			if err := a.CloseShell(sessionID); err != nil {
-				fmt.Printf("Error closing session %s: %v\n", sessionID, err)
-			}
-		}(tab.SessionID)
-	}
+	delete(a.terminal.sessionTags, tabId)
 
 	// If this was the active tab, find a new active tab
 	if a.terminal.activeTabId == tabId {
@@ -179,6 +156,15 @@ func (a *App) CloseTab(tabId string) error {
 			break
 		}
 	}
+	sessionID := tab.SessionID
+	a.terminal.mutex.Unlock()
+
+	// Drop per-session state and close the session (outside the terminal lock)
+	a.CleanupSession(sessionID)
+
+	if remembered {
+		a.saveRecentlyClosedTabs()
+	}
 
 	return nil
 }
@@ -195,29 +181,44 @@ func (a *App) StartTabShellWithSize(tabId string, cols, rows int) error {
 	a.terminal.mutex.RUnlock()
 
 	if !exists {
-		return fmt.Errorf("tab %s not found", tabId)
+		return newNotFoundError(ErrCategoryTerminal, "StartTabShellWithSize", "tab %s not found", tabId)
 	}
 
+	// Negotiate inline images before any output can arrive
+	a.setupInlineImages(tab)
+
 	var err error
 
 	// Handle SSH connections with unified messaging system
 	if tab.ConnectionType == "ssh" && tab.SSHConfig != nil {
-		// Start unified connection flow
-		target := fmt.Sprintf("%s@%s:%d", tab.SSHConfig.Username, tab.SSHConfig.Host, tab.SSHConfig.Port)
-		authMethods := []string{} // Will be populated in CreateSSHSession
+		preHooks, postHooks, hookEnv := a.connectHooksForTab(tab)
+		reconnect := hasConnectedBefore(tab.SessionID)
+
+		// Pre-connect hooks run before dialing; a failing hook with the abort policy ends the attempt
+		err = a.runConnectHooks(tab.SessionID, HookPhasePreConnect, preHooks, hookEnv, reconnect)
+		if errors.Is(err, errConnectHooksCancelled) {
+			return err
+		}
 
-		a.messages.StartConnectionFlow(tab.SessionID, target, authMethods)
+		if err == nil {
+			// Start unified connection flow
+			target := fmt.Sprintf("%s@%s:%d", tab.SSHConfig.Username, tab.SSHConfig.Host, tab.SSHConfig.Port)
+			authMethods := []string{} // Will be populated in CreateSSHSession
 
-		// Log dimensions for debugging SSH sizing issues
-		fmt.Printf("SSH Connection Debug: Starting SSH session with dimensions %dx%d for %s\n", cols, rows, tab.SSHConfig.Host)
+			a.messages.StartConnectionFlow(tab.SessionID, target, authMethods)
 
-		// Attempt SSH connection with terminal dimensions
-		err = a.startSSHSessionWithSize(tab, cols, rows)
+			// Log dimensions for debugging SSH sizing issues
+			fmt.Printf("SSH Connection Debug: Starting SSH session with dimensions %dx%d for %s\n", cols, rows, tab.SSHConfig.Host)
+
+			// Attempt SSH connection with terminal dimensions
+			err = a.startSSHSessionWithSize(tab, cols, rows)
+		}
 
 		if err != nil {
 			a.messages.ConnectionFailed(tab.SessionID, err)
 		} else {
 			a.messages.SessionReady(tab.SessionID)
+			go a.runPostConnectHooks(tab.SessionID, postHooks, hookEnv, reconnect)
 
 			// For SSH connections, ensure proper terminal sizing immediately
 			go func() {
@@ -239,6 +240,17 @@ func (a *App) StartTabShellWithSize(tabId string, cols, rows int) error {
 				}
 			}()
 		}
+	} else if tab.ConnectionType == ConnectionTypeNomadExec && tab.NomadConfig != nil {
+		// Nomad exec runs the nomad CLI locally in a PTY
+		err = a.NomadExecSession(tab.SessionID, tab.NomadConfig.AllocID, tab.NomadConfig.TaskName)
+
+		if err != nil {
+			a.messages.UpdateConnectionStatus(tab.SessionID, StatusFailed.String(), err.Error())
+			a.messages.EmitMessage(tab.SessionID, fmt.Sprintf("Failed to exec into allocation %s", tab.NomadConfig.AllocID), MessageError)
+			a.messages.EmitMessage(tab.SessionID, err.Error(), MessageError)
+		} else {
+			a.messages.UpdateConnectionStatus(tab.SessionID, StatusConnected.String(), "")
+		}
 	} else {
 		// Handle local shell with unified messaging
 		err = a.StartShell(tab.Shell, tab.SessionID)
@@ -255,6 +267,11 @@ func (a *App) StartTabShellWithSize(tabId string, cols, rows int) error {
 		}
 	}
 
+	// Tabs opened during a maintenance window show its banner once their shell is up
+	if err == nil {
+		a.applyMaintenanceWindows(tabId)
+	}
+
 	return err
 }
 
@@ -291,17 +308,25 @@ func (a *App) startSSHSessionWithSize(tab *Tab, cols, rows int) error {
 	return nil
 }
 
-// RenameTab renames a tab
+// RenameTab renames a tab, pinning the title so formats no longer update it. An empty title
+// clears the pin and goes back to the formatted title.
 func (a *App) RenameTab(tabId, newTitle string) error {
 	a.terminal.mutex.Lock()
-	defer a.terminal.mutex.Unlock()
-
 	tab, exists := a.terminal.tabs[tabId]
 	if !exists {
-		return fmt.Errorf("tab %s not found", tabId)
+		a.terminal.mutex.Unlock()
+		return newNotFoundError(ErrCategoryTerminal, "RenameTab", "tab %s not found", tabId)
 	}
 
+	if newTitle == "" {
+		tab.customTitle = false
+		a.terminal.mutex.Unlock()
+		a.refreshTabTitle(tabId)
+		return nil
+	}
 	tab.Title = newTitle
+	tab.customTitle = true
+	a.terminal.mutex.Unlock()
 	return nil
 }
 
@@ -312,7 +337,7 @@ func (a *App) GetTabStatus(tabId string) (map[string]interface{}, error) {
 
 	tab, exists := a.terminal.tabs[tabId]
 	if !exists {
-		return nil, fmt.Errorf("tab %s not found", tabId)
+		return nil, newNotFoundError(ErrCategoryTerminal, "GetTabStatus", "tab %s not found", tabId)
 	}
 
 	return map[string]interface{}{
@@ -331,7 +356,7 @@ func (a *App) ForceDisconnectTab(tabId string) error {
 	a.terminal.mutex.RUnlock()
 
 	if !exists {
-		return fmt.Errorf("tab %s not found", tabId)
+		return newNotFoundError(ErrCategoryTerminal, "ForceDisconnectTab", "tab %s not found", tabId)
 	}
 
 	if tab.ConnectionType != "ssh" {
@@ -348,6 +373,7 @@ func (a *App) ForceDisconnectTab(tabId string) error {
 	tab.Status = "disconnected"
 	tab.ErrorMessage = "Forcefully disconnected"
 	a.terminal.mutex.Unlock()
+	a.refreshTabTitle(tabId)
 
 	// Emit status update
 	if a.ctx != nil {
@@ -367,7 +393,7 @@ func (a *App) ReconnectTab(tabId string) error {
 	tab, exists := a.terminal.tabs[tabId]
 	if !exists {
 		a.terminal.mutex.Unlock()
-		return fmt.Errorf("tab %s not found", tabId)
+		return newNotFoundError(ErrCategoryTerminal, "ReconnectTab", "tab %s not found", tabId)
 	}
 
 	// Only allow reconnection for SSH tabs
@@ -389,6 +415,7 @@ func (a *App) ReconnectTab(tabId string) error {
 			"status": "connecting",
 		})
 	}
+	a.refreshTabTitle(tabId)
 
 	// CRITICAL FIX: Clean up old failed/disconnected session before reconnecting
 	fmt.Printf("Cleaning up old session before reconnect: %s\n", sessionID)
@@ -396,25 +423,42 @@ func (a *App) ReconnectTab(tabId string) error {
 	// Close SFTP client for old session
 	a.CloseFileExplorerSession(sessionID)
 
-	// Close and remove old SSH session if it exists
+	// Get current terminal dimensions from the old session before it is torn down
+	cols, rows := 80, 24 // default fallback
+
+	// Remove old SSH session if it exists, closing it outside the lock
 	a.ssh.sshSessionsMutex.Lock()
-	if oldSession, exists := a.ssh.sshSessions[sessionID]; exists {
+	oldSession, exists := a.ssh.sshSessions[sessionID]
+	delete(a.ssh.sshSessions, sessionID)
+	a.ssh.sshSessionsMutex.Unlock()
+	if exists {
 		fmt.Printf("Removing old SSH session: %s\n", sessionID)
-		// Mark as cleaning and close
+		oldSession.mu.RLock()
+		if oldSession.cols > 0 && oldSession.rows > 0 {
+			cols, rows = oldSession.cols, oldSession.rows
+		}
+		oldSession.mu.RUnlock()
 		a.CloseSSHSession(oldSession)
-		// Remove from map
-		delete(a.ssh.sshSessions, sessionID)
 	}
-	a.ssh.sshSessionsMutex.Unlock()
+
+	// Only hooks marked RunOnReconnect run again once the session has connected before
+	preHooks, postHooks, hookEnv := a.connectHooksForTab(tab)
+	reconnect := hasConnectedBefore(sessionID)
+	if err := a.runConnectHooks(sessionID, HookPhasePreConnect, preHooks, hookEnv, reconnect); err != nil {
+		if !errors.Is(err, errConnectHooksCancelled) {
+			a.messages.ConnectionFailed(sessionID, err)
+		}
+		return err
+	}
 
 	// Start unified connection flow
 	target := fmt.Sprintf("%s@%s:%d", tab.SSHConfig.Username, tab.SSHConfig.Host, tab.SSHConfig.Port)
 	a.messages.StartConnectionFlow(sessionID, target, []string{})
 
-	// Get current terminal dimensions from the frontend
-	cols, rows := 80, 24 // default fallback
+	// Renegotiate inline images; a pending sequence from the old connection must not leak into the new one
+	a.setupInlineImages(tab)
 
-	// Start fresh SSH session with current dimensions
+	// Start fresh SSH session with the previous dimensions
 	err := a.startSSHSessionWithSize(tab, cols, rows)
 	if err != nil {
 		a.messages.ConnectionFailed(sessionID, err)
@@ -422,6 +466,7 @@ func (a *App) ReconnectTab(tabId string) error {
 	}
 
 	a.messages.SessionReady(sessionID)
+	go a.runPostConnectHooks(sessionID, postHooks, hookEnv, reconnect)
 
 	// Reinitialize SFTP client for file manager functionality
 	fmt.Printf("Reinitializing SFTP client for session: %s\n", sessionID)
@@ -455,6 +500,186 @@ func (a *App) ReconnectTab(tabId string) error {
 	return nil
 }
 
+// MaxParallelReconnects bounds how many tabs ReconnectAllTabs dials at once, so a shared
+// bastion isn't hit by every tab at the same moment
+const MaxParallelReconnects = 3
+
+// tabsToReconnect returns the IDs of SSH tabs whose connection is gone, in tab order
+func (a *App) tabsToReconnect() []string {
+	a.terminal.mutex.RLock()
+	defer a.terminal.mutex.RUnlock()
+
+	var tabIDs []string
+	for _, tab := range a.terminal.tabs {
+		if tab.ConnectionType != ConnectionTypeSSH || tab.SSHConfig == nil {
+			continue
+		}
+		switch tab.Status {
+		case StatusFailed.String(), StatusDisconnected.String(), StatusHanging:
+			tabIDs = append(tabIDs, tab.ID)
+		}
+	}
+	sort.Slice(tabIDs, func(i, j int) bool {
+		return a.terminal.tabs[tabIDs[i]].Created.Before(a.terminal.tabs[tabIDs[j]].Created)
+	})
+	return tabIDs
+}
+
+// ReconnectAllTabs reconnects every SSH tab that is failed, disconnected or hanging, e.g. after
+// the machine wakes up or the VPN comes back. Connected and local tabs are left alone. At most
+// MaxParallelReconnects tabs connect at once; "reconnect-all-progress" is emitted as each one
+// finishes. Returns the error message per tab ID, empty for tabs that reconnected (error
+// values don't survive the trip to the frontend).
+func (a *App) ReconnectAllTabs() map[string]string {
+	tabIDs := a.tabsToReconnect()
+	results := make(map[string]string, len(tabIDs))
+	if len(tabIDs) == 0 {
+		return results
+	}
+	fmt.Printf("Reconnecting %d tabs\n", len(tabIDs))
+
+	var resultsMu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, MaxParallelReconnects)
+	completed := 0
+
+	for _, tabID := range tabIDs {
+		wg.Add(1)
+		go func(tabID string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			err := a.ReconnectTab(tabID)
+			<-slots
+
+			message := ""
+			if err != nil {
+				message = err.Error()
+			}
+			resultsMu.Lock()
+			results[tabID] = message
+			completed++
+			progress := map[string]interface{}{
+				"tabId":     tabID,
+				"success":   err == nil,
+				"error":     message,
+				"completed": completed,
+				"total":     len(tabIDs),
+			}
+			resultsMu.Unlock()
+
+			if a.ctx != nil {
+				wailsRuntime.EventsEmit(a.ctx, "reconnect-all-progress", progress)
+			}
+		}(tabID)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// HotSwapSSHConnection replaces the SSH connection behind a tab without closing the tab, so the
+// terminal keeps its scrollback. Useful after credentials rotate (e.g. a new certificate was issued)
+// or the server moved. A nil newSSHConfig reconnects with the tab's current config.
+func (a *App) HotSwapSSHConnection(tabID string, newSSHConfig *SSHConfig) error {
+	a.terminal.mutex.RLock()
+	tab, exists := a.terminal.tabs[tabID]
+	var sessionID string
+	var currentConfig *SSHConfig
+	if exists {
+		sessionID = tab.SessionID
+		currentConfig = tab.SSHConfig
+	}
+	a.terminal.mutex.RUnlock()
+
+	if !exists {
+		return newNotFoundError(ErrCategoryTerminal, "HotSwapSSHConnection", "tab %s not found", tabID)
+	}
+	if tab.ConnectionType != "ssh" || currentConfig == nil {
+		return fmt.Errorf("tab %s is not an SSH connection", tabID)
+	}
+
+	if newSSHConfig == nil {
+		newSSHConfig = currentConfig
+	}
+	if err := newSSHConfig.Validate(); err != nil {
+		return fmt.Errorf("invalid SSH config: %w", err)
+	}
+
+	a.ssh.sshSessionsMutex.RLock()
+	oldSession, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+
+	if !exists || oldSession == nil {
+		return newNotFoundError(ErrCategorySSH, "HotSwapSSHConnection", "SSH session %s not found", sessionID)
+	}
+
+	// The new connection gets the old one's terminal size so the remote shell lays out the same
+	cols, rows := 80, 24
+	oldSession.mu.RLock()
+	if oldSession.cols > 0 && oldSession.rows > 0 {
+		cols, rows = oldSession.cols, oldSession.rows
+	}
+	oldSession.mu.RUnlock()
+
+	fmt.Printf("Hot-swapping SSH connection for session %s to %s@%s:%d\n", sessionID, newSSHConfig.Username, newSSHConfig.Host, newSSHConfig.Port)
+
+	newSession, err := a.CreateSSHSessionWithSize(sessionID, newSSHConfig, cols, rows)
+	if err != nil {
+		return fmt.Errorf("failed to create replacement SSH session: %w", err)
+	}
+
+	if err := a.StartSSHShell(newSession); err != nil {
+		a.retireSSHSession(newSession)
+		return fmt.Errorf("failed to start replacement SSH shell: %w", err)
+	}
+
+	// Swap only if nothing else replaced or closed the session while we were connecting
+	a.ssh.sshSessionsMutex.Lock()
+	if a.ssh.sshSessions[sessionID] != oldSession {
+		a.ssh.sshSessionsMutex.Unlock()
+		a.retireSSHSession(newSession)
+		return fmt.Errorf("SSH session %s changed during hot-swap", sessionID)
+	}
+	a.ssh.sshSessions[sessionID] = newSession
+	a.ssh.sshSessionsMutex.Unlock()
+
+	a.terminal.mutex.Lock()
+	tab.SSHConfig = newSSHConfig
+	a.terminal.mutex.Unlock()
+
+	// Create monitoring session in background (don't fail the swap if this fails)
+	go func() {
+		if err := a.CreateMonitoringSession(newSession, newSSHConfig); err != nil {
+			fmt.Printf("Warning: Failed to create monitoring session for %s: %v\n", sessionID, err)
+		}
+	}()
+
+	// Move an open file explorer onto the new connection
+	a.ssh.sftpClientsMutex.RLock()
+	oldSFTPClient, hasSFTP := a.ssh.sftpClients[sessionID]
+	a.ssh.sftpClientsMutex.RUnlock()
+	if hasSFTP {
+		if _, err := a.reconnectSFTPClientNoLock(sessionID, oldSFTPClient); err != nil {
+			fmt.Printf("Warning: Failed to move SFTP client to new connection: %v\n", err)
+		}
+	}
+
+	a.retireSSHSession(oldSession)
+
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, "ssh-connection-hot-swapped", map[string]interface{}{
+			"tabId":     tabID,
+			"sessionId": sessionID,
+			"host":      newSSHConfig.Host,
+			"port":      newSSHConfig.Port,
+			"username":  newSSHConfig.Username,
+		})
+	}
+
+	fmt.Printf("SSH connection hot-swapped for session %s\n", sessionID)
+	return nil
+}
+
 // ReorderTabs reorders tabs based on the provided tab IDs array
 func (a *App) ReorderTabs(tabIds []string) error {
 	a.terminal.mutex.Lock()
@@ -463,7 +688,7 @@ func (a *App) ReorderTabs(tabIds []string) error {
 	// Validate that all provided tab IDs exist
 	for _, tabId := range tabIds {
 		if _, exists := a.terminal.tabs[tabId]; !exists {
-			return fmt.Errorf("tab %s not found", tabId)
+			return newNotFoundError(ErrCategoryTerminal, "ReorderTabs", "tab %s not found", tabId)
 		}
 	}
 
@@ -487,12 +712,22 @@ func (a *App) ReorderTabs(tabIds []string) error {
 
 // CreateTabFromProfile creates a new tab using a profile
 func (a *App) CreateTabFromProfile(profileID string) (*Tab, error) {
+	return a.createTabFromProfile(profileID, true)
+}
+
+// createTabFromProfile opens a profile's tab. With runSequence, the profile's connection
+// sequence opens its other tabs in the background once this one exists.
+func (a *App) createTabFromProfile(profileID string, runSequence bool) (*Tab, error) {
 	a.profiles.mutex.RLock()
 	profile, exists := a.profiles.profiles[profileID]
+	var sequence []ConnectionStep
+	if exists {
+		sequence = append(sequence, profile.ConnectionSequence...)
+	}
 	a.profiles.mutex.RUnlock()
 
 	if !exists {
-		return nil, fmt.Errorf("profile not found: %s", profileID)
+		return nil, newNotFoundError(ErrCategoryProfile, "CreateTabFromProfile", "profile not found: %s", profileID)
 	}
 
 	// Update usage tracking
@@ -504,13 +739,38 @@ func (a *App) CreateTabFromProfile(profileID string) (*Tab, error) {
 	switch profile.Type {
 	case "ssh":
 		tab, err = a.CreateTab("", profile.SSHConfig)
+	case ProfileTypeNomadExec:
+		if profile.NomadConfig == nil {
+			return nil, fmt.Errorf("profile %s has no Nomad config", profileID)
+		}
+		tab, err = a.CreateTab("", nil)
+		if err == nil && tab != nil {
+			nomadConfig := *profile.NomadConfig
+			a.terminal.mutex.Lock()
+			tab.ConnectionType = ConnectionTypeNomadExec
+			tab.NomadConfig = &nomadConfig
+			tab.Title = profile.Name
+			a.terminal.mutex.Unlock()
+		}
 	default:
 		tab, err = a.CreateTab(profile.Shell, nil)
 	}
 
-	// Set the profile ID on the created tab
+	// Set the profile ID on the created tab and start it with the profile's tags
 	if err == nil && tab != nil {
+		a.terminal.mutex.Lock()
 		tab.ProfileID = profileID
+		tab.profileTitleFormat = profile.TitleFormat
+		a.terminal.mutex.Unlock()
+		a.refreshTabTitle(tab.ID)
+
+		if tagErr := a.AutoTagSession(tab.ID); tagErr != nil {
+			fmt.Printf("Warning: Failed to tag tab %s from profile %s: %v\n", tab.ID, profileID, tagErr)
+		}
+
+		if runSequence && len(sequence) > 0 {
+			go a.runConnectionSequence(tab, profileID, sequence)
+		}
 	}
 
 	return tab, err
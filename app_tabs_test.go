@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTabsToReconnect(t *testing.T) {
+	app := NewApp()
+	now := time.Now()
+	sshConfig := &SSHConfig{Host: "example.com", Port: 22, Username: "deploy"}
+	for _, tab := range []*Tab{
+		{ID: "failed", ConnectionType: ConnectionTypeSSH, SSHConfig: sshConfig, Status: "failed", Created: now.Add(2 * time.Second)},
+		{ID: "hanging", ConnectionType: ConnectionTypeSSH, SSHConfig: sshConfig, Status: StatusHanging, Created: now},
+		{ID: "connected", ConnectionType: ConnectionTypeSSH, SSHConfig: sshConfig, Status: "connected", Created: now},
+		{ID: "local", ConnectionType: ConnectionTypeLocal, Status: "disconnected", Created: now},
+		{ID: "dropped", ConnectionType: ConnectionTypeSSH, SSHConfig: sshConfig, Status: "disconnected", Created: now.Add(time.Second)},
+	} {
+		app.terminal.tabs[tab.ID] = tab
+	}
+
+	got := app.tabsToReconnect()
+	want := []string{"hanging", "dropped", "failed"}
+	if len(got) != len(want) {
+		t.Fatalf("tabsToReconnect() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("tabsToReconnect() = %v, want %v", got, want)
+		}
+	}
+}
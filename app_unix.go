@@ -3,6 +3,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -59,6 +60,10 @@ func (e *UnixError) Error() string {
 	return fmt.Sprintf("unix %s on %s: %v", e.Operation, e.Platform, e.Err)
 }
 
+func (e *UnixError) Unwrap() error {
+	return e.Err
+}
+
 // Unix shell detection cache
 type unixShellCache struct {
 	shells    []string
@@ -280,6 +285,18 @@ func configurePtyProcess(cmd *pty.Cmd) {
 	}
 }
 
+// hookShellCommand builds a command that runs a connect hook through sh. The hook gets its own
+// process group so cancelling it also stops anything it started.
+func hookShellCommand(ctx context.Context, command string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+	return cmd
+}
+
 // findWSLExecutable returns error on non-Windows platforms
 func findWSLExecutable() (string, error) {
 	return "", &UnixError{
@@ -3,6 +3,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -51,6 +52,10 @@ func (e *WindowsError) Error() string {
 	return fmt.Sprintf("windows %s: %v", e.Operation, e.Err)
 }
 
+func (e *WindowsError) Unwrap() error {
+	return e.Err
+}
+
 // Windows shell detection cache
 type shellCache struct {
 	shells    []string
@@ -475,6 +480,14 @@ func configurePtyProcess(cmd *pty.Cmd) {
 	cmd.SysProcAttr.CreationFlags = 0
 }
 
+// hookShellCommand builds a command that runs a connect hook through cmd.exe without a console window
+func hookShellCommand(ctx context.Context, command string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "cmd", "/C", command)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	cmd.WaitDelay = time.Second // Don't wait on output pipes held open by processes the hook started
+	return cmd
+}
+
 // findWSLExecutable finds WSL executable with proper validation
 func findWSLExecutable() (string, error) {
 	systemRoot := safeGetEnvironment("SystemRoot", "C:\\Windows")
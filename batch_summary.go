@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"sort"
+)
+
+// BatchSummaryPhase is the phase of the transfer event sent once a batch transfer finishes,
+// whether or not every file made it
+const BatchSummaryPhase = "batch-summary"
+
+// errTransferSkipped is the result of a job the transfer was cancelled before starting
+var errTransferSkipped = errors.New("transfer cancelled")
+
+// BatchFailure is a file a batch transfer couldn't move, and why
+type BatchFailure struct {
+	FileName string `json:"fileName"`
+	Path     string `json:"path"` // The remote file, the source of a download or target of an upload
+	Error    string `json:"error"`
+}
+
+// BatchSummary is the outcome of a batch transfer. Skipped files were never started, because
+// the batch was cancelled or stopped at an earlier failure.
+type BatchSummary struct {
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	Skipped   int            `json:"skipped"`
+	Failures  []BatchFailure `json:"failures"`
+}
+
+// summarizeBatch counts the results of a batch's jobs; a job without a result was skipped
+func summarizeBatch(jobs []TransferJob, results []TransferResult) BatchSummary {
+	summary := BatchSummary{Failures: []BatchFailure{}}
+	var failed []TransferResult
+	for _, result := range results {
+		switch {
+		case result.Error == nil:
+			summary.Succeeded++
+		case errors.Is(result.Error, errTransferSkipped):
+			summary.Skipped++
+		default:
+			failed = append(failed, result)
+		}
+	}
+	summary.Failed = len(failed)
+	summary.Skipped += len(jobs) - len(results)
+
+	// Workers finish in any order; list failures in the order the files were queued
+	sort.SliceStable(failed, func(i, j int) bool { return failed[i].Job.FileIndex < failed[j].Job.FileIndex })
+	for _, result := range failed {
+		summary.Failures = append(summary.Failures, BatchFailure{
+			FileName: result.Job.FileName,
+			Path:     result.Job.RemotePath,
+			Error:    result.Error.Error(),
+		})
+	}
+	return summary
+}
+
+// emitBatchSummary sends the batch-summary event for a finished upload or download batch
+func (a *App) emitBatchSummary(sessionID string, direction string, jobs []TransferJob, results []TransferResult) {
+	summary := summarizeBatch(jobs, results)
+	a.emitTransferEvent(sessionID, BatchSummaryPhase, direction, map[string]interface{}{
+		"totalFiles": len(jobs),
+		"succeeded":  summary.Succeeded,
+		"failed":     summary.Failed,
+		"skipped":    summary.Skipped,
+		"failures":   summary.Failures,
+	})
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSummarizeBatch(t *testing.T) {
+	jobs := make([]TransferJob, 5)
+	for i := range jobs {
+		name := string(rune('a' + i))
+		jobs[i] = TransferJob{FileName: name, RemotePath: "/srv/" + name, FileIndex: i + 1}
+	}
+	// Results arrive in the order workers finish; the last job never ran
+	results := []TransferResult{
+		{Job: jobs[2], Error: errors.New("permission denied")},
+		{Job: jobs[0]},
+		{Job: jobs[1], Error: errors.New("no space left on device")},
+		{Job: jobs[3], Error: errTransferSkipped},
+	}
+
+	summary := summarizeBatch(jobs, results)
+	if summary.Succeeded != 1 || summary.Failed != 2 || summary.Skipped != 2 {
+		t.Errorf("summarizeBatch() = %d succeeded, %d failed, %d skipped; want 1, 2, 2", summary.Succeeded, summary.Failed, summary.Skipped)
+	}
+	want := []BatchFailure{
+		{FileName: "b", Path: "/srv/b", Error: "no space left on device"},
+		{FileName: "c", Path: "/srv/c", Error: "permission denied"},
+	}
+	if len(summary.Failures) != len(want) {
+		t.Fatalf("Failures = %v, want %v", summary.Failures, want)
+	}
+	for i := range want {
+		if summary.Failures[i] != want[i] {
+			t.Errorf("Failures[%d] = %+v, want %+v", i, summary.Failures[i], want[i])
+		}
+	}
+
+	// A clean batch still lists its failures as an empty array for the frontend
+	if clean := summarizeBatch(jobs[:1], results[1:2]); clean.Failures == nil || clean.Succeeded != 1 {
+		t.Errorf("summarizeBatch() of a clean batch = %+v", clean)
+	}
+}
+
+func TestExecuteParallelDownloadsReturnsEveryResult(t *testing.T) {
+	app := NewApp()
+	sessionID := "session_batch_results"
+	client := newLatencySFTPClient(t, 0, 0)
+	app.ssh.sftpClients[sessionID] = client
+	defer app.ReleaseSession(sessionID)
+
+	file, err := client.Create("/present.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte("data"))
+	file.Close()
+
+	dir := t.TempDir()
+	jobs := []TransferJob{
+		{RemotePath: "/present.txt", LocalPath: filepath.Join(dir, "present.txt"), FileName: "present.txt", FileIndex: 1, FileSize: 4},
+		{RemotePath: "/missing.txt", LocalPath: filepath.Join(dir, "missing.txt"), FileName: "missing.txt", FileIndex: 2},
+	}
+	results, err := app.executeParallelDownloads(sessionID, client, jobs, 2)
+	if err == nil {
+		t.Error("executeParallelDownloads() hid the failed download")
+	}
+	summary := summarizeBatch(jobs, results)
+	if summary.Succeeded != 1 || summary.Failed != 1 || summary.Failures[0].FileName != "missing.txt" {
+		t.Errorf("summary = %+v, want present.txt done and missing.txt failed", summary)
+	}
+}
@@ -21,6 +21,24 @@ const (
 	MaxWindowHeight    = 10000 // Arbitrary large value for upper bound
 	MinScrollbackLines = 100
 	MaxScrollbackLines = 100000
+
+	DefaultInlineImageMaxBytes = 8 * 1024 * 1024
+	MinInlineImageMaxBytes     = 64 * 1024
+	MaxInlineImageMaxBytes     = 64 * 1024 * 1024
+
+	DefaultClosedTabExpiryMinutes = 24 * 60
+	MinClosedTabExpiryMinutes     = 1
+	MaxClosedTabExpiryMinutes     = 7 * 24 * 60
+	DefaultIdleNotifySeconds      = 5
+	MinIdleNotifySeconds          = 1
+	MaxIdleNotifySeconds          = 10 * 60
+
+	// MaxConnectionsPerHost is unlimited by default
+	DefaultMaxConnectionsPerHost = 0
+
+	// MinVisibleWindowPixels is how much of the window's top edge must land on a display
+	// for a saved position to be restored
+	MinVisibleWindowPixels = 100
 )
 
 // ThemeSystem represents the system theme preference.
@@ -55,11 +73,17 @@ type AIConfig struct {
 
 // SFTPConfig holds SFTP transfer optimization settings
 type SFTPConfig struct {
-	MaxPacketSize      int  `yaml:"max_packet_size"`     // Maximum SFTP packet size in bytes (default: 256KB)
-	BufferSize         int  `yaml:"buffer_size"`         // Transfer buffer size in bytes (default: 1MB)
-	ConcurrentRequests int  `yaml:"concurrent_requests"` // Concurrent requests per file (default: 64)
-	ParallelTransfers  int  `yaml:"parallel_transfers"`  // Number of parallel file transfers (default: 4)
-	UseConcurrentIO    bool `yaml:"use_concurrent_io"`   // Enable concurrent reads/writes (default: true)
+	MaxPacketSize      int    `yaml:"max_packet_size"`     // Maximum SFTP packet size in bytes (default: 256KB)
+	BufferSize         int    `yaml:"buffer_size"`         // Transfer buffer size in bytes (default: 1MB)
+	ConcurrentRequests int    `yaml:"concurrent_requests"` // Concurrent requests per file (default: 64)
+	ParallelTransfers  int    `yaml:"parallel_transfers"`  // Number of parallel file transfers (default: 4)
+	ClientPoolSize     int    `yaml:"client_pool_size"`    // SFTP channels per session that parallel transfers spread over (default: 1)
+	UseConcurrentIO    bool   `yaml:"use_concurrent_io"`   // Enable concurrent reads/writes (default: true)
+	AutoTune           bool   `yaml:"auto_tune"`           // Pick the requests in flight per host from measured throughput; a hand-set ConcurrentRequests wins
+	AutoTuneBuffer     bool   `yaml:"auto_tune_buffer"`    // Size BufferSize per session from a short upload benchmark instead of the setting
+	MaxPreviewSize     int64  `yaml:"max_preview_size"`    // Largest file opened in the editor, in bytes (default: 10MB)
+	DownloadMargin     int64  `yaml:"download_margin"`     // Free space a directory download must leave on the local disk, in bytes (default: 1GB)
+	DirectoryMode      uint32 `yaml:"directory_mode"`      // Permissions of new remote directories, e.g. 0750; 0 leaves them to the server's umask
 }
 
 // SFTP configuration constants
@@ -76,6 +100,37 @@ const (
 	MaxSFTPConcurrentRequests     = 128
 	MinSFTPParallelTransfers      = 1
 	MaxSFTPParallelTransfers      = 16
+	DefaultSFTPClientPoolSize     = 1                       // One channel shared by every transfer
+	MaxSFTPClientPoolSize         = 4                       // Each channel is a server-side sftp-server process
+	DefaultSFTPMaxPreviewSize     = 10 * 1024 * 1024        // 10MB - larger files are offered as a download
+	MinSFTPMaxPreviewSize         = 64 * 1024               // 64KB minimum
+	MaxSFTPMaxPreviewSize         = 256 * 1024 * 1024       // 256MB maximum - the content is held in memory twice
+	DefaultSFTPDownloadMargin     = 1024 * 1024 * 1024      // 1GB - room for the OS and other apps after a download
+	MaxSFTPDownloadMargin         = 64 * 1024 * 1024 * 1024 // 64GB maximum
+	MaxSFTPDirectoryMode          = 07777                   // Permission, setuid, setgid and sticky bits only
+)
+
+// PrivacyLockConfig holds terminal privacy lock settings
+type PrivacyLockConfig struct {
+	Enabled      bool   `yaml:"enabled"`                 // Lock automatically after the idle timeout
+	IdleTimeout  int    `yaml:"idle_timeout"`            // Seconds without user activity before locking
+	BufferLimit  int    `yaml:"buffer_limit"`            // Max bytes of output held per session while locked
+	OverflowMode string `yaml:"overflow_mode"`           // What to do when the buffer fills: "drop" or "suspend"
+	PasswordHash string `yaml:"password_hash,omitempty"` // bcrypt hash of the unlock password (empty = no password)
+}
+
+// Privacy lock configuration constants
+const (
+	PrivacyOverflowDrop    = "drop"    // Keep the newest output, discard the oldest
+	PrivacyOverflowSuspend = "suspend" // Keep the oldest output, stop buffering once full
+
+	DefaultPrivacyIdleTimeout  = 300        // 5 minutes
+	DefaultPrivacyBufferLimit  = 256 * 1024 // 256KB per session
+	DefaultPrivacyOverflowMode = PrivacyOverflowDrop
+	MinPrivacyIdleTimeout      = 10
+	MaxPrivacyIdleTimeout      = 24 * 60 * 60
+	MinPrivacyBufferLimit      = 4 * 1024
+	MaxPrivacyBufferLimit      = 16 * 1024 * 1024
 )
 
 // AppConfig holds the application configuration
@@ -83,9 +138,14 @@ type AppConfig struct {
 	WindowWidth     int            `yaml:"window_width"`
 	WindowHeight    int            `yaml:"window_height"`
 	WindowMaximized bool           `yaml:"window_maximized"`
+	WindowX         *int           `yaml:"window_x,omitempty"` // nil until a position has been saved (window is centered)
+	WindowY         *int           `yaml:"window_y,omitempty"`
+	WindowScreen    string         `yaml:"window_screen,omitempty"` // Display the position is relative to, see screenIdentity
 	DefaultShell    string         `yaml:"default_shell,omitempty"` // Legacy field for migration only
 	DefaultShells   PlatformShells `yaml:"default_shells"`          // Platform-specific default shells
 	ProfilesPath    string         `yaml:"profiles_path,omitempty"` // Custom path for profiles directory
+	// Additional profile directories merged with ProfilesPath (e.g. a shared team repo)
+	ProfileSources []ProfileSource `yaml:"profile_sources,omitempty"`
 	// Context menu settings
 	EnableSelectToCopy bool `yaml:"enable_select_to_copy"` // Enable select-to-copy and right-click-to-paste (disables context menu)
 	// Sidebar settings
@@ -98,10 +158,47 @@ type AppConfig struct {
 	// Terminal settings
 	ScrollbackLines            int  `yaml:"scrollback_lines"`               // Number of lines to keep in scrollback buffer
 	OpenLinksInExternalBrowser bool `yaml:"open_links_in_external_browser"` // Open URLs in external browser instead of in-app
+	InlineImageMaxBytes        int  `yaml:"inline_image_max_bytes"`         // Largest inline image sequence passed to the terminal (0 = default)
+	ClosedTabExpiryMinutes     int  `yaml:"closed_tab_expiry_minutes"`      // How long a closed tab can be reopened (0 = default)
+	BellNotifications          bool `yaml:"bell_notifications"`             // Show an OS notification when a tab in the background rings the bell or goes idle
+	IdleNotifySeconds          int  `yaml:"idle_notify_seconds"`            // Silence after which a tab watched for activity counts as idle (0 = default)
+	// SSH settings
+	// VerifyHostKeyDNS checks SSHFP DNS records for hosts missing from known_hosts. The nameservers
+	// come from /etc/resolv.conf, so on Windows every new host goes through the first-connect prompt.
+	VerifyHostKeyDNS bool        `yaml:"verify_host_key_dns"`
+	Proxy            ProxyConfig `yaml:"proxy"` // Proxy for outbound SSH connections; profiles may override it
+	// MaxConnectionsPerHost caps simultaneous connections to one host; more wait for one to close (0 = unlimited)
+	MaxConnectionsPerHost int `yaml:"max_connections_per_host"`
+	// AutoReconnectOnNetworkChange reconnects SSH tabs that stop answering after a network change
+	AutoReconnectOnNetworkChange bool `yaml:"auto_reconnect_on_network_change"`
+	// Update settings
+	DisableUpdateCheck bool `yaml:"disable_update_check"` // Never contact the release feed
+	// Profile tree settings
+	DisableProfileProbes bool `yaml:"disable_profile_probes"` // Never run reachability probes, even for profiles that opted in
+	// AllowProfileConnectCommands runs profiles' PreConnectCommand/PostConnectCommand and their
+	// local pre/post-connect hooks. They are arbitrary local commands: only enable this if every profile, imported ones
+	// included, comes from a source you trust.
+	AllowProfileConnectCommands bool `yaml:"allow_profile_connect_commands"`
+	// Secret settings
+	SessionSecrets []string `yaml:"session_secrets,omitempty"` // Keychain secret names allowed to be sent to sessions
+	// Discovery settings
+	DiscoveryProviders []DiscoveryProviderConfig `yaml:"discovery_providers,omitempty"` // Sources of hosts that can be turned into profiles
+	// EnableMDNSDiscovery allows DiscoverLocalSSHHosts, which sends multicast DNS queries to the local network
+	EnableMDNSDiscovery bool `yaml:"enable_mdns_discovery"`
 	// AI settings
 	AI AIConfig `yaml:"ai"` // AI configuration
 	// SFTP settings
 	SFTP SFTPConfig `yaml:"sftp"` // SFTP transfer optimization settings
+	// Privacy lock settings
+	PrivacyLock PrivacyLockConfig `yaml:"privacy_lock"` // Idle lock that hides terminal output
+}
+
+// ProfileSource is an extra directory profiles are loaded from.
+// Read-only sources are never written to; usage for their profiles is tracked in local metrics.
+type ProfileSource struct {
+	Path     string `yaml:"path" json:"path"`
+	ReadOnly bool   `yaml:"read_only" json:"readOnly"`
+	Label    string `yaml:"label" json:"label"`
 }
 
 // defaultProfilesPath returns the resolved default profiles directory path
@@ -138,6 +235,20 @@ func DefaultConfig() *AppConfig {
 		// Default terminal settings
 		ScrollbackLines:            DefaultScrollbackLines,
 		OpenLinksInExternalBrowser: true, // Default to opening links in external browser
+		InlineImageMaxBytes:        DefaultInlineImageMaxBytes,
+		ClosedTabExpiryMinutes:     DefaultClosedTabExpiryMinutes,
+		// Default SSH settings
+		VerifyHostKeyDNS:      false, // SSHFP verification is opt-in
+		Proxy:                 ProxyConfig{Mode: ProxyModeNone},
+		MaxConnectionsPerHost: DefaultMaxConnectionsPerHost,
+		// Dead sessions are only marked after a network change unless this is enabled
+		AutoReconnectOnNetworkChange: false,
+		// Default update settings
+		DisableUpdateCheck: false,
+		// Default profile tree settings
+		DisableProfileProbes: false,
+		// Profile connect commands are opt-in
+		AllowProfileConnectCommands: false,
 		// Default AI settings
 		AI: AIConfig{
 			Enabled:  false,
@@ -186,8 +297,18 @@ Always respond with raw commands only. No explanations. No formatting. Just comm
 			BufferSize:         DefaultSFTPBufferSize,
 			ConcurrentRequests: DefaultSFTPConcurrentRequests,
 			ParallelTransfers:  DefaultSFTPParallelTransfers,
+			ClientPoolSize:     DefaultSFTPClientPoolSize,
+			MaxPreviewSize:     DefaultSFTPMaxPreviewSize,
+			DownloadMargin:     DefaultSFTPDownloadMargin,
 			UseConcurrentIO:    true,
 		},
+		// Default privacy lock settings (disabled until the user opts in)
+		PrivacyLock: PrivacyLockConfig{
+			Enabled:      false,
+			IdleTimeout:  DefaultPrivacyIdleTimeout,
+			BufferLimit:  DefaultPrivacyBufferLimit,
+			OverflowMode: DefaultPrivacyOverflowMode,
+		},
 	}
 }
 
@@ -223,6 +344,24 @@ func (c *AppConfig) Validate() error {
 	if len(c.ProfilesPath) > 1024 { // Arbitrary length limit for sanity
 		return fmt.Errorf("profiles path is too long (max 1024 characters)")
 	}
+	seenSourceLabels := make(map[string]bool)
+	for _, source := range c.ProfileSources {
+		if source.Path == "" {
+			return fmt.Errorf("profile source path cannot be empty")
+		}
+		if len(source.Path) > 1024 {
+			return fmt.Errorf("profile source path is too long (max 1024 characters)")
+		}
+		if source.Label == PrimaryProfileSourceLabel {
+			return fmt.Errorf("profile source label %q is reserved for the primary profiles directory", source.Label)
+		}
+		if source.Label != "" {
+			if seenSourceLabels[source.Label] {
+				return fmt.Errorf("duplicate profile source label: %s", source.Label)
+			}
+			seenSourceLabels[source.Label] = true
+		}
+	}
 	// Note: Validation for shell paths within c.DefaultShells would also be beneficial here or in ConfigManager.
 
 	// AI configuration validation
@@ -255,6 +394,69 @@ func (c *AppConfig) Validate() error {
 	if c.SFTP.ParallelTransfers < MinSFTPParallelTransfers || c.SFTP.ParallelTransfers > MaxSFTPParallelTransfers {
 		return fmt.Errorf("SFTP parallel transfers %d is out of range (%d-%d)", c.SFTP.ParallelTransfers, MinSFTPParallelTransfers, MaxSFTPParallelTransfers)
 	}
+	// Zero falls back to the default for configs written before the setting existed
+	if c.SFTP.ClientPoolSize != 0 && (c.SFTP.ClientPoolSize < 1 || c.SFTP.ClientPoolSize > MaxSFTPClientPoolSize) {
+		return fmt.Errorf("SFTP client pool size %d is out of range (1-%d)", c.SFTP.ClientPoolSize, MaxSFTPClientPoolSize)
+	}
+	if c.SFTP.MaxPreviewSize != 0 && (c.SFTP.MaxPreviewSize < MinSFTPMaxPreviewSize || c.SFTP.MaxPreviewSize > MaxSFTPMaxPreviewSize) {
+		return fmt.Errorf("SFTP max preview size %d is out of range (%d-%d)", c.SFTP.MaxPreviewSize, MinSFTPMaxPreviewSize, MaxSFTPMaxPreviewSize)
+	}
+	if c.SFTP.DownloadMargin < 0 || c.SFTP.DownloadMargin > MaxSFTPDownloadMargin {
+		return fmt.Errorf("SFTP download margin %d is out of range (0-%d)", c.SFTP.DownloadMargin, int64(MaxSFTPDownloadMargin))
+	}
+	if c.SFTP.DirectoryMode > MaxSFTPDirectoryMode {
+		return fmt.Errorf("SFTP directory mode %#o is out of range (0-%#o)", c.SFTP.DirectoryMode, MaxSFTPDirectoryMode)
+	}
+
+	// Zero falls back to the default for configs written before the setting existed
+	if c.InlineImageMaxBytes != 0 && (c.InlineImageMaxBytes < MinInlineImageMaxBytes || c.InlineImageMaxBytes > MaxInlineImageMaxBytes) {
+		return fmt.Errorf("inline image max bytes %d is out of range (%d-%d)", c.InlineImageMaxBytes, MinInlineImageMaxBytes, MaxInlineImageMaxBytes)
+	}
+	if c.ClosedTabExpiryMinutes != 0 && (c.ClosedTabExpiryMinutes < MinClosedTabExpiryMinutes || c.ClosedTabExpiryMinutes > MaxClosedTabExpiryMinutes) {
+		return fmt.Errorf("closed tab expiry %d minutes is out of range (%d-%d)", c.ClosedTabExpiryMinutes, MinClosedTabExpiryMinutes, MaxClosedTabExpiryMinutes)
+	}
+	if c.IdleNotifySeconds != 0 && (c.IdleNotifySeconds < MinIdleNotifySeconds || c.IdleNotifySeconds > MaxIdleNotifySeconds) {
+		return fmt.Errorf("idle notify delay %d seconds is out of range (%d-%d)", c.IdleNotifySeconds, MinIdleNotifySeconds, MaxIdleNotifySeconds)
+	}
+	if c.MaxConnectionsPerHost < 0 || c.MaxConnectionsPerHost > MaxHostConnectionLimit {
+		return fmt.Errorf("max connections per host %d is out of range (0-%d)", c.MaxConnectionsPerHost, MaxHostConnectionLimit)
+	}
+
+	// Privacy lock validation (zero values fall back to defaults for older configs)
+	if err := c.PrivacyLock.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Proxy.Validate(); err != nil {
+		return err
+	}
 
+	seenProviderIDs := make(map[string]bool, len(c.DiscoveryProviders))
+	for i := range c.DiscoveryProviders {
+		if err := c.DiscoveryProviders[i].Validate(); err != nil {
+			return err
+		}
+		if seenProviderIDs[c.DiscoveryProviders[i].ID] {
+			return fmt.Errorf("duplicate discovery provider ID: %s", c.DiscoveryProviders[i].ID)
+		}
+		seenProviderIDs[c.DiscoveryProviders[i].ID] = true
+	}
+
+	return nil
+}
+
+// Validate checks the privacy lock settings. Zero values are allowed and mean "use the default".
+func (c *PrivacyLockConfig) Validate() error {
+	if c.IdleTimeout != 0 && (c.IdleTimeout < MinPrivacyIdleTimeout || c.IdleTimeout > MaxPrivacyIdleTimeout) {
+		return fmt.Errorf("privacy lock idle timeout %d is out of range (%d-%d)", c.IdleTimeout, MinPrivacyIdleTimeout, MaxPrivacyIdleTimeout)
+	}
+	if c.BufferLimit != 0 && (c.BufferLimit < MinPrivacyBufferLimit || c.BufferLimit > MaxPrivacyBufferLimit) {
+		return fmt.Errorf("privacy lock buffer limit %d is out of range (%d-%d)", c.BufferLimit, MinPrivacyBufferLimit, MaxPrivacyBufferLimit)
+	}
+	switch c.OverflowMode {
+	case "", PrivacyOverflowDrop, PrivacyOverflowSuspend:
+	default:
+		return fmt.Errorf("invalid privacy lock overflow mode: '%s'. Allowed modes are: %s, %s", c.OverflowMode, PrivacyOverflowDrop, PrivacyOverflowSuspend)
+	}
 	return nil
 }
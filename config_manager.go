@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"time"
 
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
@@ -34,6 +35,10 @@ func (e *ConfigError) Error() string {
 	return fmt.Sprintf("config %s: %v", e.Op, e.Err)
 }
 
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
 // getConfigPath returns the full path to the config file
 func (a *App) getConfigPath() (string, error) {
 	configDir, err := os.UserConfigDir()
@@ -78,6 +83,44 @@ func (a *App) createConfigBackup(configPath, backupPath string) error {
 	return nil
 }
 
+// createResetBackup copies the config file to a new timestamped backup and returns its path.
+// Resets within the same second get a numbered suffix instead of overwriting an earlier backup.
+func createResetBackup(configPath string) (string, error) {
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return "", nil // Nothing to back up
+	}
+	if err != nil {
+		return "", &ConfigError{Op: "read_for_backup", Path: configPath, Err: err}
+	}
+
+	base := fmt.Sprintf("%s.reset-%s", configPath, time.Now().Format("20060102-150405"))
+	for attempt := 0; ; attempt++ {
+		backupPath := base + ".backup"
+		if attempt > 0 {
+			backupPath = fmt.Sprintf("%s-%d.backup", base, attempt)
+		}
+
+		file, err := os.OpenFile(backupPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, ConfigFileMode)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", &ConfigError{Op: "write_backup", Path: backupPath, Err: err}
+		}
+
+		_, err = file.Write(data)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(backupPath)
+			return "", &ConfigError{Op: "write_backup", Path: backupPath, Err: err}
+		}
+		return backupPath, nil
+	}
+}
+
 // writeConfigToFile writes the config to a specific file path
 func (a *App) writeConfigToFile(filePath string) error {
 	if a.config == nil || a.config.config == nil {
@@ -101,6 +144,16 @@ func (a *App) writeConfigToFile(filePath string) error {
 	return nil
 }
 
+// ensureConfigLoaded loads the config file the first time it is called.
+// It runs before wails.Run so the saved window state can be applied at window creation.
+func (a *App) ensureConfigLoaded() {
+	a.config.loadOnce.Do(func() {
+		if err := a.loadConfig(); err != nil {
+			fmt.Println("Error loading config:", err)
+		}
+	})
+}
+
 // loadConfig loads configuration from file or creates default
 func (a *App) loadConfig() error {
 	configPath, err := a.getConfigPath()
@@ -128,21 +181,27 @@ func (a *App) loadConfig() error {
 		return nil
 	}
 
-	if err := yaml.Unmarshal(data, a.config.config); err != nil {
+	// Parse onto defaults so fields missing from older files keep their default values
+	loaded := DefaultConfig()
+	if err := yaml.Unmarshal(data, loaded); err != nil {
 		fmt.Printf("Warning: Failed to parse config file %s: %v. Using default config.\n", configPath, err)
-		a.config.config = DefaultConfig() // Reset to default on parse error
+		a.replaceConfig(DefaultConfig()) // Reset to default on parse error
 		return nil
 	}
 
 	// Validate loaded config
-	if err := a.config.config.Validate(); err != nil {
+	if err := loaded.Validate(); err != nil {
 		fmt.Printf("Warning: Invalid config loaded from %s: %v. Using default config.\n", configPath, err)
-		a.config.config = DefaultConfig() // Reset to default on validation error
+		a.replaceConfig(DefaultConfig()) // Reset to default on validation error
 		return nil
 	}
+	a.replaceConfig(loaded)
 
 	// Migrate legacy configuration to platform-specific format
-	if migrated := a.migrateLegacyConfig(); migrated {
+	a.config.mutex.Lock()
+	migrated := a.migrateLegacyConfig()
+	a.config.mutex.Unlock()
+	if migrated {
 		fmt.Println("Migrated legacy shell configuration to platform-specific format")
 		a.markConfigDirty() // Save the migrated config
 	}
@@ -151,6 +210,13 @@ func (a *App) loadConfig() error {
 	return nil
 }
 
+// replaceConfig swaps in a whole new configuration
+func (a *App) replaceConfig(cfg *AppConfig) {
+	a.config.mutex.Lock()
+	defer a.config.mutex.Unlock()
+	a.config.config = cfg
+}
+
 // saveConfigAtomic saves the config using atomic operations with backup
 func (a *App) saveConfigAtomic() error {
 	configPath, err := a.getConfigPath()
@@ -250,7 +316,11 @@ func (a *App) updateWindowState() bool {
 	width, height := wailsRuntime.WindowGetSize(a.ctx)
 	isMaximized := wailsRuntime.WindowIsMaximised(a.ctx)
 
-	configChanged := false
+	// Position needs its own runtime calls, so it is captured before taking the config lock
+	configChanged := a.captureWindowPosition()
+
+	a.config.mutex.Lock()
+	defer a.config.mutex.Unlock()
 
 	if a.config.config.WindowWidth != width || a.config.config.WindowHeight != height {
 		a.config.config.WindowWidth = width
@@ -310,10 +380,12 @@ type SettingConfig struct {
 	RequiresEvent bool
 	EventName     string
 
+	// RequiresRestart marks settings that are only read at startup
+	RequiresRestart bool
+
 	// Update options
-	ConfigField   string                                 // Field name in config struct
-	RequiresMutex bool                                   // Whether this field requires mutex locking
-	CustomUpdate  func(a *App, value SettingValue) error // Only for special cases
+	ConfigField  string                                 // Field name in config struct
+	CustomUpdate func(a *App, value SettingValue) error // Only for special cases; must take config.mutex for its own writes
 }
 
 // Validate validates a setting value according to its configuration
@@ -385,7 +457,8 @@ func (c *SettingConfig) Validate(value SettingValue) error {
 	return nil
 }
 
-// Update updates the setting value using universal logic or custom function
+// Update updates the setting value using universal logic or custom function.
+// Every config field write happens under config.mutex.
 func (c *SettingConfig) Update(a *App, value SettingValue) error {
 	// Use custom update function if provided
 	if c.CustomUpdate != nil {
@@ -397,12 +470,6 @@ func (c *SettingConfig) Update(a *App, value SettingValue) error {
 		return fmt.Errorf("no config field or custom update function defined for setting %s", c.Name)
 	}
 
-	// Apply mutex if required
-	if c.RequiresMutex {
-		a.config.mutex.Lock()
-		defer a.config.mutex.Unlock()
-	}
-
 	// Convert value to proper type for integers (handle JavaScript float64)
 	if c.Type == SettingTypeInt {
 		switch val := value.(type) {
@@ -415,84 +482,130 @@ func (c *SettingConfig) Update(a *App, value SettingValue) error {
 		}
 	}
 
-	// Update the appropriate config field
-	switch c.ConfigField {
-	case "EnableSelectToCopy":
-		a.config.config.EnableSelectToCopy = value.(bool)
-	case "ProfilesPath":
-		path := value.(string)
-		if a.config.config.ProfilesPath != path {
-			// Stop existing watcher before changing path
-			a.StopProfileWatcher()
-
-			a.config.config.ProfilesPath = path
-			fmt.Printf("Profiles path updated to: %s\n", path)
-
-			// Ensure new directory exists
-			if path != "" {
-				if err := os.MkdirAll(path, ConfigDirMode); err != nil {
-					fmt.Printf("Warning: Failed to create profiles directory %s: %v\n", path, err)
-				}
-			}
-
-			// Reload profiles from new path
-			if err := a.LoadProfiles(); err != nil {
-				fmt.Printf("Warning: Failed to reload profiles from new path: %v\n", err)
-			}
+	// Changing the profiles path reloads profiles, which must not run under the config lock
+	if c.ConfigField == "ProfilesPath" {
+		return a.updateProfilesPath(value.(string))
+	}
 
-			// Create default profiles if the new folder is empty
-			if len(a.profiles.profiles) == 0 {
-				fmt.Println("No profiles found in new path, creating defaults...")
-				if err := a.CreateDefaultProfiles(); err != nil {
-					fmt.Printf("Warning: Failed to create default profiles: %v\n", err)
-				}
-			}
+	a.config.mutex.Lock()
+	err := c.setField(a.config.config, value)
+	a.config.mutex.Unlock()
+	if err != nil {
+		return err
+	}
 
-			// Restart watcher on new directory
-			if err := a.StartProfileWatcher(); err != nil {
-				fmt.Printf("Warning: Failed to restart profile watcher: %v\n", err)
-			}
+	fmt.Printf("%s updated to: %v\n", c.Name, value)
+	return nil
+}
 
-			// Emit event to frontend so sidebar refreshes automatically
-			if a.ctx != nil {
-				wailsRuntime.EventsEmit(a.ctx, "profiles:reloaded")
-			}
-		}
-		return nil
+// setField writes a value to the mapped config field. Caller must hold config.mutex.
+func (c *SettingConfig) setField(cfg *AppConfig, value SettingValue) error {
+	switch c.ConfigField {
+	case "EnableSelectToCopy":
+		cfg.EnableSelectToCopy = value.(bool)
 	case "SidebarCollapsed":
-		a.config.config.SidebarCollapsed = value.(bool)
+		cfg.SidebarCollapsed = value.(bool)
+	case "VerifyHostKeyDNS":
+		cfg.VerifyHostKeyDNS = value.(bool)
+	case "AutoReconnectOnNetworkChange":
+		cfg.AutoReconnectOnNetworkChange = value.(bool)
+	case "DisableUpdateCheck":
+		cfg.DisableUpdateCheck = value.(bool)
+	case "DisableProfileProbes":
+		cfg.DisableProfileProbes = value.(bool)
+	case "AllowProfileConnectCommands":
+		cfg.AllowProfileConnectCommands = value.(bool)
+	case "EnableMDNSDiscovery":
+		cfg.EnableMDNSDiscovery = value.(bool)
 	case "SidebarWidth":
-		a.config.config.SidebarWidth = value.(int)
+		cfg.SidebarWidth = value.(int)
 	case "SidebarProfilesWidth":
-		a.config.config.SidebarProfilesWidth = value.(int)
+		cfg.SidebarProfilesWidth = value.(int)
 	case "SidebarFilesWidth":
-		a.config.config.SidebarFilesWidth = value.(int)
+		cfg.SidebarFilesWidth = value.(int)
 	case "Theme":
-		a.config.config.Theme = value.(string)
+		cfg.Theme = value.(string)
 	case "ScrollbackLines":
-		a.config.config.ScrollbackLines = value.(int)
+		cfg.ScrollbackLines = value.(int)
+	case "InlineImageMaxBytes":
+		cfg.InlineImageMaxBytes = value.(int)
+	case "ClosedTabExpiryMinutes":
+		cfg.ClosedTabExpiryMinutes = value.(int)
+	case "IdleNotifySeconds":
+		cfg.IdleNotifySeconds = value.(int)
+	case "BellNotifications":
+		cfg.BellNotifications = value.(bool)
+	case "MaxConnectionsPerHost":
+		cfg.MaxConnectionsPerHost = value.(int)
 	case "OpenLinksInExternalBrowser":
-		a.config.config.OpenLinksInExternalBrowser = value.(bool)
+		cfg.OpenLinksInExternalBrowser = value.(bool)
 
 	// AI Configuration Fields
 	case "AI.Enabled":
-		a.config.config.AI.Enabled = value.(bool)
+		cfg.AI.Enabled = value.(bool)
 	case "AI.Provider":
-		a.config.config.AI.Provider = value.(string)
+		cfg.AI.Provider = value.(string)
 	case "AI.APIKey":
-		a.config.config.AI.APIKey = value.(string)
+		cfg.AI.APIKey = value.(string)
 	case "AI.APIURL":
-		a.config.config.AI.APIURL = value.(string)
+		cfg.AI.APIURL = value.(string)
 	case "AI.ModelID":
-		a.config.config.AI.ModelID = value.(string)
+		cfg.AI.ModelID = value.(string)
 	case "AI.Hotkey":
-		a.config.config.AI.Hotkey = value.(string)
+		cfg.AI.Hotkey = value.(string)
 
 	default:
 		return fmt.Errorf("unknown config field: %s", c.ConfigField)
 	}
+	return nil
+}
 
-	fmt.Printf("%s updated to: %v\n", c.Name, value)
+// updateProfilesPath switches the profiles directory and reloads profiles from it
+func (a *App) updateProfilesPath(path string) error {
+	a.config.mutex.Lock()
+	if a.config.config.ProfilesPath == path {
+		a.config.mutex.Unlock()
+		return nil
+	}
+	a.config.mutex.Unlock()
+
+	// Stop existing watcher before changing path
+	a.StopProfileWatcher()
+
+	a.config.mutex.Lock()
+	a.config.config.ProfilesPath = path
+	a.config.mutex.Unlock()
+	fmt.Printf("Profiles path updated to: %s\n", path)
+
+	// Ensure new directory exists
+	if path != "" {
+		if err := os.MkdirAll(path, ConfigDirMode); err != nil {
+			fmt.Printf("Warning: Failed to create profiles directory %s: %v\n", path, err)
+		}
+	}
+
+	// Reload profiles from new path
+	if err := a.LoadProfiles(); err != nil {
+		fmt.Printf("Warning: Failed to reload profiles from new path: %v\n", err)
+	}
+
+	// Create default profiles if the new folder is empty
+	if len(a.profiles.profiles) == 0 {
+		fmt.Println("No profiles found in new path, creating defaults...")
+		if err := a.CreateDefaultProfiles(); err != nil {
+			fmt.Printf("Warning: Failed to create default profiles: %v\n", err)
+		}
+	}
+
+	// Restart watcher on new directory
+	if err := a.StartProfileWatcher(); err != nil {
+		fmt.Printf("Warning: Failed to restart profile watcher: %v\n", err)
+	}
+
+	// Emit event to frontend so sidebar refreshes automatically
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, "profiles:reloaded")
+	}
 	return nil
 }
 
@@ -507,6 +620,10 @@ func (c *SettingConfig) GetEventData(value SettingValue) map[string]interface{}
 // Custom update function for DefaultShell (needs platform-specific handling)
 func updateDefaultShell(a *App, value SettingValue) error {
 	shellPath := value.(string)
+
+	a.config.mutex.Lock()
+	defer a.config.mutex.Unlock()
+
 	currentShell := a.getPlatformDefaultShell()
 	if currentShell != shellPath {
 		a.setPlatformDefaultShell(shellPath)
@@ -518,7 +635,9 @@ func updateDefaultShell(a *App, value SettingValue) error {
 // Custom update function for AI settings that also updates the AI manager
 func updateAIEnabledSetting(a *App, value SettingValue) error {
 	enabled := value.(bool)
+	a.config.mutex.Lock()
 	a.config.config.AI.Enabled = enabled
+	a.config.mutex.Unlock()
 
 	// Update AI manager with new config if available
 	if a.ai != nil {
@@ -534,7 +653,9 @@ func updateAIEnabledSetting(a *App, value SettingValue) error {
 // Custom update function for AI provider that also updates the AI manager
 func updateAIProviderSetting(a *App, value SettingValue) error {
 	provider := value.(string)
+	a.config.mutex.Lock()
 	a.config.config.AI.Provider = provider
+	a.config.mutex.Unlock()
 
 	// Update AI manager with new config if available
 	if a.ai != nil {
@@ -550,7 +671,9 @@ func updateAIProviderSetting(a *App, value SettingValue) error {
 // Custom update function for AI API key that also updates the AI manager
 func updateAIAPIKeySetting(a *App, value SettingValue) error {
 	apiKey := value.(string)
+	a.config.mutex.Lock()
 	a.config.config.AI.APIKey = apiKey
+	a.config.mutex.Unlock()
 
 	// Update AI manager with new config if available
 	if a.ai != nil {
@@ -571,34 +694,151 @@ func updateSFTPSetting(a *App, value SettingValue) error {
 		return fmt.Errorf("invalid SFTP config type: expected map, got %T", value)
 	}
 
+	a.config.mutex.Lock()
+	defer a.config.mutex.Unlock()
+
 	// Update each field if present
+	updated := a.config.config.SFTP
 	if v, exists := sftpMap["max_packet_size"]; exists {
 		if intVal, ok := toInt(v); ok {
-			a.config.config.SFTP.MaxPacketSize = intVal
+			updated.MaxPacketSize = intVal
 		}
 	}
 	if v, exists := sftpMap["buffer_size"]; exists {
 		if intVal, ok := toInt(v); ok {
-			a.config.config.SFTP.BufferSize = intVal
+			updated.BufferSize = intVal
 		}
 	}
 	if v, exists := sftpMap["concurrent_requests"]; exists {
 		if intVal, ok := toInt(v); ok {
-			a.config.config.SFTP.ConcurrentRequests = intVal
+			updated.ConcurrentRequests = intVal
 		}
 	}
 	if v, exists := sftpMap["parallel_transfers"]; exists {
 		if intVal, ok := toInt(v); ok {
-			a.config.config.SFTP.ParallelTransfers = intVal
+			updated.ParallelTransfers = intVal
+		}
+	}
+	if v, exists := sftpMap["client_pool_size"]; exists {
+		if intVal, ok := toInt(v); ok {
+			updated.ClientPoolSize = intVal
 		}
 	}
 	if v, exists := sftpMap["use_concurrent_io"]; exists {
 		if boolVal, ok := v.(bool); ok {
-			a.config.config.SFTP.UseConcurrentIO = boolVal
+			updated.UseConcurrentIO = boolVal
+		}
+	}
+	if v, exists := sftpMap["auto_tune"]; exists {
+		if boolVal, ok := v.(bool); ok {
+			updated.AutoTune = boolVal
+		}
+	}
+	if v, exists := sftpMap["auto_tune_buffer"]; exists {
+		if boolVal, ok := v.(bool); ok {
+			updated.AutoTuneBuffer = boolVal
+		}
+	}
+	if v, exists := sftpMap["max_preview_size"]; exists {
+		if intVal, ok := toInt(v); ok {
+			updated.MaxPreviewSize = int64(intVal)
+		}
+	}
+	if v, exists := sftpMap["download_margin"]; exists {
+		if intVal, ok := toInt(v); ok {
+			updated.DownloadMargin = int64(intVal)
+		}
+	}
+	if v, exists := sftpMap["directory_mode"]; exists {
+		if intVal, ok := toInt(v); ok {
+			if intVal < 0 || intVal > MaxSFTPDirectoryMode {
+				return fmt.Errorf("invalid SFTP directory mode %#o: only permission bits (up to %#o) can be set", intVal, MaxSFTPDirectoryMode)
+			}
+			updated.DirectoryMode = uint32(intVal)
+		}
+	}
+
+	a.config.config.SFTP = updated
+
+	fmt.Printf("SFTP settings updated: %+v\n", updated)
+	return nil
+}
+
+// Custom update function for the SSH proxy. The password is only replaced when the map has one.
+func updateProxySetting(a *App, value SettingValue) error {
+	proxyMap, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid proxy config type: expected map, got %T", value)
+	}
+
+	a.config.mutex.Lock()
+	defer a.config.mutex.Unlock()
+
+	updated := a.config.config.Proxy
+	for key, field := range map[string]*string{
+		"mode":     &updated.Mode,
+		"address":  &updated.Address,
+		"username": &updated.Username,
+		"password": &updated.Password,
+	} {
+		if v, exists := proxyMap[key]; exists {
+			if strVal, ok := v.(string); ok {
+				*field = strVal
+			}
+		}
+	}
+
+	if err := updated.Validate(); err != nil {
+		return err
+	}
+	a.config.config.Proxy = updated
+
+	fmt.Printf("Proxy settings updated: mode=%s address=%s\n", updated.Mode, updated.Address)
+	return nil
+}
+
+// Custom update function for privacy lock settings (the password is set via SetPrivacyLockPassword)
+func updatePrivacyLockSetting(a *App, value SettingValue) error {
+	lockMap, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid privacy lock config type: expected map, got %T", value)
+	}
+
+	a.config.mutex.Lock()
+	updated := a.config.config.PrivacyLock
+	if v, exists := lockMap["enabled"]; exists {
+		if boolVal, ok := v.(bool); ok {
+			updated.Enabled = boolVal
+		}
+	}
+	if v, exists := lockMap["idle_timeout"]; exists {
+		if intVal, ok := toInt(v); ok {
+			updated.IdleTimeout = intVal
+		}
+	}
+	if v, exists := lockMap["buffer_limit"]; exists {
+		if intVal, ok := toInt(v); ok {
+			updated.BufferLimit = intVal
+		}
+	}
+	if v, exists := lockMap["overflow_mode"]; exists {
+		if strVal, ok := v.(string); ok {
+			updated.OverflowMode = strVal
 		}
 	}
 
-	fmt.Printf("SFTP settings updated: %+v\n", a.config.config.SFTP)
+	if err := updated.Validate(); err != nil {
+		a.config.mutex.Unlock()
+		return err
+	}
+	a.config.config.PrivacyLock = updated
+	a.config.mutex.Unlock()
+
+	// Restart the idle countdown so enabling the lock doesn't fire immediately
+	a.PrivacyHeartbeat()
+
+	fmt.Printf("Privacy lock settings updated: enabled=%v idle=%ds buffer=%d mode=%s\n",
+		updated.Enabled, updated.IdleTimeout, updated.BufferLimit, updated.OverflowMode)
 	return nil
 }
 
@@ -680,7 +920,39 @@ var settingConfigs = map[string]*SettingConfig{
 		RequiresEvent: true,
 		EventName:     "config:scrollback-lines-changed",
 		ConfigField:   "ScrollbackLines",
-		RequiresMutex: true,
+	},
+	"InlineImageMaxBytes": {
+		Name:        "InlineImageMaxBytes",
+		Type:        SettingTypeInt,
+		Min:         intPtr(MinInlineImageMaxBytes),
+		Max:         intPtr(MaxInlineImageMaxBytes),
+		ConfigField: "InlineImageMaxBytes",
+	},
+	"ClosedTabExpiryMinutes": {
+		Name:        "ClosedTabExpiryMinutes",
+		Type:        SettingTypeInt,
+		Min:         intPtr(MinClosedTabExpiryMinutes),
+		Max:         intPtr(MaxClosedTabExpiryMinutes),
+		ConfigField: "ClosedTabExpiryMinutes",
+	},
+	"IdleNotifySeconds": {
+		Name:        "IdleNotifySeconds",
+		Type:        SettingTypeInt,
+		Min:         intPtr(MinIdleNotifySeconds),
+		Max:         intPtr(MaxIdleNotifySeconds),
+		ConfigField: "IdleNotifySeconds",
+	},
+	"BellNotifications": {
+		Name:        "BellNotifications",
+		Type:        SettingTypeBool,
+		ConfigField: "BellNotifications",
+	},
+	"MaxConnectionsPerHost": {
+		Name:        "MaxConnectionsPerHost",
+		Type:        SettingTypeInt,
+		Min:         intPtr(0),
+		Max:         intPtr(MaxHostConnectionLimit),
+		ConfigField: "MaxConnectionsPerHost",
 	},
 	"OpenLinksInExternalBrowser": {
 		Name:          "OpenLinksInExternalBrowser",
@@ -689,6 +961,36 @@ var settingConfigs = map[string]*SettingConfig{
 		EventName:     "config:open-links-external-changed",
 		ConfigField:   "OpenLinksInExternalBrowser",
 	},
+	"VerifyHostKeyDNS": {
+		Name:        "VerifyHostKeyDNS",
+		Type:        SettingTypeBool,
+		ConfigField: "VerifyHostKeyDNS",
+	},
+	"AutoReconnectOnNetworkChange": {
+		Name:        "AutoReconnectOnNetworkChange",
+		Type:        SettingTypeBool,
+		ConfigField: "AutoReconnectOnNetworkChange",
+	},
+	"DisableUpdateCheck": {
+		Name:        "DisableUpdateCheck",
+		Type:        SettingTypeBool,
+		ConfigField: "DisableUpdateCheck",
+	},
+	"DisableProfileProbes": {
+		Name:        "DisableProfileProbes",
+		Type:        SettingTypeBool,
+		ConfigField: "DisableProfileProbes",
+	},
+	"AllowProfileConnectCommands": {
+		Name:        "AllowProfileConnectCommands",
+		Type:        SettingTypeBool,
+		ConfigField: "AllowProfileConnectCommands",
+	},
+	"EnableMDNSDiscovery": {
+		Name:        "EnableMDNSDiscovery",
+		Type:        SettingTypeBool,
+		ConfigField: "EnableMDNSDiscovery",
+	},
 	// AI Configuration Settings
 	"AIEnabled": {
 		Name:         "AIEnabled",
@@ -731,6 +1033,18 @@ var settingConfigs = map[string]*SettingConfig{
 		Type:         SettingTypeMap,
 		CustomUpdate: updateSFTPSetting,
 	},
+	// SSH proxy Configuration
+	"Proxy": {
+		Name:         "Proxy",
+		Type:         SettingTypeMap,
+		CustomUpdate: updateProxySetting,
+	},
+	// Privacy lock Configuration
+	"PrivacyLock": {
+		Name:         "PrivacyLock",
+		Type:         SettingTypeMap,
+		CustomUpdate: updatePrivacyLockSetting,
+	},
 }
 
 // ConfigSet is a universal method for updating any configuration setting
@@ -745,6 +1059,10 @@ func (a *App) ConfigSet(settingName string, value SettingValue) error {
 		return &ConfigError{Op: "set_setting", Err: fmt.Errorf("unknown setting: %s", settingName)}
 	}
 
+	// Apply one setting at a time so update, dirty-marking and event are atomic per call
+	a.config.setMutex.Lock()
+	defer a.config.setMutex.Unlock()
+
 	// Validate the value
 	if err := config.Validate(value); err != nil {
 		return &ConfigError{Op: "validate_setting", Err: err}
@@ -778,6 +1096,9 @@ func (a *App) ConfigGet(settingName string) (SettingValue, error) {
 		return nil, &ConfigError{Op: "get_setting", Err: fmt.Errorf("unknown setting: %s", settingName)}
 	}
 
+	a.config.mutex.RLock()
+	defer a.config.mutex.RUnlock()
+
 	// Return the appropriate setting value
 	switch settingName {
 	case "DefaultShell":
@@ -797,11 +1118,31 @@ func (a *App) ConfigGet(settingName string) (SettingValue, error) {
 	case "Theme":
 		return a.config.config.Theme, nil
 	case "ScrollbackLines":
-		a.config.mutex.RLock()
-		defer a.config.mutex.RUnlock()
 		return a.config.config.ScrollbackLines, nil
+	case "InlineImageMaxBytes":
+		return inlineImageMaxBytesWithDefaults(a.config.config.InlineImageMaxBytes), nil
+	case "ClosedTabExpiryMinutes":
+		return int(closedTabExpiryWithDefaults(a.config.config.ClosedTabExpiryMinutes) / time.Minute), nil
+	case "IdleNotifySeconds":
+		return int(idleNotifyQuietWithDefaults(a.config.config.IdleNotifySeconds) / time.Second), nil
+	case "BellNotifications":
+		return a.config.config.BellNotifications, nil
+	case "MaxConnectionsPerHost":
+		return a.config.config.MaxConnectionsPerHost, nil
 	case "OpenLinksInExternalBrowser":
 		return a.config.config.OpenLinksInExternalBrowser, nil
+	case "VerifyHostKeyDNS":
+		return a.config.config.VerifyHostKeyDNS, nil
+	case "AutoReconnectOnNetworkChange":
+		return a.config.config.AutoReconnectOnNetworkChange, nil
+	case "DisableUpdateCheck":
+		return a.config.config.DisableUpdateCheck, nil
+	case "DisableProfileProbes":
+		return a.config.config.DisableProfileProbes, nil
+	case "AllowProfileConnectCommands":
+		return a.config.config.AllowProfileConnectCommands, nil
+	case "EnableMDNSDiscovery":
+		return a.config.config.EnableMDNSDiscovery, nil
 
 	// AI Configuration Settings
 	case "AIEnabled":
@@ -824,7 +1165,34 @@ func (a *App) ConfigGet(settingName string) (SettingValue, error) {
 			"buffer_size":          a.config.config.SFTP.BufferSize,
 			"concurrent_requests":  a.config.config.SFTP.ConcurrentRequests,
 			"parallel_transfers":   a.config.config.SFTP.ParallelTransfers,
+			"client_pool_size":     a.config.config.SFTP.ClientPoolSize,
 			"use_concurrent_io":    a.config.config.SFTP.UseConcurrentIO,
+			"auto_tune":            a.config.config.SFTP.AutoTune,
+			"auto_tune_buffer":     a.config.config.SFTP.AutoTuneBuffer,
+			"max_preview_size":     a.config.config.SFTP.MaxPreviewSize,
+			"download_margin":      a.config.config.SFTP.DownloadMargin,
+			"directory_mode":       a.config.config.SFTP.DirectoryMode,
+		}, nil
+
+	// SSH proxy Configuration (the password is never returned)
+	case "Proxy":
+		cfg := a.config.config.Proxy
+		return map[string]interface{}{
+			"mode":         cfg.Mode,
+			"address":      cfg.Address,
+			"username":     cfg.Username,
+			"password_set": cfg.Password != "",
+		}, nil
+
+	// Privacy lock Configuration (the password hash is never returned)
+	case "PrivacyLock":
+		cfg := privacyLockConfigWithDefaults(a.config.config.PrivacyLock)
+		return map[string]interface{}{
+			"enabled":       cfg.Enabled,
+			"idle_timeout":  cfg.IdleTimeout,
+			"buffer_limit":  cfg.BufferLimit,
+			"overflow_mode": cfg.OverflowMode,
+			"password_set":  cfg.PasswordHash != "",
 		}, nil
 
 	default:
@@ -832,15 +1200,218 @@ func (a *App) ConfigGet(settingName string) (SettingValue, error) {
 	}
 }
 
-// setPlatformDefaultShell sets the platform-specific default shell configuration
+// ResetSetting restores a single setting to its default value
+func (a *App) ResetSetting(settingName string) error {
+	value, err := defaultSettingValue(settingName)
+	if err != nil {
+		return err
+	}
+	return a.ConfigSet(settingName, value)
+}
+
+// ResetConfig replaces the whole configuration with the defaults. The current config file
+// is backed up first so a reset can be undone by hand.
+func (a *App) ResetConfig() error {
+	if a.config == nil || a.config.config == nil {
+		return &ConfigError{Op: "reset", Err: fmt.Errorf("config not initialized")}
+	}
+
+	a.config.setMutex.Lock()
+	defer a.config.setMutex.Unlock()
+
+	configPath, err := a.getConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := a.ensureConfigDir(); err != nil {
+		return err
+	}
+	backupPath, err := createResetBackup(configPath)
+	if err != nil {
+		return err
+	}
+
+	// Keep the current profiles path until updateProfilesPath switches it, so profiles get reloaded
+	defaults := DefaultConfig()
+	a.config.mutex.Lock()
+	defaultProfilesPath := defaults.ProfilesPath
+	defaults.ProfilesPath = a.config.config.ProfilesPath
+	a.config.config = defaults
+	a.config.mutex.Unlock()
+
+	if err := a.updateProfilesPath(defaultProfilesPath); err != nil {
+		return &ConfigError{Op: "reset", Err: err}
+	}
+
+	a.config.mutex.Lock()
+	if a.config.debounceTimer != nil {
+		a.config.debounceTimer.Stop()
+		a.config.debounceTimer = nil
+	}
+	err = a.saveConfig()
+	a.config.configDirty = err != nil // Leave dirty so a failed save is retried later
+	a.config.mutex.Unlock()
+	if err != nil {
+		return &ConfigError{Op: "reset", Path: configPath, Err: err}
+	}
+
+	if a.ai != nil {
+		if err := a.ai.UpdateConfig(&defaults.AI); err != nil {
+			fmt.Printf("Warning: Failed to update AI manager with reset config: %v\n", err)
+		}
+	}
+	a.PrivacyHeartbeat()
+
+	fmt.Printf("Config reset to defaults (previous config saved to %s)\n", backupPath)
+
+	// Per-setting events first so existing listeners update, then the full snapshot
+	if a.ctx != nil {
+		for name, config := range settingConfigs {
+			if !config.RequiresEvent {
+				continue
+			}
+			if value, err := a.ConfigGet(name); err == nil {
+				wailsRuntime.EventsEmit(a.ctx, config.EventName, config.GetEventData(value))
+			}
+		}
+	}
+	a.emitConfigReset()
+	return nil
+}
+
+// SettingSchemaEntry describes one setting for clients that build their UI from the backend
+type SettingSchemaEntry struct {
+	Name            string       `json:"name"`
+	Type            SettingType  `json:"type"`
+	Min             *int         `json:"min,omitempty"`
+	Max             *int         `json:"max,omitempty"`
+	MaxLength       *int         `json:"maxLength,omitempty"`
+	AllowedValues   []string     `json:"allowedValues,omitempty"`
+	Default         SettingValue `json:"default"`
+	EventName       string       `json:"eventName,omitempty"` // Event sent when the value changes, if any
+	RequiresRestart bool         `json:"requiresRestart"`
+}
+
+// GetSettingsSchema returns every setting in settingConfigs with its constraints, sorted by name
+func (a *App) GetSettingsSchema() []SettingSchemaEntry {
+	names := make([]string, 0, len(settingConfigs))
+	for name := range settingConfigs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	schema := make([]SettingSchemaEntry, 0, len(names))
+	for _, name := range names {
+		config := settingConfigs[name]
+		entry := SettingSchemaEntry{
+			Name:            config.Name,
+			Type:            config.Type,
+			Min:             config.Min,
+			Max:             config.Max,
+			MaxLength:       config.MaxLength,
+			AllowedValues:   config.AllowedValues,
+			RequiresRestart: config.RequiresRestart,
+		}
+		if config.RequiresEvent {
+			entry.EventName = config.EventName
+		}
+		if value, err := defaultSettingValue(name); err == nil {
+			entry.Default = value
+		}
+		schema = append(schema, entry)
+	}
+	return schema
+}
+
+// defaultSettingValue returns a setting's default in the same shape ConfigGet returns it
+func defaultSettingValue(settingName string) (SettingValue, error) {
+	defaults := &App{config: &ConfigManager{config: DefaultConfig()}}
+	return defaults.ConfigGet(settingName)
+}
+
+// emitConfigReset sends every setting's current value so the UI can refresh in one pass.
+// The AI API key is left out; the settings dialog fetches it when opened.
+func (a *App) emitConfigReset() {
+	if a.ctx == nil {
+		return
+	}
+
+	settings := make(map[string]interface{}, len(settingConfigs))
+	for name := range settingConfigs {
+		if name == "AIAPIKey" {
+			continue
+		}
+		if value, err := a.ConfigGet(name); err == nil {
+			settings[name] = value
+		}
+	}
+	wailsRuntime.EventsEmit(a.ctx, "config:reset", map[string]interface{}{
+		"settings": settings,
+	})
+}
+
+// setPlatformDefaultShell sets the platform-specific default shell configuration. Caller must hold config.mutex.
 func (a *App) setPlatformDefaultShell(shellPath string) {
-	switch currentPlatform {
+	a.setDefaultShellForPlatformLockFree(currentPlatform, shellPath)
+}
+
+// setDefaultShellForPlatformLockFree sets one platform's default shell and reports whether the
+// platform is known. Caller must hold config.mutex.
+func (a *App) setDefaultShellForPlatformLockFree(platform, shellPath string) bool {
+	switch platform {
 	case PlatformWindows:
 		a.config.config.DefaultShells.Windows = shellPath
 	case PlatformLinux:
 		a.config.config.DefaultShells.Linux = shellPath
 	case PlatformDarwin:
 		a.config.config.DefaultShells.Darwin = shellPath
+	default:
+		return false
+	}
+	return true
+}
+
+// SetDefaultShellForPlatform sets the default shell for any platform, so a config shared
+// between machines can be set up from one of them. The path is not checked for existence,
+// as it usually belongs to another OS; an empty path means the system default.
+func (a *App) SetDefaultShellForPlatform(platform, shellPath string) error {
+	if a.config == nil || a.config.config == nil {
+		return &ConfigError{Op: "set_default_shell", Err: fmt.Errorf("config not initialized")}
+	}
+	if err := settingConfigs["DefaultShell"].Validate(shellPath); err != nil {
+		return &ConfigError{Op: "set_default_shell", Err: err}
+	}
+
+	a.config.setMutex.Lock()
+	defer a.config.setMutex.Unlock()
+
+	a.config.mutex.Lock()
+	known := a.setDefaultShellForPlatformLockFree(platform, shellPath)
+	a.config.mutex.Unlock()
+	if !known {
+		return &ConfigError{Op: "set_default_shell", Err: fmt.Errorf("unknown platform %q, expected %s, %s or %s", platform, PlatformWindows, PlatformLinux, PlatformDarwin)}
+	}
+
+	fmt.Printf("Default shell for %s set to: %s\n", platform, shellPath)
+	a.markConfigDirty()
+	return nil
+}
+
+// GetDefaultShells returns the configured default shell for every platform, keyed by GOOS name.
+// Empty values mean the system default.
+func (a *App) GetDefaultShells() map[string]string {
+	if a.config == nil || a.config.config == nil {
+		return map[string]string{}
+	}
+
+	a.config.mutex.RLock()
+	defer a.config.mutex.RUnlock()
+
+	shells := a.config.config.DefaultShells
+	return map[string]string{
+		PlatformWindows: shells.Windows,
+		PlatformLinux:   shells.Linux,
+		PlatformDarwin:  shells.Darwin,
 	}
 }
 
@@ -858,7 +1429,7 @@ func getOSName() string {
 	}
 }
 
-// migrateLegacyConfig migrates old single shell config to platform-specific configuration
+// migrateLegacyConfig migrates old single shell config to platform-specific configuration. Caller must hold config.mutex.
 func (a *App) migrateLegacyConfig() bool {
 	if a.config.config.DefaultShell == "" {
 		return false // No legacy config to migrate
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConfigSetConcurrent(t *testing.T) {
+	// Keep the debounced save away from the real user config
+	configHome := t.TempDir()
+	t.Setenv("HOME", configHome)
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("AppData", configHome)
+
+	app := NewApp()
+	defer func() {
+		app.config.mutex.Lock()
+		if app.config.debounceTimer != nil {
+			app.config.debounceTimer.Stop()
+		}
+		app.config.mutex.Unlock()
+	}()
+
+	// Roughly what the settings dialog fires at once when it opens
+	setters := []struct {
+		name   string
+		values []SettingValue
+	}{
+		{"SidebarWidth", []SettingValue{float64(200), float64(300), 250}},
+		{"ScrollbackLines", []SettingValue{float64(1000), 5000}},
+		{"Theme", []SettingValue{ThemeDark, ThemeLight, ThemeSystem}},
+		{"EnableSelectToCopy", []SettingValue{true, false}},
+		{"AIHotkey", []SettingValue{"ctrl+k", "ctrl+j"}},
+		{"SFTP", []SettingValue{
+			map[string]interface{}{"buffer_size": float64(DefaultSFTPBufferSize), "use_concurrent_io": true},
+			map[string]interface{}{"concurrent_requests": float64(MinSFTPConcurrentRequests)},
+		}},
+		{"PrivacyLock", []SettingValue{
+			map[string]interface{}{"enabled": true, "idle_timeout": float64(DefaultPrivacyIdleTimeout)},
+			map[string]interface{}{"enabled": false, "overflow_mode": PrivacyOverflowSuspend},
+		}},
+	}
+
+	const goroutines = 16
+	const iterations = 50
+
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines*iterations*2)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				setter := setters[(g+i)%len(setters)]
+				value := setter.values[i%len(setter.values)]
+				if err := app.ConfigSet(setter.name, value); err != nil {
+					errs <- fmt.Errorf("ConfigSet(%s, %v): %w", setter.name, value, err)
+				}
+				if _, err := app.ConfigGet(setters[(g+i+1)%len(setters)].name); err != nil {
+					errs <- fmt.Errorf("ConfigGet: %w", err)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	app.config.mutex.RLock()
+	defer app.config.mutex.RUnlock()
+	if err := app.config.config.Validate(); err != nil {
+		t.Fatalf("config invalid after concurrent updates: %v", err)
+	}
+	if !app.config.configDirty {
+		t.Fatal("config should be marked dirty after updates")
+	}
+}
+
+func TestResetSettingAndConfig(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("HOME", configHome)
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("AppData", configHome)
+
+	app := NewApp()
+	defer func() {
+		app.config.mutex.Lock()
+		if app.config.debounceTimer != nil {
+			app.config.debounceTimer.Stop()
+		}
+		app.config.mutex.Unlock()
+	}()
+
+	if err := app.ConfigSet("Theme", ThemeLight); err != nil {
+		t.Fatalf("ConfigSet(Theme) returned error: %v", err)
+	}
+	if err := app.ConfigSet("ScrollbackLines", MinScrollbackLines); err != nil {
+		t.Fatalf("ConfigSet(ScrollbackLines) returned error: %v", err)
+	}
+	if err := app.ConfigSet("SFTP", map[string]interface{}{"buffer_size": float64(MinSFTPBufferSize)}); err != nil {
+		t.Fatalf("ConfigSet(SFTP) returned error: %v", err)
+	}
+	if err := app.saveConfig(); err != nil {
+		t.Fatalf("saveConfig() returned error: %v", err)
+	}
+
+	// A single reset leaves the other settings alone
+	if err := app.ResetSetting("Theme"); err != nil {
+		t.Fatalf("ResetSetting(Theme) returned error: %v", err)
+	}
+	if theme, _ := app.ConfigGet("Theme"); theme != DefaultTheme {
+		t.Errorf("Theme = %v after reset, want %v", theme, DefaultTheme)
+	}
+	if lines, _ := app.ConfigGet("ScrollbackLines"); lines != MinScrollbackLines {
+		t.Errorf("ScrollbackLines = %v, should be untouched by resetting Theme", lines)
+	}
+	if err := app.ResetSetting("SFTP"); err != nil {
+		t.Fatalf("ResetSetting(SFTP) returned error: %v", err)
+	}
+	if sftpCfg, _ := app.ConfigGet("SFTP"); sftpCfg.(map[string]interface{})["buffer_size"] != DefaultConfig().SFTP.BufferSize {
+		t.Errorf("SFTP not reset: %v", sftpCfg)
+	}
+	if err := app.ResetSetting("NoSuchSetting"); err == nil {
+		t.Error("resetting an unknown setting should fail")
+	}
+
+	if err := app.ResetConfig(); err != nil {
+		t.Fatalf("ResetConfig() returned error: %v", err)
+	}
+	if lines, _ := app.ConfigGet("ScrollbackLines"); lines != DefaultScrollbackLines {
+		t.Errorf("ScrollbackLines = %v after ResetConfig, want %d", lines, DefaultScrollbackLines)
+	}
+
+	configPath, _ := app.getConfigPath()
+	backups, _ := filepath.Glob(configPath + ".reset-*.backup")
+	if len(backups) != 1 {
+		t.Fatalf("expected one reset backup next to %s, got %v", configPath, backups)
+	}
+	data, err := os.ReadFile(backups[0])
+	if err != nil || !strings.Contains(string(data), fmt.Sprintf("scrollback_lines: %d", MinScrollbackLines)) {
+		t.Errorf("backup does not hold the pre-reset config (err %v)", err)
+	}
+
+	// A second reset right away keeps the first backup
+	if err := app.ResetConfig(); err != nil {
+		t.Fatalf("second ResetConfig() returned error: %v", err)
+	}
+	if backups, _ := filepath.Glob(configPath + ".reset-*.backup"); len(backups) != 2 {
+		t.Errorf("expected two reset backups, got %v", backups)
+	}
+	if data, _ := os.ReadFile(backups[0]); !strings.Contains(string(data), fmt.Sprintf("scrollback_lines: %d", MinScrollbackLines)) {
+		t.Error("second reset overwrote the first backup")
+	}
+}
+
+func TestGetSettingsSchema(t *testing.T) {
+	schema := NewApp().GetSettingsSchema()
+	if len(schema) != len(settingConfigs) {
+		t.Fatalf("schema has %d entries, settingConfigs has %d", len(schema), len(settingConfigs))
+	}
+
+	byName := make(map[string]SettingSchemaEntry, len(schema))
+	for i, entry := range schema {
+		if i > 0 && schema[i-1].Name >= entry.Name {
+			t.Fatalf("schema not sorted at %s", entry.Name)
+		}
+		byName[entry.Name] = entry
+	}
+
+	lines := byName["ScrollbackLines"]
+	if lines.Type != SettingTypeInt || *lines.Min != MinScrollbackLines || lines.Default != DefaultScrollbackLines {
+		t.Errorf("ScrollbackLines entry = %+v", lines)
+	}
+	if lines.EventName != "config:scrollback-lines-changed" {
+		t.Errorf("ScrollbackLines event = %q", lines.EventName)
+	}
+	if theme := byName["Theme"]; len(theme.AllowedValues) == 0 {
+		t.Error("Theme entry is missing its allowed values")
+	}
+}
+
+func TestSetDefaultShellForPlatform(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("HOME", configHome)
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("AppData", configHome)
+
+	app := NewApp()
+	defer func() {
+		app.config.mutex.Lock()
+		if app.config.debounceTimer != nil {
+			app.config.debounceTimer.Stop()
+		}
+		app.config.mutex.Unlock()
+	}()
+
+	if err := app.SetDefaultShellForPlatform(PlatformWindows, `C:\Program Files\PowerShell\7\pwsh.exe`); err != nil {
+		t.Fatalf("SetDefaultShellForPlatform(windows) returned error: %v", err)
+	}
+	if err := app.SetDefaultShellForPlatform(PlatformDarwin, "/bin/zsh"); err != nil {
+		t.Fatalf("SetDefaultShellForPlatform(darwin) returned error: %v", err)
+	}
+	if err := app.SetDefaultShellForPlatform("beos", "/bin/sh"); err == nil {
+		t.Error("an unknown platform should be rejected")
+	}
+
+	shells := app.GetDefaultShells()
+	if shells[PlatformWindows] != `C:\Program Files\PowerShell\7\pwsh.exe` || shells[PlatformDarwin] != "/bin/zsh" || shells[PlatformLinux] != "" {
+		t.Errorf("GetDefaultShells() = %v", shells)
+	}
+}
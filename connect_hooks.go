@@ -0,0 +1,384 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Connect hook constants
+const (
+	HookRunLocal  = "local"
+	HookRunRemote = "remote"
+
+	HookFailureAbort = "abort"
+	HookFailureWarn  = "warn"
+
+	HookPhasePreConnect  = "pre-connect"
+	HookPhasePostConnect = "post-connect"
+
+	DefaultHookTimeoutSeconds = 30
+	MaxHookTimeoutSeconds     = 600
+	MaxHooksPerPhase          = 10
+	MaxHookCommandLength      = 4096
+	MaxHookOutputBytes        = 16 * 1024 // Output kept per hook run, from the end
+	MaxHookLogEntries         = 50        // Hook runs kept per session
+)
+
+// ConnectHook is a command run before dialing or after the session is ready
+type ConnectHook struct {
+	Command        string `yaml:"command" json:"command"`
+	RunOn          string `yaml:"run_on,omitempty" json:"runOn,omitempty"`                   // "local" (default) or "remote"
+	TimeoutSeconds int    `yaml:"timeout_seconds,omitempty" json:"timeoutSeconds,omitempty"` // 0 = DefaultHookTimeoutSeconds
+	FailurePolicy  string `yaml:"failure_policy,omitempty" json:"failurePolicy,omitempty"`   // "abort" (default) or "warn"
+	RunOnReconnect bool   `yaml:"run_on_reconnect,omitempty" json:"runOnReconnect,omitempty"`
+	ShowOutput     bool   `yaml:"show_output,omitempty" json:"showOutput,omitempty"` // Also print the output as a terminal message
+}
+
+// ConnectHookResult is one hook run, as kept in a session's hook log
+type ConnectHookResult struct {
+	Phase      string    `json:"phase"`
+	Command    string    `json:"command"`
+	RunOn      string    `json:"runOn"`
+	Started    time.Time `json:"started"`
+	DurationMs int64     `json:"durationMs"`
+	ExitCode   int       `json:"exitCode"` // -1 when the command never ran to completion
+	Output     string    `json:"output"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// connectHookState tracks hook runs for one session
+type connectHookState struct {
+	cancel    context.CancelFunc // Cancels the hooks currently running, if any
+	log       []ConnectHookResult
+	connected bool // The session has connected once, so later connects are reconnects
+}
+
+// errConnectHooksCancelled is returned when the session is closed while its hooks run
+var errConnectHooksCancelled = errors.New("connect hooks cancelled")
+
+var connectHookStates = make(map[string]*connectHookState)
+var connectHookStatesMu sync.Mutex
+
+// Validate checks a hook definition for the given phase
+func (h *ConnectHook) Validate(phase string) error {
+	if h.Command == "" {
+		return fmt.Errorf("%s hook command cannot be empty", phase)
+	}
+	if len(h.Command) > MaxHookCommandLength {
+		return fmt.Errorf("%s hook command too long: %d characters, maximum: %d", phase, len(h.Command), MaxHookCommandLength)
+	}
+	switch h.RunOn {
+	case "", HookRunLocal:
+	case HookRunRemote:
+		if phase == HookPhasePreConnect {
+			return fmt.Errorf("pre-connect hook %q cannot run on the remote host before it is connected", h.Command)
+		}
+	default:
+		return fmt.Errorf("invalid %s hook runOn %q, expected %s or %s", phase, h.RunOn, HookRunLocal, HookRunRemote)
+	}
+	if h.TimeoutSeconds < 0 || h.TimeoutSeconds > MaxHookTimeoutSeconds {
+		return fmt.Errorf("%s hook timeout must be between 0 and %d seconds, got: %d", phase, MaxHookTimeoutSeconds, h.TimeoutSeconds)
+	}
+	switch h.FailurePolicy {
+	case "", HookFailureAbort, HookFailureWarn:
+	default:
+		return fmt.Errorf("invalid %s hook failure policy %q, expected %s or %s", phase, h.FailurePolicy, HookFailureAbort, HookFailureWarn)
+	}
+	return nil
+}
+
+// validateConnectHooks validates every hook of one phase
+func validateConnectHooks(phase string, hooks []ConnectHook) error {
+	if len(hooks) > MaxHooksPerPhase {
+		return fmt.Errorf("too many %s hooks: %d, maximum allowed: %d", phase, len(hooks), MaxHooksPerPhase)
+	}
+	for i := range hooks {
+		if err := hooks[i].Validate(phase); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateConnectCommand checks a profile's PreConnectCommand or PostConnectCommand. The command
+// is handed to the platform shell as a single argument and nothing is substituted into it, so
+// it only has to be one line the shell can read.
+func validateConnectCommand(phase, command string) error {
+	if command == "" {
+		return nil
+	}
+	if len(command) > MaxHookCommandLength {
+		return fmt.Errorf("%s command too long: %d characters, maximum: %d", phase, len(command), MaxHookCommandLength)
+	}
+	if strings.ContainsAny(command, "\x00\r\n") {
+		return fmt.Errorf("%s command must be a single line", phase)
+	}
+	return nil
+}
+
+// profileConnectCommandsAllowed reports whether the user opted in to profile connect commands
+func (a *App) profileConnectCommandsAllowed() bool {
+	if a.config == nil {
+		return false
+	}
+	a.config.mutex.RLock()
+	defer a.config.mutex.RUnlock()
+	return a.config.config != nil && a.config.config.AllowProfileConnectCommands
+}
+
+// runsLocally reports whether the hook runs on this machine rather than the remote host
+func (h *ConnectHook) runsLocally() bool {
+	return h.RunOn == "" || h.RunOn == HookRunLocal
+}
+
+// connectHooksForTab returns the hooks and environment of the profile a tab was opened from.
+// The profile's PreConnectCommand runs first and aborts on failure; its PostConnectCommand
+// runs last and only warns. Everything that runs a local command, those two and local hook
+// entries alike, is skipped with a warning unless AllowProfileConnectCommands is set.
+func (a *App) connectHooksForTab(tab *Tab) (pre, post []ConnectHook, env map[string]string) {
+	if tab.ProfileID == "" {
+		return nil, nil, nil
+	}
+	allowCommands := a.profileConnectCommandsAllowed()
+
+	skipped := 0
+	allowed := func(hooks []ConnectHook) []ConnectHook {
+		var kept []ConnectHook
+		for _, hook := range hooks {
+			if hook.runsLocally() && !allowCommands {
+				skipped++
+				continue
+			}
+			kept = append(kept, hook)
+		}
+		return kept
+	}
+
+	a.profiles.mutex.RLock()
+	profile, exists := a.profiles.profiles[tab.ProfileID]
+	if !exists {
+		a.profiles.mutex.RUnlock()
+		return nil, nil, nil
+	}
+	env = make(map[string]string, len(profile.Environment))
+	for key, value := range profile.Environment {
+		env[key] = value
+	}
+	if profile.PreConnectCommand != "" {
+		pre = append(pre, ConnectHook{Command: profile.PreConnectCommand, FailurePolicy: HookFailureAbort, RunOnReconnect: true, ShowOutput: true})
+	}
+	pre = append(pre, profile.PreConnectHooks...)
+	post = append(post, profile.PostConnectHooks...)
+	if profile.PostConnectCommand != "" {
+		post = append(post, ConnectHook{Command: profile.PostConnectCommand, FailurePolicy: HookFailureWarn, ShowOutput: true})
+	}
+	a.profiles.mutex.RUnlock()
+
+	pre, post = allowed(pre), allowed(post)
+	if skipped > 0 {
+		a.messages.EmitMessage(tab.SessionID, fmt.Sprintf("%d local connect command(s) from the profile skipped: they run arbitrary commands on this machine and are only enabled by the AllowProfileConnectCommands setting", skipped), MessageWarning)
+	}
+	return pre, post, env
+}
+
+// hasConnectedBefore reports whether hooks already completed a connection for the session
+func hasConnectedBefore(sessionID string) bool {
+	connectHookStatesMu.Lock()
+	defer connectHookStatesMu.Unlock()
+	state, exists := connectHookStates[sessionID]
+	return exists && state.connected
+}
+
+// markSessionConnected records that the session finished connecting, hooks included
+func markSessionConnected(sessionID string) {
+	connectHookStatesMu.Lock()
+	defer connectHookStatesMu.Unlock()
+	state, exists := connectHookStates[sessionID]
+	if !exists {
+		state = &connectHookState{}
+		connectHookStates[sessionID] = state
+	}
+	state.connected = true
+}
+
+// releaseConnectHooks cancels running hooks for a session and forgets its hook log
+func releaseConnectHooks(sessionID string) {
+	connectHookStatesMu.Lock()
+	state, exists := connectHookStates[sessionID]
+	delete(connectHookStates, sessionID)
+	connectHookStatesMu.Unlock()
+
+	if exists && state.cancel != nil {
+		state.cancel()
+	}
+}
+
+// beginConnectHooks returns a context that is cancelled when the session is closed
+func beginConnectHooks(sessionID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	connectHookStatesMu.Lock()
+	state, exists := connectHookStates[sessionID]
+	if !exists {
+		state = &connectHookState{}
+		connectHookStates[sessionID] = state
+	}
+	state.cancel = cancel
+	connectHookStatesMu.Unlock()
+
+	return ctx, func() {
+		connectHookStatesMu.Lock()
+		if current, exists := connectHookStates[sessionID]; exists && current == state {
+			state.cancel = nil
+		}
+		connectHookStatesMu.Unlock()
+		cancel()
+	}
+}
+
+// appendConnectHookLog adds a hook run to the session's log, dropping the oldest past the limit
+func appendConnectHookLog(sessionID string, result ConnectHookResult) {
+	connectHookStatesMu.Lock()
+	defer connectHookStatesMu.Unlock()
+
+	state, exists := connectHookStates[sessionID]
+	if !exists {
+		return // Session was closed while the hook ran
+	}
+	state.log = append(state.log, result)
+	if len(state.log) > MaxHookLogEntries {
+		state.log = state.log[len(state.log)-MaxHookLogEntries:]
+	}
+}
+
+// GetConnectHookLog returns the hook runs recorded for a session, oldest first
+func (a *App) GetConnectHookLog(sessionID string) []ConnectHookResult {
+	connectHookStatesMu.Lock()
+	defer connectHookStatesMu.Unlock()
+
+	state, exists := connectHookStates[sessionID]
+	if !exists {
+		return []ConnectHookResult{}
+	}
+	return append([]ConnectHookResult{}, state.log...)
+}
+
+// runConnectHooks runs one phase's hooks in order. On a reconnect only hooks with
+// RunOnReconnect run. It returns an error when a hook with the abort policy fails or
+// the session is closed while hooks are running.
+func (a *App) runConnectHooks(sessionID, phase string, hooks []ConnectHook, env map[string]string, reconnect bool) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	ctx, done := beginConnectHooks(sessionID)
+	defer done()
+
+	for _, hook := range hooks {
+		if reconnect && !hook.RunOnReconnect {
+			continue
+		}
+
+		a.messages.EmitMessage(sessionID, fmt.Sprintf("Running %s hook: %s", phase, hook.Command), MessageProgress)
+		result := a.runConnectHook(ctx, sessionID, phase, hook, env)
+		appendConnectHookLog(sessionID, result)
+		if output := strings.TrimSpace(result.Output); hook.ShowOutput && output != "" {
+			a.messages.EmitMessage(sessionID, output, MessageInfo)
+		}
+
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s: %w", phase, errConnectHooksCancelled)
+		}
+		if result.Error == "" {
+			continue
+		}
+		if hook.FailurePolicy == HookFailureWarn {
+			a.messages.EmitMessage(sessionID, fmt.Sprintf("%s hook failed, continuing: %s", phase, result.Error), MessageWarning)
+			continue
+		}
+		return fmt.Errorf("%s hook %q failed: %s", phase, hook.Command, result.Error)
+	}
+	return nil
+}
+
+// runConnectHook runs a single hook locally or over the session's SSH connection
+func (a *App) runConnectHook(ctx context.Context, sessionID, phase string, hook ConnectHook, env map[string]string) ConnectHookResult {
+	timeout := time.Duration(hook.TimeoutSeconds) * time.Second
+	if timeout == 0 {
+		timeout = DefaultHookTimeoutSeconds * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	runOn := hook.RunOn
+	if runOn == "" {
+		runOn = HookRunLocal
+	}
+	result := ConnectHookResult{Phase: phase, Command: hook.Command, RunOn: runOn, Started: time.Now(), ExitCode: -1}
+
+	var output []byte
+	var err error
+	if runOn == HookRunRemote {
+		output, err = a.runUserCommand(ctx, sessionID, phase+" hook", hook.Command)
+	} else {
+		output, err = runLocalHook(ctx, hook.Command, env)
+	}
+
+	result.DurationMs = time.Since(result.Started).Milliseconds()
+	if len(output) > MaxHookOutputBytes {
+		output = output[len(output)-MaxHookOutputBytes:]
+	}
+	result.Output = string(output)
+
+	var exitErr *exec.ExitError
+	var sshExitErr *ssh.ExitError
+	switch {
+	case err == nil:
+		result.ExitCode = 0
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.Error = fmt.Sprintf("timed out after %s", timeout)
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+		result.Error = fmt.Sprintf("exit status %d", result.ExitCode)
+	case errors.As(err, &sshExitErr):
+		result.ExitCode = sshExitErr.ExitStatus()
+		result.Error = fmt.Sprintf("exit status %d", result.ExitCode)
+	default:
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// runLocalHook runs a command through the platform shell with the profile's environment added
+func runLocalHook(ctx context.Context, command string, env map[string]string) ([]byte, error) {
+	cmd := hookShellCommand(ctx, command)
+	cmd.Env = os.Environ()
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	return cmd.CombinedOutput()
+}
+
+// runPostConnectHooks runs post-connect hooks once the session is ready. A failing hook with
+// the abort policy disconnects the session again.
+func (a *App) runPostConnectHooks(sessionID string, hooks []ConnectHook, env map[string]string, reconnect bool) {
+	err := a.runConnectHooks(sessionID, HookPhasePostConnect, hooks, env, reconnect)
+	if err == nil {
+		markSessionConnected(sessionID)
+		return
+	}
+	if errors.Is(err, errConnectHooksCancelled) {
+		return
+	}
+
+	a.CleanupSession(sessionID)
+	a.messages.ConnectionFailed(sessionID, err)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConnectHookValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		phase string
+		hook  ConnectHook
+		valid bool
+	}{
+		{"local defaults", HookPhasePreConnect, ConnectHook{Command: "tailscale up"}, true},
+		{"remote post", HookPhasePostConnect, ConnectHook{Command: "logger connected", RunOn: HookRunRemote, FailurePolicy: HookFailureWarn}, true},
+		{"empty command", HookPhasePreConnect, ConnectHook{}, false},
+		{"remote pre", HookPhasePreConnect, ConnectHook{Command: "uptime", RunOn: HookRunRemote}, false},
+		{"unbounded timeout", HookPhasePostConnect, ConnectHook{Command: "sleep 1", TimeoutSeconds: MaxHookTimeoutSeconds + 1}, false},
+		{"unknown policy", HookPhasePostConnect, ConnectHook{Command: "true", FailurePolicy: "ignore"}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.hook.Validate(tt.phase); (err == nil) != tt.valid {
+			t.Errorf("%s: Validate() = %v, want valid=%v", tt.name, err, tt.valid)
+		}
+	}
+}
+
+func TestRunConnectHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks in this test use sh")
+	}
+	app := NewApp()
+	app.privacy.emit = func(string, string) {}
+	const sessionID = "session_hooks"
+	defer releaseConnectHooks(sessionID)
+
+	hooks := []ConnectHook{
+		{Command: `echo "vpn for $THERMIC_TEST_ENV"`},
+		{Command: "exit 3", FailurePolicy: HookFailureWarn},
+		{Command: "echo again", RunOnReconnect: true},
+	}
+	env := map[string]string{"THERMIC_TEST_ENV": "prod"}
+	if err := app.runConnectHooks(sessionID, HookPhasePreConnect, hooks, env, false); err != nil {
+		t.Fatalf("runConnectHooks() returned error: %v", err)
+	}
+
+	log := app.GetConnectHookLog(sessionID)
+	if len(log) != 3 {
+		t.Fatalf("hook log has %d entries, want 3", len(log))
+	}
+	if strings.TrimSpace(log[0].Output) != "vpn for prod" || log[0].ExitCode != 0 {
+		t.Errorf("first hook = %+v", log[0])
+	}
+	if log[1].ExitCode != 3 || log[1].Error == "" {
+		t.Errorf("failing hook = %+v", log[1])
+	}
+
+	// Reconnects only run hooks that ask for it
+	if err := app.runConnectHooks(sessionID, HookPhasePreConnect, hooks, env, true); err != nil {
+		t.Fatalf("reconnect runConnectHooks() returned error: %v", err)
+	}
+	if log = app.GetConnectHookLog(sessionID); len(log) != 4 || log[3].Command != "echo again" {
+		t.Fatalf("reconnect ran %v", log[3:])
+	}
+
+	abort := []ConnectHook{{Command: "exit 1"}, {Command: "echo unreachable"}}
+	if err := app.runConnectHooks(sessionID, HookPhasePreConnect, abort, nil, false); err == nil {
+		t.Fatal("a failing hook with the abort policy should stop the connection")
+	}
+	if log = app.GetConnectHookLog(sessionID); len(log) != 5 {
+		t.Fatalf("hooks after an aborting failure still ran: %v", log[4:])
+	}
+}
+
+func TestConnectHooksCancelledWhenSessionCloses(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks in this test use sh")
+	}
+	app := NewApp()
+	app.privacy.emit = func(string, string) {}
+	const sessionID = "session_hooks_cancel"
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		app.ReleaseSession(sessionID)
+	}()
+
+	start := time.Now()
+	err := app.runConnectHooks(sessionID, HookPhasePreConnect, []ConnectHook{{Command: "sleep 10"}}, nil, false)
+	if !errors.Is(err, errConnectHooksCancelled) {
+		t.Fatalf("runConnectHooks() = %v, want cancellation", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("cancellation took %v", elapsed)
+	}
+}
+
+func TestFailedPostConnectHookReleasesSession(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks in this test use sh")
+	}
+	app := NewApp()
+	app.privacy.emit = func(string, string) {}
+	const sessionID = "session_hooks_post"
+	defer releaseConnectHooks(sessionID)
+	recordSudoInvocation(sessionID, "Test", remoteCommand("sudo true"))
+	defer forgetSudoAuditLog(sessionID)
+
+	app.runPostConnectHooks(sessionID, []ConnectHook{{Command: "exit 1"}}, nil, false)
+	if len(app.GetSudoAuditLog(sessionID)) != 0 {
+		t.Error("session state kept after its post-connect hook failed")
+	}
+}
+
+func TestConnectHooksForTabProfileCommands(t *testing.T) {
+	app := NewApp()
+	app.privacy.emit = func(string, string) {}
+	app.profiles.profiles["profile_cmds"] = &Profile{
+		ID:                 "profile_cmds",
+		PreConnectCommand:  "vpn up",
+		PostConnectCommand: "open https://example.com",
+		PreConnectHooks:    []ConnectHook{{Command: "pre hook"}},
+		PostConnectHooks:   []ConnectHook{{Command: "post hook", RunOn: HookRunLocal}, {Command: "uptime", RunOn: HookRunRemote}},
+	}
+	tab := &Tab{ID: "tab_cmds", SessionID: "session_cmds", ProfileID: "profile_cmds"}
+
+	// Off by default: only remote hooks run
+	pre, post, _ := app.connectHooksForTab(tab)
+	if len(pre) != 0 || len(post) != 1 || post[0].Command != "uptime" {
+		t.Fatalf("with commands disabled got pre %+v and post %+v, want only the remote hook", pre, post)
+	}
+
+	app.config.mutex.Lock()
+	app.config.config.AllowProfileConnectCommands = true
+	app.config.mutex.Unlock()
+
+	pre, post, _ = app.connectHooksForTab(tab)
+	if len(pre) != 2 || pre[0].Command != "vpn up" || pre[0].FailurePolicy != HookFailureAbort {
+		t.Errorf("pre-connect hooks = %+v, want the command first with the abort policy", pre)
+	}
+	if len(post) != 3 || post[2].Command != "open https://example.com" || post[2].FailurePolicy != HookFailureWarn {
+		t.Errorf("post-connect hooks = %+v, want the command last with the warn policy", post)
+	}
+
+	if err := validateConnectCommand(HookPhasePreConnect, "vpn up\nrm -rf ~"); err == nil {
+		t.Error("a multi-line connect command should be rejected")
+	}
+}
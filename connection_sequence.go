@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Connection sequence constants
+const (
+	ConnectionSequenceProgressEvent = "connection-sequence-progress"
+	MaxConnectionSequenceSteps      = 20
+	MaxConnectionStepDelayMs        = 5 * 60 * 1000
+	ConnectionStepPromptTimeout     = 2 * time.Minute // How long a WaitForPrompt step waits for its shell
+)
+
+// Connection sequence progress phases
+const (
+	SequencePhaseTabCreated = "tab-created" // The step's tab exists; the frontend adopts it and starts its shell
+	SequencePhaseReady      = "ready"       // The step's shell showed a prompt
+	SequencePhaseFailed     = "failed"      // The step failed and the sequence stopped
+	SequencePhaseComplete   = "complete"    // Every step ran
+)
+
+// ConnectionStep is a profile opened after the profile that holds the sequence, e.g. an
+// internal host only reachable once a VPN gateway tab is connected
+type ConnectionStep struct {
+	ProfileID     string `yaml:"profile_id" json:"profileId"`
+	DelayMs       int    `yaml:"delay_ms,omitempty" json:"delayMs,omitempty"`              // Wait before opening the step's tab
+	WaitForPrompt bool   `yaml:"wait_for_prompt,omitempty" json:"waitForPrompt,omitempty"` // Wait for the step's shell prompt before the next step
+}
+
+// ConnectionSequenceProgress is the payload of connection-sequence-progress events
+type ConnectionSequenceProgress struct {
+	TabID         string `json:"tabId"`     // Tab of the profile that holds the sequence
+	ProfileID     string `json:"profileId"` // Profile that holds the sequence
+	Step          int    `json:"step"`      // Zero-based index of the step; len(steps) once complete
+	Total         int    `json:"total"`
+	Phase         string `json:"phase"`
+	StepProfileID string `json:"stepProfileId,omitempty"`
+	StepTabID     string `json:"stepTabId,omitempty"`
+	Tab           *Tab   `json:"tab,omitempty"` // The step's tab, sent with tab-created
+	Error         string `json:"error,omitempty"`
+}
+
+// validateConnectionSequence checks a profile's sequence. Steps may only name other profiles;
+// a step's own sequence is not run, so a sequence can't recurse.
+func validateConnectionSequence(profileID string, steps []ConnectionStep) error {
+	if len(steps) > MaxConnectionSequenceSteps {
+		return fmt.Errorf("too many connection sequence steps: %d, maximum allowed: %d", len(steps), MaxConnectionSequenceSteps)
+	}
+	for i, step := range steps {
+		if step.ProfileID == "" {
+			return fmt.Errorf("connection sequence step %d has no profile ID", i+1)
+		}
+		if step.ProfileID == profileID {
+			return fmt.Errorf("connection sequence step %d opens the profile itself", i+1)
+		}
+		if step.DelayMs < 0 || step.DelayMs > MaxConnectionStepDelayMs {
+			return fmt.Errorf("connection sequence step %d delay %dms is out of range (0-%d)", i+1, step.DelayMs, MaxConnectionStepDelayMs)
+		}
+	}
+	return nil
+}
+
+// runConnectionSequence opens the tabs of a profile's sequence one after another once its
+// primary tab is open. It stops at the first failing step, or when the primary tab is closed.
+func (a *App) runConnectionSequence(primary *Tab, profileID string, steps []ConnectionStep) {
+	emitter := appEmitter{a}
+	progress := func(step int, phase string) ConnectionSequenceProgress {
+		return ConnectionSequenceProgress{TabID: primary.ID, ProfileID: profileID, Step: step, Total: len(steps), Phase: phase}
+	}
+	fail := func(event ConnectionSequenceProgress, err error) {
+		event.Phase = SequencePhaseFailed
+		event.Error = err.Error()
+		emitter.Emit(ConnectionSequenceProgressEvent, event)
+		fmt.Printf("Connection sequence of profile %s stopped at step %d: %v\n", profileID, event.Step+1, err)
+	}
+
+	for i, step := range steps {
+		event := progress(i, SequencePhaseTabCreated)
+		event.StepProfileID = step.ProfileID
+
+		if step.DelayMs > 0 {
+			time.Sleep(time.Duration(step.DelayMs) * time.Millisecond)
+		}
+		if !a.sessionHasTab(primary.SessionID) {
+			fail(event, fmt.Errorf("tab %s was closed", primary.ID))
+			return
+		}
+
+		tab, err := a.createTabFromProfile(step.ProfileID, false)
+		if err != nil {
+			fail(event, fmt.Errorf("failed to open profile %s: %w", step.ProfileID, err))
+			return
+		}
+		a.terminal.mutex.RLock()
+		snapshot := cloneTab(tab)
+		a.terminal.mutex.RUnlock()
+		event.StepTabID = tab.ID
+		event.Tab = snapshot
+		emitter.Emit(ConnectionSequenceProgressEvent, event)
+		event.Tab = nil
+
+		if !step.WaitForPrompt {
+			continue
+		}
+		if err := a.WaitForShellReady(tab.SessionID, int(ConnectionStepPromptTimeout/time.Millisecond)); err != nil {
+			fail(event, err)
+			return
+		}
+		event.Phase = SequencePhaseReady
+		emitter.Emit(ConnectionSequenceProgressEvent, event)
+	}
+
+	emitter.Emit(ConnectionSequenceProgressEvent, progress(len(steps), SequencePhaseComplete))
+	fmt.Printf("Connection sequence of profile %s opened %d tabs\n", profileID, len(steps))
+}
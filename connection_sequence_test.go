@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateConnectionSequence(t *testing.T) {
+	valid := []ConnectionStep{{ProfileID: "db", DelayMs: 2000, WaitForPrompt: true}, {ProfileID: "cache"}}
+	if err := validateConnectionSequence("gateway", valid); err != nil {
+		t.Fatalf("validateConnectionSequence() returned error: %v", err)
+	}
+
+	invalid := map[string][]ConnectionStep{
+		"missing profile ID": {{DelayMs: 100}},
+		"self reference":     {{ProfileID: "gateway"}},
+		"negative delay":     {{ProfileID: "db", DelayMs: -1}},
+		"delay too long":     {{ProfileID: "db", DelayMs: MaxConnectionStepDelayMs + 1}},
+		"too many steps":     make([]ConnectionStep, MaxConnectionSequenceSteps+1),
+	}
+	for name, steps := range invalid {
+		if err := validateConnectionSequence("gateway", steps); err == nil {
+			t.Errorf("%s: validateConnectionSequence() accepted the sequence", name)
+		}
+	}
+}
+
+func TestRunConnectionSequence(t *testing.T) {
+	app := NewApp()
+	app.profiles.profiles["gateway"] = &Profile{ID: "gateway", Name: "Gateway", Type: ProfileTypeLocal,
+		ConnectionSequence: []ConnectionStep{{ProfileID: "db"}, {ProfileID: "missing"}, {ProfileID: "cache"}}}
+	app.profiles.profiles["db"] = &Profile{ID: "db", Name: "DB", Type: ProfileTypeLocal,
+		ConnectionSequence: []ConnectionStep{{ProfileID: "cache"}}}
+	app.profiles.profiles["cache"] = &Profile{ID: "cache", Name: "Cache", Type: ProfileTypeLocal}
+
+	primary, err := app.createTabFromProfile("gateway", false)
+	if err != nil {
+		t.Fatalf("createTabFromProfile() returned error: %v", err)
+	}
+	app.runConnectionSequence(primary, "gateway", app.profiles.profiles["gateway"].ConnectionSequence)
+
+	// The sequence stops at the missing profile, and db's own sequence is not run
+	opened := make(map[string]int)
+	for _, tab := range app.terminal.tabs {
+		opened[tab.ProfileID]++
+	}
+	if opened["gateway"] != 1 || opened["db"] != 1 || opened["cache"] != 0 || len(app.terminal.tabs) != 2 {
+		t.Errorf("tabs opened per profile = %v, want gateway and db once", opened)
+	}
+}
+
+func TestWaitForShellReady(t *testing.T) {
+	app := NewApp()
+	sessionID := "session_shell_ready"
+	app.terminal.tabs["tab_shell_ready"] = &Tab{ID: "tab_shell_ready", SessionID: sessionID, Status: StatusConnecting.String()}
+	t.Cleanup(func() { releaseScrollback(sessionID) })
+
+	if err := app.WaitForShellReady("session_without_tab", 1000); toThermicError(err).Code != ErrCodeNotFound {
+		t.Fatalf("WaitForShellReady() without a tab = %v, want not found", err)
+	}
+
+	// A line ending like a prompt that was followed by a newline is not a prompt
+	recordScrollbackOutput(sessionID, "Last login: Mon from 10.0.0.1\r\nload > 5\r\n")
+	if err := app.WaitForShellReady(sessionID, 1000); err == nil || !strings.Contains(err.Error(), "no shell prompt") {
+		t.Fatalf("WaitForShellReady() without a prompt = %v, want a timeout", err)
+	}
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		recordScrollbackOutput(sessionID, "\x1b[32mdeploy@db\x1b[0m:~$ ")
+	}()
+	started := time.Now()
+	if err := app.WaitForShellReady(sessionID, 5000); err != nil {
+		t.Fatalf("WaitForShellReady() returned error: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < ShellReadyQuietPeriod {
+		t.Errorf("WaitForShellReady() returned after %s, before the prompt was quiet for %s", elapsed, ShellReadyQuietPeriod)
+	}
+
+	app.terminal.tabs["tab_shell_ready"].Status = StatusFailed.String()
+	recordScrollbackOutput(sessionID, "\r\n")
+	if err := app.WaitForShellReady(sessionID, 5000); err == nil || !strings.Contains(err.Error(), "failed") {
+		t.Errorf("WaitForShellReady() on a failed tab = %v, want a failure", err)
+	}
+}
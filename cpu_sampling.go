@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+// localCPUSampleKey is the monitoring key of the local machine's CPU counters
+const localCPUSampleKey = "local"
+
+// cpuUsageDelta returns the busy percentage between two snapshots, clamped to [0, 100].
+// ok is false when no time passed or the counters went backwards (reset, wrap, hotplug).
+func cpuUsageDelta(prev, cur CPUCounters) (float64, bool) {
+	total := cur.Total - prev.Total
+	if total <= 0 {
+		return 0, false
+	}
+	usage := (cur.Busy - prev.Busy) / total * 100
+	if usage < 0 {
+		usage = 0
+	} else if usage > 100 {
+		usage = 100
+	}
+	return usage, true
+}
+
+// cpuCountersFromTimes sums gopsutil times into busy and total counters. Guest time is
+// already part of user time on Linux, so it isn't added again.
+func cpuCountersFromTimes(t cpu.TimesStat) CPUCounters {
+	idle := t.Idle + t.Iowait
+	busy := t.User + t.Nice + t.System + t.Irq + t.Softirq + t.Steal
+	return CPUCounters{Busy: busy, Total: busy + idle}
+}
+
+// recordCPUSample stores a snapshot and returns the usage since the previous one, plus
+// per-core usage when both snapshots have the same cores. ready is false on the first
+// sample of a session, when there is nothing to compare against yet.
+func (a *App) recordCPUSample(key string, total CPUCounters, perCore []CPUCounters) (usage float64, coreUsage []float64, ready bool) {
+	a.monitoring.mutex.Lock()
+	defer a.monitoring.mutex.Unlock()
+
+	prev, exists := a.monitoring.cpuSamples[key]
+	state := &CPUSampleState{Total: total, PerCore: perCore}
+	a.monitoring.cpuSamples[key] = state
+	if !exists || prev == nil {
+		return 0, nil, false
+	}
+
+	usage, ready = cpuUsageDelta(prev.Total, total)
+	if !ready {
+		// Counters were reset or the poll came too soon - keep the last reading
+		state.LastUsage, state.Ready = prev.LastUsage, prev.Ready
+		return prev.LastUsage, nil, prev.Ready
+	}
+	state.LastUsage, state.Ready = usage, true
+
+	if len(prev.PerCore) == len(perCore) {
+		coreUsage = make([]float64, len(perCore))
+		for i := range perCore {
+			coreUsage[i], _ = cpuUsageDelta(prev.PerCore[i], perCore[i])
+		}
+	}
+	return usage, coreUsage, true
+}
+
+// lastCPUUsage returns the usage computed at the previous poll without taking a new sample
+func (a *App) lastCPUUsage(key string) (float64, bool) {
+	a.monitoring.mutex.RLock()
+	defer a.monitoring.mutex.RUnlock()
+
+	state, exists := a.monitoring.cpuSamples[key]
+	if !exists || state == nil || !state.Ready {
+		return 0, false
+	}
+	return state.LastUsage, true
+}
+
+// setCPUStats fills in the cpu fields of a stats map from a sample
+func setCPUStats(stats map[string]interface{}, usage float64, coreUsage []float64, ready bool) {
+	if !ready {
+		// The frontend shows a placeholder instead of a misleading 0%
+		stats["cpu"] = "unknown"
+		stats["cpu_warming_up"] = true
+		return
+	}
+	stats["cpu"] = fmt.Sprintf("%.1f%%", usage)
+	if len(coreUsage) > 0 {
+		cores := make([]string, len(coreUsage))
+		for i, core := range coreUsage {
+			cores[i] = fmt.Sprintf("%.1f%%", core)
+		}
+		stats["cpu_cores"] = cores
+	}
+}
+
+// sampleLocalCPU reads the local CPU counters and returns the usage since the previous poll
+func (a *App) sampleLocalCPU() (float64, []float64, bool, error) {
+	totals, err := cpu.Times(false)
+	if err != nil {
+		return 0, nil, false, err
+	}
+	if len(totals) == 0 {
+		return 0, nil, false, fmt.Errorf("no CPU usage data available")
+	}
+
+	var perCore []CPUCounters
+	if cores, err := cpu.Times(true); err == nil {
+		perCore = make([]CPUCounters, len(cores))
+		for i, core := range cores {
+			perCore[i] = cpuCountersFromTimes(core)
+		}
+	}
+
+	usage, coreUsage, ready := a.recordCPUSample(localCPUSampleKey, cpuCountersFromTimes(totals[0]), perCore)
+	return usage, coreUsage, ready, nil
+}
+
+// parseProcStatCPU parses the cpu lines of /proc/stat into aggregate and per-core counters.
+// Fields are user nice system idle iowait irq softirq steal [guest guest_nice], in clock ticks.
+func parseProcStatCPU(output string) (CPUCounters, []CPUCounters, error) {
+	var total CPUCounters
+	var perCore []CPUCounters
+	found := false
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+		values := make([]float64, 8)
+		for i := 1; i < len(fields) && i <= len(values); i++ {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return CPUCounters{}, nil, fmt.Errorf("invalid /proc/stat line: %q", line)
+			}
+			values[i-1] = value
+		}
+		idle := values[3] + values[4]
+		busy := values[0] + values[1] + values[2] + values[5] + values[6] + values[7]
+		counters := CPUCounters{Busy: busy, Total: busy + idle}
+
+		if fields[0] == "cpu" {
+			total = counters
+			found = true
+		} else {
+			perCore = append(perCore, counters)
+		}
+	}
+
+	if !found {
+		return CPUCounters{}, nil, fmt.Errorf("no aggregate cpu line in /proc/stat")
+	}
+	return total, perCore, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestCPUUsageDelta(t *testing.T) {
+	tests := []struct {
+		name      string
+		prev, cur CPUCounters
+		want      float64
+		wantOK    bool
+	}{
+		{"quarter busy", CPUCounters{Busy: 100, Total: 1000}, CPUCounters{Busy: 150, Total: 1200}, 25, true},
+		{"idle", CPUCounters{Busy: 100, Total: 1000}, CPUCounters{Busy: 100, Total: 1100}, 0, true},
+		{"no time passed", CPUCounters{Busy: 100, Total: 1000}, CPUCounters{Busy: 100, Total: 1000}, 0, false},
+		{"counter reset", CPUCounters{Busy: 100, Total: 1000}, CPUCounters{Busy: 5, Total: 20}, 0, false},
+		{"busy past total", CPUCounters{Busy: 0, Total: 1000}, CPUCounters{Busy: 500, Total: 1100}, 100, true},
+		{"busy went backwards", CPUCounters{Busy: 500, Total: 1000}, CPUCounters{Busy: 400, Total: 1100}, 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := cpuUsageDelta(tt.prev, tt.cur)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: cpuUsageDelta() = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestRecordCPUSample(t *testing.T) {
+	app := NewApp()
+	const key = "session_cpu"
+
+	if _, _, ready := app.recordCPUSample(key, CPUCounters{Busy: 0, Total: 0}, nil); ready {
+		t.Error("first sample reported a usage")
+	}
+	cores := []CPUCounters{{Busy: 10, Total: 100}, {Busy: 90, Total: 100}}
+	app.recordCPUSample(key, CPUCounters{Busy: 100, Total: 200}, cores)
+
+	next := []CPUCounters{{Busy: 10, Total: 200}, {Busy: 190, Total: 200}}
+	usage, coreUsage, ready := app.recordCPUSample(key, CPUCounters{Busy: 200, Total: 400}, next)
+	if !ready || usage != 50 || len(coreUsage) != 2 || coreUsage[0] != 0 || coreUsage[1] != 100 {
+		t.Errorf("recordCPUSample() = %v, %v, %v", usage, coreUsage, ready)
+	}
+
+	// A poll with no elapsed ticks keeps the previous reading
+	if usage, _, ready := app.recordCPUSample(key, CPUCounters{Busy: 200, Total: 400}, next); !ready || usage != 50 {
+		t.Errorf("recordCPUSample() after an empty window = %v, %v", usage, ready)
+	}
+}
+
+func TestParseProcStatCPU(t *testing.T) {
+	output := "cpu  100 0 50 800 50 0 0 0 20 0\ncpu0 50 0 25 400 25 0 0 0 10 0\ncpu1 50 0 25 400 25 0 0 0 10 0\n"
+	total, perCore, err := parseProcStatCPU(output)
+	if err != nil || total != (CPUCounters{Busy: 150, Total: 1000}) || len(perCore) != 2 {
+		t.Errorf("parseProcStatCPU() = %+v, %+v, %v", total, perCore, err)
+	}
+	if _, _, err := parseProcStatCPU("intr 1 2 3\n"); err == nil {
+		t.Error("parseProcStatCPU() accepted output without a cpu line")
+	}
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Diagnostics bundle files
+const (
+	DiagnosticsManifestFile   = "manifest.json"
+	DiagnosticsAppFile        = "app.json"
+	DiagnosticsConfigFile     = "config.yaml"
+	DiagnosticsSessionsFile   = "sessions.json"
+	DiagnosticsRuntimeFile    = "runtime.json"
+	DiagnosticsSFTPFile       = "sftp.json"
+	DiagnosticsGoroutinesFile = "goroutines.txt"
+)
+
+// DiagnosticsOptions selects what goes into a diagnostics bundle, one flag per file
+type DiagnosticsOptions struct {
+	AppInfo       bool `json:"appInfo"`       // Version, build and platform
+	Config        bool `json:"config"`        // Settings with secrets removed; paths are kept
+	Sessions      bool `json:"sessions"`      // Open tabs, their status and the per-session state they hold
+	Runtime       bool `json:"runtime"`       // Goroutine and memory statistics
+	SFTP          bool `json:"sftp"`          // SFTP settings in use for each open session
+	GoroutineDump bool `json:"goroutineDump"` // Stack of every goroutine - large, off by default
+	HashHostnames bool `json:"hashHostnames"` // Replace hostnames with hashes that are equal for equal hosts
+}
+
+// DiagnosticsFile is one file written to a bundle
+type DiagnosticsFile struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Size        int64  `json:"size"`
+}
+
+// DiagnosticsOmission is a part of the bundle that was left out and why
+type DiagnosticsOmission struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// DiagnosticsManifest lists what a bundle contains, so it can be reviewed before it is shared
+type DiagnosticsManifest struct {
+	Path           string                `json:"path"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	HashedHosts    bool                  `json:"hashedHosts"`
+	Files          []DiagnosticsFile     `json:"files"`
+	Omitted        []DiagnosticsOmission `json:"omitted"`
+	RedactedFields []string              `json:"redactedFields,omitempty"`
+}
+
+// diagnosticsNotCollected are parts of a bug report this version does not record
+var diagnosticsNotCollected = []DiagnosticsOmission{
+	{Name: "logs", Reason: "Thermic does not keep a log history; output goes to the console only"},
+	{Name: "connection-logs", Reason: "per-session connection logs are not recorded"},
+	{Name: "crash-reports", Reason: "crash reports are not recorded"},
+}
+
+// hostFieldPattern matches field names that hold a hostname or address
+var hostFieldPattern = regexp.MustCompile(`(?i)^(host|hostname|address|remote_?addr)$`)
+
+// diagnosticsRedactor strips secrets from everything written to a bundle and, if asked,
+// replaces hostnames with keyed hashes. The key is random per bundle, so a host hashes the
+// same everywhere in one bundle but can't be looked up by hashing guesses.
+type diagnosticsRedactor struct {
+	hashHosts bool
+	key       []byte
+	hosts     map[string]string // Original host -> replacement, for scrubbing free text
+	redacted  map[string]bool   // Names of fields that were redacted
+}
+
+func newDiagnosticsRedactor(hashHosts bool) *diagnosticsRedactor {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return &diagnosticsRedactor{hashHosts: hashHosts, key: key, hosts: make(map[string]string), redacted: make(map[string]bool)}
+}
+
+// host returns the hostname, or its hash when hostnames are hashed. A port is kept as is.
+func (r *diagnosticsRedactor) host(host string) string {
+	if !r.hashHosts || host == "" {
+		return host
+	}
+	if replacement, exists := r.hosts[host]; exists {
+		return replacement
+	}
+
+	name, port := host, ""
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		name, port = h, ":"+p
+	}
+
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(strings.ToLower(name)))
+	hashed := "host-" + hex.EncodeToString(mac.Sum(nil))[:12]
+	replacement := hashed + port
+	r.hosts[host] = replacement
+	if port != "" {
+		r.hosts[name] = hashed
+	}
+	return replacement
+}
+
+// text replaces every hostname seen so far in free text such as error messages
+func (r *diagnosticsRedactor) text(s string) string {
+	if !r.hashHosts || s == "" {
+		return s
+	}
+	// Longest first so "db.example.com:22" wins over "db.example.com"
+	hosts := make([]string, 0, len(r.hosts))
+	for host := range r.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Slice(hosts, func(i, j int) bool { return len(hosts[i]) > len(hosts[j]) })
+	for _, host := range hosts {
+		s = strings.ReplaceAll(s, host, r.hosts[host])
+	}
+	return s
+}
+
+// value redacts a decoded YAML/JSON value in place: secret fields are blanked, host fields
+// hashed and everything else, including paths, kept
+func (r *diagnosticsRedactor) value(v interface{}) interface{} {
+	switch typed := v.(type) {
+	case map[interface{}]interface{}:
+		for k, child := range typed {
+			typed[k] = r.field(fmt.Sprint(k), child)
+		}
+	case map[string]interface{}:
+		for k, child := range typed {
+			typed[k] = r.field(k, child)
+		}
+	case []interface{}:
+		for i, child := range typed {
+			typed[i] = r.value(child)
+		}
+	}
+	return v
+}
+
+func (r *diagnosticsRedactor) field(name string, v interface{}) interface{} {
+	switch {
+	case isSecretName(name):
+		if v == nil || v == "" {
+			return v
+		}
+		r.redacted[name] = true
+		return redactedValue
+	case hostFieldPattern.MatchString(name):
+		if s, ok := v.(string); ok {
+			return r.host(s)
+		}
+	}
+	return r.value(v)
+}
+
+// redactedFields returns the names of the fields that were blanked
+func (r *diagnosticsRedactor) redactedFields() []string {
+	fields := mapKeys(r.redacted)
+	sort.Strings(fields)
+	return fields
+}
+
+// GetDefaultDiagnosticsOptions returns the options the bug report dialog starts with
+func (a *App) GetDefaultDiagnosticsOptions() DiagnosticsOptions {
+	return DiagnosticsOptions{
+		AppInfo:       true,
+		Config:        true,
+		Sessions:      true,
+		Runtime:       true,
+		SFTP:          true,
+		HashHostnames: true,
+	}
+}
+
+// CreateDiagnosticsBundle writes a zip of the selected diagnostics to filePath for attaching
+// to a bug report, and returns a manifest of what it contains. The manifest is also stored
+// in the zip.
+func (a *App) CreateDiagnosticsBundle(filePath string, options DiagnosticsOptions) (*DiagnosticsManifest, error) {
+	if filePath == "" {
+		return nil, fmt.Errorf("bundle path cannot be empty")
+	}
+	if !strings.HasSuffix(strings.ToLower(filePath), ".zip") {
+		filePath += ".zip"
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create diagnostics bundle: %w", err)
+	}
+
+	manifest, err := a.writeDiagnosticsBundle(file, options)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filePath)
+		return nil, fmt.Errorf("failed to write diagnostics bundle: %w", err)
+	}
+
+	manifest.Path = filePath
+	fmt.Printf("Wrote diagnostics bundle %s (%d files)\n", filePath, len(manifest.Files))
+	return manifest, nil
+}
+
+// diagnosticsPart is one file a bundle may contain
+type diagnosticsPart struct {
+	name        string
+	description string
+	enabled     bool
+	write       func(w io.Writer, r *diagnosticsRedactor) error
+}
+
+// writeDiagnosticsBundle writes the zip to w
+func (a *App) writeDiagnosticsBundle(w io.Writer, options DiagnosticsOptions) (*DiagnosticsManifest, error) {
+	redactor := newDiagnosticsRedactor(options.HashHostnames)
+	manifest := &DiagnosticsManifest{
+		CreatedAt:   time.Now(),
+		HashedHosts: options.HashHostnames,
+		Files:       []DiagnosticsFile{},
+		Omitted:     append([]DiagnosticsOmission{}, diagnosticsNotCollected...),
+	}
+
+	// Sessions go before SFTP so hostnames in free text are already known to the redactor
+	parts := []diagnosticsPart{
+		{DiagnosticsAppFile, "Version, build and platform", options.AppInfo, a.writeDiagnosticsApp},
+		{DiagnosticsConfigFile, "Settings with secrets removed", options.Config, a.writeDiagnosticsConfig},
+		{DiagnosticsSessionsFile, "Open tabs and their per-session state", options.Sessions, a.writeDiagnosticsSessions},
+		{DiagnosticsRuntimeFile, "Goroutine and memory statistics", options.Runtime, a.writeDiagnosticsRuntime},
+		{DiagnosticsSFTPFile, "SFTP settings for open sessions", options.SFTP, a.writeDiagnosticsSFTP},
+		{DiagnosticsGoroutinesFile, "Stack dump of all goroutines", options.GoroutineDump, writeDiagnosticsGoroutines},
+	}
+
+	archive := zip.NewWriter(w)
+	for _, part := range parts {
+		if !part.enabled {
+			manifest.Omitted = append(manifest.Omitted, DiagnosticsOmission{Name: part.name, Reason: "excluded in options"})
+			continue
+		}
+
+		entry, err := archive.CreateHeader(&zip.FileHeader{Name: part.name, Method: zip.Deflate, Modified: manifest.CreatedAt})
+		if err != nil {
+			return nil, err
+		}
+		counter := &countingWriter{w: entry}
+		if err := part.write(counter, redactor); err != nil {
+			return nil, fmt.Errorf("%s: %w", part.name, err)
+		}
+		manifest.Files = append(manifest.Files, DiagnosticsFile{Name: part.name, Description: part.description, Size: counter.n})
+	}
+	manifest.RedactedFields = redactor.redactedFields()
+
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: DiagnosticsManifestFile, Method: zip.Deflate, Modified: manifest.CreatedAt})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeDiagnosticsJSON(entry, manifest); err != nil {
+		return nil, err
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func writeDiagnosticsJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func (a *App) writeDiagnosticsApp(w io.Writer, r *diagnosticsRedactor) error {
+	info := a.GetAppInfo()
+	info["numCpu"] = runtime.NumCPU()
+	if hostname, err := os.Hostname(); err == nil {
+		info["hostname"] = r.host(hostname)
+	}
+	return writeDiagnosticsJSON(w, info)
+}
+
+func (a *App) writeDiagnosticsConfig(w io.Writer, r *diagnosticsRedactor) error {
+	a.config.mutex.RLock()
+	data, err := yaml.Marshal(a.config.config)
+	a.config.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	var config map[interface{}]interface{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return err
+	}
+	data, err = yaml.Marshal(r.value(config))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// diagnosticsSession is one open tab in sessions.json
+type diagnosticsSession struct {
+	SessionID      string           `json:"sessionId"`
+	ConnectionType string           `json:"connectionType"`
+	Status         string           `json:"status"`
+	ErrorMessage   string           `json:"errorMessage,omitempty"`
+	Host           string           `json:"host,omitempty"`
+	Port           int              `json:"port,omitempty"`
+	Created        time.Time        `json:"created"`
+	LastActivity   time.Time        `json:"lastActivity,omitempty"`
+	Hanging        bool             `json:"hanging,omitempty"`
+	State          []string         `json:"state"` // Registry sources holding state for the session
+	SudoAudit      []SudoAuditEntry `json:"sudoAudit,omitempty"`
+}
+
+func (a *App) writeDiagnosticsSessions(w io.Writer, r *diagnosticsRedactor) error {
+	tabs := a.SnapshotTabs()
+
+	state := make(map[string][]string)
+	for _, source := range a.registry.snapshot() {
+		for _, sessionID := range source.List() {
+			state[sessionID] = append(state[sessionID], source.Name)
+		}
+	}
+
+	sessions := make([]diagnosticsSession, 0, len(tabs))
+	for _, tab := range tabs {
+		session := diagnosticsSession{
+			SessionID:      tab.SessionID,
+			ConnectionType: tab.ConnectionType,
+			Status:         tab.Status,
+			Created:        tab.Created,
+			State:          append([]string{}, state[tab.SessionID]...),
+		}
+		sort.Strings(session.State)
+		if tab.SSHConfig != nil {
+			session.Host = r.host(tab.SSHConfig.Host)
+			session.Port = tab.SSHConfig.Port
+		}
+
+		a.ssh.sshSessionsMutex.RLock()
+		sshSession, exists := a.ssh.sshSessions[tab.SessionID]
+		a.ssh.sshSessionsMutex.RUnlock()
+		if exists {
+			sshSession.mu.RLock()
+			session.LastActivity = sshSession.lastActivity
+			session.Hanging = sshSession.isHanging
+			sshSession.mu.RUnlock()
+			if sshSession.client != nil {
+				r.host(sshSession.client.RemoteAddr().String())
+			}
+		}
+		sessions = append(sessions, session)
+	}
+
+	// Error messages and sudo commands last, once every host of every tab is known
+	for i, tab := range tabs {
+		sessions[i].ErrorMessage = r.text(tab.ErrorMessage)
+		for _, entry := range a.GetSudoAuditLog(tab.SessionID) {
+			entry.Command = r.text(redactCommandSecrets(entry.Command))
+			sessions[i].SudoAudit = append(sessions[i].SudoAudit, entry)
+		}
+	}
+	return writeDiagnosticsJSON(w, sessions)
+}
+
+func (a *App) writeDiagnosticsRuntime(w io.Writer, r *diagnosticsRedactor) error {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	sessionState := make(map[string]int)
+	for _, source := range a.registry.snapshot() {
+		sessionState[source.Name] = len(source.List())
+	}
+
+	return writeDiagnosticsJSON(w, map[string]interface{}{
+		"goroutines":   runtime.NumGoroutine(),
+		"heapAlloc":    mem.HeapAlloc,
+		"heapInuse":    mem.HeapInuse,
+		"heapObjects":  mem.HeapObjects,
+		"sys":          mem.Sys,
+		"totalAlloc":   mem.TotalAlloc,
+		"numGC":        mem.NumGC,
+		"pauseTotalNs": mem.PauseTotalNs,
+		"sessionState": sessionState,
+		"orphaned":     a.findOrphanedState(),
+	})
+}
+
+func (a *App) writeDiagnosticsSFTP(w io.Writer, r *diagnosticsRedactor) error {
+	a.ssh.sftpClientsMutex.RLock()
+	sessionIDs := mapKeys(a.ssh.sftpClients)
+	a.ssh.sftpClientsMutex.RUnlock()
+
+	servers := make([]*SFTPServerInfo, 0, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		info, err := a.GetSFTPServerInfo(sessionID)
+		if err != nil {
+			continue // Closed since we listed it
+		}
+		info.Host = r.host(info.Host)
+		servers = append(servers, info)
+	}
+	return writeDiagnosticsJSON(w, servers)
+}
+
+func writeDiagnosticsGoroutines(w io.Writer, r *diagnosticsRedactor) error {
+	return pprof.Lookup("goroutine").WriteTo(w, 2)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiagnosticsRedactorHostnames(t *testing.T) {
+	r := newDiagnosticsRedactor(true)
+
+	first := r.host("db.example.com")
+	if first == "db.example.com" || !strings.HasPrefix(first, "host-") {
+		t.Fatalf("host() = %q, want a hash", first)
+	}
+	if again := r.host("DB.example.com"); again != first {
+		t.Errorf("same host hashed differently: %q and %q", first, again)
+	}
+	if withPort := r.host("db.example.com:2222"); withPort != first+":2222" {
+		t.Errorf("host with port = %q, want %q", withPort, first+":2222")
+	}
+	if other := r.host("web.example.com"); other == first {
+		t.Error("different hosts hashed the same")
+	}
+	if text := r.text("dial tcp db.example.com:2222: i/o timeout"); strings.Contains(text, "example.com") {
+		t.Errorf("text() left a hostname: %q", text)
+	}
+
+	// A new bundle uses a new key, so hashes can't be matched across bundles
+	if other := newDiagnosticsRedactor(true).host("db.example.com"); other == first {
+		t.Error("hash is the same in two bundles")
+	}
+	if plain := newDiagnosticsRedactor(false).host("db.example.com"); plain != "db.example.com" {
+		t.Errorf("host() without hashing = %q", plain)
+	}
+}
+
+func TestDiagnosticsRedactorValues(t *testing.T) {
+	r := newDiagnosticsRedactor(true)
+	config := map[interface{}]interface{}{
+		"ai":            map[interface{}]interface{}{"api_key": "sk-live", "model_id": "gpt"},
+		"privacy_lock":  map[interface{}]interface{}{"password_hash": "$2a$10$abc"},
+		"profiles_path": "/home/user/profiles",
+		"hotkey":        "ctrl+`",
+		"profile_sources": []interface{}{
+			map[interface{}]interface{}{"path": "/mnt/shared", "host": "nas.local"},
+		},
+	}
+	r.value(config)
+
+	if got := config["ai"].(map[interface{}]interface{})["api_key"]; got != redactedValue {
+		t.Errorf("api_key = %v", got)
+	}
+	if got := config["privacy_lock"].(map[interface{}]interface{})["password_hash"]; got != redactedValue {
+		t.Errorf("password_hash = %v", got)
+	}
+	if config["profiles_path"] != "/home/user/profiles" || config["hotkey"] != "ctrl+`" {
+		t.Errorf("non-secret fields changed: %v", config)
+	}
+	source := config["profile_sources"].([]interface{})[0].(map[interface{}]interface{})
+	if source["host"] == "nas.local" || source["path"] != "/mnt/shared" {
+		t.Errorf("profile source = %v", source)
+	}
+	if fields := r.redactedFields(); len(fields) != 2 || fields[0] != "api_key" || fields[1] != "password_hash" {
+		t.Errorf("redactedFields() = %v", fields)
+	}
+}
+
+func TestCreateDiagnosticsBundle(t *testing.T) {
+	app := NewApp()
+	app.config.config.AI.APIKey = "sk-secret-value"
+	app.terminal.tabs["t1"] = &Tab{ID: "t1", Title: "db", SessionID: "s1", ConnectionType: "ssh", Status: "failed",
+		SSHConfig: &SSHConfig{Host: "db.internal", Port: 22}, ErrorMessage: "dial tcp db.internal:22: connection refused"}
+	recordSudoInvocation("s1", "Test", remoteCommand("sudo env DB_PASSWORD='hunter2' tool --token abc123 -- '/srv/db.internal'"))
+	defer forgetSudoAuditLog("s1")
+
+	options := app.GetDefaultDiagnosticsOptions()
+	options.SFTP = false
+	manifest, err := app.CreateDiagnosticsBundle(filepath.Join(t.TempDir(), "report"), options)
+	if err != nil {
+		t.Fatalf("CreateDiagnosticsBundle() returned error: %v", err)
+	}
+	if !strings.HasSuffix(manifest.Path, "report.zip") {
+		t.Errorf("bundle path = %q", manifest.Path)
+	}
+
+	archive, err := zip.OpenReader(manifest.Path)
+	if err != nil {
+		t.Fatalf("failed to open bundle: %v", err)
+	}
+	defer archive.Close()
+
+	contents := make(map[string]string)
+	for _, file := range archive.File {
+		rc, _ := file.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		contents[file.Name] = string(data)
+	}
+
+	for _, file := range manifest.Files {
+		if _, exists := contents[file.Name]; !exists {
+			t.Errorf("manifest lists %s but the bundle lacks it", file.Name)
+		}
+	}
+	if _, exists := contents[DiagnosticsSFTPFile]; exists {
+		t.Error("excluded sftp.json is in the bundle")
+	}
+	if _, exists := contents[DiagnosticsGoroutinesFile]; exists {
+		t.Error("goroutine dump is in the bundle though it is off by default")
+	}
+	for name, data := range contents {
+		if strings.Contains(data, "sk-secret-value") || strings.Contains(data, "db.internal") || strings.Contains(data, "hunter2") || strings.Contains(data, "abc123") {
+			t.Errorf("%s leaks a secret or hostname", name)
+		}
+	}
+
+	if !strings.Contains(contents[DiagnosticsSessionsFile], "tool --token ") {
+		t.Errorf("sudo command missing from sessions.json: %s", contents[DiagnosticsSessionsFile])
+	}
+
+	var stored DiagnosticsManifest
+	if err := json.Unmarshal([]byte(contents[DiagnosticsManifestFile]), &stored); err != nil {
+		t.Fatalf("failed to parse stored manifest: %v", err)
+	}
+	if len(stored.Files) != len(manifest.Files) || len(stored.Omitted) != len(manifest.Omitted) {
+		t.Errorf("stored manifest differs from the returned one: %+v", stored)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Directory entry count constants
+const (
+	DirectoryCountCacheTTL       = 30 * time.Second
+	maxDirectoryCountsPerSession = 2000 // Cached paths per session before the cache is pruned
+)
+
+// directoryCount is a cached number of entries in a remote directory
+type directoryCount struct {
+	count   int
+	fetched time.Time
+}
+
+var directoryCountCache = make(map[string]map[string]directoryCount) // Session -> path -> count
+var directoryCountCacheMu sync.Mutex
+
+// cachedDirectoryCount returns a fresh cached count for a path
+func cachedDirectoryCount(sessionID, remotePath string) (int, bool) {
+	directoryCountCacheMu.Lock()
+	defer directoryCountCacheMu.Unlock()
+
+	cached, exists := directoryCountCache[sessionID][remotePath]
+	if !exists || time.Since(cached.fetched) >= DirectoryCountCacheTTL {
+		return 0, false
+	}
+	return cached.count, true
+}
+
+// storeDirectoryCount caches a count, dropping expired entries once the session has many
+func storeDirectoryCount(sessionID, remotePath string, count int) {
+	directoryCountCacheMu.Lock()
+	defer directoryCountCacheMu.Unlock()
+
+	counts, exists := directoryCountCache[sessionID]
+	if !exists {
+		counts = make(map[string]directoryCount)
+		directoryCountCache[sessionID] = counts
+	}
+	if len(counts) >= maxDirectoryCountsPerSession {
+		for path, cached := range counts {
+			if time.Since(cached.fetched) >= DirectoryCountCacheTTL {
+				delete(counts, path)
+			}
+		}
+		if len(counts) >= maxDirectoryCountsPerSession {
+			clear(counts)
+		}
+	}
+	counts[remotePath] = directoryCount{count: count, fetched: time.Now()}
+}
+
+// clearDirectoryCounts drops the cached counts of a session
+func clearDirectoryCounts(sessionID string) {
+	directoryCountCacheMu.Lock()
+	defer directoryCountCacheMu.Unlock()
+	delete(directoryCountCache, sessionID)
+}
+
+// CountRemoteDirectoryEntries returns how many entries (including hidden ones) a remote
+// directory holds, without transferring the listing when the host can count them itself.
+// Results are cached for DirectoryCountCacheTTL so folder badges can be shown cheaply.
+func (a *App) CountRemoteDirectoryEntries(sessionID, remotePath string) (int, error) {
+	if remotePath == "" {
+		remotePath = "."
+	}
+	if count, ok := cachedDirectoryCount(sessionID, remotePath); ok {
+		return count, nil
+	}
+
+	count, err := a.countRemoteDirectoryEntriesRemotely(sessionID, remotePath)
+	if err != nil {
+		// No monitoring session or the shell couldn't read it - count an SFTP listing instead
+		sftpClient, sftpErr := a.getOrReconnectSFTPClient(sessionID)
+		if sftpErr != nil {
+			return 0, sftpErr
+		}
+		entries, readErr := sftpClient.ReadDir(remotePath)
+		if readErr != nil {
+			return 0, fmt.Errorf("failed to read directory %s: %w", remotePath, readErr)
+		}
+		count = len(entries)
+	}
+
+	storeDirectoryCount(sessionID, remotePath, count)
+	return count, nil
+}
+
+// countRemoteDirectoryEntriesRemotely counts entries with ls on the host, over the monitoring connection
+func (a *App) countRemoteDirectoryEntriesRemotely(sessionID, remotePath string) (int, error) {
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+	if !exists || sshSession == nil {
+		return 0, newNotFoundError(ErrCategorySFTP, "CountRemoteDirectoryEntries", "SSH session %s not found", sessionID)
+	}
+
+	cmd := buildRemoteCommand("if [ -d %[1]s ] && [ -r %[1]s ] && [ -x %[1]s ]; then ls -1A -- %[1]s | wc -l; else echo unreadable; fi", remotePath)
+	output, err := a.executeMonitoringCommand(sshSession, cmd)
+	if err != nil {
+		return 0, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(output))
+	if err != nil {
+		return 0, fmt.Errorf("cannot count entries of %s: %s", remotePath, strings.TrimSpace(output))
+	}
+	return count, nil
+}
+
+// CountLocalDirectoryEntries returns how many entries a local directory holds
+func (a *App) CountLocalDirectoryEntries(localPath string) (int, error) {
+	entries, err := os.ReadDir(localPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read directory %s: %w", localPath, err)
+	}
+	return len(entries), nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDirectoryCountCache(t *testing.T) {
+	const sessionID = "session_counts"
+	defer clearDirectoryCounts(sessionID)
+
+	storeDirectoryCount(sessionID, "/srv", 42)
+	if count, ok := cachedDirectoryCount(sessionID, "/srv"); !ok || count != 42 {
+		t.Errorf("cachedDirectoryCount() = %d, %v, want 42", count, ok)
+	}
+
+	directoryCountCacheMu.Lock()
+	directoryCountCache[sessionID]["/srv"] = directoryCount{count: 42, fetched: time.Now().Add(-DirectoryCountCacheTTL)}
+	directoryCountCacheMu.Unlock()
+	if _, ok := cachedDirectoryCount(sessionID, "/srv"); ok {
+		t.Error("expired count was served from the cache")
+	}
+
+	for i := 0; i <= maxDirectoryCountsPerSession; i++ {
+		storeDirectoryCount(sessionID, filepath.Join("/d", string(rune('a'+i%26)), time.Duration(i).String()), i)
+	}
+	directoryCountCacheMu.Lock()
+	size := len(directoryCountCache[sessionID])
+	directoryCountCacheMu.Unlock()
+	if size > maxDirectoryCountsPerSession {
+		t.Errorf("cache holds %d paths, limit is %d", size, maxDirectoryCountsPerSession)
+	}
+}
+
+func TestCountLocalDirectoryEntries(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", ".hidden", "b"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0600)
+	}
+	os.Mkdir(filepath.Join(dir, "sub"), 0700)
+
+	app := NewApp()
+	if count, err := app.CountLocalDirectoryEntries(dir); err != nil || count != 4 {
+		t.Errorf("CountLocalDirectoryEntries() = %d, %v, want 4", count, err)
+	}
+	if _, err := app.CountLocalDirectoryEntries(filepath.Join(dir, "missing")); err == nil {
+		t.Error("CountLocalDirectoryEntries() accepted a missing directory")
+	}
+}
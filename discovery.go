@@ -0,0 +1,615 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Discovery provider types
+const (
+	DiscoveryProviderAWSEC2    = "aws-ec2"   // aws ec2 describe-instances
+	DiscoveryProviderTailscale = "tailscale" // tailscale status --json
+	DiscoveryProviderCommand   = "command"   // A user command printing a JSON array of hosts
+)
+
+// Discovery constants
+const (
+	DiscoveryCommandTimeout   = 60 * time.Second
+	maxDiscoveryOutputBytes   = 32 * 1024 * 1024
+	maxDiscoveryErrorExcerpt  = 512
+	tailscaleMacOSApplication = "/Applications/Tailscale.app/Contents/MacOS/Tailscale"
+)
+
+var discoveryEnvNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// DiscoveryProviderConfig is a configured source of hosts
+type DiscoveryProviderConfig struct {
+	ID          string `yaml:"id" json:"id"`
+	Name        string `yaml:"name" json:"name"`
+	Type        string `yaml:"type" json:"type"`                                    // DiscoveryProvider*
+	DefaultUser string `yaml:"default_user,omitempty" json:"defaultUser,omitempty"` // Login user when the provider suggests none
+	DefaultPort int    `yaml:"default_port,omitempty" json:"defaultPort,omitempty"` // SSH port when the provider gives none (0 = 22)
+	// aws-ec2
+	Region           string   `yaml:"region,omitempty" json:"region,omitempty"`
+	AWSProfile       string   `yaml:"aws_profile,omitempty" json:"awsProfile,omitempty"`              // Named profile from ~/.aws/config
+	Filters          []string `yaml:"filters,omitempty" json:"filters,omitempty"`                     // --filters values, e.g. "Name=tag:Role,Values=web"
+	UsePublicAddress bool     `yaml:"use_public_address,omitempty" json:"usePublicAddress,omitempty"` // Connect to the public IP instead of the private one
+	// command
+	Command string `yaml:"command,omitempty" json:"command,omitempty"` // Run through the platform shell
+	// SecretEnv sets environment variables from keychain secrets (variable -> secret name),
+	// e.g. AWS_SECRET_ACCESS_KEY, so credentials never sit in the config file
+	SecretEnv map[string]string `yaml:"secret_env,omitempty" json:"secretEnv,omitempty"`
+}
+
+// Validate checks the provider type and its required fields
+func (c *DiscoveryProviderConfig) Validate() error {
+	if c.ID == "" {
+		return fmt.Errorf("discovery provider ID cannot be empty")
+	}
+	if c.Name == "" {
+		return fmt.Errorf("discovery provider name cannot be empty")
+	}
+	switch c.Type {
+	case DiscoveryProviderAWSEC2, DiscoveryProviderTailscale:
+	case DiscoveryProviderCommand:
+		if strings.TrimSpace(c.Command) == "" {
+			return fmt.Errorf("discovery provider %s needs a command", c.Name)
+		}
+	default:
+		return fmt.Errorf("invalid discovery provider type: '%s'. Allowed types are: %s, %s, %s",
+			c.Type, DiscoveryProviderAWSEC2, DiscoveryProviderTailscale, DiscoveryProviderCommand)
+	}
+	if c.DefaultPort < 0 || c.DefaultPort > 65535 {
+		return fmt.Errorf("discovery provider default port must be between 1 and 65535, got: %d", c.DefaultPort)
+	}
+	for variable, secret := range c.SecretEnv {
+		if !discoveryEnvNamePattern.MatchString(variable) {
+			return fmt.Errorf("invalid environment variable name '%s'", variable)
+		}
+		if err := validateSessionSecretName(secret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DiscoveredHost is a host reported by a discovery provider
+type DiscoveredHost struct {
+	ProviderID string   `json:"providerId"`
+	HostID     string   `json:"hostId"` // Stable ID within the provider (instance ID, node ID)
+	Name       string   `json:"name"`
+	Address    string   `json:"address"`
+	Port       int      `json:"port"`
+	Tags       []string `json:"tags,omitempty"`
+	User       string   `json:"user,omitempty"`      // Suggested login user
+	ProfileID  string   `json:"profileId,omitempty"` // Profile already managing this host
+}
+
+// DiscoveryInfo marks a profile as managed by a discovery provider
+type DiscoveryInfo struct {
+	ProviderID string    `yaml:"provider_id" json:"providerId"`
+	HostID     string    `yaml:"host_id" json:"hostId"`
+	LastSynced time.Time `yaml:"last_synced" json:"lastSynced"`
+	Vanished   bool      `yaml:"vanished,omitempty" json:"vanished,omitempty"` // Missing from the provider's last run
+}
+
+// DiscoveryProvider lists the hosts a source currently knows about
+type DiscoveryProvider interface {
+	List() ([]DiscoveredHost, error)
+}
+
+// runDiscoveryCommand runs a provider command and returns its standard output
+var runDiscoveryCommand = func(ctx context.Context, env []string, cmd *exec.Cmd) ([]byte, error) {
+	var stderr strings.Builder
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %s", DiscoveryCommandTimeout)
+	}
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if len(message) > maxDiscoveryErrorExcerpt {
+			message = message[:maxDiscoveryErrorExcerpt] + "..."
+		}
+		if message != "" {
+			return nil, fmt.Errorf("%w: %s", err, message)
+		}
+		return nil, err
+	}
+	if len(output) > maxDiscoveryOutputBytes {
+		return nil, fmt.Errorf("output exceeds %d bytes", maxDiscoveryOutputBytes)
+	}
+	return output, nil
+}
+
+// awsEC2Discovery lists running EC2 instances with the aws CLI, so the SDK isn't bundled
+type awsEC2Discovery struct {
+	cfg DiscoveryProviderConfig
+	env []string
+}
+
+func (d *awsEC2Discovery) List() ([]DiscoveredHost, error) {
+	args := []string{"ec2", "describe-instances", "--output", "json"}
+	if d.cfg.Region != "" {
+		args = append(args, "--region", d.cfg.Region)
+	}
+	if d.cfg.AWSProfile != "" {
+		args = append(args, "--profile", d.cfg.AWSProfile)
+	}
+	if len(d.cfg.Filters) > 0 {
+		args = append(append(args, "--filters"), d.cfg.Filters...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DiscoveryCommandTimeout)
+	defer cancel()
+	output, err := runDiscoveryCommand(ctx, d.env, exec.CommandContext(ctx, "aws", args...))
+	if err != nil {
+		return nil, fmt.Errorf("aws ec2 describe-instances failed: %w", err)
+	}
+	return parseEC2Instances(output, d.cfg.UsePublicAddress)
+}
+
+// parseEC2Instances turns describe-instances output into hosts, skipping stopped instances
+func parseEC2Instances(output []byte, usePublicAddress bool) ([]DiscoveredHost, error) {
+	var result struct {
+		Reservations []struct {
+			Instances []struct {
+				InstanceID       string `json:"InstanceId"`
+				PrivateIPAddress string `json:"PrivateIpAddress"`
+				PublicIPAddress  string `json:"PublicIpAddress"`
+				State            struct {
+					Name string `json:"Name"`
+				} `json:"State"`
+				Tags []struct {
+					Key   string `json:"Key"`
+					Value string `json:"Value"`
+				} `json:"Tags"`
+			} `json:"Instances"`
+		} `json:"Reservations"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("invalid describe-instances output: %w", err)
+	}
+
+	var hosts []DiscoveredHost
+	for _, reservation := range result.Reservations {
+		for _, instance := range reservation.Instances {
+			if instance.State.Name != "running" {
+				continue
+			}
+			host := DiscoveredHost{HostID: instance.InstanceID, Name: instance.InstanceID, Address: instance.PrivateIPAddress}
+			if usePublicAddress && instance.PublicIPAddress != "" {
+				host.Address = instance.PublicIPAddress
+			}
+			for _, tag := range instance.Tags {
+				if tag.Key == "Name" && tag.Value != "" {
+					host.Name = tag.Value
+				} else if tag.Key != "Name" {
+					host.Tags = append(host.Tags, tag.Key+"="+tag.Value)
+				}
+			}
+			sort.Strings(host.Tags)
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, nil
+}
+
+// tailscaleDiscovery lists the peers of the local tailnet
+type tailscaleDiscovery struct {
+	cfg DiscoveryProviderConfig
+	env []string
+}
+
+func (d *tailscaleDiscovery) List() ([]DiscoveredHost, error) {
+	binary := "tailscale"
+	if _, err := exec.LookPath(binary); err != nil && runtime.GOOS == "darwin" {
+		// The App Store build has no CLI on PATH
+		if _, statErr := os.Stat(tailscaleMacOSApplication); statErr == nil {
+			binary = tailscaleMacOSApplication
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DiscoveryCommandTimeout)
+	defer cancel()
+	output, err := runDiscoveryCommand(ctx, d.env, exec.CommandContext(ctx, binary, "status", "--json"))
+	if err != nil {
+		return nil, fmt.Errorf("tailscale status failed: %w", err)
+	}
+	return parseTailscaleStatus(output)
+}
+
+// parseTailscaleStatus turns tailscale status --json output into hosts, one per peer
+func parseTailscaleStatus(output []byte) ([]DiscoveredHost, error) {
+	var status struct {
+		Peer map[string]struct {
+			ID           string   `json:"ID"`
+			HostName     string   `json:"HostName"`
+			DNSName      string   `json:"DNSName"`
+			TailscaleIPs []string `json:"TailscaleIPs"`
+			Tags         []string `json:"Tags"`
+		} `json:"Peer"`
+	}
+	if err := json.Unmarshal(output, &status); err != nil {
+		return nil, fmt.Errorf("invalid tailscale status output: %w", err)
+	}
+
+	var hosts []DiscoveredHost
+	for key, peer := range status.Peer {
+		host := DiscoveredHost{HostID: peer.ID, Name: peer.HostName, Address: strings.TrimSuffix(peer.DNSName, ".")}
+		if host.HostID == "" {
+			host.HostID = key
+		}
+		if host.Address == "" && len(peer.TailscaleIPs) > 0 {
+			host.Address = peer.TailscaleIPs[0]
+		}
+		for _, tag := range peer.Tags {
+			host.Tags = append(host.Tags, strings.TrimPrefix(tag, "tag:"))
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+// commandDiscovery runs a user command that prints a JSON array of
+// {"id", "name", "address", "port", "tags", "user"} objects
+type commandDiscovery struct {
+	cfg DiscoveryProviderConfig
+	env []string
+}
+
+func (d *commandDiscovery) List() ([]DiscoveredHost, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DiscoveryCommandTimeout)
+	defer cancel()
+	output, err := runDiscoveryCommand(ctx, d.env, hookShellCommand(ctx, d.cfg.Command))
+	if err != nil {
+		return nil, fmt.Errorf("discovery command failed: %w", err)
+	}
+
+	var entries []struct {
+		ID      string   `json:"id"`
+		Name    string   `json:"name"`
+		Address string   `json:"address"`
+		Port    int      `json:"port"`
+		Tags    []string `json:"tags"`
+		User    string   `json:"user"`
+	}
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("discovery command must print a JSON array of hosts: %w", err)
+	}
+	hosts := make([]DiscoveredHost, 0, len(entries))
+	for _, entry := range entries {
+		hosts = append(hosts, DiscoveredHost{HostID: entry.ID, Name: entry.Name, Address: entry.Address, Port: entry.Port, Tags: entry.Tags, User: entry.User})
+	}
+	return hosts, nil
+}
+
+// newDiscoveryProvider builds the provider for a config; env holds resolved secrets
+func newDiscoveryProvider(cfg DiscoveryProviderConfig, env []string) (DiscoveryProvider, error) {
+	switch cfg.Type {
+	case DiscoveryProviderAWSEC2:
+		return &awsEC2Discovery{cfg: cfg, env: env}, nil
+	case DiscoveryProviderTailscale:
+		return &tailscaleDiscovery{cfg: cfg, env: env}, nil
+	case DiscoveryProviderCommand:
+		return &commandDiscovery{cfg: cfg, env: env}, nil
+	default:
+		return nil, fmt.Errorf("unknown discovery provider type: %s", cfg.Type)
+	}
+}
+
+// resolveDiscoverySecrets reads the provider's keychain secrets into environment variables
+func resolveDiscoverySecrets(cfg DiscoveryProviderConfig) ([]string, error) {
+	env := make([]string, 0, len(cfg.SecretEnv))
+	for variable, name := range cfg.SecretEnv {
+		secret, err := readKeychainSecret(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret %s for %s: %w", name, variable, err)
+		}
+		env = append(env, variable+"="+string(secret))
+		wipeSecret(secret)
+	}
+	return env, nil
+}
+
+// normalizeDiscoveredHosts fills in defaults and drops hosts without an address or ID
+func normalizeDiscoveredHosts(cfg DiscoveryProviderConfig, hosts []DiscoveredHost) []DiscoveredHost {
+	seen := make(map[string]bool, len(hosts))
+	normalized := make([]DiscoveredHost, 0, len(hosts))
+	for _, host := range hosts {
+		if host.Address == "" {
+			continue
+		}
+		if host.HostID == "" {
+			host.HostID = host.Address
+		}
+		if seen[host.HostID] {
+			continue
+		}
+		seen[host.HostID] = true
+
+		host.ProviderID = cfg.ID
+		if host.Name == "" {
+			host.Name = host.Address
+		}
+		if host.Port <= 0 || host.Port > 65535 {
+			host.Port = cfg.DefaultPort
+			if host.Port == 0 {
+				host.Port = 22
+			}
+		}
+		if host.User == "" {
+			host.User = cfg.DefaultUser
+		}
+		normalized = append(normalized, host)
+	}
+	sort.Slice(normalized, func(i, j int) bool { return normalized[i].Name < normalized[j].Name })
+	return normalized
+}
+
+// discoveryProviderConfig returns a copy of a configured provider
+func (a *App) discoveryProviderConfig(providerID string) (DiscoveryProviderConfig, bool) {
+	a.config.mutex.RLock()
+	defer a.config.mutex.RUnlock()
+	for _, cfg := range a.config.config.DiscoveryProviders {
+		if cfg.ID == providerID {
+			return cfg, true
+		}
+	}
+	return DiscoveryProviderConfig{}, false
+}
+
+// GetDiscoveryProviders returns the configured discovery providers
+func (a *App) GetDiscoveryProviders() []DiscoveryProviderConfig {
+	a.config.mutex.RLock()
+	defer a.config.mutex.RUnlock()
+	return append([]DiscoveryProviderConfig{}, a.config.config.DiscoveryProviders...)
+}
+
+// SaveDiscoveryProvider adds a provider, or replaces the one with the same ID. A new
+// provider without an ID is given one. Returns the saved provider.
+func (a *App) SaveDiscoveryProvider(cfg DiscoveryProviderConfig) (DiscoveryProviderConfig, error) {
+	if cfg.ID == "" {
+		cfg.ID = generateID()
+	}
+	if err := cfg.Validate(); err != nil {
+		return DiscoveryProviderConfig{}, err
+	}
+
+	a.config.mutex.Lock()
+	providers := a.config.config.DiscoveryProviders
+	replaced := false
+	for i := range providers {
+		if providers[i].ID == cfg.ID {
+			providers[i] = cfg
+			replaced = true
+		}
+	}
+	if !replaced {
+		a.config.config.DiscoveryProviders = append(providers, cfg)
+	}
+	a.config.mutex.Unlock()
+
+	a.markConfigDirty()
+	return cfg, nil
+}
+
+// DeleteDiscoveryProvider removes a provider. Profiles it created are kept.
+func (a *App) DeleteDiscoveryProvider(providerID string) error {
+	a.config.mutex.Lock()
+	providers := a.config.config.DiscoveryProviders
+	kept := make([]DiscoveryProviderConfig, 0, len(providers))
+	for _, cfg := range providers {
+		if cfg.ID != providerID {
+			kept = append(kept, cfg)
+		}
+	}
+	found := len(kept) != len(providers)
+	a.config.config.DiscoveryProviders = kept
+	a.config.mutex.Unlock()
+
+	if !found {
+		return newNotFoundError(ErrCategoryConfig, "DeleteDiscoveryProvider", "discovery provider %s not found", providerID)
+	}
+	a.markConfigDirty()
+	return nil
+}
+
+// RunDiscovery lists the hosts a provider knows about. Hosts that already have a profile carry
+// its ID; profiles of this provider whose host is gone are flagged as vanished (and unflagged
+// when the host comes back) rather than deleted.
+func (a *App) RunDiscovery(providerID string) ([]DiscoveredHost, error) {
+	cfg, exists := a.discoveryProviderConfig(providerID)
+	if !exists {
+		return nil, newNotFoundError(ErrCategoryConfig, "RunDiscovery", "discovery provider %s not found", providerID)
+	}
+	env, err := resolveDiscoverySecrets(cfg)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := newDiscoveryProvider(cfg, env)
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	hosts, err := provider.List()
+	if err != nil {
+		return nil, fmt.Errorf("discovery with %s failed: %w", cfg.Name, err)
+	}
+	hosts = normalizeDiscoveredHosts(cfg, hosts)
+	a.reconcileDiscoveredProfiles(providerID, hosts)
+
+	fmt.Printf("Discovery %s found %d hosts in %s\n", cfg.Name, len(hosts), time.Since(started).Round(time.Millisecond))
+	return hosts, nil
+}
+
+// reconcileDiscoveredProfiles links hosts to their profiles and updates the vanished flags
+func (a *App) reconcileDiscoveredProfiles(providerID string, hosts []DiscoveredHost) {
+	index := make(map[string]int, len(hosts))
+	for i, host := range hosts {
+		index[host.HostID] = i
+	}
+
+	a.profiles.mutex.Lock()
+	defer a.profiles.mutex.Unlock()
+
+	for _, profile := range a.profiles.profiles {
+		if profile.Discovery == nil || profile.Discovery.ProviderID != providerID {
+			continue
+		}
+		i, found := index[profile.Discovery.HostID]
+		if found {
+			hosts[i].ProfileID = profile.ID
+		}
+		if profile.Discovery.Vanished == !found {
+			continue
+		}
+		profile.Discovery.Vanished = !found
+		if err := a.saveProfileInternal(profile); err != nil {
+			fmt.Printf("Warning: Failed to update discovery state of profile %s: %v\n", profile.ID, err)
+		}
+	}
+}
+
+// discoveredProfileFromTemplate copies a template profile for a host. Maps, slices and
+// pointers are copied so the new profile shares nothing with the template.
+func discoveredProfileFromTemplate(template *Profile, host DiscoveredHost, folderID string, now time.Time) *Profile {
+	profile := &Profile{Type: ProfileTypeSSH, Icon: "🖥️", Environment: make(map[string]string)}
+	sshConfig := SSHConfig{Port: 22}
+	if template != nil {
+		copied := *template
+		profile = &copied
+		profile.Environment = make(map[string]string, len(template.Environment))
+		for key, value := range template.Environment {
+			profile.Environment[key] = value
+		}
+		profile.Shortcuts = nil
+		if template.Shortcuts != nil {
+			profile.Shortcuts = make(map[string]string, len(template.Shortcuts))
+			for key, value := range template.Shortcuts {
+				profile.Shortcuts[key] = value
+			}
+		}
+		profile.PreConnectHooks = append([]ConnectHook(nil), template.PreConnectHooks...)
+		profile.PostConnectHooks = append([]ConnectHook(nil), template.PostConnectHooks...)
+		profile.NomadConfig = nil
+		profile.FileHistory = nil
+		if template.SSHConfig != nil {
+			sshConfig = *template.SSHConfig
+			if template.SSHConfig.Proxy != nil {
+				proxy := *template.SSHConfig.Proxy
+				sshConfig.Proxy = &proxy
+			}
+		}
+	}
+
+	profile.ID = generateID()
+	profile.Name = host.Name
+	profile.Type = ProfileTypeSSH
+	profile.FolderID = folderID
+	profile.SortOrder = 0
+	profile.Created = now
+	profile.LastModified = now
+	profile.LastUsed = time.Time{}
+	profile.UsageCount = 0
+	profile.IsFavorite = false
+
+	sshConfig.Host = host.Address
+	sshConfig.Port = host.Port
+	if host.User != "" {
+		sshConfig.Username = host.User
+	}
+	profile.SSHConfig = &sshConfig
+
+	var templateTags []string
+	if template != nil {
+		templateTags = template.Tags
+	}
+	profile.Tags = mergeKeys(templateTags, host.Tags)
+	profile.Discovery = &DiscoveryInfo{ProviderID: host.ProviderID, HostID: host.HostID, LastSynced: now}
+	return profile
+}
+
+// MaterializeDiscoveredHosts creates a profile in targetFolderID for each selected host,
+// copied from templateProfileID (or a bare SSH profile when empty). A host that already
+// has a profile is not duplicated: its profile gets the host's current address, port and
+// any new tags. Returns the created and updated profiles.
+func (a *App) MaterializeDiscoveredHosts(selected []DiscoveredHost, targetFolderID, templateProfileID string) ([]*Profile, error) {
+	a.profiles.mutex.Lock()
+	defer a.profiles.mutex.Unlock()
+
+	var template *Profile
+	if templateProfileID != "" {
+		var exists bool
+		template, exists = a.profiles.profiles[templateProfileID]
+		if !exists {
+			return nil, newNotFoundError(ErrCategoryProfile, "MaterializeDiscoveredHosts", "template profile not found: %s", templateProfileID)
+		}
+		if template.Type != ProfileTypeSSH {
+			return nil, fmt.Errorf("template profile %s is not an SSH profile", template.Name)
+		}
+	}
+	if targetFolderID != "" {
+		if _, exists := a.profiles.profileFolders[targetFolderID]; !exists {
+			return nil, newNotFoundError(ErrCategoryProfile, "MaterializeDiscoveredHosts", "folder with ID %s not found", targetFolderID)
+		}
+	}
+
+	managed := make(map[string]*Profile)
+	for _, profile := range a.profiles.profiles {
+		if profile.Discovery != nil {
+			managed[profile.Discovery.ProviderID+"/"+profile.Discovery.HostID] = profile
+		}
+	}
+
+	now := time.Now()
+	var saved []*Profile
+	for _, host := range selected {
+		if host.ProviderID == "" || host.HostID == "" || host.Address == "" {
+			return saved, fmt.Errorf("host %s is missing its provider, ID or address", host.Name)
+		}
+		if host.Port == 0 {
+			host.Port = 22
+		}
+
+		profile, exists := managed[host.ProviderID+"/"+host.HostID]
+		if exists {
+			if profile.SSHConfig == nil {
+				profile.SSHConfig = &SSHConfig{Username: host.User}
+			}
+			profile.SSHConfig.Host = host.Address
+			profile.SSHConfig.Port = host.Port
+			profile.Tags = mergeKeys(profile.Tags, host.Tags)
+			profile.Discovery.Vanished = false
+			profile.Discovery.LastSynced = now
+		} else {
+			if len(a.profiles.profiles) >= MaxProfiles {
+				return saved, fmt.Errorf("profile limit reached (%d)", MaxProfiles)
+			}
+			profile = discoveredProfileFromTemplate(template, host, targetFolderID, now)
+			if err := a.validateProfile(profile); err != nil {
+				return saved, &ProfileError{Op: "create", ProfileID: profile.ID, Err: err}
+			}
+		}
+
+		if err := a.saveProfileInternal(profile); err != nil {
+			return saved, &ProfileError{Op: "save", ProfileID: profile.ID, Err: err}
+		}
+		managed[host.ProviderID+"/"+host.HostID] = profile
+		saved = append(saved, profile)
+	}
+
+	fmt.Printf("Materialized %d discovered hosts\n", len(saved))
+	return saved, nil
+}
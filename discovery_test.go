@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+)
+
+func TestParseDiscoveryOutput(t *testing.T) {
+	ec2 := `{"Reservations":[{"Instances":[
+		{"InstanceId":"i-1","PrivateIpAddress":"10.0.0.5","PublicIpAddress":"3.3.3.3","State":{"Name":"running"},
+		 "Tags":[{"Key":"Name","Value":"web-1"},{"Key":"Role","Value":"web"}]},
+		{"InstanceId":"i-2","PrivateIpAddress":"10.0.0.6","State":{"Name":"stopped"}}]}]}`
+	hosts, err := parseEC2Instances([]byte(ec2), true)
+	if err != nil || len(hosts) != 1 {
+		t.Fatalf("parseEC2Instances() = %+v, %v", hosts, err)
+	}
+	if hosts[0].Name != "web-1" || hosts[0].Address != "3.3.3.3" || len(hosts[0].Tags) != 1 || hosts[0].Tags[0] != "Role=web" {
+		t.Errorf("parseEC2Instances() = %+v", hosts[0])
+	}
+
+	tailscale := `{"Peer":{"key":{"ID":"n1","HostName":"nas","DNSName":"nas.tail.ts.net.","TailscaleIPs":["100.64.0.2"],"Tags":["tag:storage"]}}}`
+	hosts, err = parseTailscaleStatus([]byte(tailscale))
+	if err != nil || len(hosts) != 1 || hosts[0].Address != "nas.tail.ts.net" || hosts[0].Tags[0] != "storage" {
+		t.Errorf("parseTailscaleStatus() = %+v, %v", hosts, err)
+	}
+}
+
+func TestMaterializeDiscoveredHosts(t *testing.T) {
+	app := newProfileLoadApp(t, t.TempDir())
+	provider := DiscoveryProviderConfig{ID: "inv", Name: "Inventory", Type: DiscoveryProviderCommand, Command: "inventory", DefaultUser: "ops"}
+	if _, err := app.SaveDiscoveryProvider(provider); err != nil {
+		t.Fatalf("SaveDiscoveryProvider() returned error: %v", err)
+	}
+
+	output := `[{"id":"a","name":"alpha","address":"10.0.0.1"},{"id":"b","name":"beta","address":"10.0.0.2","port":2222}]`
+	original := runDiscoveryCommand
+	runDiscoveryCommand = func(ctx context.Context, env []string, cmd *exec.Cmd) ([]byte, error) { return []byte(output), nil }
+	defer func() { runDiscoveryCommand = original }()
+
+	hosts, err := app.RunDiscovery("inv")
+	if err != nil || len(hosts) != 2 || hosts[0].User != "ops" || hosts[1].Port != 2222 {
+		t.Fatalf("RunDiscovery() = %+v, %v", hosts, err)
+	}
+	created, err := app.MaterializeDiscoveredHosts(hosts, "", "")
+	if err != nil || len(created) != 2 {
+		t.Fatalf("MaterializeDiscoveredHosts() = %+v, %v", created, err)
+	}
+
+	// A re-run links the existing profile, updates its address and flags the host that went away
+	output = `[{"id":"a","name":"alpha","address":"10.0.0.9"}]`
+	hosts, err = app.RunDiscovery("inv")
+	if err != nil || len(hosts) != 1 || hosts[0].ProfileID != created[0].ID {
+		t.Fatalf("RunDiscovery() = %+v, %v", hosts, err)
+	}
+	updated, err := app.MaterializeDiscoveredHosts(hosts, "", "")
+	if err != nil || len(updated) != 1 || updated[0].ID != created[0].ID || updated[0].SSHConfig.Host != "10.0.0.9" {
+		t.Fatalf("MaterializeDiscoveredHosts() = %+v, %v", updated, err)
+	}
+	if len(app.profiles.profiles) != 2 {
+		t.Errorf("re-run created duplicates: %d profiles", len(app.profiles.profiles))
+	}
+	if !app.profiles.profiles[created[1].ID].Discovery.Vanished {
+		t.Error("profile of the missing host was not flagged as vanished")
+	}
+}
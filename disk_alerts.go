@@ -0,0 +1,324 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Disk full alert constants
+const (
+	DiskAlertEvent            = "disk-full-alert"
+	DiskAlertCooldown         = 5 * time.Minute  // Minimum time between alerts for the same mount at the same severity
+	DiskAlertCheckInterval    = 30 * time.Second // How often a session's alert mounts are checked
+	DefaultDiskAlertThreshold = 80.0             // Used when no threshold is given; crossing it is a warning
+	DiskAlertCriticalPercent  = 90.0             // Usage above this is critical, whatever the threshold
+	MaxDiskAlertsPerSession   = 20
+
+	DiskAlertSeverityOK       = "ok"
+	DiskAlertSeverityWarning  = "warning"
+	DiskAlertSeverityCritical = "critical"
+
+	diskAlertMarker = "@@thermic-disk:"
+)
+
+// DiskAlert is a usage threshold watched on one remote mount point
+type DiskAlert struct {
+	ID               string
+	SessionID        string
+	MountPoint       string
+	ThresholdPercent float64
+	Created          time.Time
+	UsagePercent     float64 // Last measured usage, -1 before the first check
+	LastChecked      time.Time
+	LastAlerted      time.Time
+	LastSeverity     string // Severity of the last alert sent
+	LastError        string
+}
+
+// DiskAlertStatus is the current state of a disk alert as reported to the frontend
+type DiskAlertStatus struct {
+	AlertID          string    `json:"alertId"`
+	MountPoint       string    `json:"mountPoint"`
+	ThresholdPercent float64   `json:"thresholdPercent"`
+	UsagePercent     float64   `json:"usagePercent"` // -1 before the first check
+	Triggered        bool      `json:"triggered"`
+	Severity         string    `json:"severity"` // "ok", "warning" or "critical"
+	LastChecked      time.Time `json:"lastChecked"`
+	LastAlerted      time.Time `json:"lastAlerted"`
+	Error            string    `json:"error,omitempty"`
+}
+
+// diskAlertSeverity maps a usage that crossed its threshold to a severity
+func diskAlertSeverity(usage float64) string {
+	if usage > DiskAlertCriticalPercent {
+		return DiskAlertSeverityCritical
+	}
+	return DiskAlertSeverityWarning
+}
+
+// status returns the alert as a DiskAlertStatus
+func (d *DiskAlert) status() DiskAlertStatus {
+	s := DiskAlertStatus{
+		AlertID:          d.ID,
+		MountPoint:       d.MountPoint,
+		ThresholdPercent: d.ThresholdPercent,
+		UsagePercent:     d.UsagePercent,
+		Severity:         DiskAlertSeverityOK,
+		LastChecked:      d.LastChecked,
+		LastAlerted:      d.LastAlerted,
+		Error:            d.LastError,
+	}
+	if d.UsagePercent > d.ThresholdPercent {
+		s.Triggered = true
+		s.Severity = diskAlertSeverity(d.UsagePercent)
+	}
+	return s
+}
+
+// validateDiskAlertMount checks a mount point can be passed to df safely
+func validateDiskAlertMount(mountPoint string) error {
+	if !strings.HasPrefix(mountPoint, "/") {
+		return fmt.Errorf("mount point must be an absolute path: %q", mountPoint)
+	}
+	if strings.ContainsAny(mountPoint, "$`\"\\\n\r\x00") {
+		return fmt.Errorf("mount point contains unsupported characters: %q", mountPoint)
+	}
+	return nil
+}
+
+// SetDiskFullAlert watches a remote mount point and sends disk-full-alert events while its
+// usage is above thresholdPercent (0 = DefaultDiskAlertThreshold). Returns the new alert's ID.
+func (a *App) SetDiskFullAlert(sessionID, mountPoint string, thresholdPercent float64) (string, error) {
+	if err := validateDiskAlertMount(mountPoint); err != nil {
+		return "", err
+	}
+	if thresholdPercent == 0 {
+		thresholdPercent = DefaultDiskAlertThreshold
+	}
+	if thresholdPercent < 0 || thresholdPercent >= 100 {
+		return "", fmt.Errorf("threshold must be between 0 and 100 percent, got: %.1f", thresholdPercent)
+	}
+
+	a.ssh.sshSessionsMutex.RLock()
+	_, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+	if !exists {
+		return "", newNotFoundError(ErrCategoryMonitoring, "SetDiskFullAlert", "SSH session %s not found", sessionID)
+	}
+
+	a.monitoring.mutex.Lock()
+	defer a.monitoring.mutex.Unlock()
+
+	count := 0
+	for _, alert := range a.monitoring.diskAlerts {
+		if alert.SessionID == sessionID {
+			count++
+		}
+	}
+	if count >= MaxDiskAlertsPerSession {
+		return "", fmt.Errorf("too many disk alerts for session %s: maximum allowed: %d", sessionID, MaxDiskAlertsPerSession)
+	}
+
+	alert := &DiskAlert{
+		ID:               fmt.Sprintf("diskalert_%d", time.Now().UnixNano()),
+		SessionID:        sessionID,
+		MountPoint:       mountPoint,
+		ThresholdPercent: thresholdPercent,
+		Created:          time.Now(),
+		UsagePercent:     -1,
+	}
+	a.monitoring.diskAlerts[alert.ID] = alert
+	return alert.ID, nil
+}
+
+// RemoveDiskFullAlert stops watching a mount point
+func (a *App) RemoveDiskFullAlert(alertID string) error {
+	a.monitoring.mutex.Lock()
+	defer a.monitoring.mutex.Unlock()
+
+	if _, exists := a.monitoring.diskAlerts[alertID]; !exists {
+		return newNotFoundError(ErrCategoryMonitoring, "RemoveDiskFullAlert", "disk alert %s not found", alertID)
+	}
+	delete(a.monitoring.diskAlerts, alertID)
+	return nil
+}
+
+// GetDiskAlerts returns the state of every disk alert set for a session, oldest first
+func (a *App) GetDiskAlerts(sessionID string) ([]DiskAlertStatus, error) {
+	a.monitoring.mutex.RLock()
+	defer a.monitoring.mutex.RUnlock()
+
+	alerts := a.sessionDiskAlertsLockFree(sessionID)
+	statuses := make([]DiskAlertStatus, 0, len(alerts))
+	for _, alert := range alerts {
+		statuses = append(statuses, alert.status())
+	}
+	return statuses, nil
+}
+
+// sessionDiskAlertsLockFree returns a session's alerts, oldest first.
+// Caller must hold a.monitoring.mutex.
+func (a *App) sessionDiskAlertsLockFree(sessionID string) []*DiskAlert {
+	var alerts []*DiskAlert
+	for _, alert := range a.monitoring.diskAlerts {
+		if alert.SessionID == sessionID {
+			alerts = append(alerts, alert)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Created.Before(alerts[j].Created)
+	})
+	return alerts
+}
+
+// clearDiskAlertsLockFree drops every alert of a session. Caller must hold Lock on a.monitoring.mutex.
+func (a *App) clearDiskAlertsLockFree(sessionID string) {
+	for id, alert := range a.monitoring.diskAlerts {
+		if alert.SessionID == sessionID {
+			delete(a.monitoring.diskAlerts, id)
+		}
+	}
+}
+
+// diskAlertCommand builds one df call per mount point, each preceded by a marker line
+// carrying the mount's index so a missing mount can't shift the results
+func diskAlertCommand(mountPoints []string) remoteCommand {
+	var parts []string
+	for i, mountPoint := range mountPoints {
+		parts = append(parts, string(buildRemoteCommand("echo %s; df -P %s 2>/dev/null | tail -n 1", fmt.Sprintf("%s%d", diskAlertMarker, i), mountPoint)))
+	}
+	return remoteCommand(strings.Join(parts, "; "))
+}
+
+// parseDiskAlertOutput returns the usage percent found for each mount index
+func parseDiskAlertOutput(output string) map[int]float64 {
+	usage := make(map[int]float64)
+	current := -1
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, diskAlertMarker) {
+			index, err := strconv.Atoi(strings.TrimPrefix(line, diskAlertMarker))
+			if err != nil {
+				index = -1
+			}
+			current = index
+			continue
+		}
+		if current < 0 {
+			continue
+		}
+		// POSIX df: Filesystem 1024-blocks Used Available Capacity Mounted-on
+		fields := strings.Fields(line)
+		if len(fields) < 6 || !strings.HasSuffix(fields[4], "%") {
+			continue
+		}
+		if percent, err := strconv.ParseFloat(strings.TrimSuffix(fields[4], "%"), 64); err == nil {
+			usage[current] = percent
+			current = -1
+		}
+	}
+	return usage
+}
+
+// measureDiskUsage returns the usage percent of each mount point by index. Servers with the
+// statvfs@openssh.com extension are asked over SFTP; otherwise df runs on the monitoring session.
+func (a *App) measureDiskUsage(sshSession *SSHSession, sessionID string, mountPoints []string) (map[int]float64, error) {
+	if client, ok := a.statVFSClient(sessionID); ok {
+		usage := make(map[int]float64, len(mountPoints))
+		for i, mountPoint := range mountPoints {
+			stat, err := client.StatVFS(mountPoint)
+			if err != nil {
+				continue // Reported as unmeasured, like a mount df can't see
+			}
+			if percent, err := statVFSUsagePercent(stat); err == nil {
+				usage[i] = percent
+			}
+		}
+		if len(usage) > 0 {
+			return usage, nil
+		}
+		// Some servers advertise statvfs but fail it (e.g. on FUSE mounts); df may still work
+	}
+
+	output, err := a.executeMonitoringCommand(sshSession, diskAlertCommand(mountPoints))
+	return parseDiskAlertOutput(output), err
+}
+
+// checkDiskAlerts measures every alert mount of a session, at most once per DiskAlertCheckInterval,
+// and sends disk-full-alert for those over their threshold. An alert repeats only after
+// DiskAlertCooldown, unless its severity got worse.
+func (a *App) checkDiskAlerts(sshSession *SSHSession, sessionID string) {
+	now := time.Now()
+
+	a.monitoring.mutex.RLock()
+	alerts := a.sessionDiskAlertsLockFree(sessionID)
+	due := false
+	mountPoints := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		mountPoints = append(mountPoints, alert.MountPoint)
+		if now.Sub(alert.LastChecked) >= DiskAlertCheckInterval {
+			due = true
+		}
+	}
+	a.monitoring.mutex.RUnlock()
+
+	if !due {
+		return
+	}
+
+	usage, err := a.measureDiskUsage(sshSession, sessionID, mountPoints)
+
+	var events []map[string]interface{}
+	a.monitoring.mutex.Lock()
+	for i, alert := range alerts {
+		// The alert may have been removed while it was measured
+		if a.monitoring.diskAlerts[alert.ID] != alert {
+			continue
+		}
+		alert.LastChecked = now
+		percent, measured := usage[i]
+		switch {
+		case err != nil:
+			alert.LastError = err.Error()
+			continue
+		case !measured:
+			alert.LastError = fmt.Sprintf("no usage reported for %s", alert.MountPoint)
+			continue
+		}
+		alert.LastError = ""
+		alert.UsagePercent = percent
+
+		if percent <= alert.ThresholdPercent {
+			alert.LastSeverity = ""
+			continue
+		}
+		severity := diskAlertSeverity(percent)
+		escalated := severity == DiskAlertSeverityCritical && alert.LastSeverity != DiskAlertSeverityCritical
+		if !escalated && now.Sub(alert.LastAlerted) < DiskAlertCooldown {
+			continue
+		}
+		alert.LastAlerted = now
+		alert.LastSeverity = severity
+		events = append(events, map[string]interface{}{
+			"sessionId":        sessionID,
+			"alertId":          alert.ID,
+			"mountPoint":       alert.MountPoint,
+			"usagePercent":     percent,
+			"thresholdPercent": alert.ThresholdPercent,
+			"severity":         severity,
+		})
+	}
+	a.monitoring.mutex.Unlock()
+
+	for _, event := range events {
+		fmt.Printf("Disk alert for %s: %s at %.0f%% (%s)\n", sessionID, event["mountPoint"], event["usagePercent"], event["severity"])
+		if a.ctx != nil {
+			wailsRuntime.EventsEmit(a.ctx, DiskAlertEvent, event)
+		}
+	}
+}
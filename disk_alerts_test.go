@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDiskAlertOutput(t *testing.T) {
+	command := diskAlertCommand([]string{"/", "/mnt/it's data", "/missing"})
+	if !strings.Contains(string(command), `'/mnt/it'\''s data'`) {
+		t.Fatalf("mount point not quoted for sh: %s", command)
+	}
+
+	output := strings.Join([]string{
+		diskAlertMarker + "0",
+		"/dev/sda1   51475068 43803532   7671536      86% /",
+		diskAlertMarker + "1",
+		"server:/export 1048576 996147 52429      95% /mnt/it's data",
+		diskAlertMarker + "2",
+		"",
+	}, "\n")
+	usage := parseDiskAlertOutput(output)
+	if usage[0] != 86 || usage[1] != 95 {
+		t.Errorf("usage = %v", usage)
+	}
+	if _, found := usage[2]; found {
+		t.Error("a missing mount should have no usage")
+	}
+}
+
+func TestDiskAlertLifecycle(t *testing.T) {
+	app := NewApp()
+	app.ssh.sshSessions["session_disk"] = &SSHSession{}
+
+	if _, err := app.SetDiskFullAlert("session_disk", "data", 80); err == nil {
+		t.Error("a relative mount point should be rejected")
+	}
+	if _, err := app.SetDiskFullAlert("session_disk", "/", 120); err == nil {
+		t.Error("a threshold over 100% should be rejected")
+	}
+	if _, err := app.SetDiskFullAlert("session_missing", "/", 80); err == nil {
+		t.Error("an unknown session should be rejected")
+	}
+
+	rootID, err := app.SetDiskFullAlert("session_disk", "/", 0)
+	if err != nil {
+		t.Fatalf("SetDiskFullAlert() returned error: %v", err)
+	}
+	if _, err := app.SetDiskFullAlert("session_disk", "/var", 85); err != nil {
+		t.Fatalf("SetDiskFullAlert() returned error: %v", err)
+	}
+
+	app.monitoring.diskAlerts[rootID].UsagePercent = 93
+	statuses, _ := app.GetDiskAlerts("session_disk")
+	if len(statuses) != 2 {
+		t.Fatalf("got %d alerts, want 2", len(statuses))
+	}
+	if statuses[0].ThresholdPercent != DefaultDiskAlertThreshold || !statuses[0].Triggered || statuses[0].Severity != DiskAlertSeverityCritical {
+		t.Errorf("root alert = %+v", statuses[0])
+	}
+	if statuses[1].Triggered || statuses[1].Severity != DiskAlertSeverityOK {
+		t.Errorf("unchecked alert = %+v", statuses[1])
+	}
+
+	if err := app.RemoveDiskFullAlert(rootID); err != nil {
+		t.Fatalf("RemoveDiskFullAlert() returned error: %v", err)
+	}
+	app.CleanupSessionMetrics("session_disk")
+	if statuses, _ := app.GetDiskAlerts("session_disk"); len(statuses) != 0 {
+		t.Errorf("alerts survived session cleanup: %v", statuses)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Download pre-flight constants
+const (
+	DownloadSpaceCheckInterval = 2 * time.Second     // How often free space is checked while a directory downloads
+	DownloadSpaceAbortFloor    = 64 * 1024 * 1024    // A running download stops once free space falls below this
+	windowsMaxPath             = 260                 // MAX_PATH, including the drive and the terminating NUL
+	windowsMaxDirPath          = windowsMaxPath - 12 // Directories must leave room for an 8.3 file name
+	maxPathComponent           = 255                 // Longest file name on NTFS, ext4 and APFS
+	windowsLongPathPrefix      = `\\?\`
+	windowsUNCLongPathPrefix   = `\\?\UNC\`
+	downloadWriteProbePattern  = ".thermic-write-check-*"
+)
+
+// Download pre-flight checks, reported in DownloadPreflightError.Check
+const (
+	DownloadCheckSpace      = "space"
+	DownloadCheckPermission = "permission"
+	DownloadCheckPath       = "path"
+)
+
+// DownloadOptions controls how DownloadRemoteDirectoryWithOptions downloads a directory
+type DownloadOptions struct {
+	SkipPreflight bool `json:"skipPreflight"` // Start even when the free space, permission or path checks fail
+}
+
+// DownloadPreflightError reports a local download target that can't take the download, or a
+// download stopped because the disk was about to fill up
+type DownloadPreflightError struct {
+	Check     string   // The check that failed: space, permission or path
+	Path      string   // The local directory or file the check was about
+	Needed    int64    // Bytes the download needs, safety margin included (space only)
+	Available int64    // Bytes free on the target volume (space only)
+	Aborted   bool     // The download had started and was stopped when space ran low
+	Completed []string // Local paths of the files fully downloaded before the abort
+	Cause     error
+}
+
+func (e *DownloadPreflightError) Error() string {
+	switch {
+	case e.Aborted:
+		return fmt.Sprintf("download stopped with %s left on the disk holding %s; %d files completed",
+			formatByteSize(e.Available), e.Path, len(e.Completed))
+	case e.Check == DownloadCheckSpace:
+		return fmt.Sprintf("not enough space to download to %s: need %s, have %s",
+			e.Path, formatByteSize(e.Needed), formatByteSize(e.Available))
+	case e.Check == DownloadCheckPermission:
+		return fmt.Sprintf("permission denied: cannot write to %s", e.Path)
+	default:
+		return fmt.Sprintf("file name too long (over %d characters): %s", maxPathComponent, e.Path)
+	}
+}
+
+func (e *DownloadPreflightError) Unwrap() error {
+	return e.Cause
+}
+
+// formatByteSize formats n bytes for messages, e.g. "31.2 GB"
+func formatByteSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n) / unit
+	suffixes := []string{"KB", "MB", "GB", "TB", "PB"}
+	i := 0
+	for value >= unit && i < len(suffixes)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", value, suffixes[i])
+}
+
+// existingAncestor returns path or its nearest parent that exists, so a target that
+// will be created by the download can still be checked
+func existingAncestor(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// checkDownloadSpace fails when the volume holding dir has less than needed bytes plus
+// margin free. Volumes whose free space can't be read are let through.
+func checkDownloadSpace(dir string, needed, margin int64) error {
+	available, err := localFreeSpace(existingAncestor(dir))
+	if err != nil {
+		fmt.Printf("Could not read free space for %s: %v\n", dir, err)
+		return nil
+	}
+	if needed+margin > available {
+		return &DownloadPreflightError{Check: DownloadCheckSpace, Path: dir, Needed: needed + margin, Available: available}
+	}
+	return nil
+}
+
+// checkDownloadWritable creates and removes a scratch file in dir, or in its nearest
+// existing parent when dir doesn't exist yet
+func checkDownloadWritable(dir string) error {
+	probe, err := os.CreateTemp(existingAncestor(dir), downloadWriteProbePattern)
+	if err != nil {
+		return &DownloadPreflightError{Check: DownloadCheckPermission, Path: dir, Cause: err}
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return nil
+}
+
+// checkDownloadPaths fails when a local path has a component no common file system can hold.
+// Long paths as a whole are handled by localDownloadPath instead.
+func checkDownloadPaths(paths []string) error {
+	for _, path := range paths {
+		components := strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' })
+		for _, component := range components {
+			if len(component) > maxPathComponent {
+				return &DownloadPreflightError{Check: DownloadCheckPath, Path: path}
+			}
+		}
+	}
+	return nil
+}
+
+// preflightDownload runs the local target checks for a batch of jobs downloading into localPath
+func preflightDownload(localPath string, jobs []TransferJob, dirs []string, margin int64) error {
+	if err := checkDownloadWritable(localPath); err != nil {
+		return err
+	}
+	paths := append([]string{}, dirs...)
+	var needed int64
+	for _, job := range jobs {
+		paths = append(paths, job.LocalPath)
+		needed += job.FileSize
+	}
+	if err := checkDownloadPaths(paths); err != nil {
+		return err
+	}
+	return checkDownloadSpace(localPath, needed, margin)
+}
+
+// windowsLongPath adds the \\?\ prefix to an absolute Windows path too long for MAX_PATH,
+// resolving "." and ".." first since Windows doesn't for prefixed paths. Short, relative
+// and already prefixed paths are returned unchanged.
+func windowsLongPath(path string) string {
+	if len(path) < windowsMaxDirPath || strings.HasPrefix(path, windowsLongPathPrefix) {
+		return path
+	}
+	path = strings.ReplaceAll(path, "/", `\`)
+	switch {
+	case strings.HasPrefix(path, `\\`):
+		// \\server\share\... keeps the server and share as its root
+		return windowsUNCLongPathPrefix + cleanWindowsPath(path[2:], 2)
+	case len(path) >= 3 && path[1] == ':' && path[2] == '\\':
+		return windowsLongPathPrefix + cleanWindowsPath(path, 1)
+	}
+	return path
+}
+
+// cleanWindowsPath drops empty and "." components and resolves "..", never above the first
+// root components
+func cleanWindowsPath(path string, root int) string {
+	var parts []string
+	for i, part := range strings.Split(path, `\`) {
+		switch {
+		case i < root:
+			parts = append(parts, part)
+		case part == "" || part == ".":
+		case part == "..":
+			if len(parts) > root {
+				parts = parts[:len(parts)-1]
+			}
+		default:
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, `\`)
+}
+
+// localDownloadPath returns the path to create a downloaded file or directory at; on
+// Windows, deep paths get the long-path prefix
+func localDownloadPath(path string) string {
+	if runtime.GOOS != "windows" {
+		return path
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return windowsLongPath(path)
+}
+
+// watchDownloadSpace checks the free space under dir every interval until stop is closed,
+// and cancels the session's transfer once it falls below floor. The returned value holds
+// the free space seen at the abort, or -1 while there hasn't been one.
+func (a *App) watchDownloadSpace(sessionID, dir string, floor int64, interval time.Duration, stop <-chan struct{}) *atomic.Int64 {
+	lowSpace := &atomic.Int64{}
+	lowSpace.Store(-1)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				available, err := localFreeSpace(existingAncestor(dir))
+				if err != nil || available >= floor {
+					continue
+				}
+				lowSpace.Store(available)
+				fmt.Printf("Only %s left on the disk holding %s, stopping the download\n", formatByteSize(available), dir)
+				a.CancelSFTPTransfer(sessionID)
+				return
+			}
+		}
+	}()
+	return lowSpace
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWindowsLongPath(t *testing.T) {
+	deep := strings.Repeat(`node_modules\pkg\`, 16) + "index.js"
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"short path", `C:\Users\me\Downloads\file.txt`, `C:\Users\me\Downloads\file.txt`},
+		{"deep drive path", `C:\Users\me\` + deep, `\\?\C:\Users\me\` + deep},
+		{"forward slashes", `C:/Users/me/` + strings.ReplaceAll(deep, `\`, "/"), `\\?\C:\Users\me\` + deep},
+		{"dot components", `C:\Users\.\me\tmp\..\` + deep, `\\?\C:\Users\me\` + deep},
+		{"doubled separators", `C:\Users\\me\` + deep, `\\?\C:\Users\me\` + deep},
+		{"dot-dot above the drive", `C:\..\..\Users\me\` + deep, `\\?\C:\Users\me\` + deep},
+		{"UNC path", `\\fileserver\share\` + deep, `\\?\UNC\fileserver\share\` + deep},
+		{"dot-dot above the share", `\\fileserver\share\..\` + deep, `\\?\UNC\fileserver\share\` + deep},
+		{"already prefixed", `\\?\C:\Users\me\` + deep, `\\?\C:\Users\me\` + deep},
+		{"relative path", `Downloads\` + deep, `Downloads\` + deep},
+		{"drive-relative path", `C:Downloads\` + deep, `C:Downloads\` + deep},
+	}
+	for _, tt := range tests {
+		if got := windowsLongPath(tt.path); got != tt.want {
+			t.Errorf("%s: windowsLongPath(%q) = %q, want %q", tt.name, tt.path, got, tt.want)
+		}
+	}
+
+	// Directories hit the limit before files do, since they need room for an 8.3 name
+	dir := `C:\` + strings.Repeat("d", windowsMaxDirPath-3)
+	if got := windowsLongPath(dir); got != windowsLongPathPrefix+dir {
+		t.Errorf("windowsLongPath() of a %d character directory = %q, want it prefixed", len(dir), got)
+	}
+	if got := windowsLongPath(dir[:len(dir)-1]); got != dir[:len(dir)-1] {
+		t.Errorf("windowsLongPath() of a %d character directory = %q, want it unchanged", len(dir)-1, got)
+	}
+}
+
+func TestCheckDownloadPaths(t *testing.T) {
+	ok := []string{filepath.Join("out", strings.Repeat("a", maxPathComponent)), `C:\out\` + strings.Repeat("b", maxPathComponent)}
+	if err := checkDownloadPaths(ok); err != nil {
+		t.Errorf("checkDownloadPaths() = %v for names at the limit, want nil", err)
+	}
+
+	tooLong := filepath.Join("out", strings.Repeat("a", maxPathComponent+1), "file.txt")
+	err := checkDownloadPaths([]string{"out", tooLong})
+	if toThermicError(err).Code != ErrCodeInvalid || !strings.Contains(err.Error(), tooLong) {
+		t.Errorf("checkDownloadPaths() = %v, want an invalid path error naming %s", err, tooLong)
+	}
+}
+
+func TestPreflightDownload(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "not", "created", "yet")
+	jobs := []TransferJob{{LocalPath: filepath.Join(target, "a.bin"), FileSize: 1024}}
+
+	if err := preflightDownload(target, jobs, []string{target}, 1024); err != nil {
+		t.Fatalf("preflightDownload() = %v for a small download, want nil", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(filepath.Dir(filepath.Dir(target)))); len(entries) != 0 {
+		t.Errorf("preflightDownload() left %d entries behind, want none", len(entries))
+	}
+
+	jobs = append(jobs, TransferJob{LocalPath: filepath.Join(target, "b.bin"), FileSize: 1 << 60})
+	err := preflightDownload(target, jobs, []string{target}, 1024)
+	thermicErr := toThermicError(err)
+	if thermicErr.Code != ErrCodeNoSpace || thermicErr.Reason() != ErrReasonNoSpace || thermicErr.Category != ErrCategorySFTP {
+		t.Fatalf("preflightDownload() = %+v, want an insufficient space error", thermicErr)
+	}
+	if !strings.Contains(err.Error(), "need 1024.0 PB") {
+		t.Errorf("preflightDownload() message = %q, want the space needed", err.Error())
+	}
+}
+
+func TestCheckDownloadWritable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(dir, 0755)
+
+	if err := checkDownloadWritable(filepath.Join(dir, "out")); toThermicError(err).Code != ErrCodePermission {
+		t.Errorf("checkDownloadWritable() = %v, want permission denied", err)
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	tests := map[int64]string{
+		512:                          "512 B",
+		1536:                         "1.5 KB",
+		8*1024*1024*1024 + 429496730: "8.4 GB",
+		33500744909:                  "31.2 GB",
+	}
+	for n, want := range tests {
+		if got := formatByteSize(n); got != want {
+			t.Errorf("formatByteSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestWatchDownloadSpaceCancelsTransfer(t *testing.T) {
+	app := NewApp()
+	sessionID := "session_low_space"
+	app.startTransfer(sessionID)
+	defer app.endTransfer(sessionID)
+
+	// No disk has this much free, so the first check stops the transfer
+	stop := make(chan struct{})
+	defer close(stop)
+	lowSpace := app.watchDownloadSpace(sessionID, t.TempDir(), math.MaxInt64, 10*time.Millisecond, stop)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !app.isTransferCancelled(sessionID) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !app.isTransferCancelled(sessionID) {
+		t.Fatal("watchDownloadSpace() did not cancel the transfer")
+	}
+	if lowSpace.Load() < 0 {
+		t.Error("watchDownloadSpace() cancelled without recording the free space")
+	}
+}
+
+func TestDownloadRemoteDirectoryCreatesTree(t *testing.T) {
+	app := NewApp()
+	sessionID := "session_download_tree"
+	client := newLatencySFTPClient(t, 0, 0)
+	app.ssh.sftpClients[sessionID] = client
+	defer app.ReleaseSession(sessionID)
+
+	if err := client.MkdirAll("/src/sub/empty"); err != nil {
+		t.Fatal(err)
+	}
+	file, err := client.Create("/src/sub/data.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte("payload"))
+	file.Close()
+
+	target := filepath.Join(t.TempDir(), "out")
+	if err := app.DownloadRemoteDirectory(sessionID, "/src", target); err != nil {
+		t.Fatalf("DownloadRemoteDirectory() returned error: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(target, "sub", "data.txt")); err != nil || string(data) != "payload" {
+		t.Errorf("downloaded file = %q, %v, want the remote content", data, err)
+	}
+	if info, err := os.Stat(filepath.Join(target, "sub", "empty")); err != nil || !info.IsDir() {
+		t.Errorf("empty remote directory not created locally: %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package main
+
+import "golang.org/x/sys/windows"
+
+// localFreeSpace returns the bytes available to this user on the volume holding dir,
+// honouring disk quotas
+func localFreeSpace(dir string) (int64, error) {
+	dirPtr, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var available, total, free uint64
+	if err := windows.GetDiskFreeSpaceEx(dirPtr, &available, &total, &free); err != nil {
+		return 0, err
+	}
+	return int64(available), nil
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Error codes, modelled on HTTP status codes so the frontend can branch on them
+const (
+	ErrCodeInvalid     = 400
+	ErrCodePermission  = 403
+	ErrCodeNotFound    = 404
+	ErrCodeTimeout     = 408
+	ErrCodeExists      = 409
+	ErrCodeConflict    = 412 // A remote file changed since it was read
+	ErrCodeTooLarge    = 413 // A file over a size limit, e.g. the preview cap
+	ErrCodeConfirm     = 428 // The operation runs only once the user confirms it
+	ErrCodeInternal    = 500
+	ErrCodeUnsupported = 501 // The remote host lacks what an operation needs, e.g. the xattr tools
+	ErrCodeConnection  = 503 // The SSH or SFTP connection dropped
+	ErrCodeNoSpace     = 507 // Not enough free space on the local disk for a download
+)
+
+// Error reasons are the names of the codes; the frontend branches on these rather than on messages
+const (
+	ErrReasonInvalid          = "INVALID"
+	ErrReasonPermissionDenied = "PERMISSION_DENIED"
+	ErrReasonNotFound         = "NOT_FOUND"
+	ErrReasonTimeout          = "TIMEOUT"
+	ErrReasonAlreadyExists    = "ALREADY_EXISTS"
+	ErrReasonConflict         = "CONFLICT"
+	ErrReasonFileTooLarge     = "FILE_TOO_LARGE"
+	ErrReasonConfirm          = "CONFIRMATION_REQUIRED"
+	ErrReasonInternal         = "INTERNAL"
+	ErrReasonUnsupported      = "NOT_SUPPORTED"
+	ErrReasonConnectionLost   = "CONNECTION_LOST"
+	ErrReasonNoSpace          = "INSUFFICIENT_SPACE"
+)
+
+// errorReasons maps error codes to their reasons
+var errorReasons = map[int]string{
+	ErrCodeInvalid:     ErrReasonInvalid,
+	ErrCodePermission:  ErrReasonPermissionDenied,
+	ErrCodeNotFound:    ErrReasonNotFound,
+	ErrCodeTimeout:     ErrReasonTimeout,
+	ErrCodeExists:      ErrReasonAlreadyExists,
+	ErrCodeConflict:    ErrReasonConflict,
+	ErrCodeTooLarge:    ErrReasonFileTooLarge,
+	ErrCodeConfirm:     ErrReasonConfirm,
+	ErrCodeInternal:    ErrReasonInternal,
+	ErrCodeUnsupported: ErrReasonUnsupported,
+	ErrCodeConnection:  ErrReasonConnectionLost,
+	ErrCodeNoSpace:     ErrReasonNoSpace,
+}
+
+// Error categories
+const (
+	ErrCategorySSH        = "ssh"
+	ErrCategorySFTP       = "sftp"
+	ErrCategoryProfile    = "profile"
+	ErrCategoryConfig     = "config"
+	ErrCategoryMonitoring = "monitoring"
+	ErrCategoryTerminal   = "terminal"
+	ErrCategorySystem     = "system"
+	ErrCategoryApp        = "app" // Anything that could not be attributed more precisely
+)
+
+// ThermicError is the structured error returned to the frontend by every bound App method
+type ThermicError struct {
+	Code     int
+	Category string
+	Op       string
+	Message  string
+	Cause    error
+}
+
+func (e *ThermicError) Error() string {
+	switch {
+	case e.Cause == nil:
+		return e.Message
+	case e.Message == "":
+		return e.Cause.Error()
+	default:
+		return fmt.Sprintf("%s: %v", e.Message, e.Cause)
+	}
+}
+
+func (e *ThermicError) Unwrap() error {
+	return e.Cause
+}
+
+// Reason returns the machine-readable name of the error's code
+func (e *ThermicError) Reason() string {
+	if reason, ok := errorReasons[e.Code]; ok {
+		return reason
+	}
+	return ErrReasonInternal
+}
+
+// IsThermicError returns the ThermicError in err's chain, if there is one
+func IsThermicError(err error) (*ThermicError, bool) {
+	var thermicErr *ThermicError
+	if errors.As(err, &thermicErr) {
+		return thermicErr, true
+	}
+	return nil, false
+}
+
+// newNotFoundError builds a not-found error for a missing session, tab, profile or path
+func newNotFoundError(category, op, format string, args ...interface{}) *ThermicError {
+	return &ThermicError{
+		Code:     ErrCodeNotFound,
+		Category: category,
+		Op:       op,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// newInvalidError builds an error for a request that can't be carried out as asked
+func newInvalidError(category, op, format string, args ...interface{}) *ThermicError {
+	return &ThermicError{
+		Code:     ErrCodeInvalid,
+		Category: category,
+		Op:       op,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// wrapThermicError attaches a category and operation to err, classifying its code.
+// Errors that are already ThermicErrors are returned unchanged.
+func wrapThermicError(category, op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := IsThermicError(err); ok {
+		return err
+	}
+	return &ThermicError{
+		Code:     classifyErrorCode(err),
+		Category: category,
+		Op:       op,
+		Cause:    err,
+	}
+}
+
+// toThermicError converts any error into a ThermicError, inferring what it can from the chain
+func toThermicError(err error) *ThermicError {
+	if thermicErr, ok := IsThermicError(err); ok {
+		if thermicErr == err {
+			return thermicErr
+		}
+		// Wrapped further up the stack - keep its classification but the full message
+		return &ThermicError{
+			Code:     thermicErr.Code,
+			Category: thermicErr.Category,
+			Op:       thermicErr.Op,
+			Cause:    err,
+		}
+	}
+	return &ThermicError{
+		Code:     classifyErrorCode(err),
+		Category: classifyErrorCategory(err),
+		Op:       classifyErrorOp(err),
+		Cause:    err,
+	}
+}
+
+// formatBindingError is the Wails error formatter: bound methods reject with
+// {code, reason, category, op, message} instead of a bare string
+func formatBindingError(err error) any {
+	thermicErr := toThermicError(err)
+	category := thermicErr.Category
+	if category == "" {
+		category = ErrCategoryApp
+	}
+	return map[string]interface{}{
+		"code":     thermicErr.Code,
+		"reason":   thermicErr.Reason(),
+		"category": category,
+		"op":       thermicErr.Op,
+		"message":  thermicErr.Error(),
+	}
+}
+
+// classifyErrorCode maps well-known errors in the chain to an error code
+func classifyErrorCode(err error) int {
+	var netErr net.Error
+	var statusErr *sftp.StatusError
+	var readOnlyErr *ReadOnlySourceError
+	var preflightErr *DownloadPreflightError
+	var confirmErr *ExternalOpenConfirmationError
+
+	switch {
+	case errors.As(err, &confirmErr):
+		return ErrCodeConfirm
+	case errors.As(err, &preflightErr):
+		switch preflightErr.Check {
+		case DownloadCheckSpace:
+			return ErrCodeNoSpace
+		case DownloadCheckPermission:
+			return ErrCodePermission
+		}
+		return ErrCodeInvalid
+	case errors.Is(err, ErrFileTooLarge):
+		return ErrCodeTooLarge
+	case errors.Is(err, ErrConflict):
+		return ErrCodeConflict
+	case errors.Is(err, errors.ErrUnsupported):
+		return ErrCodeUnsupported
+	case errors.Is(err, os.ErrNotExist):
+		return ErrCodeNotFound
+	case errors.Is(err, os.ErrPermission), errors.As(err, &readOnlyErr):
+		return ErrCodePermission
+	case errors.Is(err, os.ErrExist), errors.Is(err, ErrDestinationExists):
+		return ErrCodeExists
+	case errors.Is(err, sftp.ErrSSHFxConnectionLost), errors.Is(err, sftp.ErrSSHFxNoConnection),
+		errors.Is(err, net.ErrClosed), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return ErrCodeConnection
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return ErrCodeTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ErrCodeTimeout
+	case errors.As(err, &statusErr):
+		switch statusErr.FxCode() {
+		case sftp.ErrSSHFxNoSuchFile:
+			return ErrCodeNotFound
+		case sftp.ErrSSHFxPermissionDenied:
+			return ErrCodePermission
+		case sftp.ErrSSHFxNoConnection, sftp.ErrSSHFxConnectionLost:
+			return ErrCodeConnection
+		}
+	}
+
+	return ErrCodeInternal
+}
+
+// classifyErrorCategory infers a category from the typed errors in the chain
+func classifyErrorCategory(err error) string {
+	var configErr *ConfigError
+	var profileErr *ProfileError
+	var readOnlyErr *ReadOnlySourceError
+	var statusErr *sftp.StatusError
+	var exitErr *ssh.ExitError
+	var keyErr *knownhosts.KeyError
+	var passphraseErr *ssh.PassphraseMissingError
+	var dialogErr *DialogError
+	var shellErr *ShellValidationError
+	var preflightErr *DownloadPreflightError
+
+	switch {
+	case errors.As(err, &configErr):
+		return ErrCategoryConfig
+	case errors.As(err, &profileErr), errors.As(err, &readOnlyErr):
+		return ErrCategoryProfile
+	case errors.As(err, &statusErr), errors.As(err, &preflightErr):
+		return ErrCategorySFTP
+	case errors.As(err, &exitErr), errors.As(err, &keyErr), errors.As(err, &passphraseErr):
+		return ErrCategorySSH
+	case errors.As(err, &dialogErr), errors.As(err, &shellErr):
+		return ErrCategorySystem
+	}
+	return ErrCategoryApp
+}
+
+// classifyErrorOp returns the operation recorded by a typed error in the chain
+func classifyErrorOp(err error) string {
+	var configErr *ConfigError
+	var profileErr *ProfileError
+	var dialogErr *DialogError
+
+	switch {
+	case errors.As(err, &configErr):
+		return configErr.Op
+	case errors.As(err, &profileErr):
+		return profileErr.Op
+	case errors.As(err, &dialogErr):
+		return dialogErr.Operation
+	}
+	return ""
+}
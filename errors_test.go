@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+func TestThermicErrorClassification(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		code     int
+		category string
+		op       string
+	}{
+		{"typed not found", newNotFoundError(ErrCategorySFTP, "ListRemoteFiles", "SSH session %s not found", "s1"), ErrCodeNotFound, ErrCategorySFTP, "ListRemoteFiles"},
+		{"wrapped typed", fmt.Errorf("reload: %w", newNotFoundError(ErrCategoryProfile, "GetProfile", "profile not found: %s", "p1")), ErrCodeNotFound, ErrCategoryProfile, "GetProfile"},
+		{"config permission", &ConfigError{Op: "save", Err: os.ErrPermission}, ErrCodePermission, ErrCategoryConfig, "save"},
+		{"read-only source", &ReadOnlySourceError{ID: "p1", Source: "team"}, ErrCodePermission, ErrCategoryProfile, ""},
+		{"path exists", &os.PathError{Op: "mkdir", Path: "/tmp/x", Err: os.ErrExist}, ErrCodeExists, ErrCategoryApp, ""},
+		{"wrapped deadline", fmt.Errorf("command: %w", os.ErrDeadlineExceeded), ErrCodeTimeout, ErrCategoryApp, ""},
+		{"sftp permission denied", fmt.Errorf("open: %w", &sftp.StatusError{Code: uint32(sftp.ErrSSHFxPermissionDenied)}), ErrCodePermission, ErrCategorySFTP, ""},
+		{"missing file", &os.PathError{Op: "open", Path: "/tmp/x", Err: os.ErrNotExist}, ErrCodeNotFound, ErrCategoryApp, ""},
+		{"plain failure", fmt.Errorf("something broke"), ErrCodeInternal, ErrCategoryApp, ""},
+		{"sftp connection lost", fmt.Errorf("read dir: %w", sftp.ErrSSHFxConnectionLost), ErrCodeConnection, ErrCategoryApp, ""},
+		// Messages aren't parsed; only errors in the chain count
+		{"plain text", fmt.Errorf("permission denied: connection timed out"), ErrCodeInternal, ErrCategoryApp, ""},
+	}
+
+	for _, tt := range tests {
+		got := toThermicError(tt.err)
+		if got.Code != tt.code || got.Category != tt.category || got.Op != tt.op {
+			t.Errorf("%s: got code=%d category=%q op=%q, want %d %q %q", tt.name, got.Code, got.Category, got.Op, tt.code, tt.category, tt.op)
+		}
+		if got.Error() != tt.err.Error() {
+			t.Errorf("%s: message changed to %q", tt.name, got.Error())
+		}
+	}
+}
+
+func TestFormatBindingError(t *testing.T) {
+	err := wrapThermicError(ErrCategorySSH, "ConnectSSH", fmt.Errorf("dial: %w", os.ErrDeadlineExceeded))
+	payload, ok := formatBindingError(err).(map[string]interface{})
+	if !ok {
+		t.Fatalf("formatter returned %T", formatBindingError(err))
+	}
+
+	if payload["code"] != ErrCodeTimeout || payload["reason"] != ErrReasonTimeout || payload["category"] != ErrCategorySSH || payload["op"] != "ConnectSSH" {
+		t.Fatalf("unexpected payload %v", payload)
+	}
+	if payload["message"] != err.Error() {
+		t.Fatalf("message = %q, want %q", payload["message"], err.Error())
+	}
+	if wrapThermicError(ErrCategorySSH, "noop", nil) != nil {
+		t.Fatal("wrapping nil should return nil")
+	}
+}
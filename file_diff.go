@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// DiffContextLines is the number of unchanged lines shown around each change
+const DiffContextLines = 3
+
+// diffMissingFile names the missing side of a diff, as diff and git do
+const diffMissingFile = "/dev/null"
+
+// readLocalFileForDiff reads a local file, refusing files over limit bytes
+func readLocalFileForDiff(localPath string, limit int64) ([]byte, error) {
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat local file %s: %w", localPath, err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("invalid local path %s: is a directory", localPath)
+	}
+	if info.Size() > limit {
+		return nil, newFileTooLargeError("DiffRemoteFile", localPath, info.Size(), limit)
+	}
+	content, err := os.ReadFile(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local file %s: %w", localPath, err)
+	}
+	return content, nil
+}
+
+// diffLines splits content into lines that each end in a newline, adding one to a last line
+// without it
+func diffLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+// unifiedDiff returns the unified diff turning from into to, empty when they're the same.
+// Content with NUL bytes is reported as "Binary files ... differ" instead.
+func unifiedDiff(from, to []byte, fromName, toName string) (string, error) {
+	if bytes.Equal(from, to) {
+		return "", nil
+	}
+	if !isTextContent(from) || !isTextContent(to) {
+		return fmt.Sprintf("Binary files %s and %s differ\n", fromName, toName), nil
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(from),
+		B:        diffLines(to),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  DiffContextLines,
+	})
+}
+
+// DiffFile returns the unified diff from a remote file to a local one: what uploading the
+// local file would change. A remote file that doesn't exist yet diffs as empty. Both files
+// are subject to the MaxPreviewSize limit.
+func (s *SFTPService) DiffFile(sessionID string, remotePath string, localPath string) (string, error) {
+	local, err := readLocalFileForDiff(localPath, s.config().MaxPreviewSize)
+	if err != nil {
+		return "", err
+	}
+
+	fromName := remotePath
+	remote, _, err := s.readFile(sessionID, remotePath, "DiffRemoteFile")
+	if errors.Is(err, os.ErrNotExist) {
+		fromName, remote = diffMissingFile, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	return unifiedDiff(remote, local, fromName, localPath)
+}
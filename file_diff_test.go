@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffFile(t *testing.T) {
+	service, _, _, _ := newTestSFTPService(t, "s1", SFTPConfig{MaxPreviewSize: 1024})
+	dir := t.TempDir()
+	localPath := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(localPath, []byte("host = web\nport = 8080\nworkers = 4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A remote file that doesn't exist yet diffs as empty
+	diff, err := service.DiffFile("s1", "/etc/app.conf", localPath)
+	if err != nil {
+		t.Fatalf("DiffFile() of a new file returned error: %v", err)
+	}
+	if !strings.HasPrefix(diff, "--- /dev/null\n+++ "+localPath+"\n") || !strings.Contains(diff, "+port = 8080\n") {
+		t.Errorf("DiffFile() of a new file = %q, want every line added", diff)
+	}
+
+	if err := service.UpdateFileContent("s1", "/app.conf", "host = web\nport = 80\nworkers = 4\n"); err != nil {
+		t.Fatal(err)
+	}
+	diff, err = service.DiffFile("s1", "/app.conf", localPath)
+	if err != nil {
+		t.Fatalf("DiffFile() returned error: %v", err)
+	}
+	want := "--- /app.conf\n+++ " + localPath + "\n@@ -1,3 +1,3 @@\n host = web\n-port = 80\n+port = 8080\n workers = 4\n"
+	if diff != want {
+		t.Errorf("DiffFile() = %q, want %q", diff, want)
+	}
+
+	// The same content has no diff
+	if err := service.UpdateFileContent("s1", "/app.conf", "host = web\nport = 8080\nworkers = 4\n"); err != nil {
+		t.Fatal(err)
+	}
+	if diff, err := service.DiffFile("s1", "/app.conf", localPath); err != nil || diff != "" {
+		t.Errorf("DiffFile() of identical files = %q, %v; want no diff", diff, err)
+	}
+}
+
+func TestDiffFileBinaryAndLimits(t *testing.T) {
+	service, _, _, _ := newTestSFTPService(t, "s1", SFTPConfig{MaxPreviewSize: 16})
+	dir := t.TempDir()
+	localPath := filepath.Join(dir, "logo.png")
+	if err := os.WriteFile(localPath, []byte{0x89, 'P', 'N', 'G', 0, 1}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.UpdateFileContent("s1", "/logo.png", "\x89PNG\x00\x02"); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := service.DiffFile("s1", "/logo.png", localPath)
+	if err != nil || diff != "Binary files /logo.png and "+localPath+" differ\n" {
+		t.Errorf("DiffFile() of binary files = %q, %v; want them reported as differing", diff, err)
+	}
+
+	bigPath := filepath.Join(dir, "big.txt")
+	if err := os.WriteFile(bigPath, []byte(strings.Repeat("x", 17)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.DiffFile("s1", "/logo.png", bigPath); toThermicError(err).Code != ErrCodeTooLarge {
+		t.Errorf("DiffFile() of a file over the limit = %v, want too large", err)
+	}
+	if _, err := service.DiffFile("s1", "/logo.png", filepath.Join(dir, "missing")); toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("DiffFile() of a missing local file = %v, want not found", err)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileExplorerOnlySession is an SSH connection opened for the file explorer alone, without a
+// tab, PTY or shell
+type FileExplorerOnlySession struct {
+	SessionID string    `json:"sessionId"`
+	ProfileID string    `json:"profileId"`
+	Name      string    `json:"name"` // The profile's name
+	Opened    time.Time `json:"opened"`
+}
+
+// fileExplorerOnlySessions holds the sessions opened with OpenFileExplorerOnly. They count as
+// live alongside tabs, so the orphan sweeper leaves their state alone.
+var fileExplorerOnlySessions = make(map[string]FileExplorerOnlySession)
+var fileExplorerOnlySessionsMu sync.Mutex
+
+// OpenFileExplorerOnly connects to an SSH profile's host and opens an SFTP client on it, without
+// a tab or shell, for when only the file explorer is wanted. No monitoring connection is opened
+// either, so remote features fall back to SFTP or are unavailable. Returns the session ID the
+// SFTP methods take; close it with CloseFileExplorerOnly.
+func (a *App) OpenFileExplorerOnly(profileID string) (string, error) {
+	a.profiles.mutex.RLock()
+	profile, exists := a.profiles.profiles[profileID]
+	var name, profileType string
+	var config *SSHConfig
+	if exists {
+		name, profileType, config = profile.Name, profile.Type, profile.SSHConfig
+	}
+	a.profiles.mutex.RUnlock()
+
+	if !exists {
+		return "", newNotFoundError(ErrCategoryProfile, "OpenFileExplorerOnly", "profile not found: %s", profileID)
+	}
+	if profileType != ProfileTypeSSH || config == nil {
+		return "", fmt.Errorf("invalid profile %s: only SSH profiles have a file explorer", profileID)
+	}
+	if err := config.Validate(); err != nil {
+		return "", fmt.Errorf("invalid SSH config: %w", err)
+	}
+
+	go a.updateProfileUsage(profileID)
+
+	// Tracked before connecting, so the sweeper doesn't take the session for an orphan while
+	// it comes up
+	sessionID := fmt.Sprintf("session_%d", time.Now().UnixNano())
+	fileExplorerOnlySessionsMu.Lock()
+	fileExplorerOnlySessions[sessionID] = FileExplorerOnlySession{
+		SessionID: sessionID,
+		ProfileID: profileID,
+		Name:      name,
+		Opened:    time.Now(),
+	}
+	fileExplorerOnlySessionsMu.Unlock()
+
+	client, jumpClients, err := a.connectSSHClient(sessionID, config)
+	if err != nil {
+		a.CloseFileExplorerOnly(sessionID)
+		return "", err
+	}
+
+	sshSession := &SSHSession{
+		client:          client,
+		jumpClients:     jumpClients,
+		done:            make(chan bool),
+		closed:          make(chan bool),
+		forceClose:      make(chan bool),
+		sessionID:       sessionID,
+		lastActivity:    time.Now(),
+		monitoringCache: make(map[string]string),
+	}
+	a.ssh.sshSessionsMutex.Lock()
+	a.ssh.sshSessions[sessionID] = sshSession
+	a.ssh.sshSessionsMutex.Unlock()
+
+	if err := a.InitializeFileExplorerSession(sessionID); err != nil {
+		a.CloseFileExplorerOnly(sessionID)
+		return "", err
+	}
+
+	fmt.Printf("Opened file explorer only session %s for profile %s\n", sessionID, profileID)
+	return sessionID, nil
+}
+
+// CloseFileExplorerOnly closes a session opened with OpenFileExplorerOnly: its SFTP client,
+// its state and its SSH connection
+func (a *App) CloseFileExplorerOnly(sessionID string) error {
+	fileExplorerOnlySessionsMu.Lock()
+	_, exists := fileExplorerOnlySessions[sessionID]
+	delete(fileExplorerOnlySessions, sessionID)
+	fileExplorerOnlySessionsMu.Unlock()
+
+	if !exists {
+		return newNotFoundError(ErrCategorySFTP, "CloseFileExplorerOnly", "file explorer session %s not found", sessionID)
+	}
+	a.CleanupSession(sessionID)
+	return nil
+}
+
+// GetFileExplorerOnlySessions returns the open file explorer only sessions, oldest first
+func (a *App) GetFileExplorerOnlySessions() []FileExplorerOnlySession {
+	fileExplorerOnlySessionsMu.Lock()
+	defer fileExplorerOnlySessionsMu.Unlock()
+	sessions := make([]FileExplorerOnlySession, 0, len(fileExplorerOnlySessions))
+	for _, session := range fileExplorerOnlySessions {
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Opened.Before(sessions[j].Opened) })
+	return sessions
+}
+
+// fileExplorerOnlySessionIDs returns the IDs of the open file explorer only sessions
+func fileExplorerOnlySessionIDs() []string {
+	fileExplorerOnlySessionsMu.Lock()
+	defer fileExplorerOnlySessionsMu.Unlock()
+	return mapKeys(fileExplorerOnlySessions)
+}
+
+// isFileExplorerOnlySession reports whether a session was opened without a terminal tab
+func isFileExplorerOnlySession(sessionID string) bool {
+	fileExplorerOnlySessionsMu.Lock()
+	defer fileExplorerOnlySessionsMu.Unlock()
+	_, exists := fileExplorerOnlySessions[sessionID]
+	return exists
+}
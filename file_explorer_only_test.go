@@ -0,0 +1,200 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestOpenFileExplorerOnly(t *testing.T) {
+	// Keep known_hosts and the profile usage writes away from the real user config
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("AppData", home)
+
+	addr, hostKey, channels := listenSFTPServer(t)
+	host, portText, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(portText)
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey) + "\n"
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "known_hosts"), []byte(line), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	app := NewApp()
+	app.profiles.profiles["sftp-only"] = &Profile{
+		ID:   "sftp-only",
+		Name: "Files",
+		Type: ProfileTypeSSH,
+		SSHConfig: &SSHConfig{
+			Host:     host,
+			Port:     port,
+			Username: "deploy",
+			Password: "secret",
+			Proxy:    &ProxyConfig{Mode: ProxyModeNone},
+		},
+	}
+	app.profiles.profiles["local"] = &Profile{ID: "local", Name: "Shell", Type: "bash"}
+
+	sessionID, err := app.OpenFileExplorerOnly("sftp-only")
+	if err != nil {
+		t.Fatalf("OpenFileExplorerOnly() returned error: %v", err)
+	}
+	defer app.ReleaseSession(sessionID)
+
+	// Wait for the usage update, so its profile write doesn't outlive the test
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		app.profiles.mutex.RLock()
+		used := app.profiles.profiles["sftp-only"].UsageCount
+		app.profiles.mutex.RUnlock()
+		if used == 1 || time.Now().After(deadline) {
+			break
+		}
+	}
+
+	sessions := app.GetFileExplorerOnlySessions()
+	if len(sessions) != 1 || sessions[0].SessionID != sessionID || sessions[0].Name != "Files" {
+		t.Fatalf("GetFileExplorerOnlySessions() = %+v, want the opened session", sessions)
+	}
+	if !app.liveSessionIDs()[sessionID] {
+		t.Error("file explorer only session not counted as live")
+	}
+	if len(app.terminal.tabs) != 0 {
+		t.Errorf("%d tabs created, want none", len(app.terminal.tabs))
+	}
+	app.ssh.sshSessionsMutex.RLock()
+	sshSession := app.ssh.sshSessions[sessionID]
+	app.ssh.sshSessionsMutex.RUnlock()
+	if sshSession == nil || sshSession.session != nil {
+		t.Fatal("want an SSH connection without a shell session")
+	}
+	if _, err := app.ListRemoteFiles(sessionID, "/"); err != nil {
+		t.Errorf("ListRemoteFiles() returned error: %v", err)
+	}
+	if n := atomic.LoadInt32(channels); n != 1 {
+		t.Errorf("%d SFTP channels opened, want 1", n)
+	}
+
+	if err := app.CloseFileExplorerOnly(sessionID); err != nil {
+		t.Fatalf("CloseFileExplorerOnly() returned error: %v", err)
+	}
+	if len(app.GetFileExplorerOnlySessions()) != 0 {
+		t.Error("session still listed after closing")
+	}
+	// The connection and SFTP client close in the background
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		app.ssh.sftpClientsMutex.RLock()
+		_, sftpOpen := app.ssh.sftpClients[sessionID]
+		app.ssh.sftpClientsMutex.RUnlock()
+		app.ssh.sshSessionsMutex.RLock()
+		_, sshOpen := app.ssh.sshSessions[sessionID]
+		app.ssh.sshSessionsMutex.RUnlock()
+		if !sftpOpen && !sshOpen {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("after closing: SFTP client open = %v, SSH connection open = %v", sftpOpen, sshOpen)
+		}
+	}
+	if err := app.CloseFileExplorerOnly(sessionID); err == nil {
+		t.Error("closing a session twice returned no error")
+	}
+
+	if _, err := app.OpenFileExplorerOnly("missing"); err == nil {
+		t.Error("opening an unknown profile returned no error")
+	}
+	if _, err := app.OpenFileExplorerOnly("local"); err == nil {
+		t.Error("opening a non-SSH profile returned no error")
+	}
+}
+
+func TestOpenFileExplorerOnlyFirstConnect(t *testing.T) {
+	// No known_hosts, so the host key has to be trusted without a terminal tab
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("AppData", home)
+
+	originalInterval := hostKeyWatchdogInterval
+	hostKeyWatchdogInterval = 10 * time.Millisecond
+	defer func() { hostKeyWatchdogInterval = originalInterval }()
+
+	addr, _, _ := listenSFTPServer(t)
+	host, portText, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(portText)
+
+	app := NewApp()
+	app.profiles.profiles["sftp-only"] = &Profile{
+		ID:   "sftp-only",
+		Name: "Files",
+		Type: ProfileTypeSSH,
+		SSHConfig: &SSHConfig{
+			Host:     host,
+			Port:     port,
+			Username: "deploy",
+			Password: "secret",
+			Proxy:    &ProxyConfig{Mode: ProxyModeNone},
+		},
+	}
+
+	type openResult struct {
+		sessionID string
+		err       error
+	}
+	result := make(chan openResult, 1)
+	go func() {
+		sessionID, err := app.OpenFileExplorerOnly("sftp-only")
+		result <- openResult{sessionID, err}
+	}()
+
+	// Answer only after several watchdog ticks, which must not take the session for closed
+	var sessionID string
+	for deadline := time.Now().Add(5 * time.Second); sessionID == ""; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("no host key prompt for the file explorer only session")
+		}
+		pendingHostKeyTrustMutex.Lock()
+		for _, id := range fileExplorerOnlySessionIDs() {
+			if _, exists := pendingHostKeyTrust[id]; exists {
+				sessionID = id
+			}
+		}
+		pendingHostKeyTrustMutex.Unlock()
+	}
+	time.Sleep(10 * hostKeyWatchdogInterval)
+	if err := resolveHostKeyTrust(sessionID, true); err != nil {
+		t.Fatalf("prompt gone before the user answered: %v", err)
+	}
+
+	select {
+	case res := <-result:
+		if res.err != nil {
+			t.Fatalf("OpenFileExplorerOnly() returned error: %v", res.err)
+		}
+		defer app.ReleaseSession(res.sessionID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("OpenFileExplorerOnly() didn't return after the host key was trusted")
+	}
+	if content, err := os.ReadFile(filepath.Join(home, ".ssh", "known_hosts")); err != nil || len(content) == 0 {
+		t.Errorf("host key not added to known_hosts: %q, %v", content, err)
+	}
+
+	// Wait for the usage update, so its profile write doesn't outlive the test
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		app.profiles.mutex.RLock()
+		used := app.profiles.profiles["sftp-only"].UsageCount
+		app.profiles.mutex.RUnlock()
+		if used == 1 || time.Now().After(deadline) {
+			break
+		}
+	}
+}
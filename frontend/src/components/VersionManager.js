@@ -4,7 +4,7 @@
  */
 
 // Import Wails bindings properly
-import { GetVersionInfo, CheckForUpdates, DownloadAndInstallUpdate } from '../../wailsjs/go/main/App';
+import { GetVersionInfo, CheckForUpdates, CheckForUpdatesNow, DownloadAndInstallUpdate } from '../../wailsjs/go/main/App';
 
 class VersionManager {
     constructor() {
@@ -267,7 +267,7 @@ Platform: ${this.versionInfo.platform}/${this.versionInfo.arch}
         
         try {
             console.log('Manually checking for updates...');
-            this.updateInfo = await CheckForUpdates();
+            this.updateInfo = await CheckForUpdatesNow();
             
             if (this.updateInfo && this.updateInfo.available) {
                 console.log('Update available:', this.updateInfo);
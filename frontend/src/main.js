@@ -257,6 +257,8 @@ class ThermicTerminal {
             }
         });
 
+        this.setupNetworkStateReporting();
+
         // Also handle page visibility changes to reduce polling when hidden
         document.addEventListener('visibilitychange', () => {
             if (document.hidden) {
@@ -267,6 +269,23 @@ class ThermicTerminal {
         });
     }
 
+    setupNetworkStateReporting() {
+        // Profile reachability probes pause while offline or on a metered connection
+        const connection = navigator.connection;
+        const report = () => {
+            const metered = !!(connection && (connection.saveData || connection.type === 'cellular'));
+            window.go?.main?.App?.SetProbeNetworkState(metered, !navigator.onLine)
+                ?.catch(error => console.warn('Failed to report network state:', error));
+        };
+
+        window.addEventListener('online', report);
+        window.addEventListener('offline', report);
+        if (connection) {
+            connection.addEventListener('change', report);
+        }
+        report();
+    }
+
     setupModuleCommunication() {
         // Connect UI theme changes to terminal
         this.uiManager.setThemeChangeCallback((isDarkTheme) => {
@@ -331,6 +350,10 @@ class ThermicTerminal {
             this.tabsManager.createNewTab();
         });
 
+        document.addEventListener('terminal:reopen-closed-tab', () => {
+            this.tabsManager.reopenClosedTab();
+        });
+
         document.addEventListener('terminal:close-tab', (e) => {
             if (e.detail && e.detail.sessionId) {
                 // Find tab by session ID
@@ -345,10 +368,10 @@ class ThermicTerminal {
 
         // Global keyboard shortcuts
         document.addEventListener('keydown', (e) => {
-            // Ctrl+Shift+T - New tab
+            // Ctrl+Shift+T - Reopen the last closed tab (a new tab when there is none)
             if (e.ctrlKey && e.shiftKey && e.code === 'KeyT') {
                 e.preventDefault();
-                this.tabsManager.createNewTab();
+                this.tabsManager.reopenClosedTab();
             }
             // Ctrl+Shift+N - New SSH tab
             else if (e.ctrlKey && e.shiftKey && e.code === 'KeyN') {
@@ -31,6 +31,14 @@ export class FileCommandRegistry extends CommandRegistry {
             (context) => context.isFile
         ));
 
+        this.register(new ContextMenuCommand(
+            'file-open-external',
+            'Open with Default App',
+            'open',
+            (context) => this.handleFileOpenExternal(context),
+            (context) => context.isFile
+        ));
+
         this.registerSeparator();
 
         this.register(new ContextMenuCommand(
@@ -135,6 +143,34 @@ export class FileCommandRegistry extends CommandRegistry {
         }
     }
 
+    async handleFileOpenExternal(context) {
+        const currentFileData = this.contextMenuManager.currentFileData;
+        if (!currentFileData || !this.remoteExplorerManager) return;
+
+        const sessionID = this.remoteExplorerManager.currentSessionID;
+        try {
+            showNotification(`Opening ${currentFileData.name}...`, 'info');
+            await window.go.main.App.OpenRemoteFileExternally(sessionID, currentFileData.path);
+        } catch (error) {
+            // Types not known to be safe (macros, scripts, archives) only open once confirmed
+            if (error?.reason === 'CONFIRMATION_REQUIRED') {
+                if (confirm(`${error.message}\n\nOpen it anyway?`)) {
+                    try {
+                        await window.go.main.App.OpenRemoteFileExternallyWithOptions(
+                            sessionID,
+                            currentFileData.path,
+                            { confirmed: true }
+                        );
+                    } catch (confirmedError) {
+                        showNotification(`Failed to open ${currentFileData.name}: ${confirmedError.message || confirmedError}`, 'error');
+                    }
+                }
+                return;
+            }
+            showNotification(`Failed to open ${currentFileData.name}: ${error.message || error}`, 'error');
+        }
+    }
+
     async handleFileDownload(context) {
         const currentFileData = this.contextMenuManager.currentFileData;
         if (!currentFileData || !this.remoteExplorerManager) return;
@@ -2,6 +2,7 @@
 import { ContextMenuCommand, CommandRegistry } from '../base/ContextMenuCommand.js';
 import { showNotification } from '../../utils.js';
 import { modal } from '../../../components/Modal.js';
+import { EventsOn } from '../../../../wailsjs/runtime/runtime';
 
 export class SidebarCommandRegistry extends CommandRegistry {
     constructor(contextMenuManager) {
@@ -90,6 +91,14 @@ export class SidebarCommandRegistry extends CommandRegistry {
             (context) => context.itemType === 'folder' || context.isRoot
         ));
 
+        this.register(new ContextMenuCommand(
+            'discover-local-hosts',
+            'Discover Local SSH Hosts',
+            'search',
+            (context) => this.handleDiscoverLocalHosts(context),
+            (context) => context.itemType === 'folder' || context.isRoot
+        ));
+
         this.registerSeparator();
 
         this.register(new ContextMenuCommand(
@@ -366,6 +375,92 @@ export class SidebarCommandRegistry extends CommandRegistry {
         }
     }
 
+    async handleDiscoverLocalHosts(context) {
+        const currentTarget = this.contextMenuManager.currentTarget;
+        const parentFolderId = currentTarget && currentTarget.dataset.type === 'folder' ? currentTarget.dataset.id : null;
+
+        modal.show({
+            title: 'Local SSH Hosts',
+            message: 'Searching the local network for SSH servers...',
+            content: '<div id="mdns-host-list" style="display: flex; flex-direction: column; gap: 8px; margin-top: 12px;"></div>',
+            buttons: [{ text: 'Close', style: 'secondary', action: 'cancel' }]
+        });
+
+        // Hosts arrive one by one while the browse runs; a host is sent again when it changes
+        const rows = new Map();
+        const unsubscribe = EventsOn('mdns:host-found', (host) => {
+            const list = document.getElementById('mdns-host-list');
+            if (!list) return;
+            const row = rows.get(host.hostId) || document.createElement('div');
+            if (!rows.has(host.hostId)) {
+                rows.set(host.hostId, row);
+                list.appendChild(row);
+            }
+            this.renderDiscoveredHostRow(row, host, parentFolderId);
+        });
+
+        try {
+            const hosts = await window.go.main.App.DiscoverLocalSSHHosts(0);
+            const message = document.getElementById('modal-message');
+            if (message) {
+                message.textContent = hosts.length > 0 ? `Found ${hosts.length} SSH host(s)` : 'No SSH hosts found on the local network';
+            }
+        } catch (error) {
+            console.error('Local SSH host discovery failed:', error);
+            modal.close();
+            showNotification(`Discovery failed: ${error.message || error}`, 'error');
+        } finally {
+            unsubscribe();
+        }
+    }
+
+    // Rows are built with textContent: names and TXT values come from the network
+    renderDiscoveredHostRow(row, host, parentFolderId) {
+        row.replaceChildren();
+        row.style.cssText = 'display: flex; align-items: center; gap: 8px; padding: 8px; border: 1px solid var(--border-color); border-radius: 6px;';
+
+        const info = document.createElement('div');
+        info.style.cssText = 'flex: 1; min-width: 0;';
+        const title = document.createElement('div');
+        title.style.fontWeight = '600';
+        title.textContent = `${host.name} (${host.hostname}:${host.port})`;
+        const details = document.createElement('div');
+        details.style.cssText = 'font-size: 12px; color: var(--text-secondary);';
+        details.textContent = (host.addresses || []).join(', ') + (host.profileId ? ' - already has a profile' : '');
+        info.append(title, details);
+
+        const connect = document.createElement('button');
+        connect.className = 'btn btn-secondary';
+        connect.textContent = 'Connect';
+        connect.addEventListener('click', async () => {
+            const tabsManager = window.thermicApp?.tabsManager;
+            if (!tabsManager) return;
+            modal.close();
+            try {
+                await tabsManager.createNewTab(null, { host: host.hostname, port: host.port });
+            } catch (error) {
+                showNotification(`Failed to connect to ${host.hostname}: ${error.message || error}`, 'error');
+            }
+        });
+
+        const create = document.createElement('button');
+        create.className = 'btn btn-secondary';
+        create.textContent = 'Create Profile';
+        create.disabled = !!host.profileId;
+        create.addEventListener('click', async () => {
+            modal.close();
+            if (window.sidebarManager) {
+                await window.sidebarManager.openProfilePanel('create', 'profile', parentFolderId, {
+                    name: host.name,
+                    type: 'ssh',
+                    sshConfig: { host: host.hostname, port: host.port }
+                });
+            }
+        });
+
+        row.append(info, connect, create);
+    }
+
     async handleSearch(context) {
         // Open search panel through sidebar manager
         if (window.sidebarManager) {
@@ -14,6 +14,7 @@ export class RemoteExplorerManager {
         this.loadingIndicator = null;
         this.breadcrumbs = [];
         this.fileCache = new Map(); // Cache directory listings for performance
+        this.dirWatch = null; // Watch of the shown directory: { id, sessionId, path }
         this.backgroundSessionID = null; // Track session in background
         this.backgroundRemotePath = null; // Track path in background
         this.maxHistoryItems = 50; // Maximum files to keep in history
@@ -96,7 +97,7 @@ export class RemoteExplorerManager {
                             );
                         } catch (uploadErr) {
                             const errorMsg = uploadErr.message || uploadErr.toString();
-                            const isPermissionError = this.isPermissionError(errorMsg);
+                            const isPermissionError = this.isPermissionError(uploadErr);
 
                             if (isPermissionError) {
                                 this.hideUploadProgress();
@@ -1010,7 +1011,7 @@ export class RemoteExplorerManager {
                 const errorMsg = apiError.message || apiError.toString();
 
                 // Check if this is a permission error
-                const isPermissionError = this.isPermissionError(errorMsg);
+                const isPermissionError = this.isPermissionError(apiError);
 
                 if (isPermissionError) {
                     console.log("Permission error detected, showing sudo retry option");
@@ -1106,6 +1107,7 @@ export class RemoteExplorerManager {
             this.currentRemotePath = remotePath;
             this.updateBreadcrumbs(remotePath);
             this.renderFileList(processedFiles);
+            this.watchCurrentDirectory();
             console.log("Fresh content rendered successfully");
         } catch (error) {
             console.error("Failed to load directory:", error);
@@ -1362,6 +1364,19 @@ export class RemoteExplorerManager {
             return;
         }
 
+        if (phase === "batch-summary") {
+            // Per-file errors were already shown; tell the user how the whole batch went
+            if (data.failed > 0) {
+                const names = (data.failures || []).slice(0, 3).map((f) => f.fileName).join(", ");
+                const more = data.failed > 3 ? `, and ${data.failed - 3} more` : "";
+                showNotification(
+                    `${data.failed} of ${data.totalFiles} files failed to ${isDownload ? "download" : "upload"}: ${names}${more}`,
+                    "error",
+                );
+            }
+            return;
+        }
+
         if (phase === "batch-complete") {
             this.resetBatchProgress();
             this.hideTransferProgress();
@@ -1494,6 +1509,57 @@ export class RemoteExplorerManager {
         }
     }
 
+    // Watch the shown directory so changes made elsewhere show up without a manual refresh
+    async watchCurrentDirectory() {
+        const sessionID = this.currentSessionID;
+        const path = this.currentRemotePath;
+        if (this.dirWatch && this.dirWatch.sessionId === sessionID && this.dirWatch.path === path) {
+            return;
+        }
+        await this.stopDirectoryWatch();
+        try {
+            const watch = await window.go.main.App.StartRemoteDirectoryWatch(sessionID, path);
+            // Keep the path as shown, which may be relative
+            this.dirWatch = { id: watch.id, sessionId: sessionID, path };
+        } catch (error) {
+            console.warn("Remote Explorer: Failed to watch directory:", error);
+        }
+    }
+
+    async stopDirectoryWatch() {
+        if (!this.dirWatch) return;
+        const watchID = this.dirWatch.id;
+        this.dirWatch = null;
+        try {
+            await window.go.main.App.StopRemoteDirectoryWatch(watchID);
+        } catch (error) {
+            // The watch already ended with its session
+        }
+    }
+
+    async handleRemoteDirChanged(data) {
+        if (!data || !this.dirWatch || data.watchId !== this.dirWatch.id) {
+            return;
+        }
+        if (data.error) {
+            // The directory went away; the next load starts a new watch
+            this.dirWatch = null;
+            return;
+        }
+        if (this.isActivePanel && this.currentSessionID === this.dirWatch.sessionId) {
+            await this.refreshCurrentDirectory();
+        } else {
+            this.fileCache.delete(`${this.dirWatch.sessionId}:${this.dirWatch.path}`);
+        }
+    }
+
+    // A directory download carries on without folders it can't read
+    handlePartialSkip(data) {
+        if (!data || !data.path) return;
+        console.warn(`Download of ${data.sourcePath} skipped ${data.path}: ${data.error}`);
+        showNotification(`Skipped ${data.path}: ${data.error}`, "warning", 6000);
+    }
+
     async handleSftpReconnected(data) {
         if (!data || !data.sessionId) {
             console.warn("Remote Explorer: Invalid sftp-reconnected data");
@@ -1758,7 +1824,7 @@ export class RemoteExplorerManager {
             : "file-item";
 
         return `
-            <div class="${fileClass}" data-name="${fileName}" data-path="${file.path}" data-is-dir="${file.isDir}" data-is-parent="${file.isParent || false}">
+            <div class="${fileClass}" data-name="${fileName}" data-path="${file.path}" data-is-dir="${file.isDir}" data-is-parent="${file.isParent || false}" data-fs-kind="${file.fsKind || ""}">
                 <div class="file-icon">${icon}</div>
                 <div class="file-details">
                     <div class="file-name">
@@ -1769,12 +1835,30 @@ export class RemoteExplorerManager {
                         <span class="file-size">${sizeDisplay}</span>
                         <span class="file-modified">${modTimeDisplay}</span>
                         ${!file.isParent ? `<span class="file-mode">${file.mode}</span>` : ""}
+                        ${file.fsType && !file.isParent ? `<span class="file-fs ${this.escapeHTML(file.fsKind || "")}" title="${this.escapeHTML(this.filesystemTitle(file))}">${this.escapeHTML(file.fsType)}</span>` : ""}
                     </div>
                 </div>
             </div>
         `;
     }
 
+    // Escape text from the remote host, such as a filesystem type from /proc/mounts, for innerHTML
+    escapeHTML(text) {
+        return String(text).replace(/[&<>"']/g, (c) => ({ "&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;", "'": "&#39;" })[c]);
+    }
+
+    // Tooltip for a mount point or an entry on a special filesystem
+    filesystemTitle(file) {
+        const where = file.mountPoint ? "Mount point" : "On";
+        if (file.fsKind === "virtual") {
+            return `${where}: virtual ${file.fsType} filesystem, can be huge or endless`;
+        }
+        if (file.fsKind === "network") {
+            return `${where}: network ${file.fsType} filesystem, can be slow`;
+        }
+        return `${where}: ${file.fsType} filesystem`;
+    }
+
     getFileIcon(file) {
         const svgIcon = (name) => `<img src="./icons/${name}.svg" class="svg-icon file-icon" alt="">`;
         
@@ -2570,6 +2654,8 @@ export class RemoteExplorerManager {
     async forceCleanup() {
         console.log("Remote Explorer: Force cleanup");
 
+        await this.stopDirectoryWatch();
+
         if (this.currentSessionID) {
             await this.cleanupSFTPSession(this.currentSessionID);
             this.currentSessionID = null;
@@ -2642,7 +2728,7 @@ export class RemoteExplorerManager {
         } catch (error) {
             console.error("Failed to create folder:", error);
             const errorMsg = error.message || error.toString();
-            const isPermissionError = this.isPermissionError(errorMsg);
+            const isPermissionError = this.isPermissionError(error);
 
             if (isPermissionError) {
                 // Try with sudo
@@ -2690,11 +2776,9 @@ export class RemoteExplorerManager {
         try {
             console.log("Creating file:", newFilePath);
 
-            // Create an empty file by uploading empty content
-            await window.go.main.App.UpdateRemoteFileContent(
+            await window.go.main.App.CreateRemoteFile(
                 this.currentSessionID,
                 newFilePath,
-                "",
             );
 
             showNotification(
@@ -2712,17 +2796,16 @@ export class RemoteExplorerManager {
         } catch (error) {
             console.error("Failed to create file:", error);
             const errorMsg = error.message || error.toString();
-            const isPermissionError = this.isPermissionError(errorMsg);
+            const isPermissionError = this.isPermissionError(error);
 
             if (isPermissionError) {
                 // Try with sudo
                 const useSudo = await this.confirmSudoOperation("create file", fileName);
                 if (useSudo) {
                     try {
-                        await window.go.main.App.UpdateRemoteFileContentWithSudo(
+                        await window.go.main.App.CreateRemoteFileWithSudo(
                             this.currentSessionID,
                             newFilePath,
-                            "",
                         );
                         showNotification(
                             `File "${fileName}" created with sudo`,
@@ -2774,9 +2857,17 @@ export class RemoteExplorerManager {
             // Refresh the current directory to show the renamed item
             await this.refreshCurrentDirectory();
         } catch (error) {
+            // The backend won't replace an existing item unless asked to
+            if (error?.reason === "ALREADY_EXISTS") {
+                if (confirm(`"${newName}" already exists. Replace it?`)) {
+                    await this.replaceOnRename(oldPath, newPath, oldName, newName);
+                }
+                return;
+            }
+
             console.error("Failed to rename file:", error);
             const errorMsg = error.message || error.toString();
-            const isPermissionError = this.isPermissionError(errorMsg);
+            const isPermissionError = this.isPermissionError(error);
 
             if (isPermissionError) {
                 const useSudo = await this.confirmSudoOperation("rename", oldName);
@@ -2801,6 +2892,23 @@ export class RemoteExplorerManager {
         }
     }
 
+    // Rename onto an existing item the user agreed to replace
+    async replaceOnRename(oldPath, newPath, oldName, newName) {
+        try {
+            await window.go.main.App.RenameRemotePathWithOptions(
+                this.currentSessionID,
+                oldPath,
+                newPath,
+                { overwrite: true },
+            );
+            showNotification(`"${oldName}" renamed to "${newName}", replacing the existing item`, "success");
+            await this.refreshCurrentDirectory();
+        } catch (error) {
+            console.error("Failed to replace on rename:", error);
+            showNotification(`Failed to rename: ${error.message || error}`, "error");
+        }
+    }
+
     async deleteFile(filePath, fileName, isDir) {
         if (!this.currentSessionID) {
             showNotification("No active session", "error");
@@ -2826,7 +2934,7 @@ export class RemoteExplorerManager {
         } catch (error) {
             console.error("Failed to delete file:", error);
             const errorMsg = error.message || error.toString();
-            const isPermissionError = this.isPermissionError(errorMsg);
+            const isPermissionError = this.isPermissionError(error);
 
             if (isPermissionError) {
                 const useSudo = await this.confirmSudoOperation("delete", fileName);
@@ -2884,7 +2992,7 @@ export class RemoteExplorerManager {
                     console.log("Deleted:", path);
                 } catch (itemError) {
                     const errorMsg = itemError.message || itemError.toString();
-                    const isPermissionError = this.isPermissionError(errorMsg);
+                    const isPermissionError = this.isPermissionError(itemError);
 
                     if (isPermissionError && !useSudoForAll) {
                         const useSudo = await this.confirmSudoOperation("delete remaining items", `${selectedElements.length - deletedCount} item(s)`);
@@ -2938,6 +3046,14 @@ export class RemoteExplorerManager {
                 `(${isDir ? "directory" : "file"})`,
             );
 
+            // Walking a virtual or network filesystem can take forever
+            const fsKind = isDir
+                ? document.querySelector(`.file-item[data-path="${CSS.escape(filePath)}"]`)?.dataset.fsKind
+                : "";
+            if (fsKind && !confirm(`"${fileName}" is on a ${fsKind} filesystem, which can be huge or slow to copy. Download it anyway?`)) {
+                return;
+            }
+
             // Use Wails runtime to show save dialog
             if (window.go?.main?.App?.SelectSaveLocation) {
                 const localPath =
@@ -2961,11 +3077,7 @@ export class RemoteExplorerManager {
                 // Progress events will be received via sftp-download-progress
                 if (isDir) {
                     // Use the new directory download method for folders
-                    await window.go.main.App.DownloadRemoteDirectory(
-                        this.currentSessionID,
-                        filePath,
-                        localPath,
-                    );
+                    await this.downloadDirectory(filePath, localPath);
                 } else {
                     // Use regular file download for individual files
                     await window.go.main.App.DownloadRemoteFile(
@@ -2992,6 +3104,32 @@ export class RemoteExplorerManager {
         }
     }
 
+    // Download a folder, offering to go ahead anyway when the backend's pre-flight
+    // check finds too little free space on the local disk
+    async downloadDirectory(remotePath, localPath) {
+        try {
+            await window.go.main.App.DownloadRemoteDirectory(
+                this.currentSessionID,
+                remotePath,
+                localPath,
+            );
+        } catch (error) {
+            // A download stopped part way for low space is reported, not retried
+            const preflight =
+                error?.reason === "INSUFFICIENT_SPACE" &&
+                !String(error.message).startsWith("download stopped");
+            if (!preflight || !confirm(`${error.message}\n\nDownload anyway?`)) {
+                throw error;
+            }
+            await window.go.main.App.DownloadRemoteDirectoryWithOptions(
+                this.currentSessionID,
+                remotePath,
+                localPath,
+                { skipPreflight: true },
+            );
+        }
+    }
+
     async uploadToDirectory(targetPath) {
         if (!this.currentSessionID) {
             showNotification("No active session", "error");
@@ -3026,7 +3164,7 @@ export class RemoteExplorerManager {
                     );
                 } catch (uploadError) {
                     const errorMsg = uploadError.message || uploadError.toString();
-                    const isPermissionError = this.isPermissionError(errorMsg);
+                    const isPermissionError = this.isPermissionError(uploadError);
 
                     if (isPermissionError) {
                         this.hideUploadProgress();
@@ -3130,7 +3268,7 @@ export class RemoteExplorerManager {
                     );
                 } catch (uploadError) {
                     const errorMsg = uploadError.message || uploadError.toString();
-                    const isPermissionError = this.isPermissionError(errorMsg);
+                    const isPermissionError = this.isPermissionError(uploadError);
 
                     if (isPermissionError) {
                         // Try with sudo
@@ -3226,16 +3364,19 @@ export class RemoteExplorerManager {
         try {
             // Download file content for preview
             let content;
+            let version = "";
             let usedSudoForRead = false;
             
             try {
-                content = await window.go.main.App.GetRemoteFileContent(
+                const opened = await window.go.main.App.GetRemoteFileContentWithVersion(
                     this.currentSessionID,
                     filePath,
                 );
+                content = opened.content;
+                version = opened.version;
             } catch (readError) {
                 const errorMsg = readError.message || readError.toString();
-                const isPermissionError = this.isPermissionError(errorMsg);
+                const isPermissionError = this.isPermissionError(readError);
                 
                 if (isPermissionError) {
                     console.log("Permission error reading file, trying with sudo");
@@ -3299,17 +3440,86 @@ export class RemoteExplorerManager {
             }
 
             // Show file preview panel
-            this.showFilePreviewPanel(filePath, fileName, content, false, requiresSudo);
+            this.showFilePreviewPanel(filePath, fileName, content, false, requiresSudo, version);
 
             // Update status to show file is loaded
             updateStatus(`Previewing: ${fileName}${requiresSudo ? " (read-only)" : ""}`);
         } catch (error) {
             console.error("Failed to load file content:", error);
+            if (error && error.reason === "FILE_TOO_LARGE") {
+                const choice = await this.confirmDownloadLargeFile(fileName, error.message);
+                if (choice === "confirm") {
+                    await this.downloadFile(filePath, fileName, false);
+                } else if (choice === "tail") {
+                    await this.showFileTail(filePath, fileName);
+                }
+                return;
+            }
             showNotification(`Failed to load file: ${error.message}`, "error");
         }
     }
 
-    showFilePreviewPanel(filePath, fileName, content, forceTextMode = false, requiresSudo = false) {
+    // Offer to download a file that is over the backend's preview size limit
+    async confirmDownloadLargeFile(fileName, reason) {
+        // The reason names the path, which may contain markup characters
+        const escapedReason = this.escapeHTML(reason);
+        if (window.modal) {
+            const result = await window.modal.show({
+                title: "File Too Large",
+                message: `"${fileName}" is too large to open in the editor.`,
+                content: `
+                    <p style="margin-top: 12px; color: var(--text-secondary);">
+                        Do you want to download it instead?
+                    </p>
+                    <p style="margin-top: 8px; font-size: 12px; color: var(--text-tertiary);">
+                        ${escapedReason}
+                    </p>
+                `,
+                buttons: [
+                    {
+                        text: "Cancel",
+                        style: "secondary",
+                        action: "cancel",
+                    },
+                    {
+                        text: "Show Last Lines",
+                        style: "secondary",
+                        action: "tail",
+                    },
+                    {
+                        text: "Download",
+                        style: "primary",
+                        action: "confirm",
+                    },
+                ],
+            });
+            return result;
+        }
+        return confirm(`"${fileName}" is too large to open in the editor. Download it instead?`) ? "confirm" : "cancel";
+    }
+
+    // Show the end of a file read-only, for logs too large for the editor
+    async showFileTail(filePath, fileName, lines = 500) {
+        try {
+            const tail = await window.go.main.App.GetRemoteFileTail(this.currentSessionID, filePath, lines);
+            const escaped = this.escapeHTML(tail);
+            if (!window.modal) {
+                showNotification("File viewer not available", "error");
+                return;
+            }
+            await window.modal.show({
+                title: `Last ${lines} lines`,
+                message: `"${fileName}"`,
+                content: `<pre style="max-height: 60vh; overflow: auto; font-family: var(--font-mono); font-size: 12px; white-space: pre-wrap;">${escaped}</pre>`,
+                buttons: [{ text: "Close", style: "primary", action: "cancel" }],
+            });
+        } catch (error) {
+            console.error("Failed to read the end of the file:", error);
+            showNotification(`Failed to read the end of "${fileName}": ${error.message || error}`, "error");
+        }
+    }
+
+    showFilePreviewPanel(filePath, fileName, content, forceTextMode = false, requiresSudo = false, version = "") {
         // Create a larger panel overlay similar to profile panel but bigger
         const overlay = document.createElement("div");
         overlay.id = "file-preview-overlay";
@@ -3392,6 +3602,7 @@ export class RemoteExplorerManager {
             isText: isTextFile,
             forceTextMode: forceTextMode,
             requiresSudo: requiresSudo,
+            version: version, // Saves are refused if the file changed on the server since
         };
 
         // Setup event handlers
@@ -3430,6 +3641,8 @@ export class RemoteExplorerManager {
                             fileName,
                             content,
                             true,
+                            requiresSudo,
+                            version,
                         );
                     }, 300);
                 });
@@ -3998,9 +4211,8 @@ export class RemoteExplorerManager {
             } else {
                 // Try regular save first
                 try {
-                    await window.go.main.App.UpdateRemoteFileContent(
-                        this.currentSessionID,
-                        this.currentEditingFile.path,
+                    this.currentEditingFile.version = await this.saveUnlessChanged(
+                        this.currentEditingFile,
                         newContent,
                     );
                     showNotification(
@@ -4010,7 +4222,7 @@ export class RemoteExplorerManager {
                 } catch (regularError) {
                     // Check if it's a permission error
                     const errorMsg = regularError.message || regularError.toString();
-                    const isPermissionError = this.isPermissionError(errorMsg);
+                    const isPermissionError = this.isPermissionError(regularError);
 
                     if (isPermissionError) {
                         // Offer to retry with sudo
@@ -4105,8 +4317,13 @@ export class RemoteExplorerManager {
         }
     }
 
-    // Check if an error message indicates a permission problem
-    isPermissionError(errorMsg) {
+    // Check if an error indicates a permission problem. Backend errors carry a
+    // reason code; plain strings (and errors from older paths) are matched by text.
+    isPermissionError(error) {
+        if (error && typeof error === "object" && error.reason) {
+            return error.reason === "PERMISSION_DENIED";
+        }
+        const errorMsg = typeof error === "string" ? error : (error?.message || String(error));
         const lowerMsg = errorMsg.toLowerCase();
         return lowerMsg.includes("permission") ||
             lowerMsg.includes("denied") ||
@@ -4154,6 +4371,35 @@ export class RemoteExplorerManager {
     }
 
     // Show confirmation dialog for sudo save
+    // Saves the file against the version it was opened at. When someone else changed it
+    // since, asks whether to overwrite their changes. Returns the new version.
+    async saveUnlessChanged(file, content) {
+        try {
+            return await window.go.main.App.UpdateRemoteFileContentIfUnchanged(
+                this.currentSessionID,
+                file.path,
+                content,
+                file.version || "",
+            );
+        } catch (error) {
+            if (error?.reason !== "CONFLICT") {
+                throw error;
+            }
+            const overwrite = confirm(
+                `${error.message}\n\nOverwrite the changes on the server with yours?`,
+            );
+            if (!overwrite) {
+                throw new Error("Save cancelled - the file changed on the server; reopen it to see the changes");
+            }
+            return await window.go.main.App.UpdateRemoteFileContentIfUnchanged(
+                this.currentSessionID,
+                file.path,
+                content,
+                "",
+            );
+        }
+    }
+
     async confirmSudoSave(fileName) {
         if (window.modal) {
             const result = await window.modal.show({
@@ -471,9 +471,12 @@ export class SettingsManager {
             const parallelTransfersInput = document.getElementById('sftp-parallel-transfers-input');
             const maxPacketInput = document.getElementById('sftp-max-packet-input');
             const bufferSizeInput = document.getElementById('sftp-buffer-size-input');
+            const maxPreviewInput = document.getElementById('sftp-max-preview-input');
             const concurrentIOToggle = document.getElementById('sftp-concurrent-io-toggle');
+            const autoTuneToggle = document.getElementById('sftp-auto-tune-toggle');
+            const autoTuneBufferToggle = document.getElementById('sftp-auto-tune-buffer-toggle');
 
-            if (!parallelTransfersInput || !maxPacketInput || !bufferSizeInput || !concurrentIOToggle) {
+            if (!parallelTransfersInput || !maxPacketInput || !bufferSizeInput || !maxPreviewInput || !concurrentIOToggle || !autoTuneToggle || !autoTuneBufferToggle) {
                 console.warn('SFTP settings elements not found in DOM');
                 return;
             }
@@ -485,7 +488,10 @@ export class SettingsManager {
                 parallelTransfersInput.value = sftpConfig.parallel_transfers || 4;
                 maxPacketInput.value = (sftpConfig.max_packet_size || 262144) / 1024; // Convert bytes to KB
                 bufferSizeInput.value = (sftpConfig.buffer_size || 1048576) / 1024; // Convert bytes to KB
+                maxPreviewInput.value = Math.round((sftpConfig.max_preview_size || 10485760) / 1048576); // Convert bytes to MB
                 concurrentIOToggle.checked = sftpConfig.use_concurrent_io !== false; // Default to true
+                autoTuneToggle.checked = sftpConfig.auto_tune === true;
+                autoTuneBufferToggle.checked = sftpConfig.auto_tune_buffer === true;
             }
 
             // Debounced handlers for numeric inputs
@@ -538,6 +544,17 @@ export class SettingsManager {
                 saveWithDebounce('buffer_size', valueKB * 1024);
             });
 
+            // Max editor file size handler (input in MB, store in bytes)
+            maxPreviewInput.addEventListener('input', async (event) => {
+                const valueMB = parseInt(event.target.value, 10);
+                if (isNaN(valueMB) || valueMB < 1 || valueMB > 256) {
+                    showNotification('Max editor file size must be between 1 and 256 MB', 'error');
+                    event.target.value = 10;
+                    return;
+                }
+                saveWithDebounce('max_preview_size', valueMB * 1048576);
+            });
+
             // Concurrent I/O toggle handler
             concurrentIOToggle.addEventListener('change', async (event) => {
                 try {
@@ -552,6 +569,34 @@ export class SettingsManager {
                 }
             });
 
+            // Auto-tune toggle handler
+            autoTuneToggle.addEventListener('change', async (event) => {
+                try {
+                    const currentConfig = await window.go.main.App.ConfigGet("SFTP") || {};
+                    currentConfig.auto_tune = event.target.checked;
+                    await window.go.main.App.ConfigSet("SFTP", currentConfig);
+                    showNotification(`SFTP auto-tune ${event.target.checked ? 'enabled' : 'disabled'}`, 'info');
+                } catch (error) {
+                    console.error('Error updating SFTP auto-tune setting:', error);
+                    showNotification(`Failed to update setting: ${error.message}`, 'error');
+                    event.target.checked = !event.target.checked;
+                }
+            });
+
+            // Buffer auto-tune toggle handler
+            autoTuneBufferToggle.addEventListener('change', async (event) => {
+                try {
+                    const currentConfig = await window.go.main.App.ConfigGet("SFTP") || {};
+                    currentConfig.auto_tune_buffer = event.target.checked;
+                    await window.go.main.App.ConfigSet("SFTP", currentConfig);
+                    showNotification(`SFTP buffer auto-tune ${event.target.checked ? 'enabled' : 'disabled'}`, 'info');
+                } catch (error) {
+                    console.error('Error updating SFTP buffer auto-tune setting:', error);
+                    showNotification(`Failed to update setting: ${error.message}`, 'error');
+                    event.target.checked = !event.target.checked;
+                }
+            });
+
         } catch (error) {
             console.error('Error in setupSFTPSettings:', error);
         }
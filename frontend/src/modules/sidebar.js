@@ -318,7 +318,11 @@ export class SidebarManager {
             
             // Create tab based on profile type
             let newTab;
-            if (profile.type === 'ssh' && profile.sshConfig) {
+            if (profile.connectionSequence?.length) {
+                // The backend opens the sequence's other tabs once this one exists
+                console.log('Creating tab with connection sequence:', profile.connectionSequence);
+                newTab = await tabsManager.createTabFromProfile(profileId);
+            } else if (profile.type === 'ssh' && profile.sshConfig) {
                 console.log('Creating SSH tab with config:', profile.sshConfig);
                 newTab = await tabsManager.createNewTab(null, profile.sshConfig, profileId);
             } else {
@@ -342,8 +346,8 @@ export class SidebarManager {
                 }
             }
             
-            // Update tab title to match profile name
-            if (newTab && profileName !== newTab.title) {
+            // Update tab title to match profile name, unless the profile formats its own title
+            if (newTab && !newTab.sshConfig?.titleFormat && profileName !== newTab.title) {
                 try {
                     await window.go.main.App.RenameTab(newTab.id, profileName);
                     newTab.title = profileName;
@@ -1216,6 +1220,28 @@ export class SidebarManager {
                 this.renderProfileTree();
             });
         });
+
+        // Progress of a long profile load, e.g. a profiles directory on a network drive
+        EventsOn('profiles:loading', (data) => {
+            const seen = data.parsed + data.failed;
+            updateStatus(`Loading profiles... ${seen}/${data.discovered}`);
+        });
+
+        EventsOn('profiles:load-complete', (summary) => {
+            updateStatus(`Loaded ${summary.profiles} profiles`);
+            if (summary.error) {
+                showNotification(`Failed to load profiles: ${summary.error}`, 'error');
+                return;
+            }
+            if (summary.failed > 0) {
+                console.warn('Profile files that failed to load:', summary.errors);
+                showNotification(`${summary.failed} profile file(s) could not be loaded - check console for details`, 'warning', 6000);
+            }
+        });
+
+        EventsOn('profiles:operation-progress', (data) => {
+            updateStatus(`${data.operation}: ${data.processed}/${data.total} ${data.current}`);
+        });
     }
 
     // Helper methods
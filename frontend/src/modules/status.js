@@ -1,6 +1,8 @@
 // Enhanced Status management module
 import { GetPlatformInfo, GetActiveTabInfo, GetSystemStats, GetMetricHistory, SetUpdateRate, GetSystemMetadata } from '../../wailsjs/go/main/App';
+import { EventsOn } from '../../wailsjs/runtime/runtime';
 import { GraphModal } from '../components/GraphModal.js';
+import { showNotification } from './utils.js';
 
 export class StatusManager {
     constructor() {
@@ -43,6 +45,13 @@ export class StatusManager {
         // Tab switch events are now handled by the global terminal manager
         // No individual listeners needed here to prevent memory leaks
         console.log('StatusManager event listeners set up (using global terminal manager)');
+
+        // Remote disk usage crossed an alert threshold (the backend applies the cooldown)
+        EventsOn('disk-full-alert', (data) => {
+            const usage = Math.round(data.usagePercent);
+            const type = data.severity === 'critical' ? 'error' : 'warning';
+            showNotification(`Disk ${data.mountPoint} is ${usage}% full`, type, 8000);
+        });
     }
 
     async updateDisplay() {
@@ -299,7 +308,8 @@ export class StatusManager {
         
         // Parse CPU (percentage)
         const cpu = stats.cpu || 'unknown';
-        const cpuValue = cpu !== 'unknown' ? cpu.replace('%', '') : '0';
+        // The first poll has no previous sample to measure against
+        const cpuValue = stats.cpu_warming_up ? '--' : (cpu !== 'unknown' ? cpu.replace('%', '') : '0');
         
         // Parse Memory (convert to MB, then to GB if large)
         const memory = stats.memory || 'unknown';
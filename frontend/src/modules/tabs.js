@@ -1,5 +1,6 @@
 // Tabs management module
-import { CreateTab, GetTabs, SetActiveTab, CloseTab, StartTabShell, GetAvailableShellsFormatted, StartTabShellWithSize, ResizeShell, ForceDisconnectTab, ReconnectTab } from '../../wailsjs/go/main/App';
+import { CreateTab, CreateTabFromProfile, GetTabs, SetActiveTab, CloseTab, StartTabShell, GetAvailableShellsFormatted, StartTabShellWithSize, ResizeShell, ForceDisconnectTab, ReconnectTab } from '../../wailsjs/go/main/App';
+import { EventsOn } from '../../wailsjs/runtime/runtime';
 import { generateSessionId, formatShellName, updateStatus } from './utils.js';
 
 export class TabsManager {
@@ -85,6 +86,9 @@ export class TabsManager {
     }
 
     setupTabEvents() {
+        // Bells and idle notifications from the backend
+        EventsOn('terminal-bell', (data) => this.handleTerminalBell(data));
+
         // New tab button
         document.addEventListener('click', (e) => {
             if (e.target.closest('#new-tab-btn')) {
@@ -198,6 +202,17 @@ export class TabsManager {
         this.updateTabStatusDisplay(tabId);
     }
 
+    handleTabTitleUpdate(data) {
+        const { tabId, title } = data;
+        const tab = this.tabs.get(tabId);
+        if (!tab || !title || tab.title === title) {
+            return;
+        }
+
+        tab.title = title;
+        this.renderTabs();
+    }
+
     updateTabStatusDisplay(tabId) {
         const tabElement = document.querySelector(`[data-tab-id="${tabId}"]`);
         if (!tabElement) return;
@@ -402,17 +417,7 @@ export class TabsManager {
                 tab.title = formattedShellName;
             }
             
-            // Add to local tabs (use backend's session ID)
-            this.tabs.set(tab.id, tab);
-
-            // Create terminal session using backend's session ID
-            this.terminalManager.createTerminalSession(tab.sessionId);
-
-            // Switch to the new tab immediately so user can see connection progress
-            await this.switchToTab(tab.id);
-
-            // Start shell process (this will show connecting status and progress)
-            await this.startTabShell(tab.id);
+            await this.adoptTab(tab);
 
             updateStatus(`New tab created: ${tab.title}`);
             return tab;
@@ -424,6 +429,59 @@ export class TabsManager {
         }
     }
 
+    // Opens a profile through the backend, which also runs the profile's connection sequence
+    async createTabFromProfile(profileId) {
+        const tab = await CreateTabFromProfile(profileId);
+        await this.adoptTab(tab);
+        return tab;
+    }
+
+    // Reopens the most recently closed tab, or opens a new one when nothing was closed
+    async reopenClosedTab() {
+        let reopened;
+        try {
+            reopened = await window.go.main.App.ReopenClosedTab('');
+        } catch (error) {
+            console.log('No closed tab to reopen:', error);
+            return this.createNewTab();
+        }
+
+        const tab = reopened.tab;
+        await this.adoptTab(tab, { startShell: reopened.connect });
+
+        // The backend put the tab back where it was; pick up the new order
+        const backendTabs = await GetTabs();
+        for (const backendTab of backendTabs) {
+            const localTab = this.tabs.get(backendTab.id);
+            if (localTab) {
+                localTab.created = backendTab.created;
+            }
+        }
+        this.renderTabs();
+
+        updateStatus(`Reopened tab: ${tab.title}`);
+        return tab;
+    }
+
+    // Shows a tab the backend created and starts its shell
+    async adoptTab(tab, { startShell = true } = {}) {
+        if (this.tabs.has(tab.id)) return;
+
+        // Add to local tabs (use backend's session ID)
+        this.tabs.set(tab.id, tab);
+
+        // Create terminal session using backend's session ID
+        this.terminalManager.createTerminalSession(tab.sessionId);
+
+        // Switch to the new tab immediately so user can see connection progress
+        await this.switchToTab(tab.id);
+
+        // Start shell process (this will show connecting status and progress)
+        if (startShell) {
+            await this.startTabShell(tab.id);
+        }
+    }
+
     async startTabShell(tabId) {
         try {
             const tab = this.tabs.get(tabId);
@@ -763,14 +821,21 @@ export class TabsManager {
         tabEl.innerHTML = `
             <div class="tab-content">
                 <div class="tab-icon">${iconSvg}</div>
-                <div class="tab-title">${displayTitle}</div>
+                <div class="tab-title"></div>
                 ${statusIndicatorHtml}
                 ${actionButtonsHtml}
                 ${closeButtonHtml}
             </div>
-            ${needsTooltip || (isSSH && tab.status && (tab.status === 'failed' || tab.status === 'hanging')) ? `<div class="tab-tooltip">${tooltipText}</div>` : ''}
+            ${needsTooltip || (isSSH && tab.status && (tab.status === 'failed' || tab.status === 'hanging')) ? '<div class="tab-tooltip"></div>' : ''}
         `;
 
+        // Titles can come from the remote host (OSC title, cwd), so never parse them as HTML
+        tabEl.querySelector('.tab-title').textContent = displayTitle;
+        const tooltipEl = tabEl.querySelector('.tab-tooltip');
+        if (tooltipEl) {
+            tooltipEl.textContent = tooltipText;
+        }
+
         // Set tooltip on the element
         tabEl.title = tooltipText;
 
@@ -1172,6 +1237,29 @@ export class TabsManager {
         }
     }
 
+    // Flash the tab that rang the bell or went idle, and show an OS notification when enabled
+    // and the tab isn't in view
+    handleTerminalBell(data) {
+        if (!data || !data.tabId || !this.tabs.has(data.tabId)) return;
+        this.markTabActivity(data.tabId);
+
+        const inView = data.tabId === this.activeTabId && !document.hidden;
+        if (!data.notify || inView || typeof window.Notification === 'undefined') return;
+
+        const tab = this.tabs.get(data.tabId);
+        const body = data.kind === 'idle'
+            ? 'Output stopped after ' + Math.round((data.activeMs || 0) / 1000) + 's of activity'
+            : 'Bell';
+        const show = () => new window.Notification(tab.title || 'Thermic', { body, tag: 'thermic-bell-' + data.tabId });
+        if (window.Notification.permission === 'granted') {
+            show();
+        } else if (window.Notification.permission !== 'denied') {
+            window.Notification.requestPermission().then((permission) => {
+                if (permission === 'granted') show();
+            });
+        }
+    }
+
     // Method to clear tab activity (when tab becomes active)
     clearTabActivity(tabId) {
         if (this.tabActivity.has(tabId)) {
@@ -399,6 +399,17 @@ export function createTerminalSettingsContent() {
                         </div>
                     </div>
                 </div>
+                <div class="setting-item">
+                    <div class="setting-item-content">
+                        <div class="setting-item-info">
+                            <div class="setting-item-title">Max Editor File Size (MB)</div>
+                            <div class="setting-item-description">Larger files are offered as a download instead of opening in the editor (1-256)</div>
+                        </div>
+                        <div class="setting-item-control">
+                            <input type="number" id="sftp-max-preview-input" class="modern-input" value="10" min="1" max="256">
+                        </div>
+                    </div>
+                </div>
                 <div class="setting-item">
                     <div class="setting-item-content">
                         <div class="setting-item-info">
@@ -413,6 +424,34 @@ export function createTerminalSettingsContent() {
                         </div>
                     </div>
                 </div>
+                <div class="setting-item">
+                    <div class="setting-item-content">
+                        <div class="setting-item-info">
+                            <div class="setting-item-title">Auto-tune Requests</div>
+                            <div class="setting-item-description">Measure large transfers and pick the requests in flight per host</div>
+                        </div>
+                        <div class="setting-item-control">
+                            <label class="modern-toggle">
+                                <input type="checkbox" id="sftp-auto-tune-toggle">
+                                <span class="toggle-slider"></span>
+                            </label>
+                        </div>
+                    </div>
+                </div>
+                <div class="setting-item">
+                    <div class="setting-item-content">
+                        <div class="setting-item-info">
+                            <div class="setting-item-title">Auto-tune Buffer Size</div>
+                            <div class="setting-item-description">Benchmark each connection and size the transfer buffer to its throughput</div>
+                        </div>
+                        <div class="setting-item-control">
+                            <label class="modern-toggle">
+                                <input type="checkbox" id="sftp-auto-tune-buffer-toggle">
+                                <span class="toggle-slider"></span>
+                            </label>
+                        </div>
+                    </div>
+                </div>
             </div>
         </div>
     `;
@@ -21,6 +21,7 @@ import {
     generateSessionId,
     formatShellName,
     updateStatus,
+    showNotification,
 } from "./utils.js";
 import { AIFloatWindow } from "../components/AIFloatWindow.js";
 
@@ -41,6 +42,10 @@ export class TerminalManager {
         // Global terminal output handler - single listener for all sessions
         this.globalOutputListener = null;
         this.globalTabStatusListener = null;
+        this.globalTabStatusBulkListener = null;
+        this.globalTabTitleListener = null;
+        this.globalConnectionSequenceListener = null;
+        this.globalMaintenanceBannerListener = null;
         this.globalTabSwitchListener = null;
         this.globalSizeSyncListener = null;
         this.globalConfigListener = null;
@@ -63,6 +68,7 @@ export class TerminalManager {
         this.hostKeyPromptMode = {
             active: false,
             sessionId: null,
+            firstConnect: false, // Trusting a new host rather than approving a changed key
             keydownHandler: null,
         };
 
@@ -292,30 +298,59 @@ export class TerminalManager {
                             "Global listener received tab status update:",
                             data,
                         );
+                        this.forwardTabStatusUpdate(data);
+                    },
+                );
+
+                // During reconnect storms the backend batches status updates into one event
+                this.globalTabStatusBulkListener = EventsOn(
+                    "tabs-status-bulk",
+                    (data) => {
+                        console.log(
+                            `Global listener received ${data.count} batched tab status updates`,
+                        );
+                        for (const update of data.updates || []) {
+                            this.forwardTabStatusUpdate(update);
+                        }
+                    },
+                );
 
-                        // Forward to tabs manager if it exists
+                // Titles built from a format follow the connection status
+                this.globalTabTitleListener = EventsOn(
+                    "tab-title-updated",
+                    (data) => {
                         if (
                             window.tabsManager &&
-                            typeof window.tabsManager.handleTabStatusUpdate ===
-                                "function"
+                            typeof window.tabsManager.handleTabTitleUpdate === "function"
                         ) {
-                            window.tabsManager.handleTabStatusUpdate(data);
-                        } else {
-                            console.warn(
-                                "TabsManager not available for status update:",
-                                data,
-                            );
+                            window.tabsManager.handleTabTitleUpdate(data);
                         }
+                    },
+                );
 
-                        // Forward to remote explorer manager if it exists
-                        if (
-                            window.remoteExplorerManager &&
-                            typeof window.remoteExplorerManager
-                                .handleTabStatusUpdate === "function"
-                        ) {
-                            window.remoteExplorerManager.handleTabStatusUpdate(
-                                data,
+                // Tabs covered by a maintenance window show a banner until it ends
+                this.globalMaintenanceBannerListener = EventsOn(
+                    "maintenance-banner",
+                    (data) => {
+                        this.setMaintenanceBanner(data.sessionId, data.banner);
+                    },
+                );
+
+                // Tabs opened by a profile's connection sequence are created by the backend
+                this.globalConnectionSequenceListener = EventsOn(
+                    "connection-sequence-progress",
+                    (data) => {
+                        if (data.phase === "tab-created" && data.tab && window.tabsManager) {
+                            window.tabsManager.adoptTab(data.tab).catch((error) => {
+                                console.error("Failed to show connection sequence tab:", error);
+                            });
+                        } else if (data.phase === "failed") {
+                            showNotification(
+                                `Connection sequence stopped at step ${data.step + 1} of ${data.total}: ${data.error}`,
+                                "error",
                             );
+                        } else if (data.phase === "complete") {
+                            updateStatus(`Connection sequence opened ${data.total} tabs`);
                         }
                     },
                 );
@@ -381,6 +416,38 @@ export class TerminalManager {
                     },
                 );
 
+                // Set up listener for folders a directory download had to skip
+                this.globalSftpPartialSkipListener = EventsOn(
+                    "sftp-partial-skip",
+                    (data) => {
+                        if (
+                            window.remoteExplorerManager &&
+                            typeof window.remoteExplorerManager
+                                .handlePartialSkip === "function"
+                        ) {
+                            window.remoteExplorerManager.handlePartialSkip(
+                                data,
+                            );
+                        }
+                    },
+                );
+
+                // Set up remote directory watch listener
+                this.globalRemoteDirChangedListener = EventsOn(
+                    "remote-dir-changed",
+                    (data) => {
+                        if (
+                            window.remoteExplorerManager &&
+                            typeof window.remoteExplorerManager
+                                .handleRemoteDirChanged === "function"
+                        ) {
+                            window.remoteExplorerManager.handleRemoteDirChanged(
+                                data,
+                            );
+                        }
+                    },
+                );
+
                 // Set up tab switch listener for status bar updates
                 this.globalTabSwitchListener = EventsOn(
                     "tab-switched",
@@ -448,6 +515,31 @@ export class TerminalManager {
                     },
                 );
 
+                // Set up first-connection host key listener
+                this.globalHostKeyFirstConnectListener = EventsOn(
+                    "host-key-first-connect",
+                    (data) => {
+                        console.log(
+                            "Global listener received first-connect host key:",
+                            data,
+                        );
+                        // A file explorer only session has no terminal to answer in
+                        if (data.explorerOnly) {
+                            const note = data.note ? `\n${data.note}` : "";
+                            EventsEmit("host-key-trust-decision", {
+                                sessionId: data.sessionId,
+                                accept: confirm(
+                                    `The authenticity of host ${data.hostname} can't be established.\n` +
+                                        `${data.keyType} key fingerprint is ${data.fingerprint}${note}\n\n` +
+                                        "Trust this host?",
+                                ),
+                            });
+                            return;
+                        }
+                        this.enableHostKeyPromptMode(data.sessionId, true);
+                    },
+                );
+
                 this.globalListenerSetup = true;
                 console.log("Global event listeners set up successfully");
             } catch (error) {
@@ -458,6 +550,27 @@ export class TerminalManager {
         }
     }
 
+    forwardTabStatusUpdate(data) {
+        // Forward to tabs manager if it exists
+        if (
+            window.tabsManager &&
+            typeof window.tabsManager.handleTabStatusUpdate === "function"
+        ) {
+            window.tabsManager.handleTabStatusUpdate(data);
+        } else {
+            console.warn("TabsManager not available for status update:", data);
+        }
+
+        // Forward to remote explorer manager if it exists
+        if (
+            window.remoteExplorerManager &&
+            typeof window.remoteExplorerManager.handleTabStatusUpdate ===
+                "function"
+        ) {
+            window.remoteExplorerManager.handleTabStatusUpdate(data);
+        }
+    }
+
     initTerminal() {
         // Initialize the main terminal container
         const terminalElement = document.getElementById("terminal");
@@ -473,6 +586,30 @@ export class TerminalManager {
         this.updateTerminalContainer();
     }
 
+    // Shows a maintenance window's banner over a session's terminal, or removes it when banner is null
+    setMaintenanceBanner(sessionId, banner) {
+        const container = document.querySelector(
+            `.terminal-instance[data-session-id="${sessionId}"]`,
+        );
+        if (!container) return;
+
+        let element = container.querySelector(".maintenance-banner");
+        if (!banner) {
+            element?.remove();
+            return;
+        }
+        if (!element) {
+            element = document.createElement("div");
+            element.className = "maintenance-banner";
+            container.appendChild(element);
+        }
+        const ends = new Date(banner.ends).toLocaleTimeString([], {
+            hour: "2-digit",
+            minute: "2-digit",
+        });
+        element.textContent = `Maintenance: ${banner.label} (until ${ends})`;
+    }
+
     createTerminalSession(sessionId) {
         // Check session limits
         if (this.terminals.size >= this.maxSessions) {
@@ -591,6 +728,11 @@ export class TerminalManager {
                 }
                 return false;
             }
+            // Ctrl+Shift+T - reopen the last closed tab
+            if (event.ctrlKey && event.shiftKey && event.code === "KeyT") {
+                document.dispatchEvent(new CustomEvent("terminal:reopen-closed-tab"));
+                return false;
+            }
             // Ctrl+T - new tab
             if (event.ctrlKey && event.code === "KeyT") {
                 // Emit event for new tab
@@ -2050,6 +2192,16 @@ export class TerminalManager {
             this.openLinksInExternalBrowser = openLinksExternal;
             // No need to apply to terminals - the handler checks the property at runtime
         });
+
+        // Settings were reset to defaults
+        EventsOn("config:reset", (data) => {
+            const { ScrollbackLines, OpenLinksInExternalBrowser } = data.settings;
+            console.log("Config reset to defaults");
+            this.scrollbackLines = ScrollbackLines;
+            this.maxBufferLines = ScrollbackLines;
+            this.openLinksInExternalBrowser = OpenLinksInExternalBrowser;
+            this.applyConfigToAllTerminals();
+        });
     }
 
     applyConfigToAllTerminals() {
@@ -2074,11 +2226,17 @@ export class TerminalManager {
     }
 
     // Host key prompt mode methods
-    enableHostKeyPromptMode(sessionId) {
+    enableHostKeyPromptMode(sessionId, firstConnect = false) {
         console.log(`Enabling host key prompt mode for session: ${sessionId}`);
 
+        // Only one prompt listens at a time; a newer one replaces it
+        if (this.hostKeyPromptMode.active) {
+            this.disableHostKeyPromptMode();
+        }
+
         this.hostKeyPromptMode.active = true;
         this.hostKeyPromptMode.sessionId = sessionId;
+        this.hostKeyPromptMode.firstConnect = firstConnect;
 
         // Add visual indicator to the terminal
         const terminalSession = this.terminals.get(sessionId);
@@ -2107,6 +2265,7 @@ export class TerminalManager {
         const sessionId = this.hostKeyPromptMode.sessionId;
         this.hostKeyPromptMode.active = false;
         this.hostKeyPromptMode.sessionId = null;
+        this.hostKeyPromptMode.firstConnect = false;
 
         // Remove visual indicator
         if (sessionId) {
@@ -2175,11 +2334,20 @@ export class TerminalManager {
                 `Host key ${approved ? "approved" : "rejected"} for session: ${sessionId}`,
             );
 
+            // A first connection waits on an event rather than a pending key update
+            if (this.hostKeyPromptMode.firstConnect) {
+                EventsEmit("host-key-trust-decision", {
+                    sessionId,
+                    accept: approved,
+                });
+                return;
+            }
+
             await ApproveHostKeyUpdate(sessionId, approved);
 
             if (approved) {
                 console.log(
-                    "Host key updated successfully. You can now retry the connection.",
+                    "Host key updated successfully. Connection continuing.",
                 );
             } else {
                 console.log("Host key update cancelled by user.");
@@ -2264,6 +2432,54 @@ export class TerminalManager {
             this.globalTabStatusListener = null;
         }
 
+        if (this.globalTabStatusBulkListener) {
+            try {
+                this.globalTabStatusBulkListener();
+            } catch (error) {
+                console.warn(
+                    "Error cleaning up global tab status bulk listener:",
+                    error,
+                );
+            }
+            this.globalTabStatusBulkListener = null;
+        }
+
+        if (this.globalTabTitleListener) {
+            try {
+                this.globalTabTitleListener();
+            } catch (error) {
+                console.warn(
+                    "Error cleaning up global tab title listener:",
+                    error,
+                );
+            }
+            this.globalTabTitleListener = null;
+        }
+
+        if (this.globalConnectionSequenceListener) {
+            try {
+                this.globalConnectionSequenceListener();
+            } catch (error) {
+                console.warn(
+                    "Error cleaning up global connection sequence listener:",
+                    error,
+                );
+            }
+            this.globalConnectionSequenceListener = null;
+        }
+
+        if (this.globalMaintenanceBannerListener) {
+            try {
+                this.globalMaintenanceBannerListener();
+            } catch (error) {
+                console.warn(
+                    "Error cleaning up global maintenance banner listener:",
+                    error,
+                );
+            }
+            this.globalMaintenanceBannerListener = null;
+        }
+
         if (this.globalTabSwitchListener) {
             try {
                 this.globalTabSwitchListener();
@@ -2325,6 +2541,18 @@ export class TerminalManager {
             this.globalHostKeyPromptListener = null;
         }
 
+        if (this.globalHostKeyFirstConnectListener) {
+            try {
+                this.globalHostKeyFirstConnectListener();
+            } catch (error) {
+                console.warn(
+                    "Error cleaning up global first-connect host key listener:",
+                    error,
+                );
+            }
+            this.globalHostKeyFirstConnectListener = null;
+        }
+
         // Disable host key prompt mode if active
         if (this.hostKeyPromptMode.active) {
             this.disableHostKeyPromptMode();
@@ -3,6 +3,7 @@
  */
 
 import { getThemeToggleIcon, updateThemeToggleIcon, updateAllIconsToInline } from '../utils/icons.js';
+import { EventsOn } from '../../wailsjs/runtime/runtime';
 
 class ThemeManager {
     constructor() {
@@ -25,6 +26,13 @@ class ThemeManager {
         this.setupThemeToggleButton();
         this.setupThemeObserver();
         await this.updateThemeIcons();
+
+        // Settings were reset to defaults - re-read the theme from the backend
+        EventsOn('config:reset', async () => {
+            await this.loadThemeFromConfig();
+            await this.updateThemeIcons();
+        });
+
         this.isInitialized = true;
         
         console.log('✅ ThemeManager fully initialized with theme:', this.currentTheme);
@@ -15,6 +15,8 @@ export function CheckFileWritePermission(arg1:string,arg2:string):Promise<boolea
 
 export function CheckForUpdates():Promise<main.UpdateInfo>;
 
+export function CheckForUpdatesNow():Promise<main.UpdateInfo>;
+
 export function CheckWSLAvailable():Promise<boolean>;
 
 export function CleanupSessionMetrics(arg1:string):Promise<void>;
@@ -101,7 +103,6 @@ export function DuplicateProfile(arg1:string):Promise<main.Profile>;
 
 export function EnableAI(arg1:boolean):Promise<void>;
 
-export function ExecuteMonitoringCommand(arg1:main.SSHSession,arg2:string):Promise<string>;
 
 export function ExportMetrics(arg1:string):Promise<void>;
 
@@ -245,6 +246,8 @@ export function MoveProfileByID(arg1:string,arg2:string):Promise<void>;
 
 export function MoveProfileByIDAPI(arg1:string,arg2:string):Promise<void>;
 
+export function OpenRemoteFileExternally(arg1:string,arg2:string):Promise<void>;
+
 export function ReconnectTab(arg1:string):Promise<void>;
 
 export function RecordMetric(arg1:string,arg2:string,arg3:number):Promise<void>;
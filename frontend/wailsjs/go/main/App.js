@@ -26,6 +26,10 @@ export function CheckForUpdates() {
   return window['go']['main']['App']['CheckForUpdates']();
 }
 
+export function CheckForUpdatesNow() {
+  return window['go']['main']['App']['CheckForUpdatesNow']();
+}
+
 export function CheckWSLAvailable() {
   return window['go']['main']['App']['CheckWSLAvailable']();
 }
@@ -198,10 +202,6 @@ export function EnableAI(arg1) {
   return window['go']['main']['App']['EnableAI'](arg1);
 }
 
-export function ExecuteMonitoringCommand(arg1, arg2) {
-  return window['go']['main']['App']['ExecuteMonitoringCommand'](arg1, arg2);
-}
-
 export function ExportMetrics(arg1) {
   return window['go']['main']['App']['ExportMetrics'](arg1);
 }
@@ -486,6 +486,10 @@ export function MoveProfileByIDAPI(arg1, arg2) {
   return window['go']['main']['App']['MoveProfileByIDAPI'](arg1, arg2);
 }
 
+export function OpenRemoteFileExternally(arg1, arg2) {
+  return window['go']['main']['App']['OpenRemoteFileExternally'](arg1, arg2);
+}
+
 export function ReconnectTab(arg1) {
   return window['go']['main']['App']['ReconnectTab'](arg1);
 }
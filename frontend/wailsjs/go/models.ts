@@ -472,8 +472,12 @@ export namespace main {
 	    latestVersion: string;
 	    currentVersion: string;
 	    downloadUrl: string;
+	    releaseUrl: string;
 	    releaseNotes: string;
 	    size: number;
+	    // Go type: time
+	    checkedAt: any;
+	    disabled: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new UpdateInfo(source);
@@ -485,9 +489,30 @@ export namespace main {
 	        this.latestVersion = source["latestVersion"];
 	        this.currentVersion = source["currentVersion"];
 	        this.downloadUrl = source["downloadUrl"];
+	        this.releaseUrl = source["releaseUrl"];
 	        this.releaseNotes = source["releaseNotes"];
 	        this.size = source["size"];
+	        this.checkedAt = this.convertValues(source["checkedAt"], null);
+	        this.disabled = source["disabled"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class VersionInfo {
 	    version: string;
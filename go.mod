@@ -7,10 +7,12 @@ require (
 	github.com/aymanbagabas/go-pty v0.2.2
 	github.com/fsnotify/fsnotify v1.9.0
 	github.com/pkg/sftp v1.13.9
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/sashabaranov/go-openai v1.17.9
 	github.com/shirou/gopsutil/v3 v3.24.5
 	github.com/wailsapp/wails/v2 v2.11.0
 	golang.org/x/crypto v0.38.0
+	golang.org/x/net v0.40.0
 	golang.org/x/sys v0.33.0
 	gopkg.in/yaml.v2 v2.2.8
 )
@@ -48,6 +50,5 @@ require (
 	github.com/wailsapp/go-webview2 v1.0.22 // indirect
 	github.com/wailsapp/mimetype v1.4.1 // indirect
 	github.com/yusufpapurcu/wmi v1.2.4 // indirect
-	golang.org/x/net v0.40.0 // indirect
 	golang.org/x/text v0.25.0 // indirect
 )
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Per-host connection limit constants
+const (
+	MaxHostConnectionLimit = 100
+	// HostConnectionQueueTimeout bounds how long a connection waits for another to the same host to close
+	HostConnectionQueueTimeout = 2 * time.Minute
+)
+
+// hostConnectionLimiter counts the open TCP connections to each host and makes new ones wait
+// while a host is at its limit
+type hostConnectionLimiter struct {
+	mu      sync.Mutex
+	open    map[string]int
+	changed chan struct{} // Closed and replaced whenever a connection is released
+}
+
+// hostConnections limits every SSH connection Thermic opens: shells, monitoring, jump hosts
+var hostConnections = newHostConnectionLimiter()
+
+func newHostConnectionLimiter() *hostConnectionLimiter {
+	return &hostConnectionLimiter{
+		open:    make(map[string]int),
+		changed: make(chan struct{}),
+	}
+}
+
+// acquire takes a connection slot for host, waiting up to timeout while limit connections are
+// open; a timeout of 0 or less fails at once instead. A limit of 0 or less means no limit.
+// The returned func releases the slot.
+func (l *hostConnectionLimiter) acquire(host string, limit int, timeout time.Duration) (func(), error) {
+	host = strings.ToLower(host)
+	deadline := time.Now().Add(timeout)
+	queued := false
+	for {
+		l.mu.Lock()
+		if limit <= 0 || l.open[host] < limit {
+			l.open[host]++
+			l.mu.Unlock()
+			var once sync.Once
+			return func() { once.Do(func() { l.release(host) }) }, nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		if timeout <= 0 {
+			return nil, fmt.Errorf("no free connection to %s: %d connections already open (MaxConnectionsPerHost)", host, limit)
+		}
+		if !queued {
+			fmt.Printf("Waiting for a free connection to %s (MaxConnectionsPerHost %d reached)\n", host, limit)
+			queued = true
+		}
+		select {
+		case <-changed:
+		case <-time.After(time.Until(deadline)):
+			return nil, fmt.Errorf("timed out waiting for a free connection to %s: %d connections already open (MaxConnectionsPerHost)", host, limit)
+		}
+	}
+}
+
+// release frees a slot and wakes the connections waiting for one
+func (l *hostConnectionLimiter) release(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.open[host]--; l.open[host] <= 0 {
+		delete(l.open, host)
+	}
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// count returns the connections open to host
+func (l *hostConnectionLimiter) count(host string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.open[strings.ToLower(host)]
+}
+
+// hostLimitedConn holds a connection slot until it is closed. The SSH client closes its
+// connection when the server drops it as well, so the slot is never leaked.
+type hostLimitedConn struct {
+	net.Conn
+	release func()
+}
+
+func (c *hostLimitedConn) Close() error {
+	err := c.Conn.Close()
+	c.release()
+	return err
+}
+
+// getMaxConnectionsPerHost returns the configured limit; 0 means unlimited
+func (a *App) getMaxConnectionsPerHost() int {
+	if a.config == nil || a.config.config == nil {
+		return 0
+	}
+	a.config.mutex.RLock()
+	defer a.config.mutex.RUnlock()
+	return a.config.config.MaxConnectionsPerHost
+}
+
+// dialHostLimited waits up to queueTimeout for a free connection slot for host, then dials.
+// The slot is held until the returned connection closes.
+func (a *App) dialHostLimited(host string, queueTimeout time.Duration, dial func() (net.Conn, error)) (net.Conn, error) {
+	release, err := hostConnections.acquire(host, a.getMaxConnectionsPerHost(), queueTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dial()
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &hostLimitedConn{Conn: conn, release: release}, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestHostConnectionLimiterQueues(t *testing.T) {
+	limiter := newHostConnectionLimiter()
+
+	releaseFirst, err := limiter.acquire("db1", 2, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseSecond, err := limiter.acquire("DB1", 2, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another host has its own slots
+	releaseOther, err := limiter.acquire("db2", 2, time.Second)
+	if err != nil {
+		t.Fatalf("acquire() for another host returned error: %v", err)
+	}
+	defer releaseOther()
+
+	// The third connection waits until one closes
+	acquired := make(chan func())
+	go func() {
+		release, err := limiter.acquire("db1", 2, 5*time.Second)
+		if err != nil {
+			t.Errorf("queued acquire() returned error: %v", err)
+		}
+		acquired <- release
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("third connection was not queued")
+	case <-time.After(50 * time.Millisecond):
+	}
+	releaseFirst()
+	releaseFirst() // Releasing twice frees one slot only
+	releaseThird := <-acquired
+	if got := limiter.count("db1"); got != 2 {
+		t.Errorf("count() = %d after the queued connection got its slot, want 2", got)
+	}
+
+	if _, err := limiter.acquire("db1", 2, 20*time.Millisecond); err == nil {
+		t.Error("acquire() over the limit did not time out")
+	}
+	// Without a timeout a full host fails at once
+	start := time.Now()
+	if _, err := limiter.acquire("db1", 2, 0); err == nil || time.Since(start) > time.Second {
+		t.Errorf("acquire() without a timeout = %v after %v, want an immediate error", err, time.Since(start))
+	}
+
+	releaseSecond()
+	releaseThird()
+	if got := limiter.count("db1"); got != 0 {
+		t.Errorf("count() = %d after every connection closed, want 0", got)
+	}
+}
+
+func TestDialHostLimitedHoldsSlotUntilClose(t *testing.T) {
+	app := NewApp()
+	app.config.config.MaxConnectionsPerHost = 1
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	dial := func() (net.Conn, error) { return net.Dial("tcp", listener.Addr().String()) }
+
+	conn, err := app.dialHostLimited("limited.example", HostConnectionQueueTimeout, dial)
+	if err != nil {
+		t.Fatalf("dialHostLimited() returned error: %v", err)
+	}
+	if got := hostConnections.count("limited.example"); got != 1 {
+		t.Errorf("count() = %d with the connection open, want 1", got)
+	}
+	conn.Close()
+	if got := hostConnections.count("limited.example"); got != 0 {
+		t.Errorf("count() = %d after Close, want 0", got)
+	}
+
+	// A failed dial gives its slot back
+	if _, err := app.dialHostLimited("limited.example", HostConnectionQueueTimeout, func() (net.Conn, error) { return nil, net.ErrClosed }); err == nil {
+		t.Fatal("dialHostLimited() hid the dial error")
+	}
+	if got := hostConnections.count("limited.example"); got != 0 {
+		t.Errorf("count() = %d after a failed dial, want 0", got)
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// hostKeyTrustTimeout is how long a first connection waits for the user to trust the host key
+var hostKeyTrustTimeout = 30 * time.Second
+
+// KnownHostEntry is a host key stored in known_hosts
+type KnownHostEntry struct {
+	Hostname    string `json:"hostname"`         // Host as looked up, in known_hosts form ([host]:port for non-22 ports)
+	Pattern     string `json:"pattern"`          // The host field that matched; hashed entries read "(hashed)"
+	KeyType     string `json:"keyType"`          // e.g. ssh-ed25519
+	Fingerprint string `json:"fingerprint"`      // SHA256:...
+	Marker      string `json:"marker,omitempty"` // "cert-authority" or "revoked"
+	Comment     string `json:"comment,omitempty"`
+}
+
+// pendingHostKeyTrust holds the decision channels of first connections waiting on the user
+var pendingHostKeyTrust = make(map[string]chan bool)
+var pendingHostKeyTrustMutex sync.Mutex
+
+// knownHostsFilePath returns the user's known_hosts path
+func knownHostsFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".ssh", "known_hosts"), nil
+}
+
+// confirmFirstConnect asks the user to trust the key of a host that isn't in known_hosts.
+// note, if set, is shown with the fingerprint (e.g. an unauthenticated SSHFP match). It blocks
+// the handshake until a host-key-trust-decision event answers, the prompt times out, or the
+// session is closed. Only an explicit accept returns nil. A file explorer only session has no
+// terminal to print the prompt in, so it is asked through the host-key-first-connect event alone.
+func (a *App) confirmFirstConnect(sessionID, hostname string, key ssh.PublicKey, note string) error {
+	decision := make(chan bool, 1)
+	pendingHostKeyTrustMutex.Lock()
+	pendingHostKeyTrust[sessionID] = decision
+	pendingHostKeyTrustMutex.Unlock()
+
+	explorerOnly := isFileExplorerOnlySession(sessionID)
+	defer func() {
+		pendingHostKeyTrustMutex.Lock()
+		if pendingHostKeyTrust[sessionID] == decision {
+			delete(pendingHostKeyTrust, sessionID)
+		}
+		pendingHostKeyTrustMutex.Unlock()
+		if !explorerOnly {
+			a.messages.SetHostKeyPromptActive(sessionID, false)
+		}
+	}()
+
+	fingerprint := ssh.FingerprintSHA256(key)
+	if !explorerOnly {
+		a.messages.SetHostKeyPromptActive(sessionID, true)
+		a.messages.EmitMessage(sessionID, fmt.Sprintf("The authenticity of host %s can't be established", hostname), MessageWarning)
+		a.messages.EmitMessage(sessionID, fmt.Sprintf("%s key fingerprint is %s", key.Type(), fingerprint), MessageInfo)
+		if note != "" {
+			a.messages.EmitMessage(sessionID, note, MessageInfo)
+		}
+		a.messages.EmitMessage(sessionID, "Trust this host? (ENTER=yes, ESC=no)", MessageWarning)
+	}
+
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, "host-key-first-connect", map[string]interface{}{
+			"sessionId":    sessionID,
+			"hostname":     hostname,
+			"fingerprint":  fingerprint,
+			"keyType":      key.Type(),
+			"note":         note,
+			"explorerOnly": explorerOnly,
+		})
+	}
+
+	timeout := time.NewTimer(hostKeyTrustTimeout)
+	defer timeout.Stop()
+
+	watchdog := time.NewTicker(hostKeyWatchdogInterval)
+	defer watchdog.Stop()
+
+	for {
+		select {
+		case accepted := <-decision:
+			if !accepted {
+				if !explorerOnly {
+					a.messages.EmitMessage(sessionID, "Connection cancelled", MessageWarning)
+				}
+				return fmt.Errorf("host key for %s rejected by user", hostname)
+			}
+			return nil
+
+		case <-timeout.C:
+			if !explorerOnly {
+				a.messages.EmitMessage(sessionID, "Host key prompt timed out", MessageWarning)
+			}
+			return fmt.Errorf("host key verification timed out after %v", hostKeyTrustTimeout)
+
+		case <-watchdog.C:
+			if !a.liveSessionIDs()[sessionID] {
+				return fmt.Errorf("host key verification cancelled: session %s closed", sessionID)
+			}
+		}
+	}
+}
+
+// resolveHostKeyTrust hands the user's answer to the first connection waiting on it
+func resolveHostKeyTrust(sessionID string, accept bool) error {
+	pendingHostKeyTrustMutex.Lock()
+	decision, exists := pendingHostKeyTrust[sessionID]
+	delete(pendingHostKeyTrust, sessionID)
+	pendingHostKeyTrustMutex.Unlock()
+
+	if !exists {
+		return fmt.Errorf("no pending host key prompt for session %s", sessionID)
+	}
+	// Buffered, and only written here after removal from the map
+	decision <- accept
+	return nil
+}
+
+// handleHostKeyTrustDecision handles host-key-trust-decision events: {sessionId, accept}
+func (a *App) handleHostKeyTrustDecision(optionalData ...interface{}) {
+	if len(optionalData) == 0 {
+		return
+	}
+	data, ok := optionalData[0].(map[string]interface{})
+	if !ok {
+		fmt.Printf("Ignoring malformed host key trust decision: %v\n", optionalData[0])
+		return
+	}
+	sessionID, _ := data["sessionId"].(string)
+	accept, _ := data["accept"].(bool)
+
+	if err := resolveHostKeyTrust(sessionID, accept); err != nil {
+		fmt.Printf("Host key trust decision: %v\n", err)
+	}
+}
+
+// knownHostsPatternMatches reports whether a known_hosts host field entry matches an
+// address in known_hosts form, including hashed (|1|salt|hash) and wildcard entries
+func knownHostsPatternMatches(pattern, address string) bool {
+	if strings.HasPrefix(pattern, "|1|") {
+		parts := strings.Split(pattern[3:], "|")
+		if len(parts) != 2 {
+			return false
+		}
+		salt, saltErr := base64.StdEncoding.DecodeString(parts[0])
+		hash, hashErr := base64.StdEncoding.DecodeString(parts[1])
+		if saltErr != nil || hashErr != nil {
+			return false
+		}
+		mac := hmac.New(sha1.New, salt)
+		mac.Write([]byte(address))
+		return hmac.Equal(mac.Sum(nil), hash)
+	}
+	if strings.ContainsAny(pattern, "*?") {
+		matched, err := path.Match(pattern, address)
+		return err == nil && matched
+	}
+	return pattern == address
+}
+
+// findKnownHostEntry returns the first key for hostname in known_hosts data
+func findKnownHostEntry(data []byte, hostname string) (*KnownHostEntry, bool) {
+	address := knownhosts.Normalize(hostname)
+
+	for len(bytes.TrimSpace(data)) > 0 {
+		marker, hosts, key, comment, rest, err := ssh.ParseKnownHosts(data)
+		if err != nil {
+			// ParseKnownHosts reports the first bad line and stops; nothing after it is usable
+			break
+		}
+		data = rest
+
+		negated := false
+		matchedPattern := ""
+		for _, pattern := range hosts {
+			if strings.HasPrefix(pattern, "!") {
+				if knownHostsPatternMatches(pattern[1:], address) {
+					negated = true
+				}
+			} else if matchedPattern == "" && knownHostsPatternMatches(pattern, address) {
+				matchedPattern = pattern
+			}
+		}
+		if negated || matchedPattern == "" {
+			continue
+		}
+		if strings.HasPrefix(matchedPattern, "|1|") {
+			matchedPattern = "(hashed)"
+		}
+
+		return &KnownHostEntry{
+			Hostname:    address,
+			Pattern:     matchedPattern,
+			KeyType:     key.Type(),
+			Fingerprint: ssh.FingerprintSHA256(key),
+			Marker:      marker,
+			Comment:     comment,
+		}, true
+	}
+	return nil, false
+}
+
+// GetHostKeyFromKnownHosts returns the key stored in known_hosts for a host ("example.com" or
+// "example.com:2222"), so it can be shown before connecting
+func (a *App) GetHostKeyFromKnownHosts(hostname string) (*KnownHostEntry, error) {
+	if strings.TrimSpace(hostname) == "" {
+		return nil, fmt.Errorf("hostname cannot be empty")
+	}
+	knownHostsPath, err := knownHostsFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(knownHostsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, newNotFoundError(ErrCategorySSH, "GetHostKeyFromKnownHosts", "%s is not in known_hosts", hostname)
+		}
+		return nil, fmt.Errorf("failed to read known_hosts file: %w", err)
+	}
+
+	entry, found := findKnownHostEntry(data, hostname)
+	if !found {
+		return nil, newNotFoundError(ErrCategorySSH, "GetHostKeyFromKnownHosts", "%s is not in known_hosts", hostname)
+	}
+	return entry, nil
+}
+
+// GetConnectionFingerprint returns the SHA256 fingerprint of the server key the session's
+// connection was verified with. ssh.Conn doesn't expose the server key after the handshake,
+// so this is the key recorded by the host key callback.
+func (a *App) GetConnectionFingerprint(sessionID string) (string, error) {
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+	if !exists || sshSession == nil || sshSession.client == nil {
+		return "", newNotFoundError(ErrCategorySSH, "GetConnectionFingerprint", "SSH session %s not found", sessionID)
+	}
+
+	verification := getHostKeyVerification(sessionID)
+	if verification == nil {
+		return "", newNotFoundError(ErrCategorySSH, "GetConnectionFingerprint", "no host key recorded for session %s", sessionID)
+	}
+	return verification.Fingerprint, nil
+}
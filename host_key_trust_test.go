@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestFirstConnectTrust(t *testing.T) {
+	for _, accept := range []bool{true, false} {
+		sessionID := "session_tofu"
+		defer forgetHostKeyVerification(sessionID)
+		app := NewApp()
+		app.terminal.tabs["tab_test"] = &Tab{ID: "tab_test", SessionID: sessionID}
+		knownHostsPath := filepath.Join(t.TempDir(), "known_hosts")
+		key := generateTestHostKey(t)
+
+		result := make(chan error, 1)
+		remote := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 22}
+		go func() { result <- app.acceptNewHostKey(sessionID, knownHostsPath, "example.com", remote, key) }()
+
+		deadline := time.Now().Add(2 * time.Second)
+		for resolveHostKeyTrust(sessionID, accept) != nil {
+			if time.Now().After(deadline) {
+				t.Fatal("first-connect prompt was never registered")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		err := waitForHostKeyResult(t, result)
+		content, _ := os.ReadFile(knownHostsPath)
+		if accept && (err != nil || !strings.Contains(string(content), knownhosts.Line([]string{"example.com"}, key))) {
+			t.Errorf("accepted key: err = %v, known_hosts = %q", err, content)
+		}
+		if !accept && (err == nil || len(content) != 0) {
+			t.Errorf("rejected key: err = %v, known_hosts = %q", err, content)
+		}
+	}
+}
+
+func TestFirstConnectTrustTimeout(t *testing.T) {
+	originalTimeout := hostKeyTrustTimeout
+	hostKeyTrustTimeout = 50 * time.Millisecond
+	defer func() { hostKeyTrustTimeout = originalTimeout }()
+
+	app := NewApp()
+	app.terminal.tabs["tab_test"] = &Tab{ID: "tab_test", SessionID: "session_tofu_timeout"}
+	err := app.confirmFirstConnect("session_tofu_timeout", "example.com", generateTestHostKey(t), "")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got: %v", err)
+	}
+	if app.messages.IsHostKeyPromptActive("session_tofu_timeout") {
+		t.Fatal("host key prompt still marked active after timeout")
+	}
+}
+
+func TestFindKnownHostEntry(t *testing.T) {
+	plainKey := generateTestHostKey(t)
+	hashedKey := generateTestHostKey(t)
+	data := "# comment\n" +
+		knownhosts.Line([]string{"example.com"}, plainKey) + "\n" +
+		knownhosts.HashHostname("[db.internal]:2222") + " " + strings.SplitN(knownhosts.Line([]string{"x"}, hashedKey), " ", 2)[1] + "\n"
+
+	entry, found := findKnownHostEntry([]byte(data), "example.com")
+	if !found || entry.Fingerprint != ssh.FingerprintSHA256(plainKey) || entry.Pattern != "example.com" {
+		t.Errorf("findKnownHostEntry(example.com) = %+v, %v", entry, found)
+	}
+	entry, found = findKnownHostEntry([]byte(data), "db.internal:2222")
+	if !found || entry.Fingerprint != ssh.FingerprintSHA256(hashedKey) || entry.Pattern != "(hashed)" {
+		t.Errorf("findKnownHostEntry(db.internal:2222) = %+v, %v", entry, found)
+	}
+	if _, found := findKnownHostEntry([]byte(data), "db.internal"); found {
+		t.Error("findKnownHostEntry() matched a host on the wrong port")
+	}
+}
@@ -0,0 +1,740 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Inline image protocols the frontend renderer can report support for
+const (
+	InlineImageProtocolSixel  = "sixel"  // DCS q ... ST (chafa, img2sixel, timg -ps)
+	InlineImageProtocolITerm2 = "iterm2" // OSC 1337 ; File= ... BEL (imgcat, timg -pi)
+	InlineImageProtocolKitty  = "kitty"  // APC G ... ST (kitty icat, timg -pk)
+
+	InlineImagesEvent = "terminal-inline-images"
+
+	inlineImageOmittedMarker = "\r\n\x1b[33m⚠ Inline image omitted: %d bytes exceeds the %d byte limit\x1b[0m\r\n"
+
+	// maxQueryLength bounds how long an unterminated device query is held back before
+	// it is treated as ordinary output
+	maxQueryLength = 4096
+
+	// sixelColorRegisters is reported to XTSMGRAPHICS queries
+	sixelColorRegisters = 256
+)
+
+// inlineImageHoldTimeout is how long a held-back sequence may go without new bytes before it is
+// given up on and released as ordinary output, so a program that never terminates one can't
+// hide the output after it
+var inlineImageHoldTimeout = 2 * time.Second
+
+// SupportedInlineImageProtocols lists every protocol the output filter understands
+var SupportedInlineImageProtocols = []string{InlineImageProtocolSixel, InlineImageProtocolITerm2, InlineImageProtocolKitty}
+
+// xtgettcapValues are the terminfo capabilities answered on behalf of the terminal
+var xtgettcapValues = map[string]string{
+	"TN":     "xterm-256color",
+	"Co":     "256",
+	"colors": "256",
+	"RGB":    "8",
+}
+
+// da1SixelReply identifies as a VT220-class terminal with sixel graphics (4) and ANSI color (22)
+const da1SixelReply = "\x1b[?62;4;22c"
+
+// seqKind identifies an escape sequence the inline image filter acts on
+type seqKind int
+
+const (
+	seqUnknown      seqKind = iota // Introducer not complete yet
+	seqSixel                       // Sixel image
+	seqITerm2                      // iTerm2 inline file
+	seqKitty                       // Kitty graphics command
+	seqDA1                         // Primary device attributes query
+	seqXTGETTCAP                   // Terminfo capability query
+	seqXTSMGRAPHICS                // Graphics attribute query
+)
+
+// isImage reports whether the sequence carries an image payload (as opposed to a query)
+func (k seqKind) isImage() bool {
+	return k == seqSixel || k == seqITerm2 || k == seqKitty
+}
+
+// scanStatus is the result of examining the bytes at an ESC
+type scanStatus int
+
+const (
+	scanNotOurs    scanStatus = iota // Ordinary output, pass through
+	scanIncomplete                   // Possibly ours, but the sequence continues in a later read
+	scanComplete                     // A whole sequence the filter handles
+)
+
+// inlineImageFilter sits between a session's output stream and the frontend. It reassembles
+// image sequences that reads split across chunks so each reaches the frontend as a single,
+// byte-identical write, enforces the payload cap, and answers capability queries so remote
+// tools pick a protocol the renderer can draw.
+type inlineImageFilter struct {
+	protocols  map[string]bool
+	maxPayload int
+
+	pending     []byte  // Incomplete sequence held back until its terminator arrives
+	pendingKind seqKind // Kind of the pending sequence (seqUnknown while the introducer is partial)
+
+	discarding  bool    // An oversized image is being skipped up to its terminator
+	discardKind seqKind // Kind of the image being skipped
+	discarded   int     // Bytes skipped so far
+	lastByte    byte    // Last byte skipped, to catch an ST split across reads
+
+	heldAt       time.Time   // When the held or skipped sequence last grew
+	releaseTimer *time.Timer // Releases a sequence that stopped growing, see inlineImageHoldTimeout
+
+	mutex       sync.Mutex
+	outputMutex sync.Mutex // Keeps writes in order between process and the release timer
+}
+
+// filteredOutput collects the result of one process call
+type filteredOutput struct {
+	chunks  []string
+	text    strings.Builder
+	replies []string
+}
+
+// addText appends ordinary output to the current run
+func (o *filteredOutput) addText(s string) {
+	o.text.WriteString(s)
+}
+
+// addChunk emits s as its own write so it is never merged or split
+func (o *filteredOutput) addChunk(s string) {
+	o.flushText()
+	o.chunks = append(o.chunks, s)
+}
+
+// flushText closes the current run of ordinary output
+func (o *filteredOutput) flushText() {
+	if o.text.Len() > 0 {
+		o.chunks = append(o.chunks, o.text.String())
+		o.text.Reset()
+	}
+}
+
+// newInlineImageFilter creates a filter for the negotiated protocols
+func newInlineImageFilter(protocols []string, maxPayload int) *inlineImageFilter {
+	enabled := make(map[string]bool, len(protocols))
+	for _, protocol := range protocols {
+		enabled[protocol] = true
+	}
+	return &inlineImageFilter{
+		protocols:  enabled,
+		maxPayload: maxPayload,
+	}
+}
+
+// process filters one read of session output. It returns the writes to send to the frontend,
+// in order, and the replies to send back to the remote program.
+func (f *inlineImageFilter) process(data string) (output []string, replies []string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	defer func() {
+		if f.holding() {
+			f.heldAt = time.Now()
+		}
+	}()
+
+	out := &filteredOutput{}
+
+	if f.discarding {
+		rest, done := f.discardUntilTerminator(data)
+		if !done {
+			return nil, nil
+		}
+		out.addChunk(fmt.Sprintf(inlineImageOmittedMarker, f.discarded, f.maxPayload))
+		f.discarded = 0
+		data = rest
+	}
+
+	if len(f.pending) > 0 {
+		rest, done := f.continuePending(data, out)
+		if !done {
+			out.flushText()
+			return out.chunks, out.replies
+		}
+		data = rest
+	}
+
+	f.scanText(data, out)
+	out.flushText()
+	return out.chunks, out.replies
+}
+
+// holding reports whether a sequence is held back or being skipped
+func (f *inlineImageFilter) holding() bool {
+	return len(f.pending) > 0 || f.discarding
+}
+
+// releaseStale gives up on a sequence that hasn't grown for inlineImageHoldTimeout: held bytes
+// go out as ordinary output and a skipped image ends with its marker. It returns nothing
+// while the sequence is still growing.
+func (f *inlineImageFilter) releaseStale(now time.Time) []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !f.holding() || now.Sub(f.heldAt) < inlineImageHoldTimeout {
+		return nil
+	}
+	if f.discarding {
+		f.discarding = false
+		f.lastByte = 0
+		output := fmt.Sprintf(inlineImageOmittedMarker, f.discarded, f.maxPayload)
+		f.discarded = 0
+		return []string{output}
+	}
+	output := string(f.pending)
+	f.pending = nil
+	return []string{output}
+}
+
+// continuePending feeds a read into the held-back sequence. It returns the unconsumed
+// remainder and whether the pending sequence was resolved.
+func (f *inlineImageFilter) continuePending(data string, out *filteredOutput) (string, bool) {
+	// Short sequences are simply rescanned together with the new data
+	if f.pendingKind == seqUnknown || !f.pendingKind.isImage() {
+		data = string(f.pending) + data
+		f.pending = nil
+		return data, true
+	}
+
+	// Image payloads can be megabytes; only search the new bytes for the terminator
+	end, aborted := findTerminator(data, f.pendingKind == seqITerm2)
+	if f.pending[len(f.pending)-1] == '\x1b' && len(data) > 0 {
+		end, aborted = 1, data[0] != '\\'
+		if aborted {
+			end = 0
+		}
+	}
+
+	if aborted {
+		// The renderer drops the sequence too; pass it on unchanged and let it do so
+		out.addText(string(f.pending) + data[:end])
+		f.pending = nil
+		return data[end:], true
+	}
+	if end < 0 {
+		if len(f.pending)+len(data) > f.maxPayload {
+			f.startDiscarding(f.pendingKind, len(f.pending), data)
+			f.pending = nil
+			return "", false
+		}
+		f.pending = append(f.pending, data...)
+		return "", false
+	}
+
+	seq := string(f.pending) + data[:end]
+	kind := f.pendingKind
+	f.pending = nil
+	f.handleSequence(seq, kind, out)
+	return data[end:], true
+}
+
+// scanText passes ordinary output through and handles any sequences found in it
+func (f *inlineImageFilter) scanText(s string, out *filteredOutput) {
+	start := 0
+	for i := 0; i < len(s); {
+		p := strings.IndexByte(s[i:], '\x1b')
+		if p < 0 {
+			break
+		}
+		p += i
+
+		kind, end, status := f.scan(s[p:])
+		switch status {
+		case scanNotOurs:
+			i = p + 1
+		case scanIncomplete:
+			out.addText(s[start:p])
+			f.hold(s[p:], kind, out)
+			return
+		case scanComplete:
+			out.addText(s[start:p])
+			f.handleSequence(s[p:p+end], kind, out)
+			start, i = p+end, p+end
+		}
+	}
+	out.addText(s[start:])
+}
+
+// hold keeps an incomplete sequence back for the next read, or gives up on it once it
+// outgrows its limit: oversized images are skipped, overlong queries become plain output
+func (f *inlineImageFilter) hold(seq string, kind seqKind, out *filteredOutput) {
+	switch {
+	case kind.isImage() && len(seq) > f.maxPayload:
+		out.flushText()
+		f.startDiscarding(kind, 0, seq)
+	case !kind.isImage() && len(seq) > maxQueryLength:
+		out.addText(seq)
+	default:
+		f.pending = []byte(seq)
+		f.pendingKind = kind
+	}
+}
+
+// startDiscarding begins skipping an oversized image; already counts bytes held before data
+func (f *inlineImageFilter) startDiscarding(kind seqKind, already int, data string) {
+	f.discarding = true
+	f.discardKind = kind
+	f.discarded = already + len(data)
+	if len(data) > 0 {
+		f.lastByte = data[len(data)-1]
+	}
+}
+
+// discardUntilTerminator skips image bytes up to the sequence terminator, or up to the byte
+// that aborts the sequence. It returns the output that follows and whether the end was found.
+func (f *inlineImageFilter) discardUntilTerminator(data string) (string, bool) {
+	end, _ := findTerminator(data, f.discardKind == seqITerm2)
+	if f.lastByte == '\x1b' && len(data) > 0 {
+		if data[0] != '\\' {
+			// The ESC skipped last starts the output that aborted the image
+			f.discarding = false
+			f.lastByte = 0
+			return "\x1b" + data, true
+		}
+		end = 1
+	}
+
+	if end < 0 {
+		f.discarded += len(data)
+		if len(data) > 0 {
+			f.lastByte = data[len(data)-1]
+		}
+		return "", false
+	}
+
+	f.discarded += end
+	f.discarding = false
+	f.lastByte = 0
+	return data[end:], true
+}
+
+// handleSequence forwards a complete image as one write, or answers a query
+func (f *inlineImageFilter) handleSequence(seq string, kind seqKind, out *filteredOutput) {
+	switch kind {
+	case seqSixel, seqITerm2:
+		f.forwardImage(seq, out)
+	case seqKitty:
+		if reply, isQuery := kittyQueryReply(seq); isQuery {
+			if reply != "" {
+				out.replies = append(out.replies, reply)
+			}
+			return
+		}
+		f.forwardImage(seq, out)
+	case seqDA1:
+		out.replies = append(out.replies, da1SixelReply)
+	case seqXTGETTCAP:
+		out.replies = append(out.replies, xtgettcapReply(seq))
+	case seqXTSMGRAPHICS:
+		out.replies = append(out.replies, xtsmgraphicsReply(seq))
+	default:
+		out.addText(seq)
+	}
+}
+
+// forwardImage sends an image sequence intact, or a marker in its place when it exceeds the cap.
+// A cut-off sequence would leave the renderer inside the sequence, so it is never sent partially.
+func (f *inlineImageFilter) forwardImage(seq string, out *filteredOutput) {
+	if len(seq) > f.maxPayload {
+		out.addChunk(fmt.Sprintf(inlineImageOmittedMarker, len(seq), f.maxPayload))
+		return
+	}
+	out.addChunk(seq)
+}
+
+// scan classifies the sequence starting at buf[0] (an ESC) and returns its length when complete
+func (f *inlineImageFilter) scan(buf string) (seqKind, int, scanStatus) {
+	if len(buf) < 2 {
+		return seqUnknown, 0, scanIncomplete
+	}
+
+	switch buf[1] {
+	case 'P':
+		return f.scanDCS(buf)
+	case ']':
+		return f.scanOSC(buf)
+	case '_':
+		return f.scanAPC(buf)
+	case '[':
+		return f.scanCSI(buf)
+	}
+	return seqUnknown, 0, scanNotOurs
+}
+
+// scanDCS recognizes sixel images (DCS Ps;Ps;Ps q) and XTGETTCAP queries (DCS + q)
+func (f *inlineImageFilter) scanDCS(buf string) (seqKind, int, scanStatus) {
+	i := 2
+	for i < len(buf) && (isDigit(buf[i]) || buf[i] == ';') {
+		i++
+	}
+	if i == len(buf) {
+		return seqUnknown, 0, scanIncomplete
+	}
+
+	kind := seqUnknown
+	switch {
+	case buf[i] == 'q' && f.protocols[InlineImageProtocolSixel]:
+		kind = seqSixel
+	case buf[i] == '+' && i == 2:
+		if i+1 == len(buf) {
+			return seqUnknown, 0, scanIncomplete
+		}
+		if buf[i+1] == 'q' {
+			kind = seqXTGETTCAP
+		}
+	}
+	if kind == seqUnknown {
+		return seqUnknown, 0, scanNotOurs
+	}
+	return scanToTerminator(buf, kind, i+1, false)
+}
+
+// scanOSC recognizes iTerm2 inline files (OSC 1337 ; ...)
+func (f *inlineImageFilter) scanOSC(buf string) (seqKind, int, scanStatus) {
+	if !f.protocols[InlineImageProtocolITerm2] {
+		return seqUnknown, 0, scanNotOurs
+	}
+	return scanPrefixed(buf, "\x1b]1337;", seqITerm2, true)
+}
+
+// scanAPC recognizes kitty graphics commands (APC G ...)
+func (f *inlineImageFilter) scanAPC(buf string) (seqKind, int, scanStatus) {
+	if !f.protocols[InlineImageProtocolKitty] {
+		return seqUnknown, 0, scanNotOurs
+	}
+	return scanPrefixed(buf, "\x1b_G", seqKitty, false)
+}
+
+// scanCSI recognizes DA1 (CSI c / CSI 0 c) and XTSMGRAPHICS (CSI ? Pi ; Pa ; Pv S) queries.
+// Both are only answered when sixel is negotiated; otherwise the renderer answers DA1 itself.
+func (f *inlineImageFilter) scanCSI(buf string) (seqKind, int, scanStatus) {
+	if !f.protocols[InlineImageProtocolSixel] {
+		return seqUnknown, 0, scanNotOurs
+	}
+	if len(buf) == 2 {
+		return seqUnknown, 0, scanIncomplete
+	}
+
+	if buf[2] == '?' {
+		i := 3
+		for i < len(buf) && (isDigit(buf[i]) || buf[i] == ';') {
+			i++
+		}
+		if i == len(buf) {
+			return seqUnknown, 0, scanIncomplete
+		}
+		if buf[i] == 'S' && i > 3 {
+			return seqXTSMGRAPHICS, i + 1, scanComplete
+		}
+		return seqUnknown, 0, scanNotOurs
+	}
+
+	i := 2
+	if buf[i] == '0' {
+		i++
+	}
+	if i == len(buf) {
+		return seqUnknown, 0, scanIncomplete
+	}
+	if buf[i] == 'c' {
+		return seqDA1, i + 1, scanComplete
+	}
+	return seqUnknown, 0, scanNotOurs
+}
+
+// scanPrefixed matches a fixed introducer, then looks for the terminator
+func scanPrefixed(buf, prefix string, kind seqKind, allowBEL bool) (seqKind, int, scanStatus) {
+	n := min(len(buf), len(prefix))
+	if buf[:n] != prefix[:n] {
+		return seqUnknown, 0, scanNotOurs
+	}
+	if len(buf) < len(prefix) {
+		return seqUnknown, 0, scanIncomplete
+	}
+	return scanToTerminator(buf, kind, len(prefix), allowBEL)
+}
+
+// scanToTerminator finds the end of a string-type sequence whose body starts at from. An
+// aborted sequence is left to the renderer as ordinary output.
+func scanToTerminator(buf string, kind seqKind, from int, allowBEL bool) (seqKind, int, scanStatus) {
+	idx, aborted := findTerminator(buf[from:], allowBEL)
+	switch {
+	case aborted:
+		return seqUnknown, 0, scanNotOurs
+	case idx >= 0:
+		return kind, from + idx, scanComplete
+	}
+	return kind, 0, scanIncomplete
+}
+
+// findTerminator returns the offset just past the first ST (ESC \), or BEL when allowed.
+// CAN, SUB and an ESC starting anything but ST abort a string sequence, as they do in the
+// renderer; aborted is then true and the offset is that of the aborting byte. It returns -1
+// when neither was found, including for an ESC at the very end.
+func findTerminator(s string, allowBEL bool) (end int, aborted bool) {
+	for i := 0; i < len(s); i++ {
+		next := strings.IndexAny(s[i:], "\a\x18\x1a\x1b")
+		if next < 0 {
+			break
+		}
+		i += next
+		switch s[i] {
+		case '\a':
+			if allowBEL {
+				return i + 1, false
+			}
+		case 0x18, 0x1a:
+			return i, true
+		case '\x1b':
+			if i+1 == len(s) {
+				return -1, false
+			}
+			if s[i+1] == '\\' {
+				return i + 2, false
+			}
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// isDigit reports whether b is an ASCII digit
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// xtgettcapReply answers each hex-encoded capability name in an XTGETTCAP query
+func xtgettcapReply(seq string) string {
+	body := strings.TrimSuffix(strings.TrimPrefix(seq, "\x1bP+q"), "\x1b\\")
+
+	var reply strings.Builder
+	for _, hexName := range strings.Split(body, ";") {
+		name, err := hex.DecodeString(hexName)
+		value, known := xtgettcapValues[string(name)]
+		if err != nil || !known {
+			reply.WriteString("\x1bP0+r" + hexName + "\x1b\\")
+			continue
+		}
+		reply.WriteString("\x1bP1+r" + hexName + "=" + hex.EncodeToString([]byte(value)) + "\x1b\\")
+	}
+	return reply.String()
+}
+
+// xtsmgraphicsReply answers a graphics attribute query. Only the color register count is known
+// to the backend; sixel geometry depends on the renderer's cell size, so that request reports failure.
+func xtsmgraphicsReply(seq string) string {
+	params := strings.Split(strings.TrimSuffix(strings.TrimPrefix(seq, "\x1b[?"), "S"), ";")
+	item := params[0]
+
+	switch item {
+	case "1":
+		return "\x1b[?1;0;" + strconv.Itoa(sixelColorRegisters) + "S"
+	case "2":
+		return "\x1b[?2;3;0S"
+	default:
+		return "\x1b[?" + item + ";1;0S"
+	}
+}
+
+// kittyQueryReply reports whether a kitty graphics command is a support query (a=q) and
+// returns the reply. Kitty only replies to queries that carry an image id.
+func kittyQueryReply(seq string) (string, bool) {
+	control := strings.TrimPrefix(seq, "\x1b_G")
+	if idx := strings.IndexAny(control, ";\x1b"); idx >= 0 {
+		control = control[:idx]
+	}
+
+	keys := make(map[string]string)
+	for _, pair := range strings.Split(control, ",") {
+		if key, value, found := strings.Cut(pair, "="); found {
+			keys[key] = value
+		}
+	}
+
+	if keys["a"] != "q" {
+		return "", false
+	}
+	if keys["i"] == "" {
+		return "", true
+	}
+	return "\x1b_Gi=" + keys["i"] + ";OK\x1b\\", true
+}
+
+// getInlineImageMaxBytes returns the inline image payload cap with the default applied
+func (a *App) getInlineImageMaxBytes() int {
+	maxBytes := 0
+	if a.config != nil {
+		a.config.mutex.RLock()
+		if a.config.config != nil {
+			maxBytes = a.config.config.InlineImageMaxBytes
+		}
+		a.config.mutex.RUnlock()
+	}
+	return inlineImageMaxBytesWithDefaults(maxBytes)
+}
+
+// inlineImageMaxBytesWithDefaults applies the default payload cap when unset. For callers
+// that already hold config.mutex.
+func inlineImageMaxBytesWithDefaults(maxBytes int) int {
+	if maxBytes == 0 {
+		return DefaultInlineImageMaxBytes
+	}
+	return maxBytes
+}
+
+// SetInlineImageProtocols records which inline image protocols the frontend renderer can draw.
+// It applies to sessions started afterwards.
+func (a *App) SetInlineImageProtocols(protocols []string) error {
+	accepted := make([]string, 0, len(protocols))
+	for _, protocol := range protocols {
+		supported := false
+		for _, known := range SupportedInlineImageProtocols {
+			if protocol == known {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return fmt.Errorf("unsupported inline image protocol: %s", protocol)
+		}
+		accepted = append(accepted, protocol)
+	}
+
+	a.terminal.imageMutex.Lock()
+	a.terminal.imageProtocols = accepted
+	a.terminal.imageMutex.Unlock()
+	return nil
+}
+
+// negotiateInlineImageProtocols returns the protocols enabled for a tab: whatever the
+// renderer supports, unless the tab's profile turns inline images off
+func (a *App) negotiateInlineImageProtocols(tab *Tab) []string {
+	if tab.ProfileID != "" {
+		if profile, err := a.GetProfile(tab.ProfileID); err == nil && profile.DisableInlineImages {
+			return []string{}
+		}
+	}
+
+	a.terminal.imageMutex.Lock()
+	defer a.terminal.imageMutex.Unlock()
+	return append([]string{}, a.terminal.imageProtocols...)
+}
+
+// setupInlineImages installs the output filter for a tab's session and advertises the
+// negotiated protocols to the frontend
+func (a *App) setupInlineImages(tab *Tab) {
+	protocols := a.negotiateInlineImageProtocols(tab)
+
+	a.terminal.imageMutex.Lock()
+	if len(protocols) == 0 {
+		delete(a.terminal.imageFilters, tab.SessionID)
+	} else {
+		a.terminal.imageFilters[tab.SessionID] = newInlineImageFilter(protocols, a.getInlineImageMaxBytes())
+	}
+	a.terminal.imageMutex.Unlock()
+
+	if a.ctx == nil {
+		return
+	}
+	wailsRuntime.EventsEmit(a.ctx, InlineImagesEvent, map[string]interface{}{
+		"sessionId": tab.SessionID,
+		"protocols": protocols,
+	})
+}
+
+// releaseInlineImageFilter drops a session's output filter
+func (a *App) releaseInlineImageFilter(sessionID string) {
+	a.terminal.imageMutex.Lock()
+	filter := a.terminal.imageFilters[sessionID]
+	delete(a.terminal.imageFilters, sessionID)
+	a.terminal.imageMutex.Unlock()
+
+	if filter != nil {
+		filter.outputMutex.Lock()
+		if filter.releaseTimer != nil {
+			filter.releaseTimer.Stop()
+		}
+		filter.outputMutex.Unlock()
+	}
+}
+
+// emitSessionOutput sends output read from a session's PTY or SSH channel to the frontend,
+// through the inline image filter when one is installed
+func (a *App) emitSessionOutput(sessionID, data string) {
+	recordEnvironmentCapture(sessionID, data)
+	recordShellCapture(sessionID, data)
+	recordSecretPromptOutput(sessionID, data)
+	recordScrollbackOutput(sessionID, data)
+	if recordShellIntegration(sessionID, data) {
+		// A title format may show {cwd} or {exit-code}
+		a.scheduleTabTitleRefresh(sessionID)
+	}
+	// Boundaries and the bell go out after the output they were found in
+	defer emitCommandBoundaries(appEmitter{a}, sessionID, takeCommandBoundaries(sessionID))
+	if a.recordTerminalBell(sessionID, data) {
+		defer a.emitTerminalBell(appEmitter{a}, sessionID, BellKindBell, 0)
+	}
+
+	a.terminal.imageMutex.Lock()
+	filter := a.terminal.imageFilters[sessionID]
+	a.terminal.imageMutex.Unlock()
+
+	if filter == nil {
+		a.emitTerminalOutput(sessionID, data)
+		return
+	}
+
+	filter.outputMutex.Lock()
+	output, replies := filter.process(data)
+	for _, chunk := range output {
+		a.emitTerminalOutput(sessionID, chunk)
+	}
+	if filter.holding() {
+		a.scheduleInlineImageRelease(sessionID, filter)
+	}
+	filter.outputMutex.Unlock()
+
+	for _, reply := range replies {
+		if err := a.WriteToShell(sessionID, reply); err != nil {
+			fmt.Printf("Failed to answer terminal query for session %s: %v\n", sessionID, err)
+		}
+	}
+}
+
+// scheduleInlineImageRelease (re)starts the timer that releases a held-back sequence once it
+// stops growing. Called with filter.outputMutex held.
+func (a *App) scheduleInlineImageRelease(sessionID string, filter *inlineImageFilter) {
+	if filter.releaseTimer != nil {
+		filter.releaseTimer.Reset(inlineImageHoldTimeout)
+		return
+	}
+	filter.releaseTimer = time.AfterFunc(inlineImageHoldTimeout, func() {
+		filter.outputMutex.Lock()
+		defer filter.outputMutex.Unlock()
+
+		// The session may have ended while the timer was pending
+		a.terminal.imageMutex.Lock()
+		current := a.terminal.imageFilters[sessionID] == filter
+		a.terminal.imageMutex.Unlock()
+		if !current {
+			return
+		}
+		for _, chunk := range filter.releaseStale(time.Now()) {
+			a.emitTerminalOutput(sessionID, chunk)
+		}
+	})
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// imgcatPNG is a 2x2 PNG as imgcat writes it outside tmux: OSC 1337 File=..., BEL-terminated
+const imgcatPNG = "\x1b]1337;File=name=ZG90LnBuZw==;inline=1;size=75:" +
+	"iVBORw0KGgoAAAANSUhEUgAAAAIAAAACCAIAAAD91JpzAAAAEklEQVR4nGP4z8DAAMIM/0EAACboBvoSQF/MAAAAAElFTkSuQmCC" +
+	"\a"
+
+// chafaSixel is a small image in the form chafa -f sixels writes it: DCS P1;P2;P3 q,
+// raster attributes, color registers, sixel data, ST
+const chafaSixel = "\x1bP0;1;0q\"1;1;8;12" +
+	"#0;2;0;0;0#1;2;100;25;0#2;2;0;50;100" +
+	"#1!8~$#2!4?!4~-#0!8~$#1~~@@vv@@-" +
+	"\x1b\\"
+
+// feedInChunks runs data through the filter split into reads of chunkSize bytes
+func feedInChunks(f *inlineImageFilter, data string, chunkSize int) (output []string, replies []string) {
+	for start := 0; start < len(data); start += chunkSize {
+		end := min(start+chunkSize, len(data))
+		out, rep := f.process(data[start:end])
+		output = append(output, out...)
+		replies = append(replies, rep...)
+	}
+	return output, replies
+}
+
+func containsChunk(chunks []string, want string) bool {
+	for _, chunk := range chunks {
+		if chunk == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestInlineImageSequencesArriveByteIdentical(t *testing.T) {
+	largeImgcat := "\x1b]1337;File=inline=1;size=300000:" + strings.Repeat("iVBORw0KGgoAAAANSUhEUgAA", 12500) + "\a"
+
+	fixtures := map[string]string{
+		"imgcat":       imgcatPNG,
+		"chafa":        chafaSixel,
+		"imgcat-large": largeImgcat,
+	}
+	protocols := []string{InlineImageProtocolSixel, InlineImageProtocolITerm2}
+
+	for name, image := range fixtures {
+		stream := "$ show image\r\n" + image + "\r\n$ "
+		for _, chunkSize := range []int{1, 3, 7, 64, 4096} {
+			f := newInlineImageFilter(protocols, DefaultInlineImageMaxBytes)
+			output, replies := feedInChunks(f, stream, chunkSize)
+
+			if got := strings.Join(output, ""); got != stream {
+				t.Fatalf("%s/%d: stream altered", name, chunkSize)
+			}
+			if !containsChunk(output, image) {
+				t.Fatalf("%s/%d: image was not delivered as a single write", name, chunkSize)
+			}
+			if len(replies) != 0 {
+				t.Fatalf("%s/%d: unexpected replies %q", name, chunkSize, replies)
+			}
+		}
+	}
+}
+
+func TestInlineImageDeliveredToFrontend(t *testing.T) {
+	app := NewApp()
+	rec := &outputRecorder{}
+	app.privacy.emit = rec.emit
+
+	if err := app.SetInlineImageProtocols([]string{InlineImageProtocolSixel}); err != nil {
+		t.Fatalf("SetInlineImageProtocols() returned error: %v", err)
+	}
+	app.setupInlineImages(&Tab{ID: "tab_img", SessionID: "s1"})
+	defer app.ReleaseSession("s1")
+
+	// Split mid-payload the way a 4KB PTY read would
+	half := len(chafaSixel) / 2
+	app.emitSessionOutput("s1", "before"+chafaSixel[:half])
+	app.emitSessionOutput("s1", chafaSixel[half:]+"after")
+
+	assertOutput(t, rec.take(), "s1:before", "s1:"+chafaSixel, "s1:after")
+}
+
+func TestInlineImageOversizedPayloadReplacedWithMarker(t *testing.T) {
+	image := "\x1b]1337;File=inline=1:" + strings.Repeat("A", 500) + "\a"
+	stream := "before" + image + "after"
+	marker := fmt.Sprintf(inlineImageOmittedMarker, len(image), 100)
+
+	for _, chunkSize := range []int{1, 50, 4096} {
+		f := newInlineImageFilter([]string{InlineImageProtocolITerm2}, 100)
+		output, _ := feedInChunks(f, stream, chunkSize)
+
+		if got, want := strings.Join(output, ""), "before"+marker+"after"; got != want {
+			t.Fatalf("chunk %d: got %q, want %q", chunkSize, got, want)
+		}
+	}
+}
+
+func TestInlineImageAbortedSequencePassesThrough(t *testing.T) {
+	// CAN, SUB or another escape sequence end an image the program never terminated
+	for _, abort := range []string{"\x18", "\x1a", "\x1b[0m"} {
+		stream := "before\x1b]1337;File=inline=1:" + strings.Repeat("A", 40) + abort + "$ prompt"
+		for _, chunkSize := range []int{1, 3, 4096} {
+			f := newInlineImageFilter([]string{InlineImageProtocolITerm2}, DefaultInlineImageMaxBytes)
+			output, _ := feedInChunks(f, stream, chunkSize)
+			if got := strings.Join(output, ""); got != stream {
+				t.Errorf("abort %q, chunk %d: got %q, want the stream unchanged", abort, chunkSize, got)
+			}
+			if f.holding() {
+				t.Errorf("abort %q, chunk %d: output still held back", abort, chunkSize)
+			}
+		}
+
+		// An oversized image being skipped ends at the abort too
+		for _, chunkSize := range []int{1, 4096} {
+			f := newInlineImageFilter([]string{InlineImageProtocolITerm2}, 10)
+			output, _ := feedInChunks(f, stream, chunkSize)
+			if got := strings.Join(output, ""); !strings.HasSuffix(got, abort+"$ prompt") {
+				t.Errorf("abort %q, chunk %d, oversized: got %q, want the output after the abort", abort, chunkSize, got)
+			}
+		}
+	}
+}
+
+func TestInlineImageStaleSequenceReleased(t *testing.T) {
+	originalTimeout := inlineImageHoldTimeout
+	inlineImageHoldTimeout = 20 * time.Millisecond
+	defer func() { inlineImageHoldTimeout = originalTimeout }()
+
+	app := NewApp()
+	rec := &outputRecorder{}
+	app.privacy.emit = rec.emit
+	if err := app.SetInlineImageProtocols([]string{InlineImageProtocolITerm2}); err != nil {
+		t.Fatal(err)
+	}
+	app.setupInlineImages(&Tab{ID: "tab_img", SessionID: "s1"})
+	defer app.ReleaseSession("s1")
+
+	// Never terminated, and followed by plain output only
+	unterminated := "\x1b]1337;File=inline=1:AAAA"
+	app.emitSessionOutput("s1", "before"+unterminated)
+	app.emitSessionOutput("s1", "plain text")
+
+	var got []string
+	for deadline := time.Now().Add(2 * time.Second); len(got) < 2 && time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		got = append(got, rec.take()...)
+	}
+	assertOutput(t, got, "s1:before", "s1:"+unterminated+"plain text")
+}
+
+func TestInlineImageDisabledProtocolPassesThrough(t *testing.T) {
+	f := newInlineImageFilter([]string{InlineImageProtocolITerm2}, DefaultInlineImageMaxBytes)
+	stream := "x" + chafaSixel + "\x1b[c" + "y"
+
+	output, replies := feedInChunks(f, stream, 5)
+	if got := strings.Join(output, ""); got != stream {
+		t.Fatalf("stream altered: %q", got)
+	}
+	if len(replies) != 0 {
+		t.Fatalf("DA1 should be left to the renderer without sixel, got replies %q", replies)
+	}
+}
+
+func TestInlineImageAnswersQueries(t *testing.T) {
+	f := newInlineImageFilter([]string{InlineImageProtocolSixel, InlineImageProtocolKitty}, DefaultInlineImageMaxBytes)
+
+	// DA1, XTGETTCAP for TN and an unknown cap, XTSMGRAPHICS color registers, kitty support query
+	stream := "a\x1b[c" + "b\x1bP+q544e;787878\x1b\\" + "c\x1b[?1;1;0S" + "d\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\" + "e\x1b[?25h"
+
+	output, replies := feedInChunks(f, stream, 2)
+	if got, want := strings.Join(output, ""), "abcde\x1b[?25h"; got != want {
+		t.Fatalf("queries should be consumed, got %q want %q", got, want)
+	}
+
+	want := []string{
+		da1SixelReply,
+		"\x1bP1+r544e=787465726d2d323536636f6c6f72\x1b\\\x1bP0+r787878\x1b\\",
+		"\x1b[?1;0;256S",
+		"\x1b_Gi=31;OK\x1b\\",
+	}
+	if len(replies) != len(want) {
+		t.Fatalf("got %d replies %q, want %d", len(replies), replies, len(want))
+	}
+	for i := range want {
+		if replies[i] != want[i] {
+			t.Fatalf("reply %d = %q, want %q", i, replies[i], want[i])
+		}
+	}
+}
+
+func TestInlineImagesDisabledPerProfile(t *testing.T) {
+	app := NewApp()
+	app.SetInlineImageProtocols([]string{InlineImageProtocolSixel})
+
+	app.profiles.mutex.Lock()
+	app.profiles.profiles["p1"] = &Profile{ID: "p1", Name: "no images", Type: ProfileTypeLocal, DisableInlineImages: true}
+	app.profiles.mutex.Unlock()
+
+	app.setupInlineImages(&Tab{ID: "t1", SessionID: "s1", ProfileID: "p1"})
+	app.setupInlineImages(&Tab{ID: "t2", SessionID: "s2"})
+
+	app.terminal.imageMutex.Lock()
+	_, disabledHasFilter := app.terminal.imageFilters["s1"]
+	_, defaultHasFilter := app.terminal.imageFilters["s2"]
+	app.terminal.imageMutex.Unlock()
+
+	if disabledHasFilter {
+		t.Fatal("profile with inline images disabled got an output filter")
+	}
+	if !defaultHasFilter {
+		t.Fatal("profile without the toggle should get an output filter")
+	}
+
+	if err := app.SetInlineImageProtocols([]string{"ascii-art"}); err == nil {
+		t.Fatal("unknown protocol should be rejected")
+	}
+}
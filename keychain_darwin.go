@@ -0,0 +1,20 @@
+//go:build darwin
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+)
+
+// readKeychainSecret reads a generic password stored under service KeychainService and
+// account name from the login keychain
+func readKeychainSecret(name string) ([]byte, error) {
+	cmd := exec.Command("security", "find-generic-password", "-s", KeychainService, "-a", name, "-w")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("keychain item %s/%s not found: %w", KeychainService, name, err)
+	}
+	return bytes.TrimSuffix(output, []byte("\n")), nil
+}
@@ -0,0 +1,22 @@
+//go:build !windows && !darwin
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// readKeychainSecret reads a secret stored in the Secret Service (GNOME Keyring, KWallet)
+// with attributes service=KeychainService and account=name, using secret-tool
+func readKeychainSecret(name string) ([]byte, error) {
+	path, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return nil, fmt.Errorf("secret-tool not found; install libsecret-tools to use keychain secrets")
+	}
+	output, err := exec.Command(path, "lookup", "service", KeychainService, "account", name).Output()
+	if err != nil || len(output) == 0 {
+		return nil, fmt.Errorf("keychain item %s/%s not found", KeychainService, name)
+	}
+	return output, nil
+}
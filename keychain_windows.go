@@ -0,0 +1,51 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	procCredReadW = syscall.NewLazyDLL("advapi32.dll").NewProc("CredReadW")
+	procCredFree  = syscall.NewLazyDLL("advapi32.dll").NewProc("CredFree")
+)
+
+const credTypeGeneric = 1
+
+// winCredential mirrors the CREDENTIALW structure
+type winCredential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// readKeychainSecret reads the generic credential "KeychainService:name" from Windows Credential Manager
+func readKeychainSecret(name string) ([]byte, error) {
+	target, err := syscall.UTF16PtrFromString(KeychainService + ":" + name)
+	if err != nil {
+		return nil, err
+	}
+
+	var cred *winCredential
+	ret, _, callErr := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ret == 0 {
+		return nil, fmt.Errorf("credential %s:%s not found: %w", KeychainService, name, callErr)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	secret := make([]byte, cred.CredentialBlobSize)
+	copy(secret, unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize))
+	return secret, nil
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Maintenance window constants
+const (
+	MaintenanceBannerEvent      = "maintenance-banner"
+	MaintenanceWindowsFileName  = "maintenance-windows.json"
+	MaxMaintenanceDurationMins  = 24 * 60
+	MaxMaintenanceLabelLength   = 200
+	MaxMaintenanceMessageLength = 1000
+	MaxActiveMaintenanceWindows = 20
+	maintenanceWindowTimeFormat = "15:04"
+	maintenanceWindowIDPrefix   = "maint_"
+)
+
+// MaintenanceWindow flags the tabs of some profiles, or carrying a tag, for a limited time
+type MaintenanceWindow struct {
+	ID         string    `json:"id"`
+	Label      string    `json:"label"`
+	Message    string    `json:"message,omitempty"`    // Written into matching terminals when they are flagged
+	ProfileIDs []string  `json:"profileIds,omitempty"` // The requested profiles, or the profiles carrying Tag when started
+	Tag        string    `json:"tag,omitempty"`
+	Hosts      []string  `json:"hosts,omitempty"` // Lower-case SSH hosts of ProfileIDs, for tabs not opened through a profile
+	Started    time.Time `json:"started"`
+	Ends       time.Time `json:"ends"`
+}
+
+// MaintenanceBanner is what a flagged tab shows, sent with maintenance-banner events
+type MaintenanceBanner struct {
+	WindowID string    `json:"windowId"`
+	Label    string    `json:"label"`
+	Ends     time.Time `json:"ends"`
+}
+
+// MaintenanceBannerUpdate is the payload of maintenance-banner events. Banner is nil once the
+// tab is no longer in a maintenance window.
+type MaintenanceBannerUpdate struct {
+	TabID     string             `json:"tabId"`
+	SessionID string             `json:"sessionId"`
+	Banner    *MaintenanceBanner `json:"banner"`
+}
+
+// Active maintenance windows by ID, with the timers that end them
+var (
+	maintenanceWindows      = make(map[string]*MaintenanceWindow)
+	maintenanceWindowTimers = make(map[string]*time.Timer)
+	maintenanceWindowsMu    sync.Mutex
+)
+
+// banner returns what tabs flagged by the window show
+func (w *MaintenanceWindow) banner() *MaintenanceBanner {
+	return &MaintenanceBanner{WindowID: w.ID, Label: w.Label, Ends: w.Ends}
+}
+
+// matches reports whether a tab is affected: it was opened from one of the window's profiles,
+// carries its tag, or is an SSH tab connected to one of its profiles' hosts
+func (w *MaintenanceWindow) matches(tab *Tab, tags []string) bool {
+	for _, profileID := range w.ProfileIDs {
+		if tab.ProfileID != "" && tab.ProfileID == profileID {
+			return true
+		}
+	}
+	if w.Tag != "" {
+		for _, tag := range tags {
+			if strings.EqualFold(tag, w.Tag) {
+				return true
+			}
+		}
+	}
+	if tab.ConnectionType == ConnectionTypeSSH && tab.SSHConfig != nil {
+		host := strings.TrimSuffix(strings.ToLower(tab.SSHConfig.Host), ".")
+		for _, h := range w.Hosts {
+			if h == host {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// StartMaintenanceWindow flags the open tabs of the given profiles, or carrying tag, with a
+// banner for durationMinutes; tabs of those profiles opened meanwhile are flagged as their shell
+// starts. A non-empty message is written into each flagged terminal as a distinct line.
+// The window ends by itself, or with EndMaintenanceWindow.
+func (a *App) StartMaintenanceWindow(profileIDs []string, tag, label string, durationMinutes int, message string) (*MaintenanceWindow, error) {
+	label = strings.TrimSpace(label)
+	tag = strings.TrimSpace(tag)
+	message = strings.TrimSpace(message)
+	if label == "" {
+		return nil, fmt.Errorf("maintenance window label cannot be empty")
+	}
+	if len(label) > MaxMaintenanceLabelLength {
+		return nil, fmt.Errorf("maintenance window label too long: %d characters, maximum allowed: %d", len(label), MaxMaintenanceLabelLength)
+	}
+	if len(message) > MaxMaintenanceMessageLength {
+		return nil, fmt.Errorf("maintenance window message too long: %d characters, maximum allowed: %d", len(message), MaxMaintenanceMessageLength)
+	}
+	if durationMinutes <= 0 || durationMinutes > MaxMaintenanceDurationMins {
+		return nil, fmt.Errorf("duration must be between 1 and %d minutes, got: %d", MaxMaintenanceDurationMins, durationMinutes)
+	}
+	if len(profileIDs) == 0 && tag == "" {
+		return nil, fmt.Errorf("maintenance window needs profile IDs or a tag")
+	}
+
+	window := &MaintenanceWindow{
+		ID:      fmt.Sprintf("%s%d", maintenanceWindowIDPrefix, time.Now().UnixNano()),
+		Label:   label,
+		Message: message,
+		Tag:     tag,
+		Started: time.Now(),
+	}
+	window.Ends = window.Started.Add(time.Duration(durationMinutes) * time.Minute)
+
+	// Resolve the profiles up front so the hosts of their tabs are known
+	requested := make(map[string]bool, len(profileIDs))
+	for _, profileID := range profileIDs {
+		if _, exists := a.profiles.Profile(profileID); !exists {
+			return nil, newNotFoundError(ErrCategoryProfile, "StartMaintenanceWindow", "profile not found: %s", profileID)
+		}
+		requested[profileID] = true
+	}
+	hosts := make(map[string]bool)
+	for _, profile := range a.profiles.Profiles() {
+		if !requested[profile.ID] && (tag == "" || !profileHasTag(profile, tag)) {
+			continue
+		}
+		window.ProfileIDs = append(window.ProfileIDs, profile.ID)
+		if profile.SSHConfig != nil && profile.SSHConfig.Host != "" {
+			hosts[strings.TrimSuffix(strings.ToLower(profile.SSHConfig.Host), ".")] = true
+		}
+	}
+	sort.Strings(window.ProfileIDs)
+	for host := range hosts {
+		window.Hosts = append(window.Hosts, host)
+	}
+	sort.Strings(window.Hosts)
+
+	maintenanceWindowsMu.Lock()
+	if len(maintenanceWindows) >= MaxActiveMaintenanceWindows {
+		maintenanceWindowsMu.Unlock()
+		return nil, fmt.Errorf("maximum number of maintenance windows (%d) reached", MaxActiveMaintenanceWindows)
+	}
+	maintenanceWindows[window.ID] = window
+	a.scheduleMaintenanceWindowEndLocked(window)
+	a.saveMaintenanceWindowsLocked()
+	maintenanceWindowsMu.Unlock()
+
+	flagged := 0
+	for _, tabID := range a.maintenanceTabIDs(nil) {
+		if a.applyMaintenanceWindows(tabID) {
+			flagged++
+		}
+	}
+	fmt.Printf("Maintenance window %s (%s) started until %s, %d open tabs flagged\n", window.ID, label, window.Ends.Format(time.RFC3339), flagged)
+
+	windowCopy := *window
+	return &windowCopy, nil
+}
+
+// EndMaintenanceWindow ends a maintenance window early and clears its banners
+func (a *App) EndMaintenanceWindow(windowID string) error {
+	maintenanceWindowsMu.Lock()
+	_, exists := maintenanceWindows[windowID]
+	maintenanceWindowsMu.Unlock()
+	if !exists {
+		return newNotFoundError(ErrCategoryTerminal, "EndMaintenanceWindow", "maintenance window %s not found", windowID)
+	}
+	a.endMaintenanceWindow(windowID)
+	return nil
+}
+
+// GetMaintenanceWindows returns the active maintenance windows, ending soonest first
+func (a *App) GetMaintenanceWindows() []MaintenanceWindow {
+	maintenanceWindowsMu.Lock()
+	defer maintenanceWindowsMu.Unlock()
+
+	windows := make([]MaintenanceWindow, 0, len(maintenanceWindows))
+	for _, window := range maintenanceWindows {
+		windows = append(windows, *window)
+	}
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].Ends.Before(windows[j].Ends)
+	})
+	return windows
+}
+
+// endMaintenanceWindow forgets a window and moves its tabs to another matching window, or
+// clears their banner
+func (a *App) endMaintenanceWindow(windowID string) {
+	maintenanceWindowsMu.Lock()
+	window, exists := maintenanceWindows[windowID]
+	if !exists {
+		maintenanceWindowsMu.Unlock()
+		return
+	}
+	delete(maintenanceWindows, windowID)
+	if timer, exists := maintenanceWindowTimers[windowID]; exists {
+		timer.Stop()
+		delete(maintenanceWindowTimers, windowID)
+	}
+	a.saveMaintenanceWindowsLocked()
+	maintenanceWindowsMu.Unlock()
+
+	for _, tabID := range a.maintenanceTabIDs(func(tab *Tab) bool {
+		return tab.Maintenance != nil && tab.Maintenance.WindowID == windowID
+	}) {
+		a.applyMaintenanceWindows(tabID)
+	}
+	fmt.Printf("Maintenance window %s (%s) ended\n", windowID, window.Label)
+}
+
+// maintenanceTabIDs returns the IDs of the open tabs accepted by filter, or of all tabs
+func (a *App) maintenanceTabIDs(filter func(tab *Tab) bool) []string {
+	a.terminal.mutex.RLock()
+	defer a.terminal.mutex.RUnlock()
+
+	tabIDs := make([]string, 0, len(a.terminal.tabs))
+	for tabID, tab := range a.terminal.tabs {
+		if filter == nil || filter(tab) {
+			tabIDs = append(tabIDs, tabID)
+		}
+	}
+	return tabIDs
+}
+
+// applyMaintenanceWindows sets a tab's banner to the matching window that ends last, or clears
+// it, announcing changes. A tab newly flagged by a window gets the window's message written
+// into its terminal. Reports whether the tab is flagged.
+func (a *App) applyMaintenanceWindows(tabID string) bool {
+	a.terminal.mutex.RLock()
+	tab, exists := a.terminal.tabs[tabID]
+	if !exists {
+		a.terminal.mutex.RUnlock()
+		return false
+	}
+	tabCopy := *tab
+	tags := append([]string(nil), a.terminal.sessionTags[tabID]...)
+	a.terminal.mutex.RUnlock()
+
+	now := time.Now()
+	var match *MaintenanceWindow
+	maintenanceWindowsMu.Lock()
+	for _, window := range maintenanceWindows {
+		if now.Before(window.Ends) && window.matches(&tabCopy, tags) && (match == nil || window.Ends.After(match.Ends)) {
+			windowCopy := *window
+			match = &windowCopy
+		}
+	}
+	maintenanceWindowsMu.Unlock()
+
+	a.terminal.mutex.Lock()
+	previous := tab.Maintenance
+	var banner *MaintenanceBanner
+	if match != nil {
+		banner = match.banner()
+	}
+	tab.Maintenance = banner
+	sessionID := tab.SessionID
+	a.terminal.mutex.Unlock()
+
+	if previous == nil && banner == nil || previous != nil && banner != nil && previous.WindowID == banner.WindowID {
+		return banner != nil
+	}
+	appEmitter{a}.Emit(MaintenanceBannerEvent, MaintenanceBannerUpdate{TabID: tabID, SessionID: sessionID, Banner: banner})
+	if match != nil && match.Message != "" && a.messages != nil {
+		line := fmt.Sprintf("Maintenance: %s until %s - %s", match.Label, match.Ends.Local().Format(maintenanceWindowTimeFormat), match.Message)
+		a.messages.EmitMessage(sessionID, line, MessageBroadcast)
+	}
+	return banner != nil
+}
+
+// scheduleMaintenanceWindowEndLocked starts the timer that ends a window. The caller must
+// hold maintenanceWindowsMu.
+func (a *App) scheduleMaintenanceWindowEndLocked(window *MaintenanceWindow) {
+	windowID := window.ID
+	maintenanceWindowTimers[windowID] = time.AfterFunc(time.Until(window.Ends), func() {
+		a.endMaintenanceWindow(windowID)
+	})
+}
+
+// getMaintenanceWindowsPath returns the file active maintenance windows are kept in
+func (a *App) getMaintenanceWindowsPath() (string, error) {
+	configPath, err := a.getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), MaintenanceWindowsFileName), nil
+}
+
+// saveMaintenanceWindowsLocked writes the active windows so a restart mid-window restores
+// them. The caller must hold maintenanceWindowsMu.
+func (a *App) saveMaintenanceWindowsLocked() {
+	windowsPath, err := a.getMaintenanceWindowsPath()
+	if err != nil {
+		return
+	}
+	if len(maintenanceWindows) == 0 {
+		if err := os.Remove(windowsPath); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Warning: Failed to remove maintenance windows file: %v\n", err)
+		}
+		return
+	}
+	if err := a.ensureConfigDir(); err != nil {
+		return
+	}
+
+	windows := make([]*MaintenanceWindow, 0, len(maintenanceWindows))
+	for _, window := range maintenanceWindows {
+		windows = append(windows, window)
+	}
+	data, err := json.MarshalIndent(windows, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(windowsPath, data, 0600); err != nil {
+		fmt.Printf("Warning: Failed to save maintenance windows: %v\n", err)
+	}
+}
+
+// restoreMaintenanceWindows reloads the windows that were active when the app last ran and
+// have not ended since
+func (a *App) restoreMaintenanceWindows() {
+	windowsPath, err := a.getMaintenanceWindowsPath()
+	if err != nil {
+		return
+	}
+	data, err := os.ReadFile(windowsPath)
+	if err != nil {
+		return
+	}
+
+	var windows []*MaintenanceWindow
+	if err := json.Unmarshal(data, &windows); err != nil {
+		fmt.Printf("Warning: Failed to read maintenance windows: %v\n", err)
+		return
+	}
+
+	now := time.Now()
+	maintenanceWindowsMu.Lock()
+	defer maintenanceWindowsMu.Unlock()
+	for _, window := range windows {
+		if window == nil || window.ID == "" || !now.Before(window.Ends) || len(maintenanceWindows) >= MaxActiveMaintenanceWindows {
+			continue
+		}
+		if _, exists := maintenanceWindows[window.ID]; exists {
+			continue
+		}
+		maintenanceWindows[window.ID] = window
+		a.scheduleMaintenanceWindowEndLocked(window)
+		fmt.Printf("Maintenance window %s (%s) restored until %s\n", window.ID, window.Label, window.Ends.Format(time.RFC3339))
+	}
+	a.saveMaintenanceWindowsLocked()
+}
+
+// profileHasTag reports whether a profile carries a tag, ignoring case
+func profileHasTag(profile *Profile, tag string) bool {
+	for _, t := range profile.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
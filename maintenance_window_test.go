@@ -0,0 +1,197 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newMaintenanceApp creates an app with its config dir in a temp dir and clears the
+// package-level windows afterwards
+func newMaintenanceApp(t *testing.T) (*App, *outputRecorder) {
+	t.Helper()
+	configHome := t.TempDir()
+	t.Setenv("HOME", configHome)
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("AppData", configHome)
+	t.Cleanup(func() {
+		for _, window := range (&App{}).GetMaintenanceWindows() {
+			dropMaintenanceWindow(window.ID)
+		}
+	})
+
+	app := NewApp()
+	output := &outputRecorder{}
+	app.privacy.emit = output.emit
+	app.profiles.profiles["web"] = &Profile{ID: "web", Name: "Web", Type: "ssh", Tags: []string{"Deploy"},
+		SSHConfig: &SSHConfig{Host: "web.internal", Port: 22, Username: "ops"}}
+	app.profiles.profiles["db"] = &Profile{ID: "db", Name: "DB", Type: "ssh",
+		SSHConfig: &SSHConfig{Host: "db.internal", Port: 22, Username: "ops"}}
+	app.terminal.tabs["tab_web"] = &Tab{ID: "tab_web", SessionID: "session_web", ConnectionType: ConnectionTypeSSH, ProfileID: "web",
+		SSHConfig: &SSHConfig{Host: "web.internal"}}
+	app.terminal.tabs["tab_db"] = &Tab{ID: "tab_db", SessionID: "session_db", ConnectionType: ConnectionTypeSSH, ProfileID: "db",
+		SSHConfig: &SSHConfig{Host: "db.internal"}}
+	return app, output
+}
+
+// dropMaintenanceWindow forgets a window without touching any tabs or files, as a restart would
+func dropMaintenanceWindow(windowID string) {
+	maintenanceWindowsMu.Lock()
+	defer maintenanceWindowsMu.Unlock()
+	if timer, exists := maintenanceWindowTimers[windowID]; exists {
+		timer.Stop()
+		delete(maintenanceWindowTimers, windowID)
+	}
+	delete(maintenanceWindows, windowID)
+}
+
+func TestStartMaintenanceWindowValidation(t *testing.T) {
+	app, _ := newMaintenanceApp(t)
+
+	invalid := map[string]func() error{
+		"empty label": func() error {
+			_, err := app.StartMaintenanceWindow([]string{"web"}, "", " ", 10, "")
+			return err
+		},
+		"no duration": func() error {
+			_, err := app.StartMaintenanceWindow([]string{"web"}, "", "Deploy", 0, "")
+			return err
+		},
+		"too long": func() error {
+			_, err := app.StartMaintenanceWindow([]string{"web"}, "", "Deploy", MaxMaintenanceDurationMins+1, "")
+			return err
+		},
+		"no target": func() error {
+			_, err := app.StartMaintenanceWindow(nil, " ", "Deploy", 10, "")
+			return err
+		},
+		"unknown profile": func() error {
+			_, err := app.StartMaintenanceWindow([]string{"missing"}, "", "Deploy", 10, "")
+			return err
+		},
+	}
+	for name, start := range invalid {
+		if err := start(); err == nil {
+			t.Errorf("%s: StartMaintenanceWindow() accepted the window", name)
+		}
+	}
+	if windows := app.GetMaintenanceWindows(); len(windows) != 0 {
+		t.Errorf("GetMaintenanceWindows() = %v after invalid requests, want none", windows)
+	}
+}
+
+func TestMaintenanceWindowFlagsMatchingTabs(t *testing.T) {
+	app, output := newMaintenanceApp(t)
+
+	window, err := app.StartMaintenanceWindow(nil, "deploy", "Release 4.2", 30, "Deploy in progress")
+	if err != nil {
+		t.Fatalf("StartMaintenanceWindow() returned error: %v", err)
+	}
+	if len(window.ProfileIDs) != 1 || window.ProfileIDs[0] != "web" || len(window.Hosts) != 1 || window.Hosts[0] != "web.internal" {
+		t.Errorf("window = %+v, want the web profile and host resolved from the tag", window)
+	}
+
+	if banner := app.terminal.tabs["tab_web"].Maintenance; banner == nil || banner.WindowID != window.ID || banner.Label != "Release 4.2" {
+		t.Errorf("web tab banner = %+v, want the window's banner", banner)
+	}
+	if banner := app.terminal.tabs["tab_db"].Maintenance; banner != nil {
+		t.Errorf("db tab banner = %+v, want none", banner)
+	}
+	entries := output.take()
+	if len(entries) != 1 || !strings.HasPrefix(entries[0], "session_web:") || !strings.Contains(entries[0], "Release 4.2") || !strings.Contains(entries[0], "Deploy in progress") {
+		t.Errorf("terminal output = %q, want one maintenance line in the web session", entries)
+	}
+
+	// A tab opened later to the same host without a profile is flagged once its shell is up
+	app.terminal.tabs["tab_quick"] = &Tab{ID: "tab_quick", SessionID: "session_quick", ConnectionType: ConnectionTypeSSH,
+		SSHConfig: &SSHConfig{Host: "WEB.internal."}}
+	if !app.applyMaintenanceWindows("tab_quick") {
+		t.Error("applyMaintenanceWindows() did not flag a tab connected to the window's host")
+	}
+	// Flagging again doesn't repeat the message
+	output.take()
+	app.applyMaintenanceWindows("tab_quick")
+	if entries := output.take(); len(entries) != 0 {
+		t.Errorf("terminal output on re-apply = %q, want none", entries)
+	}
+
+	if err := app.EndMaintenanceWindow(window.ID); err != nil {
+		t.Fatalf("EndMaintenanceWindow() returned error: %v", err)
+	}
+	for _, tabID := range []string{"tab_web", "tab_quick"} {
+		if banner := app.terminal.tabs[tabID].Maintenance; banner != nil {
+			t.Errorf("%s banner = %+v after the window ended, want none", tabID, banner)
+		}
+	}
+	if err := app.EndMaintenanceWindow(window.ID); toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("EndMaintenanceWindow() twice = %v, want not found", err)
+	}
+}
+
+func TestMaintenanceWindowExpires(t *testing.T) {
+	app, _ := newMaintenanceApp(t)
+
+	window, err := app.StartMaintenanceWindow([]string{"db"}, "", "Failover", 1, "")
+	if err != nil {
+		t.Fatalf("StartMaintenanceWindow() returned error: %v", err)
+	}
+
+	// Bring the end forward instead of waiting a minute
+	maintenanceWindowsMu.Lock()
+	maintenanceWindows[window.ID].Ends = time.Now().Add(50 * time.Millisecond)
+	maintenanceWindowTimers[window.ID].Reset(50 * time.Millisecond)
+	maintenanceWindowsMu.Unlock()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(app.GetMaintenanceWindows()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if windows := app.GetMaintenanceWindows(); len(windows) != 0 {
+		t.Fatalf("GetMaintenanceWindows() = %v after expiry, want none", windows)
+	}
+	app.terminal.mutex.RLock()
+	banner := app.terminal.tabs["tab_db"].Maintenance
+	app.terminal.mutex.RUnlock()
+	if banner != nil {
+		t.Errorf("db tab banner = %+v after expiry, want none", banner)
+	}
+}
+
+func TestMaintenanceWindowsSurviveRestart(t *testing.T) {
+	app, _ := newMaintenanceApp(t)
+
+	window, err := app.StartMaintenanceWindow([]string{"db"}, "", "Failover", 60, "")
+	if err != nil {
+		t.Fatalf("StartMaintenanceWindow() returned error: %v", err)
+	}
+	windowsPath, err := app.getMaintenanceWindowsPath()
+	if err != nil {
+		t.Fatalf("getMaintenanceWindowsPath() returned error: %v", err)
+	}
+	if _, err := os.Stat(windowsPath); err != nil {
+		t.Fatalf("maintenance windows file not written: %v", err)
+	}
+
+	// A restart loses the in-memory state but not the file
+	dropMaintenanceWindow(window.ID)
+	restarted := NewApp()
+	restarted.restoreMaintenanceWindows()
+	windows := restarted.GetMaintenanceWindows()
+	if len(windows) != 1 || windows[0].ID != window.ID || windows[0].Label != "Failover" {
+		t.Fatalf("GetMaintenanceWindows() after restart = %+v, want the window", windows)
+	}
+
+	restarted.terminal.tabs["tab_db"] = &Tab{ID: "tab_db", SessionID: "session_db", ConnectionType: ConnectionTypeSSH, ProfileID: "db",
+		SSHConfig: &SSHConfig{Host: "db.internal"}}
+	if !restarted.applyMaintenanceWindows("tab_db") {
+		t.Error("applyMaintenanceWindows() did not flag a tab of the restored window's profile")
+	}
+
+	if err := restarted.EndMaintenanceWindow(window.ID); err != nil {
+		t.Fatalf("EndMaintenanceWindow() returned error: %v", err)
+	}
+	if _, err := os.Stat(windowsPath); !os.IsNotExist(err) {
+		t.Errorf("maintenance windows file still present after the last window ended: %v", err)
+	}
+}
@@ -0,0 +1,492 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// mDNS discovery constants
+const (
+	MDNSProviderID      = "mdns" // ProviderID of hosts found on the local network
+	MDNSHostFoundEvent  = "mdns:host-found"
+	MDNSCompleteEvent   = "mdns:complete"
+	DefaultMDNSTimeout  = 3 * time.Second
+	MaxMDNSTimeout      = 30 * time.Second
+	maxMDNSPacketSize   = 9000 // Largest mDNS packet (RFC 6762 section 17)
+	mdnsDomainSuffix    = ".local."
+	mdnsServiceSSH      = "_ssh._tcp"
+	mdnsServiceSFTPSSH  = "_sftp-ssh._tcp"
+	mdnsMulticastPort   = 5353
+	mdnsTXTKeyMaxLength = 64
+)
+
+// mdnsServiceTypes are the DNS-SD service types browsed for SSH hosts
+var mdnsServiceTypes = []string{mdnsServiceSSH, mdnsServiceSFTPSSH}
+
+// mdnsDestinations returns where browse queries are sent. A variable so tests can point it at
+// a fake responder.
+var mdnsDestinations = defaultMDNSDestinations
+
+// MDNSHost is an SSH server advertised on the local network. The embedded DiscoveredHost has
+// the hostname as its address, so a host can be passed to MaterializeDiscoveredHosts as is.
+type MDNSHost struct {
+	DiscoveredHost
+	Hostname  string            `json:"hostname"`      // SRV target without the trailing dot, e.g. "raspberrypi.local"
+	Addresses []string          `json:"addresses"`     // IPv4 addresses first, then IPv6
+	Services  []string          `json:"services"`      // Service types advertising the host, e.g. "_ssh._tcp"
+	TXT       map[string]string `json:"txt,omitempty"` // TXT metadata of the services
+}
+
+// mdnsInstance is one advertised service instance, e.g. "raspberrypi._ssh._tcp.local."
+type mdnsInstance struct {
+	service string
+	label   string
+	target  string // SRV target, lower case with the trailing dot
+	port    int
+	txt     map[string]string
+}
+
+// mdnsPacket is a datagram read by one of the browse sockets
+type mdnsPacket struct {
+	data []byte
+	from *net.UDPAddr
+}
+
+// mdnsBrowser collects the records of mDNS responses, which may be split over several
+// packets and arrive in any order
+type mdnsBrowser struct {
+	instances map[string]*mdnsInstance     // By lower-case instance name
+	addresses map[string]map[string]net.IP // Host name -> address string -> address
+	asked     map[string]bool              // Follow-up questions already sent
+	reported  map[string]string            // Host key -> signature of what was last reported
+}
+
+func newMDNSBrowser() *mdnsBrowser {
+	return &mdnsBrowser{
+		instances: make(map[string]*mdnsInstance),
+		addresses: make(map[string]map[string]net.IP),
+		asked:     make(map[string]bool),
+		reported:  make(map[string]string),
+	}
+}
+
+// defaultMDNSDestinations returns the IPv4 group and the IPv6 group on every multicast
+// interface; IPv6 link-local multicast needs an interface to be sent on
+func defaultMDNSDestinations() []*net.UDPAddr {
+	destinations := []*net.UDPAddr{{IP: net.IPv4(224, 0, 0, 251), Port: mdnsMulticastPort}}
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return destinations
+	}
+	for _, ifi := range interfaces {
+		if ifi.Flags&net.FlagUp == 0 || ifi.Flags&net.FlagMulticast == 0 || ifi.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		destinations = append(destinations, &net.UDPAddr{IP: net.ParseIP("ff02::fb"), Port: mdnsMulticastPort, Zone: ifi.Name})
+	}
+	return destinations
+}
+
+// buildMDNSQuery packs a query with a question per name and type
+func buildMDNSQuery(questions []dnsmessage.Question) ([]byte, error) {
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{})
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+	for _, question := range questions {
+		if err := builder.Question(question); err != nil {
+			return nil, err
+		}
+	}
+	return builder.Finish()
+}
+
+// mdnsQuestion builds a question for a name such as "_ssh._tcp.local."
+func mdnsQuestion(name string, qtype dnsmessage.Type) (dnsmessage.Question, error) {
+	parsed, err := dnsmessage.NewName(name)
+	if err != nil {
+		return dnsmessage.Question{}, fmt.Errorf("invalid mDNS name %q: %w", name, err)
+	}
+	return dnsmessage.Question{Name: parsed, Type: qtype, Class: dnsmessage.ClassINET}, nil
+}
+
+// serviceOf returns the browsed service type an instance name belongs to and the instance
+// label in its advertised case. DNS names compare case-insensitively.
+func serviceOf(instanceName string) (service string, label string, ok bool) {
+	for _, service := range mdnsServiceTypes {
+		suffix := "." + service + mdnsDomainSuffix
+		cut := len(instanceName) - len(suffix)
+		if cut > 0 && strings.EqualFold(instanceName[cut:], suffix) {
+			return service, instanceName[:cut], true
+		}
+	}
+	return "", "", false
+}
+
+// parseMDNSTXT turns "key=value" strings into a map; a bare key maps to ""
+func parseMDNSTXT(entries []string) map[string]string {
+	txt := make(map[string]string)
+	for _, entry := range entries {
+		key, value, _ := strings.Cut(entry, "=")
+		if key == "" || len(key) > mdnsTXTKeyMaxLength {
+			continue
+		}
+		if _, exists := txt[key]; !exists {
+			txt[key] = value // The first occurrence of a key wins (RFC 6763 section 6.4)
+		}
+	}
+	return txt
+}
+
+// handle adds the records of a response. Link-local IPv6 addresses get the zone of the
+// interface the response arrived on, without which they can't be connected to.
+func (b *mdnsBrowser) handle(packet mdnsPacket) {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(packet.data); err != nil || !msg.Header.Response {
+		return
+	}
+
+	records := append(append(msg.Answers, msg.Authorities...), msg.Additionals...)
+	for _, record := range records {
+		name := record.Header.Name.String()
+		switch body := record.Body.(type) {
+		case *dnsmessage.PTRResource:
+			if service, _, ok := serviceOf(body.PTR.String()); ok && strings.EqualFold(name, service+mdnsDomainSuffix) {
+				b.instance(body.PTR.String())
+			}
+		case *dnsmessage.SRVResource:
+			if instance := b.instance(name); instance != nil {
+				instance.target = strings.ToLower(body.Target.String())
+				instance.port = int(body.Port)
+			}
+		case *dnsmessage.TXTResource:
+			if instance := b.instance(name); instance != nil {
+				instance.txt = parseMDNSTXT(body.TXT)
+			}
+		case *dnsmessage.AResource:
+			b.addAddress(strings.ToLower(name), net.IP(body.A[:]), "")
+		case *dnsmessage.AAAAResource:
+			b.addAddress(strings.ToLower(name), net.IP(body.AAAA[:]), packet.from.Zone)
+		}
+	}
+}
+
+// instance returns the instance a PTR, SRV or TXT record names, creating it on the first
+// record seen. Returns nil for names outside the browsed services.
+func (b *mdnsBrowser) instance(name string) *mdnsInstance {
+	key := strings.ToLower(name)
+	if instance, exists := b.instances[key]; exists {
+		return instance
+	}
+	service, label, ok := serviceOf(name)
+	if !ok {
+		return nil
+	}
+	instance := &mdnsInstance{service: service, label: label}
+	b.instances[key] = instance
+	return instance
+}
+
+func (b *mdnsBrowser) addAddress(host string, ip net.IP, zone string) {
+	if b.addresses[host] == nil {
+		b.addresses[host] = make(map[string]net.IP)
+	}
+	address := ip.String()
+	if zone != "" && ip.To4() == nil && ip.IsLinkLocalUnicast() {
+		address += "%" + zone
+	}
+	b.addresses[host][address] = ip
+}
+
+// followUps returns the questions still needed to resolve the instances seen so far: SRV and
+// TXT for instances only named by a PTR record, A and AAAA for targets without addresses.
+// Each question is asked once.
+func (b *mdnsBrowser) followUps() []dnsmessage.Question {
+	var questions []dnsmessage.Question
+	ask := func(name string, qtype dnsmessage.Type) {
+		key := qtype.String() + " " + name
+		if b.asked[key] {
+			return
+		}
+		b.asked[key] = true
+		if question, err := mdnsQuestion(name, qtype); err == nil {
+			questions = append(questions, question)
+		}
+	}
+
+	names := mapKeys(b.instances)
+	sort.Strings(names)
+	for _, name := range names {
+		instance := b.instances[name]
+		if instance.target == "" {
+			ask(name, dnsmessage.TypeSRV)
+			ask(name, dnsmessage.TypeTXT)
+			continue
+		}
+		if len(b.addresses[instance.target]) == 0 {
+			ask(instance.target, dnsmessage.TypeA)
+			ask(instance.target, dnsmessage.TypeAAAA)
+		}
+	}
+	return questions
+}
+
+// hosts returns the resolved hosts. Instances of several services on the same host and port
+// are merged into one host.
+func (b *mdnsBrowser) hosts() []MDNSHost {
+	merged := make(map[string]*MDNSHost)
+	names := mapKeys(b.instances)
+	sort.Strings(names)
+	for _, name := range names {
+		instance := b.instances[name]
+		if instance.target == "" || len(b.addresses[instance.target]) == 0 {
+			continue
+		}
+		port := instance.port
+		if port == 0 {
+			port = 22
+		}
+		hostname := strings.TrimSuffix(instance.target, ".")
+		key := fmt.Sprintf("%s:%d", hostname, port)
+
+		host, exists := merged[key]
+		if !exists {
+			host = &MDNSHost{
+				DiscoveredHost: DiscoveredHost{
+					ProviderID: MDNSProviderID,
+					HostID:     key,
+					Name:       instance.label,
+					Address:    hostname,
+					Port:       port,
+					Tags:       []string{MDNSProviderID},
+				},
+				Hostname:  hostname,
+				Addresses: mdnsAddresses(b.addresses[instance.target]),
+				TXT:       make(map[string]string),
+			}
+			merged[key] = host
+		}
+		host.Services = mergeKeys(host.Services, []string{instance.service})
+		for k, v := range instance.txt {
+			if _, exists := host.TXT[k]; !exists {
+				host.TXT[k] = v
+			}
+		}
+	}
+
+	hosts := make([]MDNSHost, 0, len(merged))
+	for _, host := range merged {
+		sort.Strings(host.Services)
+		hosts = append(hosts, *host)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].HostID < hosts[j].HostID
+	})
+	return hosts
+}
+
+// changed returns the hosts that are new or differ from when they were last returned
+func (b *mdnsBrowser) changed() []MDNSHost {
+	var changed []MDNSHost
+	for _, host := range b.hosts() {
+		signature := fmt.Sprintf("%v|%v|%v|%s", host.Addresses, host.Services, host.TXT, host.Name)
+		if b.reported[host.HostID] != signature {
+			b.reported[host.HostID] = signature
+			changed = append(changed, host)
+		}
+	}
+	return changed
+}
+
+// mdnsAddresses sorts a host's addresses, IPv4 first
+func mdnsAddresses(addresses map[string]net.IP) []string {
+	var v4, v6 []string
+	for address, ip := range addresses {
+		if ip.To4() != nil {
+			v4 = append(v4, address)
+		} else {
+			v6 = append(v6, address)
+		}
+	}
+	sort.Strings(v4)
+	sort.Strings(v6)
+	return append(v4, v6...)
+}
+
+// browseMDNS queries the local network for SSH services until ctx is done, calling found for
+// each host as soon as it is resolved (and again when its addresses or services change), and
+// returns every host found. Queries are sent from ephemeral ports, so responders answer by
+// unicast (RFC 6762 section 6.7) and no multicast group is joined.
+func browseMDNS(ctx context.Context, found func(MDNSHost)) ([]MDNSHost, error) {
+	conns := make(map[bool]*net.UDPConn) // Keyed by IPv4
+	for _, v4 := range []bool{true, false} {
+		network := "udp6"
+		if v4 {
+			network = "udp4"
+		}
+		conn, err := net.ListenUDP(network, nil)
+		if err != nil {
+			continue // No IPv6 (or IPv4) on this machine
+		}
+		defer conn.Close()
+		conns[v4] = conn
+	}
+	if len(conns) == 0 {
+		return nil, fmt.Errorf("failed to open a UDP socket for mDNS")
+	}
+
+	destinations := mdnsDestinations()
+	send := func(questions []dnsmessage.Question) error {
+		if len(questions) == 0 {
+			return nil
+		}
+		query, err := buildMDNSQuery(questions)
+		if err != nil {
+			return err
+		}
+		var lastErr error
+		sent := 0
+		for _, destination := range destinations {
+			conn := conns[destination.IP.To4() != nil]
+			if conn == nil {
+				continue
+			}
+			if _, err := conn.WriteToUDP(query, destination); err != nil {
+				lastErr = err
+				continue
+			}
+			sent++
+		}
+		if sent == 0 {
+			return fmt.Errorf("failed to send mDNS query: %w", lastErr)
+		}
+		return nil
+	}
+
+	packets := make(chan mdnsPacket, 64)
+	for _, conn := range conns {
+		go func(conn *net.UDPConn) {
+			for {
+				buf := make([]byte, maxMDNSPacketSize)
+				n, from, err := conn.ReadFromUDP(buf)
+				if err != nil {
+					return // Closed when browsing ends
+				}
+				select {
+				case packets <- mdnsPacket{data: buf[:n], from: from}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(conn)
+	}
+
+	var browse []dnsmessage.Question
+	for _, service := range mdnsServiceTypes {
+		question, err := mdnsQuestion(service+mdnsDomainSuffix, dnsmessage.TypePTR)
+		if err != nil {
+			return nil, err
+		}
+		browse = append(browse, question)
+	}
+	if err := send(browse); err != nil {
+		return nil, err
+	}
+
+	browser := newMDNSBrowser()
+	for {
+		select {
+		case <-ctx.Done():
+			return browser.hosts(), nil
+		case packet := <-packets:
+			browser.handle(packet)
+			for _, host := range browser.changed() {
+				found(host)
+			}
+			if err := send(browser.followUps()); err != nil {
+				fmt.Printf("mDNS follow-up query failed: %v\n", err)
+			}
+		}
+	}
+}
+
+// profileHostIndex maps the lower-case host of every SSH profile to the profile's ID
+func profileHostIndex(store ProfileStore) map[string]string {
+	index := make(map[string]string)
+	for _, profile := range store.Profiles() {
+		if profile.SSHConfig == nil || profile.SSHConfig.Host == "" {
+			continue
+		}
+		index[strings.TrimSuffix(strings.ToLower(profile.SSHConfig.Host), ".")] = profile.ID
+	}
+	return index
+}
+
+// matchProfile sets ProfileID when a profile connects to the host by its hostname, its name
+// without ".local", or one of its addresses
+func (h *MDNSHost) matchProfile(index map[string]string) {
+	candidates := []string{h.Hostname, strings.TrimSuffix(h.Hostname, ".local")}
+	for _, address := range h.Addresses {
+		candidates = append(candidates, address, strings.SplitN(address, "%", 2)[0])
+	}
+	for _, candidate := range candidates {
+		if profileID, exists := index[candidate]; exists {
+			h.ProfileID = profileID
+			return
+		}
+	}
+}
+
+// mdnsDiscoveryEnabled reports whether the EnableMDNSDiscovery setting is on
+func (a *App) mdnsDiscoveryEnabled() bool {
+	if a.config == nil {
+		return false
+	}
+	a.config.mutex.RLock()
+	defer a.config.mutex.RUnlock()
+	return a.config.config != nil && a.config.config.EnableMDNSDiscovery
+}
+
+// DiscoverLocalSSHHosts browses the local network for _ssh._tcp and _sftp-ssh._tcp services
+// for timeoutSeconds (3 when 0, at most 30). Each host is sent as mdns:host-found as soon as it
+// is resolved, so the frontend can list hosts while the browse continues; mdns:complete ends
+// the browse. Hosts that a profile already connects to carry its ID.
+func (a *App) DiscoverLocalSSHHosts(timeoutSeconds int) ([]MDNSHost, error) {
+	if !a.mdnsDiscoveryEnabled() {
+		return nil, fmt.Errorf("local network discovery is disabled: it sends multicast DNS queries, enable it with the EnableMDNSDiscovery setting")
+	}
+	timeout := DefaultMDNSTimeout
+	if timeoutSeconds < 0 || time.Duration(timeoutSeconds)*time.Second > MaxMDNSTimeout {
+		return nil, fmt.Errorf("timeout must be between 0 and %d seconds, got: %d", int(MaxMDNSTimeout.Seconds()), timeoutSeconds)
+	}
+	if timeoutSeconds > 0 {
+		timeout = time.Duration(timeoutSeconds) * time.Second
+	}
+
+	index := profileHostIndex(a.profiles)
+	emitter := appEmitter{a}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	started := time.Now()
+	hosts, err := browseMDNS(ctx, func(host MDNSHost) {
+		host.matchProfile(index)
+		emitter.Emit(MDNSHostFoundEvent, host)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("local network discovery failed: %w", err)
+	}
+	for i := range hosts {
+		hosts[i].matchProfile(index)
+	}
+
+	emitter.Emit(MDNSCompleteEvent, map[string]interface{}{"count": len(hosts)})
+	fmt.Printf("mDNS discovery found %d SSH hosts in %s\n", len(hosts), time.Since(started).Round(time.Millisecond))
+	return hosts, nil
+}
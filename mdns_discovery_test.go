@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeMDNSResponder answers mDNS queries on loopback. raspberrypi advertises both SSH services
+// with every record in one response. nas only answers a browse with its PTR record, so SRV, TXT
+// and its addresses need follow-up queries.
+type fakeMDNSResponder struct {
+	conn      *net.UDPConn
+	mu        sync.Mutex
+	questions []string
+}
+
+func newFakeMDNSResponder(t *testing.T) *fakeMDNSResponder {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	responder := &fakeMDNSResponder{conn: conn}
+	go responder.serve(t)
+	return responder
+}
+
+func (r *fakeMDNSResponder) addr() *net.UDPAddr {
+	return r.conn.LocalAddr().(*net.UDPAddr)
+}
+
+func (r *fakeMDNSResponder) asked() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.questions...)
+}
+
+func (r *fakeMDNSResponder) serve(t *testing.T) {
+	buf := make([]byte, maxMDNSPacketSize)
+	for {
+		n, from, err := r.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		var query dnsmessage.Message
+		if err := query.Unpack(buf[:n]); err != nil {
+			t.Errorf("responder got an invalid query: %v", err)
+			continue
+		}
+		var answers []dnsmessage.Resource
+		for _, question := range query.Questions {
+			r.mu.Lock()
+			r.questions = append(r.questions, question.Type.String()+" "+question.Name.String())
+			r.mu.Unlock()
+			answers = append(answers, fakeMDNSAnswers(question)...)
+		}
+		if len(answers) == 0 {
+			continue
+		}
+		response := dnsmessage.Message{Header: dnsmessage.Header{Response: true, Authoritative: true}, Answers: answers}
+		packed, err := response.Pack()
+		if err != nil {
+			t.Errorf("packing response: %v", err)
+			continue
+		}
+		r.conn.WriteToUDP(packed, from)
+	}
+}
+
+func fakeMDNSAnswers(question dnsmessage.Question) []dnsmessage.Resource {
+	record := func(name string, body dnsmessage.ResourceBody) dnsmessage.Resource {
+		return dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Class: dnsmessage.ClassINET, TTL: 120},
+			Body:   body,
+		}
+	}
+	ptr := func(service, instance string) dnsmessage.Resource {
+		return record(service, &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName(instance)})
+	}
+	piRecords := func(instance string) []dnsmessage.Resource {
+		return []dnsmessage.Resource{
+			record(instance, &dnsmessage.SRVResource{Target: dnsmessage.MustNewName("raspberrypi.local."), Port: 22}),
+			record(instance, &dnsmessage.TXTResource{TXT: []string{"model=Pi 4", "os=bookworm"}}),
+			record("raspberrypi.local.", &dnsmessage.AResource{A: [4]byte{192, 168, 1, 20}}),
+			record("raspberrypi.local.", &dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 0x20}}),
+		}
+	}
+
+	switch question.Type.String() + " " + question.Name.String() {
+	case "TypePTR _ssh._tcp.local.":
+		pi := "RaspberryPi._ssh._tcp.local."
+		return append([]dnsmessage.Resource{ptr("_ssh._tcp.local.", pi)}, piRecords(pi)...)
+	case "TypePTR _sftp-ssh._tcp.local.":
+		pi := "RaspberryPi._sftp-ssh._tcp.local."
+		return append([]dnsmessage.Resource{
+			ptr("_sftp-ssh._tcp.local.", pi),
+			ptr("_sftp-ssh._tcp.local.", "nas._sftp-ssh._tcp.local."),
+		}, piRecords(pi)...)
+	case "TypeSRV nas._sftp-ssh._tcp.local.":
+		return []dnsmessage.Resource{record("nas._sftp-ssh._tcp.local.", &dnsmessage.SRVResource{Target: dnsmessage.MustNewName("nas.local."), Port: 2222})}
+	case "TypeTXT nas._sftp-ssh._tcp.local.":
+		return []dnsmessage.Resource{record("nas._sftp-ssh._tcp.local.", &dnsmessage.TXTResource{TXT: []string{"vendor=synology"}})}
+	case "TypeA nas.local.":
+		return []dnsmessage.Resource{record("nas.local.", &dnsmessage.AResource{A: [4]byte{192, 168, 1, 30}})}
+	case "TypeAAAA nas.local.":
+		return []dnsmessage.Resource{record("nas.local.", &dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 0x30}})}
+	}
+	return nil
+}
+
+func useFakeMDNSResponder(t *testing.T) *fakeMDNSResponder {
+	responder := newFakeMDNSResponder(t)
+	original := mdnsDestinations
+	mdnsDestinations = func() []*net.UDPAddr { return []*net.UDPAddr{responder.addr()} }
+	t.Cleanup(func() { mdnsDestinations = original })
+	return responder
+}
+
+func TestBrowseMDNSWithFakeResponder(t *testing.T) {
+	responder := useFakeMDNSResponder(t)
+
+	timeout := time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var found []MDNSHost
+	started := time.Now()
+	var firstFound time.Duration
+	hosts, err := browseMDNS(ctx, func(host MDNSHost) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(found) == 0 {
+			firstFound = time.Since(started)
+		}
+		found = append(found, host)
+	})
+	if err != nil {
+		t.Fatalf("browseMDNS() returned error: %v", err)
+	}
+
+	if len(hosts) != 2 {
+		t.Fatalf("browseMDNS() found %d hosts, want 2: %+v", len(hosts), hosts)
+	}
+	nas, pi := hosts[0], hosts[1]
+
+	if pi.Name != "RaspberryPi" || pi.Hostname != "raspberrypi.local" || pi.Address != "raspberrypi.local" || pi.Port != 22 {
+		t.Errorf("raspberrypi = %+v", pi)
+	}
+	if want := []string{"192.168.1.20", "2001:db8::20"}; !reflect.DeepEqual(pi.Addresses, want) {
+		t.Errorf("raspberrypi addresses = %v, want %v", pi.Addresses, want)
+	}
+	if want := []string{mdnsServiceSFTPSSH, mdnsServiceSSH}; !reflect.DeepEqual(pi.Services, want) {
+		t.Errorf("raspberrypi services = %v, want both merged into one host: %v", pi.Services, want)
+	}
+	if pi.TXT["model"] != "Pi 4" || pi.TXT["os"] != "bookworm" {
+		t.Errorf("raspberrypi TXT = %v", pi.TXT)
+	}
+	if pi.ProviderID != MDNSProviderID || pi.HostID != "raspberrypi.local:22" {
+		t.Errorf("raspberrypi provider/host ID = %q/%q", pi.ProviderID, pi.HostID)
+	}
+
+	// Resolved through follow-up queries
+	if nas.Hostname != "nas.local" || nas.Port != 2222 || nas.TXT["vendor"] != "synology" {
+		t.Errorf("nas = %+v", nas)
+	}
+	if want := []string{"192.168.1.30", "2001:db8::30"}; !reflect.DeepEqual(nas.Addresses, want) {
+		t.Errorf("nas addresses = %v, want %v", nas.Addresses, want)
+	}
+	asked := strings.Join(responder.asked(), "\n")
+	for _, question := range []string{"TypeSRV nas._sftp-ssh._tcp.local.", "TypeA nas.local.", "TypeAAAA nas.local."} {
+		if strings.Count(asked, question) != 1 {
+			t.Errorf("%q asked %d times, want once:\n%s", question, strings.Count(asked, question), asked)
+		}
+	}
+	if strings.Contains(asked, "raspberrypi.local") {
+		t.Errorf("asked follow-ups for a host whose records were all sent:\n%s", asked)
+	}
+
+	// Hosts are reported as they resolve, not when the browse times out
+	mu.Lock()
+	defer mu.Unlock()
+	if len(found) < 2 {
+		t.Errorf("found called %d times, want at least 2", len(found))
+	}
+	if firstFound > timeout/2 {
+		t.Errorf("first host reported after %s, want well before the %s timeout", firstFound, timeout)
+	}
+}
+
+func TestDiscoverLocalSSHHosts(t *testing.T) {
+	useFakeMDNSResponder(t)
+	app := NewApp()
+
+	if _, err := app.DiscoverLocalSSHHosts(1); err == nil {
+		t.Fatal("DiscoverLocalSSHHosts() ran with the setting off")
+	}
+
+	app.config.config.EnableMDNSDiscovery = true
+	if _, err := app.DiscoverLocalSSHHosts(int(MaxMDNSTimeout.Seconds()) + 1); err == nil {
+		t.Error("DiscoverLocalSSHHosts() accepted a timeout over the maximum")
+	}
+
+	app.profiles.profiles["pi"] = &Profile{ID: "pi", Name: "Pi", Type: ProfileTypeSSH, SSHConfig: &SSHConfig{Host: "192.168.1.20", Port: 22}}
+	app.profiles.profiles["nas"] = &Profile{ID: "nas", Name: "NAS", Type: ProfileTypeSSH, SSHConfig: &SSHConfig{Host: "NAS", Port: 2222}}
+
+	hosts, err := app.DiscoverLocalSSHHosts(1)
+	if err != nil {
+		t.Fatalf("DiscoverLocalSSHHosts() returned error: %v", err)
+	}
+	profiles := make(map[string]string)
+	for _, host := range hosts {
+		profiles[host.Hostname] = host.ProfileID
+	}
+	if want := map[string]string{"raspberrypi.local": "pi", "nas.local": "nas"}; !reflect.DeepEqual(profiles, want) {
+		t.Errorf("hosts marked with profiles %v, want %v", profiles, want)
+	}
+}
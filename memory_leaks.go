@@ -0,0 +1,305 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Memory leak detection constants
+const (
+	MemoryLeakEvent            = "memory-leak-detected"
+	LeakRegressionWindow       = 60 // Samples the growth rate is fitted over; an hour at the default interval
+	LeakMinSamples             = 5  // Samples needed before a growth rate is reported
+	MaxLeakDetectorsPerSession = 10
+)
+
+// leakSampleInterval is how often a detector measures RSS. A variable so tests can shorten it.
+var leakSampleInterval = 60 * time.Second
+
+// LeakDetector samples the total resident memory of the processes matching a pattern on a
+// remote host. Its state is guarded by the monitoring mutex.
+type LeakDetector struct {
+	ID                 string
+	SessionID          string
+	ProcessPattern     string
+	ThresholdMBPerHour float64
+	Created            time.Time
+	pattern            *regexp.Regexp
+	history            *MetricHistory // Total RSS in MB
+	ProcessCount       int            // Processes matched by the last sample
+	GrowthMBPerHour    float64
+	Leaking            bool // Growth above the threshold at the last sample; the event fires when this turns on
+	LastSampled        time.Time
+	LastError          string
+	stopChan           chan struct{}
+	stopOnce           sync.Once
+}
+
+// LeakReport is the state of a memory leak detector as reported to the frontend
+type LeakReport struct {
+	DetectorID         string    `json:"detectorId"`
+	SessionID          string    `json:"sessionId"`
+	ProcessPattern     string    `json:"processPattern"`
+	ThresholdMBPerHour float64   `json:"thresholdMBPerHour"`
+	Timestamps         []int64   `json:"timestamps"` // Unix milliseconds
+	RSSMB              []float64 `json:"rssMB"`
+	CurrentRSSMB       float64   `json:"currentRSSMB"`
+	GrowthMBPerHour    float64   `json:"growthMBPerHour"` // 0 until LeakMinSamples samples exist
+	ProcessCount       int       `json:"processCount"`
+	Leaking            bool      `json:"leaking"`
+	Created            time.Time `json:"created"`
+	LastSampled        time.Time `json:"lastSampled"`
+	Error              string    `json:"error,omitempty"`
+}
+
+// stop ends the detector's sampling loop
+func (d *LeakDetector) stop() {
+	d.stopOnce.Do(func() {
+		close(d.stopChan)
+	})
+}
+
+// report returns the detector as a LeakReport. Caller must hold the monitoring mutex.
+func (d *LeakDetector) report() LeakReport {
+	timestamps, values := d.history.GetData()
+	r := LeakReport{
+		DetectorID:         d.ID,
+		SessionID:          d.SessionID,
+		ProcessPattern:     d.ProcessPattern,
+		ThresholdMBPerHour: d.ThresholdMBPerHour,
+		Timestamps:         timestamps,
+		RSSMB:              values,
+		GrowthMBPerHour:    d.GrowthMBPerHour,
+		ProcessCount:       d.ProcessCount,
+		Leaking:            d.Leaking,
+		Created:            d.Created,
+		LastSampled:        d.LastSampled,
+		Error:              d.LastError,
+	}
+	if len(values) > 0 {
+		r.CurrentRSSMB = values[len(values)-1]
+	}
+	return r
+}
+
+// parseProcessRSS sums the RSS of the processes in `ps -eo pid=,rss=,args=` output whose
+// command line matches pattern. The ps call itself is skipped.
+func parseProcessRSS(output string, pattern *regexp.Regexp) (totalKB uint64, count int) {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		args := strings.Join(fields[2:], " ")
+		if strings.Contains(args, "pid=,rss=,args=") || !pattern.MatchString(args) {
+			continue
+		}
+		rss, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		totalKB += rss
+		count++
+	}
+	return totalKB, count
+}
+
+// leakGrowthRate fits a least-squares line through RSS samples and returns its slope in MB
+// per hour. Timestamps are Unix milliseconds.
+func leakGrowthRate(timestamps []int64, values []float64) float64 {
+	n := float64(len(values))
+	if len(values) < 2 {
+		return 0
+	}
+	// Hours since the first sample keep the sums small
+	var sumX, sumY, sumXY, sumXX float64
+	for i, value := range values {
+		x := float64(timestamps[i]-timestamps[0]) / float64(time.Hour/time.Millisecond)
+		sumX += x
+		sumY += value
+		sumXY += x * value
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
+
+// StartMemoryLeakDetection samples the total RSS of the remote processes whose command line
+// matches processPattern (a regular expression) every minute, and sends memory-leak-detected
+// when the growth rate fitted over the last hour exceeds thresholdMBPerHour. Returns the
+// detector's ID.
+func (a *App) StartMemoryLeakDetection(sessionID, processPattern string, thresholdMBPerHour float64) (string, error) {
+	if strings.TrimSpace(processPattern) == "" {
+		return "", fmt.Errorf("process pattern cannot be empty")
+	}
+	pattern, err := regexp.Compile(processPattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid process pattern: %w", err)
+	}
+	if thresholdMBPerHour <= 0 {
+		return "", fmt.Errorf("threshold must be a positive number of MB per hour, got: %.1f", thresholdMBPerHour)
+	}
+	if _, err := a.monitoringSession(sessionID, "StartMemoryLeakDetection"); err != nil {
+		return "", err
+	}
+
+	a.monitoring.mutex.Lock()
+	if len(a.sessionLeakDetectorsLockFree(sessionID)) >= MaxLeakDetectorsPerSession {
+		a.monitoring.mutex.Unlock()
+		return "", fmt.Errorf("too many leak detectors for session %s: maximum allowed: %d", sessionID, MaxLeakDetectorsPerSession)
+	}
+	detector := &LeakDetector{
+		ID:                 fmt.Sprintf("leakdetector_%d", time.Now().UnixNano()),
+		SessionID:          sessionID,
+		ProcessPattern:     processPattern,
+		ThresholdMBPerHour: thresholdMBPerHour,
+		Created:            time.Now(),
+		pattern:            pattern,
+		history:            NewMetricHistory(LeakRegressionWindow),
+		stopChan:           make(chan struct{}),
+	}
+	a.monitoring.leakDetectors[detector.ID] = detector
+	a.monitoring.mutex.Unlock()
+
+	go a.runLeakDetector(detector)
+	return detector.ID, nil
+}
+
+// StopMemoryLeakDetection stops and removes a leak detector
+func (a *App) StopMemoryLeakDetection(sessionID, detectorID string) error {
+	a.monitoring.mutex.Lock()
+	defer a.monitoring.mutex.Unlock()
+
+	detector, exists := a.monitoring.leakDetectors[detectorID]
+	if !exists || detector.SessionID != sessionID {
+		return newNotFoundError(ErrCategoryMonitoring, "StopMemoryLeakDetection", "leak detector %s not found", detectorID)
+	}
+	detector.stop()
+	delete(a.monitoring.leakDetectors, detectorID)
+	return nil
+}
+
+// GetLeakDetectionReport returns the samples and growth rate of a leak detector
+func (a *App) GetLeakDetectionReport(sessionID, detectorID string) (LeakReport, error) {
+	a.monitoring.mutex.RLock()
+	defer a.monitoring.mutex.RUnlock()
+
+	detector, exists := a.monitoring.leakDetectors[detectorID]
+	if !exists || detector.SessionID != sessionID {
+		return LeakReport{}, newNotFoundError(ErrCategoryMonitoring, "GetLeakDetectionReport", "leak detector %s not found", detectorID)
+	}
+	return detector.report(), nil
+}
+
+// sessionLeakDetectorsLockFree returns a session's leak detectors, oldest first.
+// Caller must hold a.monitoring.mutex.
+func (a *App) sessionLeakDetectorsLockFree(sessionID string) []*LeakDetector {
+	var detectors []*LeakDetector
+	for _, detector := range a.monitoring.leakDetectors {
+		if detector.SessionID == sessionID {
+			detectors = append(detectors, detector)
+		}
+	}
+	sort.Slice(detectors, func(i, j int) bool {
+		return detectors[i].Created.Before(detectors[j].Created)
+	})
+	return detectors
+}
+
+// clearLeakDetectorsLockFree stops and drops every leak detector of a session.
+// Caller must hold Lock on a.monitoring.mutex.
+func (a *App) clearLeakDetectorsLockFree(sessionID string) {
+	for id, detector := range a.monitoring.leakDetectors {
+		if detector.SessionID == sessionID {
+			detector.stop()
+			delete(a.monitoring.leakDetectors, id)
+		}
+	}
+}
+
+// runLeakDetector samples a detector now and then every leakSampleInterval until it is stopped
+func (a *App) runLeakDetector(detector *LeakDetector) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("Leak detector %s panic: %v\n", detector.ID, r)
+		}
+	}()
+
+	ticker := time.NewTicker(leakSampleInterval)
+	defer ticker.Stop()
+	for {
+		a.sampleLeakDetector(detector)
+		select {
+		case <-detector.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sampleLeakDetector measures the detector's processes once, refits the growth rate and sends
+// memory-leak-detected when the rate first goes above the threshold
+func (a *App) sampleLeakDetector(detector *LeakDetector) {
+	var output string
+	sshSession, err := a.monitoringSession(detector.SessionID, "StartMemoryLeakDetection")
+	if err == nil {
+		output, err = a.executeMonitoringCommand(sshSession, remoteCmdProcessRSS)
+	}
+	now := time.Now()
+	totalKB, count := parseProcessRSS(output, detector.pattern)
+
+	var event map[string]interface{}
+	a.monitoring.mutex.Lock()
+	// The detector may have been stopped while it was measured
+	if a.monitoring.leakDetectors[detector.ID] != detector {
+		a.monitoring.mutex.Unlock()
+		return
+	}
+	detector.LastSampled = now
+	detector.ProcessCount = count
+	switch {
+	case err != nil:
+		detector.LastError = err.Error()
+	case count == 0:
+		detector.LastError = fmt.Sprintf("no process matches %q", detector.ProcessPattern)
+	default:
+		detector.LastError = ""
+		detector.history.Add(now.UnixMilli(), float64(totalKB)/1024)
+
+		timestamps, values := detector.history.GetData()
+		if len(values) >= LeakMinSamples {
+			detector.GrowthMBPerHour = leakGrowthRate(timestamps, values)
+		}
+		leaking := len(values) >= LeakMinSamples && detector.GrowthMBPerHour > detector.ThresholdMBPerHour
+		if leaking && !detector.Leaking {
+			event = map[string]interface{}{
+				"sessionId":          detector.SessionID,
+				"detectorId":         detector.ID,
+				"processPattern":     detector.ProcessPattern,
+				"growthMBPerHour":    detector.GrowthMBPerHour,
+				"thresholdMBPerHour": detector.ThresholdMBPerHour,
+				"currentRSSMB":       values[len(values)-1],
+				"processCount":       count,
+			}
+		}
+		detector.Leaking = leaking
+	}
+	a.monitoring.mutex.Unlock()
+
+	if event != nil {
+		fmt.Printf("Memory leak suspected on %s: %q growing %.1f MB/hour\n", detector.SessionID, detector.ProcessPattern, event["growthMBPerHour"])
+		if a.ctx != nil {
+			wailsRuntime.EventsEmit(a.ctx, MemoryLeakEvent, event)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestParseProcessRSS(t *testing.T) {
+	output := `    1  11840 /sbin/init
+  812   6400 sshd: /usr/sbin/sshd -D [listener] 0 of 10-100 startups
+ 2231 204800 /usr/bin/java -jar app.jar
+ 2232 102400 /usr/bin/java -jar worker.jar
+ 3100   3200 ps -eo pid=,rss=,args=
+`
+	total, count := parseProcessRSS(output, regexp.MustCompile(`java`))
+	if total != 307200 || count != 2 {
+		t.Errorf("java: total = %d KB over %d processes, want 307200 over 2", total, count)
+	}
+	// The ps call itself never counts
+	if _, count := parseProcessRSS(output, regexp.MustCompile(`^ps `)); count != 0 {
+		t.Errorf("ps matched %d processes, want 0", count)
+	}
+}
+
+func TestLeakGrowthRate(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	var timestamps []int64
+	var values []float64
+	for i := 0; i < LeakRegressionWindow; i++ {
+		timestamps = append(timestamps, start+int64(i)*time.Minute.Milliseconds())
+		// 12 MB per hour with noise that averages out
+		noise := 0.5
+		if i%2 == 1 {
+			noise = -0.5
+		}
+		values = append(values, 500+float64(i)*0.2+noise)
+	}
+	if rate := leakGrowthRate(timestamps, values); math.Abs(rate-12) > 0.1 {
+		t.Errorf("leakGrowthRate() = %.2f MB/hour, want 12", rate)
+	}
+	if rate := leakGrowthRate(timestamps[:1], values[:1]); rate != 0 {
+		t.Errorf("single sample rate = %v, want 0", rate)
+	}
+}
+
+func TestLeakDetectorLifecycle(t *testing.T) {
+	app := NewApp()
+	if _, err := app.StartMemoryLeakDetection("s1", "java(", 10); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+	if _, err := app.StartMemoryLeakDetection("missing", "java", 10); err == nil || toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("unknown session: err = %v", err)
+	}
+
+	detector := &LeakDetector{ID: "leakdetector_1", SessionID: "s1", history: NewMetricHistory(LeakRegressionWindow), stopChan: make(chan struct{})}
+	detector.history.Add(1000, 512)
+	app.monitoring.leakDetectors[detector.ID] = detector
+
+	if _, err := app.GetLeakDetectionReport("s2", detector.ID); err == nil {
+		t.Error("detector reported for another session")
+	}
+	if report, err := app.GetLeakDetectionReport("s1", detector.ID); err != nil || report.CurrentRSSMB != 512 {
+		t.Errorf("report = %+v, err = %v", report, err)
+	}
+
+	app.CleanupSessionMetrics("s1")
+	select {
+	case <-detector.stopChan:
+	default:
+		t.Error("session cleanup did not stop the detector")
+	}
+	if err := app.StopMemoryLeakDetection("s1", detector.ID); err == nil || toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("stopping a released detector: err = %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Event storm dampening constants. When the network blips every SSH tab fails at once;
+// these keep the resulting burst of messages and status events readable.
+const (
+	// MessageCollapseWindow is how long repeats of an info/warning line are folded into one
+	MessageCollapseWindow = 2 * time.Second
+
+	// TabStatusBulkThreshold is how many tab status events per TabStatusBulkWindow are sent
+	// individually before further ones are batched into a single bulk event
+	TabStatusBulkThreshold  = 10
+	TabStatusBulkWindow     = time.Second
+	TabStatusBulkFlushDelay = 250 * time.Millisecond
+	TabStatusBulkEvent      = "tabs-status-bulk"
+)
+
+// collapsedMessage is the line currently being collapsed for a session
+type collapsedMessage struct {
+	message string
+	msgType MessageType
+	repeats int // Occurrences after the first that have not been shown yet
+	timer   *time.Timer
+}
+
+// TabStatusUpdate is one tab status transition, as sent in tab-status-update or a bulk event
+type TabStatusUpdate struct {
+	TabID        string `json:"tabId"`
+	Status       string `json:"status"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// tabStatusBreaker batches tab status events once they arrive faster than the threshold
+type tabStatusBreaker struct {
+	recent  []time.Time       // Emission times within the last TabStatusBulkWindow
+	pending []TabStatusUpdate // Updates waiting for the next bulk flush, in arrival order
+	timer   *time.Timer
+}
+
+// formatTerminalMessage renders a message line with its type's color and icon
+func formatTerminalMessage(message string, msgType MessageType) string {
+	switch msgType {
+	case MessageInfo:
+		return fmt.Sprintf("\x1b[36m● %s\x1b[0m\r\n", message)
+	case MessageSuccess:
+		return fmt.Sprintf("\x1b[32m✓ %s\x1b[0m\r\n", message)
+	case MessageWarning:
+		return fmt.Sprintf("\x1b[33m⚠ %s\x1b[0m\r\n", message)
+	case MessageError:
+		return fmt.Sprintf("\x1b[31m✗ %s\x1b[0m\r\n", message)
+	case MessageProgress:
+		return fmt.Sprintf("\x1b[90m⏳ %s\x1b[0m\r\n", message)
+	case MessageBroadcast:
+		// Starts on a fresh line and stands out from both shell output and connection messages
+		return fmt.Sprintf("\r\n\x1b[1;30;43m ▲ %s \x1b[0m\r\n", message)
+	default:
+		return fmt.Sprintf("%s\r\n", message)
+	}
+}
+
+// emitCollapsed writes a message to the terminal, folding repeats of the same info/warning
+// line within the collapse window into a single "(×N)" line. Errors and every other type
+// are always shown; they only flush a pending counter first so ordering is kept.
+func (mm *MessageManager) emitCollapsed(sessionID, message string, msgType MessageType) {
+	mm.collapseMutex.Lock()
+	defer mm.collapseMutex.Unlock()
+
+	collapsible := msgType == MessageInfo || msgType == MessageWarning
+	if current, exists := mm.collapsed[sessionID]; exists {
+		if collapsible && current.message == message && current.msgType == msgType {
+			current.repeats++
+			return
+		}
+		mm.flushCollapsedLockFree(sessionID)
+	}
+
+	mm.app.emitTerminalOutput(sessionID, formatTerminalMessage(message, msgType))
+
+	if !collapsible {
+		return
+	}
+	entry := &collapsedMessage{message: message, msgType: msgType}
+	entry.timer = time.AfterFunc(mm.collapseWindow, func() {
+		mm.collapseMutex.Lock()
+		defer mm.collapseMutex.Unlock()
+		if mm.collapsed[sessionID] == entry {
+			mm.flushCollapsedLockFree(sessionID)
+		}
+	})
+	mm.collapsed[sessionID] = entry
+}
+
+// flushCollapsedLockFree shows the counter for a collapsed line, if it repeated, and forgets it.
+// Caller must hold mm.collapseMutex.
+func (mm *MessageManager) flushCollapsedLockFree(sessionID string) {
+	entry, exists := mm.collapsed[sessionID]
+	if !exists {
+		return
+	}
+	entry.timer.Stop()
+	delete(mm.collapsed, sessionID)
+
+	if entry.repeats > 0 {
+		summary := fmt.Sprintf("%s (×%d)", entry.message, entry.repeats+1)
+		mm.app.emitTerminalOutput(sessionID, formatTerminalMessage(summary, entry.msgType))
+	}
+}
+
+// clearCollapsed drops collapse state for a session that is going away
+func (mm *MessageManager) clearCollapsed(sessionID string) {
+	mm.collapseMutex.Lock()
+	defer mm.collapseMutex.Unlock()
+
+	if entry, exists := mm.collapsed[sessionID]; exists {
+		entry.timer.Stop()
+		delete(mm.collapsed, sessionID)
+	}
+}
+
+// dispatchTabStatus emits a tab status update on its own, or queues it for the next
+// bulk event while more than TabStatusBulkThreshold updates arrived within the last second
+func (mm *MessageManager) dispatchTabStatus(update TabStatusUpdate) {
+	now := time.Now()
+
+	mm.breakerMutex.Lock()
+	b := &mm.breaker
+
+	cutoff := now.Add(-TabStatusBulkWindow)
+	kept := b.recent[:0]
+	for _, t := range b.recent {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	b.recent = append(kept, now)
+
+	// Once batching has started, everything goes through the batch until it flushes
+	if b.timer == nil && len(b.recent) <= TabStatusBulkThreshold {
+		mm.breakerMutex.Unlock()
+		mm.emitEvent("tab-status-update", map[string]interface{}{
+			"tabId":        update.TabID,
+			"status":       update.Status,
+			"errorMessage": update.ErrorMessage,
+		})
+		return
+	}
+
+	b.pending = append(b.pending, update)
+	if b.timer == nil {
+		b.timer = time.AfterFunc(TabStatusBulkFlushDelay, mm.flushTabStatusBulk)
+	}
+	mm.breakerMutex.Unlock()
+}
+
+// flushTabStatusBulk sends every queued update in one tabs-status-bulk event so the
+// frontend can apply them in a single render. Updates are sent in order, never merged.
+func (mm *MessageManager) flushTabStatusBulk() {
+	mm.breakerMutex.Lock()
+	updates := mm.breaker.pending
+	mm.breaker.pending = nil
+	mm.breaker.timer = nil
+	mm.breakerMutex.Unlock()
+
+	if len(updates) == 0 {
+		return
+	}
+	fmt.Printf("Tab status storm: sending %d updates in one batch\n", len(updates))
+	mm.emitEvent(TabStatusBulkEvent, map[string]interface{}{
+		"updates": updates,
+		"count":   len(updates),
+	})
+}
+
+// stopDampening stops pending collapse and bulk timers
+func (mm *MessageManager) stopDampening() {
+	mm.collapseMutex.Lock()
+	for sessionID, entry := range mm.collapsed {
+		entry.timer.Stop()
+		delete(mm.collapsed, sessionID)
+	}
+	mm.collapseMutex.Unlock()
+
+	mm.breakerMutex.Lock()
+	if mm.breaker.timer != nil {
+		mm.breaker.timer.Stop()
+		mm.breaker.timer = nil
+	}
+	mm.breaker.pending = nil
+	mm.breakerMutex.Unlock()
+}
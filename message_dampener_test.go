@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventRecorder captures events sent through MessageManager.emitEvent
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []recordedEvent
+}
+
+type recordedEvent struct {
+	name string
+	data map[string]interface{}
+}
+
+func (r *eventRecorder) emit(event string, data interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	payload, _ := data.(map[string]interface{})
+	r.events = append(r.events, recordedEvent{name: event, data: payload})
+}
+
+func (r *eventRecorder) take() []recordedEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := r.events
+	r.events = nil
+	return events
+}
+
+// newStormApp creates an app with count SSH tabs and recorders for events and terminal output
+func newStormApp(count int) (*App, *eventRecorder, *outputRecorder) {
+	app := NewApp()
+	events := &eventRecorder{}
+	output := &outputRecorder{}
+	app.messages.emitEvent = events.emit
+	app.privacy.emit = output.emit
+
+	app.terminal.mutex.Lock()
+	for i := 0; i < count; i++ {
+		tabID := fmt.Sprintf("tab_%d", i)
+		app.terminal.tabs[tabID] = &Tab{ID: tabID, SessionID: fmt.Sprintf("session_%d", i), ConnectionType: "ssh"}
+	}
+	app.terminal.mutex.Unlock()
+	return app, events, output
+}
+
+func TestStatusStormIsBatchedWithoutLosingUpdates(t *testing.T) {
+	const tabs = 30
+	app, events, _ := newStormApp(tabs)
+	defer app.messages.Cleanup()
+
+	// 30 tabs drop at once; each reports the same transition twice, as the
+	// reader loop and keepalive both notice the dead connection
+	var wg sync.WaitGroup
+	for i := 0; i < tabs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sessionID := fmt.Sprintf("session_%d", i)
+			errMsg := fmt.Sprintf("read tcp 10.0.0.%d:22: connection reset by peer", i)
+			app.messages.UpdateConnectionStatus(sessionID, StatusFailed.String(), errMsg)
+			app.messages.UpdateConnectionStatus(sessionID, StatusFailed.String(), errMsg)
+		}(i)
+	}
+	wg.Wait()
+	time.Sleep(TabStatusBulkFlushDelay + 100*time.Millisecond)
+
+	singles, bulks := 0, 0
+	seen := make(map[string]string)
+	for _, event := range events.take() {
+		switch event.name {
+		case "tab-status-update":
+			singles++
+			seen[event.data["tabId"].(string)] = event.data["errorMessage"].(string)
+		case TabStatusBulkEvent:
+			bulks++
+			updates := event.data["updates"].([]TabStatusUpdate)
+			if event.data["count"] != len(updates) {
+				t.Fatalf("bulk count %v does not match %d updates", event.data["count"], len(updates))
+			}
+			for _, update := range updates {
+				if _, dup := seen[update.TabID]; dup {
+					t.Fatalf("duplicate status for %s", update.TabID)
+				}
+				seen[update.TabID] = update.ErrorMessage
+			}
+		default:
+			t.Fatalf("unexpected event %q", event.name)
+		}
+	}
+
+	if singles != TabStatusBulkThreshold {
+		t.Errorf("sent %d individual updates, want %d before batching", singles, TabStatusBulkThreshold)
+	}
+	if bulks != 1 {
+		t.Errorf("sent %d bulk events, want 1", bulks)
+	}
+	if len(seen) != tabs {
+		t.Fatalf("got updates for %d tabs, want %d", len(seen), tabs)
+	}
+	for tabID, errMsg := range seen {
+		if !strings.Contains(errMsg, "connection reset by peer") {
+			t.Errorf("%s lost its error detail: %q", tabID, errMsg)
+		}
+	}
+}
+
+func TestStatusBatchingStopsWhenStormEnds(t *testing.T) {
+	app, events, _ := newStormApp(TabStatusBulkThreshold + 5)
+	defer app.messages.Cleanup()
+
+	for i := 0; i < TabStatusBulkThreshold+5; i++ {
+		app.messages.UpdateConnectionStatus(fmt.Sprintf("session_%d", i), StatusFailed.String(), "network is unreachable")
+	}
+	time.Sleep(TabStatusBulkWindow + TabStatusBulkFlushDelay)
+	events.take()
+
+	app.messages.UpdateConnectionStatus("session_0", StatusConnected.String(), "")
+	got := events.take()
+	if len(got) != 1 || got[0].name != "tab-status-update" {
+		t.Fatalf("after the storm a status change should be sent on its own, got %+v", got)
+	}
+}
+
+func TestRepeatedMessagesCollapseWithCounter(t *testing.T) {
+	const tabs = 30
+	app, _, output := newStormApp(tabs)
+	app.messages.collapseWindow = 100 * time.Millisecond
+	defer app.messages.Cleanup()
+
+	var wg sync.WaitGroup
+	for i := 0; i < tabs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sessionID := fmt.Sprintf("session_%d", i)
+			for j := 0; j < 5; j++ {
+				app.messages.EmitMessage(sessionID, "SFTP client closed", MessageInfo)
+			}
+			// Errors are never collapsed, even when identical
+			app.messages.EmitMessage(sessionID, "Connection lost: EOF", MessageError)
+			app.messages.EmitMessage(sessionID, "Connection lost: EOF", MessageError)
+		}(i)
+	}
+	wg.Wait()
+
+	perSession := make(map[string][]string)
+	for _, entry := range output.take() {
+		sessionID, data, _ := strings.Cut(entry, ":")
+		perSession[sessionID] = append(perSession[sessionID], data)
+	}
+	if len(perSession) != tabs {
+		t.Fatalf("got output for %d sessions, want %d", len(perSession), tabs)
+	}
+
+	info := formatTerminalMessage("SFTP client closed", MessageInfo)
+	summary := formatTerminalMessage("SFTP client closed (×5)", MessageInfo)
+	lost := formatTerminalMessage("Connection lost: EOF", MessageError)
+	for sessionID, lines := range perSession {
+		want := []string{info, summary, lost, lost}
+		if strings.Join(lines, "|") != strings.Join(want, "|") {
+			t.Fatalf("%s: got %q, want %q", sessionID, lines, want)
+		}
+	}
+
+	// A repeat that is still collapsing shows its counter once the window closes
+	app.messages.EmitMessage("session_0", "Retrying", MessageWarning)
+	app.messages.EmitMessage("session_0", "Retrying", MessageWarning)
+	time.Sleep(250 * time.Millisecond)
+	assertOutput(t, output.take(),
+		"session_0:"+formatTerminalMessage("Retrying", MessageWarning),
+		"session_0:"+formatTerminalMessage("Retrying (×2)", MessageWarning))
+}
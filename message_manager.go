@@ -16,6 +16,14 @@ type MessageManager struct {
 	promptsMutex         sync.RWMutex
 	connectionAnimations map[string]*ConnectionAnimation
 	animationsMutex      sync.RWMutex
+
+	// Event storm dampening (see message_dampener.go)
+	collapsed      map[string]*collapsedMessage
+	collapseWindow time.Duration
+	collapseMutex  sync.Mutex
+	breaker        tabStatusBreaker
+	breakerMutex   sync.Mutex
+	emitEvent      func(event string, data interface{})
 }
 
 // ConnectionAnimation tracks ongoing connection animations
@@ -32,13 +40,24 @@ const (
 	StatusHostKeyPrompt = "host-key-prompt"
 )
 
+// StatusDebounceDelay is the window over which connecting/disconnected tab status changes are coalesced
+const StatusDebounceDelay = 200 * time.Millisecond
+
 // NewMessageManager creates a new message manager
 func NewMessageManager(app *App) *MessageManager {
-	return &MessageManager{
+	mm := &MessageManager{
 		app:                  app,
 		activePrompts:        make(map[string]bool),
 		connectionAnimations: make(map[string]*ConnectionAnimation),
+		collapsed:            make(map[string]*collapsedMessage),
+		collapseWindow:       MessageCollapseWindow,
+	}
+	mm.emitEvent = func(event string, data interface{}) {
+		if mm.app.ctx != nil {
+			wailsRuntime.EventsEmit(mm.app.ctx, event, data)
+		}
 	}
+	return mm
 }
 
 // EmitMessage sends a formatted message to the terminal
@@ -54,34 +73,15 @@ func (mm *MessageManager) EmitMessage(sessionID, message string, msgType Message
 		return
 	}
 
-	if mm.app.ctx != nil {
-		var formattedMessage string
-
-		switch msgType {
-		case MessageInfo:
-			formattedMessage = fmt.Sprintf("\x1b[36m● %s\x1b[0m\r\n", message)
-		case MessageSuccess:
-			formattedMessage = fmt.Sprintf("\x1b[32m✓ %s\x1b[0m\r\n", message)
-		case MessageWarning:
-			formattedMessage = fmt.Sprintf("\x1b[33m⚠ %s\x1b[0m\r\n", message)
-		case MessageError:
-			formattedMessage = fmt.Sprintf("\x1b[31m✗ %s\x1b[0m\r\n", message)
-		case MessageProgress:
-			formattedMessage = fmt.Sprintf("\x1b[90m⏳ %s\x1b[0m\r\n", message)
-		case MessageDebug:
-			// Don't show debug messages to user, only log to console
-			fmt.Printf("[%s] DEBUG: %s\n", sessionID, message)
-			return
-		default:
-			formattedMessage = fmt.Sprintf("%s\r\n", message)
-		}
-
-		wailsRuntime.EventsEmit(mm.app.ctx, "terminal-output", map[string]interface{}{
-			"sessionId": sessionID,
-			"data":      formattedMessage,
-		})
+	if msgType == MessageDebug {
+		// Don't show debug messages to user, only log to console
+		fmt.Printf("[%s] DEBUG: %s\n", sessionID, message)
+		return
 	}
 
+	// Repeats of the same info/warning line are collapsed into one line with a counter
+	mm.emitCollapsed(sessionID, message, msgType)
+
 	// Log to console for debugging
 	fmt.Printf("[%s] %s\n", sessionID, message)
 }
@@ -91,27 +91,90 @@ func (mm *MessageManager) UpdateConnectionStatus(sessionID string, status string
 	// Update tab status
 	mm.updateTabStatus(sessionID, status, errorMsg)
 
-	// Emit status update event
-	if mm.app.ctx != nil {
-		// Find tab associated with this session
-		mm.app.mutex.RLock()
-		var tab *Tab
-		for _, t := range mm.app.terminal.tabs {
-			if t.SessionID == sessionID {
-				tab = t
-				break
-			}
+	// Emit status update event (debounced for flapping connections)
+	mm.emitTabStatusDebounced(sessionID, status, errorMsg)
+}
+
+// emitTabStatusDebounced emits tab-status-update unless it repeats the last emitted status.
+// Connecting/disconnected transitions are coalesced over StatusDebounceDelay so a flapping
+// connection doesn't flood the frontend; every other status is emitted immediately.
+func (mm *MessageManager) emitTabStatusDebounced(sessionID, status, errorMsg string) {
+	tm := mm.app.terminal
+
+	// Late updates from a session whose tab is gone have no recipient and would only leave state behind
+	if !mm.app.sessionHasTab(sessionID) {
+		return
+	}
+
+	tm.statusMutex.Lock()
+
+	// A newer status supersedes anything still waiting in the window
+	if timer, exists := tm.statusDebouncer[sessionID]; exists {
+		timer.Stop()
+		delete(tm.statusDebouncer, sessionID)
+	}
+
+	if tm.lastStatus[sessionID] == status {
+		tm.statusMutex.Unlock()
+		return
+	}
+
+	if status != StatusConnecting.String() && status != StatusDisconnected.String() {
+		tm.lastStatus[sessionID] = status
+		tm.statusMutex.Unlock()
+
+		// Emit outside statusMutex - emitTabStatus takes the terminal mutex
+		mm.emitTabStatus(sessionID, status, errorMsg)
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(StatusDebounceDelay, func() {
+		tm.statusMutex.Lock()
+		if tm.statusDebouncer[sessionID] != timer {
+			tm.statusMutex.Unlock()
+			return // Superseded after the timer fired
 		}
-		mm.app.mutex.RUnlock()
-
-		if tab != nil {
-			wailsRuntime.EventsEmit(mm.app.ctx, "tab-status-update", map[string]interface{}{
-				"tabId":        tab.ID,
-				"status":       status,
-				"errorMessage": errorMsg,
-			})
+		delete(tm.statusDebouncer, sessionID)
+		tm.lastStatus[sessionID] = status
+		tm.statusMutex.Unlock()
+
+		mm.emitTabStatus(sessionID, status, errorMsg)
+	})
+	tm.statusDebouncer[sessionID] = timer
+	tm.statusMutex.Unlock()
+}
+
+// emitTabStatus sends tab-status-update for the tab that owns the session
+func (mm *MessageManager) emitTabStatus(sessionID, status, errorMsg string) {
+	// Find tab associated with this session
+	mm.app.terminal.mutex.RLock()
+	var tabID string
+	for _, t := range mm.app.terminal.tabs {
+		if t.SessionID == sessionID {
+			tabID = t.ID
+			break
 		}
 	}
+	mm.app.terminal.mutex.RUnlock()
+
+	if tabID != "" {
+		mm.dispatchTabStatus(TabStatusUpdate{TabID: tabID, Status: status, ErrorMessage: errorMsg})
+	}
+}
+
+// clearStatusDebounce drops debounce state for a session that is going away
+func (mm *MessageManager) clearStatusDebounce(sessionID string) {
+	tm := mm.app.terminal
+
+	tm.statusMutex.Lock()
+	defer tm.statusMutex.Unlock()
+
+	if timer, exists := tm.statusDebouncer[sessionID]; exists {
+		timer.Stop()
+		delete(tm.statusDebouncer, sessionID)
+	}
+	delete(tm.lastStatus, sessionID)
 }
 
 // StartConnectionFlow starts the connection process with clean messaging
@@ -256,10 +319,7 @@ func (mm *MessageManager) startConnectionAnimation(sessionID string) {
 				if mm.app.ctx != nil {
 					// Just update the last line with dots - very subtle
 					updateMsg := fmt.Sprintf("\r\x1b[90m⏳ Connecting%s\x1b[K", dots)
-					wailsRuntime.EventsEmit(mm.app.ctx, "terminal-output", map[string]interface{}{
-						"sessionId": sessionID,
-						"data":      updateMsg,
-					})
+					mm.app.emitTerminalOutput(sessionID, updateMsg)
 				}
 			}
 		}
@@ -278,10 +338,7 @@ func (mm *MessageManager) stopConnectionAnimation(sessionID string) {
 		// Clear the animation line to prevent mixing with next message
 		if mm.app.ctx != nil {
 			clearMsg := "\r\x1b[K" // Clear current line
-			wailsRuntime.EventsEmit(mm.app.ctx, "terminal-output", map[string]interface{}{
-				"sessionId": sessionID,
-				"data":      clearMsg,
-			})
+			mm.app.emitTerminalOutput(sessionID, clearMsg)
 		}
 	}
 }
@@ -300,15 +357,21 @@ func (ca *ConnectionAnimation) stop() {
 // updateTabStatus updates the tab status in the terminal manager
 func (mm *MessageManager) updateTabStatus(sessionID, status, errorMsg string) {
 	mm.app.terminal.mutex.Lock()
-	defer mm.app.terminal.mutex.Unlock()
-
+	tabID := ""
 	for _, tab := range mm.app.terminal.tabs {
 		if tab.SessionID == sessionID {
 			tab.Status = status
 			tab.ErrorMessage = errorMsg
+			tabID = tab.ID
 			break
 		}
 	}
+	mm.app.terminal.mutex.Unlock()
+
+	// A title format may show the status
+	if tabID != "" {
+		mm.app.refreshTabTitle(tabID)
+	}
 }
 
 // sendTerminalReset sends terminal reset sequence for clean session
@@ -322,10 +385,7 @@ func (mm *MessageManager) sendTerminalReset(sessionID string) {
 	initSequence := "\033[?1l\033[?25h\033[0m"
 	fullSequence := clearTerminal + initSequence
 
-	wailsRuntime.EventsEmit(mm.app.ctx, "terminal-output", map[string]interface{}{
-		"sessionId": sessionID,
-		"data":      fullSequence,
-	})
+	mm.app.emitTerminalOutput(sessionID, fullSequence)
 }
 
 // getErrorHints provides troubleshooting hints based on error message
@@ -367,4 +427,6 @@ func (mm *MessageManager) Cleanup() {
 	mm.promptsMutex.Lock()
 	mm.activePrompts = make(map[string]bool)
 	mm.promptsMutex.Unlock()
+
+	mm.stopDampening()
 }
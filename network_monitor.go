@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"golang.org/x/crypto/ssh"
+)
+
+// Network change detection constants
+const (
+	NetworkCheckInterval    = 5 * time.Second // How often local interfaces are compared with the last snapshot
+	NetworkSettleDelay      = 3 * time.Second // Wait after a change for DHCP/VPN to finish before probing
+	SessionKeepaliveTimeout = 5 * time.Second // A session that doesn't answer a keepalive in time is unreachable
+)
+
+// listNetworkInterfaces returns "name address" for every address of every up, non-loopback
+// interface. A variable so tests can simulate network changes.
+var listNetworkInterfaces = func() ([]string, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var entries []string
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			entries = append(entries, iface.Name+" "+addr.String())
+		}
+	}
+	return entries, nil
+}
+
+// NetworkMonitor watches the local network interfaces. There is no portable change
+// notification (netlink, SCNetworkReachability and NotifyAddrChange are each per-platform
+// and mostly need cgo), so the interface list is polled; net.Interfaces works everywhere.
+type NetworkMonitor struct {
+	snapshot string // Sorted interface/address list from the last check
+	stopChan chan struct{}
+	stopOnce sync.Once
+	mutex    sync.Mutex
+}
+
+// NewNetworkMonitor creates a network monitor with no snapshot yet
+func NewNetworkMonitor() *NetworkMonitor {
+	return &NetworkMonitor{stopChan: make(chan struct{})}
+}
+
+// Close implements the Cleanup interface and stops the monitor loop
+func (nm *NetworkMonitor) Close() error {
+	nm.stopOnce.Do(func() {
+		close(nm.stopChan)
+	})
+	return nil
+}
+
+// check takes a new snapshot of the interfaces and reports whether it differs from the
+// previous one. The first snapshot is never a change.
+func (nm *NetworkMonitor) check() (changed bool, interfaces []string) {
+	interfaces, err := listNetworkInterfaces()
+	if err != nil {
+		fmt.Printf("Network monitor: failed to list interfaces: %v\n", err)
+		return false, nil
+	}
+	sort.Strings(interfaces)
+	snapshot := strings.Join(interfaces, "\n")
+
+	nm.mutex.Lock()
+	defer nm.mutex.Unlock()
+	changed = nm.snapshot != "" && snapshot != nm.snapshot
+	nm.snapshot = snapshot
+	return changed, interfaces
+}
+
+// autoReconnectOnNetworkChange reports whether dead sessions are reconnected after a network change
+func (a *App) autoReconnectOnNetworkChange() bool {
+	if a.config == nil {
+		return false
+	}
+	a.config.mutex.RLock()
+	defer a.config.mutex.RUnlock()
+	return a.config.config != nil && a.config.config.AutoReconnectOnNetworkChange
+}
+
+// startNetworkMonitor starts the loop that validates SSH sessions when the network changes
+func (a *App) startNetworkMonitor() {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Printf("Network monitor panic: %v\n", r)
+			}
+		}()
+
+		a.network.check()
+		ticker := time.NewTicker(NetworkCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-a.network.stopChan:
+				return
+			case <-ticker.C:
+				changed, _ := a.network.check()
+				if !changed {
+					continue
+				}
+				// Interfaces flap while switching networks; probe once things have settled
+				select {
+				case <-a.network.stopChan:
+					return
+				case <-time.After(NetworkSettleDelay):
+				}
+				_, interfaces := a.network.check()
+				a.handleNetworkChange(interfaces)
+			}
+		}
+	}()
+}
+
+// handleNetworkChange probes every SSH session, marks the unreachable ones as hanging and,
+// when enabled, reconnects them
+func (a *App) handleNetworkChange(interfaces []string) {
+	fmt.Printf("Network change detected (%d interface addresses), validating SSH sessions\n", len(interfaces))
+
+	unreachable := a.ValidateSSHSessions()
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, "network-changed", map[string]interface{}{
+			"interfaces":  interfaces,
+			"unreachable": unreachable,
+		})
+	}
+
+	if len(unreachable) > 0 && a.autoReconnectOnNetworkChange() {
+		a.ReconnectAllTabs()
+	}
+}
+
+// probeSSHClient sends an OpenSSH keepalive and waits for the reply
+func probeSSHClient(client *ssh.Client, timeout time.Duration) error {
+	result := make(chan error, 1)
+	go func() {
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		// Servers that don't know the request answer with a failure, which still proves the link is up
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("no keepalive reply within %v", timeout)
+	}
+}
+
+// ValidateSSHSessions sends a keepalive over every SSH session at once and marks the ones that
+// don't answer as hanging, which makes them candidates for ReconnectAllTabs. Returns the
+// session IDs found unreachable.
+func (a *App) ValidateSSHSessions() []string {
+	var sessions []*SSHSession
+	for _, sshSession := range a.sshSessionList() {
+		if sshSession.client != nil && !sshSession.IsCleaning() {
+			sessions = append(sessions, sshSession)
+		}
+	}
+
+	var unreachable []string
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, sshSession := range sessions {
+		wg.Add(1)
+		go func(sshSession *SSHSession) {
+			defer wg.Done()
+			if err := probeSSHClient(sshSession.client, SessionKeepaliveTimeout); err != nil {
+				fmt.Printf("SSH session %s is unreachable: %v\n", sshSession.sessionID, err)
+				sshSession.SetHanging(true)
+				a.handleHangingSession(sshSession)
+
+				mu.Lock()
+				unreachable = append(unreachable, sshSession.sessionID)
+				mu.Unlock()
+			}
+		}(sshSession)
+	}
+	wg.Wait()
+
+	sort.Strings(unreachable)
+	return unreachable
+}
@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestNetworkMonitorDetectsChanges(t *testing.T) {
+	original := listNetworkInterfaces
+	defer func() { listNetworkInterfaces = original }()
+
+	current := []string{"wlan0 192.168.1.20/24", "eth0 10.0.0.5/24"}
+	listNetworkInterfaces = func() ([]string, error) {
+		return append([]string(nil), current...), nil
+	}
+
+	monitor := NewNetworkMonitor()
+	if changed, _ := monitor.check(); changed {
+		t.Error("the first snapshot was reported as a change")
+	}
+
+	current = []string{"eth0 10.0.0.5/24", "wlan0 192.168.1.20/24"}
+	if changed, _ := monitor.check(); changed {
+		t.Error("reordered interfaces were reported as a change")
+	}
+
+	current = []string{"eth0 10.0.0.5/24", "wlan0 172.20.10.3/28"}
+	changed, interfaces := monitor.check()
+	if !changed {
+		t.Error("a new wifi address was not reported as a change")
+	}
+	if len(interfaces) != 2 || interfaces[1] != "wlan0 172.20.10.3/28" {
+		t.Errorf("interfaces = %v", interfaces)
+	}
+}
+
+func TestValidateSSHSessionsWithoutSessions(t *testing.T) {
+	app := NewApp()
+	if unreachable := app.ValidateSSHSessions(); len(unreachable) != 0 {
+		t.Errorf("ValidateSSHSessions() = %v, want none", unreachable)
+	}
+}
@@ -94,6 +94,10 @@ func (e *ShellValidationError) Error() string {
 		e.Shell, e.Platform, e.Reason, e.Err)
 }
 
+func (e *ShellValidationError) Unwrap() error {
+	return e.Err
+}
+
 // OSInfoCache provides thread-safe caching for OS information
 type OSInfoCache struct {
 	osInfo      map[string]interface{}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Privacy lock constants
+const (
+	PrivacyLockCheckInterval = 1 * time.Second
+	PrivacyLockEvent         = "privacy-lock-changed"
+
+	privacyDroppedMarker   = "\r\n\x1b[33m⚠ Privacy lock: %d bytes of earlier output were discarded\x1b[0m\r\n"
+	privacySuspendedMarker = "\r\n\x1b[33m⚠ Output suspended while locked: %d bytes not shown\x1b[0m\r\n"
+)
+
+// lockedOutput holds terminal output received for one session while the privacy lock is engaged
+type lockedOutput struct {
+	data    []byte
+	dropped int    // Bytes discarded because the buffer was full
+	mode    string // Overflow mode in effect when buffering started
+}
+
+// PrivacyLockManager hides terminal output while the user is away. Sessions keep running;
+// their output is held back (up to a per-session cap) and flushed in order on unlock.
+type PrivacyLockManager struct {
+	locked       bool
+	lastActivity time.Time
+	buffers      map[string]*lockedOutput
+	order        []string // Sessions in the order they first produced output while locked
+	emit         func(sessionID, data string)
+	stopChan     chan struct{}
+	stopOnce     sync.Once
+	mutex        sync.RWMutex
+}
+
+// NewPrivacyLockManager creates an unlocked privacy lock that forwards output through emit
+func NewPrivacyLockManager(emit func(sessionID, data string)) *PrivacyLockManager {
+	return &PrivacyLockManager{
+		lastActivity: time.Now(),
+		buffers:      make(map[string]*lockedOutput),
+		emit:         emit,
+		stopChan:     make(chan struct{}),
+	}
+}
+
+// Close implements the Cleanup interface and stops the idle watcher
+func (pm *PrivacyLockManager) Close() error {
+	pm.stopOnce.Do(func() {
+		close(pm.stopChan)
+	})
+	return nil
+}
+
+// write forwards terminal output, or holds it back while locked
+func (pm *PrivacyLockManager) write(sessionID, data string, limit int, mode string) {
+	if pm.forwardIfUnlocked(sessionID, data) {
+		return
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	// Unlocked in between - the flush already ran under this lock, so ordering is preserved
+	if !pm.locked {
+		pm.emit(sessionID, data)
+		return
+	}
+	pm.bufferLockFree(sessionID, data, limit, mode)
+}
+
+// forwardIfUnlocked emits output under the read lock so an unlock flush can't be overtaken
+func (pm *PrivacyLockManager) forwardIfUnlocked(sessionID, data string) bool {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	if pm.locked {
+		return false
+	}
+	pm.emit(sessionID, data)
+	return true
+}
+
+// bufferLockFree appends output to the session buffer, applying the overflow mode once it is full.
+// Caller must hold pm.mutex.
+func (pm *PrivacyLockManager) bufferLockFree(sessionID, data string, limit int, mode string) {
+	buf, exists := pm.buffers[sessionID]
+	if !exists {
+		buf = &lockedOutput{mode: mode}
+		pm.buffers[sessionID] = buf
+		pm.order = append(pm.order, sessionID)
+	}
+
+	switch buf.mode {
+	case PrivacyOverflowSuspend:
+		// Once suspended, stay suspended so later output doesn't appear after a silent gap
+		room := limit - len(buf.data)
+		if buf.dropped > 0 || room <= 0 {
+			buf.dropped += len(data)
+			return
+		}
+		if len(data) > room {
+			buf.data = append(buf.data, data[:room]...)
+			buf.dropped += len(data) - room
+			return
+		}
+		buf.data = append(buf.data, data...)
+	default:
+		buf.data = append(buf.data, data...)
+		if excess := len(buf.data) - limit; excess > 0 {
+			buf.dropped += excess
+			// Copy so the backing array doesn't keep growing behind the slice
+			buf.data = append([]byte(nil), buf.data[excess:]...)
+		}
+	}
+}
+
+// flushLockFree emits all held-back output in arrival order with a marker for anything discarded.
+// Caller must hold pm.mutex.
+func (pm *PrivacyLockManager) flushLockFree() {
+	for _, sessionID := range pm.order {
+		buf := pm.buffers[sessionID]
+		if buf.dropped > 0 && buf.mode != PrivacyOverflowSuspend {
+			pm.emit(sessionID, fmt.Sprintf(privacyDroppedMarker, buf.dropped))
+		}
+		if len(buf.data) > 0 {
+			pm.emit(sessionID, string(buf.data))
+		}
+		if buf.dropped > 0 && buf.mode == PrivacyOverflowSuspend {
+			pm.emit(sessionID, fmt.Sprintf(privacySuspendedMarker, buf.dropped))
+		}
+	}
+	pm.buffers = make(map[string]*lockedOutput)
+	pm.order = nil
+}
+
+// discard drops output held back for a session that has been closed
+func (pm *PrivacyLockManager) discard(sessionID string) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if _, exists := pm.buffers[sessionID]; !exists {
+		return
+	}
+	delete(pm.buffers, sessionID)
+	for i, id := range pm.order {
+		if id == sessionID {
+			pm.order = append(pm.order[:i], pm.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// lock engages the lock and reports whether the state changed
+func (pm *PrivacyLockManager) lock() bool {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if pm.locked {
+		return false
+	}
+	pm.locked = true
+	return true
+}
+
+// unlock flushes held-back output, disengages the lock and reports whether the state changed
+func (pm *PrivacyLockManager) unlock() bool {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if !pm.locked {
+		return false
+	}
+	pm.flushLockFree()
+	pm.locked = false
+	pm.lastActivity = time.Now()
+	return true
+}
+
+// isLocked reports whether the lock is engaged
+func (pm *PrivacyLockManager) isLocked() bool {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+	return pm.locked
+}
+
+// touch records user activity
+func (pm *PrivacyLockManager) touch() {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.lastActivity = time.Now()
+}
+
+// idleFor returns how long it has been since the last recorded activity
+func (pm *PrivacyLockManager) idleFor() time.Duration {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+	return time.Since(pm.lastActivity)
+}
+
+// getPrivacyLockConfig returns the current privacy lock configuration with defaults
+func (a *App) getPrivacyLockConfig() PrivacyLockConfig {
+	cfg := PrivacyLockConfig{}
+	if a.config != nil {
+		a.config.mutex.RLock()
+		if a.config.config != nil {
+			cfg = a.config.config.PrivacyLock
+		}
+		a.config.mutex.RUnlock()
+	}
+	return privacyLockConfigWithDefaults(cfg)
+}
+
+// privacyLockConfigWithDefaults fills in unset privacy lock fields. For callers that already
+// hold config.mutex.
+func privacyLockConfigWithDefaults(cfg PrivacyLockConfig) PrivacyLockConfig {
+	if cfg.IdleTimeout == 0 {
+		cfg.IdleTimeout = DefaultPrivacyIdleTimeout
+	}
+	if cfg.BufferLimit == 0 {
+		cfg.BufferLimit = DefaultPrivacyBufferLimit
+	}
+	if cfg.OverflowMode == "" {
+		cfg.OverflowMode = DefaultPrivacyOverflowMode
+	}
+	return cfg
+}
+
+// emitTerminalOutput sends output to a terminal, honoring the privacy lock
+func (a *App) emitTerminalOutput(sessionID, data string) {
+	if a.privacy == nil {
+		a.emitTerminalOutputDirect(sessionID, data)
+		return
+	}
+	cfg := a.getPrivacyLockConfig()
+	a.privacy.write(sessionID, data, cfg.BufferLimit, cfg.OverflowMode)
+}
+
+// emitTerminalOutputDirect sends output to a terminal without consulting the privacy lock
+func (a *App) emitTerminalOutputDirect(sessionID, data string) {
+	if a.ctx == nil {
+		return
+	}
+	wailsRuntime.EventsEmit(a.ctx, "terminal-output", map[string]interface{}{
+		"sessionId": sessionID,
+		"data":      data,
+	})
+}
+
+// LockNow engages the privacy lock immediately (bindable to a hotkey)
+func (a *App) LockNow() error {
+	if a.privacy.lock() {
+		fmt.Println("Privacy lock engaged")
+		a.emitPrivacyLockChanged(true)
+	}
+	return nil
+}
+
+// Unlock disengages the privacy lock and flushes held-back output.
+// If an unlock password is configured it must match; otherwise the call itself is the explicit action.
+func (a *App) Unlock(password string) error {
+	if !a.privacy.isLocked() {
+		return nil
+	}
+
+	if hash := a.getPrivacyLockConfig().PasswordHash; hash != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+			return fmt.Errorf("incorrect unlock password")
+		}
+	}
+
+	if a.privacy.unlock() {
+		fmt.Println("Privacy lock released")
+		a.emitPrivacyLockChanged(false)
+	}
+	return nil
+}
+
+// PrivacyHeartbeat is called by the frontend on keyboard/mouse activity to reset the idle timer
+func (a *App) PrivacyHeartbeat() {
+	a.privacy.touch()
+}
+
+// IsPrivacyLocked reports whether terminal output is currently being held back
+func (a *App) IsPrivacyLocked() bool {
+	return a.privacy.isLocked()
+}
+
+// GetPrivacyLockState returns the lock state and settings for the frontend (never the password hash)
+func (a *App) GetPrivacyLockState() map[string]interface{} {
+	cfg := a.getPrivacyLockConfig()
+	return map[string]interface{}{
+		"locked":       a.privacy.isLocked(),
+		"enabled":      cfg.Enabled,
+		"idleTimeout":  cfg.IdleTimeout,
+		"bufferLimit":  cfg.BufferLimit,
+		"overflowMode": cfg.OverflowMode,
+		"passwordSet":  cfg.PasswordHash != "",
+	}
+}
+
+// SetPrivacyLockPassword sets or clears (empty newPassword) the unlock password.
+// The current password is required when one is already set.
+func (a *App) SetPrivacyLockPassword(currentPassword, newPassword string) error {
+	if a.config == nil || a.config.config == nil {
+		return &ConfigError{Op: "set_privacy_password", Err: fmt.Errorf("config not initialized")}
+	}
+
+	if hash := a.getPrivacyLockConfig().PasswordHash; hash != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(currentPassword)); err != nil {
+			return &ConfigError{Op: "set_privacy_password", Err: fmt.Errorf("current password is incorrect")}
+		}
+	}
+
+	newHash := ""
+	if newPassword != "" {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+		if err != nil {
+			return &ConfigError{Op: "set_privacy_password", Err: err}
+		}
+		newHash = string(hashed)
+	}
+
+	a.config.mutex.Lock()
+	a.config.config.PrivacyLock.PasswordHash = newHash
+	a.config.mutex.Unlock()
+
+	a.markConfigDirty()
+	return nil
+}
+
+// emitPrivacyLockChanged notifies the frontend of the lock state along with the affected tabs
+func (a *App) emitPrivacyLockChanged(locked bool) {
+	if a.ctx == nil {
+		return
+	}
+
+	a.terminal.mutex.RLock()
+	tabIDs := make([]string, 0, len(a.terminal.tabs))
+	for id := range a.terminal.tabs {
+		tabIDs = append(tabIDs, id)
+	}
+	a.terminal.mutex.RUnlock()
+
+	wailsRuntime.EventsEmit(a.ctx, PrivacyLockEvent, map[string]interface{}{
+		"locked": locked,
+		"tabIds": tabIDs,
+	})
+}
+
+// startPrivacyLockWatcher engages the lock once the configured idle period elapses
+func (a *App) startPrivacyLockWatcher() {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Printf("Privacy lock watcher panic: %v\n", r)
+			}
+		}()
+
+		ticker := time.NewTicker(PrivacyLockCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-a.privacy.stopChan:
+				return
+			case <-ticker.C:
+				cfg := a.getPrivacyLockConfig()
+				if !cfg.Enabled || a.privacy.isLocked() {
+					continue
+				}
+				if a.privacy.idleFor() >= time.Duration(cfg.IdleTimeout)*time.Second {
+					a.LockNow()
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// outputRecorder captures emitted terminal output in order
+type outputRecorder struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (r *outputRecorder) emit(sessionID, data string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, sessionID+":"+data)
+}
+
+func (r *outputRecorder) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := r.entries
+	r.entries = nil
+	return entries
+}
+
+func assertOutput(t *testing.T, got []string, want ...string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d outputs %q, want %d %q", len(got), got, len(want), want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("output %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestPrivacyLockShortLockFlushesInOrder(t *testing.T) {
+	rec := &outputRecorder{}
+	pm := NewPrivacyLockManager(rec.emit)
+
+	pm.write("s1", "before", 64, PrivacyOverflowDrop)
+	assertOutput(t, rec.take(), "s1:before")
+
+	pm.lock()
+	pm.write("s1", "one ", 64, PrivacyOverflowDrop)
+	pm.write("s2", "other", 64, PrivacyOverflowDrop)
+	pm.write("s1", "two", 64, PrivacyOverflowDrop)
+	assertOutput(t, rec.take())
+
+	pm.unlock()
+	assertOutput(t, rec.take(), "s1:one two", "s2:other")
+
+	pm.write("s1", "after", 64, PrivacyOverflowDrop)
+	assertOutput(t, rec.take(), "s1:after")
+}
+
+func TestPrivacyLockDropModeKeepsNewest(t *testing.T) {
+	rec := &outputRecorder{}
+	pm := NewPrivacyLockManager(rec.emit)
+
+	pm.lock()
+	pm.write("s1", "0123456789", 10, PrivacyOverflowDrop)
+	pm.write("s1", "abcde", 10, PrivacyOverflowDrop)
+	pm.unlock()
+
+	assertOutput(t, rec.take(),
+		"s1:"+fmt.Sprintf(privacyDroppedMarker, 5),
+		"s1:56789abcde",
+	)
+}
+
+func TestPrivacyLockSuspendModeKeepsOldest(t *testing.T) {
+	rec := &outputRecorder{}
+	pm := NewPrivacyLockManager(rec.emit)
+
+	pm.lock()
+	pm.write("s1", "0123456789ab", 10, PrivacyOverflowSuspend)
+	pm.write("s1", "cd", 10, PrivacyOverflowSuspend)
+	pm.unlock()
+
+	assertOutput(t, rec.take(),
+		"s1:0123456789",
+		"s1:"+fmt.Sprintf(privacySuspendedMarker, 4),
+	)
+}
+
+func TestPrivacyLockSuspendModeStaysSuspended(t *testing.T) {
+	rec := &outputRecorder{}
+	pm := NewPrivacyLockManager(rec.emit)
+
+	// A small chunk after overflow must not be appended past the gap
+	pm.lock()
+	pm.write("s1", "01234567", 10, PrivacyOverflowSuspend)
+	pm.write("s1", "abcdef", 10, PrivacyOverflowSuspend)
+	pm.write("s1", "x", 10, PrivacyOverflowSuspend)
+	pm.unlock()
+
+	assertOutput(t, rec.take(),
+		"s1:01234567ab",
+		"s1:"+fmt.Sprintf(privacySuspendedMarker, 5),
+	)
+}
+
+func TestPrivacyLockNoMarkerWithinLimit(t *testing.T) {
+	for _, mode := range []string{PrivacyOverflowDrop, PrivacyOverflowSuspend} {
+		rec := &outputRecorder{}
+		pm := NewPrivacyLockManager(rec.emit)
+
+		pm.lock()
+		pm.write("s1", "0123456789", 10, mode)
+		pm.unlock()
+
+		assertOutput(t, rec.take(), "s1:0123456789")
+	}
+}
+
+func TestPrivacyLockUnlockPassword(t *testing.T) {
+	app := NewApp()
+	rec := &outputRecorder{}
+	app.privacy.emit = rec.emit
+
+	if err := app.SetPrivacyLockPassword("", "secret"); err != nil {
+		t.Fatalf("SetPrivacyLockPassword() returned error: %v", err)
+	}
+
+	if err := app.LockNow(); err != nil {
+		t.Fatalf("LockNow() returned error: %v", err)
+	}
+	app.emitTerminalOutput("s1", "hidden")
+	assertOutput(t, rec.take())
+
+	if err := app.Unlock("wrong"); err == nil {
+		t.Fatal("Unlock() should reject a wrong password")
+	}
+	if !app.IsPrivacyLocked() {
+		t.Fatal("lock released after a wrong password")
+	}
+	assertOutput(t, rec.take())
+
+	if err := app.Unlock("secret"); err != nil {
+		t.Fatalf("Unlock() returned error: %v", err)
+	}
+	assertOutput(t, rec.take(), "s1:hidden")
+
+	if err := app.SetPrivacyLockPassword("wrong", ""); err == nil {
+		t.Fatal("SetPrivacyLockPassword() should require the current password")
+	}
+	if err := app.SetPrivacyLockPassword("secret", ""); err != nil {
+		t.Fatalf("clearing password returned error: %v", err)
+	}
+
+	app.LockNow()
+	if err := app.Unlock(""); err != nil {
+		t.Fatalf("Unlock() without a password set returned error: %v", err)
+	}
+}
+
+// Meant for -race: settings changes write the config under config.mutex while output is flowing
+func TestPrivacyLockConfigReadDuringUpdate(t *testing.T) {
+	app := NewApp()
+	app.privacy.emit = func(sessionID, data string) {}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			app.config.mutex.Lock()
+			app.config.config.PrivacyLock.BufferLimit = 1024 + i
+			app.config.mutex.Unlock()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			app.emitTerminalOutput("s1", "data")
+		}
+	}()
+	wg.Wait()
+}
+
+func TestPrivacyLockConfigValidate(t *testing.T) {
+	valid := PrivacyLockConfig{}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("zero config should be valid, got: %v", err)
+	}
+
+	invalid := PrivacyLockConfig{OverflowMode: "disconnect"}
+	if err := invalid.Validate(); err == nil || !strings.Contains(err.Error(), "overflow mode") {
+		t.Fatalf("expected overflow mode error, got: %v", err)
+	}
+
+	tooSmall := PrivacyLockConfig{BufferLimit: 1}
+	if err := tooSmall.Validate(); err == nil {
+		t.Fatal("expected buffer limit range error")
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"golang.org/x/crypto/ssh"
+)
+
+// Certificate expiry constants
+const (
+	CertExpiryWarningDays     = 30
+	CertExpiryCheckInterval   = 24 * time.Hour
+	CertExpiryVirtualFolderID = "vf_cert_expiring"
+	CertExpiryFilterType      = "cert-expiring"
+	CertExpiryEvent           = "cert-expiry-virtual-folder-updated"
+	CertFileSuffix            = "-cert.pub"
+)
+
+// certExpiryWatcher periodically refreshes the cert-expiring virtual folder
+type certExpiryWatcher struct {
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// Close implements the Cleanup interface
+func (w *certExpiryWatcher) Close() error {
+	w.stopOnce.Do(func() {
+		close(w.stopChan)
+	})
+	return nil
+}
+
+// sshCertPath returns the certificate file for an SSH config: CertPath if set,
+// otherwise the OpenSSH companion of the private key (id_ed25519 -> id_ed25519-cert.pub)
+func sshCertPath(config *SSHConfig) string {
+	if config == nil {
+		return ""
+	}
+	if config.CertPath != "" {
+		return config.CertPath
+	}
+	if config.KeyPath != "" {
+		return strings.TrimSuffix(config.KeyPath, ".pub") + CertFileSuffix
+	}
+	return ""
+}
+
+// readCertExpiry parses an OpenSSH certificate file and returns its notAfter time.
+// ok is false when the file is missing, not a certificate, or never expires.
+func readCertExpiry(certPath string) (notAfter time.Time, ok bool, err error) {
+	data, err := os.ReadFile(certPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, false, nil
+		}
+		return time.Time{}, false, fmt.Errorf("failed to read certificate %s: %w", certPath, err)
+	}
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to parse certificate %s: %w", certPath, err)
+	}
+
+	cert, isCert := pub.(*ssh.Certificate)
+	if !isCert || cert.ValidBefore == ssh.CertTimeInfinity {
+		return time.Time{}, false, nil
+	}
+
+	return time.Unix(int64(cert.ValidBefore), 0), true, nil
+}
+
+// isCertExpiringSoon reports whether the profile's SSH certificate expires (or has expired) within warningDays
+func isCertExpiringSoon(profile *Profile, warningDays int) bool {
+	if profile.Type != ProfileTypeSSH || profile.SSHConfig == nil {
+		return false
+	}
+
+	certPath := sshCertPath(profile.SSHConfig)
+	if certPath == "" {
+		return false
+	}
+
+	notAfter, ok, err := readCertExpiry(certPath)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	return time.Until(notAfter) <= time.Duration(warningDays)*24*time.Hour
+}
+
+// getCertExpiringProfileIDs returns the IDs of profiles in the cert-expiring virtual folder
+func (a *App) getCertExpiringProfileIDs() []string {
+	var folder *VirtualFolder
+	a.profiles.mutex.RLock()
+	for _, vf := range a.profiles.virtualFolders {
+		if vf.Filter.Type == CertExpiryFilterType {
+			folder = vf
+			break
+		}
+	}
+	a.profiles.mutex.RUnlock()
+
+	ids := []string{}
+	if folder == nil {
+		return ids
+	}
+	for _, profile := range a.getVirtualFolderProfiles(folder) {
+		ids = append(ids, profile.ID)
+	}
+	return ids
+}
+
+// emitCertExpiryFolderUpdated tells the frontend to refresh the cert-expiring virtual folder
+func (a *App) emitCertExpiryFolderUpdated() {
+	if a.ctx == nil {
+		return
+	}
+
+	wailsRuntime.EventsEmit(a.ctx, CertExpiryEvent, map[string]interface{}{
+		"folderId":   CertExpiryVirtualFolderID,
+		"profileIds": a.getCertExpiringProfileIDs(),
+	})
+}
+
+// startCertExpiryWatcher re-evaluates certificate expiry once a day, since certificates
+// approach expiry without any profile file changing
+func (a *App) startCertExpiryWatcher() {
+	watcher := &certExpiryWatcher{stopChan: make(chan struct{})}
+	a.profiles.resourceManager.Register(watcher)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Printf("Certificate expiry watcher panic: %v\n", r)
+			}
+		}()
+
+		ticker := time.NewTicker(CertExpiryCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-watcher.stopChan:
+				return
+			case <-ticker.C:
+				a.emitCertExpiryFolderUpdated()
+			}
+		}
+	}()
+}
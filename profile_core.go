@@ -5,7 +5,6 @@ import (
 	"encoding/hex"
 	"fmt"
 	"os"
-	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
@@ -30,6 +29,10 @@ func (e *ProfileError) Error() string {
 	return fmt.Sprintf("profile %s %s %s: %v", e.Op, e.ProfileID, e.Path, e.Err)
 }
 
+func (e *ProfileError) Unwrap() error {
+	return e.Err
+}
+
 // sanitizeFilename ensures a filename is safe for all operating systems
 func sanitizeFilename(filename string) string {
 	// Replace spaces with underscores
@@ -63,19 +66,9 @@ func sanitizeFilename(filename string) string {
 	return filename
 }
 
-// validateProfilePath validates that the path is within the profiles directory
+// validateProfilePath validates that the path is within one of the profile source directories
 func (a *App) validateProfilePath(path string) error {
-	profilesDir, err := a.GetProfilesDirectory()
-	if err != nil {
-		return err
-	}
-
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return err
-	}
-
-	if !strings.HasPrefix(absPath, profilesDir) {
+	if _, ok := a.sourceForPath(path); !ok {
 		return fmt.Errorf("invalid profile path: outside profiles directory")
 	}
 
@@ -322,7 +315,7 @@ func (a *App) DeleteProfile(id string) error {
 	a.profiles.mutex.Lock()
 	defer a.profiles.mutex.Unlock()
 
-	profile, exists := a.profiles.profiles[id]
+	_, exists := a.profiles.profiles[id]
 	if !exists {
 		return &ProfileError{
 			Op:        "delete",
@@ -332,10 +325,7 @@ func (a *App) DeleteProfile(id string) error {
 	}
 
 	// Find and delete the profile file
-	filename := fmt.Sprintf("%s-%s.yaml", profile.Name, profile.ID)
-	filename = sanitizeFilename(filename)
-
-	profilesDir, err := a.GetProfilesDirectory()
+	profilesDir, err := a.writableProfileDirLockFree(id)
 	if err != nil {
 		return &ProfileError{
 			Op:        "delete",
@@ -345,7 +335,7 @@ func (a *App) DeleteProfile(id string) error {
 		}
 	}
 
-	filePath := filepath.Join(profilesDir, filename)
+	filePath := a.profileFilePath(profilesDir, id)
 
 	// Validate path before deletion
 	if err := a.validateProfilePath(filePath); err != nil {
@@ -368,6 +358,7 @@ func (a *App) DeleteProfile(id string) error {
 
 	// Remove from memory
 	delete(a.profiles.profiles, id)
+	delete(a.profiles.profileSources, id)
 
 	return nil
 }
@@ -377,7 +368,7 @@ func (a *App) DeleteProfileFolder(id string) error {
 	a.profiles.mutex.Lock()
 	defer a.profiles.mutex.Unlock()
 
-	folder, exists := a.profiles.profileFolders[id]
+	_, exists := a.profiles.profileFolders[id]
 	if !exists {
 		return &ProfileError{
 			Op:        "delete",
@@ -387,10 +378,7 @@ func (a *App) DeleteProfileFolder(id string) error {
 	}
 
 	// Find and delete the folder file
-	filename := fmt.Sprintf("folder-%s-%s.yaml", folder.Name, folder.ID)
-	filename = sanitizeFilename(filename)
-
-	profilesDir, err := a.GetProfilesDirectory()
+	profilesDir, err := a.writableFolderDirLockFree(id)
 	if err != nil {
 		return &ProfileError{
 			Op:        "delete",
@@ -400,7 +388,7 @@ func (a *App) DeleteProfileFolder(id string) error {
 		}
 	}
 
-	filePath := filepath.Join(profilesDir, filename)
+	filePath := a.folderFilePath(profilesDir, id)
 
 	// Validate path before deletion
 	if err := a.validateProfilePath(filePath); err != nil {
@@ -434,6 +422,7 @@ func (a *App) DeleteProfileFolder(id string) error {
 
 	// Remove from memory
 	delete(a.profiles.profileFolders, id)
+	delete(a.profiles.folderSources, id)
 
 	return nil
 }
@@ -488,6 +477,37 @@ func (a *App) GetFolderByID(folderID string) (*ProfileFolder, error) {
 	return folder, nil
 }
 
+// Profile returns a copy of a loaded profile (ProfileStore)
+func (pm *ProfileManager) Profile(id string) (*Profile, bool) {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	profile, exists := pm.profiles[id]
+	if !exists {
+		return nil, false
+	}
+	return cloneProfile(profile), true
+}
+
+// Profiles returns copies of all loaded profiles in no particular order (ProfileStore)
+func (pm *ProfileManager) Profiles() []*Profile {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	profiles := make([]*Profile, 0, len(pm.profiles))
+	for _, profile := range pm.profiles {
+		profiles = append(profiles, cloneProfile(profile))
+	}
+	return profiles
+}
+
+// FolderCount returns the number of loaded profile folders (ProfileStore)
+func (pm *ProfileManager) FolderCount() int {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+	return len(pm.profileFolders)
+}
+
 // SaveProfile saves a profile to file (wrapper for external compatibility)
 func (a *App) SaveProfile(profile *Profile) error {
 	a.profiles.mutex.Lock()
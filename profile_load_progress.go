@@ -0,0 +1,161 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Profile load progress reporting
+const (
+	ProfileLoadProgressInterval = 100                    // Files between profiles:loading events
+	ProfileProgressMinInterval  = 250 * time.Millisecond // Least time between two progress events
+)
+
+// ProfileLoadError is a profile or folder file that could not be loaded
+type ProfileLoadError struct {
+	Path   string `json:"path"`
+	Source string `json:"source"` // Label of the profile source the file is in
+	Kind   string `json:"kind"`   // "profile" or "folder"; empty when the file couldn't be inspected
+	Error  string `json:"error"`
+}
+
+// ProfileLoadSummary is the payload of profiles:load-complete
+type ProfileLoadSummary struct {
+	Profiles   int                `json:"profiles"`
+	Folders    int                `json:"folders"`
+	Sources    int                `json:"sources"`
+	Parsed     int                `json:"parsed"`
+	Failed     int                `json:"failed"`
+	Collisions int                `json:"collisions"`
+	DurationMs int64              `json:"durationMs"`
+	Errors     []ProfileLoadError `json:"errors"`
+	Error      string             `json:"error,omitempty"` // Set when the primary source couldn't be loaded
+}
+
+// profileLoadProgress counts the files of one LoadProfiles run and emits profiles:loading
+// every ProfileLoadProgressInterval files, at most once per ProfileProgressMinInterval.
+// Workers report concurrently.
+type profileLoadProgress struct {
+	emitter    Emitter
+	started    time.Time
+	mu         sync.Mutex
+	source     string
+	discovered int
+	parsed     int
+	failed     int
+	errors     []ProfileLoadError
+	lastEmit   time.Time
+}
+
+func newProfileLoadProgress(emitter Emitter) *profileLoadProgress {
+	return &profileLoadProgress{emitter: emitter, started: time.Now(), errors: []ProfileLoadError{}}
+}
+
+// setSource names the source whose files are being walked
+func (p *profileLoadProgress) setSource(label string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.source = label
+}
+
+// found counts a profile or folder file discovered by the walk
+func (p *profileLoadProgress) found() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.discovered++
+	p.maybeEmitLocked(p.discovered)
+}
+
+// loaded counts a file read and parsed by a worker
+func (p *profileLoadProgress) loaded(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		p.failed++
+	} else {
+		p.parsed++
+	}
+	p.maybeEmitLocked(p.parsed + p.failed)
+}
+
+// fail records a file that will be missing from the sidebar, and why
+func (p *profileLoadProgress) fail(loadErr ProfileLoadError) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.errors = append(p.errors, loadErr)
+}
+
+// maybeEmitLocked emits profiles:loading when count reaches a multiple of the interval.
+// Caller must hold p.mu.
+func (p *profileLoadProgress) maybeEmitLocked(count int) {
+	if count%ProfileLoadProgressInterval != 0 || time.Since(p.lastEmit) < ProfileProgressMinInterval {
+		return
+	}
+	p.lastEmit = time.Now()
+	p.emitter.Emit("profiles:loading", map[string]interface{}{
+		"source":     p.source,
+		"discovered": p.discovered,
+		"parsed":     p.parsed,
+		"failed":     p.failed,
+	})
+}
+
+// loadErrors returns a copy of the recorded failures
+func (p *profileLoadProgress) loadErrors() []ProfileLoadError {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	errs := make([]ProfileLoadError, len(p.errors))
+	copy(errs, p.errors)
+	return errs
+}
+
+// complete emits profiles:load-complete with the totals of the run
+func (p *profileLoadProgress) complete(summary ProfileLoadSummary) {
+	p.mu.Lock()
+	summary.Parsed = p.parsed
+	summary.Failed = len(p.errors)
+	summary.DurationMs = time.Since(p.started).Milliseconds()
+	p.mu.Unlock()
+	summary.Errors = p.loadErrors()
+	p.emitter.Emit("profiles:load-complete", summary)
+}
+
+// profileOperationProgress emits profiles:operation-progress for a bulk profile operation,
+// at most once per ProfileProgressMinInterval apart from the first and last item
+type profileOperationProgress struct {
+	emitter   Emitter
+	operation string
+	total     int
+	processed int
+	lastEmit  time.Time
+}
+
+func newProfileOperationProgress(emitter Emitter, operation string, total int) *profileOperationProgress {
+	return &profileOperationProgress{emitter: emitter, operation: operation, total: total}
+}
+
+// step counts an item as processed; current names it
+func (p *profileOperationProgress) step(current string) {
+	p.processed++
+	if p.processed != 1 && p.processed != p.total && time.Since(p.lastEmit) < ProfileProgressMinInterval {
+		return
+	}
+	p.lastEmit = time.Now()
+	p.emitter.Emit("profiles:operation-progress", map[string]interface{}{
+		"operation": p.operation,
+		"processed": p.processed,
+		"total":     p.total,
+		"current":   current,
+	})
+}
+
+// GetProfileLoadErrors returns the profile and folder files the last load could not read or
+// parse, with the reason, so broken YAML can be found and fixed
+func (a *App) GetProfileLoadErrors() []ProfileLoadError {
+	a.profiles.mutex.RLock()
+	defer a.profiles.mutex.RUnlock()
+
+	errs := make([]ProfileLoadError, len(a.profiles.loadErrors))
+	copy(errs, a.profiles.loadErrors)
+	return errs
+}
@@ -16,8 +16,27 @@ const (
 	MetricsFilename     = "metrics.yaml"
 	MetricsUpdatePeriod = 5 * time.Minute
 	TopItemsLimit       = 10
+	GlobalStatsCacheTTL = 30 * time.Second
 )
 
+// GlobalProfileStats summarizes usage across all profiles for the statistics dashboard
+type GlobalProfileStats struct {
+	TotalProfiles          int      `json:"totalProfiles"`
+	TotalFolders           int      `json:"totalFolders"`
+	TotalSSHProfiles       int      `json:"totalSSHProfiles"`
+	TotalLocalProfiles     int      `json:"totalLocalProfiles"`
+	TotalCustomProfiles    int      `json:"totalCustomProfiles"`
+	TotalNomadProfiles     int      `json:"totalNomadProfiles"`
+	TotalFavorites         int      `json:"totalFavorites"`
+	TotalUsageCount        int64    `json:"totalUsageCount"`
+	MostUsedProfile        *Profile `json:"mostUsedProfile,omitempty"`
+	LeastUsedProfile       *Profile `json:"leastUsedProfile,omitempty"` // Among profiles used at least once
+	OldestProfile          *Profile `json:"oldestProfile,omitempty"`
+	NewestProfile          *Profile `json:"newestProfile,omitempty"`
+	ProfilesUsedToday      int      `json:"profilesUsedToday"`
+	AverageUsagePerProfile float64  `json:"averageUsagePerProfile"`
+}
+
 // updateProfileUsage increments usage statistics for a profile with safety checks
 func (a *App) updateProfileUsage(profileID string) error {
 	if profileID == "" {
@@ -39,6 +58,13 @@ func (a *App) updateProfileUsage(profileID string) error {
 	profile.LastUsed = time.Now()
 	profile.UsageCount++
 
+	// Read-only sources never receive usage writes - keep the counters in local metrics
+	if a.isProfileReadOnlyLockFree(profileID) {
+		a.recordReadOnlyUsageLockFree(profile)
+		go a.saveMetrics()
+		return nil
+	}
+
 	// Save the updated profile using internal function to avoid deadlock
 	err := a.saveProfileInternal(profile)
 	if err == nil {
@@ -67,6 +93,7 @@ func (a *App) saveMetrics() error {
 
 	a.profiles.metrics.TotalProfiles = len(a.profiles.profiles)
 	a.profiles.metrics.TotalFolders = len(a.profiles.profileFolders)
+	a.profiles.metrics.TransferTotals = transferTotalsGlobal.totals()
 	a.profiles.metrics.LastSync = time.Now()
 
 	// Collect all profiles for analysis
@@ -173,6 +200,7 @@ func (a *App) loadMetrics() error {
 	if err := yaml.Unmarshal(data, a.profiles.metrics); err != nil {
 		return fmt.Errorf("failed to parse metrics YAML: %w", err)
 	}
+	setGlobalTransferTotals(a.profiles.metrics.TransferTotals)
 
 	return nil
 }
@@ -239,11 +267,8 @@ func (a *App) GetTopProfiles(limit int) []*Profile {
 		limit = TopItemsLimit
 	}
 
-	a.profiles.mutex.RLock()
-	defer a.profiles.mutex.RUnlock()
-
 	var profiles []*Profile
-	for _, profile := range a.profiles.profiles {
+	for _, profile := range a.SnapshotProfiles() {
 		if profile.UsageCount > 0 {
 			profiles = append(profiles, profile)
 		}
@@ -271,13 +296,10 @@ func (a *App) GetRecentProfiles(limit int, days int) []*Profile {
 		days = 30
 	}
 
-	a.profiles.mutex.RLock()
-	defer a.profiles.mutex.RUnlock()
-
 	cutoffTime := time.Now().AddDate(0, 0, -days)
 	var profiles []*Profile
 
-	for _, profile := range a.profiles.profiles {
+	for _, profile := range a.SnapshotProfiles() {
 		if !profile.LastUsed.IsZero() && profile.LastUsed.After(cutoffTime) {
 			profiles = append(profiles, profile)
 		}
@@ -377,12 +399,104 @@ func (a *App) GetUsageTrends(days int) map[string]interface{} {
 	return trends
 }
 
+// GetGlobalProfileStats returns usage statistics across all profiles, cached for GlobalStatsCacheTTL
+func (a *App) GetGlobalProfileStats() (GlobalProfileStats, error) {
+	if a.profiles == nil {
+		return GlobalProfileStats{}, fmt.Errorf("profile manager not initialized")
+	}
+
+	a.profiles.globalStatsMutex.Lock()
+	defer a.profiles.globalStatsMutex.Unlock()
+
+	if a.profiles.globalStats != nil && time.Since(a.profiles.globalStatsTime) < GlobalStatsCacheTTL {
+		return *a.profiles.globalStats, nil
+	}
+
+	stats := computeGlobalProfileStats(a.profiles, time.Now())
+	a.profiles.globalStats = &stats
+	a.profiles.globalStatsTime = time.Now()
+	return stats, nil
+}
+
+// computeGlobalProfileStats aggregates usage over the profiles of a store. The store returns
+// copies, so the profiles in the result are safe to hand to callers.
+func computeGlobalProfileStats(store ProfileStore, now time.Time) GlobalProfileStats {
+	profiles := store.Profiles()
+	stats := GlobalProfileStats{
+		TotalProfiles: len(profiles),
+		TotalFolders:  store.FolderCount(),
+	}
+
+	year, month, day := now.Date()
+	startOfDay := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+
+	var mostUsed, leastUsed, oldest, newest *Profile
+	for _, profile := range profiles {
+		switch profile.Type {
+		case ProfileTypeSSH:
+			stats.TotalSSHProfiles++
+		case ProfileTypeLocal:
+			stats.TotalLocalProfiles++
+		case ProfileTypeCustom:
+			stats.TotalCustomProfiles++
+		case ProfileTypeNomadExec:
+			stats.TotalNomadProfiles++
+		}
+
+		if profile.IsFavorite {
+			stats.TotalFavorites++
+		}
+		stats.TotalUsageCount += int64(profile.UsageCount)
+		if !profile.LastUsed.Before(startOfDay) {
+			stats.ProfilesUsedToday++
+		}
+
+		if profile.UsageCount > 0 {
+			if mostUsed == nil || profile.UsageCount > mostUsed.UsageCount {
+				mostUsed = profile
+			}
+			if leastUsed == nil || profile.UsageCount < leastUsed.UsageCount {
+				leastUsed = profile
+			}
+		}
+		if !profile.Created.IsZero() {
+			if oldest == nil || profile.Created.Before(oldest.Created) {
+				oldest = profile
+			}
+			if newest == nil || profile.Created.After(newest.Created) {
+				newest = profile
+			}
+		}
+	}
+
+	if stats.TotalProfiles > 0 {
+		stats.AverageUsagePerProfile = float64(stats.TotalUsageCount) / float64(stats.TotalProfiles)
+	}
+
+	stats.MostUsedProfile = mostUsed
+	stats.LeastUsedProfile = leastUsed
+	stats.OldestProfile = oldest
+	stats.NewestProfile = newest
+
+	return stats
+}
+
+// invalidateGlobalProfileStats drops the cached GetGlobalProfileStats result
+func (a *App) invalidateGlobalProfileStats() {
+	a.profiles.globalStatsMutex.Lock()
+	a.profiles.globalStats = nil
+	a.profiles.globalStatsMutex.Unlock()
+}
+
 // ResetMetrics clears all metrics data
 func (a *App) ResetMetrics() error {
+	defer a.invalidateGlobalProfileStats()
+
 	a.profiles.mutex.Lock()
 	defer a.profiles.mutex.Unlock()
 
 	a.profiles.metrics = &ProfileMetrics{}
+	setGlobalTransferTotals(TransferTotals{})
 
 	// Also reset usage counters in profiles
 	for _, profile := range a.profiles.profiles {
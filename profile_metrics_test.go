@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetGlobalProfileStats(t *testing.T) {
+	app := NewApp()
+	now := time.Now()
+	app.profiles.profiles = map[string]*Profile{
+		"a": {ID: "a", Type: ProfileTypeSSH, UsageCount: 9, LastUsed: now, Created: now.AddDate(0, -6, 0), IsFavorite: true},
+		"b": {ID: "b", Type: ProfileTypeSSH, UsageCount: 2, LastUsed: now.AddDate(0, 0, -3), Created: now.AddDate(0, -1, 0)},
+		"c": {ID: "c", Type: ProfileTypeLocal, Created: now},
+		"d": {ID: "d", Type: ProfileTypeCustom, UsageCount: 1, LastUsed: now, Created: now.AddDate(0, 0, -1)},
+	}
+	app.profiles.profileFolders = map[string]*ProfileFolder{"f": {ID: "f"}}
+
+	stats, err := app.GetGlobalProfileStats()
+	if err != nil {
+		t.Fatalf("GetGlobalProfileStats() returned error: %v", err)
+	}
+	if stats.TotalProfiles != 4 || stats.TotalFolders != 1 || stats.TotalSSHProfiles != 2 ||
+		stats.TotalLocalProfiles != 1 || stats.TotalCustomProfiles != 1 || stats.TotalFavorites != 1 {
+		t.Errorf("unexpected counts: %+v", stats)
+	}
+	if stats.TotalUsageCount != 12 || stats.AverageUsagePerProfile != 3 || stats.ProfilesUsedToday != 2 {
+		t.Errorf("unexpected usage: total=%d average=%v today=%d", stats.TotalUsageCount, stats.AverageUsagePerProfile, stats.ProfilesUsedToday)
+	}
+	if stats.MostUsedProfile.ID != "a" || stats.LeastUsedProfile.ID != "d" || stats.OldestProfile.ID != "a" || stats.NewestProfile.ID != "c" {
+		t.Errorf("most=%s least=%s oldest=%s newest=%s", stats.MostUsedProfile.ID, stats.LeastUsedProfile.ID, stats.OldestProfile.ID, stats.NewestProfile.ID)
+	}
+
+	// Results are cached until invalidated
+	app.profiles.profiles["a"].UsageCount = 100
+	if cached, _ := app.GetGlobalProfileStats(); cached.TotalUsageCount != 12 {
+		t.Errorf("cached TotalUsageCount = %d, want 12", cached.TotalUsageCount)
+	}
+	app.invalidateGlobalProfileStats()
+	if fresh, _ := app.GetGlobalProfileStats(); fresh.TotalUsageCount != 103 {
+		t.Errorf("fresh TotalUsageCount = %d, want 103", fresh.TotalUsageCount)
+	}
+}
+
+// fakeProfileStore serves fixed profiles
+type fakeProfileStore struct {
+	profiles []*Profile
+	folders  int
+}
+
+func (s fakeProfileStore) Profile(id string) (*Profile, bool) {
+	for _, profile := range s.profiles {
+		if profile.ID == id {
+			return profile, true
+		}
+	}
+	return nil, false
+}
+
+func (s fakeProfileStore) Profiles() []*Profile { return s.profiles }
+func (s fakeProfileStore) FolderCount() int     { return s.folders }
+
+func TestComputeGlobalProfileStatsFromStore(t *testing.T) {
+	now := time.Date(2024, 5, 10, 15, 0, 0, 0, time.UTC)
+	store := fakeProfileStore{folders: 3, profiles: []*Profile{
+		{ID: "unused", Type: ProfileTypeNomadExec, Created: now.AddDate(-1, 0, 0)},
+		{ID: "yesterday", Type: ProfileTypeSSH, UsageCount: 4, LastUsed: now.Add(-16 * time.Hour)},
+	}}
+
+	stats := computeGlobalProfileStats(store, now)
+	if stats.TotalProfiles != 2 || stats.TotalFolders != 3 || stats.TotalNomadProfiles != 1 || stats.ProfilesUsedToday != 0 {
+		t.Errorf("unexpected counts: %+v", stats)
+	}
+	// Unused profiles don't count as least used, and profiles without a creation time are skipped
+	if stats.LeastUsedProfile.ID != "yesterday" || stats.OldestProfile.ID != "unused" || stats.NewestProfile.ID != "unused" {
+		t.Errorf("least=%s oldest=%s newest=%s", stats.LeastUsedProfile.ID, stats.OldestProfile.ID, stats.NewestProfile.ID)
+	}
+
+	if empty := computeGlobalProfileStats(fakeProfileStore{}, now); empty.MostUsedProfile != nil || empty.AverageUsagePerProfile != 0 {
+		t.Errorf("empty store: %+v", empty)
+	}
+}
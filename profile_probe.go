@@ -0,0 +1,344 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Profile reachability probe constants
+const (
+	ProfileProbeInterval      = 5 * time.Minute  // Base interval between probes of one profile
+	ProfileProbeJitter        = 0.2              // Fraction of the interval added or removed at random
+	ProfileProbeMaxBackoff    = 2 * time.Hour    // Longest wait after repeated failures
+	ProfileProbeTimeout       = 5 * time.Second  // TCP connect timeout
+	ProfileProbeCheckInterval = 30 * time.Second // How often due probes are looked for
+	ProfileProbeConcurrency   = 4                // Probes running at the same time
+	ProfileProbeHistoryDays   = 30               // Days of success ratios kept in metrics
+	ProfileProbeDayFormat     = "2006-01-02"
+)
+
+// ProfileProbeStatus is the last reachability result for a profile
+type ProfileProbeStatus struct {
+	ProfileID           string    `json:"profileId"`
+	Reachable           bool      `json:"reachable"`
+	LatencyMs           int64     `json:"latencyMs"`
+	LastChecked         time.Time `json:"lastChecked"`
+	LastError           string    `json:"lastError,omitempty"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	NextProbe           time.Time `json:"nextProbe"`
+	SuccessRatio        float64   `json:"successRatio"`     // Today's successful probes / attempts, -1 if none yet
+	Paused              string    `json:"paused,omitempty"` // Why probing is currently paused, if it is
+}
+
+// ProbeDayStats counts probe attempts for one profile on one day
+type ProbeDayStats struct {
+	Attempts  int `yaml:"attempts" json:"attempts"`
+	Successes int `yaml:"successes" json:"successes"`
+}
+
+// ProfileProbeManager runs the opt-in TCP reachability probes for the profile tree.
+// Probes only open and close a TCP connection; no SSH handshake or authentication is attempted.
+type ProfileProbeManager struct {
+	status   map[string]*ProfileProbeStatus
+	running  map[string]bool // Profiles with a probe in flight
+	metered  bool
+	offline  bool
+	dial     func(network, address string, timeout time.Duration) (net.Conn, error)
+	stopChan chan struct{}
+	stopOnce sync.Once
+	mutex    sync.RWMutex
+}
+
+// NewProfileProbeManager creates a prober with no results
+func NewProfileProbeManager() *ProfileProbeManager {
+	return &ProfileProbeManager{
+		status:   make(map[string]*ProfileProbeStatus),
+		running:  make(map[string]bool),
+		dial:     net.DialTimeout,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Close implements the Cleanup interface and stops the probe loop
+func (pm *ProfileProbeManager) Close() error {
+	pm.stopOnce.Do(func() {
+		close(pm.stopChan)
+	})
+	return nil
+}
+
+// pausedReason reports why probing is paused by the frontend's network state, or ""
+func (pm *ProfileProbeManager) pausedReason() string {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	switch {
+	case pm.offline:
+		return "offline"
+	case pm.metered:
+		return "metered"
+	default:
+		return ""
+	}
+}
+
+// profileProbeBackoff returns the wait before the next probe after the given number of
+// consecutive failures. The interval doubles per failure up to ProfileProbeMaxBackoff;
+// r in [0,1) spreads it by ±ProfileProbeJitter so probes of many profiles don't line up.
+func profileProbeBackoff(failures int, r float64) time.Duration {
+	delay := ProfileProbeInterval
+	for i := 0; i < failures && delay < ProfileProbeMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > ProfileProbeMaxBackoff {
+		delay = ProfileProbeMaxBackoff
+	}
+	return time.Duration(float64(delay) * (1 + ProfileProbeJitter*(2*r-1)))
+}
+
+// profileProbeAddress returns the host:port to probe for a profile, or false if it is not
+// probed. Only SSH profiles that opted in and connect straight to their host qualify: a
+// direct probe of a host reached through jump hosts or a proxy would go around them.
+// globalProxy is the proxy from the app settings.
+func profileProbeAddress(profile *Profile, globalProxy ProxyConfig) (string, bool) {
+	if profile == nil || !profile.ProbeEnabled || profile.Type != ProfileTypeSSH {
+		return "", false
+	}
+	if profile.SSHConfig == nil || profile.SSHConfig.Host == "" || len(profile.SSHConfig.JumpHosts) > 0 {
+		return "", false
+	}
+	port := profile.SSHConfig.Port
+	if port <= 0 {
+		port = 22
+	}
+	address := net.JoinHostPort(profile.SSHConfig.Host, strconv.Itoa(port))
+
+	proxy := profileProxyConfig(profile.SSHConfig, globalProxy)
+	if proxy.Mode == ProxyModeSystem {
+		resolved, err := systemProxyConfig(os.Getenv, address)
+		if err != nil {
+			return "", false
+		}
+		proxy = resolved
+	}
+	if proxy.Mode != "" && proxy.Mode != ProxyModeNone {
+		return "", false
+	}
+	return address, true
+}
+
+// profileProbesDisabled reports whether the global kill switch is set
+func (a *App) profileProbesDisabled() bool {
+	if a.config == nil {
+		return true
+	}
+	a.config.mutex.RLock()
+	defer a.config.mutex.RUnlock()
+	return a.config.config == nil || a.config.config.DisableProfileProbes
+}
+
+// SetProbeNetworkState is called by the frontend when the connection becomes metered or
+// goes offline; no probes run until both are clear again
+func (a *App) SetProbeNetworkState(metered, offline bool) {
+	a.probes.mutex.Lock()
+	changed := a.probes.metered != metered || a.probes.offline != offline
+	a.probes.metered = metered
+	a.probes.offline = offline
+	a.probes.mutex.Unlock()
+
+	if changed {
+		fmt.Printf("Profile probes: network state metered=%v offline=%v\n", metered, offline)
+	}
+}
+
+// GetProfileProbeStatus returns the last probe result for each requested profile that has one
+func (a *App) GetProfileProbeStatus(profileIDs []string) map[string]*ProfileProbeStatus {
+	paused := a.probes.pausedReason()
+	if a.profileProbesDisabled() {
+		paused = "disabled"
+	}
+
+	a.probes.mutex.RLock()
+	result := make(map[string]*ProfileProbeStatus, len(profileIDs))
+	for _, id := range profileIDs {
+		if status, exists := a.probes.status[id]; exists {
+			statusCopy := *status
+			statusCopy.Paused = paused
+			result[id] = &statusCopy
+		}
+	}
+	a.probes.mutex.RUnlock()
+
+	today := time.Now().Format(ProfileProbeDayFormat)
+	a.profiles.mutex.RLock()
+	defer a.profiles.mutex.RUnlock()
+	for id, status := range result {
+		status.SuccessRatio = -1
+		if a.profiles.metrics == nil {
+			continue
+		}
+		if day := a.profiles.metrics.ProbeHistory[id][today]; day != nil && day.Attempts > 0 {
+			status.SuccessRatio = float64(day.Successes) / float64(day.Attempts)
+		}
+	}
+	return result
+}
+
+// startProfileProber starts the loop that probes due profiles in the background
+func (a *App) startProfileProber() {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Printf("Profile prober panic: %v\n", r)
+			}
+		}()
+
+		ticker := time.NewTicker(ProfileProbeCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-a.probes.stopChan:
+				return
+			case <-ticker.C:
+				if a.runDueProfileProbes() == 0 {
+					continue
+				}
+				if err := a.saveMetrics(); err != nil {
+					fmt.Printf("Warning: Failed to save probe metrics: %v\n", err)
+				}
+			}
+		}
+	}()
+}
+
+// runDueProfileProbes probes every eligible profile whose next probe time has passed
+// and returns how many were probed
+func (a *App) runDueProfileProbes() int {
+	if a.profileProbesDisabled() || a.probes.pausedReason() != "" {
+		return 0
+	}
+
+	// Collect targets first so no lock is held while dialing
+	targets := make(map[string]string)
+	globalProxy := a.globalProxyConfig()
+	a.profiles.mutex.RLock()
+	for id, profile := range a.profiles.profiles {
+		if address, ok := profileProbeAddress(profile, globalProxy); ok {
+			targets[id] = address
+		}
+	}
+	a.profiles.mutex.RUnlock()
+
+	now := time.Now()
+	due := make(map[string]string)
+	a.probes.mutex.Lock()
+	for id := range a.probes.status {
+		// Forget profiles that were deleted or opted out
+		if _, ok := targets[id]; !ok {
+			delete(a.probes.status, id)
+		}
+	}
+	for id, address := range targets {
+		status, exists := a.probes.status[id]
+		if a.probes.running[id] || (exists && now.Before(status.NextProbe)) {
+			continue
+		}
+		a.probes.running[id] = true
+		due[id] = address
+	}
+	a.probes.mutex.Unlock()
+
+	if len(due) == 0 {
+		return 0
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, ProfileProbeConcurrency)
+	for id, address := range due {
+		wg.Add(1)
+		go func(id, address string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			a.probeProfile(id, address)
+		}(id, address)
+	}
+	wg.Wait()
+	return len(due)
+}
+
+// probeProfile opens and immediately closes a TCP connection to address and records the result
+func (a *App) probeProfile(profileID, address string) {
+	start := time.Now()
+	conn, err := a.probes.dial("tcp", address, ProfileProbeTimeout)
+	latency := time.Since(start)
+	if err == nil {
+		conn.Close()
+	}
+
+	a.probes.mutex.Lock()
+	delete(a.probes.running, profileID)
+	status, exists := a.probes.status[profileID]
+	if !exists {
+		status = &ProfileProbeStatus{ProfileID: profileID}
+		a.probes.status[profileID] = status
+	}
+	status.LastChecked = start
+	status.Reachable = err == nil
+	if err == nil {
+		status.LatencyMs = latency.Milliseconds()
+		status.LastError = ""
+		status.ConsecutiveFailures = 0
+	} else {
+		status.LatencyMs = 0
+		status.LastError = err.Error()
+		status.ConsecutiveFailures++
+	}
+	status.NextProbe = start.Add(profileProbeBackoff(status.ConsecutiveFailures, rand.Float64()))
+	a.probes.mutex.Unlock()
+
+	a.profiles.mutex.Lock()
+	a.recordProbeResultLockFree(profileID, start, err == nil)
+	a.profiles.mutex.Unlock()
+}
+
+// recordProbeResultLockFree adds a probe result to the profile's daily counters in metrics and
+// drops days older than ProfileProbeHistoryDays. Caller must hold Lock on a.profiles.mutex.
+func (a *App) recordProbeResultLockFree(profileID string, when time.Time, success bool) {
+	if a.profiles.metrics == nil {
+		a.profiles.metrics = &ProfileMetrics{}
+	}
+	if a.profiles.metrics.ProbeHistory == nil {
+		a.profiles.metrics.ProbeHistory = make(map[string]map[string]*ProbeDayStats)
+	}
+	days, exists := a.profiles.metrics.ProbeHistory[profileID]
+	if !exists {
+		days = make(map[string]*ProbeDayStats)
+		a.profiles.metrics.ProbeHistory[profileID] = days
+	}
+
+	key := when.Format(ProfileProbeDayFormat)
+	day, exists := days[key]
+	if !exists {
+		day = &ProbeDayStats{}
+		days[key] = day
+	}
+	day.Attempts++
+	if success {
+		day.Successes++
+	}
+
+	// Day keys sort lexically, so a string comparison finds the expired ones
+	cutoff := when.AddDate(0, 0, -ProfileProbeHistoryDays).Format(ProfileProbeDayFormat)
+	for k := range days {
+		if k < cutoff {
+			delete(days, k)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProfileProbeBackoff(t *testing.T) {
+	if got := profileProbeBackoff(0, 0.5); got != ProfileProbeInterval {
+		t.Errorf("first probe interval = %v, want %v", got, ProfileProbeInterval)
+	}
+	if got := profileProbeBackoff(2, 0.5); got != 4*ProfileProbeInterval {
+		t.Errorf("after two failures = %v, want %v", got, 4*ProfileProbeInterval)
+	}
+	if got := profileProbeBackoff(50, 0.5); got != ProfileProbeMaxBackoff {
+		t.Errorf("backoff should cap at %v, got %v", ProfileProbeMaxBackoff, got)
+	}
+
+	low, high := profileProbeBackoff(0, 0), profileProbeBackoff(0, 0.999)
+	if low >= ProfileProbeInterval || high <= ProfileProbeInterval {
+		t.Errorf("jitter should spread around the interval, got %v..%v", low, high)
+	}
+}
+
+func TestProfileProbeEligibility(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://proxy.example:3128")
+	t.Setenv("NO_PROXY", "internal.example")
+
+	socks := &ProxyConfig{Mode: ProxyModeSOCKS5, Address: "proxy.example:1080"}
+	direct := &ProxyConfig{Mode: ProxyModeNone}
+	system := ProxyConfig{Mode: ProxyModeSystem}
+	tests := []struct {
+		name        string
+		profile     *Profile
+		globalProxy ProxyConfig
+		address     string
+	}{
+		{"opted in", &Profile{Type: ProfileTypeSSH, ProbeEnabled: true, SSHConfig: &SSHConfig{Host: "db1", Port: 2222}}, ProxyConfig{}, "db1:2222"},
+		{"default port", &Profile{Type: ProfileTypeSSH, ProbeEnabled: true, SSHConfig: &SSHConfig{Host: "::1"}}, ProxyConfig{}, "[::1]:22"},
+		{"not opted in", &Profile{Type: ProfileTypeSSH, SSHConfig: &SSHConfig{Host: "db1", Port: 22}}, ProxyConfig{}, ""},
+		{"local shell", &Profile{Type: ProfileTypeLocal, ProbeEnabled: true}, ProxyConfig{}, ""},
+		{"no host", &Profile{Type: ProfileTypeSSH, ProbeEnabled: true, SSHConfig: &SSHConfig{}}, ProxyConfig{}, ""},
+		{"jump host", &Profile{Type: ProfileTypeSSH, ProbeEnabled: true, SSHConfig: &SSHConfig{Host: "db1", JumpHosts: []JumpHostConfig{{Host: "bastion"}}}}, ProxyConfig{}, ""},
+		{"profile proxy", &Profile{Type: ProfileTypeSSH, ProbeEnabled: true, SSHConfig: &SSHConfig{Host: "db1", Proxy: socks}}, ProxyConfig{}, ""},
+		{"global proxy", &Profile{Type: ProfileTypeSSH, ProbeEnabled: true, SSHConfig: &SSHConfig{Host: "db1"}}, *socks, ""},
+		{"profile opts out of global proxy", &Profile{Type: ProfileTypeSSH, ProbeEnabled: true, SSHConfig: &SSHConfig{Host: "db1", Proxy: direct}}, *socks, "db1:22"},
+		{"system proxy", &Profile{Type: ProfileTypeSSH, ProbeEnabled: true, SSHConfig: &SSHConfig{Host: "db1"}}, system, ""},
+		{"system proxy skipped by NO_PROXY", &Profile{Type: ProfileTypeSSH, ProbeEnabled: true, SSHConfig: &SSHConfig{Host: "internal.example"}}, system, "internal.example:22"},
+	}
+	for _, tt := range tests {
+		address, ok := profileProbeAddress(tt.profile, tt.globalProxy)
+		if ok != (tt.address != "") || address != tt.address {
+			t.Errorf("%s: got %q %v, want %q", tt.name, address, ok, tt.address)
+		}
+	}
+}
+
+func TestProfileProbesRecordResultsAndPause(t *testing.T) {
+	app := NewApp()
+	app.profiles.profiles["up"] = &Profile{ID: "up", Type: ProfileTypeSSH, ProbeEnabled: true, SSHConfig: &SSHConfig{Host: "up.example", Port: 22}}
+	app.profiles.profiles["down"] = &Profile{ID: "down", Type: ProfileTypeSSH, ProbeEnabled: true, SSHConfig: &SSHConfig{Host: "down.example", Port: 22}}
+	app.profiles.profiles["quiet"] = &Profile{ID: "quiet", Type: ProfileTypeSSH, SSHConfig: &SSHConfig{Host: "quiet.example", Port: 22}}
+
+	var mu sync.Mutex
+	var dialed []string
+	app.probes.dial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		mu.Lock()
+		dialed = append(dialed, address)
+		mu.Unlock()
+		if address == "down.example:22" {
+			return nil, errors.New("connection refused")
+		}
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}
+
+	if n := app.runDueProfileProbes(); n != 2 {
+		t.Fatalf("probed %d profiles, want 2 (dialed %v)", n, dialed)
+	}
+	status := app.GetProfileProbeStatus([]string{"up", "down", "quiet"})
+	if _, exists := status["quiet"]; exists {
+		t.Error("profile without ProbeEnabled was probed")
+	}
+	if !status["up"].Reachable || status["up"].SuccessRatio != 1 {
+		t.Errorf("up: %+v", status["up"])
+	}
+	if status["down"].Reachable || status["down"].ConsecutiveFailures != 1 || status["down"].SuccessRatio != 0 {
+		t.Errorf("down: %+v", status["down"])
+	}
+
+	// Nothing is due again until the interval has passed
+	if n := app.runDueProfileProbes(); n != 0 {
+		t.Fatalf("probed %d profiles again immediately", n)
+	}
+
+	app.probes.mutex.Lock()
+	for _, s := range app.probes.status {
+		s.NextProbe = time.Time{}
+	}
+	app.probes.mutex.Unlock()
+
+	app.SetProbeNetworkState(true, false)
+	if n := app.runDueProfileProbes(); n != 0 {
+		t.Fatalf("probed %d profiles on a metered connection", n)
+	}
+	if got := app.GetProfileProbeStatus([]string{"up"})["up"].Paused; got != "metered" {
+		t.Errorf("paused = %q, want metered", got)
+	}
+
+	app.SetProbeNetworkState(false, false)
+	app.config.config.DisableProfileProbes = true
+	if n := app.runDueProfileProbes(); n != 0 {
+		t.Fatalf("probed %d profiles with probes disabled", n)
+	}
+}
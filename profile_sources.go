@@ -0,0 +1,527 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// PrimaryProfileSourceLabel is the label of the writable profiles directory (ProfilesPath)
+const PrimaryProfileSourceLabel = "Local"
+
+// ProfileSourceCollision records a profile or folder ID found in more than one source.
+// The first source loaded wins; the duplicate is skipped.
+type ProfileSourceCollision struct {
+	ID            string `json:"id"`
+	Kind          string `json:"kind"` // "profile" or "folder"
+	KeptSource    string `json:"keptSource"`
+	SkippedSource string `json:"skippedSource"`
+	SkippedPath   string `json:"skippedPath"`
+}
+
+// ReadOnlySourceError is returned when an edit targets a profile from a read-only source
+type ReadOnlySourceError struct {
+	ID     string
+	Source string
+	Path   string
+}
+
+func (e *ReadOnlySourceError) Error() string {
+	return fmt.Sprintf("profile source %q (%s) is read-only: cannot modify %s", e.Source, e.Path, e.ID)
+}
+
+// getProfileSources returns all profile sources with the primary source first.
+// Extra sources get absolute paths and, unless set, a label derived from the directory name.
+// Sources are identified by path; derived labels are made unique, so a directory named
+// like the primary source or like another one gets a numbered label.
+func (a *App) getProfileSources() ([]ProfileSource, error) {
+	primary, err := a.primaryProfileSource()
+	if err != nil {
+		return nil, err
+	}
+
+	sources := []ProfileSource{primary}
+	if a.config == nil || a.config.config == nil {
+		return sources, nil
+	}
+
+	// Labels set in the config are validated as unique; derived ones must not take them
+	usedLabels := map[string]bool{PrimaryProfileSourceLabel: true}
+	for _, source := range a.config.config.ProfileSources {
+		if source.Label != "" {
+			usedLabels[source.Label] = true
+		}
+	}
+
+	seen := map[string]bool{primary.Path: true}
+	for _, source := range a.config.config.ProfileSources {
+		if source.Path == "" {
+			continue
+		}
+
+		absPath, err := filepath.Abs(source.Path)
+		if err != nil {
+			fmt.Printf("Warning: Invalid profile source path %s: %v\n", source.Path, err)
+			continue
+		}
+		if seen[absPath] {
+			continue
+		}
+		seen[absPath] = true
+
+		source.Path = absPath
+		if source.Label == "" {
+			source.Label = uniqueProfileSourceLabel(filepath.Base(absPath), usedLabels)
+			usedLabels[source.Label] = true
+		}
+		sources = append(sources, source)
+	}
+
+	return sources, nil
+}
+
+// uniqueProfileSourceLabel returns label, or label with the lowest number suffix not in used
+func uniqueProfileSourceLabel(label string, used map[string]bool) string {
+	if !used[label] {
+		return label
+	}
+	for n := 2; ; n++ {
+		if numbered := fmt.Sprintf("%s (%d)", label, n); !used[numbered] {
+			return numbered
+		}
+	}
+}
+
+// primaryProfileSource returns the writable source backed by the profiles directory
+func (a *App) primaryProfileSource() (ProfileSource, error) {
+	profilesDir, err := a.GetProfilesDirectory()
+	if err != nil {
+		return ProfileSource{}, err
+	}
+
+	absPath, err := filepath.Abs(profilesDir)
+	if err != nil {
+		return ProfileSource{}, fmt.Errorf("failed to resolve profiles directory: %w", err)
+	}
+
+	return ProfileSource{Path: absPath, Label: PrimaryProfileSourceLabel}, nil
+}
+
+// profileSourceByLabel finds a source by label; an empty label selects the primary source
+func (a *App) profileSourceByLabel(label string) (ProfileSource, error) {
+	sources, err := a.getProfileSources()
+	if err != nil {
+		return ProfileSource{}, err
+	}
+
+	if label == "" {
+		return sources[0], nil
+	}
+
+	for _, source := range sources {
+		if source.Label == label {
+			return source, nil
+		}
+	}
+	return ProfileSource{}, fmt.Errorf("profile source not found: %s", label)
+}
+
+// sourceForPath returns the source that contains the given file (the most specific match wins)
+func (a *App) sourceForPath(path string) (ProfileSource, bool) {
+	sources, err := a.getProfileSources()
+	if err != nil {
+		return ProfileSource{}, false
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return ProfileSource{}, false
+	}
+
+	var best ProfileSource
+	found := false
+	for _, source := range sources {
+		if isWithinDir(absPath, source.Path) && len(source.Path) > len(best.Path) {
+			best = source
+			found = true
+		}
+	}
+	return best, found
+}
+
+// isWithinDir reports whether path is dir itself or located below it
+func isWithinDir(path, dir string) bool {
+	if path == dir {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// sourceForProfileLockFree returns the source a profile belongs to (primary if untracked).
+// Caller must hold at least RLock on a.profiles.mutex.
+func (a *App) sourceForProfileLockFree(profileID string) (ProfileSource, error) {
+	if source, exists := a.profiles.profileSources[profileID]; exists {
+		return source, nil
+	}
+	return a.primaryProfileSource()
+}
+
+// sourceForFolderLockFree returns the source a folder belongs to (primary if untracked).
+// Caller must hold at least RLock on a.profiles.mutex.
+func (a *App) sourceForFolderLockFree(folderID string) (ProfileSource, error) {
+	if source, exists := a.profiles.folderSources[folderID]; exists {
+		return source, nil
+	}
+	return a.primaryProfileSource()
+}
+
+// isPrimaryProfileSource reports whether source is the primary profiles directory
+func (a *App) isPrimaryProfileSource(source ProfileSource) bool {
+	primary, err := a.primaryProfileSource()
+	return err == nil && source.Path == primary.Path
+}
+
+// profileSourcePathLockFree returns the directory of the source a profile belongs to.
+// Caller must hold at least RLock on a.profiles.mutex.
+func (a *App) profileSourcePathLockFree(profileID string) string {
+	source, err := a.sourceForProfileLockFree(profileID)
+	if err != nil {
+		return ""
+	}
+	return source.Path
+}
+
+// folderSourcePathLockFree returns the directory of the source a folder belongs to.
+// Caller must hold at least RLock on a.profiles.mutex.
+func (a *App) folderSourcePathLockFree(folderID string) string {
+	source, err := a.sourceForFolderLockFree(folderID)
+	if err != nil {
+		return ""
+	}
+	return source.Path
+}
+
+// profileSourceLabelLockFree returns the source label of a profile.
+// Caller must hold at least RLock on a.profiles.mutex.
+func (a *App) profileSourceLabelLockFree(profileID string) string {
+	if source, exists := a.profiles.profileSources[profileID]; exists {
+		return source.Label
+	}
+	return PrimaryProfileSourceLabel
+}
+
+// folderSourceLabelLockFree returns the source label of a folder.
+// Caller must hold at least RLock on a.profiles.mutex.
+func (a *App) folderSourceLabelLockFree(folderID string) string {
+	if source, exists := a.profiles.folderSources[folderID]; exists {
+		return source.Label
+	}
+	return PrimaryProfileSourceLabel
+}
+
+// isProfileReadOnlyLockFree reports whether a profile comes from a read-only source.
+// Caller must hold at least RLock on a.profiles.mutex.
+func (a *App) isProfileReadOnlyLockFree(profileID string) bool {
+	source, exists := a.profiles.profileSources[profileID]
+	return exists && source.ReadOnly
+}
+
+// writableProfileDirLockFree returns the directory a profile is stored in, rejecting read-only sources.
+// Caller must hold at least RLock on a.profiles.mutex.
+func (a *App) writableProfileDirLockFree(profileID string) (string, error) {
+	source, err := a.sourceForProfileLockFree(profileID)
+	if err != nil {
+		return "", err
+	}
+	if source.ReadOnly {
+		return "", &ReadOnlySourceError{ID: profileID, Source: source.Label, Path: source.Path}
+	}
+	return source.Path, nil
+}
+
+// writableFolderDirLockFree returns the directory a folder is stored in, rejecting read-only sources.
+// Caller must hold at least RLock on a.profiles.mutex.
+func (a *App) writableFolderDirLockFree(folderID string) (string, error) {
+	source, err := a.sourceForFolderLockFree(folderID)
+	if err != nil {
+		return "", err
+	}
+	if source.ReadOnly {
+		return "", &ReadOnlySourceError{ID: folderID, Source: source.Label, Path: source.Path}
+	}
+	return source.Path, nil
+}
+
+// registerProfileLockFree stores a loaded profile, refusing IDs already owned by another source.
+// Caller must hold Lock on a.profiles.mutex.
+func (a *App) registerProfileLockFree(profile *Profile, source ProfileSource, path string) bool {
+	if _, exists := a.profiles.profiles[profile.ID]; exists && a.profileSourcePathLockFree(profile.ID) != source.Path {
+		a.addSourceCollisionLockFree(profile.ID, "profile", a.profileSourceLabelLockFree(profile.ID), source.Label, path)
+		return false
+	}
+
+	if a.isPrimaryProfileSource(source) {
+		delete(a.profiles.profileSources, profile.ID)
+	} else {
+		a.profiles.profileSources[profile.ID] = source
+	}
+
+	if source.ReadOnly {
+		a.applyReadOnlyUsageLockFree(profile)
+	}
+
+	a.profiles.profiles[profile.ID] = profile
+	return true
+}
+
+// registerFolderLockFree stores a loaded folder, refusing IDs already owned by another source.
+// Caller must hold Lock on a.profiles.mutex.
+func (a *App) registerFolderLockFree(folder *ProfileFolder, source ProfileSource, path string) bool {
+	if _, exists := a.profiles.profileFolders[folder.ID]; exists && a.folderSourcePathLockFree(folder.ID) != source.Path {
+		a.addSourceCollisionLockFree(folder.ID, "folder", a.folderSourceLabelLockFree(folder.ID), source.Label, path)
+		return false
+	}
+
+	if a.isPrimaryProfileSource(source) {
+		delete(a.profiles.folderSources, folder.ID)
+	} else {
+		a.profiles.folderSources[folder.ID] = source
+	}
+
+	a.profiles.profileFolders[folder.ID] = folder
+	return true
+}
+
+// applyReadOnlyUsageLockFree restores usage counters kept in local metrics for a read-only profile.
+// Caller must hold Lock on a.profiles.mutex.
+func (a *App) applyReadOnlyUsageLockFree(profile *Profile) {
+	if a.profiles.metrics == nil || a.profiles.metrics.ReadOnlyUsage == nil {
+		return
+	}
+	if usage, exists := a.profiles.metrics.ReadOnlyUsage[profile.ID]; exists && usage != nil {
+		profile.LastUsed = usage.LastUsed
+		profile.UsageCount = usage.UsageCount
+	}
+}
+
+// recordReadOnlyUsageLockFree stores a read-only profile's usage in local metrics instead of its file.
+// Caller must hold Lock on a.profiles.mutex.
+func (a *App) recordReadOnlyUsageLockFree(profile *Profile) {
+	if a.profiles.metrics == nil {
+		a.profiles.metrics = &ProfileMetrics{}
+	}
+	if a.profiles.metrics.ReadOnlyUsage == nil {
+		a.profiles.metrics.ReadOnlyUsage = make(map[string]*ProfileUsage)
+	}
+	a.profiles.metrics.ReadOnlyUsage[profile.ID] = &ProfileUsage{
+		LastUsed:   profile.LastUsed,
+		UsageCount: profile.UsageCount,
+	}
+}
+
+// GetProfileSources returns the resolved list of profile sources, primary first
+func (a *App) GetProfileSources() ([]ProfileSource, error) {
+	return a.getProfileSources()
+}
+
+// GetProfileSourceCollisions returns ID collisions detected while loading profile sources
+func (a *App) GetProfileSourceCollisions() []ProfileSourceCollision {
+	a.profiles.mutex.RLock()
+	defer a.profiles.mutex.RUnlock()
+
+	collisions := make([]ProfileSourceCollision, len(a.profiles.sourceCollisions))
+	copy(collisions, a.profiles.sourceCollisions)
+	return collisions
+}
+
+// ExportProfiles writes the profiles and folders of a single source to destDir.
+// Personal usage data is stripped so exports can be shared.
+func (a *App) ExportProfiles(sourceLabel, destDir string) (int, error) {
+	source, err := a.profileSourceByLabel(sourceLabel)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(destDir, ConfigDirMode); err != nil {
+		return 0, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	a.profiles.mutex.RLock()
+	defer a.profiles.mutex.RUnlock()
+
+	exported := 0
+	for id, folder := range a.profiles.profileFolders {
+		if a.folderSourcePathLockFree(id) != source.Path {
+			continue
+		}
+		filename := folderFileName(folder.ID)
+		if err := writeYAMLFile(filepath.Join(destDir, filename), folder); err != nil {
+			return exported, err
+		}
+		exported++
+	}
+
+	for id, profile := range a.profiles.profiles {
+		if a.profileSourcePathLockFree(id) != source.Path {
+			continue
+		}
+		shared := *profile
+		shared.LastUsed = time.Time{}
+		shared.UsageCount = 0
+		shared.FileHistory = nil
+
+		filename := profileFileName(profile.ID)
+		if err := writeYAMLFile(filepath.Join(destDir, filename), &shared); err != nil {
+			return exported, err
+		}
+		exported++
+	}
+
+	return exported, nil
+}
+
+// ImportProfiles reads profile and folder files from srcDir into a writable source.
+// Entries whose ID already exists in any source are skipped and reported as collisions.
+// Files are read and parsed before the profiles mutex is taken, with progress emitted as
+// profiles:operation-progress.
+func (a *App) ImportProfiles(srcDir, sourceLabel string) (int, error) {
+	source, err := a.profileSourceByLabel(sourceLabel)
+	if err != nil {
+		return 0, err
+	}
+	if source.ReadOnly {
+		return 0, &ReadOnlySourceError{ID: srcDir, Source: source.Label, Path: source.Path}
+	}
+
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read import directory: %w", err)
+	}
+
+	var files []*profileFileLoad
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(name), ".yaml") || name == MetricsFilename {
+			continue
+		}
+		files = append(files, &profileFileLoad{path: filepath.Join(srcDir, name), folder: strings.HasPrefix(name, "folder-")})
+	}
+
+	progress := newProfileOperationProgress(appEmitter{a}, "import", len(files))
+	for _, file := range files {
+		file.profile, file.dir, file.err = a.readImportFile(file)
+		progress.step(filepath.Base(file.path))
+	}
+
+	a.profiles.mutex.Lock()
+	defer a.profiles.mutex.Unlock()
+
+	imported := 0
+	for _, file := range files {
+		if file.err != nil {
+			fmt.Printf("Warning: Skipping import file %s: %v\n", file.path, file.err)
+			continue
+		}
+
+		if folder := file.dir; folder != nil {
+			if _, exists := a.profiles.profileFolders[folder.ID]; exists {
+				a.addSourceCollisionLockFree(folder.ID, "folder", a.folderSourceLabelLockFree(folder.ID), source.Label, file.path)
+				continue
+			}
+			if !a.isPrimaryProfileSource(source) {
+				a.profiles.folderSources[folder.ID] = source
+			}
+			if err := a.saveProfileFolderInternal(folder); err != nil {
+				delete(a.profiles.folderSources, folder.ID)
+				return imported, err
+			}
+			imported++
+			continue
+		}
+
+		profile := file.profile
+		if _, exists := a.profiles.profiles[profile.ID]; exists {
+			a.addSourceCollisionLockFree(profile.ID, "profile", a.profileSourceLabelLockFree(profile.ID), source.Label, file.path)
+			continue
+		}
+		if len(a.profiles.profiles) >= MaxProfiles {
+			return imported, fmt.Errorf("profile limit reached (%d)", MaxProfiles)
+		}
+		if !a.isPrimaryProfileSource(source) {
+			a.profiles.profileSources[profile.ID] = source
+		}
+		if err := a.saveProfileInternal(profile); err != nil {
+			delete(a.profiles.profileSources, profile.ID)
+			return imported, err
+		}
+		imported++
+	}
+
+	return imported, nil
+}
+
+// readImportFile reads and validates one file of an import directory
+func (a *App) readImportFile(file *profileFileLoad) (*Profile, *ProfileFolder, error) {
+	info, err := os.Stat(file.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Size() > MaxFileSize {
+		return nil, nil, fmt.Errorf("file exceeds maximum size limit")
+	}
+
+	data, err := os.ReadFile(file.path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if file.folder {
+		var folder ProfileFolder
+		if err := yaml.Unmarshal(data, &folder); err != nil || a.validateProfileFolder(&folder) != nil || folder.ID == "" {
+			return nil, nil, fmt.Errorf("invalid folder file")
+		}
+		return nil, &folder, nil
+	}
+
+	var profile Profile
+	if err := yaml.Unmarshal(data, &profile); err != nil || a.validateProfile(&profile) != nil || profile.ID == "" {
+		return nil, nil, fmt.Errorf("invalid profile file")
+	}
+	return &profile, nil, nil
+}
+
+// addSourceCollisionLockFree records a skipped duplicate ID once per file.
+// Caller must hold Lock on a.profiles.mutex.
+func (a *App) addSourceCollisionLockFree(id, kind, kept, skipped, path string) {
+	collision := ProfileSourceCollision{
+		ID:            id,
+		Kind:          kind,
+		KeptSource:    kept,
+		SkippedSource: skipped,
+		SkippedPath:   path,
+	}
+	for _, existing := range a.profiles.sourceCollisions {
+		if existing == collision {
+			return
+		}
+	}
+
+	a.profiles.sourceCollisions = append(a.profiles.sourceCollisions, collision)
+	fmt.Printf("Warning: %s ID %s in %s collides with source %s, skipping\n", kind, id, path, kept)
+}
+
+// writeYAMLFile marshals v and writes it to path
+func writeYAMLFile(path string, v interface{}) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", filepath.Base(path), err)
+	}
+	if err := os.WriteFile(path, data, ConfigFileMode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
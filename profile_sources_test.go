@@ -0,0 +1,78 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGetProfileSourcesLabels(t *testing.T) {
+	dir := t.TempDir()
+	app := NewApp()
+	app.config.config.ProfilesPath = filepath.Join(dir, "profiles")
+	app.config.config.ProfileSources = []ProfileSource{
+		{Path: filepath.Join(dir, "a", "Local")},
+		{Path: filepath.Join(dir, "b", "shared")},
+		{Path: filepath.Join(dir, "c", "shared")},
+		{Path: filepath.Join(dir, "d", "team"), Label: "shared (2)"},
+		{Path: filepath.Join(dir, "b", "shared", ".")}, // The same directory again
+	}
+
+	sources, err := app.getProfileSources()
+	if err != nil {
+		t.Fatalf("getProfileSources() returned error: %v", err)
+	}
+	want := []string{PrimaryProfileSourceLabel, "Local (2)", "shared", "shared (3)", "shared (2)"}
+	if len(sources) != len(want) {
+		t.Fatalf("got %d sources %+v, want labels %v", len(sources), sources, want)
+	}
+	for i, source := range sources {
+		if source.Label != want[i] {
+			t.Errorf("source %d (%s) label = %q, want %q", i, source.Path, source.Label, want[i])
+		}
+	}
+}
+
+func TestRegisterProfileBySourcePath(t *testing.T) {
+	dir := t.TempDir()
+	app := NewApp()
+	app.config.config.ProfilesPath = filepath.Join(dir, "profiles")
+	app.config.config.ProfileSources = []ProfileSource{
+		{Path: filepath.Join(dir, "a", "Local")},
+		{Path: filepath.Join(dir, "b", "shared")},
+		{Path: filepath.Join(dir, "c", "shared")},
+	}
+	sources, err := app.getProfileSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary, namedLocal, shared, otherShared := sources[0], sources[1], sources[2], sources[3]
+
+	app.profiles.mutex.Lock()
+	defer app.profiles.mutex.Unlock()
+
+	// A directory named like the primary source is still its own source
+	if !app.registerProfileLockFree(&Profile{ID: "web"}, namedLocal, filepath.Join(namedLocal.Path, "web.yaml")) {
+		t.Fatal("registerProfileLockFree() refused a new profile")
+	}
+	if app.profileSourcePathLockFree("web") != namedLocal.Path {
+		t.Errorf("profile from %s tracked as %s", namedLocal.Path, app.profileSourcePathLockFree("web"))
+	}
+	if app.registerProfileLockFree(&Profile{ID: "web"}, primary, filepath.Join(primary.Path, "web.yaml")) {
+		t.Error("primary source took over a profile of the source named Local")
+	}
+
+	// Directories with the same name don't merge
+	if !app.registerProfileLockFree(&Profile{ID: "db"}, shared, filepath.Join(shared.Path, "db.yaml")) {
+		t.Fatal("registerProfileLockFree() refused a new profile")
+	}
+	if app.registerProfileLockFree(&Profile{ID: "db"}, otherShared, filepath.Join(otherShared.Path, "db.yaml")) {
+		t.Error("second directory named shared took over a profile of the first")
+	}
+	// Reloading from the owning source is fine
+	if !app.registerProfileLockFree(&Profile{ID: "db"}, shared, filepath.Join(shared.Path, "db.yaml")) {
+		t.Error("reloading a profile from its own source was refused")
+	}
+	if n := len(app.profiles.sourceCollisions); n != 2 {
+		t.Errorf("%d collisions recorded, want 2", n)
+	}
+}
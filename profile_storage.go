@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v2"
@@ -15,6 +16,26 @@ import (
 // File operation timeout for safety
 const FileOperationTimeout = 30 * time.Second
 
+// profileLoadWorkers bounds how many profile files are read and parsed at once
+var profileLoadWorkers = 8
+
+// profileFileLoad is one profile or folder file found while walking a source
+type profileFileLoad struct {
+	path    string
+	folder  bool
+	profile *Profile
+	dir     *ProfileFolder
+	err     error
+}
+
+// kind names the file type as in ProfileLoadError and ProfileSourceCollision
+func (f *profileFileLoad) kind() string {
+	if f.folder {
+		return "folder"
+	}
+	return "profile"
+}
+
 // GetProfilesDirectory returns the full path to the profiles directory with validation
 func (a *App) GetProfilesDirectory() (string, error) {
 	// Check if a custom profiles path is configured
@@ -69,18 +90,25 @@ func (a *App) InitializeProfiles() error {
 		return fmt.Errorf("failed to start profile watcher: %w", err)
 	}
 
+	// Refresh the cert-expiring virtual folder daily
+	a.startCertExpiryWatcher()
+
 	// Save initial metrics
 	go a.saveMetrics()
 
 	return nil
 }
 
-// LoadProfiles loads all profiles from the profiles directory with timeout protection
+// LoadProfiles loads all profiles from every profile source with timeout protection.
+// The primary source is loaded first; IDs that reappear in a later source are reported as collisions.
+// Progress is emitted as profiles:loading and the outcome as profiles:load-complete; files that
+// failed to load are kept for GetProfileLoadErrors.
 func (a *App) LoadProfiles() error {
-	profilesDir, err := a.GetProfilesDirectory()
+	sources, err := a.getProfileSources()
 	if err != nil {
 		return err
 	}
+	progress := newProfileLoadProgress(appEmitter{a})
 
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), FileOperationTimeout)
@@ -90,10 +118,57 @@ func (a *App) LoadProfiles() error {
 	a.profiles.mutex.Lock()
 	a.profiles.profiles = make(map[string]*Profile)
 	a.profiles.profileFolders = make(map[string]*ProfileFolder)
+	a.profiles.profileSources = make(map[string]ProfileSource)
+	a.profiles.folderSources = make(map[string]ProfileSource)
+	a.profiles.sourceCollisions = nil
+	a.profiles.loadErrors = nil
+	a.profiles.mutex.Unlock()
+
+	for i, source := range sources {
+		progress.setSource(source.Label)
+		if err := a.loadProfilesFromSource(ctx, source, sources, progress); err != nil {
+			if i == 0 {
+				progress.complete(ProfileLoadSummary{Sources: len(sources), Error: err.Error()})
+				return err
+			}
+			// Extra sources are optional - a missing shared directory shouldn't block startup
+			fmt.Printf("Warning: Failed to load profile source %s (%s): %v\n", source.Label, source.Path, err)
+			progress.fail(ProfileLoadError{Path: source.Path, Source: source.Label, Error: err.Error()})
+		}
+	}
+
+	a.profiles.mutex.Lock()
+	a.profiles.loadErrors = progress.loadErrors()
+	profileCount := len(a.profiles.profiles)
+	folderCount := len(a.profiles.profileFolders)
+	collisionCount := len(a.profiles.sourceCollisions)
 	a.profiles.mutex.Unlock()
 
-	// Walk through all files in profiles directory
-	err = filepath.WalkDir(profilesDir, func(path string, d fs.DirEntry, err error) error {
+	progress.complete(ProfileLoadSummary{
+		Profiles:   profileCount,
+		Folders:    folderCount,
+		Sources:    len(sources),
+		Collisions: collisionCount,
+	})
+
+	fmt.Printf("Loaded %d profiles and %d folders from %d sources\n", profileCount, folderCount, len(sources))
+	if collisionCount > 0 {
+		fmt.Printf("Warning: %d profile ID collisions between sources\n", collisionCount)
+	}
+	return nil
+}
+
+// loadProfilesFromSource walks a single source directory and registers its profiles and folders.
+// Directories belonging to other (nested) sources are skipped. Files are read without holding
+// the profiles mutex, which is only taken to register the results.
+func (a *App) loadProfilesFromSource(ctx context.Context, source ProfileSource, sources []ProfileSource, progress *profileLoadProgress) error {
+	if _, err := os.Stat(source.Path); err != nil {
+		return fmt.Errorf("profile source directory unavailable: %w", err)
+	}
+
+	// Walk through all files in the source directory, collecting the ones to load
+	var files []*profileFileLoad
+	err := filepath.WalkDir(source.Path, func(path string, d fs.DirEntry, err error) error {
 		// Check for context cancellation
 		select {
 		case <-ctx.Done():
@@ -104,11 +179,21 @@ func (a *App) LoadProfiles() error {
 		if err != nil {
 			// Skip individual file errors instead of aborting entire load
 			fmt.Printf("Warning: Error accessing %s: %v\n", path, err)
+			progress.fail(ProfileLoadError{Path: path, Source: source.Label, Error: err.Error()})
 			return nil
 		}
 
-		// Skip directories and non-yaml files
-		if d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".yaml") {
+		if d.IsDir() {
+			for _, other := range sources {
+				if other.Path != source.Path && other.Path == path {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+
+		// Skip non-yaml files
+		if !strings.HasSuffix(strings.ToLower(d.Name()), ".yaml") {
 			return nil
 		}
 
@@ -118,59 +203,99 @@ func (a *App) LoadProfiles() error {
 			return nil
 		}
 
+		// Folders and profiles are told apart by filename pattern
+		file := &profileFileLoad{path: path, folder: strings.HasPrefix(name, "folder-")}
+
 		// Validate file size
 		info, err := d.Info()
 		if err != nil {
 			fmt.Printf("Warning: Failed to get file info for %s: %v\n", path, err)
+			progress.fail(ProfileLoadError{Path: path, Source: source.Label, Kind: file.kind(), Error: err.Error()})
 			return nil
 		}
 
 		if info.Size() > MaxFileSize {
 			fmt.Printf("Warning: File %s exceeds maximum size limit, skipping\n", path)
+			progress.fail(ProfileLoadError{Path: path, Source: source.Label, Kind: file.kind(),
+				Error: fmt.Sprintf("file is %d bytes, the limit is %d", info.Size(), MaxFileSize)})
 			return nil
 		}
 
-		// Determine if it's a profile or folder based on filename pattern
-		if strings.HasPrefix(name, "folder-") {
-			// Load folder
-			folder, err := a.LoadProfileFolder(path)
-			if err != nil {
-				fmt.Printf("Warning: Failed to load profile folder %s: %v\n", path, err)
-				return nil // Continue loading other files
-			}
-
-			a.profiles.mutex.Lock()
-			a.profiles.profileFolders[folder.ID] = folder
-			a.profiles.mutex.Unlock()
-		} else {
-			// Load profile
-			profile, err := a.LoadProfile(path)
-			if err != nil {
-				fmt.Printf("Warning: Failed to load profile %s: %v\n", path, err)
-				return nil // Continue loading other files
-			}
-
-			a.profiles.mutex.Lock()
-			a.profiles.profiles[profile.ID] = profile
-			a.profiles.mutex.Unlock()
-		}
-
+		files = append(files, file)
+		progress.found()
 		return nil
 	})
-
 	if err != nil {
 		return fmt.Errorf("failed to walk profiles directory: %w", err)
 	}
 
-	a.profiles.mutex.RLock()
-	profileCount := len(a.profiles.profiles)
-	folderCount := len(a.profiles.profileFolders)
-	a.profiles.mutex.RUnlock()
+	// Reading and parsing dominate on a cold disk, so they run in parallel
+	a.loadProfileFiles(ctx, files, progress)
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to load profiles: %w", err)
+	}
+
+	// Files from before ID-only names are renamed where we are allowed to write
+	if !source.ReadOnly {
+		for _, file := range files {
+			migrateProfileFileName(file)
+		}
+	}
 
-	fmt.Printf("Loaded %d profiles and %d folders\n", profileCount, folderCount)
+	// Register in walk order so duplicate IDs resolve the same way every time
+	a.profiles.mutex.Lock()
+	defer a.profiles.mutex.Unlock()
+	for _, file := range files {
+		if file.err != nil {
+			progress.fail(ProfileLoadError{Path: file.path, Source: source.Label, Kind: file.kind(), Error: file.err.Error()})
+		}
+		switch {
+		case file.err != nil && file.folder:
+			fmt.Printf("Warning: Failed to load profile folder %s: %v\n", file.path, file.err)
+		case file.err != nil:
+			fmt.Printf("Warning: Failed to load profile %s: %v\n", file.path, file.err)
+		case file.folder:
+			a.registerFolderLockFree(file.dir, source, file.path)
+		default:
+			a.registerProfileLockFree(file.profile, source, file.path)
+		}
+	}
 	return nil
 }
 
+// loadProfileFiles reads and parses files with up to profileLoadWorkers at a time, storing
+// each result in its profileFileLoad and counting it in progress. Files not started before
+// ctx is done are left empty.
+func (a *App) loadProfileFiles(ctx context.Context, files []*profileFileLoad, progress *profileLoadProgress) {
+	jobs := make(chan *profileFileLoad)
+	var wg sync.WaitGroup
+	for i := 0; i < profileLoadWorkers && i < len(files); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range jobs {
+				if file.folder {
+					file.dir, file.err = a.LoadProfileFolder(file.path)
+				} else {
+					file.profile, file.err = a.LoadProfile(file.path)
+				}
+				progress.loaded(file.err)
+			}
+		}()
+	}
+
+feed:
+	for _, file := range files {
+		select {
+		case jobs <- file:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
 // LoadProfile loads a single profile from file with validation
 func (a *App) LoadProfile(filePath string) (*Profile, error) {
 	// Validate file path
@@ -231,20 +356,73 @@ func (a *App) LoadProfileFolder(filePath string) (*ProfileFolder, error) {
 	return &folder, nil
 }
 
-// findProfileFile finds the existing file for a profile by ID with better error handling
-func (a *App) findProfileFile(profileID string) (string, error) {
+// profileFileName is the file a profile is saved as. Only the ID goes into it: names can
+// contain anything, including "-something" suffixes, and two profiles may share a name.
+func profileFileName(id string) string {
+	return sanitizeFilename(id + ".yaml")
+}
+
+// folderFileName is the file a folder is saved as
+func folderFileName(id string) string {
+	return sanitizeFilename("folder-" + id + ".yaml")
+}
+
+// legacyProfileFileName is the Name-ID.yaml file profiles were saved as before IDs alone were used
+func legacyProfileFileName(name, id string) string {
+	return sanitizeFilename(fmt.Sprintf("%s-%s.yaml", name, id))
+}
+
+// legacyFolderFileName is the folder-Name-ID.yaml file folders were saved as
+func legacyFolderFileName(name, id string) string {
+	return sanitizeFilename(fmt.Sprintf("folder-%s-%s.yaml", name, id))
+}
+
+// readFileID returns the id field of a profile or folder file, or "" if it can't be read
+func readFileID(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var header struct {
+		ID string `yaml:"id"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return ""
+	}
+	return header.ID
+}
+
+// findProfileFile finds the existing file for a profile by ID within a source directory
+func (a *App) findProfileFile(profilesDir, profileID string) (string, error) {
 	if profileID == "" {
 		return "", fmt.Errorf("profile ID cannot be empty")
 	}
+	return findIDFile(profilesDir, profileFileName(profileID), profileID, false)
+}
+
+// findFolderFile finds the existing file for a folder by ID within a source directory
+func (a *App) findFolderFile(profilesDir, folderID string) (string, error) {
+	if folderID == "" {
+		return "", fmt.Errorf("folder ID cannot be empty")
+	}
+	return findIDFile(profilesDir, folderFileName(folderID), folderID, true)
+}
 
-	profilesDir, err := a.GetProfilesDirectory()
-	if err != nil {
-		return "", err
+// findIDFile looks for fileName, the ID-based name of a profile or folder file, anywhere
+// under dir. Files still named Name-ID.yaml are only matched if the ID inside them is id -
+// the ID can't be read back from such a name reliably.
+func findIDFile(dir, fileName, id string, folder bool) (string, error) {
+	kind := "profile"
+	if folder {
+		kind = "folder"
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, fileName)); err == nil {
+		return filepath.Join(dir, fileName), nil
 	}
 
-	// Walk through all files in profiles directory to find the one with matching ID
 	var foundFile string
-	err = filepath.WalkDir(profilesDir, func(path string, d fs.DirEntry, err error) error {
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -255,98 +433,90 @@ func (a *App) findProfileFile(profileID string) (string, error) {
 		}
 
 		name := d.Name()
-		// Skip metrics file and folder files
-		if name == "metrics.yaml" || strings.HasPrefix(name, "folder-") {
+		// Skip metrics file and files of the other kind
+		if name == "metrics.yaml" || strings.HasPrefix(name, "folder-") != folder {
 			return nil
 		}
 
-		// Check if this is a profile file with the matching ID
-		// Profile files are in format: Name-ID.yaml
-		parts := strings.Split(name, "-")
-		if len(parts) >= 2 {
-			id := strings.TrimSuffix(parts[len(parts)-1], ".yaml")
-			if id == profileID {
-				foundFile = path
-				return filepath.SkipAll // Stop walking once found
-			}
+		legacySuffix := "-" + strings.TrimPrefix(fileName, "folder-")
+		if name == fileName || (strings.HasSuffix(name, legacySuffix) && readFileID(path) == id) {
+			foundFile = path
+			return filepath.SkipAll // Stop walking once found
 		}
 
 		return nil
 	})
 
 	if err != nil {
-		return "", fmt.Errorf("failed to search for profile file: %w", err)
+		return "", fmt.Errorf("failed to search for %s file: %w", kind, err)
 	}
 
 	if foundFile == "" {
-		return "", fmt.Errorf("profile file not found for ID: %s", profileID)
+		return "", fmt.Errorf("%s file not found for ID: %s", kind, id)
 	}
 
 	return foundFile, nil
 }
 
-// findFolderFile finds the existing file for a folder by ID with better error handling
-func (a *App) findFolderFile(folderID string) (string, error) {
-	if folderID == "" {
-		return "", fmt.Errorf("folder ID cannot be empty")
+// profileFilePath returns the file holding a profile, or where it would be saved if it has none
+func (a *App) profileFilePath(profilesDir, profileID string) string {
+	if path, err := a.findProfileFile(profilesDir, profileID); err == nil {
+		return path
 	}
+	return filepath.Join(profilesDir, profileFileName(profileID))
+}
 
-	profilesDir, err := a.GetProfilesDirectory()
-	if err != nil {
-		return "", err
+// folderFilePath returns the file holding a folder, or where it would be saved if it has none
+func (a *App) folderFilePath(profilesDir, folderID string) string {
+	if path, err := a.findFolderFile(profilesDir, folderID); err == nil {
+		return path
 	}
+	return filepath.Join(profilesDir, folderFileName(folderID))
+}
 
-	// Walk through all files in profiles directory to find the one with matching ID
-	var foundFile string
-	err = filepath.WalkDir(profilesDir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip directories and non-yaml files
-		if d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".yaml") {
-			return nil
-		}
-
-		name := d.Name()
-		// Skip metrics file and non-folder files
-		if name == "metrics.yaml" || !strings.HasPrefix(name, "folder-") {
-			return nil
+// migrateProfileFileName renames a loaded file still named Name-ID.yaml to its ID-based name
+// in the same directory. A file already holding that name is left alone.
+func migrateProfileFileName(file *profileFileLoad) {
+	var want string
+	switch {
+	case file.err != nil:
+		return
+	case file.folder:
+		if file.dir.ID == "" {
+			return
 		}
-
-		// Check if this is a folder file with the matching ID
-		// Folder files are in format: folder-Name-ID.yaml
-		parts := strings.Split(name, "-")
-		if len(parts) >= 3 { // folder-Name-ID.yaml has at least 3 parts
-			id := strings.TrimSuffix(parts[len(parts)-1], ".yaml")
-			if id == folderID {
-				foundFile = path
-				return filepath.SkipAll // Stop walking once found
-			}
+		want = folderFileName(file.dir.ID)
+	default:
+		if file.profile.ID == "" {
+			return
 		}
-
-		return nil
-	})
-
-	if err != nil {
-		return "", fmt.Errorf("failed to search for folder file: %w", err)
+		want = profileFileName(file.profile.ID)
 	}
-
-	if foundFile == "" {
-		return "", fmt.Errorf("folder file not found for ID: %s", folderID)
+	if filepath.Base(file.path) == want {
+		return
 	}
 
-	return foundFile, nil
+	target := filepath.Join(filepath.Dir(file.path), want)
+	if _, err := os.Lstat(target); err == nil {
+		fmt.Printf("Warning: Not renaming %s, %s already exists\n", file.path, target)
+		return
+	}
+	if err := os.Rename(file.path, target); err != nil {
+		fmt.Printf("Warning: Failed to rename %s to %s: %v\n", file.path, target, err)
+		return
+	}
+	file.path = target
 }
 
-// saveProfileInternal saves a profile to file without mutex locking (internal use)
+// saveProfileInternal saves a profile to its source directory without mutex locking (internal use).
+// New profiles go to the primary source; profiles from read-only sources are rejected.
 // The file watcher may fire for our own writes — that's harmless (just a redundant re-read).
 func (a *App) saveProfileInternal(profile *Profile) error {
 	if profile == nil {
 		return fmt.Errorf("profile cannot be nil")
 	}
 
-	profilesDir, err := a.GetProfilesDirectory()
+	profilesDir, err := a.writableProfileDirLockFree(profile.ID)
 	if err != nil {
 		return err
 	}
@@ -358,10 +528,7 @@ func (a *App) saveProfileInternal(profile *Profile) error {
 		return fmt.Errorf("failed to marshal profile: %w", err)
 	}
 
-	filename := fmt.Sprintf("%s-%s.yaml", profile.Name, profile.ID)
-	filename = sanitizeFilename(filename)
-
-	filePath := filepath.Join(profilesDir, filename)
+	filePath := filepath.Join(profilesDir, profileFileName(profile.ID))
 
 	// Validate file path
 	if err := a.validateProfilePath(filePath); err != nil {
@@ -369,7 +536,7 @@ func (a *App) saveProfileInternal(profile *Profile) error {
 	}
 
 	// Find and delete any existing file for this profile ID (handles renames)
-	existingFile, err := a.findProfileFile(profile.ID)
+	existingFile, err := a.findProfileFile(profilesDir, profile.ID)
 	if err == nil && existingFile != "" && existingFile != filePath {
 		if deleteErr := os.Remove(existingFile); deleteErr != nil && !os.IsNotExist(deleteErr) {
 			fmt.Printf("Warning: Failed to delete old profile file %s: %v\n", existingFile, deleteErr)
@@ -387,14 +554,15 @@ func (a *App) saveProfileInternal(profile *Profile) error {
 	return nil
 }
 
-// saveProfileFolderInternal saves a profile folder to file without mutex locking (internal use)
+// saveProfileFolderInternal saves a profile folder to its source directory without mutex locking (internal use).
+// Folders from read-only sources are rejected.
 // The file watcher may fire for our own writes — that's harmless (just a redundant re-read).
 func (a *App) saveProfileFolderInternal(folder *ProfileFolder) error {
 	if folder == nil {
 		return fmt.Errorf("folder cannot be nil")
 	}
 
-	profilesDir, err := a.GetProfilesDirectory()
+	profilesDir, err := a.writableFolderDirLockFree(folder.ID)
 	if err != nil {
 		return err
 	}
@@ -406,10 +574,7 @@ func (a *App) saveProfileFolderInternal(folder *ProfileFolder) error {
 		return fmt.Errorf("failed to marshal profile folder: %w", err)
 	}
 
-	filename := fmt.Sprintf("folder-%s-%s.yaml", folder.Name, folder.ID)
-	filename = sanitizeFilename(filename)
-
-	filePath := filepath.Join(profilesDir, filename)
+	filePath := filepath.Join(profilesDir, folderFileName(folder.ID))
 
 	// Validate file path
 	if err := a.validateProfilePath(filePath); err != nil {
@@ -417,7 +582,7 @@ func (a *App) saveProfileFolderInternal(folder *ProfileFolder) error {
 	}
 
 	// Find and delete any existing file for this folder ID (handles renames)
-	existingFile, err := a.findFolderFile(folder.ID)
+	existingFile, err := a.findFolderFile(profilesDir, folder.ID)
 	if err == nil && existingFile != "" && existingFile != filePath {
 		if deleteErr := os.Remove(existingFile); deleteErr != nil && !os.IsNotExist(deleteErr) {
 			fmt.Printf("Warning: Failed to delete old folder file %s: %v\n", existingFile, deleteErr)
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// writeTestProfiles writes profiles SSH profiles and folders folder files into dir
+func writeTestProfiles(tb testing.TB, dir string, profiles, folders int) {
+	tb.Helper()
+	write := func(name string, v interface{}) {
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			tb.Fatalf("failed to marshal %s: %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			tb.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	for i := 0; i < folders; i++ {
+		id := fmt.Sprintf("f%04d", i)
+		write("folder-"+id+".yaml", &ProfileFolder{ID: id, Name: "Folder " + id, Icon: "📁"})
+	}
+	for i := 0; i < profiles; i++ {
+		id := fmt.Sprintf("p%04d", i)
+		write("Host-"+id+".yaml", &Profile{
+			ID:              id,
+			Name:            "Host " + id,
+			Type:            ProfileTypeSSH,
+			FolderID:        fmt.Sprintf("f%04d", i%max(folders, 1)),
+			Tags:            []string{"production", "eu-west", "db"},
+			Environment:     map[string]string{"REGION": "eu-west-1", "TIER": "db"},
+			SSHConfig:       &SSHConfig{Host: fmt.Sprintf("10.0.%d.%d", i/250, i%250), Port: 22, Username: "deploy"},
+			Description:     "Replica in the EU region",
+			PreConnectHooks: []ConnectHook{{Command: "tailscale up"}},
+		})
+	}
+}
+
+// newProfileLoadApp returns an app whose only profile source is dir
+func newProfileLoadApp(tb testing.TB, dir string) *App {
+	tb.Helper()
+	app := NewApp()
+	app.config.config.ProfilesPath = dir
+	return app
+}
+
+func TestLoadProfilesParallel(t *testing.T) {
+	dir := t.TempDir()
+	writeTestProfiles(t, dir, 120, 12)
+	// Broken and non-profile files are skipped without failing the load
+	os.WriteFile(filepath.Join(dir, "Broken-zzz.yaml"), []byte("name: [unclosed"), 0600)
+	os.WriteFile(filepath.Join(dir, "metrics.yaml"), []byte("usage: {}"), 0600)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not yaml"), 0600)
+
+	app := newProfileLoadApp(t, dir)
+	if err := app.LoadProfiles(); err != nil {
+		t.Fatalf("LoadProfiles() returned error: %v", err)
+	}
+
+	// The broken file is reported with its parse error
+	loadErrors := app.GetProfileLoadErrors()
+	if len(loadErrors) != 1 || filepath.Base(loadErrors[0].Path) != "Broken-zzz.yaml" || loadErrors[0].Kind != "profile" ||
+		!strings.Contains(loadErrors[0].Error, "YAML") {
+		t.Errorf("GetProfileLoadErrors() = %+v", loadErrors)
+	}
+
+	app.profiles.mutex.RLock()
+	defer app.profiles.mutex.RUnlock()
+	if len(app.profiles.profiles) != 120 {
+		t.Errorf("loaded %d profiles, want 120", len(app.profiles.profiles))
+	}
+	if len(app.profiles.profileFolders) != 12 {
+		t.Errorf("loaded %d folders, want 12", len(app.profiles.profileFolders))
+	}
+	if _, exists := app.profiles.profileFolders["f0003"]; !exists {
+		t.Error("folder file was not registered as a folder")
+	}
+	if profile := app.profiles.profiles["p0042"]; profile == nil || profile.SSHConfig == nil || profile.SSHConfig.Host != "10.0.0.42" {
+		t.Errorf("profile p0042 = %+v", profile)
+	}
+}
+
+func TestProfileLoadProgressRateLimit(t *testing.T) {
+	emitter := &recordingEmitter{}
+	progress := newProfileLoadProgress(emitter)
+	for i := 0; i < 3*ProfileLoadProgressInterval; i++ {
+		progress.found()
+	}
+	// Three intervals reached within ProfileProgressMinInterval emit once
+	if len(emitter.names) != 1 || emitter.names[0] != "profiles:loading" || emitter.events[0]["discovered"] != ProfileLoadProgressInterval {
+		t.Errorf("events = %v %v", emitter.names, emitter.events)
+	}
+
+	progress.loaded(nil)
+	progress.loaded(errors.New("bad yaml"))
+	progress.fail(ProfileLoadError{Path: "/p/a.yaml", Kind: "profile", Error: "bad yaml"})
+	progress.complete(ProfileLoadSummary{Profiles: 1})
+	if last := emitter.names[len(emitter.names)-1]; last != "profiles:load-complete" {
+		t.Fatalf("last event = %s, want profiles:load-complete", last)
+	}
+}
+
+// BenchmarkLoadProfiles loads 500 profiles serially and with the default worker pool
+func BenchmarkLoadProfiles(b *testing.B) {
+	dir := b.TempDir()
+	writeTestProfiles(b, dir, 500, 25)
+
+	defaultWorkers := profileLoadWorkers
+	defer func() { profileLoadWorkers = defaultWorkers }()
+
+	for _, workers := range []int{1, defaultWorkers} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			profileLoadWorkers = workers
+			app := newProfileLoadApp(b, dir)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := app.LoadProfiles(); err != nil {
+					b.Fatalf("LoadProfiles() returned error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestProfileFilesMigrateToIDNames(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, v interface{}) {
+		data, _ := yaml.Marshal(v)
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// "web-42" with ID 7 used to be confused with the profile whose ID is 42
+	write("web-42-7.yaml", &Profile{ID: "7", Name: "web-42", Type: ProfileTypeLocal})
+	write("db-42.yaml", &Profile{ID: "42", Name: "db", Type: ProfileTypeLocal})
+	write("folder-Prod-f1.yaml", &ProfileFolder{ID: "f1", Name: "Prod"})
+
+	app := newProfileLoadApp(t, dir)
+	if err := app.LoadProfiles(); err != nil {
+		t.Fatalf("LoadProfiles() returned error: %v", err)
+	}
+	for _, name := range []string{"7.yaml", "42.yaml", "folder-f1.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected migrated file %s: %v", name, err)
+		}
+	}
+	for _, name := range []string{"web-42-7.yaml", "db-42.yaml", "folder-Prod-f1.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("legacy file %s still exists", name)
+		}
+	}
+
+	if err := app.DeleteProfile("42"); err != nil {
+		t.Fatalf("DeleteProfile() returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "7.yaml")); err != nil {
+		t.Errorf("deleting profile 42 removed the file of profile 7: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "42.yaml")); !os.IsNotExist(err) {
+		t.Error("file of deleted profile 42 still exists")
+	}
+}
+
+func TestFindProfileFileLegacyName(t *testing.T) {
+	dir := t.TempDir()
+	for name, id := range map[string]string{"a-b-c.yaml": "b-c", "x-c.yaml": "c"} {
+		data, _ := yaml.Marshal(&Profile{ID: id, Name: "n"})
+		os.WriteFile(filepath.Join(dir, name), data, 0600)
+	}
+	app := newProfileLoadApp(t, dir)
+	for id, want := range map[string]string{"c": "x-c.yaml", "b-c": "a-b-c.yaml"} {
+		path, err := app.findProfileFile(dir, id)
+		if err != nil || filepath.Base(path) != want {
+			t.Errorf("findProfileFile(%q) = %q, %v, want %s", id, path, err, want)
+		}
+	}
+}
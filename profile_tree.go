@@ -101,6 +101,7 @@ func (a *App) GetProfileTree() []*ProfileTreeNode {
 			Path:     a.buildFolderPathLockFree(folder.ID, 0),
 			Children: make([]*ProfileTreeNode, 0),
 			Expanded: folder.Expanded,
+			Source:   a.folderSourceLabelLockFree(folder.ID),
 		}
 		tree[folder.ID] = node
 	}
@@ -113,7 +114,8 @@ func (a *App) GetProfileTree() []*ProfileTreeNode {
 			Icon:    profile.Icon,
 			Type:    TreeNodeTypeProfile,
 			Path:    a.buildFolderPathLockFree(profile.FolderID, 0),
-			Profile: profile,
+			Profile: cloneProfile(profile),
+			Source:  a.profileSourceLabelLockFree(profile.ID),
 		}
 
 		// Find parent folder
@@ -306,6 +308,18 @@ func (a *App) initializeVirtualFolders() {
 				Limit:     15,
 			},
 		},
+		{
+			ID:   CertExpiryVirtualFolderID,
+			Name: "Certificates Expiring",
+			Icon: "⏳",
+			Type: CertExpiryFilterType,
+			Filter: VirtualFilter{
+				Type:      CertExpiryFilterType,
+				SortBy:    "name",
+				SortOrder: "asc",
+				DateRange: CertExpiryWarningDays,
+			},
+		},
 	}
 }
 
@@ -313,10 +327,7 @@ func (a *App) initializeVirtualFolders() {
 func (a *App) getVirtualFolderProfiles(vf *VirtualFolder) []*Profile {
 	var profiles []*Profile
 
-	a.profiles.mutex.RLock()
-	defer a.profiles.mutex.RUnlock()
-
-	for _, profile := range a.profiles.profiles {
+	for _, profile := range a.SnapshotProfiles() {
 		switch vf.Filter.Type {
 		case "favorite":
 			if profile.IsFavorite {
@@ -344,6 +355,14 @@ func (a *App) getVirtualFolderProfiles(vf *VirtualFolder) []*Profile {
 			if strings.EqualFold(profile.Type, vf.Filter.Value) {
 				profiles = append(profiles, profile)
 			}
+		case CertExpiryFilterType:
+			warningDays := vf.Filter.DateRange
+			if warningDays <= 0 {
+				warningDays = CertExpiryWarningDays
+			}
+			if isCertExpiringSoon(profile, warningDays) {
+				profiles = append(profiles, profile)
+			}
 		case "search":
 			searchTerm := strings.ToLower(vf.Filter.Value)
 			if strings.Contains(strings.ToLower(profile.Name), searchTerm) ||
@@ -470,10 +489,7 @@ func (a *App) SearchProfiles(query string, tags []string) []*Profile {
 	var results []*Profile
 	queryLower := strings.ToLower(query)
 
-	a.profiles.mutex.RLock()
-	defer a.profiles.mutex.RUnlock()
-
-	for _, profile := range a.profiles.profiles {
+	for _, profile := range a.SnapshotProfiles() {
 		match := false
 
 		// Text search
@@ -532,10 +548,7 @@ func (a *App) GetProfilesByTag(tag string) []*Profile {
 
 	var results []*Profile
 
-	a.profiles.mutex.RLock()
-	defer a.profiles.mutex.RUnlock()
-
-	for _, profile := range a.profiles.profiles {
+	for _, profile := range a.SnapshotProfiles() {
 		for _, profileTag := range profile.Tags {
 			if strings.EqualFold(profileTag, tag) {
 				results = append(results, profile)
@@ -560,10 +573,7 @@ func (a *App) GetProfilesByType(profileType string) []*Profile {
 
 	var results []*Profile
 
-	a.profiles.mutex.RLock()
-	defer a.profiles.mutex.RUnlock()
-
-	for _, profile := range a.profiles.profiles {
+	for _, profile := range a.SnapshotProfiles() {
 		if strings.EqualFold(profile.Type, profileType) {
 			results = append(results, profile)
 		}
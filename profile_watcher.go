@@ -16,12 +16,13 @@ const (
 	WatcherDebounceMs  = 300 * time.Millisecond
 )
 
-// StartProfileWatcher starts monitoring profile files for changes
+// StartProfileWatcher starts monitoring profile files for changes in every profile source
 func (a *App) StartProfileWatcher() error {
-	profilesDir, err := a.GetProfilesDirectory()
+	sources, err := a.getProfileSources()
 	if err != nil {
 		return fmt.Errorf("failed to get profiles directory: %w", err)
 	}
+	profilesDir := sources[0].Path
 
 	// Stop existing watcher if running
 	if a.profiles.profileWatcher != nil {
@@ -39,8 +40,18 @@ func (a *App) StartProfileWatcher() error {
 		return fmt.Errorf("failed to watch profiles directory: %w", err)
 	}
 
+	watchDirs := []string{profilesDir}
+	for _, source := range sources[1:] {
+		if err := watcher.Add(source.Path); err != nil {
+			fmt.Printf("Warning: Failed to watch profile source %s (%s): %v\n", source.Label, source.Path, err)
+			continue
+		}
+		watchDirs = append(watchDirs, source.Path)
+	}
+
 	pw := &ProfileWatcher{
 		watchDir:    profilesDir,
+		watchDirs:   watchDirs,
 		stopChan:    make(chan bool, 1),
 		doneChan:    make(chan struct{}),
 		updatesChan: make(chan ProfileUpdate, WatcherBufferSize),
@@ -77,7 +88,7 @@ func (a *App) StartProfileWatcher() error {
 		}
 	}()
 
-	fmt.Printf("Profile file watcher started for directory: %s\n", profilesDir)
+	fmt.Printf("Profile file watcher started for %d directories: %s\n", len(watchDirs), strings.Join(watchDirs, ", "))
 	return nil
 }
 
@@ -162,6 +173,7 @@ func (a *App) emitProfileChangedDebounced() {
 	pw.debounceTimer = time.AfterFunc(WatcherDebounceMs, func() {
 		if a.ctx != nil {
 			wailsRuntime.EventsEmit(a.ctx, "profile:file:changed", nil)
+			a.emitCertExpiryFolderUpdated()
 		}
 	})
 }
@@ -179,6 +191,11 @@ func (a *App) handleFileModified(filePath string) {
 
 // handleProfileFileModified reloads a modified profile file
 func (a *App) handleProfileFileModified(filePath string) {
+	source, ok := a.sourceForPath(filePath)
+	if !ok {
+		return
+	}
+
 	profile, err := a.LoadProfile(filePath)
 	if err != nil {
 		fmt.Printf("Warning: Failed to reload modified profile %s: %v\n", filePath, err)
@@ -186,14 +203,22 @@ func (a *App) handleProfileFileModified(filePath string) {
 	}
 
 	a.profiles.mutex.Lock()
-	a.profiles.profiles[profile.ID] = profile
+	registered := a.registerProfileLockFree(profile, source, filePath)
 	a.profiles.mutex.Unlock()
+	if !registered {
+		return
+	}
 
 	fmt.Printf("Reloaded modified profile: %s\n", profile.Name)
 }
 
 // handleFolderFileModified reloads a modified folder file
 func (a *App) handleFolderFileModified(filePath string) {
+	source, ok := a.sourceForPath(filePath)
+	if !ok {
+		return
+	}
+
 	folder, err := a.LoadProfileFolder(filePath)
 	if err != nil {
 		fmt.Printf("Warning: Failed to reload modified folder %s: %v\n", filePath, err)
@@ -201,8 +226,11 @@ func (a *App) handleFolderFileModified(filePath string) {
 	}
 
 	a.profiles.mutex.Lock()
-	a.profiles.profileFolders[folder.ID] = folder
+	registered := a.registerFolderLockFree(folder, source, filePath)
 	a.profiles.mutex.Unlock()
+	if !registered {
+		return
+	}
 
 	fmt.Printf("Reloaded modified folder: %s\n", folder.Name)
 }
@@ -216,46 +244,80 @@ func (a *App) handleFileCreated(filePath string) {
 func (a *App) handleFileRemoved(filePath string) {
 	baseName := filepath.Base(filePath)
 
+	// Only drop entries owned by the source the file was removed from
+	source, ok := a.sourceForPath(filePath)
+	if !ok {
+		return
+	}
+
 	if strings.HasPrefix(strings.ToLower(baseName), "folder-") {
-		a.handleFolderFileRemoved(baseName)
+		a.handleFolderFileRemoved(baseName, source)
 	} else {
-		a.handleProfileFileRemoved(baseName)
+		a.handleProfileFileRemoved(baseName, source)
 	}
 }
 
 // handleProfileFileRemoved removes a deleted profile from memory
-func (a *App) handleProfileFileRemoved(baseName string) {
-	// Extract ID from filename: Name-ID.yaml
-	name := strings.TrimSuffix(baseName, ".yaml")
-	parts := strings.Split(name, "-")
-	if len(parts) < 2 {
+func (a *App) handleProfileFileRemoved(baseName string, source ProfileSource) {
+	// The file is gone, so match it by name: ID.yaml, or Name-ID.yaml for files not yet migrated
+	a.profiles.mutex.RLock()
+	var id string
+	for profileID, profile := range a.profiles.profiles {
+		if a.profileSourcePathLockFree(profileID) != source.Path {
+			continue
+		}
+		if baseName == profileFileName(profileID) || baseName == legacyProfileFileName(profile.Name, profileID) {
+			id = profileID
+			break
+		}
+	}
+	a.profiles.mutex.RUnlock()
+	if id == "" {
 		return
 	}
 
-	id := parts[len(parts)-1]
+	// Renaming a legacy file to its ID-based name also ends up here
+	if _, err := a.findProfileFile(source.Path, id); err == nil {
+		return
+	}
 
 	a.profiles.mutex.Lock()
-	if _, exists := a.profiles.profiles[id]; exists {
+	if _, exists := a.profiles.profiles[id]; exists && a.profileSourcePathLockFree(id) == source.Path {
 		delete(a.profiles.profiles, id)
+		delete(a.profiles.profileSources, id)
 		fmt.Printf("Removed deleted profile from memory: %s\n", id)
 	}
 	a.profiles.mutex.Unlock()
 }
 
 // handleFolderFileRemoved removes a deleted folder from memory
-func (a *App) handleFolderFileRemoved(baseName string) {
-	// Extract ID from filename: folder-Name-ID.yaml
-	name := strings.TrimSuffix(baseName, ".yaml")
-	parts := strings.Split(name, "-")
-	if len(parts) < 3 {
+func (a *App) handleFolderFileRemoved(baseName string, source ProfileSource) {
+	// The file is gone, so match it by name: folder-ID.yaml, or folder-Name-ID.yaml for files not yet migrated
+	a.profiles.mutex.RLock()
+	var id string
+	for folderID, folder := range a.profiles.profileFolders {
+		if a.folderSourcePathLockFree(folderID) != source.Path {
+			continue
+		}
+		if baseName == folderFileName(folderID) || baseName == legacyFolderFileName(folder.Name, folderID) {
+			id = folderID
+			break
+		}
+	}
+	a.profiles.mutex.RUnlock()
+	if id == "" {
 		return
 	}
 
-	id := parts[len(parts)-1]
+	// Renaming a legacy file to its ID-based name also ends up here
+	if _, err := a.findFolderFile(source.Path, id); err == nil {
+		return
+	}
 
 	a.profiles.mutex.Lock()
-	if _, exists := a.profiles.profileFolders[id]; exists {
+	if _, exists := a.profiles.profileFolders[id]; exists && a.folderSourcePathLockFree(id) == source.Path {
 		delete(a.profiles.profileFolders, id)
+		delete(a.profiles.folderSources, id)
 		fmt.Printf("Removed deleted folder from memory: %s\n", id)
 	}
 	a.profiles.mutex.Unlock()
@@ -271,6 +333,7 @@ func (a *App) GetWatcherStatus() map[string]interface{} {
 	status := map[string]interface{}{
 		"running":      false,
 		"watchDir":     "",
+		"watchDirs":    []string{},
 		"profileCount": 0,
 		"folderCount":  0,
 	}
@@ -278,6 +341,7 @@ func (a *App) GetWatcherStatus() map[string]interface{} {
 	if a.profiles.profileWatcher != nil {
 		status["running"] = true
 		status["watchDir"] = a.profiles.profileWatcher.watchDir
+		status["watchDirs"] = a.profiles.profileWatcher.watchDirs
 	}
 
 	a.profiles.mutex.RLock()
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Recently closed tab constants
+const (
+	MaxRecentlyClosedTabs      = 20
+	RecentlyClosedTabsFileName = "recently-closed-tabs.json"
+)
+
+// ClosedTab is what's kept of a closed tab to open it again: its connection settings,
+// without passwords or any live session state
+type ClosedTab struct {
+	ID             string       `json:"id"` // The closed tab's ID, used to reopen it
+	Title          string       `json:"title"`
+	CustomTitle    bool         `json:"customTitle"` // The title was set by hand rather than from a format
+	TitleFormat    string       `json:"titleFormat,omitempty"`
+	Shell          string       `json:"shell,omitempty"`
+	ConnectionType string       `json:"connectionType"`
+	SSHConfig      *SSHConfig   `json:"sshConfig,omitempty"`
+	NomadConfig    *NomadConfig `json:"nomadConfig,omitempty"`
+	ProfileID      string       `json:"profileId,omitempty"`
+	Tags           []string     `json:"tags,omitempty"`
+	Position       int          `json:"position"`  // Index among the open tabs when it was closed
+	Connected      bool         `json:"connected"` // The tab was connected when it was closed
+	Closed         time.Time    `json:"closed"`
+}
+
+// ReopenedTab is a tab recreated by ReopenClosedTab
+type ReopenedTab struct {
+	Tab     *Tab `json:"tab"`
+	Connect bool `json:"connect"` // The closed tab was connected; start its shell straight away
+}
+
+// sshConfigWithoutSecrets returns a copy of cfg with the passwords of the destination, its
+// jump hosts and its proxy removed
+func sshConfigWithoutSecrets(cfg *SSHConfig) *SSHConfig {
+	if cfg == nil {
+		return nil
+	}
+	stripped := *cfg
+	stripped.Password = ""
+	if cfg.Proxy != nil {
+		proxy := *cfg.Proxy
+		proxy.Password = ""
+		stripped.Proxy = &proxy
+	}
+	stripped.JumpHosts = make([]JumpHostConfig, len(cfg.JumpHosts))
+	for i, hop := range cfg.JumpHosts {
+		hop.Password = ""
+		stripped.JumpHosts[i] = hop
+	}
+	if len(stripped.JumpHosts) == 0 {
+		stripped.JumpHosts = nil
+	}
+	return &stripped
+}
+
+// getClosedTabExpiry returns how long closed tabs can be reopened, with the default applied
+func (a *App) getClosedTabExpiry() time.Duration {
+	minutes := 0
+	if a.config != nil {
+		a.config.mutex.RLock()
+		if a.config.config != nil {
+			minutes = a.config.config.ClosedTabExpiryMinutes
+		}
+		a.config.mutex.RUnlock()
+	}
+	return closedTabExpiryWithDefaults(minutes)
+}
+
+// closedTabExpiryWithDefaults turns the configured expiry into a duration, applying the
+// default when unset. For callers that already hold config.mutex.
+func closedTabExpiryWithDefaults(minutes int) time.Duration {
+	if minutes <= 0 {
+		minutes = DefaultClosedTabExpiryMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// rememberClosedTabLocked pushes a tab being closed onto the recently closed stack. Replay
+// tabs and tabs closed while the app shuts down aren't kept. The caller must hold
+// a.terminal.mutex and must not have removed the tab yet.
+func (a *App) rememberClosedTabLocked(tab *Tab) bool {
+	if a.terminal.closingApp || tab.ConnectionType == ConnectionTypeReplay {
+		return false
+	}
+
+	position := 0
+	for _, other := range a.terminal.tabs {
+		if other.ID != tab.ID && other.Created.Before(tab.Created) {
+			position++
+		}
+	}
+	closed := &ClosedTab{
+		ID:             tab.ID,
+		Title:          tab.Title,
+		CustomTitle:    tab.customTitle,
+		TitleFormat:    tab.TitleFormat,
+		Shell:          tab.Shell,
+		ConnectionType: tab.ConnectionType,
+		SSHConfig:      sshConfigWithoutSecrets(tab.SSHConfig),
+		ProfileID:      tab.ProfileID,
+		Tags:           append([]string(nil), a.terminal.sessionTags[tab.ID]...),
+		Position:       position,
+		Connected:      tab.Status == StatusConnected.String(),
+		Closed:         time.Now(),
+	}
+	if tab.NomadConfig != nil {
+		nomadConfig := *tab.NomadConfig
+		closed.NomadConfig = &nomadConfig
+	}
+
+	a.terminal.recentlyClosed = append([]*ClosedTab{closed}, a.terminal.recentlyClosed...)
+	if len(a.terminal.recentlyClosed) > MaxRecentlyClosedTabs {
+		a.terminal.recentlyClosed = a.terminal.recentlyClosed[:MaxRecentlyClosedTabs]
+	}
+	return true
+}
+
+// pruneClosedTabsLocked drops entries older than the expiry. The caller must hold a.terminal.mutex.
+func (a *App) pruneClosedTabsLocked() {
+	cutoff := time.Now().Add(-a.getClosedTabExpiry())
+	kept := a.terminal.recentlyClosed[:0]
+	for _, closed := range a.terminal.recentlyClosed {
+		if closed.Closed.After(cutoff) {
+			kept = append(kept, closed)
+		}
+	}
+	a.terminal.recentlyClosed = kept
+}
+
+// GetRecentlyClosedTabs returns the tabs that can be reopened, most recently closed first
+func (a *App) GetRecentlyClosedTabs() []ClosedTab {
+	a.terminal.mutex.Lock()
+	defer a.terminal.mutex.Unlock()
+
+	a.pruneClosedTabsLocked()
+	closedTabs := make([]ClosedTab, len(a.terminal.recentlyClosed))
+	for i, closed := range a.terminal.recentlyClosed {
+		closedTabs[i] = *closed
+	}
+	return closedTabs
+}
+
+// takeClosedTab removes a recently closed tab from the stack: the most recent one for an
+// empty ref, the one at that index for a number, otherwise the one with that tab ID
+func (a *App) takeClosedTab(ref string) (*ClosedTab, error) {
+	a.terminal.mutex.Lock()
+	defer a.terminal.mutex.Unlock()
+
+	a.pruneClosedTabsLocked()
+	index := -1
+	if ref == "" {
+		ref = "0"
+	}
+	if i, err := strconv.Atoi(ref); err == nil {
+		if i >= 0 && i < len(a.terminal.recentlyClosed) {
+			index = i
+		}
+	} else {
+		for i, closed := range a.terminal.recentlyClosed {
+			if closed.ID == ref {
+				index = i
+				break
+			}
+		}
+	}
+	if index < 0 {
+		return nil, newNotFoundError(ErrCategoryTerminal, "ReopenClosedTab", "no recently closed tab %q", ref)
+	}
+
+	closed := a.terminal.recentlyClosed[index]
+	a.terminal.recentlyClosed = append(a.terminal.recentlyClosed[:index], a.terminal.recentlyClosed[index+1:]...)
+	return closed, nil
+}
+
+// ReopenClosedTab opens a recently closed tab again: the most recent for an empty ref,
+// otherwise the one at that index in GetRecentlyClosedTabs or with that tab ID. The new tab
+// gets the old one's connection, profile, title and tags, and its place among the tabs.
+// Passwords that weren't saved in a profile are asked for again.
+func (a *App) ReopenClosedTab(ref string) (*ReopenedTab, error) {
+	closed, err := a.takeClosedTab(ref)
+	if err != nil {
+		return nil, err
+	}
+	defer a.saveRecentlyClosedTabs()
+
+	var tab *Tab
+	a.profiles.mutex.RLock()
+	_, profileExists := a.profiles.profiles[closed.ProfileID]
+	a.profiles.mutex.RUnlock()
+	switch {
+	case closed.ProfileID != "" && profileExists:
+		// The profile may have changed since, and holds any saved password
+		tab, err = a.createTabFromProfile(closed.ProfileID, false)
+	case closed.ConnectionType == ConnectionTypeNomadExec && closed.NomadConfig != nil:
+		tab, err = a.CreateTab("", nil)
+		if err == nil {
+			a.terminal.mutex.Lock()
+			tab.ConnectionType = ConnectionTypeNomadExec
+			tab.NomadConfig = closed.NomadConfig
+			a.terminal.mutex.Unlock()
+		}
+	default:
+		tab, err = a.CreateTab(closed.Shell, closed.SSHConfig)
+	}
+	if err != nil {
+		// Keep the entry so the user can try again
+		a.terminal.mutex.Lock()
+		a.terminal.recentlyClosed = append([]*ClosedTab{closed}, a.terminal.recentlyClosed...)
+		a.terminal.mutex.Unlock()
+		return nil, fmt.Errorf("failed to reopen tab %s: %w", closed.Title, err)
+	}
+
+	if len(closed.Tags) > 0 {
+		if tagErr := a.AddTagToSession(tab.ID, closed.Tags); tagErr != nil {
+			fmt.Printf("Warning: Failed to restore tags of reopened tab %s: %v\n", tab.ID, tagErr)
+		}
+	}
+	a.terminal.mutex.Lock()
+	tab.TitleFormat = closed.TitleFormat
+	a.terminal.mutex.Unlock()
+	if closed.CustomTitle {
+		a.RenameTab(tab.ID, closed.Title)
+	} else {
+		a.refreshTabTitle(tab.ID)
+	}
+	if err := a.moveTabTo(tab.ID, closed.Position); err != nil {
+		fmt.Printf("Warning: Failed to restore the position of reopened tab %s: %v\n", tab.ID, err)
+	}
+
+	a.terminal.mutex.RLock()
+	reopened := cloneTab(tab)
+	a.terminal.mutex.RUnlock()
+	return &ReopenedTab{Tab: reopened, Connect: closed.Connected}, nil
+}
+
+// moveTabTo moves a tab to an index in the tab order, keeping the others in order
+func (a *App) moveTabTo(tabID string, index int) error {
+	var order []string
+	for _, tab := range a.GetTabs() {
+		if tab.ID != tabID {
+			order = append(order, tab.ID)
+		}
+	}
+	if index < 0 {
+		index = 0
+	}
+	if index > len(order) {
+		index = len(order)
+	}
+	order = append(order[:index], append([]string{tabID}, order[index:]...)...)
+	return a.ReorderTabs(order)
+}
+
+// getRecentlyClosedTabsPath returns the path of the recently closed tabs file
+func (a *App) getRecentlyClosedTabsPath() (string, error) {
+	configPath, err := a.getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), RecentlyClosedTabsFileName), nil
+}
+
+// saveRecentlyClosedTabs writes the stack so tabs can still be reopened after a restart
+func (a *App) saveRecentlyClosedTabs() {
+	closedTabs := a.GetRecentlyClosedTabs()
+	closedPath, err := a.getRecentlyClosedTabsPath()
+	if err != nil {
+		return
+	}
+	if len(closedTabs) == 0 {
+		os.Remove(closedPath)
+		return
+	}
+	if err := a.ensureConfigDir(); err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(closedTabs, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(closedPath, data, 0600); err != nil {
+		fmt.Printf("Warning: Failed to save recently closed tabs: %v\n", err)
+	}
+}
+
+// restoreRecentlyClosedTabs reloads the stack saved when the app last ran, dropping
+// entries that have expired since
+func (a *App) restoreRecentlyClosedTabs() {
+	closedPath, err := a.getRecentlyClosedTabsPath()
+	if err != nil {
+		return
+	}
+	data, err := os.ReadFile(closedPath)
+	if err != nil {
+		return
+	}
+
+	var closedTabs []*ClosedTab
+	if err := json.Unmarshal(data, &closedTabs); err != nil {
+		fmt.Printf("Warning: Failed to read recently closed tabs: %v\n", err)
+		return
+	}
+
+	a.terminal.mutex.Lock()
+	for _, closed := range closedTabs {
+		if closed == nil || closed.ID == "" || len(a.terminal.recentlyClosed) >= MaxRecentlyClosedTabs {
+			continue
+		}
+		// A hand-edited file must not bring passwords back
+		closed.SSHConfig = sshConfigWithoutSecrets(closed.SSHConfig)
+		a.terminal.recentlyClosed = append(a.terminal.recentlyClosed, closed)
+	}
+	a.pruneClosedTabsLocked()
+	a.terminal.mutex.Unlock()
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// newClosedTabsApp creates an app with its config dir in a temp dir
+func newClosedTabsApp(t *testing.T) *App {
+	t.Helper()
+	configHome := t.TempDir()
+	t.Setenv("HOME", configHome)
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("AppData", configHome)
+
+	app := NewApp()
+	app.privacy.emit = (&outputRecorder{}).emit
+	return app
+}
+
+// addClosableTab adds an SSH tab without a session, created at the given offset so the
+// tab order is fixed
+func addClosableTab(app *App, id string, offset time.Duration) *Tab {
+	tab := &Tab{
+		ID:             id,
+		Title:          id,
+		SessionID:      "session_" + id,
+		ConnectionType: ConnectionTypeSSH,
+		SSHConfig: &SSHConfig{Host: id + ".internal", Port: 22, Username: "ops", Password: "secret",
+			JumpHosts: []JumpHostConfig{{Host: "bastion", Port: 22, Username: "ops", Password: "hop-secret"}}},
+		Created: time.Now().Add(-time.Hour + offset),
+		Status:  StatusConnected.String(),
+	}
+	app.terminal.mutex.Lock()
+	app.terminal.tabs[id] = tab
+	app.terminal.mutex.Unlock()
+	return tab
+}
+
+func TestCloseTabRemembersTabWithoutSecrets(t *testing.T) {
+	app := newClosedTabsApp(t)
+	addClosableTab(app, "tab_a", 0)
+	addClosableTab(app, "tab_b", time.Second)
+	addClosableTab(app, "tab_c", 2*time.Second)
+	if err := app.AddTagToSession("tab_b", []string{"Deploy"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.CloseTab("tab_b"); err != nil {
+		t.Fatal(err)
+	}
+	closedTabs := app.GetRecentlyClosedTabs()
+	if len(closedTabs) != 1 {
+		t.Fatalf("GetRecentlyClosedTabs() returned %d tabs, want 1", len(closedTabs))
+	}
+	closed := closedTabs[0]
+	if closed.ID != "tab_b" || closed.Position != 1 || !closed.Connected || len(closed.Tags) != 1 || closed.Tags[0] != "Deploy" {
+		t.Errorf("closed tab = %+v, want tab_b at position 1, connected, tagged Deploy", closed)
+	}
+	if closed.SSHConfig.Host != "tab_b.internal" || closed.SSHConfig.Password != "" || closed.SSHConfig.JumpHosts[0].Password != "" {
+		t.Errorf("closed tab SSH config = %+v, want the host kept and passwords removed", closed.SSHConfig)
+	}
+
+	// Live tabs keep their passwords
+	app.terminal.mutex.RLock()
+	password := app.terminal.tabs["tab_a"].SSHConfig.JumpHosts[0].Password
+	app.terminal.mutex.RUnlock()
+	if password != "hop-secret" {
+		t.Errorf("open tab jump host password = %q, want it untouched", password)
+	}
+}
+
+func TestRecentlyClosedTabsAreBounded(t *testing.T) {
+	app := newClosedTabsApp(t)
+	for i := 0; i < MaxRecentlyClosedTabs+5; i++ {
+		id := fmt.Sprintf("tab_%02d", i)
+		addClosableTab(app, id, time.Duration(i)*time.Second)
+		if err := app.CloseTab(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	closedTabs := app.GetRecentlyClosedTabs()
+	if len(closedTabs) != MaxRecentlyClosedTabs {
+		t.Fatalf("GetRecentlyClosedTabs() returned %d tabs, want %d", len(closedTabs), MaxRecentlyClosedTabs)
+	}
+	if want := fmt.Sprintf("tab_%02d", MaxRecentlyClosedTabs+4); closedTabs[0].ID != want {
+		t.Errorf("most recent closed tab = %s, want %s", closedTabs[0].ID, want)
+	}
+}
+
+func TestRecentlyClosedTabsExpire(t *testing.T) {
+	app := newClosedTabsApp(t)
+	addClosableTab(app, "tab_old", 0)
+	addClosableTab(app, "tab_new", time.Second)
+	app.CloseTab("tab_old")
+	app.CloseTab("tab_new")
+
+	app.terminal.mutex.Lock()
+	app.terminal.recentlyClosed[1].Closed = time.Now().Add(-app.getClosedTabExpiry() - time.Minute)
+	app.terminal.mutex.Unlock()
+
+	closedTabs := app.GetRecentlyClosedTabs()
+	if len(closedTabs) != 1 || closedTabs[0].ID != "tab_new" {
+		t.Errorf("GetRecentlyClosedTabs() = %+v, want only tab_new", closedTabs)
+	}
+	if _, err := app.ReopenClosedTab("tab_old"); toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("ReopenClosedTab() of an expired tab = %v, want not found", err)
+	}
+}
+
+func TestReopenClosedTabRestoresPlace(t *testing.T) {
+	app := newClosedTabsApp(t)
+	addClosableTab(app, "tab_a", 0)
+	addClosableTab(app, "tab_b", time.Second)
+	addClosableTab(app, "tab_c", 2*time.Second)
+	app.AddTagToSession("tab_b", []string{"Deploy"})
+	app.RenameTab("tab_b", "Build box")
+	app.CloseTab("tab_b")
+	app.CloseTab("tab_c")
+
+	// tab_c is on top; tab_b is reopened by ID
+	reopened, err := app.ReopenClosedTab("tab_b")
+	if err != nil {
+		t.Fatalf("ReopenClosedTab() returned error: %v", err)
+	}
+	if !reopened.Connect || reopened.Tab.ID == "tab_b" || reopened.Tab.Title != "Build box" {
+		t.Errorf("reopened tab = %+v, connect %v; want a new tab titled Build box to connect", reopened.Tab, reopened.Connect)
+	}
+	if reopened.Tab.SSHConfig == nil || reopened.Tab.SSHConfig.Host != "tab_b.internal" {
+		t.Errorf("reopened tab SSH config = %+v, want tab_b.internal", reopened.Tab.SSHConfig)
+	}
+	if tagged, err := app.GetSessionsByTag("deploy"); err != nil || len(tagged) != 1 || tagged[0].ID != reopened.Tab.ID {
+		t.Errorf("GetSessionsByTag(deploy) = %v, %v; want the reopened tab", tagged, err)
+	}
+	tabs := app.GetTabs()
+	if len(tabs) != 2 || tabs[0].ID != "tab_a" || tabs[1].ID != reopened.Tab.ID {
+		t.Errorf("tab order after reopen = %v, want tab_a then the reopened tab", tabs)
+	}
+
+	// The empty ref takes what's left on top
+	reopened, err = app.ReopenClosedTab("")
+	if err != nil || reopened.Tab.SSHConfig.Host != "tab_c.internal" {
+		t.Fatalf("ReopenClosedTab(\"\") = %+v, %v; want tab_c reopened", reopened, err)
+	}
+	if _, err := app.ReopenClosedTab(""); toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("ReopenClosedTab() with nothing closed = %v, want not found", err)
+	}
+}
+
+func TestShutdownDoesNotRememberTabs(t *testing.T) {
+	app := newClosedTabsApp(t)
+	addClosableTab(app, "tab_a", 0)
+	app.terminal.mutex.Lock()
+	app.terminal.closingApp = true
+	app.terminal.mutex.Unlock()
+
+	app.CloseTab("tab_a")
+	if closedTabs := app.GetRecentlyClosedTabs(); len(closedTabs) != 0 {
+		t.Errorf("GetRecentlyClosedTabs() = %+v after closing during shutdown, want none", closedTabs)
+	}
+}
+
+func TestRecentlyClosedTabsSurviveRestart(t *testing.T) {
+	app := newClosedTabsApp(t)
+	addClosableTab(app, "tab_a", 0)
+	app.CloseTab("tab_a")
+
+	closedPath, err := app.getRecentlyClosedTabsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(closedPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("recently closed tabs file = %v, %v; want it written with mode 0600", info, err)
+	}
+
+	restarted := NewApp()
+	restarted.restoreRecentlyClosedTabs()
+	closedTabs := restarted.GetRecentlyClosedTabs()
+	if len(closedTabs) != 1 || closedTabs[0].ID != "tab_a" || closedTabs[0].SSHConfig.Password != "" {
+		t.Fatalf("restored closed tabs = %+v, want tab_a without its password", closedTabs)
+	}
+
+	// Reopening the last one removes the file
+	if _, err := restarted.ReopenClosedTab(""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(closedPath); !os.IsNotExist(err) {
+		t.Errorf("recently closed tabs file still exists after the stack emptied: %v", err)
+	}
+}
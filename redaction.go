@@ -0,0 +1,41 @@
+package main
+
+import "regexp"
+
+// redactedValue replaces a secret in anything that leaves the app, such as reports and bundles
+const redactedValue = "<redacted>"
+
+// secretNamePattern matches config fields, environment variables and command options whose
+// values are secrets. A bare "key" only counts as the last word, so API_KEY is a secret but
+// hotkey and key_path are not.
+var secretNamePattern = regexp.MustCompile(`(?i)(pass|secret|token|credential|auth|cookie|signature|private_?key|api_?key|(^|[_-])key$)`)
+
+// secretAssignmentPattern matches NAME=value and --name=value in command text
+var secretAssignmentPattern = regexp.MustCompile(`\b([A-Za-z_][A-Za-z0-9_-]*)=('[^']*'|"[^"]*"|[^\s;&|]*)`)
+
+// secretOptionPattern matches an option followed by its value as a separate argument
+var secretOptionPattern = regexp.MustCompile(`(--?[A-Za-z][A-Za-z0-9_-]*)(\s+)('[^']*'|"[^"]*"|[^\s;&|'"-][^\s;&|]*)`)
+
+// isSecretName reports whether a field, variable or option of this name holds a secret
+func isSecretName(name string) bool {
+	return secretNamePattern.MatchString(name)
+}
+
+// redactCommandSecrets blanks the values of secret-looking assignments and options in a
+// shell command
+func redactCommandSecrets(command string) string {
+	command = secretAssignmentPattern.ReplaceAllStringFunc(command, func(match string) string {
+		parts := secretAssignmentPattern.FindStringSubmatch(match)
+		if !isSecretName(parts[1]) {
+			return match
+		}
+		return parts[1] + "=" + redactedValue
+	})
+	return secretOptionPattern.ReplaceAllStringFunc(command, func(match string) string {
+		parts := secretOptionPattern.FindStringSubmatch(match)
+		if !isSecretName(parts[1]) {
+			return match
+		}
+		return parts[1] + parts[2] + redactedValue
+	})
+}
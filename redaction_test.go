@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestIsSecretName(t *testing.T) {
+	for _, name := range []string{"api_key", "password_hash", "AWS_SECRET_ACCESS_KEY", "GITHUB_TOKEN", "SSH_AUTH_SOCK", "ENCRYPTION_KEY", "key", "--password", "private_key"} {
+		if !isSecretName(name) {
+			t.Errorf("isSecretName(%q) = false", name)
+		}
+	}
+	for _, name := range []string{"hotkey", "key_path", "verify_host_key_dns", "HOME", "profiles_path", "--force"} {
+		if isSecretName(name) {
+			t.Errorf("isSecretName(%q) = true", name)
+		}
+	}
+}
+
+func TestRedactCommandSecrets(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"sudo rm -rf -- '/var/log/app'", "sudo rm -rf -- '/var/log/app'"},
+		{"sudo env DB_PASSWORD='hunter2' HOME=/root tool", "sudo env DB_PASSWORD=<redacted> HOME=/root tool"},
+		{"sudo tool --token abc123 --force", "sudo tool --token <redacted> --force"},
+		{"sudo tool --api-key=\"x y\" -p secret", "sudo tool --api-key=<redacted> -p secret"},
+		{"sudo tool --password --force", "sudo tool --password --force"},
+		{"sudo sh -c 'export TOKEN=abc; run'", "sudo sh -c 'export TOKEN=<redacted>; run'"},
+	}
+	for _, tt := range tests {
+		if got := redactCommandSecrets(tt.command); got != tt.want {
+			t.Errorf("redactCommandSecrets(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
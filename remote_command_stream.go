@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Streamed remote command constants
+const (
+	CommandOutputEvent          = "command-output"
+	CommandExitEvent            = "command-exit"
+	MaxRemoteCommandsPerSession = 10
+	remoteCommandMaxLine        = 64 * 1024 // Longer lines are sent in pieces of this size
+)
+
+// remoteCommandRun is a command started by StreamRemoteCommand
+type remoteCommandRun struct {
+	id        string
+	sessionID string
+	command   string
+	session   *ssh.Session
+	started   time.Time
+	cancelled atomic.Bool
+}
+
+var (
+	// remoteCommands holds the streamed commands still running, by command ID
+	remoteCommands   = make(map[string]*remoteCommandRun)
+	remoteCommandsMu sync.Mutex
+)
+
+// streamLines calls emit with each line read from r, without its line ending. Lines longer
+// than remoteCommandMaxLine are split.
+func streamLines(r io.Reader, emit func(line string)) {
+	reader := bufio.NewReaderSize(r, remoteCommandMaxLine)
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if len(chunk) > 0 {
+			emit(strings.TrimRight(string(chunk), "\r\n"))
+		}
+		if err != nil && !errors.Is(err, bufio.ErrBufferFull) {
+			return
+		}
+	}
+}
+
+// remoteExitCode returns the exit code of a finished command; -1 when it has none, as when
+// it was killed by a signal or its channel was closed
+func remoteExitCode(err error) int {
+	var exitErr *ssh.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		if exitErr.Signal() != "" {
+			return -1
+		}
+		return exitErr.ExitStatus()
+	}
+	return -1
+}
+
+// StreamRemoteCommand runs a command on its own channel of the session's SSH connection and
+// returns its ID straight away. Output is sent line by line in command-output events, and
+// command-exit carries the exit code once it finishes. Unlike the monitoring commands there
+// is no timeout; see CancelRemoteCommand.
+func (a *App) StreamRemoteCommand(sessionID, command string) (string, error) {
+	if strings.TrimSpace(command) == "" {
+		return "", fmt.Errorf("invalid command: cannot be empty")
+	}
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+	if !exists || sshSession == nil {
+		return "", newNotFoundError(ErrCategorySSH, "StreamRemoteCommand", "SSH session %s not found", sessionID)
+	}
+	if sshSession.client == nil {
+		return "", fmt.Errorf("SSH session %s is not connected", sessionID)
+	}
+	return startRemoteCommand(sshSession, command, appEmitter{a})
+}
+
+// startRemoteCommand starts a streamed command on the session's connection, sending its
+// events to emitter
+func startRemoteCommand(sshSession *SSHSession, command string, emitter Emitter) (string, error) {
+	sessionID := sshSession.sessionID
+
+	session, err := sshSession.client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("failed to open command channel: %w", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return "", fmt.Errorf("failed to read command output: %w", err)
+	}
+	stderr, err := session.StderrPipe()
+	if err != nil {
+		session.Close()
+		return "", fmt.Errorf("failed to read command output: %w", err)
+	}
+
+	run := &remoteCommandRun{
+		id:        fmt.Sprintf("command_%d", time.Now().UnixNano()),
+		sessionID: sessionID,
+		command:   command,
+		session:   session,
+		started:   time.Now(),
+	}
+
+	// Count and take the slot under one lock so concurrent calls can't all get past the limit
+	remoteCommandsMu.Lock()
+	running := 0
+	for _, other := range remoteCommands {
+		if other.sessionID == sessionID {
+			running++
+		}
+	}
+	if running >= MaxRemoteCommandsPerSession {
+		remoteCommandsMu.Unlock()
+		session.Close()
+		return "", fmt.Errorf("too many running commands for session %s: maximum allowed: %d", sessionID, MaxRemoteCommandsPerSession)
+	}
+	for remoteCommands[run.id] != nil {
+		// Concurrent starts can read the same clock value
+		run.id = fmt.Sprintf("command_%d", time.Now().UnixNano())
+	}
+	remoteCommands[run.id] = run
+	remoteCommandsMu.Unlock()
+
+	if err := startUserCommand(session, sessionID, "StreamRemoteCommand", command); err != nil {
+		remoteCommandsMu.Lock()
+		delete(remoteCommands, run.id)
+		remoteCommandsMu.Unlock()
+		session.Close()
+		return "", fmt.Errorf("failed to start command: %w", err)
+	}
+
+	var readers sync.WaitGroup
+	for stream, r := range map[string]io.Reader{"stdout": stdout, "stderr": stderr} {
+		readers.Add(1)
+		sshSession.goTracked(func() {
+			defer readers.Done()
+			streamLines(r, func(line string) {
+				emitter.Emit(CommandOutputEvent, map[string]interface{}{
+					"commandId": run.id,
+					"sessionId": sessionID,
+					"stream":    stream,
+					"line":      line,
+				})
+			})
+		})
+	}
+	sshSession.goTracked(func() {
+		readers.Wait()
+		waitErr := session.Wait()
+		session.Close()
+
+		remoteCommandsMu.Lock()
+		delete(remoteCommands, run.id)
+		remoteCommandsMu.Unlock()
+
+		exit := map[string]interface{}{
+			"commandId":  run.id,
+			"sessionId":  sessionID,
+			"exitCode":   remoteExitCode(waitErr),
+			"cancelled":  run.cancelled.Load(),
+			"durationMs": time.Since(run.started).Milliseconds(),
+		}
+		var exitErr *ssh.ExitError
+		if waitErr != nil && !errors.As(waitErr, &exitErr) && !run.cancelled.Load() {
+			exit["error"] = waitErr.Error()
+		}
+		emitter.Emit(CommandExitEvent, exit)
+	})
+
+	return run.id, nil
+}
+
+// cancel interrupts the command and closes its channel, which ends it even on servers that
+// ignore signals
+func (r *remoteCommandRun) cancel() {
+	r.cancelled.Store(true)
+	r.session.Signal(ssh.SIGTERM)
+	r.session.Close()
+}
+
+// CancelRemoteCommand stops a command started by StreamRemoteCommand. Its command-exit event
+// is still sent, marked cancelled.
+func (a *App) CancelRemoteCommand(commandID string) error {
+	remoteCommandsMu.Lock()
+	run, exists := remoteCommands[commandID]
+	remoteCommandsMu.Unlock()
+	if !exists {
+		return newNotFoundError(ErrCategorySSH, "CancelRemoteCommand", "command %s not found or already finished", commandID)
+	}
+	run.cancel()
+	return nil
+}
+
+// cancelRemoteCommands stops every streamed command of a session
+func cancelRemoteCommands(sessionID string) {
+	remoteCommandsMu.Lock()
+	var runs []*remoteCommandRun
+	for id, run := range remoteCommands {
+		if run.sessionID == sessionID {
+			runs = append(runs, run)
+			delete(remoteCommands, id)
+		}
+	}
+	remoteCommandsMu.Unlock()
+
+	for _, run := range runs {
+		run.cancel()
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// startExecServer runs an SSH server whose exec requests understand three commands: "count N"
+// prints N lines and a warning on stderr, "fail" exits 3, and "hang" runs until the channel closes.
+// Returns a client connected to it.
+func startExecServer(t *testing.T) *ssh.Client {
+	t.Helper()
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	exitStatus := func(channel ssh.Channel, code uint32) {
+		status := make([]byte, 4)
+		binary.BigEndian.PutUint32(status, code)
+		channel.SendRequest("exit-status", false, status)
+		channel.Close()
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					conn.Close()
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					channel, requests, err := newChannel.Accept()
+					if err != nil {
+						continue
+					}
+					go func() {
+						for req := range requests {
+							if req.Type != "exec" {
+								req.Reply(req.Type == "signal", nil)
+								continue
+							}
+							command := string(req.Payload[4:])
+							req.Reply(true, nil)
+							var n int
+							fmt.Sscanf(command, "count %d", &n)
+							switch {
+							case command == "fail":
+								exitStatus(channel, 3)
+							case command == "hang":
+								// Ends when the client closes the channel
+							case n > 0:
+								for i := 1; i <= n; i++ {
+									fmt.Fprintf(channel, "line %d\r\n", i)
+								}
+								fmt.Fprint(channel.Stderr(), "warn\n")
+								exitStatus(channel, 0)
+							default:
+								exitStatus(channel, 127)
+							}
+						}
+					}()
+				}
+			}()
+		}
+	}()
+
+	client, err := ssh.Dial("tcp", listener.Addr().String(), &ssh.ClientConfig{
+		User:            "deploy",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// waitForCommandExit polls the emitter for a command's command-exit event
+func waitForCommandExit(t *testing.T, emitter *recordingEmitter, commandID string) map[string]interface{} {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		emitter.mu.Lock()
+		for i, name := range emitter.names {
+			if name == CommandExitEvent && emitter.events[i]["commandId"] == commandID {
+				event := emitter.events[i]
+				emitter.mu.Unlock()
+				return event
+			}
+		}
+		emitter.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("no %s event for %s", CommandExitEvent, commandID)
+	return nil
+}
+
+// commandOutput returns the lines a command sent on a stream
+func commandOutput(emitter *recordingEmitter, commandID, stream string) []string {
+	emitter.mu.Lock()
+	defer emitter.mu.Unlock()
+	var lines []string
+	for i, name := range emitter.names {
+		event := emitter.events[i]
+		if name == CommandOutputEvent && event["commandId"] == commandID && event["stream"] == stream {
+			lines = append(lines, event["line"].(string))
+		}
+	}
+	return lines
+}
+
+func TestStreamRemoteCommand(t *testing.T) {
+	sshSession := &SSHSession{sessionID: "session_stream", client: startExecServer(t)}
+	emitter := &recordingEmitter{}
+
+	commandID, err := startRemoteCommand(sshSession, "count 3", emitter)
+	if err != nil {
+		t.Fatalf("startRemoteCommand() returned error: %v", err)
+	}
+	exit := waitForCommandExit(t, emitter, commandID)
+	if exit["exitCode"] != 0 || exit["cancelled"] != false || exit["error"] != nil {
+		t.Errorf("exit event = %v, want exit code 0", exit)
+	}
+	if got := strings.Join(commandOutput(emitter, commandID, "stdout"), "|"); got != "line 1|line 2|line 3" {
+		t.Errorf("stdout lines = %q, want three lines without line endings", got)
+	}
+	if got := commandOutput(emitter, commandID, "stderr"); len(got) != 1 || got[0] != "warn" {
+		t.Errorf("stderr lines = %q, want [warn]", got)
+	}
+
+	commandID, err = startRemoteCommand(sshSession, "fail", emitter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exit := waitForCommandExit(t, emitter, commandID); exit["exitCode"] != 3 {
+		t.Errorf("exit event = %v, want exit code 3", exit)
+	}
+}
+
+func TestCancelRemoteCommand(t *testing.T) {
+	app := NewApp()
+	sshSession := &SSHSession{sessionID: "session_cancel", client: startExecServer(t)}
+	emitter := &recordingEmitter{}
+
+	commandID, err := startRemoteCommand(sshSession, "hang", emitter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.CancelRemoteCommand(commandID); err != nil {
+		t.Fatalf("CancelRemoteCommand() returned error: %v", err)
+	}
+	if exit := waitForCommandExit(t, emitter, commandID); exit["cancelled"] != true || exit["exitCode"] != -1 {
+		t.Errorf("exit event = %v, want a cancelled command", exit)
+	}
+	if err := app.CancelRemoteCommand(commandID); toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("CancelRemoteCommand() of a finished command = %v, want not found", err)
+	}
+
+	// Closing the session stops its commands
+	commandID, err = startRemoteCommand(sshSession, "hang", emitter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.ReleaseSession(sshSession.sessionID)
+	if exit := waitForCommandExit(t, emitter, commandID); exit["cancelled"] != true {
+		t.Errorf("exit event after the session closed = %v, want cancelled", exit)
+	}
+}
+
+func TestStreamRemoteCommandLimitUnderConcurrency(t *testing.T) {
+	sshSession := &SSHSession{sessionID: "session_stream_limit", client: startExecServer(t)}
+	defer cancelRemoteCommands(sshSession.sessionID)
+	emitter := &recordingEmitter{}
+
+	var started atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < MaxRemoteCommandsPerSession+5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := startRemoteCommand(sshSession, "hang", emitter); err == nil {
+				started.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := started.Load(); got != MaxRemoteCommandsPerSession {
+		t.Errorf("%d commands started at once, want the limit of %d", got, MaxRemoteCommandsPerSession)
+	}
+}
+
+func TestStreamLinesSplitsLongLines(t *testing.T) {
+	long := strings.Repeat("x", remoteCommandMaxLine+10)
+	var lines []string
+	streamLines(strings.NewReader("short\r\n"+long+"\nlast"), func(line string) { lines = append(lines, line) })
+	if len(lines) != 4 || lines[0] != "short" || len(lines[1])+len(lines[2]) != len(long) || lines[3] != "last" {
+		t.Errorf("streamLines() gave %d lines, want short, the long line in two pieces, and last", len(lines))
+	}
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// MaxSudoAuditEntries is how many sudo invocations are kept per session
+const MaxSudoAuditEntries = 200
+
+// remoteCommand is a shell command built inside this package: a constant, or the result of
+// buildRemoteCommand, which quotes every argument. Strings don't convert to it implicitly, so
+// frontend input can't reach a remote shell without going through a builder. Commands typed by
+// the user are the one exception and go through runUserCommand.
+type remoteCommand string
+
+// Monitoring and statistics commands. These are the only fixed commands the monitoring session
+// runs; anything with an argument is built with buildRemoteCommand.
+const (
+	remoteCmdWorkingDir    remoteCommand = "pwd"
+	remoteCmdHostname      remoteCommand = "hostname"
+	remoteCmdKernel        remoteCommand = "uname -sr"
+	remoteCmdArch          remoteCommand = "uname -m"
+	remoteCmdCPUCount      remoteCommand = "nproc 2>/dev/null || getconf _NPROCESSORS_ONLN 2>/dev/null || grep -c ^processor /proc/cpuinfo 2>/dev/null"
+	remoteCmdMemTotalKB    remoteCommand = "grep MemTotal /proc/meminfo 2>/dev/null | awk '{print $2}' | tr -d ' '"
+	remoteCmdMemTotalKBAlt remoteCommand = "cat /proc/meminfo 2>/dev/null | grep MemTotal | sed 's/[^0-9]//g'"
+	remoteCmdUptime        remoteCommand = "uptime -p 2>/dev/null"
+	remoteCmdUptimeRaw     remoteCommand = "uptime"
+	remoteCmdMeminfo       remoteCommand = "cat /proc/meminfo 2>/dev/null"
+	remoteCmdFree          remoteCommand = "free -m 2>/dev/null"
+	remoteCmdProcStatCPU   remoteCommand = "grep '^cpu' /proc/stat 2>/dev/null"
+	remoteCmdTopCPU        remoteCommand = "top -bn1 | grep '^%Cpu' | head -1"
+	remoteCmdLoadavg       remoteCommand = "cat /proc/loadavg 2>/dev/null"
+	remoteCmdRouteTable    remoteCommand = "ip route show 2>/dev/null || netstat -rn 2>/dev/null"
+	remoteCmdARPTable      remoteCommand = "arp -n 2>/dev/null || ip neigh show 2>/dev/null"
+	remoteCmdListenSockets remoteCommand = `echo "#uid $(id -u)"; if command -v ss >/dev/null 2>&1; then echo '#ss'; ss -tulpn; elif command -v netstat >/dev/null 2>&1; then echo '#netstat'; netstat -tulpn; else for f in tcp tcp6 udp udp6; do echo "#proc $f"; cat /proc/net/$f 2>/dev/null; done; fi`
+	remoteCmdConnections   remoteCommand = `echo "#uid $(id -u)"; if command -v ss >/dev/null 2>&1; then echo '#ss'; ss -tupn; elif command -v netstat >/dev/null 2>&1; then echo '#netstat'; netstat -tupn; else for f in tcp tcp6 udp udp6; do echo "#proc $f"; cat /proc/net/$f 2>/dev/null; done; fi`
+	remoteCmdListUsers     remoteCommand = "{ getent passwd 2>/dev/null || cat /etc/passwd; } | cut -d: -f1,3,5"
+	remoteCmdListGroups    remoteCommand = "{ getent group 2>/dev/null || cat /etc/group; } | cut -d: -f1,3"
+	remoteCmdPrimaryNetDev remoteCommand = "cat /proc/net/dev 2>/dev/null | grep -E '(eth|ens|enp|eno)[0-9]:' | head -1"
+	remoteCmdAnyNetDev     remoteCommand = "cat /proc/net/dev 2>/dev/null | grep -vE 'lo:|docker|veth|Inter|face|dummy|tunl|sit|bond' | grep ':' | grep -E '[0-9]' | head -1"
+	remoteCmdIPLinkStats   remoteCommand = "ip -s link 2>/dev/null | grep -A3 -E 'eth|ens|enp|wlan|wlp' | head -6"
+	remoteCmdDiskstats     remoteCommand = "cat /proc/diskstats 2>/dev/null | grep -E '(sda|nvme0n1|vda|xvda|hda)\\s' | head -1"
+	remoteCmdProcessRSS    remoteCommand = "ps -eo pid=,rss=,args= 2>/dev/null" // Filtered locally, so a pattern never reaches the shell
+	remoteCmdHasInotify    remoteCommand = "command -v inotifywait"             // Fails when directory watches have to poll
+	remoteCmdMounts        remoteCommand = "cat /proc/mounts"
+)
+
+// buildRemoteCommand fills the %s verbs of a command template with arguments quoted as single
+// shell words. Templates are constants in this package; use %[n]s to repeat an argument.
+func buildRemoteCommand(template string, args ...string) remoteCommand {
+	quoted := make([]interface{}, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return remoteCommand(fmt.Sprintf(template, quoted...))
+}
+
+// normalizeRemotePath validates a remote path passed in from the frontend and cleans it.
+// Only absolute paths without ".." segments or control characters are accepted, so a path
+// can't walk out of the directory the user picked or smuggle a second line into a command.
+func normalizeRemotePath(remotePath string) (string, error) {
+	if remotePath == "" {
+		return "", fmt.Errorf("remote path cannot be empty")
+	}
+	for _, r := range remotePath {
+		if r < 0x20 || r == 0x7f {
+			return "", fmt.Errorf("remote path contains a control character: %q", remotePath)
+		}
+	}
+	if !strings.HasPrefix(remotePath, "/") {
+		return "", fmt.Errorf("remote path must be absolute: %q", remotePath)
+	}
+	for _, segment := range strings.Split(remotePath, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("remote path must not contain '..': %q", remotePath)
+		}
+	}
+	return path.Clean(remotePath), nil
+}
+
+// sudoPathCommand builds a sudo command for a file operation on validated paths. The paths
+// follow "--", so a name starting with "-" can't become an option.
+func sudoPathCommand(template string, paths ...string) (remoteCommand, error) {
+	normalized := make([]string, len(paths))
+	for i, remotePath := range paths {
+		clean, err := normalizeRemotePath(remotePath)
+		if err != nil {
+			return "", err
+		}
+		normalized[i] = clean
+	}
+	return buildRemoteCommand(template, normalized...), nil
+}
+
+// Sudo-backed file operations; each takes only validated paths
+func sudoListDirectoryCommand(remotePath string) (remoteCommand, error) {
+	return sudoPathCommand("sudo ls -la --time-style='+%%Y-%%m-%%d %%H:%%M:%%S' -- %s 2>&1", remotePath)
+}
+
+func sudoMkdirCommand(remotePath string) (remoteCommand, error) {
+	return sudoPathCommand("sudo mkdir -p -- %s", remotePath)
+}
+
+// sudoCreateFileCommand creates an empty file, printing "exists" instead when the path is taken
+func sudoCreateFileCommand(remotePath string) (remoteCommand, error) {
+	return sudoPathCommand("sudo test -e %[1]s && echo exists || sudo touch -- %[1]s 2>&1", remotePath)
+}
+
+func sudoRemoveCommand(remotePath string) (remoteCommand, error) {
+	clean, err := normalizeRemotePath(remotePath)
+	if err != nil {
+		return "", err
+	}
+	if clean == "/" {
+		return "", fmt.Errorf("refusing to delete the root directory")
+	}
+	return buildRemoteCommand("sudo rm -rf -- %s 2>&1", clean), nil
+}
+
+// sudoMoveDestinationExists is what sudoMoveCommand prints instead of moving onto an existing path
+const sudoMoveDestinationExists = "destination-exists"
+
+// sudoMoveCommand never replaces an existing destination, nor moves into it when it is a
+// directory; it prints sudoMoveDestinationExists instead
+func sudoMoveCommand(oldPath, newPath string) (remoteCommand, error) {
+	return sudoPathCommand("if sudo test -e %[2]s || sudo test -L %[2]s; then echo "+sudoMoveDestinationExists+"; else sudo mv -- %[1]s %[2]s 2>&1; fi", oldPath, newPath)
+}
+
+func sudoReadFileCommand(remotePath string) (remoteCommand, error) {
+	return sudoPathCommand("sudo cat -- %s", remotePath)
+}
+
+// sudoReadFileHeadCommand reads at most maxBytes of a file; head runs unprivileged on cat's output
+func sudoReadFileHeadCommand(remotePath string, maxBytes int64) (remoteCommand, error) {
+	cmd, err := sudoReadFileCommand(remotePath)
+	if err != nil {
+		return "", err
+	}
+	return cmd + buildRemoteCommand(" | head -c %s", strconv.FormatInt(maxBytes, 10)), nil
+}
+
+func sudoFileSizeCommand(remotePath string) (remoteCommand, error) {
+	return sudoPathCommand("sudo wc -c -- %s", remotePath)
+}
+
+func sudoWriteFileCommand(remotePath string) (remoteCommand, error) {
+	return sudoPathCommand("sudo tee -- %s > /dev/null", remotePath)
+}
+
+// sudoAppendFileCommand appends stdin to a file and prints its new size as wc -c does
+func sudoAppendFileCommand(remotePath string) (remoteCommand, error) {
+	return sudoPathCommand("sudo tee -a -- %[1]s > /dev/null && sudo wc -c -- %[1]s", remotePath)
+}
+
+// filesystemStatsCommand prints the POSIX df line of the filesystem holding a path, in KiB
+func filesystemStatsCommand(remotePath string) remoteCommand {
+	return buildRemoteCommand("df -Pk -- %s 2>/dev/null | tail -n 1", remotePath)
+}
+
+// writePermissionCommand prints "ok" when entries can be created in a directory, which needs
+// write and search permission, "denied" when they can't and "missing" when it isn't a directory
+func writePermissionCommand(remotePath string) remoteCommand {
+	return buildRemoteCommand("if test -d %[1]s; then test -w %[1]s && test -x %[1]s && echo ok || echo denied; else echo missing; fi", remotePath)
+}
+
+// sudoShellCommand runs one of the fixed commands above as root
+func sudoShellCommand(command remoteCommand) remoteCommand {
+	return buildRemoteCommand("sudo sh -c %s", string(command))
+}
+
+// SudoAuditEntry records one sudo invocation on a remote host
+type SudoAuditEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Feature   string    `json:"feature"` // The operation that ran it, e.g. DeleteRemotePathWithSudo
+	Command   string    `json:"command"`
+}
+
+var sudoAuditLogs = make(map[string][]SudoAuditEntry)
+var sudoAuditLogsMu sync.Mutex
+
+// recordSudoInvocation appends to the session's sudo audit log, dropping the oldest entries
+// past MaxSudoAuditEntries
+func recordSudoInvocation(sessionID, feature string, command remoteCommand) {
+	sudoAuditLogsMu.Lock()
+	defer sudoAuditLogsMu.Unlock()
+
+	log := append(sudoAuditLogs[sessionID], SudoAuditEntry{Timestamp: time.Now(), Feature: feature, Command: string(command)})
+	if len(log) > MaxSudoAuditEntries {
+		log = append([]SudoAuditEntry(nil), log[len(log)-MaxSudoAuditEntries:]...)
+	}
+	sudoAuditLogs[sessionID] = log
+	fmt.Printf("sudo [%s] %s: %s\n", sessionID, feature, command)
+}
+
+// forgetSudoAuditLog drops a session's sudo audit log
+func forgetSudoAuditLog(sessionID string) {
+	sudoAuditLogsMu.Lock()
+	defer sudoAuditLogsMu.Unlock()
+	delete(sudoAuditLogs, sessionID)
+}
+
+// GetSudoAuditLog returns every sudo command run on a session's host, oldest first
+func (a *App) GetSudoAuditLog(sessionID string) []SudoAuditEntry {
+	sudoAuditLogsMu.Lock()
+	defer sudoAuditLogsMu.Unlock()
+	return append([]SudoAuditEntry{}, sudoAuditLogs[sessionID]...)
+}
+
+// runSudoCommand audits a sudo command and runs it on the monitoring session
+func (a *App) runSudoCommand(sshSession *SSHSession, feature string, command remoteCommand) (string, error) {
+	recordSudoInvocation(sshSession.sessionID, feature, command)
+	return a.executeMonitoringCommand(sshSession, command)
+}
+
+// runSudoCommandWithInput audits a sudo command and runs it on its own monitoring channel with
+// input on stdin and no timeout, for file contents. Cancelling ctx closes the channel, which
+// stops the command. stderr is returned in the error.
+func (a *App) runSudoCommandWithInput(ctx context.Context, sshSession *SSHSession, feature string, command remoteCommand, input []byte) ([]byte, error) {
+	sshSession.monitoringMutex.RLock()
+	monitoringEnabled := sshSession.monitoringEnabled
+	monitoringClient := sshSession.monitoringClient
+	sshSession.monitoringMutex.RUnlock()
+
+	if !monitoringEnabled || monitoringClient == nil {
+		return nil, fmt.Errorf("monitoring session not available - cannot use sudo")
+	}
+
+	session, err := monitoringClient.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SSH session for sudo: %w", err)
+	}
+	defer session.Close()
+	stop := context.AfterFunc(ctx, func() { session.Close() })
+	defer stop()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if input != nil {
+		session.Stdin = bytes.NewReader(input)
+	}
+
+	recordSudoInvocation(sshSession.sessionID, feature, command)
+	if err := session.Run(string(command)); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%s", message)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// runUserCommand runs a command the user wrote (a connect hook) on the session's SSH connection
+// and returns its combined output
+func (a *App) runUserCommand(ctx context.Context, sessionID, feature, command string) ([]byte, error) {
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+	if !exists || sshSession.client == nil {
+		return nil, fmt.Errorf("SSH session %s not found", sessionID)
+	}
+
+	session, err := sshSession.client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open command channel: %w", err)
+	}
+	defer session.Close()
+
+	// Closing the channel is the only way to stop a remote command
+	stop := context.AfterFunc(ctx, func() { session.Close() })
+	defer stop()
+
+	var output combinedOutput
+	session.Stdout = &output
+	session.Stderr = &output
+	if err := startUserCommand(session, sessionID, feature, command); err != nil {
+		return nil, err
+	}
+	err = session.Wait()
+	return output.buf.Bytes(), err
+}
+
+// startUserCommand starts a command the user wrote on an open channel. It is the only way
+// free-form text reaches a remote shell, so every such command is logged here.
+func startUserCommand(session *ssh.Session, sessionID, feature, command string) error {
+	fmt.Printf("User command [%s] %s: %s\n", sessionID, feature, command)
+	// Exec requests run in a non-interactive shell, which keeps no history
+	return session.Start(command)
+}
+
+// combinedOutput collects a command's stdout and stderr, which the ssh package copies from
+// separate goroutines
+type combinedOutput struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (c *combinedOutput) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.Write(p)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeRemotePath(t *testing.T) {
+	valid := map[string]string{
+		"/var/log":          "/var/log",
+		"/var//log/":        "/var/log",
+		"/srv/./data":       "/srv/data",
+		"/tmp/it's $(id)":   "/tmp/it's $(id)",
+		"/opt/..hidden/x..": "/opt/..hidden/x..",
+	}
+	for input, want := range valid {
+		got, err := normalizeRemotePath(input)
+		if err != nil || got != want {
+			t.Errorf("normalizeRemotePath(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{
+		"",
+		"etc/shadow",
+		"../../etc/shadow",
+		"/var/www/../../etc/shadow",
+		"/srv/..",
+		"/tmp/x\nrm -rf /",
+		"/tmp/x\x00y",
+	} {
+		if got, err := normalizeRemotePath(input); err == nil {
+			t.Errorf("normalizeRemotePath(%q) = %q, want an error", input, got)
+		}
+	}
+}
+
+func TestSudoCommandsRejectTraversalAndQuoteInjection(t *testing.T) {
+	builders := map[string]func(string) (remoteCommand, error){
+		"list":   sudoListDirectoryCommand,
+		"mkdir":  sudoMkdirCommand,
+		"touch":  sudoCreateFileCommand,
+		"remove": sudoRemoveCommand,
+		"read":   sudoReadFileCommand,
+		"write":  sudoWriteFileCommand,
+		"append": sudoAppendFileCommand,
+		"move":   func(p string) (remoteCommand, error) { return sudoMoveCommand(p, "/tmp/target") },
+		"chown":  func(p string) (remoteCommand, error) { return chownCommand(p, "deploy", "", false) },
+	}
+
+	for name, build := range builders {
+		for _, bad := range []string{"/etc/../root/.ssh/authorized_keys", "relative/path", "/tmp/a\n sudo reboot"} {
+			if cmd, err := build(bad); err == nil {
+				t.Errorf("%s accepted %q: %s", name, bad, cmd)
+			}
+		}
+
+		// Quotes and substitutions stay inside one single-quoted word
+		injection := `/tmp/x'; reboot; echo '$(id)` + "`id`"
+		cmd, err := build(injection)
+		if err != nil {
+			t.Errorf("%s rejected a valid (if hostile) file name: %v", name, err)
+			continue
+		}
+		if !strings.Contains(string(cmd), shellQuote(injection)) {
+			t.Errorf("%s did not quote the path as one word: %s", name, cmd)
+		}
+		if strings.Contains(string(cmd), `"`) {
+			t.Errorf("%s uses double quotes, which still expand $(...): %s", name, cmd)
+		}
+	}
+
+	if _, err := sudoRemoveCommand("/"); err == nil {
+		t.Error("sudoRemoveCommand accepted the root directory")
+	}
+	if _, err := sudoMoveCommand("/tmp/a", "/tmp/../etc/passwd"); err == nil {
+		t.Error("sudoMoveCommand accepted a traversing destination")
+	}
+}
+
+func TestBuildRemoteCommand(t *testing.T) {
+	got := string(buildRemoteCommand("test -r %[1]s && ls -- %[1]s %[2]s", "/a b", "/c'd"))
+	if want := `test -r '/a b' && ls -- '/a b' '/c'\''d'`; got != want {
+		t.Errorf("buildRemoteCommand() = %s, want %s", got, want)
+	}
+}
+
+func TestSudoAuditLog(t *testing.T) {
+	app := NewApp()
+	sessionID := "session_sudo_audit"
+	defer forgetSudoAuditLog(sessionID)
+
+	for i := 0; i < MaxSudoAuditEntries+5; i++ {
+		recordSudoInvocation(sessionID, "DeleteRemotePathWithSudo", remoteCommand(fmt.Sprintf("sudo rm -rf -- '/tmp/%d'", i)))
+	}
+
+	log := app.GetSudoAuditLog(sessionID)
+	if len(log) != MaxSudoAuditEntries {
+		t.Fatalf("audit log has %d entries, want %d", len(log), MaxSudoAuditEntries)
+	}
+	if log[0].Command != "sudo rm -rf -- '/tmp/5'" || log[0].Feature != "DeleteRemotePathWithSudo" || log[0].Timestamp.IsZero() {
+		t.Errorf("oldest entry = %+v", log[0])
+	}
+	if len(app.GetSudoAuditLog("session_unknown")) != 0 {
+		t.Error("unknown session has audit entries")
+	}
+}
+
+func TestWritePermissionCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(remotePath string) (bool, error) {
+		output, err := exec.Command("sh", "-c", string(writePermissionCommand(remotePath))).CombinedOutput()
+		if err != nil {
+			t.Fatalf("check of %s failed: %v: %s", remotePath, err, output)
+		}
+		return parseWritePermissionOutput(remotePath, string(output))
+	}
+
+	if writable, err := check(dir); err != nil || !writable {
+		t.Errorf("temp directory: writable = %v, err = %v", writable, err)
+	}
+	for _, missing := range []string{filepath.Join(dir, "gone"), file} {
+		if _, err := check(missing); toThermicError(err).Code != ErrCodeNotFound {
+			t.Errorf("%s: err = %v, want not found", missing, err)
+		}
+	}
+	if writable, err := parseWritePermissionOutput(dir, "denied\n"); err != nil || writable {
+		t.Errorf("denied: writable = %v, err = %v", writable, err)
+	}
+	if _, err := parseWritePermissionOutput(dir, "sh: test: not found\n"); err == nil {
+		t.Error("unexpected output taken as an answer")
+	}
+}
+
+func TestRunSudoCommandWithInputCancel(t *testing.T) {
+	app := NewApp()
+	sshSession := &SSHSession{sessionID: "session_sudo_cancel", monitoringEnabled: true, monitoringClient: startExecServer(t)}
+	defer forgetSudoAuditLog(sshSession.sessionID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := app.runSudoCommandWithInput(ctx, sshSession, "Test", remoteCommand("hang"), nil)
+		done <- err
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("cancelled command reported success")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelling didn't stop the command")
+	}
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Remote directory comparison constants
+const (
+	DirDiffReasonType     = "type"     // A file on one side, a directory or link on the other
+	DirDiffReasonSize     = "size"     // Files of different sizes
+	DirDiffReasonChecksum = "checksum" // Files of the same size with different content
+	DirDiffReasonTarget   = "target"   // Symlinks pointing at different paths
+
+	DirCompareMethodRemote = "remote" // Listed with find and hashed with sha256sum on the host
+	DirCompareMethodSFTP   = "sftp"   // Walked and hashed over SFTP, for hosts without GNU find
+
+	CompareRemoteDirectoriesTimeout = 5 * time.Minute
+	MaxCompareEntries               = 200000 // Entries per directory tree
+)
+
+// Exit codes of dirTreeCommand
+const (
+	dirTreeExitNoDir  = 2 // The directory can't be entered
+	dirTreeExitNoFind = 3 // find doesn't support -printf, as on BusyBox
+)
+
+// CompareOptions tunes CompareRemoteDirectoriesWithOptions
+type CompareOptions struct {
+	Checksum bool `json:"checksum"` // Compare the content of files of the same size with SHA-256
+}
+
+// DirDiffEntry is a path present in both directories that differs between them
+type DirDiffEntry struct {
+	Path   string `json:"path"`   // Relative to both directories
+	Reason string `json:"reason"` // "type", "size", "checksum" or "target"
+	SizeA  int64  `json:"sizeA"`
+	SizeB  int64  `json:"sizeB"`
+}
+
+// DirDiff is the difference between two remote directory trees. A directory found on one side
+// only is listed without its contents.
+type DirDiff struct {
+	PathA     string         `json:"pathA"`
+	PathB     string         `json:"pathB"`
+	OnlyInA   []string       `json:"onlyInA"`
+	OnlyInB   []string       `json:"onlyInB"`
+	Differing []DirDiffEntry `json:"differing"`
+	Unchecked []string       `json:"unchecked"` // Same-size files whose content couldn't be read on a side
+	Identical int            `json:"identical"` // Entries the same on both sides
+	Checksum  bool           `json:"checksum"`
+	Method    string         `json:"method"` // "remote" or "sftp"
+}
+
+// dirTreeEntry is what a listing records of an entry
+type dirTreeEntry struct {
+	kind   byte // 'f' file, 'd' directory, 'l' symlink; other types as find's %y prints them
+	size   int64
+	target string // Symlink target
+}
+
+// dirTreeCommand lists a directory tree as NUL-terminated records: "<type> <size> <relative
+// path>" followed by the symlink target, empty for other entries
+func dirTreeCommand(dir string) remoteCommand {
+	return buildRemoteCommand(`cd -- %s 2>/dev/null || exit 2; find . -maxdepth 0 -printf '' 2>/dev/null || exit 3; find . -mindepth 1 -printf '%%y %%s %%P\0%%l\0' 2>/dev/null; exit 0`, dir)
+}
+
+// checksumCommand prints the SHA-256 of each NUL-terminated relative path on stdin, one per
+// line in the same order, or "-" for a file that can't be read
+func checksumCommand(dir string) remoteCommand {
+	return buildRemoteCommand(`cd -- %s || exit 2; while IFS= read -r -d '' f; do h=$(sha256sum < "$f" 2>/dev/null) || h=-; printf '%%s\n' "${h%%%% *}"; done`, dir)
+}
+
+// parseDirTree parses the output of dirTreeCommand
+func parseDirTree(output []byte) (map[string]dirTreeEntry, error) {
+	fields := bytes.Split(output, []byte{0})
+	entries := make(map[string]dirTreeEntry)
+	for i := 0; i+1 < len(fields); i += 2 {
+		parts := strings.SplitN(string(fields[i]), " ", 3)
+		if len(parts) != 3 || len(parts[0]) != 1 || parts[2] == "" {
+			return nil, fmt.Errorf("invalid directory listing record %q", fields[i])
+		}
+		size, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid size in directory listing record %q", fields[i])
+		}
+		entries[parts[2]] = dirTreeEntry{kind: parts[0][0], size: size, target: string(fields[i+1])}
+		if len(entries) > MaxCompareEntries {
+			return nil, fmt.Errorf("directory has too many entries to compare: maximum allowed: %d", MaxCompareEntries)
+		}
+	}
+	return entries, nil
+}
+
+// diffDirTrees compares two listings by type, size and symlink target. The files the same on
+// both sides by those are returned as candidates for a content check, and counted identical.
+func diffDirTrees(a, b map[string]dirTreeEntry) (DirDiff, []string) {
+	diff := DirDiff{OnlyInA: []string{}, OnlyInB: []string{}, Differing: []DirDiffEntry{}, Unchecked: []string{}}
+	var candidates []string
+
+	// A directory on one side only stands for everything under it
+	onlyDirs := make(map[string]bool)
+	underOnlyDir := func(rel string) bool {
+		for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+			if onlyDirs[dir] {
+				return true
+			}
+		}
+		return false
+	}
+	only := func(from, other map[string]dirTreeEntry) []string {
+		var paths []string
+		for rel, entry := range from {
+			if _, exists := other[rel]; exists {
+				continue
+			}
+			paths = append(paths, rel)
+			if entry.kind == 'd' {
+				onlyDirs[rel] = true
+			}
+		}
+		return paths
+	}
+	for _, paths := range [][]string{only(a, b), only(b, a)} {
+		for _, rel := range paths {
+			if underOnlyDir(rel) {
+				continue
+			}
+			if _, inA := a[rel]; inA {
+				diff.OnlyInA = append(diff.OnlyInA, rel)
+			} else {
+				diff.OnlyInB = append(diff.OnlyInB, rel)
+			}
+		}
+	}
+
+	for rel, entryA := range a {
+		entryB, exists := b[rel]
+		if !exists {
+			continue
+		}
+		reason := ""
+		switch {
+		case entryA.kind != entryB.kind:
+			reason = DirDiffReasonType
+		case entryA.kind == 'l' && entryA.target != entryB.target:
+			reason = DirDiffReasonTarget
+		case entryA.kind == 'f' && entryA.size != entryB.size:
+			reason = DirDiffReasonSize
+		}
+		if reason != "" {
+			diff.Differing = append(diff.Differing, DirDiffEntry{Path: rel, Reason: reason, SizeA: entryA.size, SizeB: entryB.size})
+			continue
+		}
+		diff.Identical++
+		if entryA.kind == 'f' {
+			candidates = append(candidates, rel)
+		}
+	}
+
+	sort.Strings(diff.OnlyInA)
+	sort.Strings(diff.OnlyInB)
+	sort.Slice(diff.Differing, func(i, j int) bool { return diff.Differing[i].Path < diff.Differing[j].Path })
+	sort.Strings(candidates)
+	return diff, candidates
+}
+
+// applyChecksums moves candidates whose hashes differ from identical to differing. An empty
+// hash means the file couldn't be read.
+func applyChecksums(diff *DirDiff, a map[string]dirTreeEntry, candidates []string, hashesA, hashesB []string) {
+	diff.Checksum = true
+	for i, rel := range candidates {
+		switch {
+		case hashesA[i] == "" || hashesB[i] == "":
+			diff.Identical--
+			diff.Unchecked = append(diff.Unchecked, rel)
+		case hashesA[i] != hashesB[i]:
+			diff.Identical--
+			size := a[rel].size
+			diff.Differing = append(diff.Differing, DirDiffEntry{Path: rel, Reason: DirDiffReasonChecksum, SizeA: size, SizeB: size})
+		}
+	}
+	sort.Slice(diff.Differing, func(i, j int) bool { return diff.Differing[i].Path < diff.Differing[j].Path })
+}
+
+// runMonitoringCommandWithInput runs a command on its own monitoring channel with input on
+// stdin, until it exits or ctx ends. A non-zero exit is returned as *ssh.ExitError.
+func runMonitoringCommandWithInput(ctx context.Context, sshSession *SSHSession, command remoteCommand, input []byte) ([]byte, error) {
+	sshSession.monitoringMutex.RLock()
+	monitoringEnabled := sshSession.monitoringEnabled
+	monitoringClient := sshSession.monitoringClient
+	sshSession.monitoringMutex.RUnlock()
+	if !monitoringEnabled || monitoringClient == nil {
+		return nil, errNoMonitoring
+	}
+
+	session, err := monitoringClient.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create monitoring session: %w", err)
+	}
+	defer session.Close()
+	stop := context.AfterFunc(ctx, func() { session.Close() })
+	defer stop()
+
+	var stdout bytes.Buffer
+	session.Stdout = &stdout
+	session.Stdin = bytes.NewReader(input)
+	// The commands are bash; the login shell may not be
+	err = session.Run("HISTFILE=/dev/null bash -c " + shellQuote(string(command)))
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("command timed out: %w", ctx.Err())
+	}
+	return stdout.Bytes(), err
+}
+
+// Errors that send a comparison to the SFTP fallback
+var (
+	errNoMonitoring = errors.New("monitoring session not available")
+	errNoGNUFind    = errors.New("find on the host doesn't support -printf")
+)
+
+// listDirTreeRemote lists a directory tree with find on the host
+func listDirTreeRemote(ctx context.Context, sshSession *SSHSession, dir string) (map[string]dirTreeEntry, error) {
+	output, err := runMonitoringCommandWithInput(ctx, sshSession, dirTreeCommand(dir), nil)
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		switch exitErr.ExitStatus() {
+		case dirTreeExitNoDir:
+			return nil, fmt.Errorf("failed to open directory %s: it doesn't exist or isn't readable", dir)
+		case dirTreeExitNoFind:
+			return nil, errNoGNUFind
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	return parseDirTree(output)
+}
+
+// checksumsRemote hashes files relative to dir with sha256sum on the host, returning "" for
+// files that couldn't be read
+func checksumsRemote(ctx context.Context, sshSession *SSHSession, dir string, files []string) ([]string, error) {
+	var input bytes.Buffer
+	for _, rel := range files {
+		input.WriteString(rel)
+		input.WriteByte(0)
+	}
+	output, err := runMonitoringCommandWithInput(ctx, sshSession, checksumCommand(dir), input.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash files in %s: %w", dir, err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")
+	if len(files) == 0 {
+		lines = nil
+	}
+	if len(lines) != len(files) {
+		return nil, fmt.Errorf("hashed %d of %d files in %s", len(lines), len(files), dir)
+	}
+	for i, line := range lines {
+		if line == "-" {
+			lines[i] = ""
+		}
+	}
+	return lines, nil
+}
+
+// listDirTreeSFTP lists a directory tree over SFTP
+func listDirTreeSFTP(client *sftp.Client, dir string) (map[string]dirTreeEntry, error) {
+	entries := make(map[string]dirTreeEntry)
+	walker := client.Walk(dir)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			if walker.Path() == dir {
+				return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+			}
+			continue // Like find, carry on past unreadable subdirectories
+		}
+		if walker.Path() == dir {
+			continue
+		}
+		rel := strings.TrimPrefix(walker.Path(), strings.TrimSuffix(dir, "/")+"/")
+		info := walker.Stat()
+		entry := dirTreeEntry{kind: 'f', size: info.Size()}
+		switch {
+		case info.IsDir():
+			entry.kind = 'd'
+		case info.Mode()&os.ModeSymlink != 0:
+			entry.kind = 'l'
+		case !info.Mode().IsRegular():
+			entry.kind = 'o'
+		}
+		if entry.kind == 'l' {
+			entry.target, _ = client.ReadLink(walker.Path())
+		}
+		entries[rel] = entry
+		if len(entries) > MaxCompareEntries {
+			return nil, fmt.Errorf("directory has too many entries to compare: maximum allowed: %d", MaxCompareEntries)
+		}
+	}
+	return entries, nil
+}
+
+// checksumsSFTP hashes files relative to dir by reading them over SFTP
+func checksumsSFTP(ctx context.Context, client *sftp.Client, dir string, files []string) ([]string, error) {
+	hashes := make([]string, len(files))
+	for i, rel := range files {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("comparison timed out: %w", ctx.Err())
+		}
+		file, err := client.Open(joinRemotePath(dir, rel))
+		if err != nil {
+			continue
+		}
+		hash := sha256.New()
+		if _, err := io.Copy(hash, file); err == nil {
+			hashes[i] = hex.EncodeToString(hash.Sum(nil))
+		}
+		file.Close()
+	}
+	return hashes, nil
+}
+
+// CompareRemoteDirectories reports the files only in pathA, only in pathB, and in both but with
+// a different type or size, such as a deployment against its source or a backup against the
+// original
+func (a *App) CompareRemoteDirectories(sessionID, pathA, pathB string) (DirDiff, error) {
+	return a.CompareRemoteDirectoriesWithOptions(sessionID, pathA, pathB, CompareOptions{})
+}
+
+// CompareRemoteDirectoriesWithOptions compares two directory trees on a session's host, and
+// with opts.Checksum also the content of files of the same size. The trees are listed and
+// hashed on the host when the monitoring session is up and find supports -printf; otherwise
+// over SFTP, which reads every file it hashes across the network.
+func (a *App) CompareRemoteDirectoriesWithOptions(sessionID, pathA, pathB string, opts CompareOptions) (DirDiff, error) {
+	cleanA, err := normalizeRemotePath(pathA)
+	if err != nil {
+		return DirDiff{}, err
+	}
+	cleanB, err := normalizeRemotePath(pathB)
+	if err != nil {
+		return DirDiff{}, err
+	}
+	if cleanA == cleanB {
+		return DirDiff{}, fmt.Errorf("invalid comparison: %s is compared with itself", cleanA)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), CompareRemoteDirectoriesTimeout)
+	defer cancel()
+
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+	if sshSession != nil {
+		diff, err := compareDirTrees(cleanA, cleanB, opts, DirCompareMethodRemote,
+			func(dir string) (map[string]dirTreeEntry, error) { return listDirTreeRemote(ctx, sshSession, dir) },
+			func(dir string, files []string) ([]string, error) {
+				return checksumsRemote(ctx, sshSession, dir, files)
+			})
+		if !errors.Is(err, errNoGNUFind) && !errors.Is(err, errNoMonitoring) {
+			return diff, err
+		}
+	}
+
+	client, err := a.sftp.client(sessionID)
+	if err != nil {
+		return DirDiff{}, err
+	}
+	return compareDirTrees(cleanA, cleanB, opts, DirCompareMethodSFTP,
+		func(dir string) (map[string]dirTreeEntry, error) { return listDirTreeSFTP(client, dir) },
+		func(dir string, files []string) ([]string, error) { return checksumsSFTP(ctx, client, dir, files) })
+}
+
+// compareDirTrees lists both trees, diffs them and, when asked, hashes the candidates
+func compareDirTrees(pathA, pathB string, opts CompareOptions, method string,
+	list func(dir string) (map[string]dirTreeEntry, error), checksums func(dir string, files []string) ([]string, error)) (DirDiff, error) {
+	treeA, err := list(pathA)
+	if err != nil {
+		return DirDiff{}, err
+	}
+	treeB, err := list(pathB)
+	if err != nil {
+		return DirDiff{}, err
+	}
+
+	diff, candidates := diffDirTrees(treeA, treeB)
+	diff.PathA, diff.PathB, diff.Method = pathA, pathB, method
+	if !opts.Checksum {
+		return diff, nil
+	}
+	hashesA, err := checksums(pathA, candidates)
+	if err != nil {
+		return DirDiff{}, err
+	}
+	hashesB, err := checksums(pathB, candidates)
+	if err != nil {
+		return DirDiff{}, err
+	}
+	applyChecksums(&diff, treeA, candidates, hashesA, hashesB)
+	return diff, nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestParseDirTree(t *testing.T) {
+	output := []byte("d 4096 conf\x00\x00" +
+		"f 12 conf/app name.yml\x00\x00" +
+		"f 3 odd\nname\x00\x00" +
+		"l 9 current\x00releases/2\x00")
+	got, err := parseDirTree(output)
+	if err != nil {
+		t.Fatalf("parseDirTree() returned error: %v", err)
+	}
+	want := map[string]dirTreeEntry{
+		"conf":              {kind: 'd', size: 4096},
+		"conf/app name.yml": {kind: 'f', size: 12},
+		"odd\nname":         {kind: 'f', size: 3},
+		"current":           {kind: 'l', size: 9, target: "releases/2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDirTree() = %v, want %v", got, want)
+	}
+
+	if _, err := parseDirTree([]byte("f big name\x00\x00")); err == nil {
+		t.Error("parseDirTree() accepted a record without a size")
+	}
+}
+
+func TestDiffDirTrees(t *testing.T) {
+	a := map[string]dirTreeEntry{
+		"same.txt":       {kind: 'f', size: 5},
+		"edited.txt":     {kind: 'f', size: 5},
+		"grown.log":      {kind: 'f', size: 10},
+		"old":            {kind: 'd'},
+		"old/file":       {kind: 'f', size: 1},
+		"old/deep/x":     {kind: 'f', size: 1},
+		"current":        {kind: 'l', target: "releases/1"},
+		"swapped":        {kind: 'f', size: 1},
+		"removed.txt":    {kind: 'f', size: 1},
+		"shared":         {kind: 'd'},
+		"shared/only-a":  {kind: 'f', size: 2},
+		"shared/same.md": {kind: 'f', size: 2},
+	}
+	b := map[string]dirTreeEntry{
+		"same.txt":       {kind: 'f', size: 5},
+		"edited.txt":     {kind: 'f', size: 5},
+		"grown.log":      {kind: 'f', size: 20},
+		"current":        {kind: 'l', target: "releases/2"},
+		"swapped":        {kind: 'd'},
+		"new":            {kind: 'd'},
+		"new/file":       {kind: 'f', size: 1},
+		"shared":         {kind: 'd'},
+		"shared/same.md": {kind: 'f', size: 2},
+	}
+
+	diff, candidates := diffDirTrees(a, b)
+	if want := []string{"old", "removed.txt", "shared/only-a"}; !reflect.DeepEqual(diff.OnlyInA, want) {
+		t.Errorf("OnlyInA = %v, want %v", diff.OnlyInA, want)
+	}
+	if want := []string{"new"}; !reflect.DeepEqual(diff.OnlyInB, want) {
+		t.Errorf("OnlyInB = %v, want %v", diff.OnlyInB, want)
+	}
+	wantDiffering := []DirDiffEntry{
+		{Path: "current", Reason: DirDiffReasonTarget},
+		{Path: "grown.log", Reason: DirDiffReasonSize, SizeA: 10, SizeB: 20},
+		{Path: "swapped", Reason: DirDiffReasonType, SizeA: 1},
+	}
+	if !reflect.DeepEqual(diff.Differing, wantDiffering) {
+		t.Errorf("Differing = %+v, want %+v", diff.Differing, wantDiffering)
+	}
+	if want := []string{"edited.txt", "same.txt", "shared/same.md"}; !reflect.DeepEqual(candidates, want) {
+		t.Errorf("candidates = %v, want %v", candidates, want)
+	}
+	if diff.Identical != 4 { // The three candidates and the shared directory
+		t.Errorf("Identical = %d, want 4", diff.Identical)
+	}
+
+	applyChecksums(&diff, a, candidates, []string{"aaa", "bbb", ""}, []string{"ccc", "bbb", "ddd"})
+	if !diff.Checksum || diff.Identical != 2 || !reflect.DeepEqual(diff.Unchecked, []string{"shared/same.md"}) {
+		t.Errorf("after checksums: Identical = %d, Unchecked = %v", diff.Identical, diff.Unchecked)
+	}
+	if diff.Differing[1] != (DirDiffEntry{Path: "edited.txt", Reason: DirDiffReasonChecksum, SizeA: 5, SizeB: 5}) {
+		t.Errorf("Differing = %+v, want edited.txt flagged by checksum", diff.Differing)
+	}
+}
+
+// makeCompareTrees builds two directory trees under root that differ in every way a comparison reports
+func makeCompareTrees(t *testing.T, root string) (string, string) {
+	t.Helper()
+	a, b := filepath.Join(root, "a"), filepath.Join(root, "b b")
+	files := map[string]string{
+		"a/same.txt":      "hello",
+		"b b/same.txt":    "hello",
+		"a/edited.txt":    "hello",
+		"b b/edited.txt":  "world",
+		"a/grown.log":     "1",
+		"b b/grown.log":   "12",
+		"a/old/file":      "x",
+		"b b/new/it's":    "y",
+		"a/sub/keep.md":   "k",
+		"b b/sub/keep.md": "k",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return a, b
+}
+
+func checkCompareTrees(t *testing.T, diff DirDiff) {
+	t.Helper()
+	if !reflect.DeepEqual(diff.OnlyInA, []string{"old"}) || !reflect.DeepEqual(diff.OnlyInB, []string{"new"}) {
+		t.Errorf("OnlyInA = %v, OnlyInB = %v; want old and new", diff.OnlyInA, diff.OnlyInB)
+	}
+	var differing []string
+	for _, entry := range diff.Differing {
+		differing = append(differing, entry.Path+":"+entry.Reason)
+	}
+	if want := []string{"edited.txt:checksum", "grown.log:size"}; !reflect.DeepEqual(differing, want) {
+		t.Errorf("Differing = %v, want %v", differing, want)
+	}
+	if diff.Identical != 3 || len(diff.Unchecked) != 0 { // same.txt, sub and sub/keep.md
+		t.Errorf("Identical = %d, Unchecked = %v; want 3 and none", diff.Identical, diff.Unchecked)
+	}
+}
+
+func TestDirCompareCommandsRun(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("needs GNU find")
+	}
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	run := func(command remoteCommand, input []byte) []byte {
+		cmd := exec.Command(bash, "-c", string(command))
+		cmd.Stdin = bytes.NewReader(input)
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("%s failed: %v", command, err)
+		}
+		return output
+	}
+
+	a, b := makeCompareTrees(t, t.TempDir())
+	list := func(dir string) (map[string]dirTreeEntry, error) { return parseDirTree(run(dirTreeCommand(dir), nil)) }
+	checksums := func(dir string, files []string) ([]string, error) {
+		var input bytes.Buffer
+		for _, rel := range files {
+			input.WriteString(rel + "\x00")
+		}
+		lines := bytes.Split(bytes.TrimSuffix(run(checksumCommand(dir), input.Bytes()), []byte("\n")), []byte("\n"))
+		hashes := make([]string, len(lines))
+		for i, line := range lines {
+			hashes[i] = string(line)
+		}
+		return hashes, nil
+	}
+	diff, err := compareDirTrees(a, b, CompareOptions{Checksum: true}, DirCompareMethodRemote, list, checksums)
+	if err != nil {
+		t.Fatalf("compareDirTrees() returned error: %v", err)
+	}
+	checkCompareTrees(t, diff)
+
+	cmd := exec.Command(bash, "-c", string(dirTreeCommand(filepath.Join(a, "missing"))))
+	if err := cmd.Run(); err == nil || cmd.ProcessState.ExitCode() != dirTreeExitNoDir {
+		t.Errorf("listing a missing directory = %v, want exit %d", err, dirTreeExitNoDir)
+	}
+}
+
+func TestCompareRemoteDirectoriesOverSFTP(t *testing.T) {
+	client, dir := newLocalSFTPClient(t)
+	app := NewApp()
+	sessionID := "session_compare_dirs"
+	app.ssh.sftpClients[sessionID] = client
+	defer app.ReleaseSession(sessionID)
+
+	a, b := makeCompareTrees(t, dir)
+	diff, err := app.CompareRemoteDirectoriesWithOptions(sessionID, a, b, CompareOptions{Checksum: true})
+	if err != nil {
+		t.Fatalf("CompareRemoteDirectoriesWithOptions() returned error: %v", err)
+	}
+	if diff.Method != DirCompareMethodSFTP || !diff.Checksum {
+		t.Errorf("Method = %s, Checksum = %v; want an SFTP comparison with checksums", diff.Method, diff.Checksum)
+	}
+	checkCompareTrees(t, diff)
+
+	// Without checksums, same-size files count as identical
+	if diff, err := app.CompareRemoteDirectories(sessionID, a, b); err != nil || diff.Identical != 4 {
+		t.Errorf("CompareRemoteDirectories() = %d identical, %v; want 4", diff.Identical, err)
+	}
+	if _, err := app.CompareRemoteDirectories(sessionID, a, a+"/"); err == nil {
+		t.Error("comparing a directory with itself returned no error")
+	}
+	if _, err := app.CompareRemoteDirectories(sessionID, a, filepath.Join(dir, "missing")); err == nil {
+		t.Error("comparing with a missing directory returned no error")
+	}
+}
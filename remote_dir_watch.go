@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Remote directory watch constants
+const (
+	RemoteDirChangedEvent         = "remote-dir-changed"
+	RemoteDirWatchPollInterval    = 3 * time.Second
+	RemoteDirWatchResyncInterval  = 30 * time.Second       // Listing interval while inotifywait reports changes, in case it misses some
+	RemoteDirWatchDebounce        = 300 * time.Millisecond // Changes reported within this window share one listing
+	MaxRemoteDirWatchesPerSession = 10
+
+	RemoteDirWatchModeInotify = "inotify" // inotifywait on the monitoring session triggers a listing
+	RemoteDirWatchModePoll    = "poll"    // The directory is listed every RemoteDirWatchPollInterval
+)
+
+// RemoteDirectoryWatch describes a watched remote directory
+type RemoteDirectoryWatch struct {
+	ID        string `json:"id"`
+	SessionID string `json:"sessionId"`
+	Path      string `json:"path"`
+	Mode      string `json:"mode"` // "inotify" or "poll"
+}
+
+// remoteDirEntry is what a listing records of an entry to spot it changing
+type remoteDirEntry struct {
+	size    int64
+	modTime time.Time
+	mode    os.FileMode
+}
+
+// remoteDirWatch is a running watch. Both modes diff SFTP listings of the directory; inotifywait
+// only decides when to list, so the events look the same either way.
+type remoteDirWatch struct {
+	RemoteDirectoryWatch
+	stop     chan struct{}
+	stopOnce sync.Once
+	inotify  *ssh.Session // nil when polling
+}
+
+var (
+	remoteDirWatches   = make(map[string]*remoteDirWatch)
+	remoteDirWatchesMu sync.Mutex
+)
+
+// diffRemoteDir returns the names added, removed and modified between two listings, sorted
+func diffRemoteDir(before, after map[string]remoteDirEntry) (added, removed, modified []string) {
+	added, removed, modified = []string{}, []string{}, []string{}
+	for name, entry := range after {
+		previous, existed := before[name]
+		switch {
+		case !existed:
+			added = append(added, name)
+		case previous != entry:
+			modified = append(modified, name)
+		}
+	}
+	for name := range before {
+		if _, exists := after[name]; !exists {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(modified)
+	return added, removed, modified
+}
+
+// listRemoteDir lists a directory over the session's current SFTP client, which changes when
+// the session reconnects
+func (a *App) listRemoteDir(sessionID, remotePath string) (map[string]remoteDirEntry, error) {
+	client, err := a.sftp.client(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := client.ReadDir(remotePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", remotePath, err)
+	}
+	entries := make(map[string]remoteDirEntry, len(infos))
+	for _, info := range infos {
+		entries[info.Name()] = remoteDirEntry{size: info.Size(), modTime: info.ModTime(), mode: info.Mode()}
+	}
+	return entries, nil
+}
+
+// inotifyWatchCommand prints a line for every change in a directory until it is closed
+func inotifyWatchCommand(remotePath string) remoteCommand {
+	return buildRemoteCommand("exec inotifywait -m -q -e create,delete,modify,attrib,move --format . -- %s 2>/dev/null", remotePath)
+}
+
+// startInotify runs inotifywait for a directory on the monitoring connection, signalling
+// changed for every change it reports. changed is closed when inotifywait stops. Fails when
+// the monitoring session is down or inotifywait isn't installed.
+func (a *App) startInotify(sessionID, remotePath string) (*ssh.Session, <-chan struct{}, error) {
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+	if sshSession == nil {
+		return nil, nil, fmt.Errorf("SSH session %s not found", sessionID)
+	}
+	if _, err := a.executeMonitoringCommand(sshSession, remoteCmdHasInotify); err != nil {
+		return nil, nil, fmt.Errorf("inotifywait not available: %w", err)
+	}
+
+	sshSession.monitoringMutex.RLock()
+	client := sshSession.monitoringClient
+	sshSession.monitoringMutex.RUnlock()
+	if client == nil {
+		return nil, nil, fmt.Errorf("monitoring session not available")
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open watch channel: %w", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, nil, fmt.Errorf("failed to read watch output: %w", err)
+	}
+	if err := session.Start(string(inotifyWatchCommand(remotePath))); err != nil {
+		session.Close()
+		return nil, nil, fmt.Errorf("failed to start inotifywait: %w", err)
+	}
+
+	changed := make(chan struct{}, 1)
+	sshSession.goTracked(func() {
+		defer close(changed)
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			select {
+			case changed <- struct{}{}:
+			default: // A listing is already due
+			}
+		}
+	})
+	return session, changed, nil
+}
+
+// run lists the directory when changed signals or every interval, whichever comes first, and
+// sends a remote-dir-changed event for each difference. A nil changed means polling; when
+// changed closes, the watch falls back to polling. A listing error ends the watch.
+func (w *remoteDirWatch) run(emitter Emitter, list func() (map[string]remoteDirEntry, error), previous map[string]remoteDirEntry, changed <-chan struct{}, interval time.Duration) {
+	defer w.release()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		case _, open := <-changed:
+			if !open {
+				// inotifywait ended without being stopped: poll instead
+				changed = nil
+				w.setMode(RemoteDirWatchModePoll)
+				ticker.Reset(RemoteDirWatchPollInterval)
+				continue
+			}
+			// Let a burst of changes, such as an extracted archive, settle first
+			select {
+			case <-w.stop:
+				return
+			case <-time.After(RemoteDirWatchDebounce):
+			}
+		}
+
+		current, err := list()
+		if err != nil {
+			emitter.Emit(RemoteDirChangedEvent, map[string]interface{}{
+				"watchId":   w.ID,
+				"sessionId": w.SessionID,
+				"path":      w.Path,
+				"error":     err.Error(),
+			})
+			return
+		}
+		added, removed, modified := diffRemoteDir(previous, current)
+		previous = current
+		if len(added)+len(removed)+len(modified) == 0 {
+			continue
+		}
+		emitter.Emit(RemoteDirChangedEvent, map[string]interface{}{
+			"watchId":   w.ID,
+			"sessionId": w.SessionID,
+			"path":      w.Path,
+			"added":     added,
+			"removed":   removed,
+			"modified":  modified,
+		})
+	}
+}
+
+func (w *remoteDirWatch) setMode(mode string) {
+	remoteDirWatchesMu.Lock()
+	defer remoteDirWatchesMu.Unlock()
+	w.Mode = mode
+}
+
+// cancel asks the watch to stop and ends its inotifywait
+func (w *remoteDirWatch) cancel() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		if w.inotify != nil {
+			w.inotify.Close()
+		}
+	})
+}
+
+// release removes a finished watch from the registry
+func (w *remoteDirWatch) release() {
+	w.cancel()
+	remoteDirWatchesMu.Lock()
+	defer remoteDirWatchesMu.Unlock()
+	if remoteDirWatches[w.ID] == w {
+		delete(remoteDirWatches, w.ID)
+	}
+}
+
+// StartRemoteDirectoryWatch sends remote-dir-changed events listing the entries added, removed
+// and modified in a remote directory, until StopRemoteDirectoryWatch or the session closes.
+// Hosts with inotifywait report changes as they happen; others are polled every few seconds.
+// Watching a directory already watched returns the existing watch.
+func (a *App) StartRemoteDirectoryWatch(sessionID, remotePath string) (RemoteDirectoryWatch, error) {
+	clean, err := normalizeRemotePath(remotePath)
+	if err != nil {
+		return RemoteDirectoryWatch{}, err
+	}
+
+	remoteDirWatchesMu.Lock()
+	count := 0
+	for _, watch := range remoteDirWatches {
+		if watch.SessionID != sessionID {
+			continue
+		}
+		if watch.Path == clean {
+			existing := watch.RemoteDirectoryWatch
+			remoteDirWatchesMu.Unlock()
+			return existing, nil
+		}
+		count++
+	}
+	remoteDirWatchesMu.Unlock()
+	if count >= MaxRemoteDirWatchesPerSession {
+		return RemoteDirectoryWatch{}, fmt.Errorf("too many directory watches for session %s: maximum allowed: %d", sessionID, MaxRemoteDirWatchesPerSession)
+	}
+
+	list := func() (map[string]remoteDirEntry, error) { return a.listRemoteDir(sessionID, clean) }
+	initial, err := list()
+	if err != nil {
+		return RemoteDirectoryWatch{}, err
+	}
+
+	watch := &remoteDirWatch{
+		RemoteDirectoryWatch: RemoteDirectoryWatch{
+			ID:        fmt.Sprintf("dirwatch_%d", time.Now().UnixNano()),
+			SessionID: sessionID,
+			Path:      clean,
+			Mode:      RemoteDirWatchModePoll,
+		},
+		stop: make(chan struct{}),
+	}
+	interval := RemoteDirWatchPollInterval
+	inotify, changed, err := a.startInotify(sessionID, clean)
+	if err == nil {
+		watch.inotify = inotify
+		watch.Mode = RemoteDirWatchModeInotify
+		interval = RemoteDirWatchResyncInterval
+	}
+
+	info := watch.RemoteDirectoryWatch
+
+	remoteDirWatchesMu.Lock()
+	remoteDirWatches[watch.ID] = watch
+	remoteDirWatchesMu.Unlock()
+
+	a.goSession(sessionID, func() { watch.run(appEmitter{a}, list, initial, changed, interval) })
+	return info, nil
+}
+
+// StopRemoteDirectoryWatch stops a watch started by StartRemoteDirectoryWatch
+func (a *App) StopRemoteDirectoryWatch(watchID string) error {
+	remoteDirWatchesMu.Lock()
+	watch, exists := remoteDirWatches[watchID]
+	delete(remoteDirWatches, watchID)
+	remoteDirWatchesMu.Unlock()
+	if !exists {
+		return newNotFoundError(ErrCategorySFTP, "StopRemoteDirectoryWatch", "directory watch %s not found", watchID)
+	}
+	watch.cancel()
+	return nil
+}
+
+// GetRemoteDirectoryWatches returns a session's running directory watches
+func (a *App) GetRemoteDirectoryWatches(sessionID string) []RemoteDirectoryWatch {
+	remoteDirWatchesMu.Lock()
+	defer remoteDirWatchesMu.Unlock()
+	watches := []RemoteDirectoryWatch{}
+	for _, watch := range remoteDirWatches {
+		if watch.SessionID == sessionID {
+			watches = append(watches, watch.RemoteDirectoryWatch)
+		}
+	}
+	sort.Slice(watches, func(i, j int) bool { return watches[i].Path < watches[j].Path })
+	return watches
+}
+
+// stopRemoteDirWatches stops every directory watch of a session
+func stopRemoteDirWatches(sessionID string) {
+	remoteDirWatchesMu.Lock()
+	var watches []*remoteDirWatch
+	for id, watch := range remoteDirWatches {
+		if watch.SessionID == sessionID {
+			watches = append(watches, watch)
+			delete(remoteDirWatches, id)
+		}
+	}
+	remoteDirWatchesMu.Unlock()
+
+	for _, watch := range watches {
+		watch.cancel()
+	}
+}
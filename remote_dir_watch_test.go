@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDiffRemoteDir(t *testing.T) {
+	now := time.Now()
+	before := map[string]remoteDirEntry{
+		"keep.txt":   {size: 10, modTime: now},
+		"edit.go":    {size: 20, modTime: now},
+		"chmod.sh":   {size: 5, modTime: now, mode: 0644},
+		"delete.log": {size: 1, modTime: now},
+	}
+	after := map[string]remoteDirEntry{
+		"keep.txt": {size: 10, modTime: now},
+		"edit.go":  {size: 20, modTime: now.Add(time.Second)},
+		"chmod.sh": {size: 5, modTime: now, mode: 0755},
+		"new.md":   {size: 3, modTime: now},
+		"a.txt":    {size: 0, modTime: now},
+	}
+
+	added, removed, modified := diffRemoteDir(before, after)
+	if !reflect.DeepEqual(added, []string{"a.txt", "new.md"}) {
+		t.Errorf("added = %v", added)
+	}
+	if !reflect.DeepEqual(removed, []string{"delete.log"}) {
+		t.Errorf("removed = %v", removed)
+	}
+	if !reflect.DeepEqual(modified, []string{"chmod.sh", "edit.go"}) {
+		t.Errorf("modified = %v", modified)
+	}
+}
+
+// fakeDirLister serves listings set by the test
+type fakeDirLister struct {
+	mu      sync.Mutex
+	entries map[string]remoteDirEntry
+	err     error
+}
+
+func (f *fakeDirLister) set(entries map[string]remoteDirEntry, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entries, f.err = entries, err
+}
+
+func (f *fakeDirLister) list() (map[string]remoteDirEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.entries, f.err
+}
+
+// waitForEvents waits until the emitter has recorded n events
+func waitForEvents(t *testing.T, emitter *recordingEmitter, n int) []map[string]interface{} {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		emitter.mu.Lock()
+		events := append([]map[string]interface{}(nil), emitter.events...)
+		emitter.mu.Unlock()
+		if len(events) >= n {
+			return events
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d events", n)
+	return nil
+}
+
+func TestRemoteDirWatchRun(t *testing.T) {
+	watch := &remoteDirWatch{
+		RemoteDirectoryWatch: RemoteDirectoryWatch{ID: "dirwatch_test", SessionID: "session_dir_watch", Path: "/srv", Mode: RemoteDirWatchModeInotify},
+		stop:                 make(chan struct{}),
+	}
+	lister := &fakeDirLister{}
+	lister.set(map[string]remoteDirEntry{"old.txt": {size: 1}}, nil)
+	initial, _ := lister.list()
+	emitter := &recordingEmitter{}
+	changed := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		watch.run(emitter, lister.list, initial, changed, time.Hour)
+		close(done)
+	}()
+
+	// A change reported by inotifywait triggers a listing
+	lister.set(map[string]remoteDirEntry{"old.txt": {size: 2}, "new.txt": {}}, nil)
+	changed <- struct{}{}
+	event := waitForEvents(t, emitter, 1)[0]
+	if event["path"] != "/srv" || !reflect.DeepEqual(event["added"], []string{"new.txt"}) ||
+		!reflect.DeepEqual(event["modified"], []string{"old.txt"}) || len(event["removed"].([]string)) != 0 {
+		t.Errorf("event = %v, want new.txt added and old.txt modified", event)
+	}
+
+	// inotifywait stopping falls back to polling
+	close(changed)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		remoteDirWatchesMu.Lock()
+		mode := watch.Mode
+		remoteDirWatchesMu.Unlock()
+		if mode == RemoteDirWatchModePoll {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("watch did not fall back to polling")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	watch.cancel()
+	<-done
+}
+
+func TestRemoteDirWatchEndsOnListingError(t *testing.T) {
+	watch := &remoteDirWatch{
+		RemoteDirectoryWatch: RemoteDirectoryWatch{ID: "dirwatch_error", SessionID: "session_dir_watch", Path: "/gone"},
+		stop:                 make(chan struct{}),
+	}
+	lister := &fakeDirLister{}
+	lister.set(nil, errors.New("failed to list /gone: file does not exist"))
+	emitter := &recordingEmitter{}
+
+	finished := make(chan struct{})
+	go func() {
+		watch.run(emitter, lister.list, nil, nil, 10*time.Millisecond)
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch kept running after its directory disappeared")
+	}
+	if events := waitForEvents(t, emitter, 1); events[0]["error"] == nil {
+		t.Errorf("event = %v, want the listing error", events[0])
+	}
+}
+
+func TestStartRemoteDirectoryWatch(t *testing.T) {
+	app := NewApp()
+	sessionID := "session_start_dir_watch"
+	client := newLatencySFTPClient(t, 0, 0)
+	app.ssh.sftpClients[sessionID] = client
+	defer app.ReleaseSession(sessionID)
+	if err := client.MkdirAll("/project"); err != nil {
+		t.Fatal(err)
+	}
+
+	watch, err := app.StartRemoteDirectoryWatch(sessionID, "/project/")
+	if err != nil {
+		t.Fatalf("StartRemoteDirectoryWatch() returned error: %v", err)
+	}
+	// Without a monitoring session the directory is polled
+	if watch.Mode != RemoteDirWatchModePoll || watch.Path != "/project" {
+		t.Errorf("watch = %+v, want a poll watch of /project", watch)
+	}
+	again, err := app.StartRemoteDirectoryWatch(sessionID, "/project")
+	if err != nil || again.ID != watch.ID {
+		t.Errorf("watching the same directory again = %+v, %v; want the existing watch", again, err)
+	}
+	if watches := app.GetRemoteDirectoryWatches(sessionID); len(watches) != 1 {
+		t.Errorf("GetRemoteDirectoryWatches() = %v, want one watch", watches)
+	}
+
+	if _, err := app.StartRemoteDirectoryWatch(sessionID, "/missing"); err == nil {
+		t.Error("StartRemoteDirectoryWatch() of a missing directory returned no error")
+	}
+
+	if err := app.StopRemoteDirectoryWatch(watch.ID); err != nil {
+		t.Fatalf("StopRemoteDirectoryWatch() returned error: %v", err)
+	}
+	if err := app.StopRemoteDirectoryWatch(watch.ID); toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("stopping a stopped watch = %v, want not found", err)
+	}
+}
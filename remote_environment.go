@@ -0,0 +1,518 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Remote environment capture constants
+const (
+	RemoteEnvironmentSourceMonitoring  = "monitoring"
+	RemoteEnvironmentSourceInteractive = "interactive"
+
+	EnvironmentSeverityWarning = "warning"
+	EnvironmentSeverityInfo    = "info"
+
+	// InteractiveCaptureTimeout bounds how long we wait for the probe's output in the terminal
+	InteractiveCaptureTimeout = 10 * time.Second
+
+	environmentSectionPrefix = "@@thermic-env:"
+	environmentCaptureBegin  = environmentSectionPrefix + "begin"
+	environmentCaptureEnd    = environmentSectionPrefix + "end"
+)
+
+// expectedPathDirs are the directories a usable interactive PATH is expected to contain
+var expectedPathDirs = []string{"/usr/local/bin", "/usr/bin", "/bin"}
+
+// EnvironmentDiscrepancy is a difference between the remote environment and what Thermic set up
+type EnvironmentDiscrepancy struct {
+	Severity string `json:"severity"` // "warning" or "info"
+	Variable string `json:"variable,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Message  string `json:"message"`
+}
+
+// RemoteEnvironmentReport is a snapshot of a remote shell environment for bug reports
+type RemoteEnvironmentReport struct {
+	SessionID         string                   `json:"sessionId"`
+	Host              string                   `json:"host,omitempty"`
+	CapturedAt        time.Time                `json:"capturedAt"`
+	Source            string                   `json:"source"`   // "monitoring" or "interactive"
+	Degraded          bool                     `json:"degraded"` // Gathered through the interactive session
+	Term              string                   `json:"term"`
+	Shell             string                   `json:"shell"`
+	ShellVersion      string                   `json:"shellVersion,omitempty"`
+	Environment       map[string]string        `json:"environment"`
+	Locale            map[string]string        `json:"locale"`
+	LocaleWarnings    []string                 `json:"localeWarnings,omitempty"`
+	Ulimits           map[string]string        `json:"ulimits"`
+	TerminfoEntry     string                   `json:"terminfoEntry"`
+	TerminfoStatus    int                      `json:"terminfoStatus"` // infocmp exit status, -1 if unknown
+	Requested         map[string]string        `json:"requested"`
+	RedactedVariables []string                 `json:"redactedVariables,omitempty"`
+	Discrepancies     []EnvironmentDiscrepancy `json:"discrepancies"`
+}
+
+// environmentCapture collects interactive session output until the probe's end marker arrives
+type environmentCapture struct {
+	output strings.Builder
+	done   chan struct{}
+	closed bool
+}
+
+var (
+	environmentCaptures   = make(map[string]*environmentCapture)
+	environmentCapturesMu sync.Mutex
+)
+
+// requestedSSHEnvironment returns the environment Thermic asks the SSH server to set up.
+// Only the PTY's TERM today - no Setenv requests are sent.
+func requestedSSHEnvironment() map[string]string {
+	return map[string]string{
+		"TERM": SSHTerminalType,
+	}
+}
+
+// environmentProbeScript builds the POSIX sh script that prints each report section.
+// Over the monitoring session env and locale come from a login shell, since that is
+// what the interactive tab runs; in the interactive session the current shell's env is used as is.
+func environmentProbeScript(loginShell bool) string {
+	envCmd, localeCmd := "env", "locale 2>&1"
+	if loginShell {
+		envCmd = `"${SHELL:-/bin/sh}" -lc env </dev/null 2>/dev/null || env`
+		localeCmd = `"${SHELL:-/bin/sh}" -lc locale </dev/null 2>&1 || locale 2>&1`
+	}
+
+	lines := []string{
+		"echo '" + environmentCaptureBegin + "'",
+		"echo '" + environmentSectionPrefix + "env'; " + envCmd,
+		"echo '" + environmentSectionPrefix + "locale'; " + localeCmd,
+		"echo '" + environmentSectionPrefix + "term'; echo \"${TERM:-}\"",
+		"echo '" + environmentSectionPrefix + "shell'; echo \"${SHELL:-}\"; \"${SHELL:-/bin/sh}\" --version </dev/null 2>/dev/null | head -n 1",
+		"echo '" + environmentSectionPrefix + "ulimit'; ulimit -a 2>&1",
+		"echo '" + environmentSectionPrefix + "terminfo'; infocmp " + SSHTerminalType + " >/dev/null 2>&1; echo $?",
+		"echo '" + environmentCaptureEnd + "'",
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// environmentProbeCommand wraps the probe script so it survives any quoting and any login shell.
+// Base64 keeps `$` away from the outer shell and keeps the markers out of the echoed command line.
+func environmentProbeCommand(loginShell bool) string {
+	encoded := base64.StdEncoding.EncodeToString([]byte(environmentProbeScript(loginShell)))
+	return fmt.Sprintf("echo %s | base64 -d | sh", encoded)
+}
+
+// CaptureRemoteEnvironment snapshots env, locale, TERM, shell, ulimits and terminfo
+// availability over the monitoring session and flags differences from what Thermic requested
+func (a *App) CaptureRemoteEnvironment(sessionID string) (*RemoteEnvironmentReport, error) {
+	sshSession, err := a.lookupEnvironmentSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	sshSession.monitoringMutex.RLock()
+	monitoringAvailable := sshSession.monitoringEnabled && sshSession.monitoringClient != nil
+	sshSession.monitoringMutex.RUnlock()
+	if !monitoringAvailable {
+		return nil, fmt.Errorf("monitoring session not available for %s; use CaptureRemoteEnvironmentInteractive for a degraded report", sessionID)
+	}
+
+	// The probe is a fixed script; it is also typed into the terminal, so it's built as a string
+	output, err := a.executeMonitoringCommand(sshSession, remoteCommand(environmentProbeCommand(true)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture remote environment: %w", err)
+	}
+
+	report, err := parseEnvironmentReport(output, RemoteEnvironmentSourceMonitoring)
+	if err != nil {
+		return nil, err
+	}
+	report.SessionID = sessionID
+	report.Host = a.environmentSessionHost(sessionID)
+	return report, nil
+}
+
+// CaptureRemoteEnvironmentInteractive gathers a degraded report by typing the probe into the
+// tab's shell. The probe and its output are visible in the terminal, so the caller must confirm.
+func (a *App) CaptureRemoteEnvironmentInteractive(sessionID string, confirmed bool) (*RemoteEnvironmentReport, error) {
+	if !confirmed {
+		return nil, fmt.Errorf("capturing through the interactive session writes commands to the terminal and must be confirmed")
+	}
+	if _, err := a.lookupEnvironmentSession(sessionID); err != nil {
+		return nil, err
+	}
+
+	capture := &environmentCapture{done: make(chan struct{})}
+	environmentCapturesMu.Lock()
+	if _, busy := environmentCaptures[sessionID]; busy {
+		environmentCapturesMu.Unlock()
+		return nil, fmt.Errorf("an environment capture is already running for session %s", sessionID)
+	}
+	environmentCaptures[sessionID] = capture
+	environmentCapturesMu.Unlock()
+
+	defer func() {
+		environmentCapturesMu.Lock()
+		delete(environmentCaptures, sessionID)
+		environmentCapturesMu.Unlock()
+	}()
+
+	// Leading space keeps the probe out of history where HISTCONTROL=ignorespace
+	if err := a.WriteToShell(sessionID, " "+environmentProbeCommand(false)+"\r"); err != nil {
+		return nil, fmt.Errorf("failed to write environment probe: %w", err)
+	}
+
+	select {
+	case <-capture.done:
+	case <-time.After(InteractiveCaptureTimeout):
+		return nil, fmt.Errorf("timed out waiting for environment probe output from session %s", sessionID)
+	}
+
+	environmentCapturesMu.Lock()
+	output := capture.output.String()
+	environmentCapturesMu.Unlock()
+
+	report, err := parseEnvironmentReport(output, RemoteEnvironmentSourceInteractive)
+	if err != nil {
+		return nil, err
+	}
+	report.SessionID = sessionID
+	report.Host = a.environmentSessionHost(sessionID)
+	return report, nil
+}
+
+// recordEnvironmentCapture feeds terminal output to a running interactive capture
+func recordEnvironmentCapture(sessionID, data string) {
+	environmentCapturesMu.Lock()
+	defer environmentCapturesMu.Unlock()
+
+	capture, exists := environmentCaptures[sessionID]
+	if !exists || capture.closed {
+		return
+	}
+	capture.output.WriteString(data)
+	// The typed command line only carries base64, so the marker can only come from the probe
+	if strings.Contains(capture.output.String(), environmentCaptureEnd) {
+		capture.closed = true
+		close(capture.done)
+	}
+}
+
+// SaveRemoteEnvironmentReport writes a report to path (JSON for .json, text otherwise).
+// With an empty path the user picks the location; returns the path written, or "" if cancelled.
+func (a *App) SaveRemoteEnvironmentReport(report *RemoteEnvironmentReport, path string) (string, error) {
+	if report == nil {
+		return "", fmt.Errorf("no report to save")
+	}
+
+	if path == "" {
+		host := report.Host
+		if host == "" {
+			host = "remote"
+		}
+		defaultName := fmt.Sprintf("thermic-env-%s-%s.txt", sanitizeFilename(host), report.CapturedAt.Format("20060102-150405"))
+		selected, err := a.SelectSaveLocation(defaultName)
+		if err != nil {
+			return "", err
+		}
+		if selected == "" {
+			return "", nil
+		}
+		path = selected
+	}
+
+	var data []byte
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		encoded, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode environment report: %w", err)
+		}
+		data = encoded
+	} else {
+		data = []byte(formatEnvironmentReport(report))
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to save environment report: %w", err)
+	}
+	return path, nil
+}
+
+// lookupEnvironmentSession returns the live SSH session for a capture
+func (a *App) lookupEnvironmentSession(sessionID string) (*SSHSession, error) {
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+
+	if !exists || sshSession == nil || sshSession.cleaning {
+		return nil, newNotFoundError(ErrCategorySSH, "lookupEnvironmentSession", "SSH session %s not found", sessionID)
+	}
+	return sshSession, nil
+}
+
+// environmentSessionHost returns the host of the tab that owns a session
+func (a *App) environmentSessionHost(sessionID string) string {
+	a.terminal.mutex.RLock()
+	defer a.terminal.mutex.RUnlock()
+
+	for _, tab := range a.terminal.tabs {
+		if tab.SessionID == sessionID && tab.SSHConfig != nil {
+			return tab.SSHConfig.Host
+		}
+	}
+	return ""
+}
+
+// parseEnvironmentReport splits probe output into sections and builds the report with discrepancies
+func parseEnvironmentReport(output, source string) (*RemoteEnvironmentReport, error) {
+	output = strings.ReplaceAll(output, "\r", "")
+	if begin := strings.LastIndex(output, environmentCaptureBegin); begin >= 0 {
+		output = output[begin+len(environmentCaptureBegin):]
+	}
+	if end := strings.Index(output, environmentCaptureEnd); end >= 0 {
+		output = output[:end]
+	}
+
+	sections := make(map[string][]string)
+	current := ""
+	for _, line := range strings.Split(output, "\n") {
+		if name, found := strings.CutPrefix(line, environmentSectionPrefix); found {
+			current = strings.TrimSpace(name)
+			sections[current] = []string{}
+			continue
+		}
+		if current != "" {
+			sections[current] = append(sections[current], line)
+		}
+	}
+	if _, ok := sections["env"]; !ok {
+		return nil, fmt.Errorf("environment probe produced no output")
+	}
+
+	report := &RemoteEnvironmentReport{
+		CapturedAt:     time.Now(),
+		Source:         source,
+		Degraded:       source == RemoteEnvironmentSourceInteractive,
+		Environment:    make(map[string]string),
+		Locale:         make(map[string]string),
+		Ulimits:        make(map[string]string),
+		TerminfoEntry:  SSHTerminalType,
+		TerminfoStatus: -1,
+		Requested:      requestedSSHEnvironment(),
+	}
+
+	for _, line := range sections["env"] {
+		name, value, found := strings.Cut(line, "=")
+		if !found || name == "" || strings.ContainsAny(name, " \t") {
+			continue
+		}
+		report.Environment[name] = value
+	}
+
+	for _, line := range sections["locale"] {
+		if strings.HasPrefix(line, "locale:") {
+			report.LocaleWarnings = append(report.LocaleWarnings, strings.TrimSpace(line))
+			continue
+		}
+		if name, value, found := strings.Cut(line, "="); found {
+			report.Locale[name] = strings.Trim(value, `"`)
+		}
+	}
+
+	report.Term = firstNonEmptyLine(sections["term"])
+	if report.Term == "" {
+		report.Term = report.Environment["TERM"]
+	}
+
+	if shell := nonEmptyLines(sections["shell"]); len(shell) > 0 {
+		report.Shell = shell[0]
+		if len(shell) > 1 {
+			report.ShellVersion = shell[1]
+		}
+	}
+
+	for _, line := range nonEmptyLines(sections["ulimit"]) {
+		if idx := strings.LastIndexAny(line, " \t"); idx > 0 {
+			report.Ulimits[strings.TrimSpace(line[:idx])] = strings.TrimSpace(line[idx+1:])
+		}
+	}
+
+	if status, err := strconv.Atoi(firstNonEmptyLine(sections["terminfo"])); err == nil {
+		report.TerminfoStatus = status
+	}
+
+	report.RedactedVariables = redactEnvironment(report.Environment)
+	report.Discrepancies = findEnvironmentDiscrepancies(report)
+	return report, nil
+}
+
+// redactEnvironment blanks values of secret-looking variables and returns their names
+func redactEnvironment(env map[string]string) []string {
+	var redacted []string
+	for name := range env {
+		if isSecretName(name) {
+			env[name] = redactedValue
+			redacted = append(redacted, name)
+		}
+	}
+	sort.Strings(redacted)
+	return redacted
+}
+
+// findEnvironmentDiscrepancies compares the captured environment with what Thermic requested
+func findEnvironmentDiscrepancies(report *RemoteEnvironmentReport) []EnvironmentDiscrepancy {
+	discrepancies := []EnvironmentDiscrepancy{}
+
+	wantTerm := report.Requested["TERM"]
+	switch {
+	case report.Term != "" && report.Term != wantTerm:
+		discrepancies = append(discrepancies, EnvironmentDiscrepancy{
+			Severity: EnvironmentSeverityWarning,
+			Variable: "TERM",
+			Expected: wantTerm,
+			Actual:   report.Term,
+			Message:  "TERM differs from the terminal type Thermic requested",
+		})
+	case report.Term == "" && !report.Degraded:
+		discrepancies = append(discrepancies, EnvironmentDiscrepancy{
+			Severity: EnvironmentSeverityInfo,
+			Variable: "TERM",
+			Expected: wantTerm,
+			Message:  "TERM is not set outside a PTY; the interactive shell receives " + wantTerm,
+		})
+	}
+
+	switch report.TerminfoStatus {
+	case 0:
+	case 127, -1:
+		discrepancies = append(discrepancies, EnvironmentDiscrepancy{
+			Severity: EnvironmentSeverityInfo,
+			Variable: "TERM",
+			Expected: report.TerminfoEntry,
+			Message:  "infocmp is not available; terminfo support for " + report.TerminfoEntry + " could not be checked",
+		})
+	default:
+		discrepancies = append(discrepancies, EnvironmentDiscrepancy{
+			Severity: EnvironmentSeverityWarning,
+			Variable: "TERM",
+			Expected: report.TerminfoEntry,
+			Actual:   fmt.Sprintf("infocmp exit status %d", report.TerminfoStatus),
+			Message:  "No terminfo entry for " + report.TerminfoEntry + "; full-screen programs may misbehave",
+		})
+	}
+
+	if report.Environment["LC_ALL"] == "" {
+		if report.Environment["LANG"] == "" {
+			discrepancies = append(discrepancies, EnvironmentDiscrepancy{
+				Severity: EnvironmentSeverityWarning,
+				Variable: "LANG",
+				Message:  "Neither LC_ALL nor LANG is set; programs fall back to the POSIX locale",
+			})
+		} else {
+			discrepancies = append(discrepancies, EnvironmentDiscrepancy{
+				Severity: EnvironmentSeverityInfo,
+				Variable: "LC_ALL",
+				Actual:   "LANG=" + report.Environment["LANG"],
+				Message:  "LC_ALL is unset; locale comes from LANG",
+			})
+		}
+	}
+	for _, warning := range report.LocaleWarnings {
+		discrepancies = append(discrepancies, EnvironmentDiscrepancy{
+			Severity: EnvironmentSeverityWarning,
+			Variable: "LC_ALL",
+			Message:  warning,
+		})
+	}
+
+	path, hasPath := report.Environment["PATH"]
+	pathDirs := make(map[string]bool)
+	for _, dir := range strings.Split(path, ":") {
+		pathDirs[strings.TrimSuffix(dir, "/")] = true
+	}
+	var missing []string
+	for _, dir := range expectedPathDirs {
+		if !pathDirs[dir] {
+			missing = append(missing, dir)
+		}
+	}
+	if !hasPath || len(missing) > 0 {
+		discrepancies = append(discrepancies, EnvironmentDiscrepancy{
+			Severity: EnvironmentSeverityWarning,
+			Variable: "PATH",
+			Expected: strings.Join(expectedPathDirs, ":"),
+			Actual:   path,
+			Message:  "PATH is restricted; missing " + strings.Join(missing, ", "),
+		})
+	}
+
+	return discrepancies
+}
+
+// formatEnvironmentReport renders a report as plain text for attaching to bug reports
+func formatEnvironmentReport(report *RemoteEnvironmentReport) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Thermic remote environment report\n")
+	fmt.Fprintf(&b, "Host:      %s\n", report.Host)
+	fmt.Fprintf(&b, "Captured:  %s\n", report.CapturedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Source:    %s", report.Source)
+	if report.Degraded {
+		fmt.Fprintf(&b, " (degraded)")
+	}
+	fmt.Fprintf(&b, "\nTERM:      %s (requested %s)\n", report.Term, report.Requested["TERM"])
+	fmt.Fprintf(&b, "Shell:     %s %s\n", report.Shell, report.ShellVersion)
+	fmt.Fprintf(&b, "Terminfo:  %s, infocmp status %d\n", report.TerminfoEntry, report.TerminfoStatus)
+
+	fmt.Fprintf(&b, "\nDiscrepancies:\n")
+	if len(report.Discrepancies) == 0 {
+		fmt.Fprintf(&b, "  none\n")
+	}
+	for _, d := range report.Discrepancies {
+		fmt.Fprintf(&b, "  [%s] %s\n", d.Severity, d.Message)
+	}
+
+	writeSortedMap(&b, "Environment", report.Environment)
+	writeSortedMap(&b, "Locale", report.Locale)
+	writeSortedMap(&b, "Limits", report.Ulimits)
+	return b.String()
+}
+
+// writeSortedMap appends a titled, key-sorted NAME=value section
+func writeSortedMap(b *strings.Builder, title string, values map[string]string) {
+	fmt.Fprintf(b, "\n%s:\n", title)
+	keys := mapKeys(values)
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(b, "  %s=%s\n", key, values[key])
+	}
+}
+
+// firstNonEmptyLine returns the first line with content, trimmed
+func firstNonEmptyLine(lines []string) string {
+	if trimmed := nonEmptyLines(lines); len(trimmed) > 0 {
+		return trimmed[0]
+	}
+	return ""
+}
+
+// nonEmptyLines returns the trimmed lines that have content
+func nonEmptyLines(lines []string) []string {
+	var result []string
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// probeOutput is what the probe prints on a host with a restricted PATH, no LC_ALL and no terminfo entry
+const probeOutput = "motd noise\n" +
+	environmentCaptureBegin + "\n" +
+	environmentSectionPrefix + "env\n" +
+	"PATH=/opt/app/bin:/usr/bin\n" +
+	"LANG=en_US.UTF-8\n" +
+	"AWS_SECRET_ACCESS_KEY=abc123\n" +
+	"GITHUB_TOKEN=ghp_xyz\n" +
+	"HOME=/home/deploy\n" +
+	environmentSectionPrefix + "locale\n" +
+	"locale: Cannot set LC_CTYPE to default locale: No such file or directory\n" +
+	"LANG=en_US.UTF-8\n" +
+	"LC_ALL=\n" +
+	environmentSectionPrefix + "term\n" +
+	"xterm-256color\n" +
+	environmentSectionPrefix + "shell\n" +
+	"/bin/bash\n" +
+	"GNU bash, version 5.2.15(1)-release (x86_64-pc-linux-gnu)\n" +
+	environmentSectionPrefix + "ulimit\n" +
+	"open files                          (-n) 1024\n" +
+	"max user processes                  (-u) 63432\n" +
+	environmentSectionPrefix + "terminfo\n" +
+	"1\n" +
+	environmentCaptureEnd + "\n"
+
+func TestParseEnvironmentReport(t *testing.T) {
+	report, err := parseEnvironmentReport(strings.ReplaceAll(probeOutput, "\n", "\r\n"), RemoteEnvironmentSourceInteractive)
+	if err != nil {
+		t.Fatalf("parseEnvironmentReport() returned error: %v", err)
+	}
+
+	if !report.Degraded {
+		t.Error("interactive report should be marked degraded")
+	}
+	if report.Term != SSHTerminalType || report.Shell != "/bin/bash" || !strings.HasPrefix(report.ShellVersion, "GNU bash") {
+		t.Errorf("unexpected TERM/shell: %q %q %q", report.Term, report.Shell, report.ShellVersion)
+	}
+	if got := report.Ulimits["open files                          (-n)"]; got != "1024" {
+		t.Errorf("open files limit = %q, want 1024", got)
+	}
+	if report.TerminfoStatus != 1 {
+		t.Errorf("TerminfoStatus = %d, want 1", report.TerminfoStatus)
+	}
+
+	for _, name := range []string{"AWS_SECRET_ACCESS_KEY", "GITHUB_TOKEN"} {
+		if report.Environment[name] != redactedValue {
+			t.Errorf("%s was not redacted: %q", name, report.Environment[name])
+		}
+	}
+	if report.Environment["HOME"] != "/home/deploy" {
+		t.Errorf("HOME should be kept, got %q", report.Environment["HOME"])
+	}
+	if strings.Contains(formatEnvironmentReport(report), "ghp_xyz") {
+		t.Error("secret value leaked into the text report")
+	}
+
+	found := make(map[string]bool)
+	for _, d := range report.Discrepancies {
+		found[d.Variable+"/"+d.Severity] = true
+	}
+	for _, want := range []string{"TERM/warning", "LC_ALL/info", "LC_ALL/warning", "PATH/warning"} {
+		if !found[want] {
+			t.Errorf("missing %s discrepancy in %+v", want, report.Discrepancies)
+		}
+	}
+}
+
+func TestInteractiveCaptureStopsAtEndMarker(t *testing.T) {
+	capture := &environmentCapture{done: make(chan struct{})}
+	environmentCapturesMu.Lock()
+	environmentCaptures["s1"] = capture
+	environmentCapturesMu.Unlock()
+	defer func() {
+		environmentCapturesMu.Lock()
+		delete(environmentCaptures, "s1")
+		environmentCapturesMu.Unlock()
+	}()
+
+	// The echoed command line carries only base64 and must not end the capture
+	recordEnvironmentCapture("s1", " "+environmentProbeCommand(false)+"\r\n")
+	select {
+	case <-capture.done:
+		t.Fatal("capture ended on the echoed command line")
+	default:
+	}
+
+	half := len(probeOutput) / 2
+	recordEnvironmentCapture("s1", probeOutput[:half])
+	recordEnvironmentCapture("s1", probeOutput[half:])
+	recordEnvironmentCapture("s1", "$ ")
+
+	select {
+	case <-capture.done:
+	default:
+		t.Fatal("capture did not end at the end marker")
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RemoteFilePreviewTimeout bounds reading a file for the editor, so a stalled server can't
+// leave the preview loading forever
+const RemoteFilePreviewTimeout = 30 * time.Second
+
+// ErrFileTooLarge is returned for files over the preview size limit; the frontend offers to
+// download them instead
+var ErrFileTooLarge = errors.New("file too large to preview")
+
+// FileTooLargeError carries the size of a file that was over the preview limit
+type FileTooLargeError struct {
+	Path  string
+	Size  int64 // -1 when the size couldn't be read
+	Limit int64
+}
+
+func (e *FileTooLargeError) Error() string {
+	if e.Size < 0 {
+		return fmt.Sprintf("%s is larger than the %d byte preview limit", e.Path, e.Limit)
+	}
+	return fmt.Sprintf("%s is %d bytes, over the %d byte preview limit", e.Path, e.Size, e.Limit)
+}
+
+func (e *FileTooLargeError) Unwrap() error {
+	return ErrFileTooLarge
+}
+
+// newFileTooLargeError builds the error returned to the frontend for a file over the preview limit
+func newFileTooLargeError(op, remotePath string, size, limit int64) error {
+	return &ThermicError{
+		Code:     ErrCodeTooLarge,
+		Category: ErrCategorySFTP,
+		Op:       op,
+		Cause:    &FileTooLargeError{Path: remotePath, Size: size, Limit: limit},
+	}
+}
+
+// readWithTimeout runs read, giving up after timeout. abort, when set, is called on timeout
+// to unblock the read; the read's result is dropped either way.
+func readWithTimeout(timeout time.Duration, read func() ([]byte, error), abort func()) ([]byte, error) {
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		data, err := read()
+		done <- result{data, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.data, r.err
+	case <-time.After(timeout):
+		if abort != nil {
+			abort()
+		}
+		return nil, &ThermicError{
+			Code:     ErrCodeTimeout,
+			Category: ErrCategorySFTP,
+			Op:       "readWithTimeout",
+			Message:  fmt.Sprintf("reading file timed out after %v", timeout),
+		}
+	}
+}
+
+// parseWCBytes returns the byte count of "wc -c" output ("1234 /path")
+func parseWCBytes(output string) (int64, bool) {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(fields[0], 10, 64)
+	return size, err == nil
+}
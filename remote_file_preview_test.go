@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+func TestSFTPServiceFileContentSizeCap(t *testing.T) {
+	sessionID := "session_preview_cap"
+	client := newLatencySFTPClient(t, 0, 0)
+	store := &fakeSessionStore{clients: map[string]*sftp.Client{sessionID: client}}
+	service := NewSFTPService(store, &recordingEmitter{}, func() SFTPConfig {
+		return SFTPConfig{MaxPreviewSize: MinSFTPMaxPreviewSize}
+	})
+
+	writeFile := func(name string, size int) {
+		f, err := client.Create(name)
+		if err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+		if _, err := f.Write(bytes.Repeat([]byte("a"), size)); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		f.Close()
+	}
+	writeFile("/small.txt", MinSFTPMaxPreviewSize)
+	writeFile("/large.log", MinSFTPMaxPreviewSize+1)
+
+	content, err := service.FileContent(sessionID, "/small.txt")
+	if err != nil || len(content) != MinSFTPMaxPreviewSize {
+		t.Errorf("file at the limit: got %d bytes, err = %v", len(content), err)
+	}
+
+	_, err = service.FileContent(sessionID, "/large.log")
+	var tooLarge *FileTooLargeError
+	if !errors.Is(err, ErrFileTooLarge) || !errors.As(err, &tooLarge) {
+		t.Fatalf("file over the limit: err = %v, want ErrFileTooLarge", err)
+	}
+	if tooLarge.Size != MinSFTPMaxPreviewSize+1 || tooLarge.Limit != MinSFTPMaxPreviewSize {
+		t.Errorf("FileTooLargeError = %+v", tooLarge)
+	}
+	if reason := toThermicError(err).Reason(); reason != ErrReasonFileTooLarge {
+		t.Errorf("reason = %s, want %s", reason, ErrReasonFileTooLarge)
+	}
+}
+
+func TestReadWithTimeout(t *testing.T) {
+	aborted := make(chan struct{})
+	_, err := readWithTimeout(10*time.Millisecond, func() ([]byte, error) {
+		<-aborted
+		return nil, errors.New("closed")
+	}, func() { close(aborted) })
+	if err == nil || toThermicError(err).Code != ErrCodeTimeout {
+		t.Errorf("stalled read: err = %v, want a timeout", err)
+	}
+}
+
+func TestParseWCBytes(t *testing.T) {
+	if size, ok := parseWCBytes("52428800 /var/log/syslog\n"); !ok || size != 52428800 {
+		t.Errorf("parseWCBytes() = %d, %v", size, ok)
+	}
+	if _, ok := parseWCBytes("wc: /missing: No such file or directory"); ok {
+		t.Error("parseWCBytes() accepted an error message")
+	}
+}
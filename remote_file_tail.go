@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// MaxRemoteFileTailLines is the most lines GetRemoteFileTail returns
+const MaxRemoteFileTailLines = 100000
+
+// tailChunkSize is how much of a file the SFTP fallback reads per step back from its end
+const tailChunkSize = 64 * 1024
+
+// Exit statuses of fileTailCommand for files tail can't read
+const (
+	fileTailExitMissing    = 3
+	fileTailExitNotRegular = 4
+	fileTailExitUnreadable = 5
+)
+
+// fileTailCommand prints the last lines of a regular file, cut to its last maxBytes bytes so
+// one huge line can't be pulled in whole
+func fileTailCommand(remotePath string, lines int, maxBytes int64) remoteCommand {
+	return buildRemoteCommand("[ -e %[1]s ] || exit 3; [ -f %[1]s ] || exit 4; [ -r %[1]s ] || exit 5; tail -n %[2]s -- %[1]s | tail -c %[3]s",
+		remotePath, strconv.Itoa(lines), strconv.FormatInt(maxBytes, 10))
+}
+
+// fileTailRemote runs tail on the host over the monitoring connection
+func fileTailRemote(ctx context.Context, sshSession *SSHSession, remotePath string, lines int, maxBytes int64) ([]byte, error) {
+	output, err := runMonitoringCommandWithInput(ctx, sshSession, fileTailCommand(remotePath, lines, maxBytes), nil)
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		switch exitErr.ExitStatus() {
+		case fileTailExitMissing:
+			return nil, newNotFoundError(ErrCategorySFTP, "GetRemoteFileTail", "file not found: %s", remotePath)
+		case fileTailExitNotRegular:
+			return nil, fmt.Errorf("invalid file %s: not a regular file", remotePath)
+		case fileTailExitUnreadable:
+			return nil, fmt.Errorf("permission denied reading %s", remotePath)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the end of %s: %w", remotePath, err)
+	}
+	return output, nil
+}
+
+// tailLines returns the last lines of a file of the given size, like tail -n: a final newline
+// ends the last line rather than starting an empty one. It reads back from the end in chunks
+// and stops after maxBytes, so only the tail of a huge file is transferred.
+func tailLines(r io.ReaderAt, size int64, lines int, maxBytes int64) ([]byte, error) {
+	limit := int64(0)
+	if size > maxBytes {
+		limit = size - maxBytes
+	}
+
+	start := limit
+	newlines := 0
+	buf := make([]byte, tailChunkSize)
+scan:
+	for pos := size; pos > limit; {
+		n := int64(tailChunkSize)
+		if pos-limit < n {
+			n = pos - limit
+		}
+		pos -= n
+		chunk := buf[:n]
+		if read, err := r.ReadAt(chunk, pos); int64(read) < n {
+			return nil, err
+		}
+		for i := n - 1; i >= 0; i-- {
+			if chunk[i] != '\n' || pos+i == size-1 {
+				continue
+			}
+			newlines++
+			if newlines == lines {
+				start = pos + i + 1
+				break scan
+			}
+		}
+	}
+
+	data := make([]byte, size-start)
+	if read, err := r.ReadAt(data, start); read < len(data) {
+		return nil, err
+	}
+	return data, nil
+}
+
+// fileTailSFTP reads the last lines of a file over SFTP, seeking back from its end
+func fileTailSFTP(sftpClient *sftp.Client, remotePath string, lines int, maxBytes int64) ([]byte, error) {
+	file, err := sftpClient.Open(remotePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", remotePath, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", remotePath, err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("invalid file %s: not a regular file", remotePath)
+	}
+	data, err := tailLines(file, info.Size(), lines, maxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the end of %s: %w", remotePath, err)
+	}
+	return data, nil
+}
+
+// GetRemoteFileTail returns the last lines of a remote file, for peeking at the end of a large
+// log without reading all of it. tail runs on the host over the monitoring connection; without
+// one the file is read back from its end over SFTP. Either way at most the MaxPreviewSize
+// setting's worth of bytes is returned, trimmed from the front.
+func (a *App) GetRemoteFileTail(sessionID, remotePath string, lines int) (string, error) {
+	remotePath, err := normalizeRemotePath(remotePath)
+	if err != nil {
+		return "", err
+	}
+	if lines < 1 || lines > MaxRemoteFileTailLines {
+		return "", newInvalidError(ErrCategorySFTP, "GetRemoteFileTail", "invalid line count %d: must be between 1 and %d", lines, MaxRemoteFileTailLines)
+	}
+	maxBytes := a.getSFTPConfig().MaxPreviewSize
+
+	var data []byte
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+	if exists && sshSession != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), RemoteFilePreviewTimeout)
+		data, err = fileTailRemote(ctx, sshSession, remotePath, lines, maxBytes)
+		cancel()
+	} else {
+		err = errNoMonitoring
+	}
+
+	if errors.Is(err, errNoMonitoring) {
+		sftpClient, clientErr := a.getOrReconnectSFTPClient(sessionID)
+		if clientErr != nil {
+			return "", clientErr
+		}
+		data, err = readWithTimeout(RemoteFilePreviewTimeout, func() ([]byte, error) {
+			return fileTailSFTP(sftpClient, remotePath, lines, maxBytes)
+		}, nil)
+	}
+	if err != nil {
+		return "", err
+	}
+	// The byte cap can cut a character in two
+	return strings.ToValidUTF8(string(data), "\uFFFD"), nil
+}
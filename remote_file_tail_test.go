@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestTailLines(t *testing.T) {
+	long := strings.Repeat("x", tailChunkSize+10)
+	tests := []struct {
+		name     string
+		content  string
+		lines    int
+		maxBytes int64
+		want     string
+	}{
+		{"fewer lines than asked", "a\nb\n", 5, 1 << 20, "a\nb\n"},
+		{"final newline", "a\nb\nc\n", 2, 1 << 20, "b\nc\n"},
+		{"no final newline", "a\nb\nc", 2, 1 << 20, "b\nc"},
+		{"empty lines count", "a\n\n\nb\n", 3, 1 << 20, "\n\nb\n"},
+		{"empty file", "", 3, 1 << 20, ""},
+		{"byte cap", "first\n" + long + "\n", 1, 8, "xxxxxxx\n"},
+		{"across chunks", "head\n" + long + "\nlast\n", 2, 1 << 20, long + "\nlast\n"},
+	}
+	for _, tt := range tests {
+		got, err := tailLines(strings.NewReader(tt.content), int64(len(tt.content)), tt.lines, tt.maxBytes)
+		if err != nil {
+			t.Errorf("%s: tailLines() returned error: %v", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: tailLines() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFileTailCommandRuns(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app's log")
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := exec.Command(bash, "-c", string(fileTailCommand(path, 2, 1<<20))).Output()
+	if err != nil || string(output) != "two\nthree\n" {
+		t.Errorf("fileTailCommand() printed %q, %v; want the last two lines", output, err)
+	}
+	// The same answer as the SFTP fallback, byte cap included
+	if output, _ := exec.Command(bash, "-c", string(fileTailCommand(path, 2, 4))).Output(); string(output) != "ree\n" {
+		t.Errorf("fileTailCommand() with a byte cap printed %q, want %q", output, "ree\n")
+	}
+
+	for path, want := range map[string]int{filepath.Join(dir, "missing"): fileTailExitMissing, dir: fileTailExitNotRegular} {
+		cmd := exec.Command(bash, "-c", string(fileTailCommand(path, 1, 1<<20)))
+		if err := cmd.Run(); err == nil || cmd.ProcessState.ExitCode() != want {
+			t.Errorf("tail of %s = %v, want exit %d", path, err, want)
+		}
+	}
+}
+
+func TestGetRemoteFileTailOverSFTP(t *testing.T) {
+	client, dir := newLocalSFTPClient(t)
+	app := NewApp()
+	sessionID := "session_file_tail"
+	app.ssh.sftpClients[sessionID] = client
+	defer app.ReleaseSession(sessionID)
+
+	var log strings.Builder
+	for i := 1; i <= 5000; i++ {
+		log.WriteString("line " + strings.Repeat("=", i%40) + "\n")
+	}
+	log.WriteString("the end")
+	path := filepath.Join(dir, "big.log")
+	if err := os.WriteFile(path, []byte(log.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tail, err := app.GetRemoteFileTail(sessionID, path, 3)
+	if err != nil {
+		t.Fatalf("GetRemoteFileTail() returned error: %v", err)
+	}
+	lines := strings.Split(log.String(), "\n")
+	if want := strings.Join(lines[len(lines)-3:], "\n"); tail != want {
+		t.Errorf("GetRemoteFileTail() = %q, want %q", tail, want)
+	}
+
+	for _, lines := range []int{0, MaxRemoteFileTailLines + 1} {
+		if _, err := app.GetRemoteFileTail(sessionID, path, lines); toThermicError(err).Code != ErrCodeInvalid {
+			t.Errorf("GetRemoteFileTail() with %d lines = %v, want an invalid line count", lines, err)
+		}
+	}
+	if _, err := app.GetRemoteFileTail(sessionID, "relative.log", 3); err == nil {
+		t.Error("GetRemoteFileTail() accepted a relative path")
+	}
+	if _, err := app.GetRemoteFileTail(sessionID, dir, 3); err == nil {
+		t.Error("GetRemoteFileTail() read a directory")
+	}
+	if _, err := app.GetRemoteFileTail(sessionID, filepath.Join(dir, "missing.log"), 3); toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("GetRemoteFileTail() of a missing file = %v, want not found", err)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrConflict is returned when saving a remote file that changed since the editor read it;
+// the frontend offers to compare, overwrite or reload
+var ErrConflict = errors.New("remote file changed since it was read")
+
+// RemoteFileContent is a file read for the editor, with the version to save it against
+type RemoteFileContent struct {
+	Content string `json:"content"` // Base64 encoded when the file isn't text
+	Version string `json:"version"`
+}
+
+// ConflictError reports a remote file whose version no longer matches the one it was read at
+type ConflictError struct {
+	Path     string
+	Expected string // The version the editor read
+	Actual   string // The version on the server now; empty when the file was deleted
+}
+
+func (e *ConflictError) Error() string {
+	if e.Actual == "" {
+		return fmt.Sprintf("%s was deleted on the server since it was opened", e.Path)
+	}
+	return fmt.Sprintf("%s was changed on the server since it was opened", e.Path)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+// newConflictError builds the error returned to the frontend for a save that would overwrite
+// someone else's changes
+func newConflictError(op, remotePath, expected, actual string) error {
+	return &ThermicError{
+		Code:     ErrCodeConflict,
+		Category: ErrCategorySFTP,
+		Op:       op,
+		Cause:    &ConflictError{Path: remotePath, Expected: expected, Actual: actual},
+	}
+}
+
+// remoteFileVersion returns a token for a file's modification time and size. SFTP reports
+// whole seconds, so two same-size writes within a second share a version.
+func remoteFileVersion(info os.FileInfo) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%d", info.ModTime().UnixNano(), info.Size())))
+	return hex.EncodeToString(sum[:8])
+}
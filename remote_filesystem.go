@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Where a RemoteFilesystemStats came from
+const (
+	FilesystemStatsSourceStatVFS = "statvfs" // statvfs@openssh.com over SFTP
+	FilesystemStatsSourceDF      = "df"      // df on the monitoring session
+)
+
+// RemoteFilesystemStats describes the filesystem holding a remote path
+type RemoteFilesystemStats struct {
+	Path           string  `json:"path"`
+	TotalBytes     uint64  `json:"totalBytes"`
+	UsedBytes      uint64  `json:"usedBytes"`
+	AvailableBytes uint64  `json:"availableBytes"` // Free space usable without root, what a write can count on
+	UsedPercent    float64 `json:"usedPercent"`    // Computed like df's Capacity column
+	MountPoint     string  `json:"mountPoint,omitempty"`
+	Source         string  `json:"source"`
+}
+
+// statVFSFilesystemStats asks the session's SFTP server for a path's filesystem. Reports false
+// when the server lacks statvfs@openssh.com or the call fails, so the caller can fall back to df.
+func (a *App) statVFSFilesystemStats(sessionID, remotePath string) (RemoteFilesystemStats, bool) {
+	client, ok := a.statVFSClient(sessionID)
+	if !ok {
+		return RemoteFilesystemStats{}, false
+	}
+	stat, err := client.StatVFS(remotePath)
+	if err != nil {
+		return RemoteFilesystemStats{}, false
+	}
+	percent, err := statVFSUsagePercent(stat)
+	if err != nil {
+		return RemoteFilesystemStats{}, false
+	}
+
+	blockSize := stat.Frsize
+	if blockSize == 0 {
+		blockSize = stat.Bsize
+	}
+	return RemoteFilesystemStats{
+		Path:           remotePath,
+		TotalBytes:     stat.Blocks * blockSize,
+		UsedBytes:      (stat.Blocks - stat.Bfree) * blockSize,
+		AvailableBytes: stat.Bavail * blockSize,
+		UsedPercent:    percent,
+		Source:         FilesystemStatsSourceStatVFS,
+	}, true
+}
+
+// parseDFStats parses the POSIX df -Pk line of a path:
+// Filesystem 1024-blocks Used Available Capacity Mounted-on. The columns are found from the
+// Capacity field, so a filesystem or mount name containing spaces doesn't shift them.
+func parseDFStats(remotePath, output string) (RemoteFilesystemStats, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+
+	capacity := -1
+	for i := 4; i < len(fields); i++ {
+		if strings.HasSuffix(fields[i], "%") || fields[i] == "-" {
+			capacity = i
+			break
+		}
+	}
+	if capacity < 0 {
+		return RemoteFilesystemStats{}, fmt.Errorf("unexpected df output: %q", strings.TrimSpace(output))
+	}
+
+	var kib [3]uint64
+	for i := range kib {
+		value, err := strconv.ParseUint(fields[capacity-3+i], 10, 64)
+		if err != nil {
+			return RemoteFilesystemStats{}, fmt.Errorf("unexpected df output: %q", strings.TrimSpace(output))
+		}
+		kib[i] = value
+	}
+	// Pseudo filesystems print "-" for Capacity
+	percent, _ := strconv.ParseFloat(strings.TrimSuffix(fields[capacity], "%"), 64)
+
+	return RemoteFilesystemStats{
+		Path:           remotePath,
+		TotalBytes:     kib[0] * 1024,
+		UsedBytes:      kib[1] * 1024,
+		AvailableBytes: kib[2] * 1024,
+		UsedPercent:    percent,
+		MountPoint:     strings.Join(fields[capacity+1:], " "),
+		Source:         FilesystemStatsSourceDF,
+	}, nil
+}
+
+// dfFilesystemStats runs df for a path on the monitoring session
+func (a *App) dfFilesystemStats(sshSession *SSHSession, remotePath string) (RemoteFilesystemStats, error) {
+	output, err := a.executeMonitoringCommand(sshSession, filesystemStatsCommand(remotePath))
+	if err != nil {
+		return RemoteFilesystemStats{}, fmt.Errorf("failed to get filesystem stats for %s: %w", remotePath, err)
+	}
+	return parseDFStats(remotePath, output)
+}
+
+// remoteFilesystemStats returns the filesystem stats of a path, over SFTP when the server
+// supports statvfs and from df on the monitoring session otherwise
+func (a *App) remoteFilesystemStats(sessionID string, sshSession *SSHSession, remotePath string) (RemoteFilesystemStats, error) {
+	if stats, ok := a.statVFSFilesystemStats(sessionID, remotePath); ok {
+		return stats, nil
+	}
+	return a.dfFilesystemStats(sshSession, remotePath)
+}
+
+// GetRemoteFilesystemStats returns the size and free space of the filesystem holding a remote
+// path. Servers with the statvfs@openssh.com extension answer over SFTP, which works without
+// the monitoring session; others fall back to df.
+func (a *App) GetRemoteFilesystemStats(sessionID, remotePath string) (RemoteFilesystemStats, error) {
+	if remotePath == "" {
+		remotePath = "."
+	}
+	if stats, ok := a.statVFSFilesystemStats(sessionID, remotePath); ok {
+		return stats, nil
+	}
+
+	sshSession, err := a.monitoringSession(sessionID, "GetRemoteFilesystemStats")
+	if err != nil {
+		return RemoteFilesystemStats{}, err
+	}
+	return a.dfFilesystemStats(sshSession, remotePath)
+}
@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestParseDFStats(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   RemoteFilesystemStats
+	}{
+		{
+			name:   "GNU coreutils",
+			output: "/dev/nvme0n1p2  102626232 61234560  36132408      63% /\n",
+			want:   RemoteFilesystemStats{TotalBytes: 102626232 * 1024, UsedBytes: 61234560 * 1024, AvailableBytes: 36132408 * 1024, UsedPercent: 63, MountPoint: "/"},
+		},
+		{
+			name:   "macOS with spaces in the mount point",
+			output: "/dev/disk3s1 488245288 421049256 67196032 87% /Volumes/Backup Disk\n",
+			want:   RemoteFilesystemStats{TotalBytes: 488245288 * 1024, UsedBytes: 421049256 * 1024, AvailableBytes: 67196032 * 1024, UsedPercent: 87, MountPoint: "/Volumes/Backup Disk"},
+		},
+		{
+			name:   "network share with spaces in the filesystem",
+			output: "//nas/Shared Media 2097152 1048576 1048576 50% /mnt/media\n",
+			want:   RemoteFilesystemStats{TotalBytes: 2097152 * 1024, UsedBytes: 1048576 * 1024, AvailableBytes: 1048576 * 1024, UsedPercent: 50, MountPoint: "/mnt/media"},
+		},
+		{
+			name:   "pseudo filesystem",
+			output: "proc 0 0 0 - /proc\n",
+			want:   RemoteFilesystemStats{MountPoint: "/proc"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDFStats("/data", tt.output)
+			if err != nil {
+				t.Fatalf("parseDFStats() returned error: %v", err)
+			}
+			tt.want.Path = "/data"
+			tt.want.Source = FilesystemStatsSourceDF
+			if got != tt.want {
+				t.Errorf("parseDFStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	for _, output := range []string{"", "df: /missing: No such file or directory", "/dev/sda1 big 1 1 1% /"} {
+		if _, err := parseDFStats("/data", output); err == nil {
+			t.Errorf("parseDFStats(%q) expected an error", output)
+		}
+	}
+}
+
+func TestGetRemoteFilesystemStatsMissingSession(t *testing.T) {
+	app := NewApp()
+	if _, err := app.GetRemoteFilesystemStats("missing", "/"); err == nil || toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("GetRemoteFilesystemStats(missing) error = %v, want not found", err)
+	}
+}
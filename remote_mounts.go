@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Special filesystem kinds, flagged on listing entries so the UI can warn before recursing
+const (
+	FilesystemKindVirtual = "virtual" // Kernel pseudo filesystems such as proc and sysfs: huge or endless trees
+	FilesystemKindNetwork = "network" // NFS, SMB and other mounts served over the network: slow to walk
+)
+
+// RemoteMountsCacheTTL is how long a session's mount table is reused for listings
+const RemoteMountsCacheTTL = time.Minute
+
+// virtualFilesystemTypes are the kernel pseudo filesystems found in /proc/mounts
+var virtualFilesystemTypes = map[string]bool{
+	"autofs": true, "binfmt_misc": true, "bpf": true, "cgroup": true, "cgroup2": true,
+	"configfs": true, "debugfs": true, "devpts": true, "devtmpfs": true, "efivarfs": true,
+	"fusectl": true, "hugetlbfs": true, "mqueue": true, "nsfs": true, "proc": true,
+	"pstore": true, "rpc_pipefs": true, "securityfs": true, "selinuxfs": true, "sysfs": true,
+	"tracefs": true,
+}
+
+// networkFilesystemTypes are the network filesystems found in /proc/mounts. FUSE mounts
+// report "fuse.<helper>", so sshfs shows up as fuse.sshfs.
+var networkFilesystemTypes = map[string]bool{
+	"9p": true, "afs": true, "ceph": true, "cifs": true, "davfs": true, "fuse.rclone": true,
+	"fuse.s3fs": true, "fuse.sshfs": true, "glusterfs": true, "lustre": true, "ncpfs": true,
+	"nfs": true, "nfs4": true, "smb3": true, "smbfs": true,
+}
+
+// filesystemKind classifies a filesystem type, or returns "" for an ordinary one
+func filesystemKind(fsType string) string {
+	switch {
+	case virtualFilesystemTypes[fsType]:
+		return FilesystemKindVirtual
+	case networkFilesystemTypes[fsType]:
+		return FilesystemKindNetwork
+	}
+	return ""
+}
+
+// remoteMount is one line of /proc/mounts
+type remoteMount struct {
+	device string
+	path   string
+	fsType string
+}
+
+// parseProcMounts parses /proc/mounts: device, mount point and type, then options, with
+// spaces, tabs, newlines and backslashes in the first two written as octal escapes
+func parseProcMounts(output string) []remoteMount {
+	var mounts []remoteMount
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasPrefix(fields[1], "/") {
+			continue
+		}
+		mounts = append(mounts, remoteMount{
+			device: unescapeMountField(fields[0]),
+			path:   unescapeMountField(fields[1]),
+			fsType: fields[2],
+		})
+	}
+	return mounts
+}
+
+// unescapeMountField decodes the \ooo escapes of a /proc/mounts field
+func unescapeMountField(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+4 <= len(field) {
+			if code, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(code))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(field[i])
+	}
+	return b.String()
+}
+
+// mountFor returns the mount holding an absolute path: the one on its longest mount point
+// prefix, the latest when filesystems are stacked on the same point
+func mountFor(mounts []remoteMount, remotePath string) (remoteMount, bool) {
+	var found remoteMount
+	ok := false
+	for _, mount := range mounts {
+		if !pathWithin(remotePath, mount.path) {
+			continue
+		}
+		if !ok || len(mount.path) >= len(found.path) {
+			found, ok = mount, true
+		}
+	}
+	return found, ok
+}
+
+// pathWithin reports whether remotePath is dir or below it
+func pathWithin(remotePath, dir string) bool {
+	if dir == "/" || remotePath == dir {
+		return true
+	}
+	return strings.HasPrefix(remotePath, dir+"/")
+}
+
+// markMountEntries flags listing entries that are mount points or live on a special filesystem
+func markMountEntries(mounts []remoteMount, entries []RemoteFileEntry) {
+	for i := range entries {
+		entry := &entries[i]
+		if !strings.HasPrefix(entry.Path, "/") {
+			continue // Relative paths can't be matched against mount points
+		}
+		mount, ok := mountFor(mounts, entry.Path)
+		if !ok {
+			continue
+		}
+		entry.MountPoint = mount.path == entry.Path
+		entry.FSKind = filesystemKind(mount.fsType)
+		if entry.MountPoint || entry.FSKind != "" {
+			entry.FSType = mount.fsType
+		}
+	}
+}
+
+// cachedMounts is a session's mount table as last read; mounts is nil when it couldn't be read
+type cachedMounts struct {
+	mounts  []remoteMount
+	fetched time.Time
+}
+
+var remoteMountsCache = make(map[string]cachedMounts)
+var remoteMountsCacheMu sync.Mutex
+
+// clearRemoteMounts drops a session's cached mount table
+func clearRemoteMounts(sessionID string) {
+	remoteMountsCacheMu.Lock()
+	defer remoteMountsCacheMu.Unlock()
+	delete(remoteMountsCache, sessionID)
+}
+
+// remoteMounts returns a session's mount table, read from /proc/mounts on the monitoring
+// session and cached for RemoteMountsCacheTTL. Failures are cached too, so hosts without
+// /proc or a monitoring session don't pay for a command on every listing.
+func (a *App) remoteMounts(sessionID string) ([]remoteMount, error) {
+	remoteMountsCacheMu.Lock()
+	cached, exists := remoteMountsCache[sessionID]
+	remoteMountsCacheMu.Unlock()
+	if exists && time.Since(cached.fetched) < RemoteMountsCacheTTL {
+		if cached.mounts == nil {
+			return nil, fmt.Errorf("mount table of session %s is unavailable", sessionID)
+		}
+		return cached.mounts, nil
+	}
+
+	sshSession, err := a.monitoringSession(sessionID, "GetPathInfo")
+	if err != nil {
+		return nil, err
+	}
+	output, err := a.executeMonitoringCommand(sshSession, remoteCmdMounts)
+	var mounts []remoteMount
+	if err == nil {
+		mounts = parseProcMounts(output)
+		if len(mounts) == 0 {
+			err = fmt.Errorf("no mounts in /proc/mounts")
+		}
+	}
+
+	remoteMountsCacheMu.Lock()
+	remoteMountsCache[sessionID] = cachedMounts{mounts: mounts, fetched: time.Now()}
+	remoteMountsCacheMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the mount table: %w", err)
+	}
+	return mounts, nil
+}
+
+// markRemoteMountEntries flags a listing's mount points and special filesystems when the
+// session's mount table can be read, and leaves the entries alone otherwise
+func (a *App) markRemoteMountEntries(sessionID string, entries []RemoteFileEntry) {
+	if mounts, err := a.remoteMounts(sessionID); err == nil {
+		markMountEntries(mounts, entries)
+	}
+}
+
+// RemotePathInfo describes the filesystem holding a remote path
+type RemotePathInfo struct {
+	Path         string `json:"path"`
+	MountPoint   string `json:"mountPoint"`   // Where the filesystem holding the path is mounted
+	IsMountPoint bool   `json:"isMountPoint"` // True if the path itself is that mount point
+	Device       string `json:"device"`
+	FSType       string `json:"fsType"`
+	FSKind       string `json:"fsKind,omitempty"` // FilesystemKindVirtual or FilesystemKindNetwork, empty for ordinary filesystems
+}
+
+// GetPathInfo returns the filesystem holding a remote path from the host's /proc/mounts, so
+// the UI can warn before recursing into a virtual or network filesystem. Needs the monitoring
+// session and a Linux host.
+func (a *App) GetPathInfo(sessionID, remotePath string) (RemotePathInfo, error) {
+	remotePath, err := normalizeRemotePath(remotePath)
+	if err != nil {
+		return RemotePathInfo{}, err
+	}
+	mounts, err := a.remoteMounts(sessionID)
+	if err != nil {
+		return RemotePathInfo{}, err
+	}
+	mount, ok := mountFor(mounts, remotePath)
+	if !ok {
+		return RemotePathInfo{}, newNotFoundError(ErrCategorySFTP, "GetPathInfo", "no mount holds %s", remotePath)
+	}
+	return RemotePathInfo{
+		Path:         remotePath,
+		MountPoint:   mount.path,
+		IsMountPoint: mount.path == remotePath,
+		Device:       mount.device,
+		FSType:       mount.fsType,
+		FSKind:       filesystemKind(mount.fsType),
+	}, nil
+}
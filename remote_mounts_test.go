@@ -0,0 +1,127 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testProcMounts = `sysfs /sys sysfs rw,nosuid,nodev,noexec,relatime 0 0
+proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0
+/dev/sda1 / ext4 rw,relatime 0 0
+/dev/sdb1 /data xfs rw,relatime 0 0
+nas:/export/home /data/home nfs4 rw,relatime,vers=4.2 0 0
+/dev/sdc1 /mnt/usb\040stick vfat rw 0 0
+tmpfs /data tmpfs rw 0 0
+`
+
+func TestParseProcMounts(t *testing.T) {
+	mounts := parseProcMounts(testProcMounts + "garbage\nnone relative proc rw 0 0\n")
+	if len(mounts) != 7 {
+		t.Fatalf("parseProcMounts() = %d mounts, want 7", len(mounts))
+	}
+	if want := (remoteMount{device: "/dev/sdc1", path: "/mnt/usb stick", fsType: "vfat"}); mounts[5] != want {
+		t.Errorf("escaped mount = %+v, want %+v", mounts[5], want)
+	}
+	if got := unescapeMountField(`a\134b\011c\`); got != "a\\b\tc\\" {
+		t.Errorf("unescapeMountField() = %q", got)
+	}
+}
+
+func TestMountFor(t *testing.T) {
+	mounts := parseProcMounts(testProcMounts)
+	tests := []struct {
+		path   string
+		mount  string
+		fsType string
+	}{
+		{"/", "/", "ext4"},
+		{"/etc/hosts", "/", "ext4"},
+		{"/proc/1/fd", "/proc", "proc"},
+		{"/processes", "/", "ext4"},
+		{"/data/home/alice", "/data/home", "nfs4"},
+		{"/data/x", "/data", "tmpfs"}, // Stacked on /data after the xfs mount
+		{"/mnt/usb stick/a", "/mnt/usb stick", "vfat"},
+	}
+	for _, tt := range tests {
+		mount, ok := mountFor(mounts, tt.path)
+		if !ok || mount.path != tt.mount || mount.fsType != tt.fsType {
+			t.Errorf("mountFor(%q) = %+v, want %s on %s", tt.path, mount, tt.fsType, tt.mount)
+		}
+	}
+}
+
+func TestMarkMountEntries(t *testing.T) {
+	entries := []RemoteFileEntry{
+		{Name: "proc", Path: "/proc", IsDir: true},
+		{Name: "etc", Path: "/etc", IsDir: true},
+		{Name: "data", Path: "/data", IsDir: true},
+		{Name: "cpuinfo", Path: "/proc/cpuinfo"},
+		{Name: "home", Path: "/data/home", IsDir: true},
+		{Name: "relative", Path: "relative", IsDir: true},
+	}
+	markMountEntries(parseProcMounts(testProcMounts), entries)
+
+	type mark struct {
+		mountPoint     bool
+		fsType, fsKind string
+	}
+	want := []mark{
+		{true, "proc", FilesystemKindVirtual},
+		{false, "", ""},
+		{true, "tmpfs", ""},
+		{false, "proc", FilesystemKindVirtual},
+		{true, "nfs4", FilesystemKindNetwork},
+		{false, "", ""},
+	}
+	for i, entry := range entries {
+		if got := (mark{entry.MountPoint, entry.FSType, entry.FSKind}); got != want[i] {
+			t.Errorf("%s marked %+v, want %+v", entry.Path, got, want[i])
+		}
+	}
+
+	// Prefetching skips special filesystems
+	if got := subdirectoryPaths(entries); !reflect.DeepEqual(got, []string{"/etc", "/data", "relative"}) {
+		t.Errorf("subdirectoryPaths() = %v, want the ordinary directories", got)
+	}
+}
+
+func TestGetPathInfo(t *testing.T) {
+	app := NewApp()
+	sessionID := "session_path_info"
+	defer app.ReleaseSession(sessionID)
+	remoteMountsCacheMu.Lock()
+	remoteMountsCache[sessionID] = cachedMounts{mounts: parseProcMounts(testProcMounts), fetched: time.Now()}
+	remoteMountsCacheMu.Unlock()
+
+	info, err := app.GetPathInfo(sessionID, "/proc/")
+	if err != nil {
+		t.Fatalf("GetPathInfo() returned error: %v", err)
+	}
+	want := RemotePathInfo{Path: "/proc", MountPoint: "/proc", IsMountPoint: true, Device: "proc", FSType: "proc", FSKind: FilesystemKindVirtual}
+	if info != want {
+		t.Errorf("GetPathInfo() = %+v, want %+v", info, want)
+	}
+	if info, err := app.GetPathInfo(sessionID, "/data/home/alice"); err != nil || info.IsMountPoint || info.FSKind != FilesystemKindNetwork {
+		t.Errorf("GetPathInfo() below an NFS mount = %+v, %v", info, err)
+	}
+	if _, err := app.GetPathInfo(sessionID, "relative/path"); err == nil {
+		t.Error("GetPathInfo() accepted a relative path")
+	}
+
+	// A mount table that couldn't be read isn't retried until it expires
+	remoteMountsCacheMu.Lock()
+	remoteMountsCache[sessionID] = cachedMounts{fetched: time.Now()}
+	remoteMountsCacheMu.Unlock()
+	if _, err := app.GetPathInfo(sessionID, "/proc"); err == nil {
+		t.Error("GetPathInfo() with an unreadable mount table returned no error")
+	}
+
+	app.ReleaseSession(sessionID)
+	remoteMountsCacheMu.Lock()
+	_, cached := remoteMountsCache[sessionID]
+	remoteMountsCacheMu.Unlock()
+	if cached {
+		t.Error("mount table kept after the session was released")
+	}
+}
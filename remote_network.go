@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// RouteEntry is a row of a remote host's routing table
+type RouteEntry struct {
+	Destination string `json:"destination"` // CIDR or "default" for ip route, address for netstat
+	Gateway     string `json:"gateway,omitempty"`
+	Netmask     string `json:"netmask,omitempty"`
+	Interface   string `json:"interface,omitempty"`
+	Metric      string `json:"metric,omitempty"`
+	Flags       string `json:"flags,omitempty"` // netstat style: U (up), G (gateway), H (host), plus the route type
+}
+
+// ARPEntry is a neighbour in a remote host's ARP cache
+type ARPEntry struct {
+	Address   string `json:"address"`
+	HWType    string `json:"hwType,omitempty"`
+	HWAddress string `json:"hwAddress,omitempty"` // Empty while the entry is incomplete
+	Flags     string `json:"flags,omitempty"`     // arp flags (C, M, P), or the neighbour state from ip neigh
+	Interface string `json:"interface,omitempty"`
+}
+
+// ipRouteTypes are the route types ip route prints before the destination
+var ipRouteTypes = map[string]bool{
+	"unicast": true, "local": true, "broadcast": true, "multicast": true, "throw": true,
+	"unreachable": true, "prohibit": true, "blackhole": true, "nat": true, "anycast": true,
+}
+
+// cidrNetmask returns the dotted netmask of an IPv4 CIDR, or "" for anything else
+func cidrNetmask(cidr string) string {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil || network.IP.To4() == nil {
+		return ""
+	}
+	return net.IP(network.Mask).String()
+}
+
+// parseIPRoute parses `ip route show` lines such as "10.0.0.0/24 via 10.0.0.1 dev eth0 metric 100"
+func parseIPRoute(output string) []RouteEntry {
+	var routes []RouteEntry
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var route RouteEntry
+		routeType := ""
+		if ipRouteTypes[fields[0]] && len(fields) > 1 {
+			routeType = fields[0]
+			fields = fields[1:]
+		}
+		route.Destination = fields[0]
+		for i := 1; i+1 < len(fields); i++ {
+			switch fields[i] {
+			case "via":
+				route.Gateway = fields[i+1]
+			case "dev":
+				route.Interface = fields[i+1]
+			case "metric":
+				route.Metric = fields[i+1]
+			default:
+				continue
+			}
+			i++
+		}
+
+		switch {
+		case route.Destination == "default":
+			route.Netmask = "0.0.0.0"
+		case !strings.Contains(route.Destination, "/"):
+			// ip route omits /32 on host routes
+			if ip := net.ParseIP(route.Destination); ip != nil && ip.To4() != nil {
+				route.Netmask = "255.255.255.255"
+			}
+		default:
+			route.Netmask = cidrNetmask(route.Destination)
+		}
+
+		flags := "U"
+		if route.Gateway != "" {
+			flags += "G"
+		}
+		if route.Netmask == "255.255.255.255" {
+			flags += "H"
+		}
+		if routeType != "" && routeType != "unicast" {
+			flags += " " + routeType
+		}
+		route.Flags = flags
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// parseNetstatRoutes parses the tables of `netstat -rn` on Linux and the BSDs/macOS, whose
+// column names differ (Genmask/Netmask, Iface/Netif) and which may print several tables
+func parseNetstatRoutes(output string) []RouteEntry {
+	var routes []RouteEntry
+	var columns map[string]int
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "Destination" {
+			columns = make(map[string]int, len(fields))
+			for i, name := range fields {
+				columns[name] = i
+			}
+			continue
+		}
+		// Titles ("Kernel IP routing table", "Internet6:") and anything before a header
+		if columns == nil || len(fields) < 2 || strings.HasSuffix(fields[0], ":") || fields[0] == "Kernel" {
+			continue
+		}
+
+		column := func(names ...string) string {
+			for _, name := range names {
+				if i, ok := columns[name]; ok && i < len(fields) {
+					return fields[i]
+				}
+			}
+			return ""
+		}
+		route := RouteEntry{
+			Destination: fields[0],
+			Gateway:     column("Gateway"),
+			Netmask:     column("Genmask", "Netmask"),
+			Flags:       column("Flags"),
+			Metric:      column("Metric"),
+			Interface:   column("Iface", "Netif", "Interface"),
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// parseRouteTable parses either `ip route` or `netstat -rn` output
+func parseRouteTable(output string) []RouteEntry {
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "Destination") {
+			return parseNetstatRoutes(output)
+		}
+	}
+	return parseIPRoute(output)
+}
+
+// parseARPTable parses `arp -n` output, or `ip neigh` output on hosts without net-tools
+func parseARPTable(output string) []ARPEntry {
+	var entries []ARPEntry
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] == "Address" {
+			continue
+		}
+
+		if fields[1] == "dev" {
+			// ip neigh: "10.0.0.1 dev eth0 lladdr 52:54:00:12:34:56 REACHABLE"
+			entry := ARPEntry{Address: fields[0], Flags: fields[len(fields)-1]}
+			for i := 1; i+1 < len(fields); i++ {
+				switch fields[i] {
+				case "dev":
+					entry.Interface = fields[i+1]
+				case "lladdr":
+					entry.HWAddress = fields[i+1]
+					entry.HWType = "ether"
+				}
+			}
+			entries = append(entries, entry)
+			continue
+		}
+
+		// arp -n: "10.0.0.1  ether  52:54:00:12:34:56  C  eth0", or
+		// "10.0.0.7  (incomplete)  eth0" with the empty columns collapsed
+		entry := ARPEntry{Address: fields[0], Interface: fields[len(fields)-1]}
+		if fields[1] == "(incomplete)" {
+			entry.Flags = "incomplete"
+		} else if len(fields) >= 5 {
+			entry.HWType = fields[1]
+			entry.HWAddress = fields[2]
+			entry.Flags = fields[3]
+		} else {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// GetRemoteRouteTable returns the routing table of a remote host, from ip route or, where
+// iproute2 isn't installed (BSDs, macOS, old Linux), netstat -rn
+func (a *App) GetRemoteRouteTable(sessionID string) ([]RouteEntry, error) {
+	sshSession, err := a.monitoringSession(sessionID, "GetRemoteRouteTable")
+	if err != nil {
+		return nil, err
+	}
+	output, err := a.executeMonitoringCommand(sshSession, remoteCmdRouteTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote route table: %w", err)
+	}
+	return parseRouteTable(output), nil
+}
+
+// GetRemoteARPTable returns the hosts in a remote host's ARP cache, i.e. the neighbours it
+// has recently talked to
+func (a *App) GetRemoteARPTable(sessionID string) ([]ARPEntry, error) {
+	sshSession, err := a.monitoringSession(sessionID, "GetRemoteARPTable")
+	if err != nil {
+		return nil, err
+	}
+	output, err := a.executeMonitoringCommand(sshSession, remoteCmdARPTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote ARP table: %w", err)
+	}
+	return parseARPTable(output), nil
+}
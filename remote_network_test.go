@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestParseRouteTableIPRoute(t *testing.T) {
+	routes := parseRouteTable(`default via 10.0.0.1 dev eth0 proto dhcp metric 100
+10.0.0.0/24 dev eth0 proto kernel scope link src 10.0.0.5 metric 100
+blackhole 10.9.0.0/16
+172.17.0.9 via 10.0.0.254 dev eth0
+`)
+	want := []RouteEntry{
+		{Destination: "default", Gateway: "10.0.0.1", Netmask: "0.0.0.0", Interface: "eth0", Metric: "100", Flags: "UG"},
+		{Destination: "10.0.0.0/24", Netmask: "255.255.255.0", Interface: "eth0", Metric: "100", Flags: "U"},
+		{Destination: "10.9.0.0/16", Netmask: "255.255.0.0", Flags: "U blackhole"},
+		{Destination: "172.17.0.9", Gateway: "10.0.0.254", Netmask: "255.255.255.255", Interface: "eth0", Flags: "UGH"},
+	}
+	if len(routes) != len(want) {
+		t.Fatalf("got %d routes, want %d: %+v", len(routes), len(want), routes)
+	}
+	for i := range want {
+		if routes[i] != want[i] {
+			t.Errorf("route %d = %+v, want %+v", i, routes[i], want[i])
+		}
+	}
+}
+
+func TestParseRouteTableNetstat(t *testing.T) {
+	linux := parseRouteTable(`Kernel IP routing table
+Destination     Gateway         Genmask         Flags   MSS Window  irtt Iface
+0.0.0.0         10.0.0.1        0.0.0.0         UG        0 0          0 eth0
+`)
+	if len(linux) != 1 || linux[0] != (RouteEntry{Destination: "0.0.0.0", Gateway: "10.0.0.1", Netmask: "0.0.0.0", Flags: "UG", Interface: "eth0"}) {
+		t.Errorf("linux netstat = %+v", linux)
+	}
+
+	mac := parseRouteTable(`Routing tables
+
+Internet:
+Destination        Gateway            Flags        Netif Expire
+default            192.168.1.1        UGScg          en0
+127                127.0.0.1          UCS            lo0
+`)
+	if len(mac) != 2 || mac[0].Gateway != "192.168.1.1" || mac[0].Interface != "en0" || mac[1].Flags != "UCS" {
+		t.Errorf("macOS netstat = %+v", mac)
+	}
+}
+
+func TestParseARPTable(t *testing.T) {
+	entries := parseARPTable(`Address                  HWtype  HWaddress           Flags Mask            Iface
+10.0.0.1                 ether   52:54:00:12:34:56   C                     eth0
+10.0.0.7                         (incomplete)                              eth0
+`)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries: %+v", len(entries), entries)
+	}
+	if entries[0] != (ARPEntry{Address: "10.0.0.1", HWType: "ether", HWAddress: "52:54:00:12:34:56", Flags: "C", Interface: "eth0"}) {
+		t.Errorf("entry 0 = %+v", entries[0])
+	}
+	if entries[1] != (ARPEntry{Address: "10.0.0.7", Flags: "incomplete", Interface: "eth0"}) {
+		t.Errorf("entry 1 = %+v", entries[1])
+	}
+
+	neigh := parseARPTable("10.0.0.1 dev eth0 lladdr 52:54:00:12:34:56 REACHABLE\n10.0.0.9 dev eth0 FAILED\n")
+	if len(neigh) != 2 || neigh[0].HWAddress != "52:54:00:12:34:56" || neigh[0].Flags != "REACHABLE" || neigh[1].Flags != "FAILED" {
+		t.Errorf("ip neigh = %+v", neigh)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExternalOpenCleanupDelay is how long a file opened with its default application is kept.
+// The application must have read it by then; most keep their own copy once loaded.
+const ExternalOpenCleanupDelay = 15 * time.Minute
+
+// externalOpenSafeExtensions open in a viewer without running anything, so they open directly.
+// Every other type needs the user's confirmation first.
+var externalOpenSafeExtensions = map[string]bool{
+	".pdf": true, ".txt": true, ".log": true, ".md": true, ".csv": true, ".tsv": true, ".json": true,
+	".yaml": true, ".yml": true, ".toml": true, ".ini": true, ".conf": true, ".png": true, ".jpg": true,
+	".jpeg": true, ".gif": true, ".bmp": true, ".webp": true, ".tif": true, ".tiff": true, ".mp3": true,
+	".wav": true, ".flac": true, ".ogg": true, ".mp4": true, ".mkv": true, ".webm": true, ".mov": true,
+	".docx": true, ".xlsx": true, ".pptx": true, ".odt": true, ".ods": true, ".odp": true,
+}
+
+// externalOpenBlockedExtensions are files the OS would run rather than open in a viewer;
+// they are refused even with confirmation
+var externalOpenBlockedExtensions = map[string]bool{
+	".exe": true, ".com": true, ".bat": true, ".cmd": true, ".msi": true, ".msp": true, ".scr": true,
+	".pif": true, ".cpl": true, ".ps1": true, ".psm1": true, ".vbs": true, ".vbe": true, ".js": true,
+	".jse": true, ".wsf": true, ".wsh": true, ".hta": true, ".lnk": true, ".reg": true, ".jar": true,
+	".chm": true, ".msc": true, ".url": true, ".scf": true, ".settingcontent-ms": true,
+	".library-ms": true, ".appref-ms": true, ".application": true, ".inf": true,
+	".app": true, ".command": true, ".tool": true, ".pkg": true, ".terminal": true, ".workflow": true,
+	".desktop": true, ".appimage": true, ".sh": true, ".run": true, ".bin": true,
+}
+
+// externalOpenRiskyExtensions describe common types that can run code inside the application
+// that opens them, for the confirmation prompt
+var externalOpenRiskyExtensions = map[string]string{
+	".docm": "Word document with macros", ".xlsm": "Excel workbook with macros", ".pptm": "PowerPoint presentation with macros",
+	".doc": "legacy Office document that may contain macros", ".xls": "legacy Office document that may contain macros",
+	".html": "web page that may run scripts", ".htm": "web page that may run scripts", ".svg": "image that may run scripts",
+	".iso": "disk image", ".dmg": "disk image", ".zip": "archive", ".7z": "archive", ".rar": "archive",
+}
+
+// ExternalOpenOptions controls how OpenRemoteFileExternallyWithOptions opens a file
+type ExternalOpenOptions struct {
+	Confirmed bool `json:"confirmed"` // The user agreed to open a type that isn't known to be safe
+}
+
+// ExternalOpenConfirmationError reports a file type that only opens once the user confirms it
+type ExternalOpenConfirmationError struct {
+	FileName string
+	Warning  string // What the user is asked to confirm
+}
+
+func (e *ExternalOpenConfirmationError) Error() string {
+	return e.Warning
+}
+
+var externalOpenDirs = make(map[string]*time.Timer) // Temp dir -> pending cleanup
+var externalOpenDirsMu sync.Mutex
+
+// externalOpenCheck refuses executables and asks for confirmation for anything not known to be safe
+func externalOpenCheck(fileName string, confirmed bool) error {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	switch {
+	case externalOpenBlockedExtensions[ext]:
+		return fmt.Errorf("refusing to open %s: %s files are executable; download it instead", fileName, ext)
+	case externalOpenSafeExtensions[ext] || confirmed:
+		return nil
+	}
+	warning := fmt.Sprintf("%s is not a file type Thermic knows to be safe to open.", fileName)
+	if risk, exists := externalOpenRiskyExtensions[ext]; exists {
+		warning = fmt.Sprintf("%s is a %s.", fileName, risk)
+	}
+	return &ExternalOpenConfirmationError{
+		FileName: fileName,
+		Warning:  warning + " Only open it if you trust its source.",
+	}
+}
+
+// localFileName turns a remote file name into one every local file system accepts. Windows
+// drops trailing dots and spaces, so they are stripped here: "evil.exe." must be checked as
+// the "evil.exe" it becomes.
+func localFileName(remotePath string) string {
+	base := path.Base(remotePath)
+	if base == "/" {
+		return "download"
+	}
+	name := strings.TrimRight(strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, base), ". ")
+	if name == "" {
+		return "download" // "." and ".."
+	}
+	return name
+}
+
+// launchWithDefaultApp opens a local file in the application the OS associates with it
+func launchWithDefaultApp(localPath string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", localPath)
+	case "windows":
+		// Not "cmd /c start": cmd would interpret characters like & in the file name
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", localPath)
+	default:
+		cmd = exec.Command("xdg-open", localPath)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open %s: %w", filepath.Base(localPath), err)
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// scheduleExternalOpenCleanup removes a temp dir after ExternalOpenCleanupDelay
+func scheduleExternalOpenCleanup(dir string) {
+	externalOpenDirsMu.Lock()
+	defer externalOpenDirsMu.Unlock()
+	externalOpenDirs[dir] = time.AfterFunc(ExternalOpenCleanupDelay, func() {
+		externalOpenDirsMu.Lock()
+		delete(externalOpenDirs, dir)
+		externalOpenDirsMu.Unlock()
+		os.RemoveAll(dir)
+	})
+}
+
+// cleanupExternalOpenFiles removes every file still kept for an external application
+func cleanupExternalOpenFiles() {
+	externalOpenDirsMu.Lock()
+	defer externalOpenDirsMu.Unlock()
+	for dir, timer := range externalOpenDirs {
+		timer.Stop()
+		os.RemoveAll(dir)
+		delete(externalOpenDirs, dir)
+	}
+}
+
+// OpenRemoteFileExternally downloads a remote file to a temp dir and opens it in its default
+// application, e.g. a PDF viewer. Executables are refused, and types that aren't known to be
+// safe return an ExternalOpenConfirmationError until reopened with confirmation.
+// The copy is removed after ExternalOpenCleanupDelay or when Thermic exits.
+func (a *App) OpenRemoteFileExternally(sessionID, remotePath string) error {
+	return a.OpenRemoteFileExternallyWithOptions(sessionID, remotePath, ExternalOpenOptions{})
+}
+
+// OpenRemoteFileExternallyWithOptions is OpenRemoteFileExternally with options, e.g. to open
+// a type the user confirmed
+func (a *App) OpenRemoteFileExternallyWithOptions(sessionID, remotePath string, options ExternalOpenOptions) error {
+	fileName := localFileName(remotePath)
+	if err := externalOpenCheck(fileName, options.Confirmed); err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "thermic-open-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	localPath := filepath.Join(dir, fileName)
+	if err := a.DownloadRemoteFile(sessionID, remotePath, localPath); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	// Never executable locally, whatever its mode on the server
+	if err := os.Chmod(localPath, 0600); err != nil {
+		os.RemoveAll(dir)
+		return fmt.Errorf("failed to secure downloaded file: %w", err)
+	}
+	scheduleExternalOpenCleanup(dir)
+	return launchWithDefaultApp(localPath)
+}
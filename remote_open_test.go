@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExternalOpenCheck(t *testing.T) {
+	for _, name := range []string{"setup.exe", "INSTALL.CMD", "run.sh", "Tool.app", "help.chm", "link.url", "x.settingcontent-ms"} {
+		if err := externalOpenCheck(name, true); err == nil {
+			t.Errorf("externalOpenCheck(%q) allowed an executable", name)
+		}
+	}
+	for _, name := range []string{"report.xlsm", "page.html", "notes.unknown", "README"} {
+		var confirmErr *ExternalOpenConfirmationError
+		if err := externalOpenCheck(name, false); !errors.As(err, &confirmErr) || confirmErr.Warning == "" {
+			t.Errorf("externalOpenCheck(%q) = %v, want a confirmation request", name, err)
+		}
+		if err := externalOpenCheck(name, true); err != nil {
+			t.Errorf("externalOpenCheck(%q) once confirmed = %v", name, err)
+		}
+	}
+	if err := externalOpenCheck("manual.PDF", false); err != nil {
+		t.Errorf("externalOpenCheck(manual.PDF) = %v, want nil", err)
+	}
+	if code := classifyErrorCode(externalOpenCheck("report.xlsm", false)); code != ErrCodeConfirm {
+		t.Errorf("confirmation request classified as %d, want %d", code, ErrCodeConfirm)
+	}
+}
+
+func TestLocalFileName(t *testing.T) {
+	for remote, want := range map[string]string{
+		"/var/log/app.log":   "app.log",
+		"/tmp/a:b?.txt":      "a_b_.txt",
+		"/":                  "download",
+		"/srv/..":            "download",
+		"/srv/notes\\v2.txt": "notes_v2.txt",
+		"/tmp/evil.exe.":     "evil.exe",
+		"/tmp/evil.exe . .":  "evil.exe",
+	} {
+		if got := localFileName(remote); got != want {
+			t.Errorf("localFileName(%q) = %q, want %q", remote, got, want)
+		}
+	}
+	// The check runs on the local name, so a trailing dot can't hide an executable
+	if err := externalOpenCheck(localFileName("/tmp/evil.exe."), true); err == nil {
+		t.Error("evil.exe. passed the executable check")
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// remoteAccountNamePattern matches user and group names chown accepts (POSIX portable names,
+// the trailing $ of Samba machine accounts, or a numeric ID)
+var remoteAccountNamePattern = regexp.MustCompile(`^[A-Za-z0-9._][A-Za-z0-9._-]*\$?$`)
+
+// RemoteUser is an account on a remote host
+type RemoteUser struct {
+	Username string `json:"username"`
+	UID      int    `json:"uid"`
+	Comment  string `json:"comment,omitempty"` // GECOS field, usually the full name
+}
+
+// RemoteGroup is a group on a remote host
+type RemoteGroup struct {
+	Name string `json:"name"`
+	GID  int    `json:"gid"`
+}
+
+// RemoteOwnership is the owner and group of a remote path
+type RemoteOwnership struct {
+	User  string `json:"user"`
+	Group string `json:"group"`
+	UID   int    `json:"uid"`
+	GID   int    `json:"gid"`
+}
+
+// monitoringSession returns the SSH session whose monitoring connection runs commands
+func (a *App) monitoringSession(sessionID, operation string) (*SSHSession, error) {
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+
+	if !exists || sshSession == nil {
+		return nil, newNotFoundError(ErrCategorySFTP, operation, "SSH session %s not found", sessionID)
+	}
+	return sshSession, nil
+}
+
+// parseRemoteUsers parses "name:uid:comment" lines
+func parseRemoteUsers(output string) []RemoteUser {
+	var users []RemoteUser
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), ":", 3)
+		if len(fields) < 2 {
+			continue
+		}
+		uid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		user := RemoteUser{Username: fields[0], UID: uid}
+		if len(fields) == 3 {
+			// "Jane Doe,,," - the other GECOS subfields are rarely filled in
+			user.Comment = strings.TrimRight(fields[2], ",")
+		}
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users
+}
+
+// parseRemoteGroups parses "name:gid" lines
+func parseRemoteGroups(output string) []RemoteGroup {
+	var groups []RemoteGroup
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ":")
+		if len(fields) != 2 {
+			continue
+		}
+		gid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		groups = append(groups, RemoteGroup{Name: fields[0], GID: gid})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups
+}
+
+// GetRemoteUsers lists the accounts known to a remote host, including directory (LDAP/SSSD)
+// accounts when getent is available
+func (a *App) GetRemoteUsers(sessionID string) ([]RemoteUser, error) {
+	sshSession, err := a.monitoringSession(sessionID, "GetRemoteUsers")
+	if err != nil {
+		return nil, err
+	}
+	// Hosts without getent (macOS, some BusyBox builds) only have the local file
+	output, err := a.executeMonitoringCommand(sshSession, remoteCmdListUsers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remote users: %w", err)
+	}
+	return parseRemoteUsers(output), nil
+}
+
+// GetRemoteGroups lists the groups known to a remote host
+func (a *App) GetRemoteGroups(sessionID string) ([]RemoteGroup, error) {
+	sshSession, err := a.monitoringSession(sessionID, "GetRemoteGroups")
+	if err != nil {
+		return nil, err
+	}
+	output, err := a.executeMonitoringCommand(sshSession, remoteCmdListGroups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remote groups: %w", err)
+	}
+	return parseRemoteGroups(output), nil
+}
+
+// GetRemoteFileOwnership returns the owner and group of a remote path
+func (a *App) GetRemoteFileOwnership(sessionID, remotePath string) (RemoteOwnership, error) {
+	sshSession, err := a.monitoringSession(sessionID, "GetRemoteFileOwnership")
+	if err != nil {
+		return RemoteOwnership{}, err
+	}
+
+	// GNU stat first, then the BSD/macOS form
+	cmd := buildRemoteCommand("stat -c '%%U %%G %%u %%g' -- %[1]s 2>/dev/null || stat -f '%%Su %%Sg %%u %%g' -- %[1]s", remotePath)
+	output, err := a.executeMonitoringCommand(sshSession, cmd)
+	if err != nil {
+		return RemoteOwnership{}, fmt.Errorf("failed to read ownership of %s: %w", remotePath, err)
+	}
+	return parseRemoteOwnership(output)
+}
+
+// parseRemoteOwnership parses "user group uid gid"
+func parseRemoteOwnership(output string) (RemoteOwnership, error) {
+	fields := strings.Fields(output)
+	if len(fields) != 4 {
+		return RemoteOwnership{}, fmt.Errorf("unexpected stat output: %q", strings.TrimSpace(output))
+	}
+	uid, uidErr := strconv.Atoi(fields[2])
+	gid, gidErr := strconv.Atoi(fields[3])
+	if uidErr != nil || gidErr != nil {
+		return RemoteOwnership{}, fmt.Errorf("unexpected stat output: %q", strings.TrimSpace(output))
+	}
+	return RemoteOwnership{User: fields[0], Group: fields[1], UID: uid, GID: gid}, nil
+}
+
+// chownCommand builds the command that changes ownership, retrying with passwordless sudo
+// when the user may not. It prints sudo's failure, if any, and then "exit:0" or "exit:1".
+func chownCommand(remotePath, username, groupname string, recursive bool) (remoteCommand, error) {
+	if username == "" && groupname == "" {
+		return "", fmt.Errorf("a user or a group is required")
+	}
+	for _, name := range []string{username, groupname} {
+		if name != "" && !remoteAccountNamePattern.MatchString(name) {
+			return "", fmt.Errorf("invalid user or group name: '%s'", name)
+		}
+	}
+	remotePath, err := normalizeRemotePath(remotePath)
+	if err != nil {
+		return "", err
+	}
+
+	spec := username
+	if groupname != "" {
+		spec += ":" + groupname
+	}
+	if recursive {
+		return buildRemoteCommand("chown -R -- %[1]s %[2]s 2>/dev/null || sudo -n chown -R -- %[1]s %[2]s 2>&1 && echo exit:0 || echo exit:1", spec, remotePath), nil
+	}
+	return buildRemoteCommand("chown -- %[1]s %[2]s 2>/dev/null || sudo -n chown -- %[1]s %[2]s 2>&1 && echo exit:0 || echo exit:1", spec, remotePath), nil
+}
+
+// ChownRemotePath changes the owner and/or group of a remote path. Either name may be empty
+// to leave it unchanged. Changing the owner usually needs root, so when the user can't the
+// change is retried with passwordless sudo; sudo that asks for a password is reported.
+func (a *App) ChownRemotePath(sessionID, remotePath, username, groupname string, recursive bool) error {
+	cmd, err := chownCommand(remotePath, username, groupname, recursive)
+	if err != nil {
+		return err
+	}
+	sshSession, err := a.monitoringSession(sessionID, "ChownRemotePath")
+	if err != nil {
+		return err
+	}
+
+	// The sudo fallback may run, so the command is audited either way
+	output, err := a.runSudoCommand(sshSession, "ChownRemotePath", cmd)
+	if err != nil {
+		return fmt.Errorf("failed to change ownership of %s: %w", remotePath, err)
+	}
+	output = strings.TrimSpace(output)
+	if strings.HasSuffix(output, "exit:0") {
+		fmt.Printf("Changed ownership of %s to %s:%s (recursive: %v)\n", remotePath, username, groupname, recursive)
+		return nil
+	}
+
+	message := strings.TrimSpace(strings.TrimSuffix(output, "exit:1"))
+	if strings.Contains(message, "password is required") || strings.Contains(message, "a terminal is required") {
+		return fmt.Errorf("permission denied changing ownership of %s, and sudo requires a password", remotePath)
+	}
+	if message == "" {
+		message = "permission denied"
+	}
+	return fmt.Errorf("failed to change ownership of %s: %s", remotePath, message)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRemoteAccounts(t *testing.T) {
+	users := parseRemoteUsers("root:0:root\ndeploy:1001:Deploy User,,,\nbroken\nnobody:x:\n")
+	if len(users) != 2 || users[0].Username != "deploy" || users[0].UID != 1001 || users[0].Comment != "Deploy User" {
+		t.Errorf("parseRemoteUsers() = %+v", users)
+	}
+
+	groups := parseRemoteGroups("wheel:10\nadm:4\n\n")
+	if len(groups) != 2 || groups[0].Name != "adm" || groups[0].GID != 4 {
+		t.Errorf("parseRemoteGroups() = %+v", groups)
+	}
+
+	owner, err := parseRemoteOwnership("www-data www-data 33 33\n")
+	if err != nil || owner != (RemoteOwnership{User: "www-data", Group: "www-data", UID: 33, GID: 33}) {
+		t.Errorf("parseRemoteOwnership() = %+v, %v", owner, err)
+	}
+}
+
+func TestChownCommand(t *testing.T) {
+	cmd, err := chownCommand("/srv/it's", "deploy", "", true)
+	if err != nil {
+		t.Fatalf("chownCommand() returned error: %v", err)
+	}
+	if !strings.HasPrefix(string(cmd), `chown -R -- 'deploy' '/srv/it'\''s' 2>/dev/null || sudo -n chown -R`) {
+		t.Errorf("chownCommand() = %s", cmd)
+	}
+
+	for _, names := range [][2]string{{"", ""}, {"-R", ""}, {"root;id", ""}, {"", "a b"}} {
+		if _, err := chownCommand("/srv", names[0], names[1], false); err == nil {
+			t.Errorf("chownCommand() accepted user %q group %q", names[0], names[1])
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RemoteSocketCacheTTL is how long socket listings are reused, so a panel refreshing on a timer
+// doesn't run ss for every redraw
+const RemoteSocketCacheTTL = 5 * time.Second
+
+// Tools a socket listing may come from, best first
+const (
+	SocketSourceSS      = "ss"
+	SocketSourceNetstat = "netstat"
+	SocketSourceProc    = "proc" // /proc/net/{tcp,udp}[6] on hosts without either; never has process names
+)
+
+// RemoteSocket is a listening socket or connection on a remote host
+type RemoteSocket struct {
+	Proto         string `json:"proto"` // tcp, udp, tcp6 or udp6
+	LocalAddress  string `json:"localAddress"`
+	LocalPort     int    `json:"localPort"`           // 0 when the port is "*"
+	Interface     string `json:"interface,omitempty"` // Device the socket is bound to, from "addr%dev"
+	PeerAddress   string `json:"peerAddress,omitempty"`
+	PeerPort      int    `json:"peerPort,omitempty"`
+	State         string `json:"state"` // netstat names: LISTEN, ESTABLISHED, TIME_WAIT...; UNCONN for unconnected UDP
+	PID           int    `json:"pid,omitempty"`
+	Process       string `json:"process,omitempty"`
+	ProcessHidden bool   `json:"processHidden,omitempty"` // Owned by another user; only root can see the process
+}
+
+// RemoteSocketReport is a socket listing and how it was obtained
+type RemoteSocketReport struct {
+	Sockets         []RemoteSocket `json:"sockets"`
+	Source          string         `json:"source"`          // SocketSource*
+	ProcessesHidden bool           `json:"processesHidden"` // Some processes need root to see; retry with sudo
+	FetchedAt       time.Time      `json:"fetchedAt"`
+}
+
+// ssUsersPattern matches one ("name",pid=N,fd=M) entry of ss's users:(...) field. Process names
+// may contain quotes, commas and parentheses, so the name runs up to the closing `",pid=`.
+var ssUsersPattern = regexp.MustCompile(`\("(.*?)",pid=(\d+)`)
+
+// socketStateNames maps ss state names to the netstat ones used in RemoteSocket.State
+var socketStateNames = map[string]string{
+	"ESTAB":     "ESTABLISHED",
+	"UNCONN":    "UNCONN",
+	"LISTEN":    "LISTEN",
+	"TIME-WAIT": "TIME_WAIT",
+}
+
+// procSocketStates maps the hex st column of /proc/net/tcp to state names
+var procSocketStates = map[string]string{
+	"01": "ESTABLISHED", "02": "SYN_SENT", "03": "SYN_RECV", "04": "FIN_WAIT1", "05": "FIN_WAIT2",
+	"06": "TIME_WAIT", "07": "CLOSE", "08": "CLOSE_WAIT", "09": "LAST_ACK", "0A": "LISTEN", "0B": "CLOSING",
+}
+
+// normalizeSocketState converts an ss state name, e.g. ESTAB or CLOSE-WAIT, to the netstat one
+func normalizeSocketState(state string) string {
+	if name, known := socketStateNames[state]; known {
+		return name
+	}
+	return strings.ReplaceAll(state, "-", "_")
+}
+
+// splitSocketAddress splits "addr:port", "[v6]:port" or "addr%dev:port" as printed by ss and
+// netstat. A "*" port is returned as 0.
+func splitSocketAddress(s string) (address string, port int, iface string) {
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return s, 0, ""
+	}
+	address = strings.TrimSuffix(strings.TrimPrefix(s[:i], "["), "]")
+	port, _ = strconv.Atoi(s[i+1:])
+	if zone := strings.IndexByte(address, '%'); zone >= 0 {
+		address, iface = address[:zone], address[zone+1:]
+		address = strings.TrimSuffix(address, "]")
+	}
+	return address, port, iface
+}
+
+// socketProto appends "6" to ss's tcp/udp for IPv6 addresses, matching netstat's names
+func socketProto(netid, address string) string {
+	if strings.Contains(address, ":") && !strings.HasSuffix(netid, "6") {
+		return netid + "6"
+	}
+	return netid
+}
+
+// parseSSLine parses a row of `ss -tulpn` / `ss -tupn`:
+// "tcp LISTEN 0 4096 [::]:22 [::]:* users:(("sshd",pid=812,fd=4))"
+func parseSSLine(line string) (RemoteSocket, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 6 || (fields[0] != "tcp" && fields[0] != "udp") {
+		return RemoteSocket{}, false
+	}
+
+	var socket RemoteSocket
+	socket.LocalAddress, socket.LocalPort, socket.Interface = splitSocketAddress(fields[4])
+	socket.PeerAddress, socket.PeerPort, _ = splitSocketAddress(fields[5])
+	socket.Proto = socketProto(fields[0], socket.LocalAddress)
+	socket.State = normalizeSocketState(fields[1])
+
+	if match := ssUsersPattern.FindStringSubmatch(strings.Join(fields[6:], " ")); match != nil {
+		socket.Process = match[1]
+		socket.PID, _ = strconv.Atoi(match[2])
+	}
+	return socket, true
+}
+
+// parseNetstatSocketLine parses a row of Linux `netstat -tulpn` / `netstat -tupn`. UDP rows
+// usually have no state, and the program name may contain spaces:
+// "tcp6 0 0 :::22 :::* LISTEN 812/sshd: /usr/sbin"
+func parseNetstatSocketLine(line string) (RemoteSocket, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 6 || !strings.HasPrefix(fields[0], "tcp") && !strings.HasPrefix(fields[0], "udp") {
+		return RemoteSocket{}, false
+	}
+
+	var socket RemoteSocket
+	socket.Proto = fields[0]
+	socket.LocalAddress, socket.LocalPort, socket.Interface = splitSocketAddress(fields[3])
+	socket.PeerAddress, socket.PeerPort, _ = splitSocketAddress(fields[4])
+
+	rest := fields[5:]
+	isProgram := func(field string) bool {
+		return field == "-" || strings.Contains(field, "/")
+	}
+	if strings.HasPrefix(socket.Proto, "tcp") || !isProgram(rest[0]) {
+		socket.State = rest[0]
+		rest = rest[1:]
+	} else {
+		socket.State = "UNCONN"
+	}
+
+	if program := strings.Join(rest, " "); program != "" && program != "-" {
+		if pid, name, found := strings.Cut(program, "/"); found {
+			socket.PID, _ = strconv.Atoi(pid)
+			socket.Process = name
+		}
+	}
+	return socket, true
+}
+
+// decodeProcAddress decodes a /proc/net address such as "0100007F:0277". The address is
+// stored as 32-bit words in host (little-endian) order, the port big-endian.
+func decodeProcAddress(s string) (string, int, bool) {
+	hexAddress, hexPort, found := strings.Cut(s, ":")
+	if !found {
+		return "", 0, false
+	}
+	raw, err := hex.DecodeString(hexAddress)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return "", 0, false
+	}
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return "", 0, false
+	}
+
+	ip := make(net.IP, len(raw))
+	for word := 0; word < len(raw); word += 4 {
+		for b := 0; b < 4; b++ {
+			ip[word+b] = raw[word+3-b]
+		}
+	}
+	return ip.String(), int(port), true
+}
+
+// parseProcNetLine parses a row of /proc/net/tcp, tcp6, udp or udp6 (proto names the file)
+func parseProcNetLine(proto, line string) (RemoteSocket, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || !strings.HasSuffix(fields[0], ":") {
+		return RemoteSocket{}, false
+	}
+
+	socket := RemoteSocket{Proto: proto, State: procSocketStates[fields[3]]}
+	var ok bool
+	if socket.LocalAddress, socket.LocalPort, ok = decodeProcAddress(fields[1]); !ok {
+		return RemoteSocket{}, false
+	}
+	if socket.PeerAddress, socket.PeerPort, ok = decodeProcAddress(fields[2]); !ok {
+		return RemoteSocket{}, false
+	}
+	// Unconnected UDP sockets sit in TCP_CLOSE
+	if strings.HasPrefix(proto, "udp") && socket.State == "CLOSE" {
+		socket.State = "UNCONN"
+	}
+	return socket, true
+}
+
+// parseRemoteSockets parses the output of remoteCmdListenSockets or remoteCmdConnections:
+// marker lines ("#uid 1000", "#ss", "#netstat", "#proc tcp6") followed by the tool's output.
+// listening keeps LISTEN and UNCONN sockets, otherwise only ESTABLISHED ones are kept.
+func parseRemoteSockets(output string, listening bool) RemoteSocketReport {
+	report := RemoteSocketReport{Sockets: []RemoteSocket{}}
+	uid := -1
+	procProto := ""
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if marker, found := strings.CutPrefix(line, "#"); found {
+			name, arg, _ := strings.Cut(marker, " ")
+			switch name {
+			case "uid":
+				uid, _ = strconv.Atoi(strings.TrimSpace(arg))
+			case SocketSourceSS, SocketSourceNetstat:
+				report.Source = name
+			case SocketSourceProc:
+				report.Source = name
+				procProto = arg
+			}
+			continue
+		}
+
+		var socket RemoteSocket
+		var ok bool
+		switch report.Source {
+		case SocketSourceSS:
+			socket, ok = parseSSLine(line)
+		case SocketSourceNetstat:
+			socket, ok = parseNetstatSocketLine(line)
+		case SocketSourceProc:
+			socket, ok = parseProcNetLine(procProto, line)
+		}
+		if !ok {
+			continue
+		}
+
+		isListening := socket.State == "LISTEN" || socket.State == "UNCONN"
+		if listening != isListening || (!listening && socket.State != "ESTABLISHED") {
+			continue
+		}
+		if listening {
+			// The peer of a listening socket is always a wildcard
+			socket.PeerAddress, socket.PeerPort = "", 0
+		}
+		// ss and netstat only name processes the user owns, unless running as root
+		if report.Source != SocketSourceProc && socket.PID == 0 && uid != 0 {
+			socket.ProcessHidden = true
+			report.ProcessesHidden = true
+		}
+		report.Sockets = append(report.Sockets, socket)
+	}
+	return report
+}
+
+// filterRemoteSockets keeps the sockets whose protocol, addresses, ports, state or process
+// contain filter, ignoring case
+func filterRemoteSockets(sockets []RemoteSocket, filter string) []RemoteSocket {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	if filter == "" {
+		return sockets
+	}
+	filtered := []RemoteSocket{}
+	for _, socket := range sockets {
+		text := strings.ToLower(fmt.Sprintf("%s %s:%d %s:%d %s %d %s", socket.Proto,
+			socket.LocalAddress, socket.LocalPort, socket.PeerAddress, socket.PeerPort, socket.State, socket.PID, socket.Process))
+		if strings.Contains(text, filter) {
+			filtered = append(filtered, socket)
+		}
+	}
+	return filtered
+}
+
+var remoteSocketCache = make(map[string]map[string]RemoteSocketReport) // Session -> operation -> report
+var remoteSocketCacheMu sync.Mutex
+
+// clearRemoteSocketCache drops the cached socket listings of a session
+func clearRemoteSocketCache(sessionID string) {
+	remoteSocketCacheMu.Lock()
+	defer remoteSocketCacheMu.Unlock()
+	delete(remoteSocketCache, sessionID)
+}
+
+// fetchRemoteSockets runs a socket listing on the monitoring session, as root when useSudo is
+// set, reusing a listing younger than RemoteSocketCacheTTL
+func (a *App) fetchRemoteSockets(sessionID, operation string, command remoteCommand, listening, useSudo bool) (RemoteSocketReport, error) {
+	remoteSocketCacheMu.Lock()
+	cached, exists := remoteSocketCache[sessionID][operation]
+	remoteSocketCacheMu.Unlock()
+	if exists && time.Since(cached.FetchedAt) < RemoteSocketCacheTTL {
+		return cached, nil
+	}
+
+	sshSession, err := a.monitoringSession(sessionID, operation)
+	if err != nil {
+		return RemoteSocketReport{}, err
+	}
+	var output string
+	if useSudo {
+		output, err = a.runSudoCommand(sshSession, operation, sudoShellCommand(command))
+	} else {
+		output, err = a.executeMonitoringCommand(sshSession, command)
+	}
+	if err != nil {
+		return RemoteSocketReport{}, fmt.Errorf("failed to list remote sockets: %w", err)
+	}
+
+	report := parseRemoteSockets(output, listening)
+	if report.Source == "" {
+		return RemoteSocketReport{}, fmt.Errorf("failed to list remote sockets: %s", strings.TrimSpace(output))
+	}
+	report.FetchedAt = time.Now()
+
+	remoteSocketCacheMu.Lock()
+	if remoteSocketCache[sessionID] == nil {
+		remoteSocketCache[sessionID] = make(map[string]RemoteSocketReport)
+	}
+	remoteSocketCache[sessionID][operation] = report
+	remoteSocketCacheMu.Unlock()
+	return report, nil
+}
+
+// GetRemoteListeningSockets returns the TCP and UDP sockets listening on a remote host, from
+// ss, netstat or /proc/net, whichever the host has. Processes of other users are only named
+// for root; ProcessesHidden says when GetRemoteListeningSocketsWithSudo would show more.
+func (a *App) GetRemoteListeningSockets(sessionID string) (RemoteSocketReport, error) {
+	return a.fetchRemoteSockets(sessionID, "GetRemoteListeningSockets", remoteCmdListenSockets, true, false)
+}
+
+// GetRemoteListeningSocketsWithSudo lists listening sockets as root, so every process is named
+func (a *App) GetRemoteListeningSocketsWithSudo(sessionID string) (RemoteSocketReport, error) {
+	return a.fetchRemoteSockets(sessionID, "GetRemoteListeningSocketsWithSudo", remoteCmdListenSockets, true, true)
+}
+
+// GetRemoteConnections returns the established TCP and connected UDP sockets of a remote host
+// with their peers. filter, when set, keeps the connections whose protocol, addresses, ports,
+// state or process contain it.
+func (a *App) GetRemoteConnections(sessionID, filter string) (RemoteSocketReport, error) {
+	report, err := a.fetchRemoteSockets(sessionID, "GetRemoteConnections", remoteCmdConnections, false, false)
+	if err != nil {
+		return RemoteSocketReport{}, err
+	}
+	report.Sockets = filterRemoteSockets(report.Sockets, filter)
+	return report, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import "testing"
+
+func TestParseRemoteSocketsSS(t *testing.T) {
+	// iproute2 6.x as an unprivileged user on Ubuntu 24.04, plus a bound-device v6 socket
+	report := parseRemoteSockets(`#uid 1000
+#ss
+Netid State  Recv-Q Send-Q            Local Address:Port  Peer Address:Port Process
+udp   UNCONN 0      0             127.0.0.53%lo:53         0.0.0.0:*
+tcp   LISTEN 0      4096                0.0.0.0:22         0.0.0.0:*
+tcp   LISTEN 0      511                    [::]:8080          [::]:*     users:(("node /srv/app \"x\"",pid=4242,fd=21),("node",pid=4243,fd=21))
+tcp   LISTEN 0      128         [fe80::1]%eth0:9100           [::]:*     users:(("node_exporter",pid=77,fd=3))
+tcp   ESTAB  0      0              10.0.0.5:22        10.0.0.9:51514
+`, true)
+
+	if report.Source != SocketSourceSS || !report.ProcessesHidden {
+		t.Errorf("source = %q, processesHidden = %v", report.Source, report.ProcessesHidden)
+	}
+	want := []RemoteSocket{
+		{Proto: "udp", LocalAddress: "127.0.0.53", LocalPort: 53, Interface: "lo", State: "UNCONN", ProcessHidden: true},
+		{Proto: "tcp", LocalAddress: "0.0.0.0", LocalPort: 22, State: "LISTEN", ProcessHidden: true},
+		{Proto: "tcp6", LocalAddress: "::", LocalPort: 8080, State: "LISTEN", PID: 4242, Process: `node /srv/app \"x\"`},
+		{Proto: "tcp6", LocalAddress: "fe80::1", LocalPort: 9100, Interface: "eth0", State: "LISTEN", PID: 77, Process: "node_exporter"},
+	}
+	if len(report.Sockets) != len(want) {
+		t.Fatalf("got %d sockets, want %d: %+v", len(report.Sockets), len(want), report.Sockets)
+	}
+	for i := range want {
+		if report.Sockets[i] != want[i] {
+			t.Errorf("socket %d = %+v, want %+v", i, report.Sockets[i], want[i])
+		}
+	}
+
+	// The same host listed as root names every process
+	root := parseRemoteSockets("#uid 0\n#ss\ntcp LISTEN 0 128 0.0.0.0:22 0.0.0.0:* users:((\"sshd\",pid=812,fd=3))\n", true)
+	if root.ProcessesHidden || len(root.Sockets) != 1 || root.Sockets[0].Process != "sshd" {
+		t.Errorf("root listing = %+v", root)
+	}
+}
+
+func TestParseRemoteSocketsNetstat(t *testing.T) {
+	// net-tools 2.10 on CentOS 7, with its stderr warning mixed in
+	output := `#uid 1000
+#netstat
+(Not all processes could be identified, non-owned process info
+ will not be shown, you would have to be root to see it all.)
+Active Internet connections (only servers)
+Proto Recv-Q Send-Q Local Address           Foreign Address         State       PID/Program name
+tcp        0      0 0.0.0.0:22              0.0.0.0:*               LISTEN      -
+tcp6       0      0 :::3000                 :::*                    LISTEN      2211/php-fpm: pool www
+udp        0      0 0.0.0.0:68              0.0.0.0:*                           -
+udp6       0      0 ::1:323                 :::*                                2301/chronyd
+tcp        0      0 10.0.0.5:22             10.0.0.9:51514          ESTABLISHED -
+`
+	report := parseRemoteSockets(output, true)
+	want := []RemoteSocket{
+		{Proto: "tcp", LocalAddress: "0.0.0.0", LocalPort: 22, State: "LISTEN", ProcessHidden: true},
+		{Proto: "tcp6", LocalAddress: "::", LocalPort: 3000, State: "LISTEN", PID: 2211, Process: "php-fpm: pool www"},
+		{Proto: "udp", LocalAddress: "0.0.0.0", LocalPort: 68, State: "UNCONN", ProcessHidden: true},
+		{Proto: "udp6", LocalAddress: "::1", LocalPort: 323, State: "UNCONN", PID: 2301, Process: "chronyd"},
+	}
+	if len(report.Sockets) != len(want) {
+		t.Fatalf("got %d sockets, want %d: %+v", len(report.Sockets), len(want), report.Sockets)
+	}
+	for i := range want {
+		if report.Sockets[i] != want[i] {
+			t.Errorf("socket %d = %+v, want %+v", i, report.Sockets[i], want[i])
+		}
+	}
+
+	connections := parseRemoteSockets(output, false)
+	if len(connections.Sockets) != 1 || connections.Sockets[0].PeerAddress != "10.0.0.9" || connections.Sockets[0].PeerPort != 51514 {
+		t.Errorf("connections = %+v", connections.Sockets)
+	}
+}
+
+func TestParseRemoteSocketsProc(t *testing.T) {
+	// BusyBox host without ss or netstat
+	report := parseRemoteSockets(`#uid 0
+#proc tcp
+  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:0277 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 20981 1 0000000000000000 100 0 0 10 0
+   1: 0500000A:0016 0900000A:C93A 01 00000000:00000000 02:0009E8A1 00000000     0        0 31337 4 0000000000000000 20 4 31 10 -1
+#proc tcp6
+   0: 00000000000000000000000001000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 22222 1 0000000000000000 100 0 0 10 0
+#proc udp
+   0: 00000000:0044 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 15000 2 0000000000000000 0
+`, true)
+
+	want := []RemoteSocket{
+		{Proto: "tcp", LocalAddress: "127.0.0.1", LocalPort: 631, State: "LISTEN"},
+		{Proto: "tcp6", LocalAddress: "::1", LocalPort: 8080, State: "LISTEN"},
+		{Proto: "udp", LocalAddress: "0.0.0.0", LocalPort: 68, State: "UNCONN"},
+	}
+	if report.Source != SocketSourceProc || report.ProcessesHidden || len(report.Sockets) != len(want) {
+		t.Fatalf("report = %+v", report)
+	}
+	for i := range want {
+		if report.Sockets[i] != want[i] {
+			t.Errorf("socket %d = %+v, want %+v", i, report.Sockets[i], want[i])
+		}
+	}
+}
+
+func TestFilterRemoteSockets(t *testing.T) {
+	sockets := []RemoteSocket{
+		{Proto: "tcp", LocalAddress: "10.0.0.5", LocalPort: 22, PeerAddress: "10.0.0.9", PeerPort: 51514, State: "ESTABLISHED", Process: "sshd"},
+		{Proto: "tcp", LocalAddress: "10.0.0.5", LocalPort: 40112, PeerAddress: "140.82.112.3", PeerPort: 443, State: "ESTABLISHED", Process: "Git"},
+	}
+	if got := filterRemoteSockets(sockets, ":443"); len(got) != 1 || got[0].Process != "Git" {
+		t.Errorf("filter :443 = %+v", got)
+	}
+	if got := filterRemoteSockets(sockets, "git"); len(got) != 1 {
+		t.Errorf("filter is case sensitive: %+v", got)
+	}
+	if got := filterRemoteSockets(sockets, " "); len(got) != 2 {
+		t.Errorf("empty filter dropped sockets: %+v", got)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Markers the xattr commands print around their output
+const (
+	xattrMarkerGetfattr    = "#getfattr"
+	xattrMarkerSELinux     = "#selinux"
+	xattrMarkerMissing     = "#missing"
+	xattrMarkerUnsupported = "#unsupported"
+	xattrMarkerFailed      = "#failed"
+)
+
+// RemoteFileXattrs is the extended attributes of a remote file. SFTP has no call for them, so
+// they are read with getfattr on the monitoring session; Supported is false on hosts without it.
+type RemoteFileXattrs struct {
+	Path           string            `json:"path"`
+	Supported      bool              `json:"supported"`
+	Attributes     map[string]string `json:"attributes"`               // Binary values are given as 0x-prefixed hex
+	SELinuxContext string            `json:"selinuxContext,omitempty"` // From ls -Z, which works without getfattr
+}
+
+// xattrNamePattern matches a namespaced attribute name such as user.comment or security.selinux
+var xattrNamePattern = regexp.MustCompile(`^(user|trusted|security|system)\.[^\s=]+$`)
+
+// selinuxContextPattern matches a user:role:type[:level] context in ls -Z output
+var selinuxContextPattern = regexp.MustCompile(`^[^:\s]+:[^:\s]+:[^:\s]+(:\S+)?$`)
+
+// getXattrsCommand dumps every attribute of a path hex-encoded, so binary values survive, and
+// its SELinux context
+func getXattrsCommand(remotePath string) remoteCommand {
+	return buildRemoteCommand("[ -e %[1]s ] || { echo '"+xattrMarkerMissing+"'; exit 0; }; "+
+		"if command -v getfattr >/dev/null 2>&1; then echo '"+xattrMarkerGetfattr+"'; getfattr -d -m - -e hex --absolute-names -- %[1]s 2>/dev/null; fi; "+
+		"echo '"+xattrMarkerSELinux+"'; ls -dZ -- %[1]s 2>/dev/null; true", remotePath)
+}
+
+// setXattrCommand sets an attribute. The value goes hex-encoded, as setfattr would otherwise
+// decode a value starting with 0x or 0s itself; an empty value is set by leaving out -v.
+func setXattrCommand(remotePath, name, value string) remoteCommand {
+	const check = "command -v setfattr >/dev/null 2>&1 || { echo '" + xattrMarkerUnsupported + "'; exit 0; }; "
+	const failed = " 2>&1 || echo '" + xattrMarkerFailed + "'"
+	if value == "" {
+		return buildRemoteCommand(check+"setfattr -n %s -- %s"+failed, name, remotePath)
+	}
+	return buildRemoteCommand(check+"setfattr -n %s -v %s -- %s"+failed, name, "0x"+hex.EncodeToString([]byte(value)), remotePath)
+}
+
+// decodeXattrValue turns a getfattr hex value into text, or keeps the hex when it isn't printable.
+// C strings such as SELinux contexts lose their terminating NUL.
+func decodeXattrValue(encoded string) string {
+	raw, err := hex.DecodeString(strings.TrimPrefix(encoded, "0x"))
+	if err != nil {
+		return encoded
+	}
+	text := strings.TrimRight(string(raw), "\x00")
+	if !utf8.ValidString(text) {
+		return encoded
+	}
+	for _, r := range text {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return encoded
+		}
+	}
+	return text
+}
+
+// parseSELinuxContext finds the context in ls -dZ output, in either the newer "context path"
+// or the older "mode owner group context path" layout. Hosts without SELinux print "?".
+func parseSELinuxContext(line string) string {
+	for _, field := range strings.Fields(line) {
+		if selinuxContextPattern.MatchString(field) {
+			return field
+		}
+	}
+	return ""
+}
+
+// parseXattrsOutput parses the output of getXattrsCommand
+func parseXattrsOutput(remotePath, output string) (RemoteFileXattrs, error) {
+	result := RemoteFileXattrs{Path: remotePath, Attributes: map[string]string{}}
+	section := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch line {
+		case xattrMarkerMissing:
+			return RemoteFileXattrs{}, newNotFoundError(ErrCategorySFTP, "GetRemoteFileXattrs", "remote path %s not found", remotePath)
+		case xattrMarkerGetfattr:
+			result.Supported = true
+			section = line
+			continue
+		case xattrMarkerSELinux:
+			section = line
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "# file:") {
+			continue
+		}
+
+		switch section {
+		case xattrMarkerGetfattr:
+			// name=0x... or just name for an empty value
+			name, value, _ := strings.Cut(line, "=")
+			result.Attributes[name] = decodeXattrValue(value)
+		case xattrMarkerSELinux:
+			if result.SELinuxContext == "" {
+				result.SELinuxContext = parseSELinuxContext(line)
+			}
+		}
+	}
+	return result, nil
+}
+
+// GetRemoteFileXattrs returns the extended attributes and SELinux context of a remote file.
+// On a host without getfattr, Supported is false and only the SELinux context is filled in.
+func (a *App) GetRemoteFileXattrs(sessionID, remotePath string) (RemoteFileXattrs, error) {
+	clean, err := normalizeRemotePath(remotePath)
+	if err != nil {
+		return RemoteFileXattrs{}, err
+	}
+	sshSession, err := a.monitoringSession(sessionID, "GetRemoteFileXattrs")
+	if err != nil {
+		return RemoteFileXattrs{}, err
+	}
+
+	output, err := a.executeMonitoringCommand(sshSession, getXattrsCommand(clean))
+	if err != nil {
+		return RemoteFileXattrs{}, fmt.Errorf("failed to read extended attributes of %s: %w", clean, err)
+	}
+	return parseXattrsOutput(clean, output)
+}
+
+// SetRemoteFileXattr sets an extended attribute of a remote file, e.g. user.comment, with
+// setfattr on the monitoring session. Fails with a not-supported error when the host has no
+// setfattr or the filesystem doesn't take the attribute.
+func (a *App) SetRemoteFileXattr(sessionID, remotePath, name, value string) error {
+	clean, err := normalizeRemotePath(remotePath)
+	if err != nil {
+		return err
+	}
+	if !xattrNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid attribute name %q: expected a namespace such as user. followed by a name", name)
+	}
+	sshSession, err := a.monitoringSession(sessionID, "SetRemoteFileXattr")
+	if err != nil {
+		return err
+	}
+
+	output, err := a.executeMonitoringCommand(sshSession, setXattrCommand(clean, name, value))
+	if err != nil {
+		return fmt.Errorf("failed to set %s on %s: %w", name, clean, err)
+	}
+	return parseSetXattrOutput(clean, name, output)
+}
+
+// parseSetXattrOutput turns the output of setXattrCommand into an error, if it failed
+func parseSetXattrOutput(remotePath, name, output string) error {
+	output = strings.TrimSpace(output)
+	switch {
+	case output == xattrMarkerUnsupported:
+		return fmt.Errorf("extended attributes not supported: setfattr is not installed on the remote host: %w", errors.ErrUnsupported)
+	case strings.HasSuffix(output, xattrMarkerFailed):
+		message := strings.TrimSpace(strings.TrimSuffix(output, xattrMarkerFailed))
+		lower := strings.ToLower(message)
+		if strings.Contains(lower, "not supported") {
+			return fmt.Errorf("failed to set %s on %s: %s: %w", name, remotePath, message, errors.ErrUnsupported)
+		}
+		if strings.Contains(lower, "permission denied") || strings.Contains(lower, "operation not permitted") {
+			return &ThermicError{
+				Code:     ErrCodePermission,
+				Category: ErrCategorySFTP,
+				Op:       "SetRemoteFileXattr",
+				Message:  fmt.Sprintf("failed to set %s on %s: %s", name, remotePath, message),
+			}
+		}
+		return fmt.Errorf("failed to set %s on %s: %s", name, remotePath, message)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestParseXattrsOutput(t *testing.T) {
+	output := "#getfattr\n" +
+		"# file: /srv/app/config.yml\n" +
+		"security.selinux=0x73797374656d5f753a6f626a6563745f723a6574635f743a733000\n" +
+		"user.comment=0x72657669657765642062792061646d696e\n" +
+		"user.checksum=0x00ff10\n" +
+		"user.flag\n" +
+		"\n" +
+		"#selinux\n" +
+		"system_u:object_r:etc_t:s0 /srv/app/config.yml\n"
+
+	got, err := parseXattrsOutput("/srv/app/config.yml", output)
+	if err != nil {
+		t.Fatalf("parseXattrsOutput() returned error: %v", err)
+	}
+	want := map[string]string{
+		"security.selinux": "system_u:object_r:etc_t:s0",
+		"user.comment":     "reviewed by admin",
+		"user.checksum":    "0x00ff10", // Binary stays hex
+		"user.flag":        "",
+	}
+	if !got.Supported || len(got.Attributes) != len(want) {
+		t.Fatalf("parseXattrsOutput() = %+v, want %v", got, want)
+	}
+	for name, value := range want {
+		if got.Attributes[name] != value {
+			t.Errorf("Attributes[%s] = %q, want %q", name, got.Attributes[name], value)
+		}
+	}
+	if got.SELinuxContext != "system_u:object_r:etc_t:s0" {
+		t.Errorf("SELinuxContext = %q", got.SELinuxContext)
+	}
+}
+
+func TestParseXattrsOutputWithoutGetfattr(t *testing.T) {
+	// Older coreutils print the mode and owners before the context
+	got, err := parseXattrsOutput("/etc", "#selinux\ndrwxr-xr-x. root root system_u:object_r:etc_t:s0 /etc\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Supported || got.SELinuxContext != "system_u:object_r:etc_t:s0" {
+		t.Errorf("parseXattrsOutput() = %+v, want unsupported with the SELinux context", got)
+	}
+
+	// No SELinux either
+	if got, _ := parseXattrsOutput("/etc", "#selinux\n? /etc\n"); got.SELinuxContext != "" {
+		t.Errorf("SELinuxContext = %q on a host without SELinux", got.SELinuxContext)
+	}
+
+	if _, err := parseXattrsOutput("/gone", "#missing\n"); toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("parseXattrsOutput() of a missing path = %v, want not found", err)
+	}
+}
+
+func TestParseSetXattrOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		code   int // 0 for success
+	}{
+		{"set", "", 0},
+		{"no setfattr", "#unsupported\n", ErrCodeUnsupported},
+		{"filesystem without user xattrs", "setfattr: /mnt/x: Operation not supported\n#failed\n", ErrCodeUnsupported},
+		{"not the owner", "setfattr: /etc/passwd: Permission denied\n#failed\n", ErrCodePermission},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseSetXattrOutput("/x", "user.comment", tt.output)
+			if tt.code == 0 {
+				if err != nil {
+					t.Errorf("parseSetXattrOutput() returned error: %v", err)
+				}
+				return
+			}
+			if got := toThermicError(err); got.Code != tt.code {
+				t.Errorf("parseSetXattrOutput() = %v (code %d), want code %d", err, got.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestXattrCommandsRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	run := func(command remoteCommand) string {
+		output, err := exec.Command(bash, "-c", string(command)).CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s failed: %v: %s", command, err, output)
+		}
+		return string(output)
+	}
+
+	file := filepath.Join(t.TempDir(), "it's a file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := parseXattrsOutput(file, run(getXattrsCommand(file)))
+	if err != nil {
+		t.Fatalf("parseXattrsOutput() returned error: %v", err)
+	}
+	_, lookErr := exec.LookPath("getfattr")
+	if got.Supported != (lookErr == nil) {
+		t.Errorf("Supported = %v with getfattr lookup error %v", got.Supported, lookErr)
+	}
+
+	err = parseSetXattrOutput(file, "user.comment", run(setXattrCommand(file, "user.comment", "0x not hex")))
+	if _, lookErr := exec.LookPath("setfattr"); lookErr != nil {
+		if !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("setting an attribute without setfattr = %v, want not supported", err)
+		}
+		return
+	}
+	if err != nil && !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("setting an attribute returned error: %v", err)
+	}
+	if err == nil {
+		got, _ := parseXattrsOutput(file, run(getXattrsCommand(file)))
+		if got.Attributes["user.comment"] != "0x not hex" {
+			t.Errorf("user.comment = %q after setting it, want the literal value", got.Attributes["user.comment"])
+		}
+	}
+}
@@ -0,0 +1,456 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Replay constants
+const (
+	MaxReplaySessions       = 4                 // Open replays, counted separately from terminal tabs
+	MaxRecordingFileSize    = 100 * 1024 * 1024 // Largest recording LoadSessionRecording reads
+	replaySessionPrefix     = "replay_"
+	replayPlainLineInterval = 0.02 // Seconds between lines of a plain recording, which has no timing
+	minReplaySpeed          = 0.1
+	maxReplaySpeed          = 16
+)
+
+// Replay control actions
+const (
+	ReplayActionPlay  = "play"
+	ReplayActionPause = "pause"
+	ReplayActionSeek  = "seek"  // position is the time in seconds
+	ReplayActionSpeed = "speed" // position is the playback speed factor
+	ReplayActionClose = "close"
+)
+
+// replayScreenSequences clear the screen (0), or switch to (1) or back from (2) the alternate screen
+var replayScreenSequences = map[string]int{
+	"\x1b[2J": 0, "\x1bc": 0,
+	"\x1b[?1049h": 1, "\x1b[?1047h": 1, "\x1b[?47h": 1,
+	"\x1b[?1049l": 2, "\x1b[?1047l": 2, "\x1b[?47l": 2,
+}
+
+// RecordingMarker is an annotation at a point in a recording
+type RecordingMarker struct {
+	Time  float64 `json:"time"`
+	Label string  `json:"label"`
+}
+
+// RecordingInfo describes a loaded recording and the replay opened for it
+type RecordingInfo struct {
+	Handle   string            `json:"handle"` // Replay session ID; output is emitted on terminal-output for it
+	TabID    string            `json:"tabId"`
+	Format   string            `json:"format"` // "asciicast-v2" or "plain"
+	Title    string            `json:"title"`
+	Width    int               `json:"width"`
+	Height   int               `json:"height"`
+	Duration float64           `json:"duration"` // Seconds
+	Markers  []RecordingMarker `json:"markers,omitempty"`
+}
+
+// ReplayState is the playback position of a replay
+type ReplayState struct {
+	Handle   string  `json:"handle"`
+	Playing  bool    `json:"playing"`
+	Position float64 `json:"position"` // Seconds
+	Speed    float64 `json:"speed"`
+	Duration float64 `json:"duration"`
+}
+
+// replayEvent is a chunk of recorded output
+type replayEvent struct {
+	time float64
+	data string
+}
+
+// replaySession plays a recording into a terminal-output stream
+type replaySession struct {
+	sessionID string
+	tabID     string
+	events    []replayEvent
+	keyframes []int // Indices of events that start from a cleared main screen
+	duration  float64
+
+	mu       sync.Mutex
+	next     int       // Index of the next event to emit
+	offset   float64   // Recording time when playback last (re)started
+	resumed  time.Time // Wall clock when playback last (re)started
+	playing  bool
+	speed    float64
+	wake     chan struct{}
+	done     chan struct{}
+	closeOne sync.Once
+}
+
+var replaySessions = make(map[string]*replaySession)
+var replaySessionsMu sync.Mutex
+
+// isReplaySession reports whether a session ID belongs to a replay
+func isReplaySession(sessionID string) bool {
+	return strings.HasPrefix(sessionID, replaySessionPrefix)
+}
+
+// parseRecording parses an asciicast v2 file, or treats anything else as plain terminal output
+func parseRecording(data []byte) (*RecordingInfo, []replayEvent, error) {
+	firstLine, rest, _ := bytes.Cut(data, []byte("\n"))
+	var header struct {
+		Version int    `json:"version"`
+		Width   int    `json:"width"`
+		Height  int    `json:"height"`
+		Title   string `json:"title"`
+	}
+	if json.Unmarshal(firstLine, &header) != nil || header.Version == 0 {
+		return parsePlainRecording(data)
+	}
+	if header.Version != 2 {
+		return nil, nil, fmt.Errorf("unsupported asciicast version %d", header.Version)
+	}
+
+	info := &RecordingInfo{Format: "asciicast-v2", Title: header.Title, Width: header.Width, Height: header.Height}
+	var events []replayEvent
+	scanner := bufio.NewScanner(bytes.NewReader(rest))
+	scanner.Buffer(make([]byte, 64*1024), MaxRecordingFileSize)
+	line := 1
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var event [3]interface{}
+		if err := json.Unmarshal(raw, &event); err != nil {
+			return nil, nil, fmt.Errorf("invalid event on line %d: %w", line, err)
+		}
+		at, atOK := event[0].(float64)
+		kind, kindOK := event[1].(string)
+		payload, payloadOK := event[2].(string)
+		if !atOK || !kindOK || !payloadOK || at < 0 {
+			return nil, nil, fmt.Errorf("invalid event on line %d", line)
+		}
+		switch kind {
+		case "o":
+			events = append(events, replayEvent{time: at, data: payload})
+		case "m":
+			info.Markers = append(info.Markers, RecordingMarker{Time: at, Label: payload})
+		}
+		// Input ("i") and resize ("r") events don't change what is shown
+		info.Duration = max(info.Duration, at)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+
+	// Events are written in order, but don't trust hand-edited files
+	sort.SliceStable(events, func(i, j int) bool { return events[i].time < events[j].time })
+	if info.Width <= 0 || info.Height <= 0 {
+		info.Width, info.Height = 80, 24
+	}
+	return info, events, nil
+}
+
+// parsePlainRecording turns raw terminal output (e.g. from script(1)) into one event per line
+func parsePlainRecording(data []byte) (*RecordingInfo, []replayEvent, error) {
+	info := &RecordingInfo{Format: "plain", Width: 80, Height: 24}
+	var events []replayEvent
+	for i, chunk := range strings.SplitAfter(string(data), "\n") {
+		if chunk == "" {
+			continue
+		}
+		at := float64(i) * replayPlainLineInterval
+		events = append(events, replayEvent{time: at, data: chunk})
+		info.Duration = at
+	}
+	return info, events, nil
+}
+
+// findReplayKeyframes returns the events from which replaying reproduces the screen: those that
+// clear the main screen. A clear on the alternate screen (an editor, a pager) isn't one, since
+// leaving it restores main screen content from before the clear.
+func findReplayKeyframes(events []replayEvent) []int {
+	keyframes := []int{0}
+	altScreen := false
+	for i, event := range events {
+		clearsMain := false
+		for data := event.data; ; {
+			escape := strings.IndexByte(data, '\x1b')
+			if escape == -1 {
+				break
+			}
+			data = data[escape:]
+			matched := 1
+			for sequence, action := range replayScreenSequences {
+				if !strings.HasPrefix(data, sequence) {
+					continue
+				}
+				switch {
+				case sequence == "\x1bc": // A full reset also leaves the alternate screen
+					altScreen = false
+					clearsMain = true
+				case action == 0:
+					clearsMain = clearsMain || !altScreen
+				default:
+					altScreen = action == 1
+				}
+				matched = len(sequence)
+				break
+			}
+			data = data[matched:]
+		}
+		if clearsMain && i > 0 {
+			keyframes = append(keyframes, i)
+		}
+	}
+	return keyframes
+}
+
+// LoadSessionRecording opens a recording (asciicast v2, or plain terminal output) in a new
+// replay tab, paused at the start. Drive it with ReplayControl.
+func (a *App) LoadSessionRecording(filePath string) (*RecordingInfo, error) {
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	if stat.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", filePath)
+	}
+	if stat.Size() > MaxRecordingFileSize {
+		return nil, fmt.Errorf("recording is too large (%d bytes, limit %d)", stat.Size(), MaxRecordingFileSize)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	info, events, err := parseRecording(data)
+	if err != nil {
+		return nil, err
+	}
+	if info.Title == "" {
+		info.Title = filepath.Base(filePath)
+	}
+
+	replay := &replaySession{
+		sessionID: replaySessionPrefix + generateID(),
+		tabID:     "tab_" + generateID(),
+		events:    events,
+		keyframes: findReplayKeyframes(events),
+		duration:  info.Duration,
+		speed:     1,
+		wake:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+
+	replaySessionsMu.Lock()
+	if len(replaySessions) >= MaxReplaySessions {
+		replaySessionsMu.Unlock()
+		return nil, fmt.Errorf("replay limit reached (%d), close a replay first", MaxReplaySessions)
+	}
+	replaySessions[replay.sessionID] = replay
+	replaySessionsMu.Unlock()
+
+	a.terminal.mutex.Lock()
+	a.terminal.tabs[replay.tabID] = &Tab{
+		ID:             replay.tabID,
+		Title:          "▶ " + info.Title,
+		SessionID:      replay.sessionID,
+		Shell:          filePath,
+		ConnectionType: ConnectionTypeReplay,
+		Status:         StatusConnected.String(),
+		Created:        time.Now(),
+	}
+	a.terminal.mutex.Unlock()
+
+	go a.runReplay(replay)
+
+	info.Handle = replay.sessionID
+	info.TabID = replay.tabID
+	fmt.Printf("Loaded %s recording %s: %d events, %.1fs\n", info.Format, filePath, len(events), info.Duration)
+	return info, nil
+}
+
+// position returns the current playback time; the caller holds r.mu
+func (r *replaySession) position() float64 {
+	if !r.playing {
+		return r.offset
+	}
+	return min(r.offset+time.Since(r.resumed).Seconds()*r.speed, r.duration)
+}
+
+// state returns the playback state; the caller holds r.mu
+func (r *replaySession) state() ReplayState {
+	return ReplayState{Handle: r.sessionID, Playing: r.playing, Position: r.position(), Speed: r.speed, Duration: r.duration}
+}
+
+// signal wakes the player goroutine after a state change
+func (r *replaySession) signal() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// runReplay emits events as their time comes, until the replay is closed
+func (a *App) runReplay(r *replaySession) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		r.mu.Lock()
+		var output strings.Builder
+		var wait time.Duration
+		ended := false
+		if r.playing {
+			now := r.position()
+			for r.next < len(r.events) && r.events[r.next].time <= now {
+				output.WriteString(r.events[r.next].data)
+				r.next++
+			}
+			if r.next < len(r.events) {
+				wait = time.Duration((r.events[r.next].time - now) / r.speed * float64(time.Second))
+			} else {
+				r.offset, r.playing, ended = r.duration, false, true
+			}
+		}
+		playing, state := r.playing, r.state()
+		r.mu.Unlock()
+
+		if output.Len() > 0 {
+			a.emitTerminalOutput(r.sessionID, output.String())
+		}
+		if ended {
+			a.emitReplayState(state)
+		}
+
+		if !playing {
+			select {
+			case <-r.wake:
+			case <-r.done:
+				return
+			}
+			continue
+		}
+
+		timer.Reset(wait)
+		select {
+		case <-timer.C:
+		case <-r.wake:
+			if !timer.Stop() {
+				<-timer.C
+			}
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// seek moves playback to a time and returns the output that rebuilds the screen there:
+// a terminal reset followed by everything since the nearest keyframe. The caller holds r.mu.
+func (r *replaySession) seek(target float64) string {
+	target = min(max(target, 0), r.duration)
+	next := sort.Search(len(r.events), func(i int) bool { return r.events[i].time > target })
+
+	keyframe := 0
+	if k := sort.Search(len(r.keyframes), func(i int) bool { return r.keyframes[i] >= next }); k > 0 {
+		keyframe = r.keyframes[k-1]
+	}
+
+	var output strings.Builder
+	output.WriteString("\x1bc")
+	for _, event := range r.events[keyframe:next] {
+		output.WriteString(event.data)
+	}
+
+	r.next = next
+	r.offset = target
+	r.resumed = time.Now()
+	return output.String()
+}
+
+// ReplayControl plays, pauses, seeks (position in seconds), changes the speed (position is the
+// factor) or closes a replay, and returns the resulting playback state
+func (a *App) ReplayControl(handle, action string, position float64) (ReplayState, error) {
+	replaySessionsMu.Lock()
+	replay, exists := replaySessions[handle]
+	replaySessionsMu.Unlock()
+	if !exists {
+		return ReplayState{}, newNotFoundError(ErrCategoryTerminal, "ReplayControl", "replay %s not found", handle)
+	}
+
+	if action == ReplayActionClose {
+		state := ReplayState{Handle: handle}
+		if err := a.CloseTab(replay.tabID); err != nil {
+			// The tab is already gone; release the replay directly
+			a.releaseReplaySession(handle)
+		}
+		return state, nil
+	}
+
+	replay.mu.Lock()
+	redraw := ""
+	switch action {
+	case ReplayActionPlay:
+		if !replay.playing {
+			if replay.next >= len(replay.events) {
+				redraw = replay.seek(0)
+			}
+			replay.resumed = time.Now()
+			replay.playing = true
+		}
+	case ReplayActionPause:
+		replay.offset = replay.position()
+		replay.playing = false
+	case ReplayActionSeek:
+		redraw = replay.seek(position)
+	case ReplayActionSpeed:
+		if position < minReplaySpeed || position > maxReplaySpeed {
+			replay.mu.Unlock()
+			return ReplayState{}, fmt.Errorf("replay speed must be between %g and %g, got: %g", float64(minReplaySpeed), float64(maxReplaySpeed), position)
+		}
+		replay.offset = replay.position()
+		replay.resumed = time.Now()
+		replay.speed = position
+	default:
+		replay.mu.Unlock()
+		return ReplayState{}, fmt.Errorf("invalid replay action: '%s'", action)
+	}
+	state := replay.state()
+	replay.mu.Unlock()
+
+	if redraw != "" {
+		a.emitTerminalOutput(handle, redraw)
+	}
+	replay.signal()
+	a.emitReplayState(state)
+	return state, nil
+}
+
+// emitReplayState tells the viewer where playback is, e.g. when it reaches the end
+func (a *App) emitReplayState(state ReplayState) {
+	if a.ctx == nil {
+		return
+	}
+	wailsRuntime.EventsEmit(a.ctx, "replay-state", state)
+}
+
+// releaseReplaySession stops a replay's player; its tab closing releases it through the session registry
+func (a *App) releaseReplaySession(sessionID string) {
+	replaySessionsMu.Lock()
+	replay, exists := replaySessions[sessionID]
+	delete(replaySessions, sessionID)
+	replaySessionsMu.Unlock()
+
+	if exists {
+		replay.closeOne.Do(func() { close(replay.done) })
+	}
+}
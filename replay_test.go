@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testAsciicast = `{"version": 2, "width": 100, "height": 30, "title": "deploy"}
+[0.5, "o", "$ ls\r\n"]
+[1.0, "i", "q"]
+[1.2, "o", "\u001b[2Jfirst screen\r\n"]
+[2.0, "m", "build started"]
+[2.5, "o", "\u001b[?1049h\u001b[2Jless output"]
+[3.0, "o", "\u001b[?1049lback\r\n"]
+[4.0, "r", "120x40"]
+`
+
+func TestParseRecordingAsciicast(t *testing.T) {
+	info, events, err := parseRecording([]byte(testAsciicast))
+	if err != nil {
+		t.Fatalf("parseRecording returned error: %v", err)
+	}
+	if info.Format != "asciicast-v2" || info.Width != 100 || info.Height != 30 || info.Title != "deploy" {
+		t.Errorf("unexpected header: %+v", info)
+	}
+	if info.Duration != 4.0 {
+		t.Errorf("duration = %v, want 4", info.Duration)
+	}
+	if len(events) != 4 {
+		t.Fatalf("got %d output events, want 4", len(events))
+	}
+	if len(info.Markers) != 1 || info.Markers[0] != (RecordingMarker{Time: 2.0, Label: "build started"}) {
+		t.Errorf("markers = %+v", info.Markers)
+	}
+
+	// The clear inside the alternate screen is not a keyframe
+	if keyframes := findReplayKeyframes(events); !reflect.DeepEqual(keyframes, []int{0, 1}) {
+		t.Errorf("keyframes = %v, want [0 1]", keyframes)
+	}
+
+	if _, _, err := parseRecording([]byte(`{"version": 1, "width": 80, "height": 24}`)); err == nil {
+		t.Error("expected an error for asciicast v1")
+	}
+}
+
+func TestParseRecordingPlain(t *testing.T) {
+	info, events, err := parseRecording([]byte("line one\nline two\npartial"))
+	if err != nil {
+		t.Fatalf("parseRecording returned error: %v", err)
+	}
+	if info.Format != "plain" || info.Width != 80 || info.Height != 24 {
+		t.Errorf("unexpected info: %+v", info)
+	}
+	if len(events) != 3 || events[1].data != "line two\n" || events[2].time != 2*replayPlainLineInterval {
+		t.Errorf("unexpected events: %+v", events)
+	}
+}
+
+func TestReplaySeekRebuildsFromKeyframe(t *testing.T) {
+	_, events, err := parseRecording([]byte(testAsciicast))
+	if err != nil {
+		t.Fatalf("parseRecording returned error: %v", err)
+	}
+	replay := &replaySession{events: events, keyframes: findReplayKeyframes(events), duration: 4, speed: 1}
+
+	if got, want := replay.seek(2.6), "\x1bc\x1b[2Jfirst screen\r\n\x1b[?1049h\x1b[2Jless output"; got != want {
+		t.Errorf("seek(2.6) = %q, want %q", got, want)
+	}
+	if replay.next != 3 || replay.offset != 2.6 {
+		t.Errorf("after seek next = %d, offset = %v", replay.next, replay.offset)
+	}
+	if got := replay.seek(0.7); got != "\x1bc$ ls\r\n" {
+		t.Errorf("seek(0.7) = %q", got)
+	}
+}
+
+func TestLoadSessionRecordingOpensReplayTab(t *testing.T) {
+	app := NewApp()
+	path := filepath.Join(t.TempDir(), "session.cast")
+	if err := os.WriteFile(path, []byte(testAsciicast), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := app.LoadSessionRecording(path)
+	if err != nil {
+		t.Fatalf("LoadSessionRecording returned error: %v", err)
+	}
+	if !isReplaySession(info.Handle) {
+		t.Errorf("handle %q is not a replay session", info.Handle)
+	}
+	if err := app.WriteToShell(info.Handle, "ignored"); err != nil {
+		t.Errorf("WriteToShell on a replay returned error: %v", err)
+	}
+
+	state, err := app.ReplayControl(info.Handle, ReplayActionSpeed, 2)
+	if err != nil || state.Speed != 2 {
+		t.Errorf("ReplayControl(speed) = %+v, %v", state, err)
+	}
+	if _, err := app.ReplayControl(info.Handle, ReplayActionSpeed, 100); err == nil {
+		t.Error("expected an error for an out of range speed")
+	}
+
+	if _, err := app.ReplayControl(info.Handle, ReplayActionClose, 0); err != nil {
+		t.Fatalf("ReplayControl(close) returned error: %v", err)
+	}
+	replaySessionsMu.Lock()
+	_, exists := replaySessions[info.Handle]
+	replaySessionsMu.Unlock()
+	if exists {
+		t.Error("replay still registered after close")
+	}
+	if _, err := app.ReplayControl(info.Handle, ReplayActionPlay, 0); err == nil {
+		t.Error("expected an error for a closed replay")
+	}
+}
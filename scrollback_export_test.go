@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecordScrollbackOutput(t *testing.T) {
+	const sessionID = "session_scrollback"
+	defer releaseScrollback(sessionID)
+
+	recordScrollbackOutput(sessionID, "\x1b[32mgreen\x1b[0m line\r\nprogress 10%\rprogress 100%\npart")
+	recordScrollbackOutput(sessionID, "ial\n")
+
+	lines, next := scrollbackLinesSince(sessionID, 0, false)
+	want := []string{"green line", "progress 100%", "partial"}
+	if len(lines) != len(want) || next != 3 {
+		t.Fatalf("got %d lines, next %d, want %v", len(lines), next, want)
+	}
+	for i, line := range lines {
+		if line.text != want[i] {
+			t.Errorf("line %d = %q, want %q", i, line.text, want[i])
+		}
+	}
+
+	// The ring keeps only the newest lines
+	for i := 0; i < ScrollbackBufferLines; i++ {
+		recordScrollbackOutput(sessionID, "x\n")
+	}
+	lines, _ = scrollbackLinesSince(sessionID, 0, false)
+	if len(lines) != ScrollbackBufferLines || lines[0].seq != 3 {
+		t.Errorf("ring holds %d lines from seq %d, want %d from 3", len(lines), lines[0].seq, ScrollbackBufferLines)
+	}
+
+	// Long lines are bounded by bytes rather than by count
+	long := strings.Repeat("y", ScrollbackMaxLineBytes-1) + "\n"
+	for i := 0; i < 2*ScrollbackBufferBytes/len(long); i++ {
+		recordScrollbackOutput(sessionID, long)
+	}
+	lines, next = scrollbackLinesSince(sessionID, 0, false)
+	total := 0
+	for _, line := range lines {
+		total += len(line.text)
+	}
+	if total > ScrollbackBufferBytes || len(lines) == 0 || lines[len(lines)-1].seq != next-1 {
+		t.Errorf("buffer holds %d lines, %d bytes, want the newest within %d bytes", len(lines), total, ScrollbackBufferBytes)
+	}
+}
+
+func TestExportScrollbackToElasticsearch(t *testing.T) {
+	var path, authorization string
+	var docs []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, authorization = r.URL.Path, r.Header.Get("Authorization")
+		scanner := bufio.NewScanner(r.Body)
+		for i := 0; scanner.Scan(); i++ {
+			if i%2 == 1 {
+				var doc map[string]interface{}
+				json.Unmarshal(scanner.Bytes(), &doc)
+				docs = append(docs, doc)
+			}
+		}
+		w.Write([]byte(`{"errors":false,"items":[]}`))
+	}))
+	defer server.Close()
+
+	app := NewApp()
+	const sessionID = "session_export"
+	app.terminal.tabs["tab_export"] = &Tab{ID: "tab_export", SessionID: sessionID, SSHConfig: &SSHConfig{Host: "web1"}}
+	defer releaseScrollback(sessionID)
+	recordScrollbackOutput(sessionID, "plain text\n{\"level\":\"error\",\"msg\":\"disk full\"}\n")
+
+	if err := app.ExportScrollbackToElasticsearch(sessionID, server.URL, "thermic-logs", "abc123"); err != nil {
+		t.Fatalf("ExportScrollbackToElasticsearch() returned error: %v", err)
+	}
+	if path != "/thermic-logs/_bulk" || authorization != "ApiKey abc123" {
+		t.Errorf("request went to %s with Authorization %q", path, authorization)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("indexed %d documents, want 2", len(docs))
+	}
+	if docs[0]["message"] != "plain text" || docs[0]["hostname"] != "web1" || docs[0]["session_id"] != sessionID {
+		t.Errorf("plain line indexed as %v", docs[0])
+	}
+	if docs[1]["level"] != "error" || docs[1]["message"] != nil || docs[1]["session_id"] != sessionID {
+		t.Errorf("JSON line indexed as %v", docs[1])
+	}
+
+	if err := app.ExportScrollbackToElasticsearch(sessionID, server.URL, "Bad/Index", ""); err == nil || !strings.Contains(err.Error(), "index name") {
+		t.Errorf("invalid index name accepted: %v", err)
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Session secret constants
+const (
+	KeychainService         = "Thermic" // Keychain service/target prefix secrets are stored under
+	SecretPromptTimeout     = 30 * time.Second
+	SecretPromptTailBytes   = 512 // Output kept per session to spot a prompt that is already showing
+	DefaultSecretFileTTL    = 60  // Seconds a secret file lives on the remote host
+	MaxSecretFileTTL        = 3600
+	secretFilePrefix        = ".thermic-secret-"
+	maxSessionSecretNameLen = 64
+)
+
+var (
+	sessionSecretNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+	secretPromptPattern      = regexp.MustCompile(`(?i)(password|passphrase|token|secret)[^\n]{0,120}:\s*$`)
+	terminalEscapePattern    = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)`)
+)
+
+// secretPromptWatch is the recent output of a session and whoever waits for a prompt in it
+type secretPromptWatch struct {
+	tail   string
+	waiter chan struct{} // Closed when a prompt appears; nil when nobody waits
+}
+
+var secretPromptWatches = make(map[string]*secretPromptWatch)
+var secretPromptWatchesMu sync.Mutex
+
+// hasSecretPrompt reports whether the last line of output asks for a password or token
+func hasSecretPrompt(tail string) bool {
+	clean := terminalEscapePattern.ReplaceAllString(tail, "")
+	// Only the line being shown counts; a prompt followed by a newline was already answered
+	if i := strings.LastIndexAny(clean, "\r\n"); i >= 0 {
+		clean = clean[i+1:]
+	}
+	return secretPromptPattern.MatchString(clean)
+}
+
+// recordSecretPromptOutput keeps the tail of a session's output and wakes a waiting
+// SendSecretToSession once a prompt shows up
+func recordSecretPromptOutput(sessionID, data string) {
+	secretPromptWatchesMu.Lock()
+	defer secretPromptWatchesMu.Unlock()
+
+	watch, exists := secretPromptWatches[sessionID]
+	if !exists {
+		watch = &secretPromptWatch{}
+		secretPromptWatches[sessionID] = watch
+	}
+	watch.tail += data
+	if len(watch.tail) > SecretPromptTailBytes {
+		watch.tail = watch.tail[len(watch.tail)-SecretPromptTailBytes:]
+	}
+	if watch.waiter != nil && hasSecretPrompt(watch.tail) {
+		close(watch.waiter)
+		watch.waiter = nil
+	}
+}
+
+// forgetSecretPromptOutput drops the output tail of a session
+func forgetSecretPromptOutput(sessionID string) {
+	secretPromptWatchesMu.Lock()
+	defer secretPromptWatchesMu.Unlock()
+
+	if watch, exists := secretPromptWatches[sessionID]; exists {
+		if watch.waiter != nil {
+			close(watch.waiter)
+		}
+		delete(secretPromptWatches, sessionID)
+	}
+}
+
+// waitForSecretPrompt returns once the session shows a password/token prompt, which may
+// already be on screen. The tail is cleared so the same prompt isn't answered twice.
+func waitForSecretPrompt(sessionID string, timeout time.Duration) error {
+	secretPromptWatchesMu.Lock()
+	watch, exists := secretPromptWatches[sessionID]
+	if !exists {
+		watch = &secretPromptWatch{}
+		secretPromptWatches[sessionID] = watch
+	}
+	if hasSecretPrompt(watch.tail) {
+		watch.tail = ""
+		secretPromptWatchesMu.Unlock()
+		return nil
+	}
+	if watch.waiter != nil {
+		secretPromptWatchesMu.Unlock()
+		return fmt.Errorf("already waiting for a prompt in session %s", sessionID)
+	}
+	waiter := make(chan struct{})
+	watch.waiter = waiter
+	secretPromptWatchesMu.Unlock()
+
+	select {
+	case <-waiter:
+	case <-time.After(timeout):
+		secretPromptWatchesMu.Lock()
+		if watch.waiter == waiter {
+			watch.waiter = nil
+		}
+		secretPromptWatchesMu.Unlock()
+		return fmt.Errorf("no password or token prompt appeared in session %s within %s", sessionID, timeout)
+	}
+
+	secretPromptWatchesMu.Lock()
+	defer secretPromptWatchesMu.Unlock()
+	if secretPromptWatches[sessionID] != watch {
+		return fmt.Errorf("session %s closed while waiting for a prompt", sessionID)
+	}
+	watch.tail = ""
+	return nil
+}
+
+// validateSessionSecretName checks a name can be used as a keychain account
+func validateSessionSecretName(name string) error {
+	if name == "" || len(name) > maxSessionSecretNameLen || !sessionSecretNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q: use up to %d letters, digits, '.', '_' or '-'", name, maxSessionSecretNameLen)
+	}
+	return nil
+}
+
+// isSessionSecretRegistered reports whether the user registered the secret name
+func (a *App) isSessionSecretRegistered(name string) bool {
+	a.config.mutex.RLock()
+	defer a.config.mutex.RUnlock()
+	for _, registered := range a.config.config.SessionSecrets {
+		if registered == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterSessionSecret allows a keychain secret to be sent to sessions. The secret must
+// already exist in the OS keychain under service "Thermic" and the given account name.
+func (a *App) RegisterSessionSecret(name string) error {
+	if err := validateSessionSecretName(name); err != nil {
+		return err
+	}
+	secret, err := readKeychainSecret(name)
+	if err != nil {
+		return err
+	}
+	wipeSecret(secret)
+
+	if a.isSessionSecretRegistered(name) {
+		return nil
+	}
+	a.config.mutex.Lock()
+	a.config.config.SessionSecrets = append(a.config.config.SessionSecrets, name)
+	a.config.mutex.Unlock()
+	a.markConfigDirty()
+	return nil
+}
+
+// UnregisterSessionSecret stops a secret from being sent to sessions. The keychain item is kept.
+func (a *App) UnregisterSessionSecret(name string) error {
+	a.config.mutex.Lock()
+	names := a.config.config.SessionSecrets
+	kept := make([]string, 0, len(names))
+	for _, registered := range names {
+		if registered != name {
+			kept = append(kept, registered)
+		}
+	}
+	found := len(kept) != len(names)
+	a.config.config.SessionSecrets = kept
+	a.config.mutex.Unlock()
+
+	if !found {
+		return newNotFoundError(ErrCategoryConfig, "UnregisterSessionSecret", "secret %s is not registered", name)
+	}
+	a.markConfigDirty()
+	return nil
+}
+
+// GetSessionSecrets returns the names of the registered secrets, never their values
+func (a *App) GetSessionSecrets() []string {
+	a.config.mutex.RLock()
+	defer a.config.mutex.RUnlock()
+	return append([]string{}, a.config.config.SessionSecrets...)
+}
+
+// loadSessionSecret reads a registered secret from the keychain
+func (a *App) loadSessionSecret(name string) ([]byte, error) {
+	if !a.isSessionSecretRegistered(name) {
+		return nil, fmt.Errorf("secret %s is not registered", name)
+	}
+	return readKeychainSecret(name)
+}
+
+// wipeSecret overwrites a secret held in memory
+func wipeSecret(secret []byte) {
+	for i := range secret {
+		secret[i] = 0
+	}
+}
+
+// SendSecretToSession types a registered secret into a session once it shows a password or
+// token prompt, waiting up to SecretPromptTimeout for one. The value goes straight to the
+// session's input: it is never logged and, as prompts don't echo, never reaches the output.
+func (a *App) SendSecretToSession(sessionID, secretName string) error {
+	if !a.isSessionSecretRegistered(secretName) {
+		return fmt.Errorf("secret %s is not registered", secretName)
+	}
+	if err := waitForSecretPrompt(sessionID, SecretPromptTimeout); err != nil {
+		return err
+	}
+
+	secret, err := a.loadSessionSecret(secretName)
+	if err != nil {
+		return err
+	}
+	defer wipeSecret(secret)
+
+	input := make([]byte, 0, len(secret)+1)
+	input = append(append(input, secret...), '\r')
+	defer wipeSecret(input)
+
+	if err := a.writeShellInput(sessionID, input); err != nil {
+		return fmt.Errorf("failed to send secret %s: %w", secretName, err)
+	}
+	fmt.Printf("Sent secret %s to session %s\n", secretName, sessionID)
+	return nil
+}
+
+// WriteSecretToRemoteFile writes a registered secret to a new 0600 file in the remote home
+// directory, for tools that read tokens from a file (docker login --password-stdin < file).
+// A background command on the host deletes it after ttlSeconds (0 = DefaultSecretFileTTL),
+// even if Thermic disconnects first. Returns the file's absolute path.
+func (a *App) WriteSecretToRemoteFile(sessionID, secretName string, ttlSeconds int) (string, error) {
+	if ttlSeconds == 0 {
+		ttlSeconds = DefaultSecretFileTTL
+	}
+	if ttlSeconds < 0 || ttlSeconds > MaxSecretFileTTL {
+		return "", fmt.Errorf("secret file TTL must be between 1 and %d seconds, got: %d", MaxSecretFileTTL, ttlSeconds)
+	}
+
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+	if !exists || sshSession.client == nil {
+		return "", newNotFoundError(ErrCategorySSH, "WriteSecretToRemoteFile", "SSH session %s not found", sessionID)
+	}
+
+	secret, err := a.loadSessionSecret(secretName)
+	if err != nil {
+		return "", err
+	}
+	defer wipeSecret(secret)
+
+	sftpClient, err := a.getOrReconnectSFTPClient(sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to name secret file: %w", err)
+	}
+	home, err := sftpClient.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to find remote home directory: %w", err)
+	}
+	remotePath := strings.TrimSuffix(home, "/") + "/" + secretFilePrefix + hex.EncodeToString(suffix)
+
+	// O_EXCL so an existing file or symlink at the path is never written through
+	file, err := sftpClient.OpenFile(remotePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+	if err != nil {
+		return "", fmt.Errorf("failed to create secret file: %w", err)
+	}
+	writeErr := file.Chmod(0600)
+	if writeErr == nil {
+		_, writeErr = file.Write(secret)
+	}
+	if closeErr := file.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		sftpClient.Remove(remotePath)
+		return "", fmt.Errorf("failed to write secret file: %w", writeErr)
+	}
+
+	if err := scheduleRemoteSecretCleanup(sshSession, remotePath, ttlSeconds); err != nil {
+		sftpClient.Remove(remotePath)
+		return "", err
+	}
+
+	fmt.Printf("Wrote secret %s to %s for %ds\n", secretName, remotePath, ttlSeconds)
+	return remotePath, nil
+}
+
+// scheduleRemoteSecretCleanup starts a detached job on the host that removes the file after ttl
+func scheduleRemoteSecretCleanup(sshSession *SSHSession, remotePath string, ttlSeconds int) error {
+	session, err := sshSession.client.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to schedule secret file cleanup: %w", err)
+	}
+	defer session.Close()
+
+	command := fmt.Sprintf("nohup sh -c 'sleep %d; rm -f \"$0\"' %s >/dev/null 2>&1 &", ttlSeconds, shellQuote(remotePath))
+	if err := session.Run(command); err != nil {
+		return fmt.Errorf("failed to schedule secret file cleanup: %w", err)
+	}
+	return nil
+}
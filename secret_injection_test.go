@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHasSecretPrompt(t *testing.T) {
+	tests := []struct {
+		tail string
+		want bool
+	}{
+		{"Cloning into 'repo'...\r\nPassword for 'https://user@github.com': ", true},
+		{"\x1b[1mEnter passphrase for key '/home/u/.ssh/id_ed25519':\x1b[0m ", true},
+		{"Token: ", true},
+		{"[sudo] password for user:", true},
+		{"Password: \r\nLogin Succeeded\r\n", false},
+		{"Password: \r\n", false},
+		{"user@host:~$ ", false},
+		{"password rotation is due\r\n$ ", false},
+	}
+	for _, tt := range tests {
+		if got := hasSecretPrompt(tt.tail); got != tt.want {
+			t.Errorf("hasSecretPrompt(%q) = %v, want %v", tt.tail, got, tt.want)
+		}
+	}
+}
+
+func TestWaitForSecretPrompt(t *testing.T) {
+	const sessionID = "secret_prompt_session"
+	defer forgetSecretPromptOutput(sessionID)
+
+	// A prompt already on screen is answered at once, but only once
+	recordSecretPromptOutput(sessionID, "docker login\r\nPassword: ")
+	if err := waitForSecretPrompt(sessionID, time.Second); err != nil {
+		t.Fatalf("waitForSecretPrompt with prompt showing returned error: %v", err)
+	}
+	if err := waitForSecretPrompt(sessionID, 50*time.Millisecond); err == nil {
+		t.Fatal("waitForSecretPrompt answered the same prompt twice")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- waitForSecretPrompt(sessionID, 5*time.Second)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	recordSecretPromptOutput(sessionID, "Username: user\r\n")
+	recordSecretPromptOutput(sessionID, "Token: ")
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("waitForSecretPrompt returned error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("waitForSecretPrompt did not return after the prompt appeared")
+	}
+}
+
+func TestValidateSessionSecretName(t *testing.T) {
+	for _, name := range []string{"github-token", "docker.hub_pw", "A1"} {
+		if err := validateSessionSecretName(name); err != nil {
+			t.Errorf("validateSessionSecretName(%q) returned error: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "has space", "semi;colon", "quote'", string(make([]byte, 65))} {
+		if err := validateSessionSecretName(name); err == nil {
+			t.Errorf("validateSessionSecretName(%q) accepted an invalid name", name)
+		}
+	}
+}
+
+// stdinRecorder keeps the buffers written to it, to check they are the caller's own
+type stdinRecorder struct {
+	writes [][]byte
+}
+
+func (r *stdinRecorder) Write(p []byte) (int, error) {
+	r.writes = append(r.writes, p)
+	return len(p), nil
+}
+
+func (r *stdinRecorder) Close() error { return nil }
+
+func TestWriteShellInputDoesNotCopy(t *testing.T) {
+	app := NewApp()
+	sessionID := "session_secret_input"
+	stdin := &stdinRecorder{}
+	app.ssh.sshSessions[sessionID] = &SSHSession{sessionID: sessionID, stdin: stdin}
+
+	input := []byte("hunter2\r")
+	if err := app.writeShellInput(sessionID, input); err != nil {
+		t.Fatalf("writeShellInput() returned error: %v", err)
+	}
+	// Wiping the input must wipe what was handed to the session
+	wipeSecret(input)
+	if len(stdin.writes) != 1 || &stdin.writes[0][0] != &input[0] {
+		t.Fatalf("session got a copy of the input: %q", stdin.writes)
+	}
+}
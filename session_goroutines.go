@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Session goroutine leak check constants
+const (
+	SessionGoroutineLeakEvent = "session-goroutine-leak"
+	MaxLeakedSessions         = 100 // Closed sessions still reported by GetSessionGoroutineCount
+)
+
+// sessionGoroutineGracePeriod is how long a closed session's goroutines get to finish before
+// they're reported as leaked. A variable so tests can shorten it.
+var sessionGoroutineGracePeriod = 10 * time.Second
+
+var (
+	// closedSSHSessions holds closed sessions whose goroutines are being waited on, or were
+	// still running at the end of the grace period, by session ID
+	closedSSHSessions   = make(map[string]*SSHSession)
+	closedSSHSessionsMu sync.Mutex
+)
+
+// debugBuild reports whether this is a development build, which checks closed sessions for
+// leaked goroutines
+func debugBuild() bool {
+	return Version == "dev"
+}
+
+// goTracked runs fn in a goroutine counted in the session's activeGoroutines
+func (s *SSHSession) goTracked(fn func()) {
+	atomic.AddInt32(&s.activeGoroutines, 1)
+	go func() {
+		defer atomic.AddInt32(&s.activeGoroutines, -1)
+		fn()
+	}()
+}
+
+// goroutineCount returns the number of the session's tracked goroutines still running
+func (s *SSHSession) goroutineCount() int {
+	return int(atomic.LoadInt32(&s.activeGoroutines))
+}
+
+// goSession runs fn in a goroutine counted against the session's SSH connection, or in a
+// plain goroutine when the session has no SSH connection (local shells, tests)
+func (a *App) goSession(sessionID string, fn func()) {
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+	if sshSession == nil {
+		go fn()
+		return
+	}
+	sshSession.goTracked(fn)
+}
+
+// GetSessionGoroutineCount returns the number of goroutines still running for a session:
+// output and error handlers, monitoring commands and transfers. Closed sessions are reported
+// until their goroutines finish, so leaks can be inspected.
+func (a *App) GetSessionGoroutineCount(sessionID string) (int, error) {
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+	if sshSession != nil {
+		return sshSession.goroutineCount(), nil
+	}
+
+	closedSSHSessionsMu.Lock()
+	defer closedSSHSessionsMu.Unlock()
+	closed := closedSSHSessions[sessionID]
+	if closed == nil {
+		return 0, newNotFoundError(ErrCategorySSH, "GetSessionGoroutineCount", "SSH session %s not found", sessionID)
+	}
+	count := closed.goroutineCount()
+	if count == 0 {
+		delete(closedSSHSessions, sessionID)
+	}
+	return count, nil
+}
+
+// checkSessionGoroutines warns, in debug builds, when a closed session still has goroutines
+// running once the grace period is over. The returned channel is closed after the check ran;
+// it is nil when no check is scheduled.
+func (a *App) checkSessionGoroutines(sshSession *SSHSession) <-chan struct{} {
+	if !debugBuild() {
+		return nil
+	}
+
+	closedSSHSessionsMu.Lock()
+	closedSSHSessions[sshSession.sessionID] = sshSession
+	closedSSHSessionsMu.Unlock()
+
+	gracePeriod := sessionGoroutineGracePeriod
+	done := make(chan struct{})
+	time.AfterFunc(gracePeriod, func() {
+		defer close(done)
+		count := sshSession.goroutineCount()
+
+		closedSSHSessionsMu.Lock()
+		if closedSSHSessions[sshSession.sessionID] == sshSession {
+			if count == 0 || len(closedSSHSessions) > MaxLeakedSessions {
+				delete(closedSSHSessions, sshSession.sessionID)
+			}
+		}
+		closedSSHSessionsMu.Unlock()
+
+		if count == 0 {
+			return
+		}
+		fmt.Printf("Warning: SSH session %s still has %d goroutines running %s after it closed\n",
+			sshSession.sessionID, count, gracePeriod)
+		appEmitter{a}.Emit(SessionGoroutineLeakEvent, map[string]interface{}{
+			"sessionId":  sshSession.sessionID,
+			"goroutines": count,
+		})
+	})
+	return done
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// waitForGoroutineCount polls until the session reports want goroutines
+func waitForGoroutineCount(t *testing.T, app *App, sessionID string, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		count, err := app.GetSessionGoroutineCount(sessionID)
+		if err == nil && count == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("GetSessionGoroutineCount(%s) = %d, %v; want %d", sessionID, count, err, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSessionGoroutinesAreCounted(t *testing.T) {
+	app := NewApp()
+	sshSession := &SSHSession{sessionID: "session_goroutines"}
+	app.ssh.sshSessions[sshSession.sessionID] = sshSession
+
+	release := make(chan struct{})
+	sshSession.goTracked(func() { <-release })
+	app.goSession(sshSession.sessionID, func() { <-release })
+	waitForGoroutineCount(t, app, sshSession.sessionID, 2)
+
+	close(release)
+	waitForGoroutineCount(t, app, sshSession.sessionID, 0)
+
+	if _, err := app.GetSessionGoroutineCount("session_unknown"); toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("GetSessionGoroutineCount() of an unknown session = %v, want not found", err)
+	}
+}
+
+// waitForSessionGoroutineCheck waits for a scheduled leak check, so the grace period a test
+// changed isn't restored while the check still uses it
+func waitForSessionGoroutineCheck(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("session goroutine check never ran")
+	}
+}
+
+func TestClosedSessionGoroutineLeakIsReported(t *testing.T) {
+	if !debugBuild() {
+		t.Skip("leak checks only run in debug builds")
+	}
+	defer func(previous time.Duration) { sessionGoroutineGracePeriod = previous }(sessionGoroutineGracePeriod)
+	sessionGoroutineGracePeriod = 20 * time.Millisecond
+
+	app := NewApp()
+	sshSession := &SSHSession{sessionID: "session_leaky"}
+	release := make(chan struct{})
+	sshSession.goTracked(func() { <-release })
+
+	// The session is gone from the live map, as after CloseSSHSession
+	waitForSessionGoroutineCheck(t, app.checkSessionGoroutines(sshSession))
+	if count, err := app.GetSessionGoroutineCount(sshSession.sessionID); err != nil || count != 1 {
+		t.Fatalf("GetSessionGoroutineCount() after the grace period = %d, %v; want the leaked goroutine", count, err)
+	}
+
+	// Once it finishes the session is forgotten
+	close(release)
+	waitForGoroutineCount(t, app, sshSession.sessionID, 0)
+	if _, err := app.GetSessionGoroutineCount(sshSession.sessionID); toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("GetSessionGoroutineCount() after the goroutines finished = %v, want not found", err)
+	}
+}
+
+func TestClosedSessionWithoutLeaksIsForgotten(t *testing.T) {
+	defer func(previous time.Duration) { sessionGoroutineGracePeriod = previous }(sessionGoroutineGracePeriod)
+	sessionGoroutineGracePeriod = 10 * time.Millisecond
+
+	app := NewApp()
+	sshSession := &SSHSession{sessionID: "session_clean"}
+	if done := app.checkSessionGoroutines(sshSession); done != nil {
+		waitForSessionGoroutineCheck(t, done)
+	}
+	closedSSHSessionsMu.Lock()
+	_, kept := closedSSHSessions[sshSession.sessionID]
+	closedSSHSessionsMu.Unlock()
+	if kept {
+		t.Error("session without goroutines still tracked after the grace period")
+	}
+}
@@ -0,0 +1,611 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SessionSweepInterval is how often the sweeper cross-checks per-session state against live tabs
+const SessionSweepInterval = 60 * time.Second
+
+// SessionStateSource is one manager's per-session state. List returns the session IDs it
+// currently holds state for; Release tears that state down for a single session.
+type SessionStateSource struct {
+	Name    string
+	List    func() []string
+	Release func(sessionID string)
+}
+
+// SessionRegistry tracks every kind of per-session state so it can be released in one place
+type SessionRegistry struct {
+	sources  []SessionStateSource
+	suspects map[string]bool // "source/session" pairs seen orphaned by the previous sweep
+	stopChan chan struct{}
+	stopOnce sync.Once
+	mutex    sync.Mutex
+}
+
+// NewSessionRegistry creates an empty session registry
+func NewSessionRegistry() *SessionRegistry {
+	return &SessionRegistry{
+		suspects: make(map[string]bool),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Register adds a per-session state source
+func (r *SessionRegistry) Register(source SessionStateSource) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.sources = append(r.sources, source)
+}
+
+// Close implements the Cleanup interface and stops the sweeper
+func (r *SessionRegistry) Close() error {
+	r.stopOnce.Do(func() {
+		close(r.stopChan)
+	})
+	return nil
+}
+
+// snapshot returns a copy of the registered sources
+func (r *SessionRegistry) snapshot() []SessionStateSource {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]SessionStateSource(nil), r.sources...)
+}
+
+// ReleaseSession tears down all per-session state held by any manager.
+// It does not close the PTY/SSH connection itself - that is CloseShell's job.
+func (a *App) ReleaseSession(sessionID string) {
+	if sessionID == "" || a.registry == nil {
+		return
+	}
+
+	for _, source := range a.registry.snapshot() {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					fmt.Printf("Panic releasing %s state for session %s: %v\n", source.Name, sessionID, r)
+				}
+			}()
+			source.Release(sessionID)
+		}()
+	}
+}
+
+// CleanupSession tears a session down in one go: every registered state source is released
+// (transfers cancelled, SFTP client closed, metrics and streams dropped, ...) and the PTY or
+// SSH connection, monitoring included, is closed and removed. Closing the connection runs in
+// the background since it can block on a dead link. Every step is idempotent, so calling it
+// again, or for a session that is already gone, does nothing.
+func (a *App) CleanupSession(sessionID string) {
+	if sessionID == "" {
+		return
+	}
+
+	a.ReleaseSession(sessionID)
+
+	go func() {
+		if err := a.CloseShell(sessionID); err != nil && toThermicError(err).Code != ErrCodeNotFound {
+			fmt.Printf("Error closing session %s: %v\n", sessionID, err)
+		}
+	}()
+}
+
+// liveSessionIDs returns the session IDs that still belong to an open tab or a file explorer
+// only session
+func (a *App) liveSessionIDs() map[string]bool {
+	live := make(map[string]bool)
+	for _, sessionID := range fileExplorerOnlySessionIDs() {
+		live[sessionID] = true
+	}
+
+	a.terminal.mutex.RLock()
+	defer a.terminal.mutex.RUnlock()
+	for _, tab := range a.terminal.tabs {
+		if tab.SessionID != "" {
+			live[tab.SessionID] = true
+		}
+	}
+	return live
+}
+
+// findOrphanedState returns, per source, the session IDs that hold state but have no open tab
+func (a *App) findOrphanedState() map[string][]string {
+	live := a.liveSessionIDs()
+	orphaned := make(map[string][]string)
+
+	for _, source := range a.registry.snapshot() {
+		for _, sessionID := range source.List() {
+			if !live[sessionID] {
+				orphaned[source.Name] = append(orphaned[source.Name], sessionID)
+			}
+		}
+		sort.Strings(orphaned[source.Name])
+	}
+	return orphaned
+}
+
+// GetOrphanedStateReport lists per-session state that outlived its tab, keyed by source name
+func (a *App) GetOrphanedStateReport() map[string]interface{} {
+	orphaned := a.findOrphanedState()
+
+	total := 0
+	sources := make(map[string]interface{}, len(orphaned))
+	for name, sessionIDs := range orphaned {
+		if len(sessionIDs) == 0 {
+			continue
+		}
+		total += len(sessionIDs)
+		sources[name] = sessionIDs
+	}
+
+	return map[string]interface{}{
+		"totalOrphaned": total,
+		"sources":       sources,
+		"liveSessions":  len(a.liveSessionIDs()),
+		"generatedAt":   time.Now(),
+	}
+}
+
+// sweepOrphanedSessions releases state that has been orphaned for two consecutive sweeps.
+// The grace period avoids racing a tab that is still being created.
+func (a *App) sweepOrphanedSessions() int {
+	orphaned := a.findOrphanedState()
+
+	a.registry.mutex.Lock()
+	previous := a.registry.suspects
+	current := make(map[string]bool)
+	toRelease := make(map[string]bool)
+	for name, sessionIDs := range orphaned {
+		for _, sessionID := range sessionIDs {
+			key := name + "/" + sessionID
+			current[key] = true
+			if previous[key] {
+				toRelease[sessionID] = true
+			}
+		}
+	}
+	a.registry.suspects = current
+	a.registry.mutex.Unlock()
+
+	for sessionID := range toRelease {
+		fmt.Printf("Releasing orphaned state for session %s\n", sessionID)
+		// A live PTY/SSH session without a tab is orphaned too
+		a.CleanupSession(sessionID)
+	}
+	return len(toRelease)
+}
+
+// startSessionSweeper periodically releases orphaned per-session state
+func (a *App) startSessionSweeper() {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Printf("Session sweeper panic: %v\n", r)
+			}
+		}()
+
+		ticker := time.NewTicker(SessionSweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-a.registry.stopChan:
+				return
+			case <-ticker.C:
+				a.sweepOrphanedSessions()
+			}
+		}
+	}()
+}
+
+// registerSessionStateSources registers every manager's per-session state with the registry
+func (a *App) registerSessionStateSources() {
+	r := a.registry
+
+	// Terminal sessions and SSH connections are released by CloseShell; listed for the report/sweeper
+	r.Register(SessionStateSource{
+		Name: "terminal.sessions",
+		List: func() []string {
+			a.terminal.mutex.RLock()
+			defer a.terminal.mutex.RUnlock()
+			return mapKeys(a.terminal.sessions)
+		},
+		Release: func(string) {},
+	})
+	r.Register(SessionStateSource{
+		Name: "ssh.sessions",
+		List: func() []string {
+			a.ssh.sshSessionsMutex.RLock()
+			defer a.ssh.sshSessionsMutex.RUnlock()
+			return mapKeys(a.ssh.sshSessions)
+		},
+		Release: func(string) {},
+	})
+	r.Register(SessionStateSource{
+		Name: "ssh.sftpClients",
+		List: func() []string {
+			a.ssh.sftpClientsMutex.RLock()
+			defer a.ssh.sftpClientsMutex.RUnlock()
+			return mergeKeys(mapKeys(a.ssh.sftpClients), mapKeys(a.ssh.sftpPools))
+		},
+		Release: func(sessionID string) {
+			a.ssh.sftpClientsMutex.RLock()
+			_, exists := a.ssh.sftpClients[sessionID]
+			_, pooled := a.ssh.sftpPools[sessionID]
+			a.ssh.sftpClientsMutex.RUnlock()
+			if exists || pooled {
+				// Closing can block on a dead connection - don't hold up the tab close
+				go a.CloseFileExplorerSession(sessionID)
+			}
+		},
+	})
+
+	r.Register(SessionStateSource{
+		Name: "terminal.statusDebounce",
+		List: func() []string {
+			a.terminal.statusMutex.Lock()
+			defer a.terminal.statusMutex.Unlock()
+			return mergeKeys(mapKeys(a.terminal.lastStatus), mapKeys(a.terminal.statusDebouncer))
+		},
+		Release: a.messages.clearStatusDebounce,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "terminal.titleRefresh",
+		List: func() []string {
+			tabTitleRefreshTimersMu.Lock()
+			defer tabTitleRefreshTimersMu.Unlock()
+			return mapKeys(tabTitleRefreshTimers)
+		},
+		Release: cancelTabTitleRefresh,
+	})
+	r.Register(SessionStateSource{
+		Name: "terminal.shellIntegration",
+		List: func() []string {
+			shellIntegrationStatesMu.Lock()
+			defer shellIntegrationStatesMu.Unlock()
+			return mapKeys(shellIntegrationStates)
+		},
+		Release: forgetShellIntegration,
+	})
+	r.Register(SessionStateSource{
+		Name: "terminal.shellCaptures",
+		List: func() []string {
+			shellCapturesMu.Lock()
+			defer shellCapturesMu.Unlock()
+			return mapKeys(shellCaptures)
+		},
+		Release: abortShellCapture,
+	})
+	r.Register(SessionStateSource{
+		Name: "terminal.bells",
+		List: func() []string {
+			terminalBellStatesMu.Lock()
+			defer terminalBellStatesMu.Unlock()
+			return mapKeys(terminalBellStates)
+		},
+		Release: releaseTerminalBell,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "terminal.imageFilters",
+		List: func() []string {
+			a.terminal.imageMutex.Lock()
+			defer a.terminal.imageMutex.Unlock()
+			return mapKeys(a.terminal.imageFilters)
+		},
+		Release: a.releaseInlineImageFilter,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "messages.hostKeyPrompts",
+		List: func() []string {
+			a.messages.promptsMutex.RLock()
+			defer a.messages.promptsMutex.RUnlock()
+			return mapKeys(a.messages.activePrompts)
+		},
+		Release: func(sessionID string) {
+			a.messages.SetHostKeyPromptActive(sessionID, false)
+		},
+	})
+	r.Register(SessionStateSource{
+		Name: "messages.connectionAnimations",
+		List: func() []string {
+			a.messages.animationsMutex.RLock()
+			defer a.messages.animationsMutex.RUnlock()
+			return mapKeys(a.messages.connectionAnimations)
+		},
+		Release: a.messages.stopConnectionAnimation,
+	})
+	r.Register(SessionStateSource{
+		Name: "messages.collapsed",
+		List: func() []string {
+			a.messages.collapseMutex.Lock()
+			defer a.messages.collapseMutex.Unlock()
+			return mapKeys(a.messages.collapsed)
+		},
+		Release: a.messages.clearCollapsed,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "monitoring.metrics",
+		List: func() []string {
+			a.monitoring.mutex.RLock()
+			defer a.monitoring.mutex.RUnlock()
+			alertSessions := make([]string, 0, len(a.monitoring.diskAlerts))
+			for _, alert := range a.monitoring.diskAlerts {
+				alertSessions = append(alertSessions, alert.SessionID)
+			}
+			for _, detector := range a.monitoring.leakDetectors {
+				alertSessions = append(alertSessions, detector.SessionID)
+			}
+			return mergeKeys(mapKeys(a.monitoring.sessionHistories), mapKeys(a.monitoring.updateRates), mapKeys(a.monitoring.diskIOTracking), mapKeys(a.monitoring.cpuSamples), alertSessions)
+		},
+		Release: a.CleanupSessionMetrics,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "sftp.activeTransfers",
+		List: func() []string {
+			activeTransfersMu.RLock()
+			defer activeTransfersMu.RUnlock()
+			return mapKeys(activeTransfers)
+		},
+		Release: func(sessionID string) {
+			// Cancel first so a still-running transfer loop stops at its next check
+			a.CancelSFTPTransfer(sessionID)
+			a.endTransfer(sessionID)
+		},
+	})
+	r.Register(SessionStateSource{
+		Name: "sftp.streams",
+		List: func() []string {
+			sftpStreamsMu.Lock()
+			defer sftpStreamsMu.Unlock()
+			sessionIDs := make([]string, 0, len(sftpStreams))
+			for _, reg := range sftpStreams {
+				sessionIDs = append(sessionIDs, reg.sessionID)
+			}
+			return mergeKeys(sessionIDs)
+		},
+		Release: releaseSFTPStreams,
+	})
+	r.Register(SessionStateSource{
+		Name: "sftp.pathCompletions",
+		List: func() []string {
+			pathCompletionCacheMu.Lock()
+			defer pathCompletionCacheMu.Unlock()
+			return mapKeys(pathCompletionCache)
+		},
+		Release: clearPathCompletionCache,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "ssh.pendingHostKeyUpdates",
+		List: func() []string {
+			pendingHostKeyMutex.RLock()
+			defer pendingHostKeyMutex.RUnlock()
+			return mapKeys(pendingHostKeyUpdates)
+		},
+		Release: func(sessionID string) {
+			pendingHostKeyMutex.Lock()
+			pending, exists := pendingHostKeyUpdates[sessionID]
+			pendingHostKeyMutex.Unlock()
+			if exists {
+				a.cancelPendingHostKeyUpdate(pending)
+			}
+		},
+	})
+	r.Register(SessionStateSource{
+		Name: "ssh.hostKeyVerifications",
+		List: func() []string {
+			hostKeyVerificationsMutex.RLock()
+			defer hostKeyVerificationsMutex.RUnlock()
+			return mapKeys(hostKeyVerifications)
+		},
+		Release: forgetHostKeyVerification,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "hooks.connect",
+		List: func() []string {
+			connectHookStatesMu.Lock()
+			defer connectHookStatesMu.Unlock()
+			return mapKeys(connectHookStates)
+		},
+		Release: releaseConnectHooks,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "sftp.directoryCounts",
+		List: func() []string {
+			directoryCountCacheMu.Lock()
+			defer directoryCountCacheMu.Unlock()
+			return mapKeys(directoryCountCache)
+		},
+		Release: clearDirectoryCounts,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "sftp.remoteMounts",
+		List: func() []string {
+			remoteMountsCacheMu.Lock()
+			defer remoteMountsCacheMu.Unlock()
+			return mapKeys(remoteMountsCache)
+		},
+		Release: clearRemoteMounts,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "ssh.directoryCache",
+		List: func() []string {
+			a.ssh.directoryCacheMutex.Lock()
+			defer a.ssh.directoryCacheMutex.Unlock()
+			return mapKeys(a.ssh.directoryCache)
+		},
+		Release: a.invalidateDirectoryCache,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "ssh.sftpBufferSizes",
+		List: func() []string {
+			a.ssh.sftpBufferMutex.Lock()
+			defer a.ssh.sftpBufferMutex.Unlock()
+			return mapKeys(a.ssh.sftpBufferSizes)
+		},
+		Release: a.releaseSFTPBufferTune,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "ssh.sftpSessionConfigs",
+		List: func() []string {
+			a.ssh.sftpSessionConfigsMutex.RLock()
+			defer a.ssh.sftpSessionConfigsMutex.RUnlock()
+			return mapKeys(a.ssh.sftpSessionConfigs)
+		},
+		Release: a.releaseSessionSFTPConfig,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "remote.sockets",
+		List: func() []string {
+			remoteSocketCacheMu.Lock()
+			defer remoteSocketCacheMu.Unlock()
+			return mapKeys(remoteSocketCache)
+		},
+		Release: clearRemoteSocketCache,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "remote.commands",
+		List: func() []string {
+			remoteCommandsMu.Lock()
+			defer remoteCommandsMu.Unlock()
+			sessionIDs := make([]string, 0, len(remoteCommands))
+			for _, run := range remoteCommands {
+				sessionIDs = append(sessionIDs, run.sessionID)
+			}
+			return mergeKeys(sessionIDs)
+		},
+		Release: cancelRemoteCommands,
+	})
+	r.Register(SessionStateSource{
+		Name: "sftp.dirWatches",
+		List: func() []string {
+			remoteDirWatchesMu.Lock()
+			defer remoteDirWatchesMu.Unlock()
+			sessionIDs := make([]string, 0, len(remoteDirWatches))
+			for _, watch := range remoteDirWatches {
+				sessionIDs = append(sessionIDs, watch.SessionID)
+			}
+			return mergeKeys(sessionIDs)
+		},
+		Release: stopRemoteDirWatches,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "sftp.transferTotals",
+		List: func() []string {
+			sessionTransferTotalsMu.Lock()
+			defer sessionTransferTotalsMu.Unlock()
+			return mapKeys(sessionTransferTotals)
+		},
+		Release: releaseTransferTotals,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "sftp.tuning",
+		List: func() []string {
+			sftpTuningMu.Lock()
+			defer sftpTuningMu.Unlock()
+			return mapKeys(sftpTunings)
+		},
+		Release: releaseSFTPTuning,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "secrets.prompts",
+		List: func() []string {
+			secretPromptWatchesMu.Lock()
+			defer secretPromptWatchesMu.Unlock()
+			return mapKeys(secretPromptWatches)
+		},
+		Release: forgetSecretPromptOutput,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "scrollback",
+		List: func() []string {
+			scrollbackBuffersMu.Lock()
+			sessionIDs := mapKeys(scrollbackBuffers)
+			scrollbackBuffersMu.Unlock()
+
+			scrollbackStreamsMu.Lock()
+			defer scrollbackStreamsMu.Unlock()
+			for _, stream := range scrollbackStreams {
+				sessionIDs = append(sessionIDs, stream.sessionID)
+			}
+			return mergeKeys(sessionIDs)
+		},
+		Release: releaseScrollback,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "privacy.buffers",
+		List: func() []string {
+			a.privacy.mutex.RLock()
+			defer a.privacy.mutex.RUnlock()
+			return mapKeys(a.privacy.buffers)
+		},
+		Release: a.privacy.discard,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "sudo.audit",
+		List: func() []string {
+			sudoAuditLogsMu.Lock()
+			defer sudoAuditLogsMu.Unlock()
+			return mapKeys(sudoAuditLogs)
+		},
+		Release: forgetSudoAuditLog,
+	})
+
+	r.Register(SessionStateSource{
+		Name: "replay",
+		List: func() []string {
+			replaySessionsMu.Lock()
+			defer replaySessionsMu.Unlock()
+			return mapKeys(replaySessions)
+		},
+		Release: a.releaseReplaySession,
+	})
+}
+
+// mapKeys returns the keys of a string-keyed map
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// mergeKeys returns the de-duplicated union of several key lists
+func mergeKeys(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var merged []string
+	for _, list := range lists {
+		for _, key := range list {
+			if !seen[key] {
+				seen[key] = true
+				merged = append(merged, key)
+			}
+		}
+	}
+	return merged
+}
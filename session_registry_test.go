@@ -0,0 +1,358 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// sessionStateSizes reads every per-session map directly, independent of the registry,
+// so a map that was never registered still shows up as a leak.
+func sessionStateSizes(app *App) map[string]int {
+	sizes := make(map[string]int)
+
+	app.terminal.mutex.RLock()
+	sizes["terminal.tabs"] = len(app.terminal.tabs)
+	sizes["terminal.sessions"] = len(app.terminal.sessions)
+	sizes["terminal.sessionTags"] = len(app.terminal.sessionTags)
+	app.terminal.mutex.RUnlock()
+
+	tabTitleRefreshTimersMu.Lock()
+	sizes["tabTitleRefreshTimers"] = len(tabTitleRefreshTimers)
+	tabTitleRefreshTimersMu.Unlock()
+
+	shellIntegrationStatesMu.Lock()
+	sizes["shellIntegrationStates"] = len(shellIntegrationStates)
+	shellIntegrationStatesMu.Unlock()
+
+	app.terminal.imageMutex.Lock()
+	sizes["terminal.imageFilters"] = len(app.terminal.imageFilters)
+	app.terminal.imageMutex.Unlock()
+
+	app.terminal.statusMutex.Lock()
+	sizes["terminal.statusDebouncer"] = len(app.terminal.statusDebouncer)
+	sizes["terminal.lastStatus"] = len(app.terminal.lastStatus)
+	app.terminal.statusMutex.Unlock()
+
+	app.ssh.sshSessionsMutex.RLock()
+	sizes["ssh.sshSessions"] = len(app.ssh.sshSessions)
+	app.ssh.sshSessionsMutex.RUnlock()
+
+	app.ssh.sftpClientsMutex.RLock()
+	sizes["ssh.sftpClients"] = len(app.ssh.sftpClients)
+	app.ssh.sftpClientsMutex.RUnlock()
+
+	app.ssh.directoryCacheMutex.Lock()
+	sizes["ssh.directoryCache"] = len(app.ssh.directoryCache)
+	app.ssh.directoryCacheMutex.Unlock()
+
+	app.messages.promptsMutex.RLock()
+	sizes["messages.activePrompts"] = len(app.messages.activePrompts)
+	app.messages.promptsMutex.RUnlock()
+
+	app.messages.animationsMutex.RLock()
+	sizes["messages.connectionAnimations"] = len(app.messages.connectionAnimations)
+	app.messages.animationsMutex.RUnlock()
+
+	app.messages.collapseMutex.Lock()
+	sizes["messages.collapsed"] = len(app.messages.collapsed)
+	app.messages.collapseMutex.Unlock()
+
+	app.monitoring.mutex.RLock()
+	sizes["monitoring.sessionHistories"] = len(app.monitoring.sessionHistories)
+	sizes["monitoring.updateRates"] = len(app.monitoring.updateRates)
+	sizes["monitoring.diskIOTracking"] = len(app.monitoring.diskIOTracking)
+	sizes["monitoring.cpuSamples"] = len(app.monitoring.cpuSamples)
+	sizes["monitoring.diskAlerts"] = len(app.monitoring.diskAlerts)
+	app.monitoring.mutex.RUnlock()
+
+	app.privacy.mutex.RLock()
+	sizes["privacy.buffers"] = len(app.privacy.buffers)
+	sizes["privacy.order"] = len(app.privacy.order)
+	app.privacy.mutex.RUnlock()
+
+	activeTransfersMu.RLock()
+	sizes["activeTransfers"] = len(activeTransfers)
+	activeTransfersMu.RUnlock()
+
+	sftpStreamsMu.Lock()
+	sizes["sftpStreams"] = len(sftpStreams)
+	sftpStreamsMu.Unlock()
+
+	pathCompletionCacheMu.Lock()
+	sizes["pathCompletionCache"] = len(pathCompletionCache)
+	pathCompletionCacheMu.Unlock()
+
+	pendingHostKeyMutex.RLock()
+	sizes["pendingHostKeyUpdates"] = len(pendingHostKeyUpdates)
+	pendingHostKeyMutex.RUnlock()
+
+	hostKeyVerificationsMutex.RLock()
+	sizes["hostKeyVerifications"] = len(hostKeyVerifications)
+	hostKeyVerificationsMutex.RUnlock()
+
+	connectHookStatesMu.Lock()
+	sizes["connectHookStates"] = len(connectHookStates)
+	connectHookStatesMu.Unlock()
+
+	remoteSocketCacheMu.Lock()
+	sizes["remoteSocketCache"] = len(remoteSocketCache)
+	remoteSocketCacheMu.Unlock()
+
+	sftpTuningMu.Lock()
+	sizes["sftpTunings"] = len(sftpTunings)
+	sftpTuningMu.Unlock()
+
+	secretPromptWatchesMu.Lock()
+	sizes["secretPromptWatches"] = len(secretPromptWatches)
+	secretPromptWatchesMu.Unlock()
+
+	directoryCountCacheMu.Lock()
+	sizes["directoryCountCache"] = len(directoryCountCache)
+	directoryCountCacheMu.Unlock()
+
+	scrollbackBuffersMu.Lock()
+	sizes["scrollbackBuffers"] = len(scrollbackBuffers)
+	scrollbackBuffersMu.Unlock()
+
+	scrollbackStreamsMu.Lock()
+	sizes["scrollbackStreams"] = len(scrollbackStreams)
+	scrollbackStreamsMu.Unlock()
+
+	sudoAuditLogsMu.Lock()
+	sizes["sudoAuditLogs"] = len(sudoAuditLogs)
+	sudoAuditLogsMu.Unlock()
+
+	replaySessionsMu.Lock()
+	sizes["replaySessions"] = len(replaySessions)
+	replaySessionsMu.Unlock()
+
+	return sizes
+}
+
+// populateSessionState creates a tab and touches every manager that keeps per-session state
+func populateSessionState(t *testing.T, app *App, i int) string {
+	t.Helper()
+
+	tabID := fmt.Sprintf("tab_leak_%d", i)
+	sessionID := fmt.Sprintf("session_leak_%d", i)
+
+	tab := &Tab{ID: tabID, Title: tabID, SessionID: sessionID}
+	app.terminal.mutex.Lock()
+	app.terminal.tabs[tabID] = tab
+	app.terminal.mutex.Unlock()
+	app.setupInlineImages(tab)
+	if err := app.AddTagToSession(tabID, []string{"production"}); err != nil {
+		t.Fatalf("AddTagToSession(%s) returned error: %v", tabID, err)
+	}
+
+	app.InitSessionMetrics(sessionID)
+	app.monitoring.mutex.Lock()
+	app.monitoring.diskIOTracking[sessionID] = &DiskIOState{}
+	app.monitoring.cpuSamples[sessionID] = &CPUSampleState{}
+	app.monitoring.diskAlerts["alert_"+sessionID] = &DiskAlert{ID: "alert_" + sessionID, SessionID: sessionID, MountPoint: "/"}
+	app.monitoring.mutex.Unlock()
+
+	app.startTransfer(sessionID)
+
+	if i%2 == 0 {
+		app.messages.UpdateConnectionStatus(sessionID, StatusConnected.String(), "")
+	} else {
+		app.messages.UpdateConnectionStatus(sessionID, StatusConnecting.String(), "")
+	}
+	app.messages.startConnectionAnimation(sessionID)
+	app.messages.EmitMessage(sessionID, "SFTP client closed", MessageInfo)
+	app.messages.SetHostKeyPromptActive(sessionID, true)
+
+	key := generateTestHostKey(t)
+	app.storePendingHostKeyUpdate(sessionID, "example.com", "", nil, key, nil)
+	recordHostKeyVerification(sessionID, HostKeyAcceptedNew, "example.com", key, false)
+
+	sftpStreamsMu.Lock()
+	sftpStreams["nonce_"+sessionID] = &sftpStreamRegistration{sessionID: sessionID, remotePath: "/tmp/file"}
+	sftpStreamsMu.Unlock()
+
+	pathCompletionCacheMu.Lock()
+	pathCompletionCache[sessionID] = &remoteListingCache{dir: "/tmp", fetched: time.Now()}
+	pathCompletionCacheMu.Unlock()
+
+	sftpTuningMu.Lock()
+	sftpTunings[sessionID] = &SFTPTuning{ConcurrentRequests: 8, AutoTuned: true}
+	sftpTuningMu.Unlock()
+
+	markSessionConnected(sessionID)
+	recordSecretPromptOutput(sessionID, "Password: ")
+	recordScrollbackOutput(sessionID, "last login\n")
+	recordShellIntegration(sessionID, "\x1b]7;file://host/srv\a")
+	storeDirectoryCount(sessionID, "/var/log", 42)
+	remoteSocketCacheMu.Lock()
+	remoteSocketCache[sessionID] = map[string]RemoteSocketReport{"GetRemoteListeningSockets": {Source: SocketSourceSS, FetchedAt: time.Now()}}
+	remoteSocketCacheMu.Unlock()
+	app.storeDirectoryListing(sessionID, "/var/log", []RemoteFileEntry{{Name: "syslog", Path: "/var/log/syslog"}})
+	recordSudoInvocation(sessionID, "DeleteRemotePathWithSudo", "sudo rm -rf -- '/tmp/x'")
+
+	replaySessionsMu.Lock()
+	replaySessions[sessionID] = &replaySession{sessionID: sessionID, done: make(chan struct{})}
+	replaySessionsMu.Unlock()
+
+	app.emitTerminalOutput(sessionID, "output while locked")
+
+	return tabID
+}
+
+func TestReleaseSessionRestoresBaseline(t *testing.T) {
+	// Closed tabs are saved for reopening; keep them out of the real config dir
+	configHome := t.TempDir()
+	t.Setenv("HOME", configHome)
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("AppData", configHome)
+	app := NewApp()
+	app.privacy.emit = func(string, string) {}
+	app.SetInlineImageProtocols([]string{InlineImageProtocolSixel})
+	app.LockNow()
+
+	baseline := sessionStateSizes(app)
+
+	const sessionCount = 200
+	tabIDs := make([]string, 0, sessionCount)
+	for i := 0; i < sessionCount; i++ {
+		tabIDs = append(tabIDs, populateSessionState(t, app, i))
+	}
+
+	if report := app.GetOrphanedStateReport(); report["totalOrphaned"] != 0 {
+		t.Fatalf("live sessions reported as orphaned: %v", report["sources"])
+	}
+
+	// Close in an unusual order: odd tabs first, then even tabs in reverse
+	for i := 1; i < sessionCount; i += 2 {
+		if err := app.CloseTab(tabIDs[i]); err != nil {
+			t.Fatalf("CloseTab(%s) returned error: %v", tabIDs[i], err)
+		}
+	}
+	for i := sessionCount - 2; i >= 0; i -= 2 {
+		if err := app.CloseTab(tabIDs[i]); err != nil {
+			t.Fatalf("CloseTab(%s) returned error: %v", tabIDs[i], err)
+		}
+	}
+
+	// Animation goroutines and debounce timers settle asynchronously
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		sizes := sessionStateSizes(app)
+		leaked := false
+		for name, size := range sizes {
+			if size != baseline[name] {
+				leaked = true
+				if time.Now().After(deadline) {
+					t.Errorf("%s has %d entries, baseline %d", name, size, baseline[name])
+				}
+			}
+		}
+		if !leaked || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if report := app.GetOrphanedStateReport(); report["totalOrphaned"] != 0 {
+		t.Fatalf("orphaned state left after closing all tabs: %v", report["sources"])
+	}
+}
+
+func TestSweeperReleasesOrphanedState(t *testing.T) {
+	app := NewApp()
+	app.privacy.emit = func(string, string) {}
+
+	tabID := populateSessionState(t, app, 0)
+	sessionID := "session_leak_0"
+
+	// Simulate a tab that vanished without going through CloseTab
+	app.terminal.mutex.Lock()
+	delete(app.terminal.tabs, tabID)
+	app.terminal.mutex.Unlock()
+
+	report := app.GetOrphanedStateReport()
+	if report["totalOrphaned"] == 0 {
+		t.Fatal("orphaned state was not reported")
+	}
+
+	if released := app.sweepOrphanedSessions(); released != 0 {
+		t.Fatalf("first sweep should only mark suspects, released %d", released)
+	}
+	if released := app.sweepOrphanedSessions(); released != 1 {
+		t.Fatalf("second sweep should release the orphaned session, released %d", released)
+	}
+
+	if getHostKeyVerification(sessionID) != nil {
+		t.Fatal("host key verification survived the sweep")
+	}
+	app.monitoring.mutex.RLock()
+	_, exists := app.monitoring.sessionHistories[sessionID]
+	app.monitoring.mutex.RUnlock()
+	if exists {
+		t.Fatal("session metrics survived the sweep")
+	}
+}
+
+func TestCleanupSessionIsIdempotent(t *testing.T) {
+	app := NewApp()
+	app.privacy.emit = func(string, string) {}
+
+	tabID := populateSessionState(t, app, 0)
+	sessionID := "session_leak_0"
+	app.terminal.mutex.Lock()
+	delete(app.terminal.tabs, tabID)
+	delete(app.terminal.sessionTags, tabID)
+	app.terminal.mutex.Unlock()
+
+	app.CleanupSession(sessionID)
+	app.CleanupSession(sessionID)
+	app.CleanupSession("")
+
+	if activeTransferCount(sessionID) != 0 {
+		t.Error("CleanupSession() left the transfer running")
+	}
+	// Animation goroutines and debounce timers settle asynchronously
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		report := app.GetOrphanedStateReport()
+		if report["totalOrphaned"] == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("state left after CleanupSession(): %v", report["sources"])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCloseSSHSessionReleasesStateOfClosedTab(t *testing.T) {
+	app := NewApp()
+	app.privacy.emit = func(string, string) {}
+
+	// The tab is still open: only the connection goes, e.g. for a reconnect
+	tabID := populateSessionState(t, app, 0)
+	sessionID := "session_leak_0"
+	app.CloseSSHSession(&SSHSession{sessionID: sessionID})
+	sftpTuningMu.Lock()
+	_, kept := sftpTunings[sessionID]
+	sftpTuningMu.Unlock()
+	if !kept {
+		t.Fatal("CloseSSHSession() released the state of a session whose tab is open")
+	}
+
+	// The tab is gone: the rest of the session's state goes with the connection
+	app.terminal.mutex.Lock()
+	delete(app.terminal.tabs, tabID)
+	app.terminal.mutex.Unlock()
+	app.CloseSSHSession(&SSHSession{sessionID: sessionID})
+	sftpTuningMu.Lock()
+	_, kept = sftpTunings[sessionID]
+	sftpTuningMu.Unlock()
+	if kept {
+		t.Error("CloseSSHSession() kept the state of a session without a tab")
+	}
+	if getHostKeyVerification(sessionID) != nil {
+		t.Error("CloseSSHSession() kept the host key verification")
+	}
+}
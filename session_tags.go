@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// SessionTagsUpdatedEvent is sent with a tab's full tag list whenever it changes
+const SessionTagsUpdatedEvent = "tab-tags-updated"
+
+// AddTagToSession adds tags to a tab. Tags match case-insensitively; duplicates are ignored.
+func (a *App) AddTagToSession(tabID string, tags []string) error {
+	return a.updateSessionTags("AddTagToSession", tabID, func(current []string) ([]string, error) {
+		merged := mergeSessionTags(current, tags)
+		if len(merged) > MaxTagsPerProfile {
+			return nil, fmt.Errorf("too many tags: %d, maximum allowed: %d", len(merged), MaxTagsPerProfile)
+		}
+		return merged, nil
+	})
+}
+
+// RemoveTagFromSession removes tags from a tab. Tags the tab doesn't have are ignored.
+func (a *App) RemoveTagFromSession(tabID string, tags []string) error {
+	return a.updateSessionTags("RemoveTagFromSession", tabID, func(current []string) ([]string, error) {
+		remove := make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			remove[strings.ToLower(strings.TrimSpace(tag))] = true
+		}
+		kept := make([]string, 0, len(current))
+		for _, tag := range current {
+			if !remove[strings.ToLower(tag)] {
+				kept = append(kept, tag)
+			}
+		}
+		return kept, nil
+	})
+}
+
+// AutoTagSession copies the tags of the profile a tab was opened from onto the tab
+func (a *App) AutoTagSession(tabID string) error {
+	a.terminal.mutex.RLock()
+	tab, exists := a.terminal.tabs[tabID]
+	profileID := ""
+	if exists {
+		profileID = tab.ProfileID
+	}
+	a.terminal.mutex.RUnlock()
+
+	if !exists {
+		return newNotFoundError(ErrCategoryTerminal, "AutoTagSession", "tab %s not found", tabID)
+	}
+	if profileID == "" {
+		return fmt.Errorf("tab %s was not opened from a profile", tabID)
+	}
+
+	a.profiles.mutex.RLock()
+	profile, exists := a.profiles.profiles[profileID]
+	var tags []string
+	if exists {
+		tags = append(tags, profile.Tags...)
+	}
+	a.profiles.mutex.RUnlock()
+
+	if !exists {
+		return newNotFoundError(ErrCategoryProfile, "AutoTagSession", "profile not found: %s", profileID)
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return a.AddTagToSession(tabID, tags)
+}
+
+// GetSessionsByTag returns the tabs carrying a tag, oldest first
+func (a *App) GetSessionsByTag(tag string) ([]*Tab, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return nil, fmt.Errorf("tag cannot be empty")
+	}
+
+	a.terminal.mutex.RLock()
+	defer a.terminal.mutex.RUnlock()
+
+	tabs := make([]*Tab, 0)
+	for tabID, tags := range a.terminal.sessionTags {
+		tab, exists := a.terminal.tabs[tabID]
+		if !exists {
+			continue
+		}
+		for _, t := range tags {
+			if strings.ToLower(t) == tag {
+				tabs = append(tabs, tab)
+				break
+			}
+		}
+	}
+	sort.Slice(tabs, func(i, j int) bool {
+		return tabs[i].Created.Before(tabs[j].Created)
+	})
+	return tabs, nil
+}
+
+// updateSessionTags applies change to a tab's tags under the terminal lock and announces the result
+func (a *App) updateSessionTags(op, tabID string, change func(current []string) ([]string, error)) error {
+	a.terminal.mutex.Lock()
+	tab, exists := a.terminal.tabs[tabID]
+	if !exists {
+		a.terminal.mutex.Unlock()
+		return newNotFoundError(ErrCategoryTerminal, op, "tab %s not found", tabID)
+	}
+
+	tags, err := change(a.terminal.sessionTags[tabID])
+	if err != nil {
+		a.terminal.mutex.Unlock()
+		return err
+	}
+	if len(tags) == 0 {
+		delete(a.terminal.sessionTags, tabID)
+	} else {
+		a.terminal.sessionTags[tabID] = tags
+	}
+	// The tab carries a copy so GetTabs and tab events include the tags
+	tab.Tags = append([]string(nil), tags...)
+	a.terminal.mutex.Unlock()
+
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, SessionTagsUpdatedEvent, map[string]interface{}{
+			"tabId": tabID,
+			"tags":  tags,
+		})
+	}
+	return nil
+}
+
+// mergeSessionTags appends trimmed, non-empty tags that aren't already present, ignoring case
+func mergeSessionTags(current, add []string) []string {
+	merged := append([]string(nil), current...)
+	seen := make(map[string]bool, len(current)+len(add))
+	for _, tag := range current {
+		seen[strings.ToLower(tag)] = true
+	}
+	for _, tag := range add {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		merged = append(merged, tag)
+	}
+	return merged
+}
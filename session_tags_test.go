@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestSessionTags(t *testing.T) {
+	app := NewApp()
+	app.profiles.profiles["db"] = &Profile{ID: "db", Name: "db", Type: ProfileTypeLocal, Tags: []string{"Production", "database"}}
+
+	tab, _ := app.CreateTab("", nil)
+	tab.ProfileID = "db"
+	if err := app.AutoTagSession(tab.ID); err != nil {
+		t.Fatalf("AutoTagSession() returned error: %v", err)
+	}
+	other, _ := app.CreateTab("", nil)
+
+	if err := app.AddTagToSession(other.ID, []string{" production ", "", "web"}); err != nil {
+		t.Fatalf("AddTagToSession() returned error: %v", err)
+	}
+	if err := app.AddTagToSession(tab.ID, []string{"DATABASE"}); err != nil {
+		t.Fatalf("AddTagToSession() returned error: %v", err)
+	}
+	if len(tab.Tags) != 2 {
+		t.Fatalf("profile tags were not copied or duplicated: %v", tab.Tags)
+	}
+
+	tabs, err := app.GetSessionsByTag("production")
+	if err != nil || len(tabs) != 2 || tabs[0].ID != tab.ID {
+		t.Fatalf("GetSessionsByTag(production) = %v, %v", tabs, err)
+	}
+
+	if err := app.RemoveTagFromSession(other.ID, []string{"Production"}); err != nil {
+		t.Fatalf("RemoveTagFromSession() returned error: %v", err)
+	}
+	if tabs, _ := app.GetSessionsByTag("production"); len(tabs) != 1 {
+		t.Fatalf("removed tag still matches %d tabs", len(tabs))
+	}
+	if err := app.AutoTagSession(other.ID); err == nil {
+		t.Error("auto-tagging a tab without a profile should fail")
+	}
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// SFTP auto-tune constants
+const (
+	SFTPAutoTuneMinFileSize      = 8 * 1024 * 1024        // Smaller transfers finish before a measurement means anything
+	SFTPAutoTuneStartConcurrency = 8                      // In-flight requests tried first when the host has no cached value
+	SFTPAutoTuneMinWindow        = 250 * time.Millisecond // Shortest measurement window per setting
+	SFTPAutoTuneWindowRTTs       = 4                      // Measurement window length in request round trips
+	SFTPAutoTuneMaxDuration      = 15 * time.Second       // Exploration stops after this, keeping the best setting seen
+	SFTPAutoTuneGain             = 0.10                   // Throughput gain needed to prefer more requests in flight
+	SFTPAutoTuneDropTolerance    = 0.05                   // Throughput loss accepted to use fewer requests in flight
+)
+
+// SFTPTuning is the transfer setting chosen for a session, as reported to the frontend
+type SFTPTuning struct {
+	Host               string    `json:"host"`
+	MaxPacketSize      int       `json:"maxPacketSize"`
+	ConcurrentRequests int       `json:"concurrentRequests"`
+	ParallelTransfers  int       `json:"parallelTransfers,omitempty"` // Only set by BenchmarkSFTPTransfer
+	AutoTuned          bool      `json:"autoTuned"`                   // false when manual settings are in effect
+	Settled            bool      `json:"settled"`                     // The tuner finished exploring
+	BytesPerSec        int64     `json:"bytesPerSec"`                 // Best throughput measured while tuning
+	FromCache          bool      `json:"fromCache"`                   // Started from the value cached for the host
+	Updated            time.Time `json:"updated,omitempty"`
+
+	running bool // A transfer of the session is using this tuning
+}
+
+// SFTPServerInfo describes a session's SFTP connection and the transfer settings in use
+type SFTPServerInfo struct {
+	SessionID          string          `json:"sessionId"`
+	Host               string          `json:"host"`
+	MaxPacketSize      int             `json:"maxPacketSize"`
+	ConcurrentRequests int             `json:"concurrentRequests"`
+	AutoTune           bool            `json:"autoTune"`
+	Tuning             *SFTPTuning     `json:"tuning,omitempty"` // Set once a transfer has been auto-tuned
+	Capabilities       map[string]bool `json:"capabilities"`     // Advertised SFTP extensions, see GetSFTPCapabilities
+}
+
+var (
+	// sftpTunings holds the setting last chosen for each session
+	sftpTunings = make(map[string]*SFTPTuning)
+	// sftpTuneCache remembers the settled in-flight request count per host for the app's lifetime
+	sftpTuneCache = make(map[string]int)
+	sftpTuningMu  sync.Mutex
+)
+
+// sftpTuner picks the number of in-flight requests for one transfer. It measures throughput at
+// each setting for a few round trips, doubles the count while that gains SFTPAutoTuneGain, and
+// otherwise halves it while the loss stays within SFTPAutoTuneDropTolerance, then settles on
+// the best setting seen.
+type sftpTuner struct {
+	mu          sync.Mutex
+	min, max    int
+	current     int
+	best        int
+	bestRate    float64
+	baseRate    float64 // Rate of the first setting measured
+	descending  bool
+	settled     bool
+	started     time.Time
+	warmupUntil time.Time // Completions before this still reflect the previous setting
+	windowStart time.Time
+	windowBytes int64
+	latency     time.Duration // Moving average of request round trips
+}
+
+// newSFTPTuner creates a tuner starting at start in-flight requests, clamped to [min, max]
+func newSFTPTuner(start, min, max int, now time.Time) *sftpTuner {
+	if start < min {
+		start = min
+	}
+	if start > max {
+		start = max
+	}
+	return &sftpTuner{min: min, max: max, current: start, best: start, started: now}
+}
+
+// limit returns the number of requests to keep in flight
+func (t *sftpTuner) limit() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current
+}
+
+// result returns the chosen setting, the best throughput measured and whether tuning finished
+func (t *sftpTuner) result() (int, float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.best, t.bestRate, t.settled
+}
+
+// observe records a completed request of n bytes that took took
+func (t *sftpTuner) observe(n int, took time.Duration, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.latency == 0 {
+		t.latency = took
+	} else {
+		t.latency = (t.latency*7 + took) / 8
+	}
+	if t.settled || now.Before(t.warmupUntil) {
+		return
+	}
+	if t.windowStart.IsZero() {
+		t.windowStart = now
+		return
+	}
+	t.windowBytes += int64(n)
+
+	window := SFTPAutoTuneWindowRTTs * t.latency
+	if window < SFTPAutoTuneMinWindow {
+		window = SFTPAutoTuneMinWindow
+	}
+	elapsed := now.Sub(t.windowStart)
+	if elapsed < window {
+		return
+	}
+	t.decide(float64(t.windowBytes)/elapsed.Seconds(), now)
+}
+
+// decide moves to the next setting after a window measured rate at the current one
+func (t *sftpTuner) decide(rate float64, now time.Time) {
+	next := 0
+	switch {
+	case t.bestRate == 0:
+		// First measurement
+		t.best, t.bestRate, t.baseRate = t.current, rate, rate
+		next = t.current * 2
+	case !t.descending && rate > t.bestRate*(1+SFTPAutoTuneGain):
+		t.best, t.bestRate = t.current, rate
+		next = t.current * 2
+	case !t.descending && t.best == t.current/2 && t.bestRate == t.baseRate:
+		// The first step up didn't pay off; see whether fewer requests do as well
+		t.descending = true
+		next = t.best / 2
+	case t.descending && rate >= t.bestRate*(1-SFTPAutoTuneDropTolerance):
+		t.best = t.current
+		if rate > t.bestRate {
+			t.bestRate = rate
+		}
+		next = t.current / 2
+	}
+
+	if next < t.min || next > t.max || now.Sub(t.started) >= SFTPAutoTuneMaxDuration {
+		next = 0
+	}
+	if next == 0 {
+		t.settled = true
+		t.current = t.best
+		return
+	}
+	t.current = next
+	t.warmupUntil = now.Add(t.latency)
+	t.windowStart = time.Time{}
+	t.windowBytes = 0
+}
+
+// sftpManualConcurrency reports whether the user set ConcurrentRequests by hand, globally or
+// for the session, which always wins over auto-tuning
+func (a *App) sftpManualConcurrency(sessionID string) bool {
+	if override, exists := a.sessionSFTPOverride(sessionID); exists && override.ConcurrentRequests != 0 {
+		return true
+	}
+	if a.config == nil {
+		return false
+	}
+	a.config.mutex.RLock()
+	defer a.config.mutex.RUnlock()
+	if a.config.config == nil {
+		return false
+	}
+	requests := a.config.config.SFTP.ConcurrentRequests
+	return requests != 0 && requests != DefaultSFTPConcurrentRequests
+}
+
+// sftpSessionHost returns the address a session's SSH connection goes to, used as the tune cache key
+func (a *App) sftpSessionHost(sessionID string) string {
+	a.ssh.sshSessionsMutex.RLock()
+	defer a.ssh.sshSessionsMutex.RUnlock()
+	if sshSession, exists := a.ssh.sshSessions[sessionID]; exists && sshSession.client != nil {
+		return sshSession.client.RemoteAddr().String()
+	}
+	return ""
+}
+
+// startSFTPAutoTune returns a tuner for a transfer of size bytes, or nil when the transfer
+// should use the fixed settings: auto-tune is off, ConcurrentRequests was set by hand or the
+// file is too small to measure
+func (a *App) startSFTPAutoTune(sessionID string, size int64) *sftpTuner {
+	cfg := a.sessionSFTPConfig(sessionID)
+	if !cfg.AutoTune || size < SFTPAutoTuneMinFileSize || a.sftpManualConcurrency(sessionID) {
+		return nil
+	}
+	host := a.sftpSessionHost(sessionID)
+
+	sftpTuningMu.Lock()
+	defer sftpTuningMu.Unlock()
+
+	if tuning, exists := sftpTunings[sessionID]; exists {
+		if tuning.running {
+			// One tuned transfer per session at a time; parallel transfers would skew the measurements
+			return nil
+		}
+		if tuning.Settled {
+			tuning.running = true
+			tuner := newSFTPTuner(tuning.ConcurrentRequests, MinSFTPConcurrentRequests, MaxSFTPConcurrentRequests, time.Now())
+			tuner.bestRate, tuner.settled = float64(tuning.BytesPerSec), true
+			return tuner
+		}
+	}
+
+	start, cached := sftpTuneCache[host]
+	if !cached {
+		start = SFTPAutoTuneStartConcurrency
+	} else if start > MinSFTPConcurrentRequests {
+		// Start one step below the cached value so a faster link is still noticed
+		start /= 2
+	}
+	sftpTunings[sessionID] = &SFTPTuning{
+		Host:               host,
+		MaxPacketSize:      cfg.MaxPacketSize,
+		ConcurrentRequests: start,
+		AutoTuned:          true,
+		FromCache:          cached,
+		Updated:            time.Now(),
+		running:            true,
+	}
+	return newSFTPTuner(start, MinSFTPConcurrentRequests, MaxSFTPConcurrentRequests, time.Now())
+}
+
+// finishSFTPAutoTune stores a tuner's choice for the session and, once settled, for the host
+func finishSFTPAutoTune(sessionID string, tuner *sftpTuner) {
+	best, rate, settled := tuner.result()
+
+	sftpTuningMu.Lock()
+	defer sftpTuningMu.Unlock()
+
+	tuning, exists := sftpTunings[sessionID]
+	if !exists {
+		return // Session closed during the transfer
+	}
+	tuning.running = false
+	tuning.ConcurrentRequests = best
+	tuning.BytesPerSec = int64(rate)
+	tuning.Settled = settled
+	tuning.Updated = time.Now()
+	if settled && tuning.Host != "" {
+		sftpTuneCache[tuning.Host] = best
+	}
+	fmt.Printf("SFTP auto-tune for session %s (%s): %d requests in flight, %.1f MB/s, settled=%v\n",
+		sessionID, tuning.Host, best, rate/(1024*1024), settled)
+}
+
+// sftpTuningFields returns the auto-tune values added to a session's progress events, or nil
+func sftpTuningFields(sessionID string) map[string]interface{} {
+	sftpTuningMu.Lock()
+	defer sftpTuningMu.Unlock()
+
+	tuning, exists := sftpTunings[sessionID]
+	if !exists {
+		return nil
+	}
+	return map[string]interface{}{
+		"autoTuned":          tuning.AutoTuned,
+		"concurrentRequests": tuning.ConcurrentRequests,
+		"maxPacketSize":      tuning.MaxPacketSize,
+	}
+}
+
+// updateSFTPTuningLimit records the in-flight count a running tuner is trying
+func updateSFTPTuningLimit(sessionID string, limit int) {
+	sftpTuningMu.Lock()
+	defer sftpTuningMu.Unlock()
+	if tuning, exists := sftpTunings[sessionID]; exists {
+		tuning.ConcurrentRequests = limit
+	}
+}
+
+// releaseSFTPTuning forgets a session's tuning. The per-host cache is kept for later sessions.
+func releaseSFTPTuning(sessionID string) {
+	sftpTuningMu.Lock()
+	defer sftpTuningMu.Unlock()
+	delete(sftpTunings, sessionID)
+}
+
+// GetSFTPServerInfo returns the SFTP transfer settings in use for a session
+func (a *App) GetSFTPServerInfo(sessionID string) (*SFTPServerInfo, error) {
+	a.ssh.sftpClientsMutex.RLock()
+	client, exists := a.ssh.sftpClients[sessionID]
+	a.ssh.sftpClientsMutex.RUnlock()
+	if !exists || client == nil {
+		return nil, newNotFoundError(ErrCategorySFTP, "GetSFTPServerInfo", "SFTP client not initialized for session %s", sessionID)
+	}
+
+	cfg := a.sessionSFTPConfig(sessionID)
+	info := &SFTPServerInfo{
+		SessionID:          sessionID,
+		Host:               a.sftpSessionHost(sessionID),
+		MaxPacketSize:      cfg.MaxPacketSize,
+		ConcurrentRequests: cfg.ConcurrentRequests,
+		AutoTune:           cfg.AutoTune && !a.sftpManualConcurrency(sessionID),
+		Capabilities:       sftpClientCapabilities(client),
+	}
+
+	sftpTuningMu.Lock()
+	defer sftpTuningMu.Unlock()
+	if tuning, exists := sftpTunings[sessionID]; exists && info.AutoTune {
+		tuningCopy := *tuning
+		info.Tuning = &tuningCopy
+		info.ConcurrentRequests = tuning.ConcurrentRequests
+	}
+	return info, nil
+}
+
+// copyFromRemoteFile copies a remote file of size bytes into dst, auto-tuned when enabled
+func (a *App) copyFromRemoteFile(sessionID string, dst io.Writer, src *sftp.File, size int64, buffer []byte) error {
+	if tuner := a.startSFTPAutoTune(sessionID, size); tuner != nil {
+		defer finishSFTPAutoTune(sessionID, tuner)
+		_, err := tunedDownload(dst, src, size, a.sessionSFTPConfig(sessionID).MaxPacketSize, tuner, func(limit int) {
+			updateSFTPTuningLimit(sessionID, limit)
+		})
+		return err
+	}
+	_, err := io.CopyBuffer(dst, src, buffer)
+	return err
+}
+
+// copyToRemoteFile copies src, size bytes long, into a remote file, auto-tuned when enabled
+func (a *App) copyToRemoteFile(sessionID string, dst *sftp.File, src io.Reader, size int64, buffer []byte) error {
+	if tuner := a.startSFTPAutoTune(sessionID, size); tuner != nil {
+		defer finishSFTPAutoTune(sessionID, tuner)
+		_, err := tunedUpload(dst, src, a.sessionSFTPConfig(sessionID).MaxPacketSize, tuner, func(limit int) {
+			updateSFTPTuningLimit(sessionID, limit)
+		})
+		return err
+	}
+	_, err := io.CopyBuffer(dst, src, buffer)
+	return err
+}
+
+// sftpChunkResult is one finished request of a tuned copy
+type sftpChunkResult struct {
+	off  int64
+	data []byte
+	n    int
+	took time.Duration
+	err  error
+}
+
+// tunedDownload copies size bytes from src to dst, reading packet-sized chunks with as many
+// requests in flight as the tuner allows. Chunks are written to dst in order.
+func tunedDownload(dst io.Writer, src io.ReaderAt, size int64, chunk int, tuner *sftpTuner, onLimit func(int)) (int64, error) {
+	results := make(chan sftpChunkResult)
+	pending := make(map[int64][]byte)
+	var next, written int64
+	inflight, lastLimit := 0, 0
+	var firstErr error
+
+	for {
+		limit := tuner.limit()
+		if limit != lastLimit && onLimit != nil {
+			onLimit(limit)
+		}
+		lastLimit = limit
+		// Bound the chunks held for reordering as well as the requests in flight
+		for firstErr == nil && next < size && inflight < limit && next-written < int64(2*limit*chunk) {
+			n := int64(chunk)
+			if size-next < n {
+				n = size - next
+			}
+			go func(off int64, buf []byte) {
+				start := time.Now()
+				read, err := src.ReadAt(buf, off)
+				if err == io.EOF && read == len(buf) {
+					err = nil
+				} else if err == nil && read < len(buf) {
+					err = io.ErrUnexpectedEOF
+				}
+				results <- sftpChunkResult{off: off, data: buf[:read], n: read, took: time.Since(start), err: err}
+			}(next, make([]byte, n))
+			next += n
+			inflight++
+		}
+		if inflight == 0 {
+			break
+		}
+
+		r := <-results
+		inflight--
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			continue
+		}
+		if firstErr != nil {
+			continue
+		}
+		tuner.observe(r.n, r.took, time.Now())
+		pending[r.off] = r.data
+		for data, ok := pending[written]; ok && firstErr == nil; data, ok = pending[written] {
+			delete(pending, written)
+			if _, err := dst.Write(data); err != nil {
+				firstErr = err
+				break
+			}
+			written += int64(len(data))
+		}
+	}
+	return written, firstErr
+}
+
+// tunedUpload copies src to dst in packet-sized writes with as many requests in flight as
+// the tuner allows
+func tunedUpload(dst io.WriterAt, src io.Reader, chunk int, tuner *sftpTuner, onLimit func(int)) (int64, error) {
+	results := make(chan sftpChunkResult)
+	var free [][]byte
+	var off, written int64
+	inflight, lastLimit := 0, 0
+	eof := false
+	var firstErr error
+
+	for {
+		limit := tuner.limit()
+		if limit != lastLimit && onLimit != nil {
+			onLimit(limit)
+		}
+		lastLimit = limit
+		for firstErr == nil && !eof && inflight < limit {
+			var buf []byte
+			if len(free) > 0 {
+				buf, free = free[len(free)-1], free[:len(free)-1]
+			} else {
+				buf = make([]byte, chunk)
+			}
+			n, err := io.ReadFull(src, buf)
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				eof = true
+			} else if err != nil {
+				firstErr = err
+			}
+			if n == 0 {
+				free = append(free, buf)
+				break
+			}
+			go func(off int64, buf []byte, n int) {
+				start := time.Now()
+				wrote, err := dst.WriteAt(buf[:n], off)
+				results <- sftpChunkResult{off: off, data: buf, n: wrote, took: time.Since(start), err: err}
+			}(off, buf, n)
+			off += int64(n)
+			inflight++
+		}
+		if inflight == 0 {
+			break
+		}
+
+		r := <-results
+		inflight--
+		free = append(free, r.data)
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			continue
+		}
+		written += int64(r.n)
+		tuner.observe(r.n, r.took, time.Now())
+	}
+	return written, firstErr
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// tuneToSettle feeds the tuner the rate a link model gives for each setting until it settles
+func tuneToSettle(t *testing.T, tuner *sftpTuner, rate func(concurrency int) float64) int {
+	t.Helper()
+	now := tuner.started
+	for i := 0; !tuner.settled; i++ {
+		if i > 20 {
+			t.Fatalf("tuner did not settle, at %d requests", tuner.current)
+		}
+		now = now.Add(time.Second)
+		tuner.decide(rate(tuner.current), now)
+	}
+	best, _, _ := tuner.result()
+	return best
+}
+
+func TestSFTPTunerConverges(t *testing.T) {
+	const chunk = 64 * 1024
+	const bandwidth = 16 * 1024 * 1024
+	for _, rtt := range []time.Duration{10 * time.Millisecond, 100 * time.Millisecond, 300 * time.Millisecond} {
+		// Throughput grows with requests in flight until the link is full
+		link := func(concurrency int) float64 {
+			rate := float64(concurrency*chunk) / rtt.Seconds()
+			if rate > bandwidth {
+				rate = bandwidth
+			}
+			return rate
+		}
+		best := 0.0
+		for c := MinSFTPConcurrentRequests; c <= MaxSFTPConcurrentRequests; c *= 2 {
+			if r := link(c); r > best {
+				best = r
+			}
+		}
+
+		tuner := newSFTPTuner(SFTPAutoTuneStartConcurrency, MinSFTPConcurrentRequests, MaxSFTPConcurrentRequests, time.Now())
+		chosen := tuneToSettle(t, tuner, link)
+		if got := link(chosen); got < best*0.85 {
+			t.Errorf("rtt %s: tuner chose %d requests for %.1f MB/s, best fixed setting gives %.1f MB/s",
+				rtt, chosen, got/(1024*1024), best/(1024*1024))
+		}
+	}
+}
+
+func TestSFTPTunerPrefersFewerRequestsWhenSaturated(t *testing.T) {
+	tuner := newSFTPTuner(SFTPAutoTuneStartConcurrency, MinSFTPConcurrentRequests, MaxSFTPConcurrentRequests, time.Now())
+	chosen := tuneToSettle(t, tuner, func(int) float64 { return 50 * 1024 * 1024 })
+	if chosen >= SFTPAutoTuneStartConcurrency {
+		t.Errorf("with a saturated link the tuner chose %d requests, want fewer than %d", chosen, SFTPAutoTuneStartConcurrency)
+	}
+}
+
+// delayedWriter delivers writes to w delay later, in order, at most bandwidth bytes per second
+// (0 = unlimited), simulating one direction of a network link
+type delayedWriter struct {
+	w         io.WriteCloser
+	delay     time.Duration
+	bandwidth float64
+	queue     chan delayedChunk
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type delayedChunk struct {
+	data []byte
+	at   time.Time
+}
+
+func newDelayedWriter(w io.WriteCloser, delay time.Duration, bandwidth float64) *delayedWriter {
+	d := &delayedWriter{w: w, delay: delay, bandwidth: bandwidth, queue: make(chan delayedChunk, 4096), done: make(chan struct{})}
+	go d.deliver()
+	return d
+}
+
+func (d *delayedWriter) Write(p []byte) (int, error) {
+	select {
+	case <-d.done:
+		return 0, io.ErrClosedPipe
+	case d.queue <- delayedChunk{data: append([]byte(nil), p...), at: time.Now().Add(d.delay)}:
+		return len(p), nil
+	}
+}
+
+func (d *delayedWriter) Close() error {
+	d.closeOnce.Do(func() { close(d.done) })
+	return nil
+}
+
+func (d *delayedWriter) deliver() {
+	defer d.w.Close()
+	var free time.Time // When the simulated link finishes sending what it already has
+	for {
+		select {
+		case <-d.done:
+			return
+		case chunk := <-d.queue:
+			send := chunk.at
+			if d.bandwidth > 0 {
+				if free.After(send) {
+					send = free
+				}
+				free = send.Add(time.Duration(float64(len(chunk.data)) / d.bandwidth * float64(time.Second)))
+				send = free
+			}
+			time.Sleep(time.Until(send))
+			if _, err := d.w.Write(chunk.data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// serverConn joins the two pipe ends the SFTP server reads and writes
+type serverConn struct {
+	io.Reader
+	io.WriteCloser
+}
+
+// newLatencySFTPClient connects a client to the in-memory SFTP test server over a link with the
+// given round trip and upload bandwidth
+func newLatencySFTPClient(t testing.TB, rtt time.Duration, bandwidth float64) *sftp.Client {
+	t.Helper()
+	toServerR, toServerW := io.Pipe()
+	toClientR, toClientW := io.Pipe()
+
+	clientOut := newDelayedWriter(toServerW, rtt/2, bandwidth)
+	serverOut := newDelayedWriter(toClientW, rtt/2, 0)
+
+	server := sftp.NewRequestServer(serverConn{toServerR, serverOut}, sftp.InMemHandler())
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(toClientR, clientOut, sftp.MaxPacketUnchecked(DefaultSFTPMaxPacketSize))
+	if err != nil {
+		t.Fatalf("failed to start SFTP client: %v", err)
+	}
+	t.Cleanup(func() {
+		// Dropping the link first lets both ends see EOF instead of waiting on each other
+		clientOut.Close()
+		serverOut.Close()
+		client.Close()
+		server.Close()
+	})
+	return client
+}
+
+func TestTunedCopyRoundTrip(t *testing.T) {
+	client := newLatencySFTPClient(t, 0, 0)
+
+	payload := make([]byte, 3*DefaultSFTPMaxPacketSize+1234)
+	rand.Read(payload)
+
+	remote, err := client.Create("/roundtrip")
+	if err != nil {
+		t.Fatalf("Create() returned error: %v", err)
+	}
+	tuner := newSFTPTuner(4, MinSFTPConcurrentRequests, MaxSFTPConcurrentRequests, time.Now())
+	written, err := tunedUpload(remote, bytes.NewReader(payload), DefaultSFTPMaxPacketSize, tuner, nil)
+	remote.Close()
+	if err != nil || written != int64(len(payload)) {
+		t.Fatalf("tunedUpload() = %d, %v, want %d bytes", written, err, len(payload))
+	}
+
+	remote, err = client.Open("/roundtrip")
+	if err != nil {
+		t.Fatalf("Open() returned error: %v", err)
+	}
+	defer remote.Close()
+	var got bytes.Buffer
+	read, err := tunedDownload(&got, remote, int64(len(payload)), DefaultSFTPMaxPacketSize, tuner, nil)
+	if err != nil || read != int64(len(payload)) {
+		t.Fatalf("tunedDownload() = %d, %v, want %d bytes", read, err, len(payload))
+	}
+	if !bytes.Equal(got.Bytes(), payload) {
+		t.Fatal("downloaded data differs from what was uploaded")
+	}
+}
+
+// zeroReader reads zeros until stop returns true
+type zeroReader struct{ stop func() bool }
+
+func (z zeroReader) Read(p []byte) (int, error) {
+	if z.stop() {
+		return 0, io.EOF
+	}
+	clear(p)
+	return len(p), nil
+}
+
+// measureUpload uploads zeros with the tuner's settings until stop returns true and returns bytes per second
+func measureUpload(b *testing.B, client *sftp.Client, name string, tuner *sftpTuner, stop func() bool) float64 {
+	b.Helper()
+	remote, err := client.Create(name)
+	if err != nil {
+		b.Fatalf("Create() returned error: %v", err)
+	}
+	defer client.Remove(name)
+	defer remote.Close()
+
+	start := time.Now()
+	written, err := tunedUpload(remote, zeroReader{stop: stop}, DefaultSFTPMaxPacketSize, tuner, nil)
+	if err != nil {
+		b.Fatalf("tunedUpload() returned error: %v", err)
+	}
+	return float64(written) / time.Since(start).Seconds()
+}
+
+// BenchmarkSFTPAutoTune checks the tuner against every fixed setting over simulated links.
+// It takes about a minute: go test -run '^$' -bench SFTPAutoTune -benchtime 1x
+func BenchmarkSFTPAutoTune(b *testing.B) {
+	const bandwidth = 16 * 1024 * 1024
+	for _, rtt := range []time.Duration{10 * time.Millisecond, 100 * time.Millisecond, 300 * time.Millisecond} {
+		b.Run(fmt.Sprintf("rtt=%s", rtt), func(b *testing.B) {
+			client := newLatencySFTPClient(b, rtt, bandwidth)
+			window := 8 * rtt
+			if window < time.Second {
+				window = time.Second
+			}
+			lasting := func(d time.Duration) func() bool {
+				deadline := time.Now().Add(d)
+				return func() bool { return time.Now().After(deadline) }
+			}
+
+			for i := 0; i < b.N; i++ {
+				best, bestAt := 0.0, 0
+				for c := MinSFTPConcurrentRequests; c <= MaxSFTPConcurrentRequests; c *= 2 {
+					fixed := newSFTPTuner(c, c, c, time.Now())
+					fixed.settled = true
+					if rate := measureUpload(b, client, "/fixed", fixed, lasting(window)); rate > best {
+						best, bestAt = rate, c
+					}
+				}
+
+				tuner := newSFTPTuner(SFTPAutoTuneStartConcurrency, MinSFTPConcurrentRequests, MaxSFTPConcurrentRequests, time.Now())
+				measureUpload(b, client, "/tuning", tuner, func() bool {
+					_, _, settled := tuner.result()
+					return settled
+				})
+				chosen, _, settled := tuner.result()
+
+				fixed := newSFTPTuner(chosen, chosen, chosen, time.Now())
+				fixed.settled = true
+				rate := measureUpload(b, client, "/tuned", fixed, lasting(window))
+
+				b.ReportMetric(float64(chosen), "requests")
+				b.ReportMetric(rate/best, "tuned/best")
+				if !settled || rate < best*0.85 {
+					b.Errorf("tuner chose %d requests (settled=%v) for %.1f MB/s; best fixed is %d requests at %.1f MB/s",
+						chosen, settled, rate/(1024*1024), bestAt, best/(1024*1024))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// SFTP benchmark constants
+const (
+	SFTPBenchmarkFileSize    = 4 * 1024 * 1024  // Bytes uploaded and downloaded at each setting
+	SFTPBenchmarkMaxDuration = 60 * time.Second // Remaining settings are skipped after this, keeping the best seen
+	sftpBenchmarkPrefix      = ".thermic-sftp-benchmark-"
+)
+
+// Settings tried by BenchmarkSFTPTransfer. Every packet size is tried with every request count;
+// the parallel transfer counts are then tried with the best of those.
+var (
+	sftpBenchmarkPacketSizes = []int{32 * 1024, 128 * 1024, 256 * 1024}
+	sftpBenchmarkConcurrency = []int{8, 32, 64}
+	sftpBenchmarkParallel    = []int{1, 2, 4}
+)
+
+var (
+	// sftpBenchmarksRunning holds the sessions with a benchmark in progress
+	sftpBenchmarksRunning   = make(map[string]bool)
+	sftpBenchmarksRunningMu sync.Mutex
+)
+
+// sftpClientFactory opens an SFTP client with the given packet size and requests per file
+type sftpClientFactory func(maxPacketSize, concurrentRequests int) (*sftp.Client, error)
+
+// benchmarkSFTPRoundTrip uploads SFTPBenchmarkFileSize bytes split over files scratch files at
+// once, downloads them again and returns the bytes per second of both directions together
+func benchmarkSFTPRoundTrip(client *sftp.Client, name string, files int) (float64, error) {
+	payload := make([]byte, SFTPBenchmarkFileSize/files)
+	paths := make([]string, files)
+	errs := make([]error, files)
+	defer func() {
+		for _, remotePath := range paths {
+			if remotePath != "" {
+				client.Remove(remotePath)
+			}
+		}
+	}()
+
+	run := func(step func(i int) error) {
+		var wg sync.WaitGroup
+		for i := 0; i < files; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = step(i)
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	start := time.Now()
+	run(func(i int) error {
+		file, remotePath, err := createSFTPScratchFile(client, fmt.Sprintf("%s%s-%d", sftpBenchmarkPrefix, name, i))
+		if err != nil {
+			return err
+		}
+		paths[i] = remotePath
+		_, err = io.Copy(file, bytes.NewReader(payload))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	})
+	run(func(i int) error {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		file, err := client.Open(paths[i])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		n, err := file.WriteTo(io.Discard)
+		if err == nil && n != int64(len(payload)) {
+			err = fmt.Errorf("downloaded %d of %d bytes", n, len(payload))
+		}
+		return err
+	})
+	elapsed := time.Since(start)
+
+	for _, err := range errs {
+		if err != nil {
+			return 0, err
+		}
+	}
+	return float64(2*len(payload)*files) / elapsed.Seconds(), nil
+}
+
+// benchmarkSFTPSettings measures a round trip at each packet size and request count, then at
+// each parallel transfer count with the fastest of those. Fewer parallel transfers are kept
+// unless more gain SFTPAutoTuneGain. Stops trying new settings after maxDuration.
+func benchmarkSFTPSettings(newClient sftpClientFactory, name string, maxDuration time.Duration) (SFTPTuning, error) {
+	deadline := time.Now().Add(maxDuration)
+	var best SFTPTuning
+	var bestRate float64
+	var lastErr error
+
+	for _, packetSize := range sftpBenchmarkPacketSizes {
+		for _, requests := range sftpBenchmarkConcurrency {
+			if bestRate > 0 && time.Now().After(deadline) {
+				break
+			}
+			client, err := newClient(packetSize, requests)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			rate, err := benchmarkSFTPRoundTrip(client, name, 1)
+			client.Close()
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			if rate > bestRate {
+				bestRate = rate
+				best = SFTPTuning{MaxPacketSize: packetSize, ConcurrentRequests: requests, ParallelTransfers: 1}
+			}
+		}
+	}
+	if bestRate == 0 {
+		return SFTPTuning{}, fmt.Errorf("SFTP benchmark failed at every setting: %w", lastErr)
+	}
+
+	client, err := newClient(best.MaxPacketSize, best.ConcurrentRequests)
+	if err == nil {
+		defer client.Close()
+		parallelRate := bestRate
+		for _, files := range sftpBenchmarkParallel {
+			if files <= best.ParallelTransfers || time.Now().After(deadline) {
+				continue
+			}
+			rate, err := benchmarkSFTPRoundTrip(client, name, files)
+			if err != nil {
+				break
+			}
+			if rate > parallelRate*(1+SFTPAutoTuneGain) {
+				parallelRate = rate
+				best.ParallelTransfers = files
+			}
+		}
+		bestRate = parallelRate
+	}
+
+	best.BytesPerSec = int64(bestRate)
+	best.Settled = true
+	best.Updated = time.Now()
+	return best, nil
+}
+
+// BenchmarkSFTPTransfer uploads and downloads a few MB at several packet sizes, request counts
+// and parallel transfer counts over a session's SSH connection and returns the fastest setting.
+// The test files are removed afterwards. Nothing is changed; see ApplySFTPTuning.
+func (a *App) BenchmarkSFTPTransfer(sessionID string) (SFTPTuning, error) {
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+	if !exists || sshSession == nil {
+		return SFTPTuning{}, newNotFoundError(ErrCategorySFTP, "BenchmarkSFTPTransfer", "SSH session %s not found", sessionID)
+	}
+	if sshSession.client == nil {
+		return SFTPTuning{}, fmt.Errorf("SSH session %s is not connected", sessionID)
+	}
+
+	sftpBenchmarksRunningMu.Lock()
+	if sftpBenchmarksRunning[sessionID] {
+		sftpBenchmarksRunningMu.Unlock()
+		return SFTPTuning{}, fmt.Errorf("an SFTP benchmark is already running for session %s", sessionID)
+	}
+	sftpBenchmarksRunning[sessionID] = true
+	sftpBenchmarksRunningMu.Unlock()
+	defer func() {
+		sftpBenchmarksRunningMu.Lock()
+		delete(sftpBenchmarksRunning, sessionID)
+		sftpBenchmarksRunningMu.Unlock()
+	}()
+
+	cfg := a.sessionSFTPConfig(sessionID)
+	newClient := func(maxPacketSize, concurrentRequests int) (*sftp.Client, error) {
+		cfg.MaxPacketSize = maxPacketSize
+		cfg.ConcurrentRequests = concurrentRequests
+		return sftp.NewClient(sshSession.client, sftpClientOptions(cfg)...)
+	}
+
+	tuning, err := benchmarkSFTPSettings(newClient, sessionID, SFTPBenchmarkMaxDuration)
+	if err != nil {
+		return SFTPTuning{}, err
+	}
+	tuning.Host = a.sftpSessionHost(sessionID)
+	fmt.Printf("SFTP benchmark for session %s (%s): packet %dKB, %d requests, %d parallel, %.1f MB/s\n",
+		sessionID, tuning.Host, tuning.MaxPacketSize/1024, tuning.ConcurrentRequests, tuning.ParallelTransfers,
+		float64(tuning.BytesPerSec)/(1024*1024))
+	return tuning, nil
+}
+
+// ApplySFTPTuning makes a benchmark's setting the session's SFTP override (see
+// SetSessionSFTPConfig). The packet size and request count take effect with the next SFTP client.
+func (a *App) ApplySFTPTuning(sessionID string, tuning SFTPTuning) error {
+	return a.SetSessionSFTPConfig(sessionID, SFTPConfig{
+		MaxPacketSize:      tuning.MaxPacketSize,
+		ConcurrentRequests: tuning.ConcurrentRequests,
+		ParallelTransfers:  tuning.ParallelTransfers,
+	})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+func TestBenchmarkSFTPSettings(t *testing.T) {
+	tried := make(map[[2]int]bool)
+	newClient := func(maxPacketSize, concurrentRequests int) (*sftp.Client, error) {
+		tried[[2]int{maxPacketSize, concurrentRequests}] = true
+		toServerR, toServerW := io.Pipe()
+		toClientR, toClientW := io.Pipe()
+		server := sftp.NewRequestServer(serverConn{toServerR, toClientW}, sftp.InMemHandler())
+		// Once the server stops, for a closed client or a packet it rejects, the link goes down
+		go func() {
+			server.Serve()
+			toServerR.Close()
+			toClientW.Close()
+		}()
+		return sftp.NewClientPipe(toClientR, toServerW, sftp.MaxPacketUnchecked(maxPacketSize),
+			sftp.MaxConcurrentRequestsPerFile(concurrentRequests), sftp.UseConcurrentWrites(true), sftp.UseConcurrentReads(true))
+	}
+
+	tuning, err := benchmarkSFTPSettings(newClient, "session_benchmark", time.Minute)
+	if err != nil {
+		t.Fatalf("benchmarkSFTPSettings() returned error: %v", err)
+	}
+	if len(tried) != len(sftpBenchmarkPacketSizes)*len(sftpBenchmarkConcurrency) {
+		t.Errorf("tried %d settings, want every packet size with every request count", len(tried))
+	}
+	if !tried[[2]int{tuning.MaxPacketSize, tuning.ConcurrentRequests}] || tuning.ParallelTransfers < 1 || tuning.BytesPerSec <= 0 || !tuning.Settled {
+		t.Errorf("tuning = %+v, want one of the tried settings with a measurement", tuning)
+	}
+	if err := validateSessionSFTPConfig(SFTPConfig{MaxPacketSize: tuning.MaxPacketSize, ConcurrentRequests: tuning.ConcurrentRequests, ParallelTransfers: tuning.ParallelTransfers}); err != nil {
+		t.Errorf("recommended setting can't be applied: %v", err)
+	}
+
+	// A deadline in the past still measures one setting
+	tried = make(map[[2]int]bool)
+	if _, err := benchmarkSFTPSettings(newClient, "session_benchmark", -time.Second); err != nil {
+		t.Fatalf("benchmarkSFTPSettings() past its deadline returned error: %v", err)
+	}
+	if len(tried) != 1 {
+		t.Errorf("tried %d settings past the deadline, want 1", len(tried))
+	}
+}
+
+func TestBenchmarkSFTPTransferWithoutSession(t *testing.T) {
+	app := NewApp()
+	if _, err := app.BenchmarkSFTPTransfer("session_missing"); toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("BenchmarkSFTPTransfer() without a session = %v, want not found", err)
+	}
+}
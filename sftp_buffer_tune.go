@@ -0,0 +1,208 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// SFTP buffer auto-tune constants
+const (
+	SFTPBufferBenchmarkSize   = 1024 * 1024             // Bytes uploaded by the benchmark
+	SFTPBufferRetuneChange    = 0.20                    // Relative change in throughput that re-tunes the buffer
+	SFTPBufferRetuneWindow    = 5                       // Transfers averaged before comparing with the tuned throughput
+	SFTPBufferSampleMinSize   = SFTPBufferBenchmarkSize // Smallest transfer fed into the re-tune window
+	sftpBufferBenchmarkPrefix = ".thermic-buffer-benchmark-"
+)
+
+// TuneResult is the transfer buffer size picked for a session from measured throughput
+type TuneResult struct {
+	SessionID          string    `json:"sessionId"`
+	MeasuredMBps       float64   `json:"measuredMBps"`
+	SelectedBufferSize int       `json:"selectedBufferSize"`
+	Source             string    `json:"source"`  // "benchmark", or "transfers" after a re-tune
+	Retunes            int       `json:"retunes"` // Times transfers moved the throughput by more than 20%
+	Measured           time.Time `json:"measured"`
+}
+
+// sftpBufferTune is a session's tune result and the transfer rates seen since
+type sftpBufferTune struct {
+	result TuneResult
+	window []float64 // Bytes per second of recent large transfers
+}
+
+// sftpBufferSize picks a buffer holding a tenth of a second of transfer, capped at sixteen
+// packets and kept within the buffer size setting's range
+func sftpBufferSize(bytesPerSec float64, maxPacketSize int) int {
+	size := int(bytesPerSec / 10)
+	if limit := maxPacketSize * 16; size > limit {
+		size = limit
+	}
+	if size < MinSFTPBufferSize {
+		size = MinSFTPBufferSize
+	}
+	if size > MaxSFTPBufferSize {
+		size = MaxSFTPBufferSize
+	}
+	return size
+}
+
+// createSFTPScratchFile creates a new file named prefix plus a random suffix in /tmp, or the
+// login directory when /tmp isn't writable, and returns it with its path. O_EXCL keeps it from
+// writing through a file or symlink someone else planted in the shared directory.
+func createSFTPScratchFile(client *sftp.Client, prefix string) (*sftp.File, string, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, "", fmt.Errorf("failed to name benchmark file: %w", err)
+	}
+	name := prefix + "-" + hex.EncodeToString(suffix)
+
+	dirs := []string{"/tmp"}
+	if wd, err := client.Getwd(); err == nil {
+		dirs = append(dirs, wd)
+	}
+
+	var lastErr error
+	for _, dir := range dirs {
+		remotePath := path.Join(dir, name)
+		file, err := client.OpenFile(remotePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return file, remotePath, nil
+	}
+	return nil, "", fmt.Errorf("no writable directory for the benchmark: %w", lastErr)
+}
+
+// benchmarkSFTPUpload uploads SFTPBufferBenchmarkSize zero bytes to a scratch file and returns
+// the bytes per second achieved
+func benchmarkSFTPUpload(client *sftp.Client, sessionID string) (float64, error) {
+	file, remotePath, err := createSFTPScratchFile(client, sftpBufferBenchmarkPrefix+sessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	_, err = file.Write(make([]byte, SFTPBufferBenchmarkSize))
+	closeErr := file.Close()
+	elapsed := time.Since(start)
+	client.Remove(remotePath)
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("benchmark upload to %s failed: %w", remotePath, err)
+	}
+	return float64(SFTPBufferBenchmarkSize) / elapsed.Seconds(), nil
+}
+
+// tuneSFTPBuffer benchmarks a session's new SFTP client and stores the buffer size it picks
+func (a *App) tuneSFTPBuffer(sessionID string, client *sftp.Client) {
+	bytesPerSec, err := benchmarkSFTPUpload(client, sessionID)
+	if err != nil {
+		fmt.Printf("SFTP buffer auto-tune for session %s skipped: %v\n", sessionID, err)
+		return
+	}
+
+	result := TuneResult{
+		SessionID:          sessionID,
+		MeasuredMBps:       bytesPerSec / (1024 * 1024),
+		SelectedBufferSize: sftpBufferSize(bytesPerSec, a.sessionSFTPConfig(sessionID).MaxPacketSize),
+		Source:             "benchmark",
+		Measured:           time.Now(),
+	}
+
+	a.ssh.sftpBufferMutex.Lock()
+	a.ssh.sftpBufferSizes[sessionID] = &sftpBufferTune{result: result}
+	a.ssh.sftpBufferMutex.Unlock()
+	fmt.Printf("SFTP buffer auto-tune for session %s: %.1f MB/s, buffer %dKB\n", sessionID, result.MeasuredMBps, result.SelectedBufferSize/1024)
+}
+
+// recordSFTPTransferRate feeds a finished transfer into the session's sliding window. Once
+// SFTPBufferRetuneWindow large transfers average more than SFTPBufferRetuneChange away from
+// the tuned throughput, the buffer is re-sized from that average.
+func (a *App) recordSFTPTransferRate(sessionID string, bytes int64, elapsed time.Duration) {
+	if bytes < SFTPBufferSampleMinSize || elapsed <= 0 {
+		return
+	}
+	rate := float64(bytes) / elapsed.Seconds()
+	maxPacketSize := a.sessionSFTPConfig(sessionID).MaxPacketSize
+
+	a.ssh.sftpBufferMutex.Lock()
+	defer a.ssh.sftpBufferMutex.Unlock()
+
+	tune, exists := a.ssh.sftpBufferSizes[sessionID]
+	if !exists {
+		return
+	}
+	tune.window = append(tune.window, rate)
+	if len(tune.window) > SFTPBufferRetuneWindow {
+		tune.window = tune.window[1:]
+	}
+	if len(tune.window) < SFTPBufferRetuneWindow {
+		return
+	}
+
+	var sum float64
+	for _, r := range tune.window {
+		sum += r
+	}
+	average := sum / float64(len(tune.window))
+	tuned := tune.result.MeasuredMBps * 1024 * 1024
+	if tuned > 0 && average > tuned*(1-SFTPBufferRetuneChange) && average < tuned*(1+SFTPBufferRetuneChange) {
+		return
+	}
+
+	tune.result.MeasuredMBps = average / (1024 * 1024)
+	tune.result.SelectedBufferSize = sftpBufferSize(average, maxPacketSize)
+	tune.result.Source = "transfers"
+	tune.result.Retunes++
+	tune.result.Measured = time.Now()
+	tune.window = nil
+	fmt.Printf("SFTP buffer re-tuned for session %s: %.1f MB/s, buffer %dKB\n", sessionID, tune.result.MeasuredMBps, tune.result.SelectedBufferSize/1024)
+}
+
+// sessionSFTPConfig returns the SFTP settings for a session's transfers: getSFTPConfig with
+// the session's override applied, and the tuned buffer size when AutoTuneBuffer is on, the
+// benchmark has run and the override doesn't set the buffer size by hand
+func (a *App) sessionSFTPConfig(sessionID string) SFTPConfig {
+	cfg := a.getSFTPConfig()
+	override, exists := a.sessionSFTPOverride(sessionID)
+	if exists {
+		cfg = applySessionSFTPConfig(cfg, override)
+	}
+	if !cfg.AutoTuneBuffer || override.BufferSize != 0 {
+		return cfg
+	}
+	a.ssh.sftpBufferMutex.Lock()
+	defer a.ssh.sftpBufferMutex.Unlock()
+	if tune, exists := a.ssh.sftpBufferSizes[sessionID]; exists {
+		cfg.BufferSize = tune.result.SelectedBufferSize
+	}
+	return cfg
+}
+
+// releaseSFTPBufferTune forgets a session's tuned buffer size
+func (a *App) releaseSFTPBufferTune(sessionID string) {
+	a.ssh.sftpBufferMutex.Lock()
+	defer a.ssh.sftpBufferMutex.Unlock()
+	delete(a.ssh.sftpBufferSizes, sessionID)
+}
+
+// GetSFTPBufferTuneResult returns the buffer size auto-tuning picked for a session
+func (a *App) GetSFTPBufferTuneResult(sessionID string) (TuneResult, error) {
+	a.ssh.sftpBufferMutex.Lock()
+	defer a.ssh.sftpBufferMutex.Unlock()
+
+	tune, exists := a.ssh.sftpBufferSizes[sessionID]
+	if !exists {
+		return TuneResult{}, newNotFoundError(ErrCategorySFTP, "GetSFTPBufferTuneResult", "no buffer tune result for session %s", sessionID)
+	}
+	return tune.result, nil
+}
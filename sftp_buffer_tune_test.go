@@ -0,0 +1,131 @@
+package main
+
+import (
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSFTPBufferSize(t *testing.T) {
+	cases := []struct {
+		name          string
+		bytesPerSec   float64
+		maxPacketSize int
+		want          int
+	}{
+		{"slow link gets the minimum", 100 * 1024, 256 * 1024, MinSFTPBufferSize},
+		{"tenth of a second of transfer", 10 * 1024 * 1024, 256 * 1024, 1024 * 1024},
+		{"capped at sixteen packets", 500 * 1024 * 1024, 32 * 1024, 512 * 1024},
+		{"capped at the setting maximum", 1024 * 1024 * 1024, 2 * 1024 * 1024, MaxSFTPBufferSize},
+	}
+	for _, tc := range cases {
+		if got := sftpBufferSize(tc.bytesPerSec, tc.maxPacketSize); got != tc.want {
+			t.Errorf("%s: sftpBufferSize(%.0f, %d) = %d, want %d", tc.name, tc.bytesPerSec, tc.maxPacketSize, got, tc.want)
+		}
+	}
+}
+
+func TestTuneSFTPBuffer(t *testing.T) {
+	app := NewApp()
+	app.config.config.SFTP.AutoTuneBuffer = true
+	sessionID := "session_buffer_tune"
+	client := newLatencySFTPClient(t, 0, 0)
+
+	if _, err := app.GetSFTPBufferTuneResult(sessionID); toThermicError(err).Code != ErrCodeNotFound {
+		t.Fatalf("GetSFTPBufferTuneResult() before tuning = %v, want not found", err)
+	}
+
+	// The in-memory server has no /tmp, so the benchmark falls back to the login directory
+	app.tuneSFTPBuffer(sessionID, client)
+	result, err := app.GetSFTPBufferTuneResult(sessionID)
+	if err != nil {
+		t.Fatalf("GetSFTPBufferTuneResult() returned error: %v", err)
+	}
+	if result.MeasuredMBps <= 0 || result.Source != "benchmark" {
+		t.Errorf("result = %+v, want a benchmark measurement", result)
+	}
+	if got := app.sessionSFTPConfig(sessionID).BufferSize; got != result.SelectedBufferSize {
+		t.Errorf("sessionSFTPConfig().BufferSize = %d, want %d", got, result.SelectedBufferSize)
+	}
+	if entries, err := client.ReadDir("/"); err != nil || len(entries) != 0 {
+		t.Errorf("benchmark left files behind: %v, %v", entries, err)
+	}
+
+	app.config.config.SFTP.AutoTuneBuffer = false
+	if got := app.sessionSFTPConfig(sessionID).BufferSize; got != app.getSFTPConfig().BufferSize {
+		t.Errorf("sessionSFTPConfig().BufferSize with auto-tune off = %d, want the setting", got)
+	}
+}
+
+func TestRecordSFTPTransferRateRetunes(t *testing.T) {
+	app := NewApp()
+	tuned := func(sessionID string) func(mbps float64) {
+		app.ssh.sftpBufferSizes[sessionID] = &sftpBufferTune{result: TuneResult{
+			SessionID:          sessionID,
+			MeasuredMBps:       10,
+			SelectedBufferSize: 1024 * 1024,
+			Source:             "benchmark",
+		}}
+		return func(mbps float64) {
+			app.recordSFTPTransferRate(sessionID, 10*1024*1024, time.Duration(float64(10*time.Second)/mbps))
+		}
+	}
+
+	// Within 20% of the measured throughput, and small transfers, change nothing
+	steady := tuned("session_buffer_steady")
+	for i := 0; i < 2*SFTPBufferRetuneWindow; i++ {
+		steady(11)
+		app.recordSFTPTransferRate("session_buffer_steady", 1024, time.Millisecond)
+	}
+	if result, _ := app.GetSFTPBufferTuneResult("session_buffer_steady"); result.Retunes != 0 {
+		t.Fatalf("result = %+v, want no re-tune within 20%%", result)
+	}
+
+	// A full window at 20 MB/s re-tunes from the window average
+	faster := tuned("session_buffer_faster")
+	for i := 0; i < SFTPBufferRetuneWindow; i++ {
+		if result, _ := app.GetSFTPBufferTuneResult("session_buffer_faster"); result.Retunes != 0 {
+			t.Fatalf("re-tuned after %d transfers, want a full window", i)
+		}
+		faster(20)
+	}
+	result, _ := app.GetSFTPBufferTuneResult("session_buffer_faster")
+	if result.Retunes != 1 || result.Source != "transfers" {
+		t.Fatalf("result = %+v, want one re-tune from transfers", result)
+	}
+	if result.MeasuredMBps < 19 || result.MeasuredMBps > 21 {
+		t.Errorf("MeasuredMBps = %.1f, want about 20", result.MeasuredMBps)
+	}
+	if want := sftpBufferSize(result.MeasuredMBps*1024*1024, app.getSFTPConfig().MaxPacketSize); result.SelectedBufferSize != want {
+		t.Errorf("SelectedBufferSize = %d, want %d", result.SelectedBufferSize, want)
+	}
+
+	app.releaseSFTPBufferTune("session_buffer_faster")
+	if _, err := app.GetSFTPBufferTuneResult("session_buffer_faster"); err == nil {
+		t.Error("GetSFTPBufferTuneResult() after release returned no error")
+	}
+}
+
+func TestCreateSFTPScratchFileNamesAreUnpredictable(t *testing.T) {
+	client := newLatencySFTPClient(t, 0, 0)
+
+	paths := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		file, remotePath, err := createSFTPScratchFile(client, sftpBufferBenchmarkPrefix+"session_scratch")
+		if err != nil {
+			t.Fatalf("createSFTPScratchFile() returned error: %v", err)
+		}
+		file.Close()
+		defer client.Remove(remotePath)
+
+		name := path.Base(remotePath)
+		if !strings.HasPrefix(name, sftpBufferBenchmarkPrefix+"session_scratch-") || len(name) <= len(sftpBufferBenchmarkPrefix+"session_scratch-") {
+			t.Errorf("scratch file %s, want the prefix and a random suffix", remotePath)
+		}
+		paths[remotePath] = true
+	}
+	if len(paths) != 2 {
+		t.Errorf("two scratch files got the same path: %v", paths)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/pkg/sftp"
+)
+
+// SFTP protocol extensions Thermic knows how to use
+const (
+	SFTPExtPosixRename = "posix-rename@openssh.com" // Rename that replaces an existing target atomically
+	SFTPExtHardlink    = "hardlink@openssh.com"
+	SFTPExtStatVFS     = "statvfs@openssh.com" // Filesystem size and free space for a path
+	SFTPExtFStatVFS    = "fstatvfs@openssh.com"
+	SFTPExtFsync       = "fsync@openssh.com"
+	SFTPExtLSetStat    = "lsetstat@openssh.com"
+	SFTPExtLimits      = "limits@openssh.com"
+	SFTPExtExpandPath  = "expand-path@openssh.com"
+	SFTPExtCopyData    = "copy-data" // Server-side copy
+)
+
+// sftpKnownExtensions are reported by GetSFTPCapabilities, supported or not. The client
+// library only answers for names it is asked about, so unknown extensions aren't listed.
+var sftpKnownExtensions = []string{
+	SFTPExtPosixRename,
+	SFTPExtHardlink,
+	SFTPExtStatVFS,
+	SFTPExtFStatVFS,
+	SFTPExtFsync,
+	SFTPExtLSetStat,
+	SFTPExtLimits,
+	SFTPExtExpandPath,
+	SFTPExtCopyData,
+}
+
+// sftpClientCapabilities reports which known extensions the server advertised in its version packet
+func sftpClientCapabilities(client *sftp.Client) map[string]bool {
+	capabilities := make(map[string]bool, len(sftpKnownExtensions))
+	for _, name := range sftpKnownExtensions {
+		_, supported := client.HasExtension(name)
+		capabilities[name] = supported
+	}
+	return capabilities
+}
+
+// GetSFTPCapabilities returns the SFTP extensions the session's server supports, keyed by
+// extension name, so features such as server-side rename or filesystem stats can be offered
+// only where they work
+func (a *App) GetSFTPCapabilities(sessionID string) (map[string]bool, error) {
+	a.ssh.sftpClientsMutex.RLock()
+	client, exists := a.ssh.sftpClients[sessionID]
+	a.ssh.sftpClientsMutex.RUnlock()
+	if !exists || client == nil {
+		return nil, newNotFoundError(ErrCategorySFTP, "GetSFTPCapabilities", "SFTP client not initialized for session %s", sessionID)
+	}
+	return sftpClientCapabilities(client), nil
+}
+
+// statVFSUsagePercent returns the used share of a filesystem the way df computes its
+// Capacity column: used / (used + available to unprivileged users), rounded up
+func statVFSUsagePercent(stat *sftp.StatVFS) (float64, error) {
+	if stat.Blocks == 0 || stat.Bfree > stat.Blocks {
+		return 0, fmt.Errorf("filesystem reports no usable size")
+	}
+	used := stat.Blocks - stat.Bfree
+	if used+stat.Bavail == 0 {
+		return 0, fmt.Errorf("filesystem reports no usable size")
+	}
+	return math.Ceil(float64(used) * 100 / float64(used+stat.Bavail)), nil
+}
+
+// statVFSClient returns the session's SFTP client when its server supports statvfs@openssh.com
+func (a *App) statVFSClient(sessionID string) (*sftp.Client, bool) {
+	a.ssh.sftpClientsMutex.RLock()
+	client, exists := a.ssh.sftpClients[sessionID]
+	a.ssh.sftpClientsMutex.RUnlock()
+	if !exists || client == nil {
+		return nil, false
+	}
+	if _, supported := client.HasExtension(SFTPExtStatVFS); !supported {
+		return nil, false
+	}
+	return client, true
+}
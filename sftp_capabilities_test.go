@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+func TestGetSFTPCapabilities(t *testing.T) {
+	app := NewApp()
+	if _, err := app.GetSFTPCapabilities("missing"); err == nil || toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("GetSFTPCapabilities(missing) error = %v, want not found", err)
+	}
+
+	sessionID := "session_sftp_capabilities"
+	app.ssh.sftpClientsMutex.Lock()
+	app.ssh.sftpClients[sessionID] = newLatencySFTPClient(t, 0, 0)
+	app.ssh.sftpClientsMutex.Unlock()
+
+	capabilities, err := app.GetSFTPCapabilities(sessionID)
+	if err != nil {
+		t.Fatalf("GetSFTPCapabilities() returned error: %v", err)
+	}
+	if len(capabilities) != len(sftpKnownExtensions) {
+		t.Errorf("got %d capabilities, want one per known extension: %v", len(capabilities), capabilities)
+	}
+	// The in-memory test server advertises the same extensions as pkg/sftp's server
+	for name, want := range map[string]bool{SFTPExtPosixRename: true, SFTPExtStatVFS: true, SFTPExtHardlink: true, SFTPExtCopyData: false} {
+		if capabilities[name] != want {
+			t.Errorf("capabilities[%s] = %v, want %v", name, capabilities[name], want)
+		}
+	}
+}
+
+func TestStatVFSUsagePercent(t *testing.T) {
+	// 1000 blocks, 200 free of which 150 are available to users: df reports ceil(800/950)
+	percent, err := statVFSUsagePercent(&sftp.StatVFS{Frsize: 4096, Blocks: 1000, Bfree: 200, Bavail: 150})
+	if err != nil || percent != 85 {
+		t.Errorf("statVFSUsagePercent() = %v, %v, want 85", percent, err)
+	}
+	if _, err := statVFSUsagePercent(&sftp.StatVFS{}); err == nil {
+		t.Error("expected an error for an empty filesystem")
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Remote path completion constants
+const (
+	PathCompletionCacheTTL     = 10 * time.Second
+	DefaultPathCompletionLimit = 50
+	RemotePathCompletionsEvent = "sftp-path-completions"
+
+	RemotePathTypeFile     = "file"
+	RemotePathTypeDir      = "dir"
+	RemotePathTypeSymlink  = "symlink"
+	RemotePathTypeNotFound = "notfound"
+)
+
+// remoteListingCache is the last directory listed for completions in a session
+type remoteListingCache struct {
+	dir     string
+	entries []RemoteFileEntry
+	fetched time.Time
+}
+
+var pathCompletionCache = make(map[string]*remoteListingCache)
+var pathCompletionCacheMu sync.Mutex
+
+// splitCompletionPath splits a partially typed remote path into the directory to list
+// and the name prefix to match, with forward-slash semantics on every platform
+func splitCompletionPath(partialPath string) (dir, prefix string) {
+	switch {
+	case partialPath == "":
+		return ".", ""
+	case strings.HasSuffix(partialPath, "/"):
+		dir = strings.TrimRight(partialPath, "/")
+		if dir == "" {
+			dir = "/"
+		}
+		return dir, ""
+	default:
+		return path.Dir(partialPath), path.Base(partialPath)
+	}
+}
+
+// GetRemotePathCompletions returns up to limit paths in the directory part of partialPath whose
+// names start with its last element. Directories sort first; dotfiles are only offered once the
+// typed name starts with a dot.
+func (a *App) GetRemotePathCompletions(sessionID, partialPath string, limit int) ([]string, error) {
+	if limit <= 0 {
+		limit = DefaultPathCompletionLimit
+	}
+
+	dir, prefix := splitCompletionPath(partialPath)
+	entries, err := a.listRemoteDirCached(sessionID, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []RemoteFileEntry
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name, prefix) {
+			continue
+		}
+		if strings.HasPrefix(entry.Name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		matches = append(matches, entry)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].IsDir != matches[j].IsDir {
+			return matches[i].IsDir
+		}
+		return matches[i].Name < matches[j].Name
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	completions := make([]string, 0, len(matches))
+	for _, entry := range matches {
+		completions = append(completions, entry.Path)
+	}
+
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, RemotePathCompletionsEvent, map[string]interface{}{
+			"sessionId":   sessionID,
+			"partialPath": partialPath,
+			"completions": completions,
+		})
+	}
+	return completions, nil
+}
+
+// listRemoteDirCached lists a remote directory, reusing the session's last listing while it is fresh
+func (a *App) listRemoteDirCached(sessionID, dir string) ([]RemoteFileEntry, error) {
+	pathCompletionCacheMu.Lock()
+	cached, exists := pathCompletionCache[sessionID]
+	pathCompletionCacheMu.Unlock()
+
+	if exists && cached.dir == dir && time.Since(cached.fetched) < PathCompletionCacheTTL {
+		return cached.entries, nil
+	}
+
+	entries, err := a.ListRemoteFiles(sessionID, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pathCompletionCacheMu.Lock()
+	pathCompletionCache[sessionID] = &remoteListingCache{dir: dir, entries: entries, fetched: time.Now()}
+	pathCompletionCacheMu.Unlock()
+	return entries, nil
+}
+
+// clearPathCompletionCache drops the cached listing for a session
+func clearPathCompletionCache(sessionID string) {
+	pathCompletionCacheMu.Lock()
+	defer pathCompletionCacheMu.Unlock()
+	delete(pathCompletionCache, sessionID)
+}
+
+// GetRemotePathType reports whether a remote path is a "file", "dir", "symlink" or "notfound".
+// A missing path is not an error.
+func (a *App) GetRemotePathType(sessionID, remotePath string) (string, error) {
+	sftpClient, err := a.getOrReconnectSFTPClient(sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	// Lstat rather than Stat, which would follow the link and never report one
+	info, err := sftpClient.Lstat(remotePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return RemotePathTypeNotFound, nil
+		}
+		return "", fmt.Errorf("failed to stat %s: %w", remotePath, err)
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		return RemotePathTypeSymlink, nil
+	case info.IsDir():
+		return RemotePathTypeDir, nil
+	default:
+		return RemotePathTypeFile, nil
+	}
+}
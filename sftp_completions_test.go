@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestSplitCompletionPath(t *testing.T) {
+	tests := []struct {
+		partial, dir, prefix string
+	}{
+		{"", ".", ""},
+		{"/", "/", ""},
+		{"/et", "/", "et"},
+		{"/etc/", "/etc", ""},
+		{"/etc//", "/etc", ""},
+		{"/etc/ngi", "/etc", "ngi"},
+		{"/var/log/.hid", "/var/log", ".hid"},
+		{"src", ".", "src"},
+		{"src/ma", "src", "ma"},
+	}
+
+	for _, tt := range tests {
+		dir, prefix := splitCompletionPath(tt.partial)
+		if dir != tt.dir || prefix != tt.prefix {
+			t.Errorf("splitCompletionPath(%q) = (%q, %q), want (%q, %q)", tt.partial, dir, prefix, tt.dir, tt.prefix)
+		}
+	}
+}
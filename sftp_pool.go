@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/pkg/sftp"
+)
+
+// sftpTransferClients returns the SFTP clients a parallel transfer with the given number of
+// workers spreads its files over: the session's client, then up to ClientPoolSize-1 extra
+// channels on the same SSH connection. A single channel serializes its requests, so on
+// high-latency links the extra channels raise multi-file throughput. Extra channels are opened
+// on first use and kept until the explorer session closes; when one can't be opened the
+// transfer makes do with fewer.
+func (a *App) sftpTransferClients(sessionID string, primary *sftp.Client, workers int) []*sftp.Client {
+	size := a.getSFTPConfig().ClientPoolSize
+	if size > MaxSFTPClientPoolSize {
+		size = MaxSFTPClientPoolSize
+	}
+	if workers < size {
+		size = workers
+	}
+	if size <= 1 {
+		return []*sftp.Client{primary}
+	}
+
+	a.ssh.sftpClientsMutex.RLock()
+	extras := a.ssh.sftpPools[sessionID]
+	a.ssh.sftpClientsMutex.RUnlock()
+
+	if len(extras) < size-1 {
+		opened := a.openSFTPPoolClients(sessionID, size-1-len(extras))
+		if len(opened) > 0 {
+			a.ssh.sftpClientsMutex.Lock()
+			if _, exists := a.ssh.sftpClients[sessionID]; !exists {
+				// The explorer session closed while the channels were opening
+				a.ssh.sftpClientsMutex.Unlock()
+				closeSFTPClients(opened)
+				return []*sftp.Client{primary}
+			}
+			a.ssh.sftpPools[sessionID] = append(a.ssh.sftpPools[sessionID], opened...)
+			extras = a.ssh.sftpPools[sessionID]
+			a.ssh.sftpClientsMutex.Unlock()
+		}
+	}
+
+	if len(extras) > size-1 {
+		extras = extras[:size-1]
+	}
+	return append([]*sftp.Client{primary}, extras...)
+}
+
+// openSFTPPoolClients opens up to n extra SFTP channels on a session's SSH connection
+func (a *App) openSFTPPoolClients(sessionID string, n int) []*sftp.Client {
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+	if !exists || sshSession == nil || sshSession.client == nil {
+		return nil
+	}
+
+	var opened []*sftp.Client
+	for i := 0; i < n; i++ {
+		client, err := a.newSFTPClient(sessionID, sshSession)
+		if err != nil {
+			// Servers may cap the channels or sftp-server processes per connection
+			fmt.Printf("Warning: opened %d of %d extra SFTP channels for session %s: %v\n", len(opened), n, sessionID, err)
+			break
+		}
+		a.ssh.resourceManager.Register(&SFTPClientWrapper{
+			client:    client,
+			sessionID: sessionID,
+		})
+		opened = append(opened, client)
+	}
+	return opened
+}
+
+// dropSFTPPoolLocked removes a session's extra SFTP channels and closes them in the background,
+// since closing a dead client can block on the broken transport. Callers hold sftpClientsMutex.
+func (a *App) dropSFTPPoolLocked(sessionID string) {
+	if pool, exists := a.ssh.sftpPools[sessionID]; exists {
+		delete(a.ssh.sftpPools, sessionID)
+		go closeSFTPClients(pool)
+	}
+}
+
+func closeSFTPClients(clients []*sftp.Client) {
+	for _, client := range clients {
+		client.Close()
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// startSFTPServer runs an SSH server whose sftp subsystem channels share one in-memory
+// filesystem. Returns a client connected to it and a count of the channels opened.
+func startSFTPServer(t *testing.T) (*ssh.Client, *int32) {
+	t.Helper()
+	addr, _, channels := listenSFTPServer(t)
+	client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            "deploy",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, channels
+}
+
+// listenSFTPServer starts the server behind startSFTPServer without connecting to it. Returns
+// its address, its host key and a count of the SFTP channels opened.
+func listenSFTPServer(t *testing.T) (string, ssh.PublicKey, *int32) {
+	t.Helper()
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	handlers := sftp.InMemHandler()
+	var channels int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					conn.Close()
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					channel, requests, err := newChannel.Accept()
+					if err != nil {
+						continue
+					}
+					go func() {
+						for req := range requests {
+							// The payload is the length-prefixed subsystem name
+							if req.Type != "subsystem" || string(req.Payload[4:]) != "sftp" {
+								req.Reply(false, nil)
+								continue
+							}
+							req.Reply(true, nil)
+							atomic.AddInt32(&channels, 1)
+							go func() {
+								server := sftp.NewRequestServer(channel, handlers)
+								server.Serve()
+								server.Close()
+							}()
+						}
+					}()
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String(), signer.PublicKey(), &channels
+}
+
+func TestSFTPTransferClients(t *testing.T) {
+	app := NewApp()
+	sessionID := "session_sftp_pool"
+	sshClient, channels := startSFTPServer(t)
+	app.ssh.sshSessions[sessionID] = &SSHSession{client: sshClient}
+	defer func() {
+		app.ssh.sshSessionsMutex.Lock()
+		delete(app.ssh.sshSessions, sessionID)
+		app.ssh.sshSessionsMutex.Unlock()
+	}()
+	defer app.ReleaseSession(sessionID)
+	primary, err := sftp.NewClient(sshClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.ssh.sftpClients[sessionID] = primary
+
+	// The default is a single channel
+	if clients := app.sftpTransferClients(sessionID, primary, 4); len(clients) != 1 || clients[0] != primary {
+		t.Fatalf("sftpTransferClients() with the default pool size = %d clients, want the session's client", len(clients))
+	}
+
+	app.config.config.SFTP.ClientPoolSize = 3
+	// Never more channels than workers
+	if clients := app.sftpTransferClients(sessionID, primary, 2); len(clients) != 2 {
+		t.Errorf("sftpTransferClients() with 2 workers = %d clients, want 2", len(clients))
+	}
+	clients := app.sftpTransferClients(sessionID, primary, 4)
+	if len(clients) != 3 || clients[0] != primary || clients[1] == clients[2] {
+		t.Fatalf("sftpTransferClients() = %d clients, want the session's client and 2 distinct extras", len(clients))
+	}
+	if n := atomic.LoadInt32(channels); n != 3 {
+		t.Errorf("%d SFTP channels opened, want the pool to be reused", n)
+	}
+
+	// Uploads spread over the pool land on the same remote filesystem
+	localDir := t.TempDir()
+	var jobs []TransferJob
+	for i, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		localPath := filepath.Join(localDir, name)
+		if err := os.WriteFile(localPath, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		jobs = append(jobs, TransferJob{LocalPath: localPath, RemotePath: "/" + name, FileName: name, FileSize: int64(len(name)), FileIndex: i, TotalFiles: 4})
+	}
+	if _, err := app.executeParallelUploads(sessionID, primary, jobs, 4); err != nil {
+		t.Fatalf("executeParallelUploads() returned error: %v", err)
+	}
+	for _, job := range jobs {
+		if _, err := primary.Stat(job.RemotePath); err != nil {
+			t.Errorf("%s not uploaded: %v", job.RemotePath, err)
+		}
+	}
+
+	if err := app.CloseFileExplorerSession(sessionID); err != nil {
+		t.Fatal(err)
+	}
+	app.ssh.sftpClientsMutex.RLock()
+	_, pooled := app.ssh.sftpPools[sessionID]
+	app.ssh.sftpClientsMutex.RUnlock()
+	if pooled {
+		t.Error("extra SFTP channels kept after the explorer session closed")
+	}
+}
+
+func TestConcurrentSFTPReconnectsShareOneClient(t *testing.T) {
+	app := NewApp()
+	sessionID := "session_sftp_reconnect"
+	sshClient, channels := startSFTPServer(t)
+	app.ssh.sshSessions[sessionID] = &SSHSession{sessionID: sessionID, client: sshClient}
+	defer func() {
+		app.ssh.sshSessionsMutex.Lock()
+		delete(app.ssh.sshSessions, sessionID)
+		app.ssh.sshSessionsMutex.Unlock()
+	}()
+	defer app.ReleaseSession(sessionID)
+
+	broken, err := sftp.NewClient(sshClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken.Close()
+	app.ssh.sftpClients[sessionID] = broken
+
+	clients := make([]*sftp.Client, 8)
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Every caller saw the same broken client fail its health check
+			clients[i], _ = app.reconnectSFTPClientNoLock(sessionID, broken)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, client := range clients {
+		if client == nil || client != clients[0] {
+			t.Fatalf("caller %d got client %p, want the one client installed by the first reconnect %p", i, client, clients[0])
+		}
+	}
+	if _, err := clients[0].Getwd(); err != nil {
+		t.Errorf("replacement client closed by a later reconnect: %v", err)
+	}
+
+	// A caller that only notices the failure now gets the replacement without a new channel
+	opened := atomic.LoadInt32(channels)
+	if client, err := app.reconnectSFTPClientNoLock(sessionID, broken); err != nil || client != clients[0] {
+		t.Errorf("late reconnect = %p, %v; want the installed client %p", client, err, clients[0])
+	}
+	if n := atomic.LoadInt32(channels); n != opened {
+		t.Errorf("late reconnect opened %d SFTP channels, want none", n-opened)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// Directory listing cache and prefetch constants
+const (
+	DirectoryCacheTTL            = 60 * time.Second
+	DirectoryPrefetchConcurrency = 3  // Listings read at once by prefetches and background refreshes
+	MaxDirectoryPrefetchDepth    = 3  // Deepest level PrefetchRemoteDirectory will walk
+	maxPrefetchedSubdirectories  = 32 // Subdirectories prefetched per directory, so huge trees don't flood the server
+)
+
+// directoryListing is a cached ListRemoteFiles result
+type directoryListing struct {
+	entries    []RemoteFileEntry
+	fetched    time.Time
+	refreshing bool // A background refresh is in flight
+}
+
+// directoryPrefetchSlots limits the listings read in the background across all sessions
+var directoryPrefetchSlots = make(chan struct{}, DirectoryPrefetchConcurrency)
+
+// cachedDirectoryListing returns a copy of a fresh cached listing
+func (a *App) cachedDirectoryListing(sessionID, remotePath string) ([]RemoteFileEntry, bool) {
+	a.ssh.directoryCacheMutex.Lock()
+	defer a.ssh.directoryCacheMutex.Unlock()
+
+	cached, exists := a.ssh.directoryCache[sessionID][remotePath]
+	if !exists || time.Since(cached.fetched) >= DirectoryCacheTTL {
+		return nil, false
+	}
+	return append([]RemoteFileEntry(nil), cached.entries...), true
+}
+
+// storeDirectoryListing caches a listing, dropping the session's expired listings as it goes
+func (a *App) storeDirectoryListing(sessionID, remotePath string, entries []RemoteFileEntry) {
+	a.ssh.directoryCacheMutex.Lock()
+	defer a.ssh.directoryCacheMutex.Unlock()
+
+	listings, exists := a.ssh.directoryCache[sessionID]
+	if !exists {
+		listings = make(map[string]*directoryListing)
+		a.ssh.directoryCache[sessionID] = listings
+	}
+	for path, cached := range listings {
+		if time.Since(cached.fetched) >= DirectoryCacheTTL && !cached.refreshing {
+			delete(listings, path)
+		}
+	}
+	listings[remotePath] = &directoryListing{entries: entries, fetched: time.Now()}
+}
+
+// invalidateDirectoryCache drops every cached listing of a session
+func (a *App) invalidateDirectoryCache(sessionID string) {
+	a.ssh.InvalidateDirectoryCache(sessionID)
+}
+
+// ClearDirectoryCache drops the cached and prefetched directory listings of a session, so the
+// next ListRemoteFiles reads from the server
+func (a *App) ClearDirectoryCache(sessionID string) error {
+	a.invalidateDirectoryCache(sessionID)
+	return nil
+}
+
+// existingSFTPClient returns the session's SFTP client without reconnecting. Background work
+// uses it so a prefetch can't reopen a file explorer that was just closed.
+func (a *App) existingSFTPClient(sessionID string) *sftp.Client {
+	client, _ := a.ssh.SFTPClient(sessionID)
+	return client
+}
+
+// readDirectoryInBackground lists a directory in a prefetch slot and caches the result.
+// Returns nil when the session's SFTP client is gone or the directory can't be read.
+func (a *App) readDirectoryInBackground(sessionID, remotePath string) []RemoteFileEntry {
+	directoryPrefetchSlots <- struct{}{}
+	defer func() { <-directoryPrefetchSlots }()
+
+	sftpClient := a.existingSFTPClient(sessionID)
+	if sftpClient == nil {
+		return nil
+	}
+	entries, err := a.readRemoteDirectory(sessionID, sftpClient, remotePath)
+	if err != nil {
+		return nil
+	}
+	// Closing the explorer while the read was in flight clears the cache; don't refill it
+	if a.existingSFTPClient(sessionID) != sftpClient {
+		return nil
+	}
+	a.storeDirectoryListing(sessionID, remotePath, entries)
+	return entries
+}
+
+// refreshDirectoryListing re-reads a cached listing in the background, once at a time per path
+func (a *App) refreshDirectoryListing(sessionID, remotePath string) {
+	a.ssh.directoryCacheMutex.Lock()
+	cached, exists := a.ssh.directoryCache[sessionID][remotePath]
+	if !exists || cached.refreshing {
+		a.ssh.directoryCacheMutex.Unlock()
+		return
+	}
+	cached.refreshing = true
+	a.ssh.directoryCacheMutex.Unlock()
+
+	go func() {
+		if a.readDirectoryInBackground(sessionID, remotePath) == nil {
+			a.ssh.directoryCacheMutex.Lock()
+			cached.refreshing = false
+			a.ssh.directoryCacheMutex.Unlock()
+		}
+	}()
+}
+
+// subdirectoryPaths returns the paths of the directories in a listing, skipping symlinks so
+// a prefetch can't loop
+func subdirectoryPaths(entries []RemoteFileEntry) []string {
+	var paths []string
+	for _, entry := range entries {
+		// Virtual and network filesystems are huge or slow to walk - only list them when opened
+		if entry.IsDir && !entry.IsSymlink && entry.FSKind == "" {
+			paths = append(paths, entry.Path)
+			if len(paths) == maxPrefetchedSubdirectories {
+				break
+			}
+		}
+	}
+	return paths
+}
+
+// prefetchDirectories lists and caches directories that aren't cached yet, then walks their
+// subdirectories down to depth more levels
+func (a *App) prefetchDirectories(sessionID string, remotePaths []string, depth int) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("Directory prefetch panic for session %s: %v\n", sessionID, r)
+		}
+	}()
+
+	for level := 0; level <= depth && len(remotePaths) > 0; level++ {
+		var next []string
+		var nextMutex sync.Mutex
+		var wg sync.WaitGroup
+		for _, remotePath := range remotePaths {
+			if entries, cached := a.cachedDirectoryListing(sessionID, remotePath); cached {
+				if level < depth {
+					nextMutex.Lock()
+					next = append(next, subdirectoryPaths(entries)...)
+					nextMutex.Unlock()
+				}
+				continue
+			}
+			wg.Add(1)
+			go func(remotePath string) {
+				defer wg.Done()
+				entries := a.readDirectoryInBackground(sessionID, remotePath)
+				if level < depth {
+					nextMutex.Lock()
+					next = append(next, subdirectoryPaths(entries)...)
+					nextMutex.Unlock()
+				}
+			}(remotePath)
+		}
+		wg.Wait()
+		remotePaths = next
+	}
+}
+
+// PrefetchRemoteDirectory lists a remote directory and its subdirectories down to depth levels
+// (capped at MaxDirectoryPrefetchDepth) in the background, so expanding them in the file
+// explorer is served from the cache. Returns once the prefetch has started.
+func (a *App) PrefetchRemoteDirectory(sessionID, remotePath string, depth int) error {
+	if a.existingSFTPClient(sessionID) == nil {
+		return newNotFoundError(ErrCategorySFTP, "PrefetchRemoteDirectory", "SFTP client not initialized for session %s", sessionID)
+	}
+	if remotePath == "" {
+		remotePath = "."
+	}
+	if depth < 0 {
+		depth = 0
+	}
+	if depth > MaxDirectoryPrefetchDepth {
+		depth = MaxDirectoryPrefetchDepth
+	}
+
+	go a.prefetchDirectories(sessionID, []string{remotePath}, depth)
+	return nil
+}
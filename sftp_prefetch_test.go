@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestListRemoteFilesPrefetchesSubdirectories(t *testing.T) {
+	app := NewApp()
+	sessionID := "session_prefetch"
+	client := newLatencySFTPClient(t, 0, 0)
+	app.ssh.sftpClientsMutex.Lock()
+	app.ssh.sftpClients[sessionID] = client
+	app.ssh.sftpClientsMutex.Unlock()
+
+	for _, dir := range []string{"/srv", "/srv/app", "/srv/app/logs"} {
+		if err := client.Mkdir(dir); err != nil {
+			t.Fatalf("Mkdir(%s) returned error: %v", dir, err)
+		}
+	}
+
+	if _, err := app.ListRemoteFiles(sessionID, "/srv"); err != nil {
+		t.Fatalf("ListRemoteFiles() returned error: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		entries, cached := app.cachedDirectoryListing(sessionID, "/srv/app")
+		if cached {
+			if len(entries) != 1 || entries[0].Path != "/srv/app/logs" {
+				t.Errorf("prefetched /srv/app = %+v", entries)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("/srv/app was not prefetched")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Prefetching from ListRemoteFiles stops one level down
+	if _, cached := app.cachedDirectoryListing(sessionID, "/srv/app/logs"); cached {
+		t.Error("/srv/app/logs was prefetched past depth 1")
+	}
+
+	// Changing remote files drops the cache so the new directory shows up
+	if err := app.CreateRemoteDirectory(sessionID, "/srv/data"); err != nil {
+		t.Fatalf("CreateRemoteDirectory() returned error: %v", err)
+	}
+	entries, err := app.ListRemoteFiles(sessionID, "/srv")
+	if err != nil || len(entries) != 2 {
+		t.Errorf("ListRemoteFiles() after mkdir = %+v, %v; want 2 entries", entries, err)
+	}
+
+	app.ClearDirectoryCache(sessionID)
+	if _, cached := app.cachedDirectoryListing(sessionID, "/srv"); cached {
+		t.Error("listing still cached after ClearDirectoryCache")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// SFTP retry constants
+const (
+	MaxSFTPRetries     = 3                      // Retries after the first attempt
+	SFTPRetryBaseDelay = 500 * time.Millisecond // Waited before retry n is n times this
+)
+
+// isRetryableSFTPError reports whether an SFTP error looks like a dropped or garbled connection
+// that a retry on a fresh client may get past. Errors about the file itself are final.
+func isRetryableSFTPError(err error) bool {
+	var netErr net.Error
+	var statusErr *sftp.StatusError
+
+	switch {
+	case err == nil, errors.Is(err, ErrTransferCancelled):
+		return false
+	case errors.Is(err, os.ErrNotExist), errors.Is(err, os.ErrPermission), errors.Is(err, syscall.ENAMETOOLONG):
+		return false
+	case errors.As(err, &statusErr):
+		switch statusErr.FxCode() {
+		case sftp.ErrSSHFxBadMessage, sftp.ErrSSHFxNoConnection, sftp.ErrSSHFxConnectionLost:
+			return true
+		}
+		return false
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	case errors.Is(err, sftp.ErrSSHFxConnectionLost), errors.Is(err, sftp.ErrSSHFxNoConnection),
+		errors.Is(err, net.ErrClosed), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return true
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return true
+	}
+
+	// pkg/sftp reports malformed or out-of-order packets with unexported errors
+	message := strings.ToLower(err.Error())
+	for _, packetErr := range []string{"packet too long", "packet too short", "unexpected packet", "failed to send packet", "sid not found"} {
+		if strings.Contains(message, packetErr) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryableSFTPOp runs an SFTP operation, retrying it up to MaxSFTPRetries times on transient
+// errors. Each retry waits SFTPRetryBaseDelay times the attempt number, then gets the session's
+// client again through getOrReconnectSFTPClient, so op must use the client it is passed.
+// A transfer cancelled in the meantime stops the retries.
+func (a *App) retryableSFTPOp(sessionID, operation string, sftpClient *sftp.Client, op func(*sftp.Client) error) error {
+	for attempt := 1; ; attempt++ {
+		err := op(sftpClient)
+		if err == nil || attempt > MaxSFTPRetries || !isRetryableSFTPError(err) {
+			return err
+		}
+
+		fmt.Printf("SFTP %s failed for session %s (attempt %d/%d): %v - retrying\n", operation, sessionID, attempt, MaxSFTPRetries+1, err)
+		if a.isTransferCancelled(sessionID) {
+			return ErrTransferCancelled
+		}
+		time.Sleep(SFTPRetryBaseDelay * time.Duration(attempt))
+		if a.isTransferCancelled(sessionID) {
+			return ErrTransferCancelled
+		}
+
+		reconnected, reconnectErr := a.getOrReconnectSFTPClient(sessionID)
+		if reconnectErr != nil {
+			fmt.Printf("SFTP %s retry for session %s could not reconnect: %v\n", operation, sessionID, reconnectErr)
+			return err
+		}
+		sftpClient = reconnected
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+func TestIsRetryableSFTPError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{io.EOF, true},
+		{fmt.Errorf("failed to read directory /srv: %w", io.ErrUnexpectedEOF), true},
+		{sftp.ErrSSHFxConnectionLost, true},
+		{&os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET}, true},
+		{os.ErrDeadlineExceeded, true},
+		{fmt.Errorf("packet too long"), true},
+		{fmt.Errorf("failed to open remote file /srv/a: %w", os.ErrNotExist), false},
+		{&os.PathError{Op: "open", Path: "/root/a", Err: os.ErrPermission}, false},
+		{&os.PathError{Op: "create", Path: "/tmp/long", Err: syscall.ENAMETOOLONG}, false},
+		{fmt.Errorf("failed to copy file data: %w", ErrTransferCancelled), false},
+		{errors.New("disk quota exceeded"), false},
+	}
+	for _, tt := range tests {
+		if got := isRetryableSFTPError(tt.err); got != tt.want {
+			t.Errorf("isRetryableSFTPError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryableSFTPOp(t *testing.T) {
+	app := NewApp()
+	sessionID := "session_sftp_retry"
+	client := newLatencySFTPClient(t, 0, 0)
+	app.ssh.sftpClientsMutex.Lock()
+	app.ssh.sftpClients[sessionID] = client
+	app.ssh.sftpClientsMutex.Unlock()
+
+	calls := 0
+	err := app.retryableSFTPOp(sessionID, "list", client, func(c *sftp.Client) error {
+		calls++
+		if c != client {
+			t.Error("retry was not given the session's client")
+		}
+		if calls == 1 {
+			return io.ErrUnexpectedEOF
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("transient failure: err = %v after %d calls, want success after 2", err, calls)
+	}
+
+	calls = 0
+	err = app.retryableSFTPOp(sessionID, "list", client, func(*sftp.Client) error {
+		calls++
+		return os.ErrNotExist
+	})
+	if !errors.Is(err, os.ErrNotExist) || calls != 1 {
+		t.Errorf("permanent failure: err = %v after %d calls, want it returned after 1", err, calls)
+	}
+
+	// A cancelled transfer stops the retries
+	app.startTransfer(sessionID)
+	defer app.endTransfer(sessionID)
+	app.CancelSFTPTransfer(sessionID)
+	calls = 0
+	err = app.retryableSFTPOp(sessionID, "download", client, func(*sftp.Client) error {
+		calls++
+		return io.EOF
+	})
+	if !errors.Is(err, ErrTransferCancelled) || calls != 1 {
+		t.Errorf("cancelled transfer: err = %v after %d calls, want ErrTransferCancelled after 1", err, calls)
+	}
+}
@@ -0,0 +1,512 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// SFTPService performs file operations on the SFTP clients of connected sessions. It depends
+// only on a SessionStore, an Emitter and the SFTP settings, so it can be tested without an App.
+type SFTPService struct {
+	sessions SessionStore
+	emitter  Emitter
+	config   func() SFTPConfig
+}
+
+// NewSFTPService creates an SFTP service; config is read on every operation so settings
+// changes apply immediately
+func NewSFTPService(sessions SessionStore, emitter Emitter, config func() SFTPConfig) *SFTPService {
+	return &SFTPService{
+		sessions: sessions,
+		emitter:  emitter,
+		config:   config,
+	}
+}
+
+// client returns the SFTP client of a session with an open file explorer
+func (s *SFTPService) client(sessionID string) (*sftp.Client, error) {
+	sftpClient, exists := s.sessions.SFTPClient(sessionID)
+	if !exists {
+		return nil, fmt.Errorf("SFTP client not initialized for session %s", sessionID)
+	}
+	return sftpClient, nil
+}
+
+// transferEvent builds the event name and payload of an upload or download progress event
+func transferEvent(sessionID string, phase string, direction string, payload map[string]interface{}) (string, map[string]interface{}) {
+	data := map[string]interface{}{
+		"sessionId": sessionID,
+		"phase":     phase,
+		"direction": direction,
+	}
+	for k, v := range payload {
+		data[k] = v
+	}
+	eventName := "sftp-upload-progress"
+	if direction == "download" {
+		eventName = "sftp-download-progress"
+	}
+	return eventName, data
+}
+
+func (s *SFTPService) emitUploadEvent(sessionID string, phase string, payload map[string]interface{}) {
+	eventName, data := transferEvent(sessionID, phase, "upload", payload)
+	s.emitter.Emit(eventName, data)
+}
+
+// CreateDirectory creates a new directory on the remote server with the configured
+// DirectoryMode
+func (s *SFTPService) CreateDirectory(sessionID string, remotePath string) error {
+	return s.CreateDirectoryWithMode(sessionID, remotePath, 0)
+}
+
+// CreateDirectoryWithMode creates a new directory on the remote server and sets its
+// permissions, so they don't depend on the server's umask. A mode of 0 uses the configured
+// DirectoryMode, and leaves the server's default when that isn't set either.
+func (s *SFTPService) CreateDirectoryWithMode(sessionID string, remotePath string, mode uint32) error {
+	if err := validateUploadMode(mode); err != nil {
+		return err
+	}
+	if mode == 0 {
+		mode = s.config().DirectoryMode
+	}
+	defer s.sessions.InvalidateDirectoryCache(sessionID)
+
+	sftpClient, err := s.client(sessionID)
+	if err != nil {
+		return err
+	}
+
+	if err := sftpClient.Mkdir(remotePath); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", remotePath, err)
+	}
+	return applyUploadMode(sftpClient, remotePath, mode)
+}
+
+// CreateFile creates an empty file on the remote server, failing if the path already exists
+func (s *SFTPService) CreateFile(sessionID string, remotePath string) error {
+	defer s.sessions.InvalidateDirectoryCache(sessionID)
+
+	sftpClient, err := s.client(sessionID)
+	if err != nil {
+		return err
+	}
+
+	file, err := sftpClient.OpenFile(remotePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", remotePath, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close new file %s: %w", remotePath, err)
+	}
+	return nil
+}
+
+// DeletePath deletes a file or directory on the remote server, directories recursively
+func (s *SFTPService) DeletePath(sessionID string, remotePath string) error {
+	return s.DeletePathAdvanced(sessionID, remotePath, true)
+}
+
+// DeletePathAdvanced deletes a file or directory with explicit recursion control
+func (s *SFTPService) DeletePathAdvanced(sessionID string, remotePath string, isRecursive bool) error {
+	defer s.sessions.InvalidateDirectoryCache(sessionID)
+
+	sftpClient, err := s.client(sessionID)
+	if err != nil {
+		return err
+	}
+
+	// Check if it's a directory
+	stat, err := sftpClient.Stat(remotePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", remotePath, err)
+	}
+
+	if stat.IsDir() {
+		if isRecursive {
+			return deleteRemoteDirectoryRecursive(sftpClient, remotePath)
+		}
+		if err := sftpClient.RemoveDirectory(remotePath); err != nil {
+			return fmt.Errorf("failed to remove directory %s: %w", remotePath, err)
+		}
+		return nil
+	}
+
+	if err := sftpClient.Remove(remotePath); err != nil {
+		return fmt.Errorf("failed to remove file %s: %w", remotePath, err)
+	}
+	return nil
+}
+
+// deleteRemoteDirectoryRecursive deletes a directory and everything below it
+func deleteRemoteDirectoryRecursive(sftpClient *sftp.Client, remotePath string) error {
+	// List directory contents
+	fileInfos, err := sftpClient.ReadDir(remotePath)
+	if err != nil {
+		return fmt.Errorf("failed to read directory %s: %w", remotePath, err)
+	}
+
+	// Delete each item recursively
+	for _, fileInfo := range fileInfos {
+		fullPath := joinRemotePath(remotePath, fileInfo.Name())
+
+		if fileInfo.IsDir() {
+			if err := deleteRemoteDirectoryRecursive(sftpClient, fullPath); err != nil {
+				return err
+			}
+		} else {
+			if err := sftpClient.Remove(fullPath); err != nil {
+				return fmt.Errorf("failed to remove file %s: %w", fullPath, err)
+			}
+		}
+	}
+
+	// Remove the directory itself
+	if err := sftpClient.RemoveDirectory(remotePath); err != nil {
+		return fmt.Errorf("failed to remove directory %s: %w", remotePath, err)
+	}
+	return nil
+}
+
+// ErrDestinationExists is returned when a rename would replace an existing path and
+// overwriting wasn't asked for
+var ErrDestinationExists = errors.New("destination already exists")
+
+// RenameOptions controls RenamePath
+type RenameOptions struct {
+	Overwrite bool `json:"overwrite"` // Replace an existing destination instead of failing with ErrDestinationExists
+}
+
+// validateRename rejects renames that can't do what was asked: onto themselves, or of a
+// directory into its own subtree
+func validateRename(oldPath, newPath string) error {
+	if oldPath == "" || newPath == "" {
+		return newInvalidError(ErrCategorySFTP, "RenamePath", "invalid rename: source and destination cannot be empty")
+	}
+	oldPath, newPath = path.Clean(oldPath), path.Clean(newPath)
+	if oldPath == newPath {
+		return newInvalidError(ErrCategorySFTP, "RenamePath", "invalid rename: source and destination are the same: %s", oldPath)
+	}
+	if pathWithin(newPath, oldPath) {
+		return newInvalidError(ErrCategorySFTP, "RenamePath", "invalid rename: cannot move %s into itself (%s)", oldPath, newPath)
+	}
+	return nil
+}
+
+// RenamePath renames a file or directory on the remote server. Servers disagree on renames
+// onto an existing path - some replace it, some fail - so the destination is checked first
+// and an existing one fails with ErrDestinationExists unless opts.Overwrite is set. Overwrites
+// use posix-rename@openssh.com where the server has it, and otherwise remove the destination
+// before renaming.
+func (s *SFTPService) RenamePath(sessionID string, oldPath string, newPath string, opts RenameOptions) error {
+	if err := validateRename(oldPath, newPath); err != nil {
+		return err
+	}
+	defer s.sessions.InvalidateDirectoryCache(sessionID)
+
+	sftpClient, err := s.client(sessionID)
+	if err != nil {
+		return err
+	}
+
+	source, err := sftpClient.Lstat(oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", oldPath, err)
+	}
+
+	// Changing only the case of a name finds the source itself on case-insensitive servers,
+	// so it's never taken for an existing destination
+	if !strings.EqualFold(oldPath, newPath) {
+		destination, err := sftpClient.Lstat(newPath)
+		switch {
+		case err == nil && !opts.Overwrite:
+			return fmt.Errorf("cannot rename %s: %w: %s", oldPath, ErrDestinationExists, newPath)
+		case err == nil:
+			if destination.IsDir() != source.IsDir() {
+				return newInvalidError(ErrCategorySFTP, "RenamePath", "invalid rename: cannot replace %s with %s, one is a directory and the other isn't", newPath, oldPath)
+			}
+			if _, posix := sftpClient.HasExtension(SFTPExtPosixRename); posix {
+				if err := sftpClient.PosixRename(oldPath, newPath); err != nil {
+					return fmt.Errorf("failed to rename %s to %s: %w", oldPath, newPath, err)
+				}
+				return nil
+			}
+			// Directories only go if they are empty, like a POSIX rename
+			if err := sftpClient.Remove(newPath); err != nil {
+				return fmt.Errorf("failed to replace %s: %w", newPath, err)
+			}
+		case !errors.Is(err, os.ErrNotExist):
+			return fmt.Errorf("failed to stat %s: %w", newPath, err)
+		}
+	}
+
+	if err := sftpClient.Rename(oldPath, newPath); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", oldPath, newPath, err)
+	}
+	return nil
+}
+
+// FileContent reads the content of a remote file for the editor, base64 encoded when it isn't
+// text. Files over the MaxPreviewSize setting fail with ErrFileTooLarge, and the read gives up
+// after RemoteFilePreviewTimeout.
+func (s *SFTPService) FileContent(sessionID string, remotePath string) (string, error) {
+	file, err := s.FileContentWithVersion(sessionID, remotePath)
+	return file.Content, err
+}
+
+// FileContentWithVersion reads a remote file like FileContent, along with the version to pass
+// to UpdateFileContentIfUnchanged
+func (s *SFTPService) FileContentWithVersion(sessionID string, remotePath string) (RemoteFileContent, error) {
+	content, info, err := s.readFile(sessionID, remotePath, "GetRemoteFileContent")
+	if err != nil {
+		return RemoteFileContent{}, err
+	}
+
+	result := RemoteFileContent{Content: string(content), Version: remoteFileVersion(info)}
+	// Check if it's a binary file - consider both extension and content
+	if !isTextContentWithExtension(remotePath, content) {
+		result.Content = base64.StdEncoding.EncodeToString(content)
+	}
+	return result, nil
+}
+
+// readFile reads a whole remote file and returns it with the file's info, taken before the
+// read. Files over the MaxPreviewSize setting fail with ErrFileTooLarge, and the read gives
+// up after RemoteFilePreviewTimeout.
+func (s *SFTPService) readFile(sessionID string, remotePath string, op string) ([]byte, os.FileInfo, error) {
+	sftpClient, err := s.client(sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Open the remote file
+	file, err := sftpClient.Open(remotePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open remote file %s: %w", remotePath, err)
+	}
+	defer file.Close()
+
+	// The version is taken before reading, so a write during the read shows up as a conflict
+	info, err := file.Stat()
+	if err != nil {
+		// Some servers don't support fstat
+		if info, err = sftpClient.Stat(remotePath); err != nil {
+			return nil, nil, fmt.Errorf("failed to stat remote file %s: %w", remotePath, err)
+		}
+	}
+
+	// Refuse files over the preview limit before reading any of them
+	limit := s.config().MaxPreviewSize
+	if info.Size() > limit {
+		return nil, nil, newFileTooLargeError(op, remotePath, info.Size(), limit)
+	}
+
+	// Read the file content; the limit also holds for a file growing while it is read
+	content, err := readWithTimeout(RemoteFilePreviewTimeout, func() ([]byte, error) {
+		return io.ReadAll(io.LimitReader(file, limit+1))
+	}, func() { file.Close() })
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read file content: %w", err)
+	}
+	if int64(len(content)) > limit {
+		return nil, nil, newFileTooLargeError(op, remotePath, -1, limit)
+	}
+	return content, info, nil
+}
+
+// UpdateFileContent replaces the content of a remote file, creating it if needed
+func (s *SFTPService) UpdateFileContent(sessionID string, remotePath string, content string) error {
+	defer s.sessions.InvalidateDirectoryCache(sessionID)
+
+	sftpClient, err := s.client(sessionID)
+	if err != nil {
+		return err
+	}
+
+	// Create or truncate the remote file
+	file, err := sftpClient.Create(remotePath)
+	if err != nil {
+		return fmt.Errorf("failed to create/open remote file %s: %w", remotePath, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write([]byte(content)); err != nil {
+		return fmt.Errorf("failed to write file content: %w", err)
+	}
+	return nil
+}
+
+// UpdateFileContentIfUnchanged replaces the content of a remote file only if it is still at
+// version, failing with ErrConflict otherwise, and returns the file's new version. An empty
+// version saves unconditionally. The check and the write aren't atomic; a write landing
+// between them is still overwritten.
+func (s *SFTPService) UpdateFileContentIfUnchanged(sessionID string, remotePath string, content string, version string) (string, error) {
+	sftpClient, err := s.client(sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	if version != "" {
+		info, err := sftpClient.Stat(remotePath)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			return "", newConflictError("UpdateRemoteFileContentIfUnchanged", remotePath, version, "")
+		case err != nil:
+			return "", fmt.Errorf("failed to stat remote file %s: %w", remotePath, err)
+		case remoteFileVersion(info) != version:
+			return "", newConflictError("UpdateRemoteFileContentIfUnchanged", remotePath, version, remoteFileVersion(info))
+		}
+	}
+
+	if err := s.UpdateFileContent(sessionID, remotePath, content); err != nil {
+		return "", err
+	}
+	info, err := sftpClient.Stat(remotePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat remote file %s: %w", remotePath, err)
+	}
+	return remoteFileVersion(info), nil
+}
+
+// AppendFileContent appends content to a remote file, creating it if needed, and returns the
+// file's new size
+func (s *SFTPService) AppendFileContent(sessionID string, remotePath string, content string) (int64, error) {
+	defer s.sessions.InvalidateDirectoryCache(sessionID)
+
+	sftpClient, err := s.client(sessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	file, err := sftpClient.OpenFile(remotePath, os.O_APPEND|os.O_WRONLY|os.O_CREATE)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open remote file %s for appending: %w", remotePath, err)
+	}
+	defer file.Close()
+
+	// pkg/sftp writes at its own offset, and servers that ignore the append flag honour it;
+	// starting at the end appends on both
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		return 0, fmt.Errorf("failed to seek to the end of %s: %w", remotePath, err)
+	}
+	if _, err := file.Write([]byte(content)); err != nil {
+		return 0, fmt.Errorf("failed to append to %s: %w", remotePath, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat %s: %w", remotePath, err)
+	}
+	return info.Size(), nil
+}
+
+// UploadFileContent writes base64 encoded content to a remote path in BufferSize chunks,
+// emitting upload progress events as a single-file transfer
+func (s *SFTPService) UploadFileContent(sessionID string, remotePath string, base64Content string) error {
+	return s.UploadFileContentWithMode(sessionID, remotePath, base64Content, 0)
+}
+
+// UploadFileContentWithMode is UploadFileContent that also sets the file's permissions, e.g.
+// 0755 for a script. A mode of 0 keeps the server's default.
+func (s *SFTPService) UploadFileContentWithMode(sessionID string, remotePath string, base64Content string, mode uint32) error {
+	if err := validateUploadMode(mode); err != nil {
+		return err
+	}
+	defer s.sessions.InvalidateDirectoryCache(sessionID)
+
+	sftpClient, err := s.client(sessionID)
+	if err != nil {
+		return err
+	}
+
+	// Decode base64 content
+	content, err := base64.StdEncoding.DecodeString(base64Content)
+	if err != nil {
+		return fmt.Errorf("failed to decode base64 content: %w", err)
+	}
+
+	// Create or truncate the remote file
+	file, err := sftpClient.Create(remotePath)
+	if err != nil {
+		return fmt.Errorf("failed to create remote file %s: %w", remotePath, err)
+	}
+	defer file.Close()
+	if err := applyUploadMode(sftpClient, remotePath, mode); err != nil {
+		return err
+	}
+
+	// Emit start for single file upload
+	fileName := filepath.Base(remotePath)
+	totalBytes := int64(len(content))
+	s.emitUploadEvent(sessionID, "start", map[string]interface{}{
+		"fileName":   fileName,
+		"fileIndex":  1,
+		"totalFiles": 1,
+		"total":      totalBytes,
+	})
+
+	// Get optimized chunk size from config
+	chunkSize := s.config().BufferSize
+	if chunkSize > len(content) {
+		chunkSize = len(content)
+	}
+	if chunkSize == 0 {
+		chunkSize = 64 * 1024 // Fallback to 64KB
+	}
+
+	startTime := time.Now()
+	var written int64
+	for written < totalBytes {
+		end := written + int64(chunkSize)
+		if end > totalBytes {
+			end = totalBytes
+		}
+		n, err := file.Write(content[written:end])
+		if n > 0 {
+			written += int64(n)
+			percent := float64(0)
+			if totalBytes > 0 {
+				percent = float64(written) * 100.0 / float64(totalBytes)
+			}
+			// Calculate transfer speed
+			elapsed := time.Since(startTime).Seconds()
+			var bytesPerSec int64
+			if elapsed > 0 {
+				bytesPerSec = int64(float64(written) / elapsed)
+			}
+			s.emitUploadEvent(sessionID, "progress", map[string]interface{}{
+				"fileName":    fileName,
+				"fileIndex":   1,
+				"totalFiles":  1,
+				"transferred": written,
+				"total":       totalBytes,
+				"percent":     percent,
+				"bytesPerSec": bytesPerSec,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write file content: %w", err)
+		}
+	}
+
+	// Emit complete
+	s.emitUploadEvent(sessionID, "complete", map[string]interface{}{
+		"fileName":    fileName,
+		"fileIndex":   1,
+		"totalFiles":  1,
+		"transferred": totalBytes,
+		"total":       totalBytes,
+		"percent":     100.0,
+	})
+
+	return nil
+}
@@ -0,0 +1,494 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+// fakeSessionStore serves SFTP clients from a map and records cache invalidations
+type fakeSessionStore struct {
+	clients     map[string]*sftp.Client
+	invalidated []string
+}
+
+func (s *fakeSessionStore) SSHSession(sessionID string) (*SSHSession, bool) { return nil, false }
+
+func (s *fakeSessionStore) SFTPClient(sessionID string) (*sftp.Client, bool) {
+	client, exists := s.clients[sessionID]
+	return client, exists
+}
+
+func (s *fakeSessionStore) InvalidateDirectoryCache(sessionID string) {
+	s.invalidated = append(s.invalidated, sessionID)
+}
+
+// recordingEmitter keeps every emitted event
+type recordingEmitter struct {
+	mu     sync.Mutex
+	names  []string
+	events []map[string]interface{}
+}
+
+func (e *recordingEmitter) Emit(eventName string, data ...interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.names = append(e.names, eventName)
+	payload, _ := data[0].(map[string]interface{})
+	e.events = append(e.events, payload)
+}
+
+func newTestSFTPService(t *testing.T, sessionID string, cfg SFTPConfig) (*SFTPService, *fakeSessionStore, *recordingEmitter, *sftp.Client) {
+	client := newLatencySFTPClient(t, 0, 0)
+	store := &fakeSessionStore{clients: map[string]*sftp.Client{sessionID: client}}
+	emitter := &recordingEmitter{}
+	return NewSFTPService(store, emitter, func() SFTPConfig { return cfg }), store, emitter, client
+}
+
+// newLocalSFTPClient connects a client to an SFTP server on the local filesystem, for what the
+// in-memory server doesn't model, such as permissions. Returns the client and a directory to
+// work in.
+func newLocalSFTPClient(t *testing.T) (*sftp.Client, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs POSIX permissions")
+	}
+	toServerR, toServerW := io.Pipe()
+	toClientR, toClientW := io.Pipe()
+
+	server, err := sftp.NewServer(serverConn{toServerR, toClientW})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(toClientR, toServerW)
+	if err != nil {
+		t.Fatalf("failed to start SFTP client: %v", err)
+	}
+	t.Cleanup(func() {
+		toServerW.Close()
+		toClientW.Close()
+		client.Close()
+		server.Close()
+	})
+	return client, t.TempDir()
+}
+
+func TestSFTPServiceUploadFileContent(t *testing.T) {
+	service, store, emitter, client := newTestSFTPService(t, "s1", SFTPConfig{BufferSize: 4})
+
+	content := "hello world"
+	if err := service.UploadFileContent("s1", "/hello.txt", base64.StdEncoding.EncodeToString([]byte(content))); err != nil {
+		t.Fatalf("UploadFileContent() returned error: %v", err)
+	}
+	f, err := client.Open("/hello.txt")
+	if err != nil {
+		t.Fatalf("uploaded file missing: %v", err)
+	}
+	defer f.Close()
+	buf := new(strings.Builder)
+	if _, err := f.WriteTo(buf); err != nil || buf.String() != content {
+		t.Errorf("remote content = %q, err = %v", buf.String(), err)
+	}
+
+	// 11 bytes in 4-byte chunks: start, three progress events, complete
+	var phases []string
+	for i, name := range emitter.names {
+		if name != "sftp-upload-progress" || emitter.events[i]["sessionId"] != "s1" {
+			t.Errorf("event %d = %s %v", i, name, emitter.events[i])
+		}
+		phases = append(phases, emitter.events[i]["phase"].(string))
+	}
+	if got := strings.Join(phases, ","); got != "start,progress,progress,progress,complete" {
+		t.Errorf("phases = %s", got)
+	}
+	if len(store.invalidated) != 1 || store.invalidated[0] != "s1" {
+		t.Errorf("invalidated = %v, want [s1]", store.invalidated)
+	}
+}
+
+func TestSFTPServiceDeletePath(t *testing.T) {
+	service, store, _, client := newTestSFTPService(t, "s1", SFTPConfig{})
+	if err := client.MkdirAll("/tree/sub"); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if f, err := client.Create("/tree/sub/file"); err == nil {
+		f.Close()
+	}
+
+	if err := service.DeletePathAdvanced("s1", "/tree", false); err == nil {
+		t.Error("non-recursive delete of a non-empty directory succeeded")
+	}
+	if err := service.DeletePath("s1", "/tree"); err != nil {
+		t.Fatalf("DeletePath() returned error: %v", err)
+	}
+	if _, err := client.Stat("/tree"); err == nil {
+		t.Error("/tree still exists")
+	}
+	if len(store.invalidated) != 2 {
+		t.Errorf("invalidated %d times, want 2", len(store.invalidated))
+	}
+
+	if err := service.CreateDirectory("missing", "/x"); err == nil || !strings.Contains(err.Error(), "not initialized") {
+		t.Errorf("unknown session: err = %v", err)
+	}
+}
+
+func TestSFTPServiceAppendFileContent(t *testing.T) {
+	service, _, _, client := newTestSFTPService(t, "s1", SFTPConfig{})
+
+	for i, line := range []string{"first\n", "second\n"} {
+		size, err := service.AppendFileContent("s1", "/app.log", line)
+		if err != nil {
+			t.Fatalf("AppendFileContent() returned error: %v", err)
+		}
+		if want := []int64{6, 13}[i]; size != want {
+			t.Errorf("size after append %d = %d, want %d", i+1, size, want)
+		}
+	}
+
+	f, err := client.Open("/app.log")
+	if err != nil {
+		t.Fatalf("appended file missing: %v", err)
+	}
+	defer f.Close()
+	buf := new(strings.Builder)
+	if _, err := f.WriteTo(buf); err != nil || buf.String() != "first\nsecond\n" {
+		t.Errorf("content = %q, err = %v", buf.String(), err)
+	}
+}
+
+func TestSFTPServiceCreateFile(t *testing.T) {
+	service, _, _, client := newTestSFTPService(t, "s1", SFTPConfig{})
+
+	if err := service.CreateFile("s1", "/notes.txt"); err != nil {
+		t.Fatalf("CreateFile() returned error: %v", err)
+	}
+	info, err := client.Stat("/notes.txt")
+	if err != nil || info.Size() != 0 {
+		t.Fatalf("new file: info = %v, err = %v", info, err)
+	}
+
+	if _, err := service.AppendFileContent("s1", "/notes.txt", "keep me"); err != nil {
+		t.Fatalf("AppendFileContent() returned error: %v", err)
+	}
+	if err := service.CreateFile("s1", "/notes.txt"); err == nil {
+		t.Error("CreateFile() over an existing file succeeded")
+	}
+	if info, _ := client.Stat("/notes.txt"); info == nil || info.Size() != 7 {
+		t.Errorf("existing file was changed: %v", info)
+	}
+}
+
+func TestSFTPServiceSaveConflictDetection(t *testing.T) {
+	service, _, _, client := newTestSFTPService(t, "s1", SFTPConfig{MaxPreviewSize: 1024})
+	if err := service.UpdateFileContent("s1", "/shared.conf", "port = 80\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	opened, err := service.FileContentWithVersion("s1", "/shared.conf")
+	if err != nil || opened.Content != "port = 80\n" || opened.Version == "" {
+		t.Fatalf("FileContentWithVersion() = %+v, %v; want the content and a version", opened, err)
+	}
+
+	// Someone else edits the file
+	if err := service.UpdateFileContent("s1", "/shared.conf", "port = 8443\n"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = service.UpdateFileContentIfUnchanged("s1", "/shared.conf", "port = 8080\n", opened.Version)
+	if thermicErr := toThermicError(err); thermicErr.Code != ErrCodeConflict || thermicErr.Reason() != ErrReasonConflict {
+		t.Fatalf("UpdateFileContentIfUnchanged() with a stale version = %v, want a conflict", err)
+	}
+	if current, _ := service.FileContent("s1", "/shared.conf"); current != "port = 8443\n" {
+		t.Errorf("file content after a refused save = %q, want the other edit kept", current)
+	}
+
+	// Saving against the current version succeeds, and the returned version allows another save
+	reopened, err := service.FileContentWithVersion("s1", "/shared.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err := service.UpdateFileContentIfUnchanged("s1", "/shared.conf", "port = 8080\n", reopened.Version)
+	if err != nil {
+		t.Fatalf("UpdateFileContentIfUnchanged() with the current version returned error: %v", err)
+	}
+	if _, err := service.UpdateFileContentIfUnchanged("s1", "/shared.conf", "port = 8081\n", version); err != nil {
+		t.Errorf("UpdateFileContentIfUnchanged() with the version of the last save returned error: %v", err)
+	}
+
+	// A deleted file is a conflict too; an empty version saves regardless
+	if err := client.Remove("/shared.conf"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.UpdateFileContentIfUnchanged("s1", "/shared.conf", "x", version); toThermicError(err).Code != ErrCodeConflict {
+		t.Errorf("UpdateFileContentIfUnchanged() of a deleted file = %v, want a conflict", err)
+	}
+	if _, err := service.UpdateFileContentIfUnchanged("s1", "/shared.conf", "x", ""); err != nil {
+		t.Errorf("UpdateFileContentIfUnchanged() without a version returned error: %v", err)
+	}
+}
+
+func TestSFTPServiceUploadFileContentWithMode(t *testing.T) {
+	client, dir := newLocalSFTPClient(t)
+	store := &fakeSessionStore{clients: map[string]*sftp.Client{"s1": client}}
+	service := NewSFTPService(store, &recordingEmitter{}, func() SFTPConfig { return SFTPConfig{BufferSize: 4} })
+
+	script := filepath.Join(dir, "deploy.sh")
+	if err := service.UploadFileContentWithMode("s1", script, base64.StdEncoding.EncodeToString([]byte("#!/bin/sh\n")), 0755); err != nil {
+		t.Fatalf("UploadFileContentWithMode() returned error: %v", err)
+	}
+	if info, err := os.Stat(script); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("uploaded script mode = %v, %v; want 0755", info.Mode(), err)
+	}
+
+	if err := service.UploadFileContentWithMode("s1", script, "", 010000); err == nil || toThermicError(err).Code != ErrCodeInvalid {
+		t.Errorf("UploadFileContentWithMode() with a file type bit = %v, want invalid", err)
+	}
+}
+
+func TestUploadRemoteFilesWithMode(t *testing.T) {
+	client, dir := newLocalSFTPClient(t)
+	app := NewApp()
+	sessionID := "session_upload_mode"
+	app.ssh.sftpClients[sessionID] = client
+	defer app.ReleaseSession(sessionID)
+
+	local := filepath.Join(t.TempDir(), "run.sh")
+	if err := os.WriteFile(local, []byte("#!/bin/sh\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	remoteDir := filepath.Join(dir, "bin")
+	if err := os.Mkdir(remoteDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.UploadRemoteFilesWithOptions(sessionID, []string{local}, remoteDir, UploadOptions{Mode: 0750}); err != nil {
+		t.Fatalf("UploadRemoteFilesWithOptions() returned error: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(remoteDir, "run.sh")); err != nil || info.Mode().Perm() != 0750 {
+		t.Errorf("uploaded file mode = %v, %v; want 0750", info.Mode(), err)
+	}
+}
+
+func TestSFTPServiceCreateDirectoryWithMode(t *testing.T) {
+	client, dir := newLocalSFTPClient(t)
+	store := &fakeSessionStore{clients: map[string]*sftp.Client{"s1": client}}
+	service := NewSFTPService(store, &recordingEmitter{}, func() SFTPConfig { return SFTPConfig{DirectoryMode: 0750} })
+
+	private := filepath.Join(dir, "private")
+	if err := service.CreateDirectoryWithMode("s1", private, 0700); err != nil {
+		t.Fatalf("CreateDirectoryWithMode() returned error: %v", err)
+	}
+	if info, err := os.Stat(private); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("directory mode = %v, %v; want 0700", info.Mode(), err)
+	}
+
+	// Without a mode the configured default applies, whatever the umask
+	shared := filepath.Join(dir, "shared")
+	if err := service.CreateDirectory("s1", shared); err != nil {
+		t.Fatalf("CreateDirectory() returned error: %v", err)
+	}
+	if info, err := os.Stat(shared); err != nil || info.Mode().Perm() != 0750 {
+		t.Errorf("directory mode = %v, %v; want the configured 0750", info.Mode(), err)
+	}
+
+	if err := service.CreateDirectoryWithMode("s1", filepath.Join(dir, "bad"), 040755); err == nil || toThermicError(err).Code != ErrCodeInvalid {
+		t.Errorf("CreateDirectoryWithMode() with a file type bit = %v, want invalid", err)
+	}
+}
+
+// deniedLister refuses to list one directory, like a folder the SSH user can't read
+type deniedLister struct {
+	sftp.FileLister
+	denied string
+}
+
+func (l deniedLister) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	if r.Method == "List" && r.Filepath == l.denied {
+		return nil, os.ErrPermission
+	}
+	return l.FileLister.Filelist(r)
+}
+
+func TestDownloadRemoteDirectorySkipsUnreadableDirectories(t *testing.T) {
+	handlers := sftp.InMemHandler()
+	handlers.FileList = deniedLister{handlers.FileList, "/tree/private"}
+	toServerR, toServerW := io.Pipe()
+	toClientR, toClientW := io.Pipe()
+	server := sftp.NewRequestServer(serverConn{toServerR, toClientW}, handlers)
+	go server.Serve()
+	client, err := sftp.NewClientPipe(toClientR, toServerW)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		toServerW.Close()
+		toClientW.Close()
+		client.Close()
+		server.Close()
+	})
+
+	for _, dir := range []string{"/tree/private/secrets", "/tree/public/nested"} {
+		if err := client.MkdirAll(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"/tree/top.txt", "/tree/private/key.pem", "/tree/public/nested/readme.md"} {
+		f, err := client.Create(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(file))
+		f.Close()
+	}
+
+	app := NewApp()
+	target := t.TempDir()
+	var jobs []TransferJob
+	dirs := []string{target}
+	skipped, err := app.collectDownloadJobs(client, "/tree", target, &jobs, &dirs, map[string]os.FileMode{})
+	if err != nil {
+		t.Fatalf("collectDownloadJobs() returned error: %v", err)
+	}
+	if len(skipped) != 1 || skipped[0].Path != "/tree/private" || !strings.Contains(skipped[0].Error, "permission denied") {
+		t.Errorf("skipped = %+v, want /tree/private with its error", skipped)
+	}
+	var remotePaths []string
+	for _, job := range jobs {
+		remotePaths = append(remotePaths, job.RemotePath)
+	}
+	if len(remotePaths) != 2 || !strings.Contains(strings.Join(remotePaths, " "), "/tree/public/nested/readme.md") {
+		t.Errorf("jobs = %v, want the files outside the unreadable directory", remotePaths)
+	}
+	for _, dir := range dirs {
+		if strings.Contains(dir, "private") {
+			t.Errorf("local directory %s created for an unreadable remote one", dir)
+		}
+	}
+
+	// The download goes ahead with the rest of the tree
+	sessionID := "session_download_partial"
+	app.ssh.sftpClients[sessionID] = client
+	defer app.ReleaseSession(sessionID)
+	out := filepath.Join(target, "out")
+	if err := app.DownloadRemoteDirectoryWithOptions(sessionID, "/tree", out, DownloadOptions{SkipPreflight: true}); err != nil {
+		t.Fatalf("DownloadRemoteDirectoryWithOptions() returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "public", "nested", "readme.md")); err != nil {
+		t.Errorf("readable file not downloaded: %v", err)
+	}
+
+	// An unreadable top directory is still an error
+	if _, err := app.collectDownloadJobs(client, "/tree/private", target, &jobs, &dirs, map[string]os.FileMode{}); err == nil {
+		t.Error("collectDownloadJobs() of an unreadable directory returned no error")
+	}
+}
+
+func TestDownloadRemoteDirectoryPreservesModes(t *testing.T) {
+	client, dir := newLocalSFTPClient(t)
+	app := NewApp()
+	sessionID := "session_download_modes"
+	app.ssh.sftpClients[sessionID] = client
+	defer app.ReleaseSession(sessionID)
+
+	src := filepath.Join(dir, "src")
+	readOnly := filepath.Join(src, "readonly")
+	if err := os.MkdirAll(readOnly, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(readOnly, "data.txt"), []byte("payload"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(src, 0750)
+	os.Chmod(readOnly, 0555)
+	t.Cleanup(func() { os.Chmod(readOnly, 0755) })
+
+	target := filepath.Join(t.TempDir(), "out")
+	t.Cleanup(func() { os.Chmod(filepath.Join(target, "readonly"), 0755) })
+	if err := app.DownloadRemoteDirectoryWithOptions(sessionID, src, target, DownloadOptions{SkipPreflight: true}); err != nil {
+		t.Fatalf("DownloadRemoteDirectoryWithOptions() returned error: %v", err)
+	}
+
+	// The files land before a read-only directory gets its mode
+	if data, err := os.ReadFile(filepath.Join(target, "readonly", "data.txt")); err != nil || string(data) != "payload" {
+		t.Errorf("downloaded file = %q, %v, want the remote content", data, err)
+	}
+	for path, want := range map[string]os.FileMode{target: 0750, filepath.Join(target, "readonly"): 0555} {
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != want {
+			t.Errorf("%s mode = %v, %v; want %#o", path, info.Mode(), err, want)
+		}
+	}
+}
+
+func TestSFTPServiceRenamePath(t *testing.T) {
+	client, dir := newLocalSFTPClient(t)
+	store := &fakeSessionStore{clients: map[string]*sftp.Client{"s1": client}}
+	service := NewSFTPService(store, &recordingEmitter{}, func() SFTPConfig { return SFTPConfig{} })
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	a, b := write("a.txt", "new"), write("b.txt", "old")
+	tree := filepath.Join(dir, "tree")
+	write("tree/sub/file", "x")
+
+	err := service.RenamePath("s1", a, b, RenameOptions{})
+	if !errors.Is(err, ErrDestinationExists) || toThermicError(err).Code != ErrCodeExists {
+		t.Fatalf("rename onto an existing file = %v, want ErrDestinationExists", err)
+	}
+	if err := service.RenamePath("s1", a, b, RenameOptions{Overwrite: true}); err != nil {
+		t.Fatalf("RenamePath() with overwrite returned error: %v", err)
+	}
+	if content, _ := os.ReadFile(b); string(content) != "new" {
+		t.Errorf("destination holds %q after overwriting, want the source", content)
+	}
+	if _, err := os.Stat(a); !os.IsNotExist(err) {
+		t.Error("source still exists after the rename")
+	}
+
+	// An existing directory isn't replaced by a file, nor moved into
+	if err := service.RenamePath("s1", b, tree, RenameOptions{}); !errors.Is(err, ErrDestinationExists) {
+		t.Errorf("rename onto a directory = %v, want ErrDestinationExists", err)
+	}
+	if err := service.RenamePath("s1", b, tree, RenameOptions{Overwrite: true}); err == nil {
+		t.Error("a directory was replaced by a file")
+	}
+
+	invalid := map[string][2]string{
+		"same path":        {b, b},
+		"same after clean": {b, filepath.Join(dir, ".", "b.txt")},
+		"into itself":      {tree, filepath.Join(tree, "sub", "tree")},
+		"empty":            {b, ""},
+	}
+	for name, paths := range invalid {
+		if err := service.RenamePath("s1", paths[0], paths[1], RenameOptions{Overwrite: true}); toThermicError(err).Code != ErrCodeInvalid {
+			t.Errorf("%s: RenamePath() = %v, want an invalid rename", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tree, "sub", "file")); err != nil {
+		t.Errorf("tree changed by rejected renames: %v", err)
+	}
+
+	renamed := filepath.Join(dir, "c.txt")
+	if err := service.RenamePath("s1", b, renamed, RenameOptions{}); err != nil {
+		t.Fatalf("RenamePath() to a free name returned error: %v", err)
+	}
+	if err := service.RenamePath("s1", b, renamed, RenameOptions{}); toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("renaming a missing source = %v, want not found", err)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+)
+
+// validateSessionSFTPConfig checks the tuning fields of a per-session override. Zero means
+// "use the global setting", so only non-zero values are range checked.
+func validateSessionSFTPConfig(cfg SFTPConfig) error {
+	checks := []struct {
+		name     string
+		value    int
+		min, max int
+	}{
+		{"max packet size", cfg.MaxPacketSize, MinSFTPMaxPacketSize, MaxSFTPMaxPacketSize},
+		{"buffer size", cfg.BufferSize, MinSFTPBufferSize, MaxSFTPBufferSize},
+		{"concurrent requests", cfg.ConcurrentRequests, MinSFTPConcurrentRequests, MaxSFTPConcurrentRequests},
+		{"parallel transfers", cfg.ParallelTransfers, MinSFTPParallelTransfers, MaxSFTPParallelTransfers},
+	}
+	for _, check := range checks {
+		if check.value == 0 {
+			continue
+		}
+		if check.value < check.min || check.value > check.max {
+			return fmt.Errorf("SFTP %s %d is out of range (%d-%d)", check.name, check.value, check.min, check.max)
+		}
+	}
+	return nil
+}
+
+// applySessionSFTPConfig overlays the non-zero tuning fields of a session's override on cfg
+func applySessionSFTPConfig(cfg SFTPConfig, override SFTPConfig) SFTPConfig {
+	if override.MaxPacketSize != 0 {
+		cfg.MaxPacketSize = override.MaxPacketSize
+	}
+	if override.BufferSize != 0 {
+		cfg.BufferSize = override.BufferSize
+	}
+	if override.ConcurrentRequests != 0 {
+		cfg.ConcurrentRequests = override.ConcurrentRequests
+	}
+	if override.ParallelTransfers != 0 {
+		cfg.ParallelTransfers = override.ParallelTransfers
+	}
+	return cfg
+}
+
+// sessionSFTPOverride returns the tuning set for a session with SetSessionSFTPConfig
+func (a *App) sessionSFTPOverride(sessionID string) (SFTPConfig, bool) {
+	a.ssh.sftpSessionConfigsMutex.RLock()
+	defer a.ssh.sftpSessionConfigsMutex.RUnlock()
+	override, exists := a.ssh.sftpSessionConfigs[sessionID]
+	return override, exists
+}
+
+// SetSessionSFTPConfig overrides the SFTP tuning of one session, e.g. more parallelism for a
+// host on the LAN than for one behind a VPN. Only MaxPacketSize, BufferSize,
+// ConcurrentRequests and ParallelTransfers are used; zero keeps the global setting. Packet
+// size and requests per file apply to the session's next SFTP client, the rest to the next transfer.
+func (a *App) SetSessionSFTPConfig(sessionID string, cfg SFTPConfig) error {
+	a.ssh.sshSessionsMutex.RLock()
+	_, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+	if !exists {
+		return newNotFoundError(ErrCategorySFTP, "SetSessionSFTPConfig", "SSH session %s not found", sessionID)
+	}
+
+	if err := validateSessionSFTPConfig(cfg); err != nil {
+		return err
+	}
+
+	override := applySessionSFTPConfig(SFTPConfig{}, cfg)
+	a.ssh.sftpSessionConfigsMutex.Lock()
+	if override == (SFTPConfig{}) {
+		delete(a.ssh.sftpSessionConfigs, sessionID)
+	} else {
+		a.ssh.sftpSessionConfigs[sessionID] = override
+	}
+	a.ssh.sftpSessionConfigsMutex.Unlock()
+
+	fmt.Printf("SFTP settings for session %s: %+v\n", sessionID, override)
+	return nil
+}
+
+// GetSessionSFTPConfig returns the SFTP settings a session's transfers use: the global
+// settings with the session's override and tuned buffer size applied
+func (a *App) GetSessionSFTPConfig(sessionID string) SFTPConfig {
+	return a.sessionSFTPConfig(sessionID)
+}
+
+// releaseSessionSFTPConfig forgets a session's SFTP override
+func (a *App) releaseSessionSFTPConfig(sessionID string) {
+	a.ssh.sftpSessionConfigsMutex.Lock()
+	defer a.ssh.sftpSessionConfigsMutex.Unlock()
+	delete(a.ssh.sftpSessionConfigs, sessionID)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSetSessionSFTPConfig(t *testing.T) {
+	app := NewApp()
+	sessionID := "session_sftp_config"
+
+	if err := app.SetSessionSFTPConfig(sessionID, SFTPConfig{ParallelTransfers: 8}); toThermicError(err).Code != ErrCodeNotFound {
+		t.Fatalf("SetSessionSFTPConfig() without a session = %v, want not found", err)
+	}
+
+	app.ssh.sshSessions[sessionID] = &SSHSession{}
+	invalid := []SFTPConfig{
+		{MaxPacketSize: MaxSFTPMaxPacketSize + 1},
+		{BufferSize: MinSFTPBufferSize - 1},
+		{ConcurrentRequests: -1},
+		{ParallelTransfers: MaxSFTPParallelTransfers + 1},
+	}
+	for _, cfg := range invalid {
+		if err := app.SetSessionSFTPConfig(sessionID, cfg); err == nil {
+			t.Errorf("SetSessionSFTPConfig(%+v) accepted an out of range value", cfg)
+		}
+	}
+
+	// Fields other than the tuning ones are ignored; zero keeps the global setting
+	global := app.getSFTPConfig()
+	if err := app.SetSessionSFTPConfig(sessionID, SFTPConfig{ConcurrentRequests: 96, ParallelTransfers: 8, AutoTune: true}); err != nil {
+		t.Fatalf("SetSessionSFTPConfig() returned error: %v", err)
+	}
+	cfg := app.GetSessionSFTPConfig(sessionID)
+	if cfg.ConcurrentRequests != 96 || cfg.ParallelTransfers != 8 {
+		t.Errorf("GetSessionSFTPConfig() = %+v, want the session's concurrency", cfg)
+	}
+	if cfg.MaxPacketSize != global.MaxPacketSize || cfg.BufferSize != global.BufferSize || cfg.AutoTune != global.AutoTune {
+		t.Errorf("GetSessionSFTPConfig() = %+v, want the global settings for fields not overridden", cfg)
+	}
+	if !app.sftpManualConcurrency(sessionID) || app.sftpManualConcurrency("session_other") {
+		t.Error("sftpManualConcurrency() should only report the session with an override")
+	}
+	if got := app.GetSessionSFTPConfig("session_other"); got != global {
+		t.Errorf("GetSessionSFTPConfig() for another session = %+v, want the global settings", got)
+	}
+
+	// A hand-set buffer size wins over the tuned one
+	app.config.config.SFTP.AutoTuneBuffer = true
+	app.ssh.sftpBufferSizes[sessionID] = &sftpBufferTune{result: TuneResult{SelectedBufferSize: 4 * 1024 * 1024}}
+	if got := app.GetSessionSFTPConfig(sessionID).BufferSize; got != 4*1024*1024 {
+		t.Errorf("BufferSize = %d, want the tuned size", got)
+	}
+	if err := app.SetSessionSFTPConfig(sessionID, SFTPConfig{BufferSize: 128 * 1024}); err != nil {
+		t.Fatalf("SetSessionSFTPConfig() returned error: %v", err)
+	}
+	if got := app.GetSessionSFTPConfig(sessionID).BufferSize; got != 128*1024 {
+		t.Errorf("BufferSize = %d, want the session's setting", got)
+	}
+
+	// An empty override clears the session's settings
+	if err := app.SetSessionSFTPConfig(sessionID, SFTPConfig{}); err != nil {
+		t.Fatalf("SetSessionSFTPConfig() returned error: %v", err)
+	}
+	if _, exists := app.sessionSFTPOverride(sessionID); exists {
+		t.Error("empty SetSessionSFTPConfig() kept the override")
+	}
+}
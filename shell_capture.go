@@ -0,0 +1,186 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Shell capture constants
+const (
+	// ShellCaptureTimeout bounds how long RunAndCapture waits for the command to finish
+	ShellCaptureTimeout = 30 * time.Second
+	// MaxShellCaptureBytes caps the output kept for one capture
+	MaxShellCaptureBytes = 1024 * 1024
+
+	shellCapturePrefix = "@@thermic-capture:"
+)
+
+// errShellCaptureAborted is returned when the session goes away during a capture
+var errShellCaptureAborted = errors.New("session closed before the command finished")
+
+// shellCapture collects the output a command prints between its begin and end markers
+type shellCapture struct {
+	begin   string
+	end     string
+	pending string // Output not yet matched against a marker
+	started bool   // The begin marker has been seen
+	scanned int    // Bytes of pending already searched for the end marker
+	output  string
+	err     error
+	done    chan struct{}
+	closed  bool
+}
+
+var (
+	// shellCaptures holds the running RunAndCapture call of each session
+	shellCaptures   = make(map[string]*shellCapture)
+	shellCapturesMu sync.Mutex
+)
+
+// newShellCapture builds a capture with markers no earlier output can contain
+func newShellCapture() (*shellCapture, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to create capture markers: %w", err)
+	}
+	id := hex.EncodeToString(nonce)
+	return &shellCapture{
+		begin: shellCapturePrefix + id + ":begin",
+		end:   shellCapturePrefix + id + ":end",
+		done:  make(chan struct{}),
+	}, nil
+}
+
+// markerEcho returns a command printing marker on its own line. The marker is split across two
+// quoted words so the shell's echo of the typed line never matches it.
+func markerEcho(marker string) string {
+	split := len(shellCapturePrefix) / 2
+	return "echo " + shellQuote(marker[:split]) + shellQuote(marker[split:])
+}
+
+// command returns the line typed into the shell. eval keeps a trailing comment or & in the
+// command from swallowing the end marker; the leading space keeps the line out of history
+// where HISTCONTROL=ignorespace.
+func (c *shellCapture) command(command string) string {
+	return " " + markerEcho(c.begin) + "; eval " + shellQuote(command) + "; " + markerEcho(c.end) + "\r"
+}
+
+// feed scans a chunk of session output. Markers may be split across chunks, so whatever could
+// still be the start of one is kept for the next call.
+func (c *shellCapture) feed(data string) {
+	if c.closed {
+		return
+	}
+	c.pending += data
+
+	if !c.started {
+		idx := strings.Index(c.pending, c.begin)
+		if idx < 0 {
+			if keep := len(c.begin) - 1; len(c.pending) > keep {
+				c.pending = c.pending[len(c.pending)-keep:]
+			}
+			return
+		}
+		// Output starts on the line after the marker
+		lineEnd := strings.IndexByte(c.pending[idx:], '\n')
+		if lineEnd < 0 {
+			c.pending = c.pending[idx:]
+			return
+		}
+		c.pending = c.pending[idx+lineEnd+1:]
+		c.started = true
+	}
+
+	if idx := strings.Index(c.pending[c.scanned:], c.end); idx >= 0 {
+		c.finish(strings.ReplaceAll(c.pending[:c.scanned+idx], "\r\n", "\n"), nil)
+		return
+	}
+	if len(c.pending) > MaxShellCaptureBytes {
+		c.finish("", fmt.Errorf("command output exceeds %d bytes", MaxShellCaptureBytes))
+		return
+	}
+	c.scanned = len(c.pending) - len(c.end) + 1
+	if c.scanned < 0 {
+		c.scanned = 0
+	}
+}
+
+// finish records the result and wakes RunAndCapture
+func (c *shellCapture) finish(output string, err error) {
+	c.output, c.err = output, err
+	c.pending = ""
+	c.closed = true
+	close(c.done)
+}
+
+// RunAndCapture types a command into the session's interactive shell and returns what it
+// printed, without the surrounding prompt or the echoed command line. The command runs in the
+// user's shell as if typed, so it sees and changes the shell's directory and variables, and it
+// stays visible in the terminal. The shell must be POSIX-compatible and waiting at a prompt.
+func (a *App) RunAndCapture(sessionID, command string) (string, error) {
+	if strings.TrimSpace(command) == "" {
+		return "", fmt.Errorf("invalid command: cannot be empty")
+	}
+
+	capture, err := newShellCapture()
+	if err != nil {
+		return "", err
+	}
+	shellCapturesMu.Lock()
+	if _, busy := shellCaptures[sessionID]; busy {
+		shellCapturesMu.Unlock()
+		return "", fmt.Errorf("a captured command is already running in session %s", sessionID)
+	}
+	shellCaptures[sessionID] = capture
+	shellCapturesMu.Unlock()
+
+	defer func() {
+		shellCapturesMu.Lock()
+		if shellCaptures[sessionID] == capture {
+			delete(shellCaptures, sessionID)
+		}
+		shellCapturesMu.Unlock()
+	}()
+
+	if err := a.WriteToShell(sessionID, capture.command(command)); err != nil {
+		return "", fmt.Errorf("failed to write command: %w", err)
+	}
+
+	select {
+	case <-capture.done:
+	case <-time.After(ShellCaptureTimeout):
+		return "", fmt.Errorf("timed out waiting for command output from session %s", sessionID)
+	}
+
+	shellCapturesMu.Lock()
+	defer shellCapturesMu.Unlock()
+	return capture.output, capture.err
+}
+
+// recordShellCapture feeds session output to a running RunAndCapture
+func recordShellCapture(sessionID, data string) {
+	shellCapturesMu.Lock()
+	defer shellCapturesMu.Unlock()
+
+	if capture, exists := shellCaptures[sessionID]; exists {
+		capture.feed(data)
+	}
+}
+
+// abortShellCapture fails a session's running capture, as when the session closes
+func abortShellCapture(sessionID string) {
+	shellCapturesMu.Lock()
+	defer shellCapturesMu.Unlock()
+
+	if capture, exists := shellCaptures[sessionID]; exists {
+		if !capture.closed {
+			capture.finish("", errShellCaptureAborted)
+		}
+		delete(shellCaptures, sessionID)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeShellInput runs each line typed into it with sh and plays the echoed line, the output
+// and a prompt back through the session's output handling in small chunks, as a PTY would
+type fakeShellInput struct {
+	app       *App
+	sessionID string
+}
+
+func (f *fakeShellInput) Write(p []byte) (int, error) {
+	line := strings.TrimSuffix(string(p), "\r")
+	output, _ := exec.Command("sh", "-c", line).CombinedOutput()
+	terminal := line + "\r\n" + strings.ReplaceAll(string(output), "\n", "\r\n") + "$ "
+	for len(terminal) > 0 {
+		n := min(7, len(terminal))
+		f.app.emitSessionOutput(f.sessionID, terminal[:n])
+		terminal = terminal[n:]
+	}
+	return len(p), nil
+}
+
+func (f *fakeShellInput) Close() error { return nil }
+
+func TestRunAndCapture(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	app := NewApp()
+	sessionID := "session_capture"
+	app.ssh.sshSessions[sessionID] = &SSHSession{sessionID: sessionID, stdin: &fakeShellInput{app: app, sessionID: sessionID}}
+	t.Cleanup(func() { app.ReleaseSession(sessionID) })
+
+	output, err := app.RunAndCapture(sessionID, "printf 'M  main.go\\n?? notes.txt\\n' # it's status")
+	if err != nil {
+		t.Fatalf("RunAndCapture() returned error: %v", err)
+	}
+	if output != "M  main.go\n?? notes.txt\n" {
+		t.Errorf("RunAndCapture() = %q, want the command's output only", output)
+	}
+
+	if output, err := app.RunAndCapture(sessionID, "true"); err != nil || output != "" {
+		t.Errorf("RunAndCapture() of a silent command = %q, %v; want empty output", output, err)
+	}
+
+	if _, err := app.RunAndCapture("session_unknown", "true"); err == nil || toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("RunAndCapture() of an unknown session = %v, want not found", err)
+	}
+}
+
+func TestShellCaptureMarkersAcrossChunks(t *testing.T) {
+	capture, err := newShellCapture()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := "prompt$ " + capture.command("ls") + "\n" + capture.begin + "\r\nfile\r\n" + capture.end + "\r\nprompt$ "
+	for i := 0; i < len(stream); i++ {
+		capture.feed(stream[i : i+1])
+	}
+	select {
+	case <-capture.done:
+	default:
+		t.Fatal("capture did not finish")
+	}
+	if capture.err != nil || capture.output != "file\n" {
+		t.Errorf("capture = %q, %v; want file", capture.output, capture.err)
+	}
+}
+
+func TestShellCaptureAbortedWithSession(t *testing.T) {
+	capture, err := newShellCapture()
+	if err != nil {
+		t.Fatal(err)
+	}
+	shellCapturesMu.Lock()
+	shellCaptures["session_aborted"] = capture
+	shellCapturesMu.Unlock()
+
+	abortShellCapture("session_aborted")
+	<-capture.done
+	if capture.err != errShellCaptureAborted {
+		t.Errorf("capture error = %v, want %v", capture.err, errShellCaptureAborted)
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Shell integration sequences tracked in session output
+const (
+	oscCurrentDirectory = "7;"     // OSC 7 ; file://host/path - the shell's working directory
+	oscSemanticPrompt   = "133;"   // OSC 133 ; A|B|C|D [; params] - prompt and command boundaries
+	maxPendingOSCBytes  = 4096     // A sequence still unterminated after this many bytes is dropped
+	oscIntroducer       = "\x1b]"  // Starts every OSC sequence
+	oscStringTerminator = "\x1b\\" // ST; BEL ends a sequence as well
+)
+
+// Command boundaries, sent as command-boundary events for the OSC 133 sequences
+const (
+	CommandBoundaryEvent = "command-boundary"
+	BoundaryPromptStart  = "prompt-start"  // 133;A - the prompt is about to be drawn
+	BoundaryCommandStart = "command-start" // 133;B - the prompt ended; the user types the command
+	BoundaryOutputStart  = "output-start"  // 133;C - the command was entered and runs
+	BoundaryCommandEnd   = "command-end"   // 133;D [; exit code] - the command finished
+)
+
+// semanticPromptBoundaries maps the OSC 133 marks to their boundaries; other marks are ignored
+var semanticPromptBoundaries = map[string]string{
+	"A": BoundaryPromptStart,
+	"B": BoundaryCommandStart,
+	"C": BoundaryOutputStart,
+	"D": BoundaryCommandEnd,
+}
+
+// Shell readiness constants
+const (
+	ShellReadyPollInterval = 100 * time.Millisecond
+	ShellReadyQuietPeriod  = 500 * time.Millisecond // A prompt must stay unchanged this long; MOTD output may still follow a login
+	MaxShellReadyTimeout   = 10 * time.Minute
+)
+
+// shellPromptPattern matches the end of a typical prompt: "user@host:~$ ", "root# ", "% ",
+// "PS C:\> " or the arrows of popular themes
+var shellPromptPattern = regexp.MustCompile(`[$#%>❯➜»]\s*$`)
+
+// commandBoundary is an OSC 133 mark seen in a session's output
+type commandBoundary struct {
+	kind       string
+	exitCode   *int  // command-end only, when the shell reported one
+	durationMs int64 // command-end only, when its output-start was seen
+}
+
+// shellIntegrationState is what a session's shell has reported about itself
+type shellIntegrationState struct {
+	cwd           string
+	exitCode      string // Empty until a command reported finishing
+	pending       string // Start of a sequence split across reads
+	outputStarted time.Time
+	boundaries    []commandBoundary // Seen since the last takeCommandBoundaries
+}
+
+var shellIntegrationStates = make(map[string]*shellIntegrationState)
+var shellIntegrationStatesMu sync.Mutex
+
+// parseOSC7Directory returns the path of an OSC 7 "file://host/path" payload. The remote host
+// chooses the path and it ends up in tab titles, so paths with control characters or HTML
+// metacharacters are rejected.
+func parseOSC7Directory(payload string) (string, bool) {
+	path := payload
+	if !strings.HasPrefix(payload, "/") {
+		u, err := url.Parse(payload)
+		if err != nil || u.Scheme != "file" || u.Path == "" {
+			return "", false
+		}
+		path = u.Path
+	}
+	for _, r := range path {
+		if unicode.IsControl(r) || strings.ContainsRune(`<>"'&`, r) {
+			return "", false
+		}
+	}
+	return path, true
+}
+
+// apply records an OSC sequence body and reports whether the state changed
+func (s *shellIntegrationState) apply(body string) bool {
+	switch {
+	case strings.HasPrefix(body, oscCurrentDirectory):
+		if cwd, ok := parseOSC7Directory(body[len(oscCurrentDirectory):]); ok && cwd != s.cwd {
+			s.cwd = cwd
+			return true
+		}
+	case strings.HasPrefix(body, oscSemanticPrompt):
+		// "133;D;2" - the mark, then its parameters
+		params := strings.Split(body[len(oscSemanticPrompt):], ";")
+		kind, known := semanticPromptBoundaries[params[0]]
+		if !known {
+			return false
+		}
+		boundary := commandBoundary{kind: kind}
+		changed := false
+		switch kind {
+		case BoundaryOutputStart:
+			s.outputStarted = time.Now()
+		case BoundaryCommandEnd:
+			if !s.outputStarted.IsZero() {
+				boundary.durationMs = time.Since(s.outputStarted).Milliseconds()
+				s.outputStarted = time.Time{}
+			}
+			// The exit code is absent when unknown, as for an empty command line
+			if len(params) > 1 && params[1] != "" {
+				if code, err := strconv.Atoi(params[1]); err == nil {
+					boundary.exitCode = &code
+				}
+				changed = params[1] != s.exitCode
+				s.exitCode = params[1]
+			}
+		}
+		s.boundaries = append(s.boundaries, boundary)
+		return changed
+	}
+	return false
+}
+
+// recordShellIntegration scans session output for the working directory and OSC 133 sequences,
+// recording command boundaries, and reports whether the directory or exit code changed.
+// Sessions whose shell never emits an OSC sequence get no state.
+func recordShellIntegration(sessionID, data string) bool {
+	shellIntegrationStatesMu.Lock()
+	defer shellIntegrationStatesMu.Unlock()
+
+	state, exists := shellIntegrationStates[sessionID]
+	if !exists {
+		if !strings.Contains(data, oscIntroducer) {
+			return false
+		}
+		state = &shellIntegrationState{}
+		shellIntegrationStates[sessionID] = state
+	}
+
+	buf := state.pending + data
+	state.pending = ""
+	changed := false
+	for {
+		start := strings.Index(buf, oscIntroducer)
+		if start < 0 {
+			// An ESC at the very end may be the first half of an introducer
+			if strings.HasSuffix(buf, "\x1b") {
+				state.pending = "\x1b"
+			}
+			break
+		}
+		body := buf[start+len(oscIntroducer):]
+		end, terminatorLen := strings.IndexByte(body, '\a'), 1
+		if st := strings.Index(body, oscStringTerminator); st >= 0 && (end < 0 || st < end) {
+			end, terminatorLen = st, len(oscStringTerminator)
+		}
+		if end < 0 {
+			if len(body) < maxPendingOSCBytes {
+				state.pending = buf[start:]
+			}
+			break
+		}
+		if state.apply(body[:end]) {
+			changed = true
+		}
+		buf = body[end+terminatorLen:]
+	}
+	return changed
+}
+
+// shellIntegrationValues returns the working directory and last exit code a session's shell reported
+func shellIntegrationValues(sessionID string) (cwd, exitCode string) {
+	shellIntegrationStatesMu.Lock()
+	defer shellIntegrationStatesMu.Unlock()
+	if state, exists := shellIntegrationStates[sessionID]; exists {
+		return state.cwd, state.exitCode
+	}
+	return "", ""
+}
+
+// takeCommandBoundaries returns and clears the boundaries recorded for a session
+func takeCommandBoundaries(sessionID string) []commandBoundary {
+	shellIntegrationStatesMu.Lock()
+	defer shellIntegrationStatesMu.Unlock()
+	state, exists := shellIntegrationStates[sessionID]
+	if !exists {
+		return nil
+	}
+	boundaries := state.boundaries
+	state.boundaries = nil
+	return boundaries
+}
+
+// emitCommandBoundaries sends a command-boundary event for each boundary, in order
+func emitCommandBoundaries(emitter Emitter, sessionID string, boundaries []commandBoundary) {
+	for _, boundary := range boundaries {
+		event := map[string]interface{}{
+			"sessionId": sessionID,
+			"kind":      boundary.kind,
+		}
+		if boundary.exitCode != nil {
+			event["exitCode"] = *boundary.exitCode
+		}
+		if boundary.durationMs > 0 {
+			event["durationMs"] = boundary.durationMs
+		}
+		emitter.Emit(CommandBoundaryEvent, event)
+	}
+}
+
+// forgetShellIntegration drops what a session's shell reported
+func forgetShellIntegration(sessionID string) {
+	shellIntegrationStatesMu.Lock()
+	defer shellIntegrationStatesMu.Unlock()
+	delete(shellIntegrationStates, sessionID)
+}
+
+// sessionTabStatus returns the connection status of the tab showing a session
+func (a *App) sessionTabStatus(sessionID string) (string, bool) {
+	a.terminal.mutex.RLock()
+	defer a.terminal.mutex.RUnlock()
+
+	for _, tab := range a.terminal.tabs {
+		if tab.SessionID == sessionID {
+			return tab.Status, true
+		}
+	}
+	return "", false
+}
+
+// WaitForShellReady waits until a session's shell shows a prompt: the unfinished last line of
+// its output ends like a prompt and stays unchanged for ShellReadyQuietPeriod. Fails when the
+// tab's connection fails or the tab is closed first, or after timeoutMs.
+func (a *App) WaitForShellReady(sessionID string, timeoutMs int) error {
+	timeout := time.Duration(timeoutMs) * time.Millisecond
+	if timeout <= 0 || timeout > MaxShellReadyTimeout {
+		return fmt.Errorf("timeout must be between 1 and %d milliseconds, got: %d", MaxShellReadyTimeout.Milliseconds(), timeoutMs)
+	}
+
+	deadline := time.Now().Add(timeout)
+	var prompt string
+	var promptSince time.Time
+	var seen uint64
+	for {
+		status, exists := a.sessionTabStatus(sessionID)
+		if !exists {
+			return newNotFoundError(ErrCategoryTerminal, "WaitForShellReady", "no tab for session %s", sessionID)
+		}
+		if status == StatusFailed.String() || status == StatusDisconnected.String() {
+			return fmt.Errorf("session %s %s before its shell was ready", sessionID, status)
+		}
+
+		// Only the unfinished line counts; a prompt is never followed by a newline
+		lines, next := scrollbackLinesSince(sessionID, seen, true)
+		seen = next
+		line := ""
+		if n := len(lines); n > 0 && lines[n-1].seq == next {
+			line = lines[n-1].text
+		}
+		switch {
+		case !shellPromptPattern.MatchString(line):
+			prompt = ""
+		case line != prompt:
+			prompt, promptSince = line, time.Now()
+		case time.Since(promptSince) >= ShellReadyQuietPeriod:
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("session %s showed no shell prompt within %s", sessionID, timeout)
+		}
+		time.Sleep(ShellReadyPollInterval)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCommandBoundaries(t *testing.T) {
+	sessionID := "session_boundaries"
+	defer forgetShellIntegration(sessionID)
+
+	// A prompt, a command typed and run, and the next prompt, split mid-sequence
+	recordShellIntegration(sessionID, "\x1b]133;A\a$ \x1b]133;B\x1b\\ls\r\n\x1b]13")
+	recordShellIntegration(sessionID, "3;C\afile\r\n\x1b]133;D;2\a\x1b]133;A;cl=m\a$ \x1b]133;P;k=i\a")
+
+	emitter := &recordingEmitter{}
+	emitCommandBoundaries(emitter, sessionID, takeCommandBoundaries(sessionID))
+	want := []string{BoundaryPromptStart, BoundaryCommandStart, BoundaryOutputStart, BoundaryCommandEnd, BoundaryPromptStart}
+	if len(emitter.events) != len(want) {
+		t.Fatalf("got %d boundary events, want %d: %v", len(emitter.events), len(want), emitter.events)
+	}
+	for i, event := range emitter.events {
+		if emitter.names[i] != CommandBoundaryEvent || event["kind"] != want[i] || event["sessionId"] != sessionID {
+			t.Errorf("event %d = %s %v, want %s", i, emitter.names[i], event, want[i])
+		}
+	}
+	if end := emitter.events[3]; end["exitCode"] != 2 {
+		t.Errorf("command-end event = %v, want exit code 2", end)
+	}
+	if _, hasCode := emitter.events[0]["exitCode"]; hasCode {
+		t.Error("prompt-start event carries an exit code")
+	}
+
+	// Taking the boundaries clears them
+	if boundaries := takeCommandBoundaries(sessionID); len(boundaries) != 0 {
+		t.Errorf("takeCommandBoundaries() gave %d boundaries a second time", len(boundaries))
+	}
+
+	// An empty command line finishes without an exit code
+	recordShellIntegration(sessionID, "\x1b]133;D\a")
+	if boundaries := takeCommandBoundaries(sessionID); len(boundaries) != 1 || boundaries[0].exitCode != nil {
+		t.Errorf("takeCommandBoundaries() = %v, want a command-end without exit code", boundaries)
+	}
+}
+
+func TestCommandBoundariesIgnoreShellsWithoutIntegration(t *testing.T) {
+	sessionID := "session_plain_shell"
+	defer forgetShellIntegration(sessionID)
+
+	recordShellIntegration(sessionID, "$ ls\r\nfile\r\n$ ")
+	if boundaries := takeCommandBoundaries(sessionID); boundaries != nil {
+		t.Errorf("takeCommandBoundaries() = %v for a shell without OSC 133", boundaries)
+	}
+	shellIntegrationStatesMu.Lock()
+	_, tracked := shellIntegrationStates[sessionID]
+	shellIntegrationStatesMu.Unlock()
+	if tracked {
+		t.Error("a shell without integration sequences got state")
+	}
+}
+
+func TestParseOSC7Directory(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    string
+		ok      bool
+	}{
+		{"file://host/home/alice", "/home/alice", true},
+		{"file://host/srv/my%20app", "/srv/my app", true},
+		{"/var/log", "/var/log", true},
+		{"http://host/home", "", false},
+		{"file://host", "", false},
+		{"file://host/tmp/<img src=x onerror=alert(1)>", "", false},
+		{"file://host/tmp/%3Cscript%3E", "", false},
+		{"/tmp/a&b", "", false},
+		{"/tmp/it's", "", false},
+		{"file://host/tmp/%1B%5B2J", "", false},
+		{"/tmp/a\x07b", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseOSC7Directory(tt.payload)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseOSC7Directory(%q) = %q, %v; want %q, %v", tt.payload, got, ok, tt.want, tt.ok)
+		}
+	}
+}
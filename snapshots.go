@@ -0,0 +1,156 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// Snapshots of the managers' collections, copied under their locks so callers can read and
+// serialize them without racing the live maps
+
+// cloneSSHConfig copies an SSH config along with its proxy and jump hosts
+func cloneSSHConfig(config *SSHConfig) *SSHConfig {
+	if config == nil {
+		return nil
+	}
+	clone := *config
+	if config.Proxy != nil {
+		proxy := *config.Proxy
+		clone.Proxy = &proxy
+	}
+	clone.JumpHosts = append([]JumpHostConfig(nil), config.JumpHosts...)
+	return &clone
+}
+
+// cloneNomadConfig copies a Nomad config
+func cloneNomadConfig(config *NomadConfig) *NomadConfig {
+	if config == nil {
+		return nil
+	}
+	clone := *config
+	return &clone
+}
+
+// cloneStringMap copies a map, keeping nil as nil
+func cloneStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	clone := make(map[string]string, len(m))
+	for k, v := range m {
+		clone[k] = v
+	}
+	return clone
+}
+
+// cloneProfile copies a profile with everything it points to, so the copy can be read or
+// changed without the profile lock
+func cloneProfile(profile *Profile) *Profile {
+	clone := *profile
+	clone.Environment = cloneStringMap(profile.Environment)
+	clone.Shortcuts = cloneStringMap(profile.Shortcuts)
+	clone.SSHConfig = cloneSSHConfig(profile.SSHConfig)
+	clone.NomadConfig = cloneNomadConfig(profile.NomadConfig)
+	clone.Tags = append([]string(nil), profile.Tags...)
+	clone.PreConnectHooks = append([]ConnectHook(nil), profile.PreConnectHooks...)
+	clone.PostConnectHooks = append([]ConnectHook(nil), profile.PostConnectHooks...)
+	clone.ConnectionSequence = append([]ConnectionStep(nil), profile.ConnectionSequence...)
+	if profile.FileHistory != nil {
+		clone.FileHistory = make([]*FileHistoryEntry, len(profile.FileHistory))
+		for i, entry := range profile.FileHistory {
+			if entry != nil {
+				entryCopy := *entry
+				clone.FileHistory[i] = &entryCopy
+			}
+		}
+	}
+	if profile.Discovery != nil {
+		discovery := *profile.Discovery
+		clone.Discovery = &discovery
+	}
+	return &clone
+}
+
+// cloneTab copies a tab with everything it points to
+func cloneTab(tab *Tab) *Tab {
+	clone := *tab
+	clone.SSHConfig = cloneSSHConfig(tab.SSHConfig)
+	clone.NomadConfig = cloneNomadConfig(tab.NomadConfig)
+	clone.Tags = append([]string(nil), tab.Tags...)
+	if tab.Maintenance != nil {
+		banner := *tab.Maintenance
+		clone.Maintenance = &banner
+	}
+	return &clone
+}
+
+// SnapshotTabs returns copies of the open tabs, oldest first
+func (a *App) SnapshotTabs() []*Tab {
+	a.terminal.mutex.RLock()
+	tabs := make([]*Tab, 0, len(a.terminal.tabs))
+	for _, tab := range a.terminal.tabs {
+		tabs = append(tabs, cloneTab(tab))
+	}
+	a.terminal.mutex.RUnlock()
+
+	sort.SliceStable(tabs, func(i, j int) bool { return tabs[i].Created.Before(tabs[j].Created) })
+	return tabs
+}
+
+// SnapshotProfiles returns copies of the loaded profiles, sorted by ID
+func (a *App) SnapshotProfiles() []*Profile {
+	profiles := a.profiles.Profiles()
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].ID < profiles[j].ID })
+	return profiles
+}
+
+// SSHSessionSnapshot is the state of an SSH connection at the time of a snapshot
+type SSHSessionSnapshot struct {
+	SessionID         string    `json:"sessionId"`
+	LastActivity      time.Time `json:"lastActivity"`
+	Hanging           bool      `json:"hanging"`
+	Cleaning          bool      `json:"cleaning"`          // Being closed
+	Shell             bool      `json:"shell"`             // Has an interactive shell; file explorer only connections don't
+	MonitoringEnabled bool      `json:"monitoringEnabled"` // The monitoring connection is up
+	JumpHosts         int       `json:"jumpHosts"`
+	Goroutines        int       `json:"goroutines"` // Tracked goroutines still running
+}
+
+// sshSessionList returns the live SSH sessions, sorted by session ID. The sessions themselves
+// are shared; use their accessors to read them.
+func (a *App) sshSessionList() []*SSHSession {
+	a.ssh.sshSessionsMutex.RLock()
+	sessions := make([]*SSHSession, 0, len(a.ssh.sshSessions))
+	for _, sshSession := range a.ssh.sshSessions {
+		if sshSession != nil {
+			sessions = append(sessions, sshSession)
+		}
+	}
+	a.ssh.sshSessionsMutex.RUnlock()
+
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].sessionID < sessions[j].sessionID })
+	return sessions
+}
+
+// SnapshotSSHSessions returns the state of the SSH connections, sorted by session ID
+func (a *App) SnapshotSSHSessions() []SSHSessionSnapshot {
+	sessions := a.sshSessionList()
+	snapshots := make([]SSHSessionSnapshot, 0, len(sessions))
+	for _, sshSession := range sessions {
+		sshSession.monitoringMutex.RLock()
+		monitoring := sshSession.monitoringEnabled && sshSession.monitoringClient != nil
+		sshSession.monitoringMutex.RUnlock()
+
+		snapshots = append(snapshots, SSHSessionSnapshot{
+			SessionID:         sshSession.sessionID,
+			LastActivity:      sshSession.GetLastActivity(),
+			Hanging:           sshSession.IsHanging(),
+			Cleaning:          sshSession.IsCleaning(),
+			Shell:             sshSession.session != nil,
+			MonitoringEnabled: monitoring,
+			JumpHosts:         len(sshSession.jumpClients),
+			Goroutines:        sshSession.goroutineCount(),
+		})
+	}
+	return snapshots
+}
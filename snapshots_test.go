@@ -0,0 +1,126 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSnapshotTabs(t *testing.T) {
+	app := NewApp()
+	now := time.Now()
+	app.terminal.tabs["tab_new"] = &Tab{ID: "tab_new", Created: now}
+	app.terminal.tabs["tab_old"] = &Tab{
+		ID:          "tab_old",
+		Created:     now.Add(-time.Minute),
+		Tags:        []string{"prod"},
+		SSHConfig:   &SSHConfig{Host: "db.internal", JumpHosts: []JumpHostConfig{{Host: "bastion"}}, Proxy: &ProxyConfig{Mode: ProxyModeNone}},
+		Maintenance: &MaintenanceBanner{Label: "patching"},
+	}
+
+	tabs := app.SnapshotTabs()
+	if len(tabs) != 2 || tabs[0].ID != "tab_old" || tabs[1].ID != "tab_new" {
+		t.Fatalf("SnapshotTabs() = %v, want tab_old then tab_new", tabs)
+	}
+
+	// Changing the snapshot leaves the live tab alone
+	tabs[0].Title = "changed"
+	tabs[0].Tags[0] = "changed"
+	tabs[0].SSHConfig.JumpHosts[0].Host = "changed"
+	tabs[0].SSHConfig.Proxy.Mode = "changed"
+	tabs[0].Maintenance.Label = "changed"
+	live := app.terminal.tabs["tab_old"]
+	if live.Title != "" || live.Tags[0] != "prod" || live.SSHConfig.JumpHosts[0].Host != "bastion" ||
+		live.SSHConfig.Proxy.Mode != ProxyModeNone || live.Maintenance.Label != "patching" {
+		t.Errorf("live tab changed through its snapshot: %+v", live)
+	}
+}
+
+func TestSnapshotProfiles(t *testing.T) {
+	app := NewApp()
+	app.profiles.profiles["b"] = &Profile{ID: "b", Name: "Beta"}
+	app.profiles.profiles["a"] = &Profile{
+		ID:          "a",
+		Name:        "Alpha",
+		Environment: map[string]string{"ENV": "prod"},
+		Tags:        []string{"db"},
+		SSHConfig:   &SSHConfig{Host: "db.internal", JumpHosts: []JumpHostConfig{{Host: "bastion"}}},
+		FileHistory: []*FileHistoryEntry{{Path: "/etc/hosts", AccessCount: 1}},
+		Discovery:   &DiscoveryInfo{ProviderID: "aws"},
+	}
+
+	profiles := app.SnapshotProfiles()
+	if len(profiles) != 2 || profiles[0].ID != "a" || profiles[1].ID != "b" {
+		t.Fatalf("SnapshotProfiles() = %v, want a then b", profiles)
+	}
+	if !reflect.DeepEqual(profiles[0], app.profiles.profiles["a"]) {
+		t.Errorf("snapshot = %+v, want a copy of the profile", profiles[0])
+	}
+
+	profiles[0].Environment["ENV"] = "changed"
+	profiles[0].Tags[0] = "changed"
+	profiles[0].SSHConfig.JumpHosts[0].Host = "changed"
+	profiles[0].FileHistory[0].AccessCount = 99
+	profiles[0].Discovery.ProviderID = "changed"
+	live := app.profiles.profiles["a"]
+	if live.Environment["ENV"] != "prod" || live.Tags[0] != "db" || live.SSHConfig.JumpHosts[0].Host != "bastion" ||
+		live.FileHistory[0].AccessCount != 1 || live.Discovery.ProviderID != "aws" {
+		t.Errorf("live profile changed through its snapshot: %+v", live)
+	}
+
+	// Query methods hand out copies too
+	app.profiles.profiles["b"].Type = ProfileTypeSSH
+	found := app.GetProfilesByType(ProfileTypeSSH)
+	if len(found) != 1 || found[0] == app.profiles.profiles["b"] {
+		t.Errorf("GetProfilesByType() = %v, want a copy of b", found)
+	}
+}
+
+// Meant for -race: the tree's profiles are read after the profile lock is released
+func TestSnapshotProfilesConcurrentWithUpdates(t *testing.T) {
+	app := NewApp()
+	app.profiles.profiles["p"] = &Profile{ID: "p", Name: "P", Tags: []string{"x"}}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			app.profiles.mutex.Lock()
+			app.profiles.profiles["p"].UsageCount++
+			app.profiles.profiles["p"].Tags = append(app.profiles.profiles["p"].Tags, "y")
+			app.profiles.mutex.Unlock()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			for _, node := range app.GetProfileTree() {
+				if node.Profile != nil {
+					_ = len(node.Profile.Tags) + node.Profile.UsageCount
+				}
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+func TestSnapshotSSHSessions(t *testing.T) {
+	app := NewApp()
+	active := time.Now().Add(-time.Minute)
+	hanging := &SSHSession{sessionID: "session_b", lastActivity: active}
+	hanging.SetHanging(true)
+	app.ssh.sshSessions["session_b"] = hanging
+	app.ssh.sshSessions["session_a"] = &SSHSession{sessionID: "session_a", monitoringEnabled: true}
+	app.ssh.sshSessions["session_nil"] = nil
+
+	snapshots := app.SnapshotSSHSessions()
+	want := []SSHSessionSnapshot{
+		{SessionID: "session_a"}, // Monitoring is only up with a client
+		{SessionID: "session_b", LastActivity: active, Hanging: true},
+	}
+	if !reflect.DeepEqual(snapshots, want) {
+		t.Errorf("SnapshotSSHSessions() = %+v, want %+v", snapshots, want)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"golang.org/x/crypto/ssh"
+)
+
+// MaxJumpHops is the longest jump host chain a connection may go through
+const MaxJumpHops = 5
+
+// JumpHostConfig is one hop of a ProxyJump-style chain. Each hop authenticates with its own
+// credentials; the first hop is reached through the connection's proxy, every later hop
+// through the one before it.
+type JumpHostConfig struct {
+	Host     string `yaml:"host" json:"host"`
+	Port     int    `yaml:"port,omitempty" json:"port,omitempty"` // 0 means 22
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password,omitempty" json:"password,omitempty"`
+	KeyPath  string `yaml:"key_path,omitempty" json:"keyPath,omitempty"`
+	// AgentForwarding authenticates this hop with the local ssh-agent. Hops only relay TCP,
+	// so the agent itself is never exposed to them.
+	AgentForwarding bool `yaml:"agent_forwarding,omitempty" json:"agentForwarding,omitempty"`
+}
+
+// address returns the hop's host:port
+func (j JumpHostConfig) address() string {
+	port := j.Port
+	if port == 0 {
+		port = 22
+	}
+	return net.JoinHostPort(j.Host, strconv.Itoa(port))
+}
+
+// jumpHostKey identifies a host:port for cycle detection
+func jumpHostKey(host string, port int) string {
+	return JumpHostConfig{Host: strings.ToLower(host), Port: port}.address()
+}
+
+// validateJumpChain checks the hops of a chain: at most MaxJumpHops, no host:port visited
+// twice, and well-formed host, port, username and key path on each
+func validateJumpChain(hosts []JumpHostConfig) error {
+	if len(hosts) > MaxJumpHops {
+		return fmt.Errorf("jump host chain has %d hops, the maximum is %d", len(hosts), MaxJumpHops)
+	}
+
+	seen := make(map[string]int, len(hosts))
+	for i, hop := range hosts {
+		n := i + 1
+		if hop.Host == "" {
+			return fmt.Errorf("jump host %d: host cannot be empty", n)
+		}
+		if strings.ContainsAny(hop.Host, " \t\r\n/@") {
+			return fmt.Errorf("jump host %d: invalid host %q", n, hop.Host)
+		}
+		if hop.Port < 0 || hop.Port > 65535 {
+			return fmt.Errorf("jump host %d: port must be between 1 and 65535, got: %d", n, hop.Port)
+		}
+		if hop.Username == "" {
+			return fmt.Errorf("jump host %d (%s): username cannot be empty", n, hop.Host)
+		}
+		if strings.ContainsAny(hop.Username, " \t\r\n@:") {
+			return fmt.Errorf("jump host %d (%s): invalid username %q", n, hop.Host, hop.Username)
+		}
+		if strings.ContainsAny(hop.KeyPath, "\r\n\x00") {
+			return fmt.Errorf("jump host %d (%s): invalid key path", n, hop.Host)
+		}
+
+		key := jumpHostKey(hop.Host, hop.Port)
+		if previous, exists := seen[key]; exists {
+			return fmt.Errorf("jump host chain loops: %s is both hop %d and hop %d", key, previous, n)
+		}
+		seen[key] = n
+	}
+	return nil
+}
+
+// validateJumpHosts checks a connection's jump chain, including that the destination isn't
+// one of its own hops
+func validateJumpHosts(config *SSHConfig) error {
+	if err := validateJumpChain(config.JumpHosts); err != nil {
+		return err
+	}
+	destination := jumpHostKey(config.Host, config.Port)
+	for i, hop := range config.JumpHosts {
+		if jumpHostKey(hop.Host, hop.Port) == destination {
+			return fmt.Errorf("jump host chain loops: destination %s is also hop %d", destination, i+1)
+		}
+	}
+	return nil
+}
+
+// jumpHostClientConfig builds the client config of one hop from its own credentials. Host keys
+// are verified like the destination's, and the connection timeout is shared.
+func (a *App) jumpHostClientConfig(hop JumpHostConfig, sshConfig *ssh.ClientConfig) (*ssh.ClientConfig, error) {
+	hopConfig := &ssh.ClientConfig{
+		User:            hop.Username,
+		HostKeyCallback: sshConfig.HostKeyCallback,
+		Timeout:         sshConfig.Timeout,
+	}
+	if hop.Password != "" {
+		hopConfig.Auth = append(hopConfig.Auth, ssh.Password(hop.Password))
+	}
+	if hop.KeyPath != "" {
+		key, err := a.loadSSHKey(hop.KeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SSH key from %s: %w", hop.KeyPath, err)
+		}
+		hopConfig.Auth = append(hopConfig.Auth, ssh.PublicKeys(key))
+	}
+	if hop.AgentForwarding || len(hopConfig.Auth) == 0 {
+		if agentAuth, err := a.getSSHAgentAuth(); err == nil {
+			hopConfig.Auth = append(hopConfig.Auth, agentAuth)
+		}
+	}
+	if len(hopConfig.Auth) == 0 {
+		return nil, fmt.Errorf("no authentication methods available: please provide password or SSH key")
+	}
+	return hopConfig, nil
+}
+
+// closeSSHClients closes clients in reverse order, innermost tunnel first
+func closeSSHClients(clients []*ssh.Client) {
+	for i := len(clients) - 1; i >= 0; i-- {
+		clients[i].Close()
+	}
+}
+
+// dialJumpChain connects through config's jump hosts in order and returns their clients.
+// queueTimeout is passed to dialHostLimited; onHop, when set, is called as each hop is
+// established.
+func (a *App) dialJumpChain(config *SSHConfig, sshConfig *ssh.ClientConfig, queueTimeout time.Duration, onHop func(hop int, jump JumpHostConfig)) ([]*ssh.Client, error) {
+	if err := validateJumpHosts(config); err != nil {
+		return nil, err
+	}
+
+	var clients []*ssh.Client
+	for i, hop := range config.JumpHosts {
+		hopConfig, err := a.jumpHostClientConfig(hop, sshConfig)
+		if err != nil {
+			closeSSHClients(clients)
+			return nil, fmt.Errorf("jump host %d (%s): %w", i+1, hop.Host, err)
+		}
+
+		address := hop.address()
+		conn, err := a.dialHostLimited(hop.Host, queueTimeout, func() (net.Conn, error) {
+			if i == 0 {
+				return dialThroughProxy(a.sshProxyConfig(config), address, sshConfig.Timeout)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), sshConfig.Timeout)
+			defer cancel()
+			return clients[i-1].DialContext(ctx, "tcp", address)
+		})
+		if err != nil {
+			closeSSHClients(clients)
+			return nil, fmt.Errorf("jump host %d (%s): %w", i+1, address, err)
+		}
+
+		clientConn, chans, reqs, err := ssh.NewClientConn(conn, address, hopConfig)
+		if err != nil {
+			conn.Close()
+			closeSSHClients(clients)
+			return nil, fmt.Errorf("jump host %d (%s): %w", i+1, address, err)
+		}
+		clients = append(clients, ssh.NewClient(clientConn, chans, reqs))
+		if onHop != nil {
+			onHop(i, hop)
+		}
+	}
+	return clients, nil
+}
+
+// emitJumpHopConnected reports an established hop in the terminal and as a
+// jump-hop-connected event, for multi-step connection progress
+func (a *App) emitJumpHopConnected(sessionID string, hop, total int, jump JumpHostConfig) {
+	a.messages.EmitMessage(sessionID, fmt.Sprintf("Connected to jump host %d/%d: %s", hop+1, total, jump.address()), MessageProgress)
+	if a.ctx == nil {
+		return
+	}
+	wailsRuntime.EventsEmit(a.ctx, "jump-hop-connected", map[string]interface{}{
+		"sessionId": sessionID,
+		"hop":       hop + 1,
+		"total":     total,
+		"host":      jump.Host,
+		"port":      jump.Port,
+		"username":  jump.Username,
+	})
+}
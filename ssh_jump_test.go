@@ -0,0 +1,168 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// startJumpServer runs an SSH server that accepts one password and relays direct-tcpip channels
+func startJumpServer(t *testing.T, user, password string) (string, int) {
+	t.Helper()
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if conn.User() == user && string(pass) == password {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("wrong credentials for %s", conn.User())
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					conn.Close()
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					if newChannel.ChannelType() != "direct-tcpip" {
+						newChannel.Reject(ssh.UnknownChannelType, "only direct-tcpip")
+						continue
+					}
+					// host string, port uint32, origin host string, origin port uint32
+					data := newChannel.ExtraData()
+					hostLen := binary.BigEndian.Uint32(data)
+					host := string(data[4 : 4+hostLen])
+					port := binary.BigEndian.Uint32(data[4+hostLen:])
+					target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+					if err != nil {
+						newChannel.Reject(ssh.ConnectionFailed, err.Error())
+						continue
+					}
+					channel, channelReqs, err := newChannel.Accept()
+					if err != nil {
+						target.Close()
+						continue
+					}
+					go ssh.DiscardRequests(channelReqs)
+					go func() { io.Copy(channel, target); channel.Close() }()
+					go func() { io.Copy(target, channel); target.Close() }()
+				}
+			}()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return host, portNumber
+}
+
+func TestDialSSHClientThroughJumpHosts(t *testing.T) {
+	app := NewApp()
+	host1, port1 := startJumpServer(t, "bastion", "first")
+	host2, port2 := startJumpServer(t, "relay", "second")
+	destHost, destPort := startJumpServer(t, "deploy", "third")
+
+	config := &SSHConfig{
+		Host: destHost, Port: destPort, Username: "deploy",
+		Proxy: &ProxyConfig{Mode: ProxyModeNone},
+		JumpHosts: []JumpHostConfig{
+			{Host: host1, Port: port1, Username: "bastion", Password: "first"},
+			{Host: host2, Port: port2, Username: "relay", Password: "second"},
+		},
+	}
+	sshConfig := &ssh.ClientConfig{
+		User:            "deploy",
+		Auth:            []ssh.AuthMethod{ssh.Password("third")},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	}
+
+	var hops []int
+	client, jumpClients, err := app.dialSSHClient(config, sshConfig, HostConnectionQueueTimeout, func(hop int, jump JumpHostConfig) {
+		hops = append(hops, hop)
+	})
+	if err != nil {
+		t.Fatalf("dialSSHClient() returned error: %v", err)
+	}
+	defer closeSSHClients(jumpClients)
+	defer client.Close()
+	if len(jumpClients) != 2 || len(hops) != 2 || hops[1] != 1 {
+		t.Errorf("got %d jump clients and hops %v, want 2 and [0 1]", len(jumpClients), hops)
+	}
+	if client.User() != "deploy" || jumpClients[1].User() != "relay" {
+		t.Errorf("second hop and destination authenticated as %s and %s", jumpClients[1].User(), client.User())
+	}
+
+	// Each hop uses only its own credentials
+	config.JumpHosts[1].Password = "first"
+	if _, _, err := app.dialSSHClient(config, sshConfig, HostConnectionQueueTimeout, nil); err == nil || !strings.Contains(err.Error(), "jump host 2") {
+		t.Errorf("wrong second hop password gave %v, want a jump host 2 error", err)
+	}
+}
+
+func TestValidateJumpChain(t *testing.T) {
+	valid := []JumpHostConfig{
+		{Host: "bastion.example.com", Username: "ops", KeyPath: "/home/ops/.ssh/id_ed25519"},
+		{Host: "10.0.0.5", Port: 2222, Username: "relay", AgentForwarding: true},
+	}
+	if err := validateJumpChain(valid); err != nil {
+		t.Errorf("validateJumpChain() rejected a valid chain: %v", err)
+	}
+
+	tooLong := make([]JumpHostConfig, MaxJumpHops+1)
+	for i := range tooLong {
+		tooLong[i] = JumpHostConfig{Host: fmt.Sprintf("hop%d", i), Username: "ops"}
+	}
+
+	invalid := map[string][]JumpHostConfig{
+		"too many hops":  tooLong,
+		"cycle":          {{Host: "Bastion", Username: "a"}, {Host: "relay", Username: "b"}, {Host: "bastion", Port: 22, Username: "c"}},
+		"empty host":     {{Username: "ops"}},
+		"bad port":       {{Host: "bastion", Port: 70000, Username: "ops"}},
+		"empty username": {{Host: "bastion"}},
+		"bad username":   {{Host: "bastion", Username: "ops@evil"}},
+		"bad key path":   {{Host: "bastion", Username: "ops", KeyPath: "/tmp/key\n"}},
+	}
+	for name, chain := range invalid {
+		if err := validateJumpChain(chain); err == nil {
+			t.Errorf("%s: validateJumpChain() accepted %+v", name, chain)
+		}
+	}
+
+	config := &SSHConfig{Host: "db1", Port: 22, Username: "deploy", JumpHosts: []JumpHostConfig{{Host: "DB1", Username: "ops"}}}
+	if err := config.Validate(); err == nil {
+		t.Error("SSHConfig.Validate() accepted a chain through its own destination")
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -10,20 +11,25 @@ import (
 	"sync"
 	"time"
 
+	"github.com/pkg/sftp"
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/agent"
 	"golang.org/x/crypto/ssh/knownhosts"
 )
 
+// SSHTerminalType is the TERM value requested for the interactive PTY
+const SSHTerminalType = "xterm-256color"
+
 // SSHSession represents a native SSH session
 type SSHSession struct {
 	// Core SSH connection fields
-	client  *ssh.Client
-	session *ssh.Session
-	stdin   io.WriteCloser
-	stdout  io.Reader
-	stderr  io.Reader
+	client      *ssh.Client
+	jumpClients []*ssh.Client // Jump host connections client is tunnelled through, first hop first
+	session     *ssh.Session
+	stdin       io.WriteCloser
+	stdout      io.Reader
+	stderr      io.Reader
 
 	// Channel management
 	done       chan bool
@@ -42,10 +48,11 @@ type SSHSession struct {
 	isHanging    bool
 
 	// Monitoring session for system stats
-	monitoringClient  *ssh.Client
-	monitoringEnabled bool
-	monitoringCache   map[string]string
-	monitoringMutex   sync.RWMutex
+	monitoringClient      *ssh.Client
+	monitoringJumpClients []*ssh.Client // The monitoring connection's own jump host chain
+	monitoringEnabled     bool
+	monitoringCache       map[string]string
+	monitoringMutex       sync.RWMutex
 
 	// Resource tracking for cleanup
 	activeGoroutines int32
@@ -59,6 +66,41 @@ type PendingHostKeyUpdate struct {
 	Remote         net.Addr
 	NewKey         ssh.PublicKey
 	KeyError       *knownhosts.KeyError
+
+	// decision receives the user's answer while the handshake waits on it
+	decision chan bool
+}
+
+// Host key prompt timing. The timeout stays below the default sshd LoginGraceTime (120s)
+// so the server doesn't drop the handshake while we're still waiting for the user.
+var (
+	hostKeyPromptTimeout    = 90 * time.Second
+	hostKeyWatchdogInterval = 1 * time.Second
+)
+
+// SSHSession returns a connected session (SessionStore)
+func (m *SSHManager) SSHSession(sessionID string) (*SSHSession, bool) {
+	m.sshSessionsMutex.RLock()
+	defer m.sshSessionsMutex.RUnlock()
+	sshSession, exists := m.sshSessions[sessionID]
+	return sshSession, exists && sshSession != nil
+}
+
+// SFTPClient returns a session's SFTP client without reconnecting (SessionStore)
+func (m *SSHManager) SFTPClient(sessionID string) (*sftp.Client, bool) {
+	m.sftpClientsMutex.RLock()
+	defer m.sftpClientsMutex.RUnlock()
+	client, exists := m.sftpClients[sessionID]
+	return client, exists
+}
+
+// InvalidateDirectoryCache drops every cached listing of a session (SessionStore). Called after
+// anything that changes remote files, since a rename or delete can touch listings other than
+// the parent's.
+func (m *SSHManager) InvalidateDirectoryCache(sessionID string) {
+	m.directoryCacheMutex.Lock()
+	defer m.directoryCacheMutex.Unlock()
+	delete(m.directoryCache, sessionID)
 }
 
 // Thread-safe getters and setters for SSHSession state
@@ -122,7 +164,7 @@ func (a *App) createHostKeyCallback(sessionID string) ssh.HostKeyCallback {
 		if err != nil {
 			// known_hosts file doesn't exist or can't be read, create it
 			a.emitTerminalMessage(sessionID, fmt.Sprintf("Creating new known_hosts file: %s", knownHostsPath))
-			return a.addHostKeyToKnownHosts(sessionID, knownHostsPath, hostname, remote, key)
+			return a.acceptNewHostKey(sessionID, knownHostsPath, hostname, remote, key)
 		}
 
 		// Try to verify with existing known_hosts
@@ -138,6 +180,7 @@ func (a *App) createHostKeyCallback(sessionID string) ssh.HostKeyCallback {
 			return err
 		}
 
+		recordHostKeyVerification(sessionID, HostKeyVerifiedKnownHosts, hostname, key, false)
 		return nil
 	}
 }
@@ -152,6 +195,7 @@ const (
 	MessageError
 	MessageProgress
 	MessageDebug
+	MessageBroadcast // Announcements from outside the session, such as a maintenance window
 )
 
 // emitTerminalMessage sends a basic message (for backward compatibility)
@@ -186,7 +230,7 @@ func (a *App) handleHostKeyError(sessionID, knownHostsPath, hostname string, rem
 	if len(keyErr.Want) == 0 {
 		// Host not in known_hosts, add it
 		a.messages.EmitMessage(sessionID, fmt.Sprintf("New host: %s", hostname), MessageInfo)
-		return a.addHostKeyToKnownHosts(sessionID, knownHostsPath, hostname, remote, key)
+		return a.acceptNewHostKey(sessionID, knownHostsPath, hostname, remote, key)
 	}
 
 	// Host key has changed - this is potentially dangerous
@@ -204,7 +248,7 @@ func (a *App) handleHostKeyError(sessionID, knownHostsPath, hostname string, rem
 	a.messages.EmitMessage(sessionID, fmt.Sprintf("New: %s", newFingerprint), MessageInfo)
 
 	// Store the pending host key info for user decision
-	a.storePendingHostKeyUpdate(sessionID, hostname, knownHostsPath, remote, key, keyErr)
+	pending := a.storePendingHostKeyUpdate(sessionID, hostname, knownHostsPath, remote, key, keyErr)
 
 	// Mark that a host key prompt is active for this session
 	a.messages.SetHostKeyPromptActive(sessionID, true)
@@ -224,8 +268,88 @@ func (a *App) handleHostKeyError(sessionID, knownHostsPath, hostname string, rem
 		})
 	}
 
-	// Return a specific error that indicates user intervention is needed
-	return fmt.Errorf("host key verification pending user approval")
+	// Block the handshake until the user answers, the prompt times out, or the tab goes away
+	return a.waitForHostKeyDecision(pending)
+}
+
+// waitForHostKeyDecision blocks the host key callback until the pending prompt is resolved.
+// Approval updates known_hosts and lets the in-flight handshake continue.
+func (a *App) waitForHostKeyDecision(pending *PendingHostKeyUpdate) error {
+	sessionID := pending.SessionID
+
+	timeout := time.NewTimer(hostKeyPromptTimeout)
+	defer timeout.Stop()
+
+	watchdog := time.NewTicker(hostKeyWatchdogInterval)
+	defer watchdog.Stop()
+
+	for {
+		select {
+		case approved := <-pending.decision:
+			if !approved {
+				a.messages.EmitMessage(sessionID, "Connection cancelled", MessageWarning)
+				return fmt.Errorf("host key rejected by user")
+			}
+
+			a.messages.EmitMessage(sessionID, "Updating known_hosts...", MessageProgress)
+			if err := a.updateKnownHostsEntry(pending); err != nil {
+				a.messages.EmitMessage(sessionID, fmt.Sprintf("Failed to update: %v", err), MessageError)
+				return fmt.Errorf("failed to update known_hosts: %w", err)
+			}
+			a.messages.EmitMessage(sessionID, "Host key updated - continuing connection", MessageSuccess)
+			recordHostKeyVerification(sessionID, HostKeyVerifiedUserApproved, pending.Hostname, pending.NewKey, false)
+			return nil
+
+		case <-timeout.C:
+			a.cancelPendingHostKeyUpdate(pending)
+			a.messages.EmitMessage(sessionID, "Host key prompt timed out", MessageWarning)
+			return fmt.Errorf("host key verification timed out after %v", hostKeyPromptTimeout)
+
+		case <-watchdog.C:
+			// The tab was closed while the prompt was showing - nobody is left to answer
+			if !a.sessionHasTab(sessionID) {
+				a.cancelPendingHostKeyUpdate(pending)
+				return fmt.Errorf("host key verification cancelled: session %s closed", sessionID)
+			}
+		}
+	}
+}
+
+// cancelPendingHostKeyUpdate removes a pending prompt if it is still the current one for its session
+func (a *App) cancelPendingHostKeyUpdate(pending *PendingHostKeyUpdate) {
+	pendingHostKeyMutex.Lock()
+	if current, exists := pendingHostKeyUpdates[pending.SessionID]; exists && current == pending {
+		delete(pendingHostKeyUpdates, pending.SessionID)
+	}
+	pendingHostKeyMutex.Unlock()
+
+	a.messages.SetHostKeyPromptActive(pending.SessionID, false)
+}
+
+// sessionHasTab reports whether any open tab still owns the given session
+func (a *App) sessionHasTab(sessionID string) bool {
+	a.terminal.mutex.RLock()
+	defer a.terminal.mutex.RUnlock()
+
+	for _, tab := range a.terminal.tabs {
+		if tab.SessionID == sessionID {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionTabID returns the ID of the tab showing a session, or "" when no tab does
+func (a *App) sessionTabID(sessionID string) string {
+	a.terminal.mutex.RLock()
+	defer a.terminal.mutex.RUnlock()
+
+	for _, tab := range a.terminal.tabs {
+		if tab.SessionID == sessionID {
+			return tab.ID
+		}
+	}
+	return ""
 }
 
 // UpdateHostKey manually updates a host key in known_hosts (can be called from frontend)
@@ -255,60 +379,45 @@ var pendingHostKeyUpdates = make(map[string]*PendingHostKeyUpdate)
 var pendingHostKeyMutex sync.RWMutex
 
 // storePendingHostKeyUpdate stores a pending host key update for user approval
-func (a *App) storePendingHostKeyUpdate(sessionID, hostname, knownHostsPath string, remote net.Addr, key ssh.PublicKey, keyErr *knownhosts.KeyError) {
+func (a *App) storePendingHostKeyUpdate(sessionID, hostname, knownHostsPath string, remote net.Addr, key ssh.PublicKey, keyErr *knownhosts.KeyError) *PendingHostKeyUpdate {
 	pendingHostKeyMutex.Lock()
 	defer pendingHostKeyMutex.Unlock()
 
-	pendingHostKeyUpdates[sessionID] = &PendingHostKeyUpdate{
+	pending := &PendingHostKeyUpdate{
 		SessionID:      sessionID,
 		Hostname:       hostname,
 		KnownHostsPath: knownHostsPath,
 		Remote:         remote,
 		NewKey:         key,
 		KeyError:       keyErr,
+		decision:       make(chan bool, 1),
 	}
+	pendingHostKeyUpdates[sessionID] = pending
+	return pending
 }
 
-// ApproveHostKeyUpdate handles user approval/rejection of host key changes
+// ApproveHostKeyUpdate handles user approval/rejection of host key changes.
+// The answer is handed to the handshake that is waiting on the prompt.
 func (a *App) ApproveHostKeyUpdate(sessionID string, approved bool) error {
 	// Clear the host key prompt active flag first
 	a.messages.SetHostKeyPromptActive(sessionID, false)
 
 	pendingHostKeyMutex.Lock()
 	pending, exists := pendingHostKeyUpdates[sessionID]
-	if exists && approved {
-		// Keep the pending update for processing
-	} else {
-		// Remove the pending update
-		delete(pendingHostKeyUpdates, sessionID)
-	}
+	delete(pendingHostKeyUpdates, sessionID)
 	pendingHostKeyMutex.Unlock()
 
 	if !exists {
 		return fmt.Errorf("no pending host key update found for session %s", sessionID)
 	}
 
+	// decision is buffered and only ever written here after removal from the map
+	pending.decision <- approved
+
 	if !approved {
-		a.messages.EmitMessage(sessionID, "Connection cancelled", MessageWarning)
 		return fmt.Errorf("host key update rejected by user")
 	}
 
-	// User approved the update - overwrite the known_hosts entry
-	a.messages.EmitMessage(sessionID, "Updating known_hosts...", MessageProgress)
-
-	err := a.updateKnownHostsEntry(pending)
-	if err != nil {
-		a.messages.EmitMessage(sessionID, fmt.Sprintf("Failed to update: %v", err), MessageError)
-		return err
-	}
-
-	// Clean up the pending update
-	pendingHostKeyMutex.Lock()
-	delete(pendingHostKeyUpdates, sessionID)
-	pendingHostKeyMutex.Unlock()
-
-	a.messages.EmitMessage(sessionID, "Host key updated - retry connection", MessageSuccess)
-
 	return nil
 }
 
@@ -374,20 +483,85 @@ func (a *App) CreateSSHSession(sessionID string, config *SSHConfig) (*SSHSession
 
 // CreateSSHSessionWithSize creates a new SSH connection and session with specified terminal size
 func (a *App) CreateSSHSessionWithSize(sessionID string, config *SSHConfig, cols, rows int) (*SSHSession, error) {
+	// Validate terminal dimensions
+	if cols <= 0 || rows <= 0 {
+		cols, rows = 80, 24 // fallback to default
+	}
+
+	client, jumpClients, err := a.connectSSHClient(sessionID, config)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		client.Close()
+		closeSSHClients(jumpClients)
+		return nil, fmt.Errorf("failed to create SSH session: %w", err)
+	}
+
+	// Set up session I/O
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		client.Close()
+		closeSSHClients(jumpClients)
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		client.Close()
+		closeSSHClients(jumpClients)
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	stderr, err := session.StderrPipe()
+	if err != nil {
+		session.Close()
+		client.Close()
+		closeSSHClients(jumpClients)
+		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+
+	// Create SSH session wrapper with proper initialization
+	sshSession := &SSHSession{
+		client:            client,
+		jumpClients:       jumpClients,
+		session:           session,
+		stdin:             stdin,
+		stdout:            stdout,
+		stderr:            stderr,
+		done:              make(chan bool),
+		closed:            make(chan bool),
+		forceClose:        make(chan bool),
+		cols:              cols,
+		rows:              rows,
+		sessionID:         sessionID,
+		lastActivity:      time.Now(),
+		isHanging:         false,
+		monitoringEnabled: false,
+		monitoringCache:   make(map[string]string),
+	}
+
+	// Session is ready - this should be called from the tab management layer
+	// after StartSSHShell succeeds, so we don't call SessionReady here
+	return sshSession, nil
+}
+
+// connectSSHClient authenticates to a session's host, through its proxy and jump hosts, and
+// returns the connection along with the jump host connections it is tunnelled through
+func (a *App) connectSSHClient(sessionID string, config *SSHConfig) (*ssh.Client, []*ssh.Client, error) {
 	// Validate configuration
 	if config.Host == "" {
-		return nil, fmt.Errorf("SSH host cannot be empty")
+		return nil, nil, fmt.Errorf("SSH host cannot be empty")
 	}
 	if config.Username == "" {
-		return nil, fmt.Errorf("SSH username cannot be empty")
+		return nil, nil, fmt.Errorf("SSH username cannot be empty")
 	}
 	if config.Port <= 0 || config.Port > 65535 {
-		return nil, fmt.Errorf("SSH port must be between 1 and 65535")
-	}
-
-	// Validate terminal dimensions
-	if cols <= 0 || rows <= 0 {
-		cols, rows = 80, 24 // fallback to default
+		return nil, nil, fmt.Errorf("SSH port must be between 1 and 65535")
 	}
 
 	// Create SSH client configuration with secure host key verification
@@ -411,7 +585,7 @@ func (a *App) CreateSSHSessionWithSize(sessionID string, config *SSHConfig, cols
 		a.messages.EmitMessage(sessionID, fmt.Sprintf("Loading key: %s", filepath.Base(config.KeyPath)), MessageProgress)
 		key, err := a.loadSSHKey(config.KeyPath)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load SSH key from %s: %w", config.KeyPath, err)
+			return nil, nil, fmt.Errorf("failed to load SSH key from %s: %w", config.KeyPath, err)
 		} else {
 			authMethods = append(authMethods, "private key")
 			sshConfig.Auth = append(sshConfig.Auth, ssh.PublicKeys(key))
@@ -451,7 +625,7 @@ func (a *App) CreateSSHSessionWithSize(sessionID string, config *SSHConfig, cols
 
 	// If still no auth methods available, return specific error
 	if authMethodsAdded == 0 {
-		return nil, fmt.Errorf("no authentication methods available: please provide password or SSH key")
+		return nil, nil, fmt.Errorf("no authentication methods available: please provide password or SSH key")
 	}
 
 	// Show authentication methods being used
@@ -463,94 +637,51 @@ func (a *App) CreateSSHSessionWithSize(sessionID string, config *SSHConfig, cols
 	address := fmt.Sprintf("%s:%d", config.Host, config.Port)
 	// Don't emit "Connecting to..." here - it's already shown by StartConnectionFlow()
 
-	client, err := ssh.Dial("tcp", address, sshConfig)
+	client, jumpClients, err := a.dialSSHClient(config, sshConfig, HostConnectionQueueTimeout, func(hop int, jump JumpHostConfig) {
+		a.emitJumpHopConnected(sessionID, hop, len(config.JumpHosts), jump)
+	})
 	if err != nil {
+		// Proxy failures already say whether the proxy or the destination is at fault
+		var proxyErr *ProxyError
+		if errors.As(err, &proxyErr) {
+			return nil, nil, err
+		}
+
 		// Provide more specific error messages based on error type
 		if netErr, ok := err.(net.Error); ok {
 			if netErr.Timeout() {
-				return nil, fmt.Errorf("connection timeout: could not reach %s (check host and port)", address)
+				return nil, nil, fmt.Errorf("connection timeout: could not reach %s (check host and port)", address)
 			}
 		}
 
 		// Check for common SSH errors
 		errStr := err.Error()
 		if strings.Contains(errStr, "connection refused") {
-			return nil, fmt.Errorf("connection refused: SSH server may not be running on %s", address)
+			return nil, nil, fmt.Errorf("connection refused: SSH server may not be running on %s", address)
 		}
 		if strings.Contains(errStr, "no route to host") {
-			return nil, fmt.Errorf("no route to host: %s is not reachable", config.Host)
+			return nil, nil, fmt.Errorf("no route to host: %s is not reachable", config.Host)
 		}
 		if strings.Contains(errStr, "authentication failed") || strings.Contains(errStr, "unable to authenticate") {
-			return nil, fmt.Errorf("authentication failed: invalid username/password or SSH key")
+			return nil, nil, fmt.Errorf("authentication failed: invalid username/password or SSH key")
 		}
 		if strings.Contains(errStr, "host key verification failed") {
-			return nil, fmt.Errorf("host key verification failed: host key has changed or is unknown")
+			return nil, nil, fmt.Errorf("host key verification failed: host key has changed or is unknown")
 		}
 
 		// Generic connection error
-		return nil, fmt.Errorf("failed to connect to %s: %v", address, err)
+		return nil, nil, fmt.Errorf("failed to connect to %s: %w", address, err)
 	}
 
 	// Use unified connection flow to stop animation properly
 	a.messages.ConnectionEstablished(sessionID)
-
-	session, err := client.NewSession()
-	if err != nil {
-		client.Close()
-		return nil, fmt.Errorf("failed to create SSH session: %w", err)
-	}
-
-	// Set up session I/O
-	stdin, err := session.StdinPipe()
-	if err != nil {
-		session.Close()
-		client.Close()
-		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
-	}
-
-	stdout, err := session.StdoutPipe()
-	if err != nil {
-		session.Close()
-		client.Close()
-		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
-	}
-
-	stderr, err := session.StderrPipe()
-	if err != nil {
-		session.Close()
-		client.Close()
-		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
-	}
-
-	// Create SSH session wrapper with proper initialization
-	sshSession := &SSHSession{
-		client:            client,
-		session:           session,
-		stdin:             stdin,
-		stdout:            stdout,
-		stderr:            stderr,
-		done:              make(chan bool),
-		closed:            make(chan bool),
-		forceClose:        make(chan bool),
-		cols:              cols,
-		rows:              rows,
-		sessionID:         sessionID,
-		lastActivity:      time.Now(),
-		isHanging:         false,
-		monitoringEnabled: false,
-		monitoringCache:   make(map[string]string),
-		activeGoroutines:  0,
-	}
-
-	// Session is ready - this should be called from the tab management layer
-	// after StartSSHShell succeeds, so we don't call SessionReady here
-	return sshSession, nil
+	return client, jumpClients, nil
 }
 
 // StartSSHShell starts a shell on the SSH session
 func (a *App) StartSSHShell(sshSession *SSHSession) error {
 	// Request a pseudo-terminal with comprehensive terminal modes
-	if err := sshSession.session.RequestPty("xterm-256color", sshSession.rows, sshSession.cols, ssh.TerminalModes{
+	if err := sshSession.session.RequestPty(SSHTerminalType, sshSession.rows, sshSession.cols, ssh.TerminalModes{
 		ssh.ECHO:          1,     // Enable echo
 		ssh.TTY_OP_ISPEED: 14400, // Input speed
 		ssh.TTY_OP_OSPEED: 14400, // Output speed
@@ -577,9 +708,9 @@ func (a *App) StartSSHShell(sshSession *SSHSession) error {
 	}
 
 	// Start output handling goroutines
-	go a.handleSSHOutput(sshSession)
-	go a.handleSSHErrors(sshSession)
-	go a.waitForSSHSessionEnd(sshSession)
+	sshSession.goTracked(func() { a.handleSSHOutput(sshSession) })
+	sshSession.goTracked(func() { a.handleSSHErrors(sshSession) })
+	sshSession.goTracked(func() { a.waitForSSHSessionEnd(sshSession) })
 
 	return nil
 }
@@ -637,10 +768,7 @@ func (a *App) handleSSHOutput(sshSession *SSHSession) {
 
 			if a.ctx != nil {
 				output := string(buffer[:n])
-				wailsRuntime.EventsEmit(a.ctx, "terminal-output", map[string]interface{}{
-					"sessionId": sshSession.sessionID,
-					"data":      output,
-				})
+				a.emitSessionOutput(sshSession.sessionID, output)
 			}
 		}
 	}
@@ -672,10 +800,7 @@ func (a *App) handleSSHErrors(sshSession *SSHSession) {
 			output := string(buffer[:n])
 			// Send stderr as regular output with error formatting
 			errorOutput := fmt.Sprintf("\x1b[31m%s\x1b[0m", output)
-			wailsRuntime.EventsEmit(a.ctx, "terminal-output", map[string]interface{}{
-				"sessionId": sshSession.sessionID,
-				"data":      errorOutput,
-			})
+			a.emitTerminalOutput(sshSession.sessionID, errorOutput)
 		}
 	}
 }
@@ -718,11 +843,16 @@ func (a *App) waitForSSHSessionEnd(sshSession *SSHSession) {
 
 // WriteToSSHSession writes data to SSH session
 func (a *App) WriteToSSHSession(sshSession *SSHSession, data string) error {
+	return writeSSHSessionInput(sshSession, []byte(data))
+}
+
+// writeSSHSessionInput writes data to the SSH session's stdin
+func writeSSHSessionInput(sshSession *SSHSession, data []byte) error {
 	if sshSession.IsCleaning() {
 		return fmt.Errorf("SSH session is being cleaned up")
 	}
 
-	_, err := sshSession.stdin.Write([]byte(data))
+	_, err := sshSession.stdin.Write(data)
 	return err
 }
 
@@ -732,8 +862,10 @@ func (a *App) ResizeSSHSession(sshSession *SSHSession, cols, rows int) error {
 		return fmt.Errorf("SSH session is being cleaned up")
 	}
 
+	sshSession.mu.Lock()
 	sshSession.cols = cols
 	sshSession.rows = rows
+	sshSession.mu.Unlock()
 
 	// Send window change signal
 	return sshSession.session.WindowChange(rows, cols)
@@ -747,25 +879,60 @@ func (a *App) CloseSSHSession(sshSession *SSHSession) error {
 
 	sshSession.SetCleaning(true)
 
+	forgetHostKeyVerification(sshSession.sessionID)
+
 	// Close SFTP client if it exists for this session
 	a.CloseFileExplorerSession(sshSession.sessionID)
 
 	// Close monitoring session first
 	a.CloseMonitoringSession(sshSession)
 
+	// A connection closed after its tab is gone takes the rest of the session's state with it;
+	// one closed for a reconnect or a failed attempt leaves the tab's state alone
+	if !a.liveSessionIDs()[sshSession.sessionID] {
+		a.CleanupSession(sshSession.sessionID)
+	}
+
 	// Close session and client
-	go func() {
+	sshSession.goTracked(func() {
 		if sshSession.session != nil {
 			sshSession.session.Close()
 		}
 		if sshSession.client != nil {
 			sshSession.client.Close()
 		}
-	}()
+		closeSSHClients(sshSession.jumpClients)
+	})
+	a.checkSessionGoroutines(sshSession)
 
 	return nil
 }
 
+// retireSSHSession gracefully closes a connection that is no longer (or never was) the live one
+// for its session ID. Unlike CloseSSHSession it leaves the state keyed by session ID - SFTP client,
+// host key verification - alone, since that now belongs to the replacement connection.
+func (a *App) retireSSHSession(sshSession *SSHSession) {
+	if sshSession.IsCleaning() {
+		return
+	}
+
+	// Stops the output handlers and tells waitForSSHSessionEnd this end is expected
+	sshSession.SetCleaning(true)
+
+	a.CloseMonitoringSession(sshSession)
+
+	sshSession.goTracked(func() {
+		if sshSession.session != nil {
+			sshSession.session.Close()
+		}
+		if sshSession.client != nil {
+			sshSession.client.Close()
+		}
+		closeSSHClients(sshSession.jumpClients)
+	})
+	a.checkSessionGoroutines(sshSession)
+}
+
 // loadSSHKey loads an SSH private key from file
 func (a *App) loadSSHKey(keyPath string) (ssh.Signer, error) {
 	key, err := os.ReadFile(keyPath)
@@ -819,7 +986,7 @@ func (a *App) ForceDisconnectSSHSession(sessionID string) error {
 	a.ssh.sshSessionsMutex.RUnlock()
 
 	if !exists {
-		return fmt.Errorf("SSH session %s not found", sessionID)
+		return newNotFoundError(ErrCategorySSH, "ForceDisconnectSSHSession", "SSH session %s not found", sessionID)
 	}
 
 	fmt.Printf("Force disconnecting SSH session: %s\n", sessionID)
@@ -834,6 +1001,44 @@ func (a *App) ForceDisconnectSSHSession(sessionID string) error {
 	return a.CloseSSHSession(sshSession)
 }
 
+// GetSSHConnectionInfo returns connection details for an SSH session, including how its host key was verified
+func (a *App) GetSSHConnectionInfo(sessionID string) (map[string]interface{}, error) {
+	a.ssh.sshSessionsMutex.RLock()
+	sshSession, exists := a.ssh.sshSessions[sessionID]
+	a.ssh.sshSessionsMutex.RUnlock()
+
+	if !exists {
+		return nil, newNotFoundError(ErrCategorySSH, "GetSSHConnectionInfo", "SSH session %s not found", sessionID)
+	}
+
+	info := map[string]interface{}{
+		"sessionId":    sessionID,
+		"lastActivity": sshSession.GetLastActivity(),
+	}
+
+	a.terminal.mutex.RLock()
+	for _, tab := range a.terminal.tabs {
+		if tab.SessionID == sessionID && tab.SSHConfig != nil {
+			info["host"] = tab.SSHConfig.Host
+			info["port"] = tab.SSHConfig.Port
+			info["username"] = tab.SSHConfig.Username
+			break
+		}
+	}
+	a.terminal.mutex.RUnlock()
+
+	if sshSession.client != nil {
+		info["serverVersion"] = string(sshSession.client.ServerVersion())
+		info["remoteAddr"] = sshSession.client.RemoteAddr().String()
+	}
+
+	if verification := getHostKeyVerification(sessionID); verification != nil {
+		info["hostKeyVerification"] = verification
+	}
+
+	return info, nil
+}
+
 // CreateMonitoringSession creates a separate SSH connection for system monitoring
 func (a *App) CreateMonitoringSession(sshSession *SSHSession, config *SSHConfig) error {
 	// Create SSH client configuration (same as main session)
@@ -878,16 +1083,25 @@ func (a *App) CreateMonitoringSession(sshSession *SSHSession, config *SSHConfig)
 		}
 	}
 
-	// Connect monitoring client
-	address := fmt.Sprintf("%s:%d", config.Host, config.Port)
-	monitoringClient, err := ssh.Dial("tcp", address, sshConfig)
+	// Connect monitoring client. It never queues for a connection slot: a shell waiting behind
+	// it would be worse than a tab without monitoring.
+	monitoringClient, monitoringJumpClients, err := a.dialSSHClient(config, sshConfig, 0, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create monitoring SSH connection: %w", err)
 	}
 
-	// Store monitoring client
+	// Store monitoring client, unless the session was closed while the connection came up:
+	// its cleanup marks it first and then waits for monitoringMutex, so once it is marked
+	// nothing would close the client later
 	sshSession.monitoringMutex.Lock()
+	if sshSession.IsCleaning() {
+		sshSession.monitoringMutex.Unlock()
+		monitoringClient.Close()
+		closeSSHClients(monitoringJumpClients)
+		return fmt.Errorf("session %s closed while the monitoring connection was opened", sshSession.sessionID)
+	}
 	sshSession.monitoringClient = monitoringClient
+	sshSession.monitoringJumpClients = monitoringJumpClients
 	sshSession.monitoringEnabled = true
 	sshSession.monitoringMutex.Unlock()
 
@@ -895,9 +1109,9 @@ func (a *App) CreateMonitoringSession(sshSession *SSHSession, config *SSHConfig)
 	return nil
 }
 
-// ExecuteMonitoringCommand executes a command on the monitoring SSH session
+// executeMonitoringCommand executes a command on the monitoring SSH session
 // Commands are executed in a way that prevents them from being logged to shell history
-func (a *App) ExecuteMonitoringCommand(sshSession *SSHSession, command string) (string, error) {
+func (a *App) executeMonitoringCommand(sshSession *SSHSession, command remoteCommand) (string, error) {
 	sshSession.monitoringMutex.RLock()
 	monitoringClient := sshSession.monitoringClient
 	enabled := sshSession.monitoringEnabled
@@ -916,7 +1130,7 @@ func (a *App) ExecuteMonitoringCommand(sshSession *SSHSession, command string) (
 
 	// Set timeout for command execution
 	done := make(chan bool)
-	go func() {
+	sshSession.goTracked(func() {
 		time.Sleep(5 * time.Second) // 5 second timeout
 		select {
 		case <-done:
@@ -924,13 +1138,14 @@ func (a *App) ExecuteMonitoringCommand(sshSession *SSHSession, command string) (
 		default:
 			session.Close() // Force close on timeout
 		}
-	}()
+	})
 
 	// Wrap command to prevent history logging
 	// Method 1: Use HISTFILE=/dev/null for bash/zsh
 	// Method 2: Prefix with space (works if HISTCONTROL=ignorespace)
 	// Method 3: Use a subshell with disabled history
-	wrappedCommand := fmt.Sprintf("HISTFILE=/dev/null bash -c %q", command)
+	// Single quotes keep the login shell from expanding anything before bash sees the command
+	wrappedCommand := "HISTFILE=/dev/null bash -c " + shellQuote(string(command))
 
 	// Execute command and get output
 	output, err := session.CombinedOutput(wrappedCommand)
@@ -974,6 +1189,8 @@ func (a *App) CloseMonitoringSession(sshSession *SSHSession) {
 	if sshSession.monitoringClient != nil {
 		sshSession.monitoringClient.Close()
 		sshSession.monitoringClient = nil
+		closeSSHClients(sshSession.monitoringJumpClients)
+		sshSession.monitoringJumpClients = nil
 		fmt.Printf("Closed monitoring SSH session for %s\n", sshSession.sessionID)
 	}
 	sshSession.monitoringEnabled = false
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// newHostKeyPromptFixture sets up an app with an open tab and a known_hosts file
+// holding a stale key, so that handleHostKeyError takes the "key changed" path.
+func newHostKeyPromptFixture(t *testing.T, sessionID string) (*App, string, ssh.PublicKey, *knownhosts.KeyError) {
+	t.Helper()
+
+	app := NewApp()
+	app.terminal.tabs["tab_test"] = &Tab{ID: "tab_test", SessionID: sessionID}
+
+	oldKey := generateTestHostKey(t)
+	newKey := generateTestHostKey(t)
+
+	knownHostsPath := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(knownHostsPath, []byte(knownhosts.Line([]string{"example.com"}, oldKey)+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write known_hosts: %v", err)
+	}
+
+	keyErr := &knownhosts.KeyError{Want: []knownhosts.KnownKey{{Key: oldKey, Filename: knownHostsPath, Line: 1}}}
+	return app, knownHostsPath, newKey, keyErr
+}
+
+func generateTestHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("failed to wrap key: %v", err)
+	}
+	return key
+}
+
+// startHostKeyPrompt runs handleHostKeyError in the background and waits until the prompt is pending
+func startHostKeyPrompt(t *testing.T, app *App, sessionID, knownHostsPath string, key ssh.PublicKey, keyErr *knownhosts.KeyError) <-chan error {
+	t.Helper()
+
+	result := make(chan error, 1)
+	remote := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 22}
+	go func() {
+		result <- app.handleHostKeyError(sessionID, knownHostsPath, "example.com", remote, key, keyErr)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		pendingHostKeyMutex.RLock()
+		_, exists := pendingHostKeyUpdates[sessionID]
+		pendingHostKeyMutex.RUnlock()
+		if exists {
+			return result
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("host key prompt was never registered")
+	return nil
+}
+
+func waitForHostKeyResult(t *testing.T, result <-chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(3 * time.Second):
+		t.Fatal("host key callback did not return")
+		return nil
+	}
+}
+
+func assertNoPendingHostKey(t *testing.T, sessionID string) {
+	t.Helper()
+
+	pendingHostKeyMutex.RLock()
+	defer pendingHostKeyMutex.RUnlock()
+	if _, exists := pendingHostKeyUpdates[sessionID]; exists {
+		t.Fatalf("pending host key update leaked for session %s", sessionID)
+	}
+}
+
+func TestHostKeyPromptApprove(t *testing.T) {
+	sessionID := "session_approve"
+	app, knownHostsPath, newKey, keyErr := newHostKeyPromptFixture(t, sessionID)
+
+	result := startHostKeyPrompt(t, app, sessionID, knownHostsPath, newKey, keyErr)
+	if err := app.ApproveHostKeyUpdate(sessionID, true); err != nil {
+		t.Fatalf("ApproveHostKeyUpdate() returned error: %v", err)
+	}
+
+	if err := waitForHostKeyResult(t, result); err != nil {
+		t.Fatalf("handshake should continue after approval, got: %v", err)
+	}
+	assertNoPendingHostKey(t, sessionID)
+
+	content, err := os.ReadFile(knownHostsPath)
+	if err != nil {
+		t.Fatalf("failed to read known_hosts: %v", err)
+	}
+	if !strings.Contains(string(content), knownhosts.Line([]string{"example.com"}, newKey)) {
+		t.Fatal("known_hosts was not updated with the approved key")
+	}
+}
+
+func TestHostKeyPromptReject(t *testing.T) {
+	sessionID := "session_reject"
+	app, knownHostsPath, newKey, keyErr := newHostKeyPromptFixture(t, sessionID)
+
+	result := startHostKeyPrompt(t, app, sessionID, knownHostsPath, newKey, keyErr)
+	if err := app.ApproveHostKeyUpdate(sessionID, false); err == nil {
+		t.Fatal("ApproveHostKeyUpdate() should report rejection")
+	}
+
+	if err := waitForHostKeyResult(t, result); err == nil {
+		t.Fatal("handshake should fail after rejection")
+	}
+	assertNoPendingHostKey(t, sessionID)
+}
+
+func TestHostKeyPromptTimeout(t *testing.T) {
+	originalTimeout := hostKeyPromptTimeout
+	hostKeyPromptTimeout = 50 * time.Millisecond
+	defer func() { hostKeyPromptTimeout = originalTimeout }()
+
+	sessionID := "session_timeout"
+	app, knownHostsPath, newKey, keyErr := newHostKeyPromptFixture(t, sessionID)
+
+	result := startHostKeyPrompt(t, app, sessionID, knownHostsPath, newKey, keyErr)
+	if err := waitForHostKeyResult(t, result); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got: %v", err)
+	}
+	assertNoPendingHostKey(t, sessionID)
+
+	if app.messages.IsHostKeyPromptActive(sessionID) {
+		t.Fatal("host key prompt still marked active after timeout")
+	}
+}
+
+func TestHostKeyPromptTabClosed(t *testing.T) {
+	originalInterval := hostKeyWatchdogInterval
+	hostKeyWatchdogInterval = 10 * time.Millisecond
+	defer func() { hostKeyWatchdogInterval = originalInterval }()
+
+	sessionID := "session_closed"
+	app, knownHostsPath, newKey, keyErr := newHostKeyPromptFixture(t, sessionID)
+
+	result := startHostKeyPrompt(t, app, sessionID, knownHostsPath, newKey, keyErr)
+
+	app.terminal.mutex.Lock()
+	delete(app.terminal.tabs, "tab_test")
+	app.terminal.mutex.Unlock()
+
+	if err := waitForHostKeyResult(t, result); err == nil || !strings.Contains(err.Error(), "closed") {
+		t.Fatalf("expected cancellation after tab close, got: %v", err)
+	}
+	assertNoPendingHostKey(t, sessionID)
+}
+
+func TestCreateMonitoringSessionAfterClose(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("AppData", home)
+
+	addr, hostKey, _ := listenSFTPServer(t)
+	host, portText, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(portText)
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey) + "\n"
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "known_hosts"), []byte(line), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	app := NewApp()
+	config := &SSHConfig{Host: host, Port: port, Username: "deploy", Password: "secret", Proxy: &ProxyConfig{Mode: ProxyModeNone}}
+	sshSession := &SSHSession{sessionID: "session_monitoring_closed"}
+	sshSession.SetCleaning(true)
+	// Connections other tests have still closing count too
+	before := hostConnections.count(host)
+
+	if err := app.CreateMonitoringSession(sshSession, config); err == nil {
+		t.Fatal("CreateMonitoringSession() on a closed session returned no error")
+	}
+	if sshSession.monitoringClient != nil || sshSession.monitoringEnabled {
+		t.Error("monitoring client kept for a closed session")
+	}
+	if got := hostConnections.count(host); got > before {
+		t.Errorf("%d connections to %s open, want at most the %d open before", got, host, before)
+	}
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/proxy"
+)
+
+// Proxy modes for outbound SSH connections
+const (
+	ProxyModeNone   = "none"   // Connect directly
+	ProxyModeSystem = "system" // Use HTTPS_PROXY/ALL_PROXY, honoring NO_PROXY
+	ProxyModeHTTP   = "http"   // HTTP CONNECT proxy
+	ProxyModeSOCKS5 = "socks5" // SOCKS5 proxy
+)
+
+// Proxy failure stages, so the user knows whether to look at the proxy or the server
+const (
+	ProxyStageConnect   = "connect"   // The proxy could not be reached
+	ProxyStageHandshake = "handshake" // The proxy was reached but refused us (authentication, protocol)
+	ProxyStageTunnel    = "tunnel"    // The proxy could not open a connection to the destination
+)
+
+// ProxyConfig selects how outbound SSH connections reach the network
+type ProxyConfig struct {
+	Mode     string `yaml:"mode" json:"mode"`                             // ProxyMode*; empty in a profile means the global setting
+	Address  string `yaml:"address,omitempty" json:"address,omitempty"`   // host:port of an http or socks5 proxy
+	Username string `yaml:"username,omitempty" json:"username,omitempty"` // Optional proxy authentication
+	Password string `yaml:"password,omitempty" json:"password,omitempty"`
+}
+
+// Validate checks the proxy mode and address
+func (c *ProxyConfig) Validate() error {
+	switch c.Mode {
+	case "", ProxyModeNone, ProxyModeSystem:
+		return nil
+	case ProxyModeHTTP, ProxyModeSOCKS5:
+		if _, _, err := net.SplitHostPort(c.Address); err != nil {
+			return fmt.Errorf("invalid %s proxy address '%s': expected host:port", c.Mode, c.Address)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid proxy mode: '%s'. Allowed modes are: %s, %s, %s, %s",
+			c.Mode, ProxyModeNone, ProxyModeSystem, ProxyModeHTTP, ProxyModeSOCKS5)
+	}
+}
+
+// ProxyError is a connection failure caused by the proxy rather than the destination
+type ProxyError struct {
+	Proxy  string // Proxy address
+	Target string // Destination the proxy was asked to reach
+	Stage  string // ProxyStage*
+	Err    error
+}
+
+func (e *ProxyError) Error() string {
+	switch e.Stage {
+	case ProxyStageConnect:
+		return fmt.Sprintf("cannot reach proxy %s: %v", e.Proxy, e.Err)
+	case ProxyStageHandshake:
+		return fmt.Sprintf("proxy %s refused the connection: %v", e.Proxy, e.Err)
+	default:
+		return fmt.Sprintf("proxy %s could not connect to %s: %v", e.Proxy, e.Target, e.Err)
+	}
+}
+
+func (e *ProxyError) Unwrap() error {
+	return e.Err
+}
+
+// sshProxyConfig returns the proxy for a connection: the profile's own setting if it has
+// one, otherwise the global setting
+func (a *App) sshProxyConfig(config *SSHConfig) ProxyConfig {
+	return profileProxyConfig(config, a.globalProxyConfig())
+}
+
+// globalProxyConfig returns the proxy set in the app settings
+func (a *App) globalProxyConfig() ProxyConfig {
+	if a.config == nil {
+		return ProxyConfig{}
+	}
+	a.config.mutex.RLock()
+	defer a.config.mutex.RUnlock()
+	if a.config.config == nil {
+		return ProxyConfig{}
+	}
+	return a.config.config.Proxy
+}
+
+// profileProxyConfig returns config's own proxy if it sets one, otherwise global
+func profileProxyConfig(config *SSHConfig, global ProxyConfig) ProxyConfig {
+	if config != nil && config.Proxy != nil && config.Proxy.Mode != "" {
+		return *config.Proxy
+	}
+	return global
+}
+
+// dialSSHClient opens an SSH client connection to config's host, through the configured proxy
+// and then its jump hosts, if any. Everything built on the client - the shell, SFTP, port
+// forwards - shares the proxied stream. The jump host clients are returned for the caller
+// to close after the client. queueTimeout bounds the wait for a free connection slot on each
+// host (see dialHostLimited); onHop is passed to dialJumpChain.
+func (a *App) dialSSHClient(config *SSHConfig, sshConfig *ssh.ClientConfig, queueTimeout time.Duration, onHop func(hop int, jump JumpHostConfig)) (*ssh.Client, []*ssh.Client, error) {
+	address := net.JoinHostPort(config.Host, fmt.Sprint(config.Port))
+
+	var conn net.Conn
+	var jumpClients []*ssh.Client
+	var err error
+	if len(config.JumpHosts) > 0 {
+		jumpClients, err = a.dialJumpChain(config, sshConfig, queueTimeout, onHop)
+		if err != nil {
+			return nil, nil, err
+		}
+		conn, err = a.dialHostLimited(config.Host, queueTimeout, func() (net.Conn, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), sshConfig.Timeout)
+			defer cancel()
+			return jumpClients[len(jumpClients)-1].DialContext(ctx, "tcp", address)
+		})
+		if err != nil {
+			closeSSHClients(jumpClients)
+			return nil, nil, fmt.Errorf("last jump host could not connect to %s: %w", address, err)
+		}
+	} else {
+		conn, err = a.dialHostLimited(config.Host, queueTimeout, func() (net.Conn, error) {
+			return dialThroughProxy(a.sshProxyConfig(config), address, sshConfig.Timeout)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, address, sshConfig)
+	if err != nil {
+		conn.Close()
+		closeSSHClients(jumpClients)
+		return nil, nil, err
+	}
+	return ssh.NewClient(clientConn, chans, reqs), jumpClients, nil
+}
+
+// dialThroughProxy opens a TCP stream to address, directly or through the proxy
+func dialThroughProxy(cfg ProxyConfig, address string, timeout time.Duration) (net.Conn, error) {
+	if cfg.Mode == ProxyModeSystem {
+		resolved, err := systemProxyConfig(os.Getenv, address)
+		if err != nil {
+			return nil, err
+		}
+		cfg = resolved
+	}
+
+	switch cfg.Mode {
+	case ProxyModeHTTP:
+		return dialHTTPConnect(cfg, address, timeout)
+	case ProxyModeSOCKS5:
+		return dialSOCKS5(cfg, address, timeout)
+	default:
+		return net.DialTimeout("tcp", address, timeout)
+	}
+}
+
+// systemProxyConfig resolves the proxy the environment sets for address: HTTPS_PROXY, then
+// ALL_PROXY, skipping hosts matched by NO_PROXY. Returns mode none when no proxy applies.
+func systemProxyConfig(getenv func(string) string, address string) (ProxyConfig, error) {
+	env := func(names ...string) string {
+		for _, name := range names {
+			if value := getenv(name); value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+
+	proxyEnv := &httpproxy.Config{
+		HTTPSProxy: env("HTTPS_PROXY", "https_proxy", "ALL_PROXY", "all_proxy"),
+		NoProxy:    env("NO_PROXY", "no_proxy"),
+	}
+	proxyURL, err := proxyEnv.ProxyFunc()(&url.URL{Scheme: "https", Host: address})
+	if err != nil {
+		return ProxyConfig{}, fmt.Errorf("invalid system proxy setting: %w", err)
+	}
+	if proxyURL == nil {
+		return ProxyConfig{Mode: ProxyModeNone}, nil
+	}
+
+	cfg := ProxyConfig{Address: proxyURL.Host}
+	if proxyURL.User != nil {
+		cfg.Username = proxyURL.User.Username()
+		cfg.Password, _ = proxyURL.User.Password()
+	}
+	switch proxyURL.Scheme {
+	case "http":
+		cfg.Mode = ProxyModeHTTP
+		if proxyURL.Port() == "" {
+			cfg.Address = net.JoinHostPort(proxyURL.Hostname(), "80")
+		}
+	case "socks5", "socks5h":
+		cfg.Mode = ProxyModeSOCKS5
+		if proxyURL.Port() == "" {
+			cfg.Address = net.JoinHostPort(proxyURL.Hostname(), "1080")
+		}
+	default:
+		return ProxyConfig{}, fmt.Errorf("unsupported system proxy scheme '%s' (use http or socks5)", proxyURL.Scheme)
+	}
+	return cfg, nil
+}
+
+// dialHTTPConnect opens a tunnel to address with an HTTP CONNECT request
+func dialHTTPConnect(cfg ProxyConfig, address string, timeout time.Duration) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", cfg.Address, timeout)
+	if err != nil {
+		return nil, &ProxyError{Proxy: cfg.Address, Target: address, Stage: ProxyStageConnect, Err: err}
+	}
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+
+	request := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if cfg.Username != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(cfg.Username + ":" + cfg.Password))
+		request.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := request.Write(conn); err != nil {
+		conn.Close()
+		return nil, &ProxyError{Proxy: cfg.Address, Target: address, Stage: ProxyStageConnect, Err: err}
+	}
+
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		conn.Close()
+		return nil, &ProxyError{Proxy: cfg.Address, Target: address, Stage: ProxyStageHandshake, Err: err}
+	}
+	response.Body.Close()
+	switch {
+	case response.StatusCode == http.StatusProxyAuthRequired:
+		conn.Close()
+		return nil, &ProxyError{Proxy: cfg.Address, Target: address, Stage: ProxyStageHandshake, Err: errors.New(response.Status)}
+	case response.StatusCode < 200 || response.StatusCode > 299:
+		conn.Close()
+		return nil, &ProxyError{Proxy: cfg.Address, Target: address, Stage: ProxyStageTunnel, Err: errors.New(response.Status)}
+	}
+
+	conn.SetDeadline(time.Time{})
+	// The SSH server speaks first, so its banner may already sit in the reader's buffer
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+// bufferedConn reads through a bufio.Reader that may hold bytes already read from Conn
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// recordingDialer dials directly and remembers whether that failed, so a failure to reach
+// the proxy can be told apart from the proxy failing to reach the destination
+type recordingDialer struct {
+	dialer *net.Dialer
+	err    error
+}
+
+func (d *recordingDialer) Dial(network, address string) (net.Conn, error) {
+	conn, err := d.dialer.Dial(network, address)
+	d.err = err
+	return conn, err
+}
+
+// dialSOCKS5 opens a connection to address through a SOCKS5 proxy. The destination name is
+// resolved by the proxy, as hosts behind it are often unknown to local DNS.
+func dialSOCKS5(cfg ProxyConfig, address string, timeout time.Duration) (net.Conn, error) {
+	var auth *proxy.Auth
+	if cfg.Username != "" {
+		auth = &proxy.Auth{User: cfg.Username, Password: cfg.Password}
+	}
+	forward := &recordingDialer{dialer: &net.Dialer{Timeout: timeout}}
+	dialer, err := proxy.SOCKS5("tcp", cfg.Address, auth, forward)
+	if err != nil {
+		return nil, &ProxyError{Proxy: cfg.Address, Target: address, Stage: ProxyStageConnect, Err: err}
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	conn, err := dialer.(proxy.ContextDialer).DialContext(ctx, "tcp", address)
+	if err != nil {
+		stage := ProxyStageHandshake
+		switch {
+		case forward.err != nil:
+			stage = ProxyStageConnect
+		case strings.Contains(err.Error(), "unknown error"):
+			// The proxy answered the CONNECT command with a failure reply
+			stage = ProxyStageTunnel
+		}
+		return nil, &ProxyError{Proxy: cfg.Address, Target: address, Stage: stage, Err: err}
+	}
+	return conn, nil
+}
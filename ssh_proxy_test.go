@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// startBannerServer accepts connections, greets each like an SSH server does and then echoes
+func startBannerServer(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.Write([]byte("SSH-2.0-Test\r\n"))
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// startSOCKS5Server runs a minimal SOCKS5 proxy (RFC 1928/1929) that accepts CONNECT to
+// IPv4 and domain destinations, requiring user/pass when user is set
+func startSOCKS5Server(t *testing.T, user, pass string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSOCKS5(conn, user, pass)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func serveSOCKS5(conn net.Conn, user, pass string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return
+	}
+	io.ReadFull(r, make([]byte, header[1])) // Offered methods
+	if user == "" {
+		conn.Write([]byte{5, 0})
+	} else {
+		conn.Write([]byte{5, 2})
+		io.ReadFull(r, make([]byte, 1))
+		gotUser := readSOCKSString(r)
+		gotPass := readSOCKSString(r)
+		if gotUser != user || gotPass != pass {
+			conn.Write([]byte{1, 1})
+			return
+		}
+		conn.Write([]byte{1, 0})
+	}
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(r, request); err != nil {
+		return
+	}
+	var host string
+	switch request[3] {
+	case 1:
+		ip := make([]byte, 4)
+		io.ReadFull(r, ip)
+		host = net.IP(ip).String()
+	case 3:
+		host = readSOCKSString(r)
+	default:
+		conn.Write([]byte{5, 8, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	portBytes := make([]byte, 2)
+	io.ReadFull(r, portBytes)
+	target := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(portBytes))))
+
+	upstream, err := net.Dial("tcp", target)
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0}) // Connection refused
+		return
+	}
+	defer upstream.Close()
+	conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0})
+	go io.Copy(upstream, r)
+	io.Copy(conn, upstream)
+}
+
+func readSOCKSString(r *bufio.Reader) string {
+	n, _ := r.ReadByte()
+	b := make([]byte, n)
+	io.ReadFull(r, b)
+	return string(b)
+}
+
+// startConnectProxy runs an HTTP CONNECT proxy that requires the given Proxy-Authorization
+func startConnectProxy(t *testing.T, authorization string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Proxy-Authorization") != authorization {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		conn, buffered, _ := w.(http.Hijacker).Hijack()
+		conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go func() {
+			defer upstream.Close()
+			io.Copy(upstream, buffered)
+		}()
+		io.Copy(conn, upstream)
+		conn.Close()
+	}))
+	return listener.Addr().String()
+}
+
+// closedAddress returns an address nothing listens on
+func closedAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+// checkTunnel reads the server banner and an echo through conn
+func checkTunnel(t *testing.T, conn net.Conn) {
+	t.Helper()
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	if banner, err := r.ReadString('\n'); err != nil || banner != "SSH-2.0-Test\r\n" {
+		t.Fatalf("banner = %q, %v", banner, err)
+	}
+	conn.Write([]byte("ping\n"))
+	if echo, err := r.ReadString('\n'); err != nil || echo != "ping\n" {
+		t.Fatalf("echo = %q, %v", echo, err)
+	}
+}
+
+func proxyStage(err error) string {
+	var proxyErr *ProxyError
+	if errors.As(err, &proxyErr) {
+		return proxyErr.Stage
+	}
+	return ""
+}
+
+func TestDialThroughSOCKS5(t *testing.T) {
+	target := startBannerServer(t)
+	proxyAddr := startSOCKS5Server(t, "alice", "s3cret")
+
+	conn, err := dialThroughProxy(ProxyConfig{Mode: ProxyModeSOCKS5, Address: proxyAddr, Username: "alice", Password: "s3cret"}, target, 5*time.Second)
+	if err != nil {
+		t.Fatalf("dialThroughProxy() returned error: %v", err)
+	}
+	checkTunnel(t, conn)
+
+	tests := []struct {
+		name  string
+		cfg   ProxyConfig
+		stage string
+	}{
+		{"wrong password", ProxyConfig{Mode: ProxyModeSOCKS5, Address: proxyAddr, Username: "alice", Password: "nope"}, ProxyStageHandshake},
+		{"proxy down", ProxyConfig{Mode: ProxyModeSOCKS5, Address: closedAddress(t)}, ProxyStageConnect},
+	}
+	for _, tt := range tests {
+		if _, err := dialThroughProxy(tt.cfg, target, 5*time.Second); proxyStage(err) != tt.stage {
+			t.Errorf("%s: error %v has stage %q, want %q", tt.name, err, proxyStage(err), tt.stage)
+		}
+	}
+
+	cfg := ProxyConfig{Mode: ProxyModeSOCKS5, Address: proxyAddr, Username: "alice", Password: "s3cret"}
+	if _, err := dialThroughProxy(cfg, closedAddress(t), 5*time.Second); proxyStage(err) != ProxyStageTunnel {
+		t.Errorf("destination down: error %v has stage %q, want %q", err, proxyStage(err), ProxyStageTunnel)
+	}
+}
+
+func TestDialThroughHTTPConnect(t *testing.T) {
+	target := startBannerServer(t)
+	proxyAddr := startConnectProxy(t, "Basic Ym9iOmh1bnRlcjI=") // bob:hunter2
+
+	conn, err := dialThroughProxy(ProxyConfig{Mode: ProxyModeHTTP, Address: proxyAddr, Username: "bob", Password: "hunter2"}, target, 5*time.Second)
+	if err != nil {
+		t.Fatalf("dialThroughProxy() returned error: %v", err)
+	}
+	checkTunnel(t, conn)
+
+	if _, err := dialThroughProxy(ProxyConfig{Mode: ProxyModeHTTP, Address: proxyAddr}, target, 5*time.Second); proxyStage(err) != ProxyStageHandshake {
+		t.Errorf("missing credentials: error %v has stage %q", err, proxyStage(err))
+	}
+	cfg := ProxyConfig{Mode: ProxyModeHTTP, Address: proxyAddr, Username: "bob", Password: "hunter2"}
+	if _, err := dialThroughProxy(cfg, closedAddress(t), 5*time.Second); proxyStage(err) != ProxyStageTunnel {
+		t.Errorf("destination down: error %v has stage %q", err, proxyStage(err))
+	}
+}
+
+func TestSystemProxyConfig(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(name string) string { return vars[name] }
+	}
+	tests := []struct {
+		vars    map[string]string
+		address string
+		want    ProxyConfig
+	}{
+		{map[string]string{}, "db.corp:22", ProxyConfig{Mode: ProxyModeNone}},
+		{map[string]string{"HTTPS_PROXY": "http://u:p@proxy.corp:3128"}, "db.corp:22",
+			ProxyConfig{Mode: ProxyModeHTTP, Address: "proxy.corp:3128", Username: "u", Password: "p"}},
+		{map[string]string{"all_proxy": "socks5h://gw.corp"}, "db.corp:22", ProxyConfig{Mode: ProxyModeSOCKS5, Address: "gw.corp:1080"}},
+		{map[string]string{"HTTPS_PROXY": "proxy.corp:3128"}, "db.corp:22", ProxyConfig{Mode: ProxyModeHTTP, Address: "proxy.corp:3128"}},
+		{map[string]string{"HTTPS_PROXY": "http://proxy.corp:3128", "NO_PROXY": ".corp"}, "db.corp:22", ProxyConfig{Mode: ProxyModeNone}},
+		{map[string]string{"HTTPS_PROXY": "http://proxy.corp:3128", "NO_PROXY": ".corp"}, "example.com:22",
+			ProxyConfig{Mode: ProxyModeHTTP, Address: "proxy.corp:3128"}},
+	}
+	for _, tt := range tests {
+		got, err := systemProxyConfig(env(tt.vars), tt.address)
+		if err != nil || got != tt.want {
+			t.Errorf("systemProxyConfig(%v, %s) = %+v, %v, want %+v", tt.vars, tt.address, got, err, tt.want)
+		}
+	}
+}
+
+func TestSSHProxyConfigOverride(t *testing.T) {
+	app := NewApp()
+	app.config.config.Proxy = ProxyConfig{Mode: ProxyModeSystem}
+
+	if got := app.sshProxyConfig(&SSHConfig{}); got.Mode != ProxyModeSystem {
+		t.Errorf("profile without proxy uses mode %q, want global %q", got.Mode, ProxyModeSystem)
+	}
+	override := &ProxyConfig{Mode: ProxyModeNone}
+	if got := app.sshProxyConfig(&SSHConfig{Proxy: override}); got.Mode != ProxyModeNone {
+		t.Errorf("profile override ignored: mode %q", got.Mode)
+	}
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// SSHFP lookup constants (RFC 4255, RFC 6594, RFC 7479)
+const (
+	SSHFPLookupTimeout = 2 * time.Second
+	ResolvConfPath     = "/etc/resolv.conf"
+
+	dnsTypeSSHFP  = dnsmessage.Type(44) // Not among dnsmessage's named types
+	dnsMaxUDPSize = 4096
+
+	sshfpAlgRSA     = 1
+	sshfpAlgDSA     = 2
+	sshfpAlgECDSA   = 3
+	sshfpAlgEd25519 = 4
+	sshfpTypeSHA1   = 1
+	sshfpTypeSHA256 = 2
+)
+
+// Host key verification methods reported by GetSSHConnectionInfo
+const (
+	HostKeyVerifiedKnownHosts   = "known_hosts"   // Key matched an existing known_hosts entry
+	HostKeyVerifiedSSHFP        = "sshfp"         // Unknown host, key matched DNS SSHFP records
+	HostKeyAcceptedNew          = "accepted_new"  // Unknown host, accepted on first use
+	HostKeyVerifiedUserApproved = "user_approved" // Changed key approved by the user
+)
+
+// errNoNameservers is returned when resolv.conf can't be read or lists no nameservers
+var errNoNameservers = errors.New("no DNS nameservers configured in " + ResolvConfPath)
+
+// SSHFPRecord is a single DNS SSHFP resource record
+type SSHFPRecord struct {
+	Algorithm       uint8
+	FingerprintType uint8
+	Fingerprint     []byte
+}
+
+// HostKeyVerification records how a session's host key was trusted
+type HostKeyVerification struct {
+	Method      string    `json:"method"`
+	Hostname    string    `json:"hostname"`
+	Fingerprint string    `json:"fingerprint"`
+	DNSSEC      bool      `json:"dnssec"` // Resolver set the AD flag on the SSHFP answer
+	VerifiedAt  time.Time `json:"verifiedAt"`
+}
+
+// sshfpLookup is replaced in tests
+var sshfpLookup = lookupSSHFP
+
+// hostKeyVerifications tracks the verification result per session
+var hostKeyVerifications = make(map[string]*HostKeyVerification)
+var hostKeyVerificationsMutex sync.RWMutex
+
+// recordHostKeyVerification stores how the host key for a session was trusted.
+// A plain known_hosts match never overwrites a more specific earlier result (e.g. from the monitoring connection).
+func recordHostKeyVerification(sessionID, method, hostname string, key ssh.PublicKey, dnssec bool) {
+	hostKeyVerificationsMutex.Lock()
+	defer hostKeyVerificationsMutex.Unlock()
+
+	if existing, exists := hostKeyVerifications[sessionID]; exists && method == HostKeyVerifiedKnownHosts && existing.Method != HostKeyVerifiedKnownHosts {
+		return
+	}
+
+	hostKeyVerifications[sessionID] = &HostKeyVerification{
+		Method:      method,
+		Hostname:    hostname,
+		Fingerprint: ssh.FingerprintSHA256(key),
+		DNSSEC:      dnssec,
+		VerifiedAt:  time.Now(),
+	}
+}
+
+// getHostKeyVerification returns a copy of the verification result for a session
+func getHostKeyVerification(sessionID string) *HostKeyVerification {
+	hostKeyVerificationsMutex.RLock()
+	defer hostKeyVerificationsMutex.RUnlock()
+
+	if v, exists := hostKeyVerifications[sessionID]; exists {
+		copied := *v
+		return &copied
+	}
+	return nil
+}
+
+// forgetHostKeyVerification drops the verification result once a session is closed
+func forgetHostKeyVerification(sessionID string) {
+	hostKeyVerificationsMutex.Lock()
+	defer hostKeyVerificationsMutex.Unlock()
+	delete(hostKeyVerifications, sessionID)
+}
+
+// acceptNewHostKey trusts a host that is not in known_hosts. With DNS verification enabled,
+// SSHFP records the resolver validated with DNSSEC are trusted without asking, and mismatching
+// ones abort the connection. Anything else, including an SSHFP match from a plain DNS answer
+// that could have been spoofed, is only added once the user accepts it (trust on first use).
+func (a *App) acceptNewHostKey(sessionID, knownHostsPath, hostname string, remote net.Addr, key ssh.PublicKey) error {
+	method := HostKeyAcceptedNew
+	dnssec := false
+	note := ""
+
+	if a.verifyHostKeyDNSEnabled() {
+		verified, secure, err := a.verifyHostKeyDNS(sessionID, hostname, key)
+		if err != nil {
+			return err
+		}
+		if verified {
+			method = HostKeyVerifiedSSHFP
+			dnssec = secure
+			if !secure {
+				note = "SSHFP match (not DNSSEC-validated)"
+			}
+		}
+	}
+
+	if method != HostKeyVerifiedSSHFP || !dnssec {
+		if err := a.confirmFirstConnect(sessionID, hostname, key, note); err != nil {
+			return err
+		}
+	}
+
+	if err := a.addHostKeyToKnownHosts(sessionID, knownHostsPath, hostname, remote, key); err != nil {
+		return err
+	}
+	recordHostKeyVerification(sessionID, method, hostname, key, dnssec)
+	return nil
+}
+
+// verifyHostKeyDNSEnabled reports whether SSHFP verification is turned on
+func (a *App) verifyHostKeyDNSEnabled() bool {
+	if a.config == nil {
+		return false
+	}
+	a.config.mutex.RLock()
+	defer a.config.mutex.RUnlock()
+	return a.config.config != nil && a.config.config.VerifyHostKeyDNS
+}
+
+// verifyHostKeyDNS checks the presented key against SSHFP records for the host.
+// Lookup failures and hosts without applicable records are not errors; a mismatch is.
+func (a *App) verifyHostKeyDNS(sessionID, hostname string, key ssh.PublicKey) (verified bool, dnssec bool, err error) {
+	host := hostname
+	if h, _, splitErr := net.SplitHostPort(hostname); splitErr == nil {
+		host = h
+	}
+	if net.ParseIP(host) != nil {
+		return false, false, nil // SSHFP records only exist for names
+	}
+
+	records, authenticated, lookupErr := sshfpLookup(host, SSHFPLookupTimeout)
+	if errors.Is(lookupErr, errNoNameservers) {
+		a.messages.EmitMessage(sessionID, fmt.Sprintf("SSHFP lookup for %s skipped: %v", host, lookupErr), MessageWarning)
+		return false, false, nil
+	}
+	if lookupErr != nil {
+		a.messages.EmitMessage(sessionID, fmt.Sprintf("SSHFP lookup for %s failed: %v", host, lookupErr), MessageDebug)
+		return false, false, nil
+	}
+
+	matched, applicable := matchSSHFP(records, key)
+	if !applicable {
+		return false, false, nil
+	}
+
+	if !matched {
+		a.messages.EmitMessage(sessionID, fmt.Sprintf("Host key for %s does not match its DNS SSHFP records!", host), MessageError)
+		a.messages.EmitMessage(sessionID, fmt.Sprintf("Presented: %s", ssh.FingerprintSHA256(key)), MessageInfo)
+		return false, authenticated, fmt.Errorf("host key verification failed: SSHFP mismatch for %s", host)
+	}
+
+	dnssecNote := "not DNSSEC-validated"
+	if authenticated {
+		dnssecNote = "DNSSEC-validated"
+	}
+	a.messages.EmitMessage(sessionID, fmt.Sprintf("Host key for %s verified via DNS SSHFP (%s)", host, dnssecNote), MessageSuccess)
+	return true, authenticated, nil
+}
+
+// sshfpAlgorithm maps an SSH key type to its SSHFP algorithm number, or 0 if there is none
+func sshfpAlgorithm(key ssh.PublicKey) uint8 {
+	keyType := key.Type()
+	switch {
+	case keyType == ssh.KeyAlgoRSA:
+		return sshfpAlgRSA
+	case keyType == ssh.KeyAlgoDSA:
+		return sshfpAlgDSA
+	case strings.HasPrefix(keyType, "ecdsa-sha2-"):
+		return sshfpAlgECDSA
+	case keyType == ssh.KeyAlgoED25519:
+		return sshfpAlgEd25519
+	}
+	return 0
+}
+
+// matchSSHFP compares the key with the records. applicable is false when no record covers the
+// key's algorithm with a supported fingerprint type, in which case DNS says nothing about the key.
+func matchSSHFP(records []SSHFPRecord, key ssh.PublicKey) (matched bool, applicable bool) {
+	algorithm := sshfpAlgorithm(key)
+	if algorithm == 0 {
+		return false, false
+	}
+
+	wire := key.Marshal()
+	sha1Sum := sha1.Sum(wire)
+	sha256Sum := sha256.Sum256(wire)
+
+	for _, record := range records {
+		if record.Algorithm != algorithm {
+			continue
+		}
+
+		var expected []byte
+		switch record.FingerprintType {
+		case sshfpTypeSHA1:
+			expected = sha1Sum[:]
+		case sshfpTypeSHA256:
+			expected = sha256Sum[:]
+		default:
+			continue
+		}
+
+		applicable = true
+		if bytes.Equal(record.Fingerprint, expected) {
+			return true, true
+		}
+	}
+	return false, applicable
+}
+
+// lookupSSHFP queries the nameservers in resolv.conf for SSHFP records. authenticated reports
+// whether the resolver set the AD flag, i.e. claims to have validated the answer with DNSSEC.
+// Go's resolver can't look up SSHFP records and Windows has no resolv.conf, so there the
+// lookup fails with errNoNameservers and new hosts go through the first-connect prompt.
+func lookupSSHFP(host string, timeout time.Duration) (records []SSHFPRecord, authenticated bool, err error) {
+	servers, err := systemNameservers(ResolvConfPath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	query, id, err := buildSSHFPQuery(host)
+	if err != nil {
+		return nil, false, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	var lastErr error
+	for _, server := range servers {
+		if time.Now().After(deadline) {
+			break
+		}
+
+		response, err := dnsExchange("udp", server, query, deadline)
+		if err == nil && dnsResponseTruncated(response) {
+			// Retry the same server over TCP
+			response, err = dnsExchange("tcp", server, query, deadline)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		return parseSSHFPResponse(response, id)
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("SSHFP lookup timed out")
+	}
+	return nil, false, lastErr
+}
+
+// systemNameservers reads nameserver addresses from resolv.conf
+func systemNameservers(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errNoNameservers
+	}
+	defer file.Close()
+
+	var servers []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" && net.ParseIP(fields[1]) != nil {
+			servers = append(servers, net.JoinHostPort(fields[1], "53"))
+		}
+	}
+	if len(servers) == 0 {
+		return nil, errNoNameservers
+	}
+	return servers, nil
+}
+
+// buildSSHFPQuery encodes a recursive SSHFP query with the AD bit set (RFC 6840 section 5.7)
+func buildSSHFPQuery(host string) ([]byte, uint16, error) {
+	host = strings.TrimSuffix(host, ".")
+	name, err := dnsmessage.NewName(host + ".")
+	if host == "" || err != nil {
+		return nil, 0, fmt.Errorf("invalid hostname for DNS lookup: %s", host)
+	}
+
+	var idBytes [2]byte
+	if _, err := rand.Read(idBytes[:]); err != nil {
+		return nil, 0, fmt.Errorf("failed to generate DNS query ID: %w", err)
+	}
+	id := binary.BigEndian.Uint16(idBytes[:])
+
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: true, AuthenticData: true})
+	if err := builder.StartQuestions(); err != nil {
+		return nil, 0, err
+	}
+	if err := builder.Question(dnsmessage.Question{Name: name, Type: dnsTypeSSHFP, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, 0, fmt.Errorf("invalid hostname for DNS lookup: %s: %w", host, err)
+	}
+	query, err := builder.Finish()
+	if err != nil {
+		return nil, 0, err
+	}
+	return query, id, nil
+}
+
+// dnsExchange sends a query over UDP or TCP and returns the raw response
+func dnsExchange(network, server string, query []byte, deadline time.Time) ([]byte, error) {
+	conn, err := net.DialTimeout(network, server, time.Until(deadline))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	if network == "tcp" {
+		framed := binary.BigEndian.AppendUint16(nil, uint16(len(query)))
+		if _, err := conn.Write(append(framed, query...)); err != nil {
+			return nil, err
+		}
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return nil, err
+		}
+		response := make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, response); err != nil {
+			return nil, err
+		}
+		return response, nil
+	}
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	response := make([]byte, dnsMaxUDPSize)
+	n, err := conn.Read(response)
+	if err != nil {
+		return nil, err
+	}
+	return response[:n], nil
+}
+
+// dnsResponseTruncated reports whether a UDP response has the TC flag set
+func dnsResponseTruncated(msg []byte) bool {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(msg)
+	return err == nil && header.Truncated
+}
+
+// parseSSHFPResponse extracts SSHFP answers and the AD flag from a DNS response
+func parseSSHFPResponse(msg []byte, id uint16) ([]SSHFPRecord, bool, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(msg)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid DNS response: %w", err)
+	}
+	if header.ID != id {
+		return nil, false, fmt.Errorf("DNS response ID mismatch")
+	}
+	if !header.Response {
+		return nil, false, fmt.Errorf("DNS response is not a reply")
+	}
+
+	switch header.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, header.AuthenticData, nil
+	default:
+		return nil, false, fmt.Errorf("DNS server returned %v", header.RCode)
+	}
+
+	if err := parser.SkipAllQuestions(); err != nil {
+		return nil, false, fmt.Errorf("invalid DNS response: %w", err)
+	}
+
+	var records []SSHFPRecord
+	for {
+		answer, err := parser.AnswerHeader()
+		if err == dnsmessage.ErrSectionDone {
+			break
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid DNS answer: %w", err)
+		}
+		if answer.Type != dnsTypeSSHFP || answer.Class != dnsmessage.ClassINET {
+			if err := parser.SkipAnswer(); err != nil {
+				return nil, false, fmt.Errorf("invalid DNS answer: %w", err)
+			}
+			continue
+		}
+
+		resource, err := parser.UnknownResource()
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid SSHFP record: %w", err)
+		}
+		if len(resource.Data) > 2 {
+			records = append(records, SSHFPRecord{
+				Algorithm:       resource.Data[0],
+				FingerprintType: resource.Data[1],
+				Fingerprint:     append([]byte(nil), resource.Data[2:]...),
+			})
+		}
+	}
+
+	return records, header.AuthenticData, nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// sshfpResponse builds a compressed DNS reply to an SSHFP query for host.example.com
+func sshfpResponse(t *testing.T, header dnsmessage.Header, answers ...dnsmessage.UnknownResource) []byte {
+	t.Helper()
+
+	name := dnsmessage.MustNewName("host.example.com.")
+	header.Response = true
+	builder := dnsmessage.NewBuilder(nil, header)
+	builder.EnableCompression()
+	if err := builder.StartQuestions(); err != nil {
+		t.Fatal(err)
+	}
+	if err := builder.Question(dnsmessage.Question{Name: name, Type: dnsTypeSSHFP, Class: dnsmessage.ClassINET}); err != nil {
+		t.Fatal(err)
+	}
+	if err := builder.StartAnswers(); err != nil {
+		t.Fatal(err)
+	}
+	for _, answer := range answers {
+		if err := builder.UnknownResource(dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: 60}, answer); err != nil {
+			t.Fatal(err)
+		}
+	}
+	msg, err := builder.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestBuildSSHFPQuery(t *testing.T) {
+	query, id, err := buildSSHFPQuery("host.example.com")
+	if err != nil {
+		t.Fatalf("buildSSHFPQuery() returned error: %v", err)
+	}
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil {
+		t.Fatalf("query doesn't parse: %v", err)
+	}
+	if msg.ID != id || !msg.RecursionDesired || !msg.AuthenticData || msg.Response {
+		t.Errorf("query header = %+v, want ID %d with RD and AD", msg.Header, id)
+	}
+	want := dnsmessage.Question{Name: dnsmessage.MustNewName("host.example.com."), Type: dnsTypeSSHFP, Class: dnsmessage.ClassINET}
+	if len(msg.Questions) != 1 || msg.Questions[0] != want {
+		t.Errorf("query questions = %v, want %v", msg.Questions, want)
+	}
+
+	for _, host := range []string{"", "a..b", strings.Repeat("x", 64) + ".com"} {
+		if _, _, err := buildSSHFPQuery(host); err == nil {
+			t.Errorf("buildSSHFPQuery(%q) returned no error", host)
+		}
+	}
+}
+
+func TestParseSSHFPResponse(t *testing.T) {
+	const id = 0x1234
+	ed25519Record := dnsmessage.UnknownResource{Type: dnsTypeSSHFP, Data: []byte{sshfpAlgEd25519, sshfpTypeSHA256, 0xAB, 0xCD}}
+	rsaRecord := dnsmessage.UnknownResource{Type: dnsTypeSSHFP, Data: []byte{sshfpAlgRSA, sshfpTypeSHA1, 0x01}}
+	shortRecord := dnsmessage.UnknownResource{Type: dnsTypeSSHFP, Data: []byte{sshfpAlgRSA, sshfpTypeSHA1}}
+	txtRecord := dnsmessage.UnknownResource{Type: dnsmessage.TypeTXT, Data: []byte{3, 'f', 'o', 'o'}}
+
+	valid := sshfpResponse(t, dnsmessage.Header{ID: id, AuthenticData: true}, ed25519Record, txtRecord, rsaRecord, shortRecord)
+	// Answer names point back at the question name
+	if !bytes.Contains(valid, []byte{0xC0, 0x0C}) {
+		t.Fatal("test response isn't compressed")
+	}
+
+	// An answer whose name is a pointer to itself
+	pointerLoop := []byte{0x12, 0x34, 0x81, 0x80, 0, 0, 0, 1, 0, 0, 0, 0, 0xC0, 0x0C, 0, 44, 0, 1, 0, 0, 0, 60, 0, 0}
+
+	tests := []struct {
+		name          string
+		msg           []byte
+		want          []SSHFPRecord
+		authenticated bool
+		wantErr       bool
+	}{
+		{
+			name: "answers with compressed names",
+			msg:  valid,
+			want: []SSHFPRecord{
+				{Algorithm: sshfpAlgEd25519, FingerprintType: sshfpTypeSHA256, Fingerprint: []byte{0xAB, 0xCD}},
+				{Algorithm: sshfpAlgRSA, FingerprintType: sshfpTypeSHA1, Fingerprint: []byte{0x01}},
+			},
+			authenticated: true,
+		},
+		{name: "not authenticated", msg: sshfpResponse(t, dnsmessage.Header{ID: id}, rsaRecord), want: []SSHFPRecord{{Algorithm: sshfpAlgRSA, FingerprintType: sshfpTypeSHA1, Fingerprint: []byte{0x01}}}},
+		{name: "no answers", msg: sshfpResponse(t, dnsmessage.Header{ID: id})},
+		{name: "nxdomain", msg: sshfpResponse(t, dnsmessage.Header{ID: id, RCode: dnsmessage.RCodeNameError, AuthenticData: true}), authenticated: true},
+		{name: "servfail", msg: sshfpResponse(t, dnsmessage.Header{ID: id, RCode: dnsmessage.RCodeServerFailure}), wantErr: true},
+		{name: "other ID", msg: sshfpResponse(t, dnsmessage.Header{ID: id + 1}, rsaRecord), wantErr: true},
+		{name: "query instead of reply", msg: func() []byte { q, _, _ := buildSSHFPQuery("host.example.com"); q[0], q[1] = 0x12, 0x34; return q }(), wantErr: true},
+		{name: "shorter than a header", msg: valid[:5], wantErr: true},
+		{name: "truncated answer", msg: valid[:len(valid)-3], wantErr: true},
+		{name: "truncated question", msg: valid[:14], wantErr: true},
+		{name: "compression loop", msg: pointerLoop, wantErr: true},
+	}
+	for _, tt := range tests {
+		records, authenticated, err := parseSSHFPResponse(tt.msg, id)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: parseSSHFPResponse() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if !reflect.DeepEqual(records, tt.want) || authenticated != tt.authenticated {
+			t.Errorf("%s: parseSSHFPResponse() = %+v, %v; want %+v, %v", tt.name, records, authenticated, tt.want, tt.authenticated)
+		}
+	}
+}
+
+func TestDNSResponseTruncated(t *testing.T) {
+	if !dnsResponseTruncated(sshfpResponse(t, dnsmessage.Header{Truncated: true})) {
+		t.Error("TC flag not detected")
+	}
+	if dnsResponseTruncated(sshfpResponse(t, dnsmessage.Header{})) || dnsResponseTruncated([]byte{1, 2}) {
+		t.Error("response without the TC flag reported as truncated")
+	}
+}
+
+func TestMatchSSHFP(t *testing.T) {
+	key := generateTestHostKey(t)
+	sha1Sum := sha1.Sum(key.Marshal())
+	sha256Sum := sha256.Sum256(key.Marshal())
+	otherSum := sha256.Sum256([]byte("other key"))
+
+	tests := []struct {
+		name       string
+		records    []SSHFPRecord
+		matched    bool
+		applicable bool
+	}{
+		{"no records", nil, false, false},
+		{"sha256 match", []SSHFPRecord{{sshfpAlgEd25519, sshfpTypeSHA256, sha256Sum[:]}}, true, true},
+		{"sha1 match", []SSHFPRecord{{sshfpAlgEd25519, sshfpTypeSHA1, sha1Sum[:]}}, true, true},
+		{"mismatch", []SSHFPRecord{{sshfpAlgEd25519, sshfpTypeSHA256, otherSum[:]}}, false, true},
+		{"one of several matches", []SSHFPRecord{{sshfpAlgEd25519, sshfpTypeSHA256, otherSum[:]}, {sshfpAlgEd25519, sshfpTypeSHA1, sha1Sum[:]}}, true, true},
+		{"other algorithm only", []SSHFPRecord{{sshfpAlgRSA, sshfpTypeSHA256, sha256Sum[:]}}, false, false},
+		{"unknown fingerprint type", []SSHFPRecord{{sshfpAlgEd25519, 9, sha256Sum[:]}}, false, false},
+	}
+	for _, tt := range tests {
+		matched, applicable := matchSSHFP(tt.records, key)
+		if matched != tt.matched || applicable != tt.applicable {
+			t.Errorf("%s: matchSSHFP() = %v, %v; want %v, %v", tt.name, matched, applicable, tt.matched, tt.applicable)
+		}
+	}
+}
+
+func TestSystemNameservers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resolv.conf")
+	content := "# comment\nsearch example.com\nnameserver 192.0.2.1\nnameserver ::1\nnameserver not-an-ip\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	servers, err := systemNameservers(path)
+	if want := []string{"192.0.2.1:53", "[::1]:53"}; err != nil || !reflect.DeepEqual(servers, want) {
+		t.Errorf("systemNameservers() = %v, %v; want %v", servers, err, want)
+	}
+	if _, err := systemNameservers(filepath.Join(t.TempDir(), "missing")); err != errNoNameservers {
+		t.Errorf("systemNameservers() of a missing file = %v, want errNoNameservers", err)
+	}
+}
+
+func TestAcceptNewHostKeySSHFP(t *testing.T) {
+	key := generateTestHostKey(t)
+	sum := sha256.Sum256(key.Marshal())
+	matching := []SSHFPRecord{{sshfpAlgEd25519, sshfpTypeSHA256, sum[:]}}
+
+	originalLookup := sshfpLookup
+	defer func() { sshfpLookup = originalLookup }()
+
+	for _, secure := range []bool{true, false} {
+		sessionID := "session_sshfp"
+		defer forgetHostKeyVerification(sessionID)
+		sshfpLookup = func(host string, timeout time.Duration) ([]SSHFPRecord, bool, error) {
+			return matching, secure, nil
+		}
+		app := NewApp()
+		app.config.config.VerifyHostKeyDNS = true
+		app.terminal.tabs["tab_test"] = &Tab{ID: "tab_test", SessionID: sessionID}
+		knownHostsPath := filepath.Join(t.TempDir(), "known_hosts")
+
+		result := make(chan error, 1)
+		remote := &net.TCPAddr{IP: net.ParseIP("192.0.2.10"), Port: 22}
+		go func() { result <- app.acceptNewHostKey(sessionID, knownHostsPath, "host.example.com", remote, key) }()
+
+		if !secure {
+			// A spoofable answer still needs the user
+			deadline := time.Now().Add(2 * time.Second)
+			for resolveHostKeyTrust(sessionID, true) != nil {
+				if time.Now().After(deadline) {
+					t.Fatal("unauthenticated SSHFP match skipped the first-connect prompt")
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		}
+		if err := waitForHostKeyResult(t, result); err != nil {
+			t.Fatalf("acceptNewHostKey() with secure=%v returned error: %v", secure, err)
+		}
+		verification := getHostKeyVerification(sessionID)
+		if verification == nil || verification.Method != HostKeyVerifiedSSHFP || verification.DNSSEC != secure {
+			t.Errorf("verification with secure=%v = %+v", secure, verification)
+		}
+		if content, _ := os.ReadFile(knownHostsPath); !strings.Contains(string(content), string(ssh.MarshalAuthorizedKey(key)[:40])) {
+			t.Errorf("key not written to known_hosts: %q", content)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// DefaultTabTitleFormat is the title of SSH tabs whose connection doesn't set TitleFormat
+const DefaultTabTitleFormat = "{username}@{hostname}:{port}"
+
+// TabTitleRefreshDelay batches title updates from output (cd, finished commands) and transfers
+const TabTitleRefreshDelay = 250 * time.Millisecond
+
+// tabTitleVariables are the names a title format may use between braces
+var tabTitleVariables = map[string]bool{
+	"username":        true,
+	"user":            true, // Alias of username
+	"hostname":        true,
+	"host":            true, // Alias of hostname
+	"port":            true,
+	"profile-name":    true,
+	"session-id":      true,
+	"connection-type": true,
+	"status":          true,
+	"cwd":             true, // Working directory reported by the shell (OSC 7)
+	"exit-code":       true, // Exit code of the last command (OSC 133)
+	"transfers":       true, // SFTP transfers running in the session
+}
+
+// tabTitleVariableList is the allowed variables as shown in validation errors
+const tabTitleVariableList = "{username}, {user}, {hostname}, {host}, {port}, {profile-name}, {session-id}, {connection-type}, {status}, {cwd}, {exit-code}, {transfers}"
+
+// titleSegment is literal text or, when variable is set, a variable to substitute
+type titleSegment struct {
+	literal  string
+	variable string
+}
+
+// compileTitleFormat splits a format like "{username}@{host}" into segments, rejecting
+// unknown variables and unclosed braces
+func compileTitleFormat(format string) ([]titleSegment, error) {
+	var segments []titleSegment
+	rest := format
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		literal := rest
+		if open >= 0 {
+			literal = rest[:open]
+		}
+		if strings.IndexByte(literal, '}') >= 0 {
+			return nil, fmt.Errorf("unmatched '}' in title format %q", format)
+		}
+		if open < 0 {
+			segments = append(segments, titleSegment{literal: rest})
+			break
+		}
+		if open > 0 {
+			segments = append(segments, titleSegment{literal: literal})
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed '{' in title format %q", format)
+		}
+		name := rest[open+1 : open+end]
+		if !tabTitleVariables[name] {
+			return nil, fmt.Errorf("unknown title variable {%s}. Allowed variables are: %s", name, tabTitleVariableList)
+		}
+		segments = append(segments, titleSegment{variable: name})
+		rest = rest[open+end+1:]
+	}
+	return segments, nil
+}
+
+// tabTitleFormat returns the format that builds the tab's title: its own, then its
+// connection's, then its profile's, then the SSH default. Empty when the title isn't generated.
+func tabTitleFormat(tab *Tab) string {
+	if tab.customTitle {
+		return ""
+	}
+	if tab.TitleFormat != "" {
+		return tab.TitleFormat
+	}
+	if tab.SSHConfig != nil {
+		if tab.SSHConfig.TitleFormat != "" {
+			return tab.SSHConfig.TitleFormat
+		}
+	}
+	if tab.profileTitleFormat != "" {
+		return tab.profileTitleFormat
+	}
+	if tab.SSHConfig != nil {
+		return DefaultTabTitleFormat
+	}
+	return ""
+}
+
+// formatTabTitle builds the tab's title with profileName for {profile-name}. It returns the
+// current title when the tab has no format or the format renders to nothing.
+func formatTabTitle(tab *Tab, profileName string) string {
+	format := tabTitleFormat(tab)
+	if format == "" {
+		return tab.Title
+	}
+	segments, err := compileTitleFormat(format)
+	if err != nil {
+		fmt.Printf("Invalid title format for tab %s, using default: %v\n", tab.ID, err)
+		if tab.SSHConfig == nil {
+			return tab.Title
+		}
+		segments, _ = compileTitleFormat(DefaultTabTitleFormat)
+	}
+
+	cwd, exitCode := shellIntegrationValues(tab.SessionID)
+	values := map[string]string{
+		"profile-name":    profileName,
+		"session-id":      tab.SessionID,
+		"connection-type": tab.ConnectionType,
+		"status":          tab.Status,
+		"cwd":             cwd,
+		"exit-code":       exitCode,
+		"transfers":       strconv.Itoa(activeTransferCount(tab.SessionID)),
+	}
+	if tab.SSHConfig != nil {
+		values["username"] = tab.SSHConfig.Username
+		values["user"] = tab.SSHConfig.Username
+		values["hostname"] = tab.SSHConfig.Host
+		values["host"] = tab.SSHConfig.Host
+		values["port"] = strconv.Itoa(tab.SSHConfig.Port)
+	}
+
+	var title strings.Builder
+	for _, segment := range segments {
+		if segment.variable != "" {
+			title.WriteString(values[segment.variable])
+		} else {
+			title.WriteString(segment.literal)
+		}
+	}
+	if strings.TrimSpace(title.String()) == "" {
+		return tab.Title
+	}
+	return title.String()
+}
+
+// FormatTabTitle returns the title the tab's format gives with its current session variables
+func (a *App) FormatTabTitle(tab *Tab) string {
+	if tab == nil {
+		return ""
+	}
+	profileName := ""
+	if tab.ProfileID != "" {
+		a.profiles.mutex.RLock()
+		if profile, exists := a.profiles.profiles[tab.ProfileID]; exists {
+			profileName = profile.Name
+		}
+		a.profiles.mutex.RUnlock()
+	}
+	return formatTabTitle(tab, profileName)
+}
+
+// refreshTabTitle re-formats a tab's title and emits tab-title-updated when it changed
+func (a *App) refreshTabTitle(tabID string) {
+	a.terminal.mutex.RLock()
+	tab, exists := a.terminal.tabs[tabID]
+	if !exists {
+		a.terminal.mutex.RUnlock()
+		return
+	}
+	snapshot := *tab
+	a.terminal.mutex.RUnlock()
+
+	// The profile lock is taken without holding the terminal lock
+	title := a.FormatTabTitle(&snapshot)
+
+	a.terminal.mutex.Lock()
+	if tab.Title == title || tabTitleFormat(tab) != tabTitleFormat(&snapshot) {
+		// Unchanged, or renamed/re-formatted meanwhile by a call that refreshes on its own
+		a.terminal.mutex.Unlock()
+		return
+	}
+	tab.Title = title
+	a.terminal.mutex.Unlock()
+
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, "tab-title-updated", map[string]interface{}{
+			"tabId": tabID,
+			"title": title,
+		})
+	}
+}
+
+// UpdateTabTitle sets the format a tab's title is built from and re-formats it with the
+// current session variables. An empty format goes back to the connection's format.
+func (a *App) UpdateTabTitle(tabID, titleFormat string) error {
+	if _, err := compileTitleFormat(titleFormat); err != nil {
+		return err
+	}
+
+	a.terminal.mutex.Lock()
+	tab, exists := a.terminal.tabs[tabID]
+	if !exists {
+		a.terminal.mutex.Unlock()
+		return newNotFoundError(ErrCategoryTerminal, "UpdateTabTitle", "tab %s not found", tabID)
+	}
+	tab.TitleFormat = titleFormat
+	tab.customTitle = false
+	a.terminal.mutex.Unlock()
+
+	a.refreshTabTitle(tabID)
+	return nil
+}
+
+// ValidateTitleFormat checks a title format before it is saved, e.g. from the profile editor.
+// Tabs with an invalid format fall back to the default title.
+func (a *App) ValidateTitleFormat(titleFormat string) error {
+	_, err := compileTitleFormat(titleFormat)
+	return err
+}
+
+var tabTitleRefreshTimers = make(map[string]*time.Timer) // Session -> pending refresh
+var tabTitleRefreshTimersMu sync.Mutex
+
+// scheduleTabTitleRefresh re-formats the title of a session's tab after TabTitleRefreshDelay.
+// Changes arriving while a refresh is pending join it rather than pushing it back, so a
+// chatty session still gets its title updated.
+func (a *App) scheduleTabTitleRefresh(sessionID string) {
+	tabTitleRefreshTimersMu.Lock()
+	defer tabTitleRefreshTimersMu.Unlock()
+
+	if _, pending := tabTitleRefreshTimers[sessionID]; pending {
+		return
+	}
+	tabTitleRefreshTimers[sessionID] = time.AfterFunc(TabTitleRefreshDelay, func() {
+		tabTitleRefreshTimersMu.Lock()
+		delete(tabTitleRefreshTimers, sessionID)
+		tabTitleRefreshTimersMu.Unlock()
+
+		if tabID := a.sessionTabID(sessionID); tabID != "" {
+			a.refreshTabTitle(tabID)
+		}
+	})
+}
+
+// cancelTabTitleRefresh drops a session's pending title refresh
+func cancelTabTitleRefresh(sessionID string) {
+	tabTitleRefreshTimersMu.Lock()
+	defer tabTitleRefreshTimersMu.Unlock()
+	if timer, pending := tabTitleRefreshTimers[sessionID]; pending {
+		timer.Stop()
+		delete(tabTitleRefreshTimers, sessionID)
+	}
+}
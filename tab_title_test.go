@@ -0,0 +1,83 @@
+package main
+
+import "testing"
+
+func TestFormatTabTitle(t *testing.T) {
+	tab := &Tab{
+		ID:             "tab_1",
+		Title:          "old",
+		SessionID:      "session_1",
+		ConnectionType: ConnectionTypeSSH,
+		Status:         "connected",
+		SSHConfig:      &SSHConfig{Host: "db1", Port: 2222, Username: "deploy"},
+	}
+	if got := formatTabTitle(tab, ""); got != "deploy@db1:2222" {
+		t.Errorf("default format gave %q", got)
+	}
+
+	tab.SSHConfig.TitleFormat = "{profile-name} ({host}) [{status}]"
+	if got := formatTabTitle(tab, "Prod DB"); got != "Prod DB (db1) [connected]" {
+		t.Errorf("connection format gave %q", got)
+	}
+
+	tab.TitleFormat = "{connection-type}:{session-id}"
+	if got := formatTabTitle(tab, "Prod DB"); got != "ssh:session_1" {
+		t.Errorf("tab format gave %q", got)
+	}
+
+	// A format that renders to nothing keeps the current title
+	tab.TitleFormat = "{profile-name}"
+	if got := formatTabTitle(tab, ""); got != "old" {
+		t.Errorf("empty render gave %q, want the current title", got)
+	}
+
+	tab.customTitle = true
+	if got := formatTabTitle(tab, "Prod DB"); got != "old" {
+		t.Errorf("renamed tab gave %q, want the current title", got)
+	}
+}
+
+func TestCompileTitleFormatErrors(t *testing.T) {
+	for _, format := range []string{"{usr}", "{hostname", "x {port} {nope}", "{host}} ", "a } b"} {
+		if _, err := compileTitleFormat(format); err == nil {
+			t.Errorf("compileTitleFormat(%q) accepted an invalid format", format)
+		}
+	}
+	if _, err := compileTitleFormat("plain title"); err != nil {
+		t.Errorf("compileTitleFormat() rejected a literal title: %v", err)
+	}
+}
+
+func TestFormatTabTitleLiveVariables(t *testing.T) {
+	sessionID := "session_title_live"
+	defer forgetShellIntegration(sessionID)
+	tab := &Tab{
+		ID:                 "tab_live",
+		Title:              "old",
+		SessionID:          sessionID,
+		ConnectionType:     ConnectionTypeSSH,
+		Status:             "connected",
+		SSHConfig:          &SSHConfig{Host: "db1", Port: 22, Username: "deploy"},
+		profileTitleFormat: "{user}@{host} · {cwd} · {status} [{exit-code}]",
+	}
+
+	// The sequences arrive split across reads, terminated by BEL and by ST
+	if recordShellIntegration(sessionID, "prompt\x1b]7;file://db1/var/lo") {
+		t.Error("a partial sequence was reported as a change")
+	}
+	if !recordShellIntegration(sessionID, "g%20files\a$ false\r\n\x1b]133;D;1\x1b\\") {
+		t.Error("working directory and exit code changes were not reported")
+	}
+	if got := formatTabTitle(tab, ""); got != "deploy@db1 · /var/log files · connected [1]" {
+		t.Errorf("profile format gave %q", got)
+	}
+	if recordShellIntegration(sessionID, "\x1b]7;file://db1/var/log%20files\a") {
+		t.Error("an unchanged directory was reported as a change")
+	}
+
+	// The connection's format wins over the profile's; an invalid one falls back to the default
+	tab.SSHConfig.TitleFormat = "{cwd"
+	if got := formatTabTitle(tab, ""); got != "deploy@db1:22" {
+		t.Errorf("invalid format gave %q, want the default title", got)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Terminal notifications, sent as terminal-bell events
+const (
+	TerminalBellEvent = "terminal-bell"
+	BellKindBell      = "bell" // The output rang the bell: a BEL outside an escape sequence
+	BellKindIdle      = "idle" // A watched session went quiet after sustained output
+	// IdleNotifyMinActivity is how long a watched session must have been printing for its
+	// silence to be worth a notification; a prompt redrawing doesn't count
+	IdleNotifyMinActivity = 10 * time.Second
+)
+
+// bellScanner finds BEL characters that ring the bell. A BEL also ends OSC sequences, such as
+// window titles, so the scanner follows escape sequences across reads to tell the two apart.
+type bellScanner struct {
+	escape   bool // The last byte was ESC
+	inString bool // Inside an OSC, DCS, SOS, PM or APC string
+}
+
+// scan reports whether data rings the bell
+func (s *bellScanner) scan(data string) bool {
+	rang := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if s.escape {
+			// ESC \ ends a string; any other escape cancels it, and may start another
+			s.escape = false
+			s.inString = c == ']' || c == 'P' || c == 'X' || c == '^' || c == '_'
+			continue
+		}
+		switch c {
+		case 0x1b:
+			s.escape = true
+		case 0x18, 0x1a: // CAN and SUB abort a string
+			s.inString = false
+		case '\a':
+			if s.inString {
+				s.inString = false
+			} else {
+				rang = true
+			}
+		}
+	}
+	return rang
+}
+
+// idle reports whether the scanner is outside any sequence
+func (s *bellScanner) idle() bool {
+	return !s.escape && !s.inString
+}
+
+// idleWatch fires an idle notification when a session's output stops after sustained activity
+type idleWatch struct {
+	emitter     Emitter
+	quiet       time.Duration // Silence that counts as idle
+	minActivity time.Duration
+	activeSince time.Time // Start of the current burst of output; zero while idle
+	lastOutput  time.Time
+	timer       *time.Timer
+}
+
+// terminalBellState is a session's bell scanner and idle watch. Sessions get one only while a
+// sequence is split across reads or idle notifications are on.
+type terminalBellState struct {
+	scanner bellScanner
+	watch   *idleWatch
+}
+
+var terminalBellStates = make(map[string]*terminalBellState)
+var terminalBellStatesMu sync.Mutex
+
+// recordTerminalBell scans session output for the bell and restarts the idle timer of a
+// watched session. Reports whether the output rang the bell; bells within one read count once.
+func (a *App) recordTerminalBell(sessionID, data string) bool {
+	terminalBellStatesMu.Lock()
+	defer terminalBellStatesMu.Unlock()
+
+	state, exists := terminalBellStates[sessionID]
+	if !exists {
+		if !strings.ContainsAny(data, "\a\x1b") {
+			return false
+		}
+		state = &terminalBellState{}
+	}
+
+	rang := state.scanner.scan(data)
+	if watch := state.watch; watch != nil {
+		now := time.Now()
+		if watch.activeSince.IsZero() {
+			watch.activeSince = now
+		}
+		watch.lastOutput = now
+		watch.timer.Reset(watch.quiet)
+	}
+
+	if state.scanner.idle() && state.watch == nil {
+		delete(terminalBellStates, sessionID)
+	} else {
+		terminalBellStates[sessionID] = state
+	}
+	return rang
+}
+
+// checkIdle runs when a watched session's idle timer fires, and sends the idle notification if
+// the session had been busy long enough
+func (a *App) checkIdle(sessionID string) {
+	terminalBellStatesMu.Lock()
+	state, exists := terminalBellStates[sessionID]
+	if !exists || state.watch == nil || state.watch.activeSince.IsZero() {
+		terminalBellStatesMu.Unlock()
+		return
+	}
+	watch := state.watch
+	if silence := time.Since(watch.lastOutput); silence < watch.quiet {
+		// Output arrived as the timer fired; it was reset
+		terminalBellStatesMu.Unlock()
+		return
+	}
+	active := watch.lastOutput.Sub(watch.activeSince)
+	watch.activeSince = time.Time{}
+	emitter := watch.emitter
+	terminalBellStatesMu.Unlock()
+
+	if active >= watch.minActivity {
+		a.emitTerminalBell(emitter, sessionID, BellKindIdle, active)
+	}
+}
+
+// watchIdle turns idle notifications for a session on or off
+func (a *App) watchIdle(emitter Emitter, sessionID string, enabled bool, quiet, minActivity time.Duration) {
+	terminalBellStatesMu.Lock()
+	defer terminalBellStatesMu.Unlock()
+
+	state, exists := terminalBellStates[sessionID]
+	if !enabled {
+		if exists && state.watch != nil {
+			state.watch.timer.Stop()
+			state.watch = nil
+			if state.scanner.idle() {
+				delete(terminalBellStates, sessionID)
+			}
+		}
+		return
+	}
+
+	if !exists {
+		state = &terminalBellState{}
+		terminalBellStates[sessionID] = state
+	}
+	if state.watch != nil {
+		state.watch.timer.Stop()
+	}
+	timer := time.AfterFunc(quiet, func() { a.checkIdle(sessionID) })
+	timer.Stop() // Armed by the first output
+	state.watch = &idleWatch{emitter: emitter, quiet: quiet, minActivity: minActivity, timer: timer}
+}
+
+// releaseTerminalBell drops a closed session's bell state and stops its idle timer
+func releaseTerminalBell(sessionID string) {
+	terminalBellStatesMu.Lock()
+	defer terminalBellStatesMu.Unlock()
+	if state, exists := terminalBellStates[sessionID]; exists {
+		if state.watch != nil {
+			state.watch.timer.Stop()
+		}
+		delete(terminalBellStates, sessionID)
+	}
+}
+
+// getIdleNotifyQuiet returns how long a watched session must be silent to count as idle
+func (a *App) getIdleNotifyQuiet() time.Duration {
+	seconds := 0
+	if a.config != nil {
+		a.config.mutex.RLock()
+		if a.config.config != nil {
+			seconds = a.config.config.IdleNotifySeconds
+		}
+		a.config.mutex.RUnlock()
+	}
+	return idleNotifyQuietWithDefaults(seconds)
+}
+
+// idleNotifyQuietWithDefaults turns the configured idle time into a duration, applying the
+// default when unset. For callers that already hold config.mutex.
+func idleNotifyQuietWithDefaults(seconds int) time.Duration {
+	if seconds <= 0 {
+		seconds = DefaultIdleNotifySeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// bellNotificationsEnabled reports whether bells and idle sessions should raise OS notifications
+func (a *App) bellNotificationsEnabled() bool {
+	if a.config == nil || a.config.config == nil {
+		return false
+	}
+	a.config.mutex.RLock()
+	defer a.config.mutex.RUnlock()
+	return a.config.config.BellNotifications
+}
+
+// emitTerminalBell sends a terminal-bell event. The frontend flashes the tab, and shows an OS
+// notification when notify is set and the tab isn't in view.
+func (a *App) emitTerminalBell(emitter Emitter, sessionID, kind string, active time.Duration) {
+	event := map[string]interface{}{
+		"sessionId": sessionID,
+		"tabId":     a.sessionTabID(sessionID),
+		"kind":      kind,
+		"notify":    a.bellNotificationsEnabled(),
+	}
+	if kind == BellKindIdle {
+		event["activeMs"] = active.Milliseconds()
+	}
+	emitter.Emit(TerminalBellEvent, event)
+}
+
+// SetIdleNotification turns on or off the notification sent when a session's output stops
+// after at least IdleNotifyMinActivity of activity, such as a long build finishing
+func (a *App) SetIdleNotification(sessionID string, enabled bool) error {
+	if enabled && !a.sessionHasTab(sessionID) {
+		return newNotFoundError(ErrCategoryTerminal, "SetIdleNotification", "session %s not found", sessionID)
+	}
+	a.watchIdle(appEmitter{a}, sessionID, enabled, a.getIdleNotifyQuiet(), IdleNotifyMinActivity)
+	return nil
+}
+
+// GetIdleNotification reports whether idle notifications are on for a session
+func (a *App) GetIdleNotification(sessionID string) bool {
+	terminalBellStatesMu.Lock()
+	defer terminalBellStatesMu.Unlock()
+	state, exists := terminalBellStates[sessionID]
+	return exists && state.watch != nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBellScanner(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []bool
+	}{
+		{"plain bell", []string{"done\a"}, []bool{true}},
+		{"title ended by BEL", []string{"\x1b]0;user@host: ~\a$ "}, []bool{false}},
+		{"title ended by ST, then a bell", []string{"\x1b]2;build\x1b\\\a"}, []bool{true}},
+		{"title split across reads", []string{"\x1b]0;long ti", "tle\a", "\a"}, []bool{false, false, true}},
+		{"escape split across reads", []string{"text\x1b", "]0;t\a"}, []bool{false, false}},
+		{"colors don't hide the bell", []string{"\x1b[31mfailed\x1b[0m\a"}, []bool{true}},
+		{"CAN aborts a string", []string{"\x1bPq#0\x18\a"}, []bool{true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var scanner bellScanner
+			for i, chunk := range tt.chunks {
+				if got := scanner.scan(chunk); got != tt.want[i] {
+					t.Errorf("scan(%q) = %v, want %v", chunk, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRecordTerminalBellKeepsStateOnlyMidSequence(t *testing.T) {
+	app := NewApp()
+	sessionID := "session_bell_state"
+	defer releaseTerminalBell(sessionID)
+
+	if !app.recordTerminalBell(sessionID, "\x1b[1mdone\x1b[0m\a") {
+		t.Error("recordTerminalBell() missed the bell")
+	}
+	if app.recordTerminalBell(sessionID, "\x1b]0;half a ti") {
+		t.Error("recordTerminalBell() rang for a title")
+	}
+	terminalBellStatesMu.Lock()
+	_, midSequence := terminalBellStates[sessionID]
+	terminalBellStatesMu.Unlock()
+	if !midSequence {
+		t.Fatal("a title split across reads lost its state")
+	}
+
+	if app.recordTerminalBell(sessionID, "tle\a$ ") {
+		t.Error("the BEL ending a split title rang the bell")
+	}
+	terminalBellStatesMu.Lock()
+	_, kept := terminalBellStates[sessionID]
+	terminalBellStatesMu.Unlock()
+	if kept {
+		t.Error("state kept after the sequence ended")
+	}
+}
+
+func TestIdleNotification(t *testing.T) {
+	app := NewApp()
+	sessionID := "session_idle_watch"
+	defer releaseTerminalBell(sessionID)
+	emitter := &recordingEmitter{}
+	events := func() int {
+		emitter.mu.Lock()
+		defer emitter.mu.Unlock()
+		return len(emitter.events)
+	}
+
+	const quiet = 100 * time.Millisecond
+	app.watchIdle(emitter, sessionID, true, quiet, 150*time.Millisecond)
+	if !app.GetIdleNotification(sessionID) {
+		t.Fatal("GetIdleNotification() = false after turning it on")
+	}
+
+	// A short burst isn't worth a notification
+	app.recordTerminalBell(sessionID, "$ ")
+	time.Sleep(3 * quiet)
+	if n := events(); n != 0 {
+		t.Fatalf("got %d events after a short burst, want none", n)
+	}
+
+	// Sustained output, then silence
+	for start := time.Now(); time.Since(start) < 200*time.Millisecond; time.Sleep(10 * time.Millisecond) {
+		app.recordTerminalBell(sessionID, "compiling...\r\n")
+	}
+	time.Sleep(3 * quiet)
+	if n := events(); n != 1 {
+		t.Fatalf("got %d events after sustained output stopped, want 1", n)
+	}
+	emitter.mu.Lock()
+	name, event := emitter.names[0], emitter.events[0]
+	emitter.mu.Unlock()
+	if name != TerminalBellEvent || event["kind"] != BellKindIdle || event["sessionId"] != sessionID {
+		t.Errorf("event = %s %v, want an idle terminal-bell", name, event)
+	}
+	if activeMs, _ := event["activeMs"].(int64); activeMs < 150 {
+		t.Errorf("activeMs = %v, want the length of the burst", event["activeMs"])
+	}
+
+	app.watchIdle(emitter, sessionID, false, 0, 0)
+	if app.GetIdleNotification(sessionID) {
+		t.Error("GetIdleNotification() = true after turning it off")
+	}
+	terminalBellStatesMu.Lock()
+	_, kept := terminalBellStates[sessionID]
+	terminalBellStatesMu.Unlock()
+	if kept {
+		t.Error("state kept after idle notifications were turned off")
+	}
+}
+
+func TestSetIdleNotificationNeedsSession(t *testing.T) {
+	app := NewApp()
+	if err := app.SetIdleNotification("session_no_tab", true); toThermicError(err).Code != ErrCodeNotFound {
+		t.Errorf("SetIdleNotification() for an unknown session = %v, want not found", err)
+	}
+}
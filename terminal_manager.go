@@ -19,6 +19,58 @@ func (a *App) StartShell(shell string, sessionId string) error {
 		shell = a.GetDefaultShell()
 	}
 
+	return a.startPtySession(sessionId, func(ptty pty.Pty) (*pty.Cmd, error) {
+		// Handle WSL shells differently (VS Code style)
+		if strings.HasPrefix(shell, "wsl::") {
+			// Extract WSL distribution name
+			distName := strings.TrimPrefix(shell, "wsl::")
+
+			// Validate that we have a distribution name
+			if distName == "" || distName == "undefined" {
+				return nil, fmt.Errorf("invalid WSL distribution name: %s", distName)
+			}
+
+			// Find WSL executable using universal detection
+			wslPath, err := findWSLExecutable()
+			if err != nil {
+				return nil, fmt.Errorf("wsl.exe not found: %v", err)
+			}
+
+			// VS Code approach: always specify the distribution explicitly
+			cmd := ptty.Command(wslPath, "-d", distName)
+			// Configure Windows-specific process attributes
+			configurePtyProcess(cmd)
+			return cmd, nil
+		}
+
+		// Get the full path to the shell executable using native detection
+		shellPath, err := findShellExecutable(shell)
+		if err != nil {
+			return nil, fmt.Errorf("shell not found: %v", err)
+		}
+
+		// Create command with PTY using full path (exactly like VS Code does)
+		var cmd *pty.Cmd
+		switch runtime.GOOS {
+		case "windows":
+			// On Windows, use the shell directly with PTY
+			cmd = ptty.Command(shellPath)
+		case "darwin":
+			// On macOS, don't use -i flag as it can cause issues with zsh
+			cmd = ptty.Command(shellPath)
+		default:
+			// On other Unix-like systems, use interactive shell
+			cmd = ptty.Command(shellPath, "-i")
+		}
+		// Configure platform-specific process attributes (prevents additional windows on macOS/Windows)
+		configurePtyProcess(cmd)
+		return cmd, nil
+	})
+}
+
+// startPtySession creates a PTY, starts the command produced by buildCmd inside it
+// and registers the resulting terminal session under sessionId.
+func (a *App) startPtySession(sessionId string, buildCmd func(ptty pty.Pty) (*pty.Cmd, error)) error {
 	a.terminal.mutex.Lock()
 	defer a.terminal.mutex.Unlock()
 
@@ -49,60 +101,17 @@ func (a *App) StartShell(shell string, sessionId string) error {
 		// Not critical, continue - size will be synced later
 	}
 
-	// Handle WSL shells differently (VS Code style)
-	var cmd *pty.Cmd
-	if strings.HasPrefix(shell, "wsl::") {
-		// Extract WSL distribution name
-		distName := strings.TrimPrefix(shell, "wsl::")
-
-		// Validate that we have a distribution name
-		if distName == "" || distName == "undefined" {
-			ptty.Close()
-			return fmt.Errorf("invalid WSL distribution name: %s", distName)
-		}
-
-		// Find WSL executable using universal detection
-		wslPath, err := findWSLExecutable()
-		if err != nil {
-			ptty.Close()
-			return fmt.Errorf("wsl.exe not found: %v", err)
-		}
-
-		// VS Code approach: always specify the distribution explicitly
-		cmd = ptty.Command(wslPath, "-d", distName)
-		// Configure Windows-specific process attributes
-		configurePtyProcess(cmd)
-	} else {
-		// Get the full path to the shell executable using native detection
-		shellPath, err := findShellExecutable(shell)
-		if err != nil {
-			ptty.Close()
-			return fmt.Errorf("shell not found: %v", err)
-		}
-
-		// Create command with PTY using full path (exactly like VS Code does)
-		switch runtime.GOOS {
-		case "windows":
-			// On Windows, use the shell directly with PTY
-			cmd = ptty.Command(shellPath)
-			// Configure Windows-specific process attributes
-			configurePtyProcess(cmd)
-		case "darwin":
-			// On macOS, don't use -i flag as it can cause issues with zsh
-			cmd = ptty.Command(shellPath)
-			// Configure Unix-specific process attributes (prevents additional windows on macOS)
-			configurePtyProcess(cmd)
-		default:
-			// On other Unix-like systems, use interactive shell
-			cmd = ptty.Command(shellPath, "-i")
-			// Configure Unix-specific process attributes (prevents additional windows on macOS)
-			configurePtyProcess(cmd)
-		}
+	cmd, err := buildCmd(ptty)
+	if err != nil {
+		ptty.Close()
+		return err
 	}
 
 	// Set working directory
-	if wd, err := os.Getwd(); err == nil {
-		cmd.Dir = wd
+	if cmd.Dir == "" {
+		if wd, err := os.Getwd(); err == nil {
+			cmd.Dir = wd
+		}
 	}
 
 	// Start the command in the PTY
@@ -219,10 +228,7 @@ func (a *App) streamPtyOutputWithContext(sessionId string, ptty pty.Pty, ctx con
 				data := string(buffer[:n])
 				// Send raw PTY data to frontend (exactly like VS Code)
 				if a.ctx != nil {
-					wailsRuntime.EventsEmit(a.ctx, "terminal-output", map[string]interface{}{
-						"sessionId": sessionId,
-						"data":      data,
-					})
+					a.emitSessionOutput(sessionId, data)
 				}
 			}
 		}
@@ -266,10 +272,7 @@ func (a *App) monitorProcessWithContext(sessionId string, cmd *pty.Cmd, ctx cont
 
 	// Notify frontend that process has ended
 	if a.ctx != nil {
-		wailsRuntime.EventsEmit(a.ctx, "terminal-output", map[string]interface{}{
-			"sessionId": sessionId,
-			"data":      "\r\n[Process completed]\r\n",
-		})
+		a.emitTerminalOutput(sessionId, "\r\n[Process completed]\r\n")
 	}
 }
 
@@ -314,6 +317,15 @@ func (a *App) syncTerminalSize(sessionId string, ctx context.Context) {
 
 // WriteToShell writes data to the PTY or SSH session
 func (a *App) WriteToShell(sessionId string, data string) error {
+	return a.writeShellInput(sessionId, []byte(data))
+}
+
+// writeShellInput writes data to the PTY or SSH session as is, without a copy the caller
+// can't wipe afterwards
+func (a *App) writeShellInput(sessionId string, data []byte) error {
+	if isReplaySession(sessionId) {
+		return nil // Replays are read-only
+	}
 	a.terminal.mutex.RLock()
 
 	// Check if it's a PTY session
@@ -322,7 +334,7 @@ func (a *App) WriteToShell(sessionId string, data string) error {
 		if session.isClosing() {
 			return fmt.Errorf("session %s is closing", sessionId)
 		}
-		_, err := session.pty.Write([]byte(data))
+		_, err := session.pty.Write(data)
 		return err
 	}
 	a.terminal.mutex.RUnlock()
@@ -331,15 +343,18 @@ func (a *App) WriteToShell(sessionId string, data string) error {
 	a.ssh.sshSessionsMutex.RLock()
 	if sshSession, exists := a.ssh.sshSessions[sessionId]; exists {
 		a.ssh.sshSessionsMutex.RUnlock()
-		return a.WriteToSSHSession(sshSession, data)
+		return writeSSHSessionInput(sshSession, data)
 	}
 	a.ssh.sshSessionsMutex.RUnlock()
 
-	return fmt.Errorf("session %s not found", sessionId)
+	return newNotFoundError(ErrCategoryTerminal, "WriteToShell", "session %s not found", sessionId)
 }
 
 // ResizeShell resizes the PTY or SSH session
 func (a *App) ResizeShell(sessionId string, cols, rows int) error {
+	if isReplaySession(sessionId) {
+		return nil // Replays keep the recorded size
+	}
 	a.terminal.mutex.Lock()
 	// Check if it's a PTY session
 	if session, exists := a.terminal.sessions[sessionId]; exists {
@@ -363,11 +378,15 @@ func (a *App) ResizeShell(sessionId string, cols, rows int) error {
 	}
 	a.ssh.sshSessionsMutex.Unlock()
 
-	return fmt.Errorf("session %s not found", sessionId)
+	return newNotFoundError(ErrCategoryTerminal, "ResizeShell", "session %s not found", sessionId)
 }
 
 // CloseShell closes a PTY or SSH session with proper cleanup
 func (a *App) CloseShell(sessionId string) error {
+	if isReplaySession(sessionId) {
+		a.releaseReplaySession(sessionId)
+		return nil
+	}
 	// First, check and handle PTY sessions
 	a.terminal.mutex.Lock()
 	session, isPtySession := a.terminal.sessions[sessionId]
@@ -434,7 +453,7 @@ func (a *App) CloseShell(sessionId string) error {
 		return a.CloseSSHSession(sshSession)
 	}
 
-	return fmt.Errorf("session %s not found", sessionId)
+	return newNotFoundError(ErrCategoryTerminal, "CloseShell", "session %s not found", sessionId)
 }
 
 // IsSessionClosed checks if a session is completely closed and cleaned up
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// TransferThroughputInterval is how often the aggregate transfer-throughput event is emitted
+const TransferThroughputInterval = time.Second
+
+// Bytes moved by all transfers since the last throughput tick
+var (
+	transferBytesUp   atomic.Int64
+	transferBytesDown atomic.Int64
+)
+
+// recordTransferBytes counts n bytes moved by a session's transfer in direction "upload" or
+// "download", for the throughput ticker and the transfer totals
+func recordTransferBytes(sessionID, direction string, n int) {
+	recordTransferTotals(sessionID, direction, n)
+	if direction == "download" {
+		transferBytesDown.Add(int64(n))
+	} else {
+		transferBytesUp.Add(int64(n))
+	}
+}
+
+// takeTransferThroughput returns the bytes per second moved since the previous call, elapsed ago
+func takeTransferThroughput(elapsed time.Duration) (upload, download int64) {
+	up, down := transferBytesUp.Swap(0), transferBytesDown.Swap(0)
+	if elapsed <= 0 {
+		return 0, 0
+	}
+	return int64(float64(up) / elapsed.Seconds()), int64(float64(down) / elapsed.Seconds())
+}
+
+// startTransferThroughputTicker emits a transfer-throughput event every second with the
+// combined upload and download rate of all transfers in all sessions. Once transfers stop, a
+// single zero reading is sent and the ticker stays quiet until bytes move again.
+func (a *App) startTransferThroughputTicker() {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Printf("Transfer throughput ticker panic: %v\n", r)
+			}
+		}()
+
+		ticker := time.NewTicker(TransferThroughputInterval)
+		defer ticker.Stop()
+
+		last := time.Now()
+		idle := true
+		for {
+			select {
+			case <-a.registry.stopChan:
+				return
+			case now := <-ticker.C:
+				upload, download := takeTransferThroughput(now.Sub(last))
+				last = now
+				if upload == 0 && download == 0 {
+					if idle {
+						continue
+					}
+					idle = true
+				} else {
+					idle = false
+				}
+				if a.ctx != nil {
+					wailsRuntime.EventsEmit(a.ctx, "transfer-throughput", map[string]interface{}{
+						"uploadBytesPerSec":   upload,
+						"downloadBytesPerSec": download,
+						"totalBytesPerSec":    upload + download,
+					})
+				}
+			}
+		}
+	}()
+}
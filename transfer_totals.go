@@ -0,0 +1,85 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// TransferTotals is the number of bytes uploaded and downloaded by SFTP transfers
+type TransferTotals struct {
+	Uploaded   int64 `yaml:"uploaded" json:"uploaded"`
+	Downloaded int64 `yaml:"downloaded" json:"downloaded"`
+}
+
+// transferCounter accumulates the bytes moved in each direction
+type transferCounter struct {
+	uploaded   atomic.Int64
+	downloaded atomic.Int64
+}
+
+// add counts n bytes moved in direction "upload" or "download"
+func (c *transferCounter) add(direction string, n int64) {
+	if direction == "download" {
+		c.downloaded.Add(n)
+	} else {
+		c.uploaded.Add(n)
+	}
+}
+
+func (c *transferCounter) totals() TransferTotals {
+	return TransferTotals{Uploaded: c.uploaded.Load(), Downloaded: c.downloaded.Load()}
+}
+
+var (
+	// transferTotalsGlobal holds every byte moved since the totals were last reset; it is
+	// saved in the metrics file
+	transferTotalsGlobal transferCounter
+
+	// sessionTransferTotals holds the bytes moved by each live session
+	sessionTransferTotals   = make(map[string]*transferCounter)
+	sessionTransferTotalsMu sync.Mutex
+)
+
+// recordTransferTotals adds n bytes moved by a session's transfer to its totals and the global ones
+func recordTransferTotals(sessionID, direction string, n int) {
+	sessionTransferTotalsMu.Lock()
+	counter, exists := sessionTransferTotals[sessionID]
+	if !exists {
+		counter = &transferCounter{}
+		sessionTransferTotals[sessionID] = counter
+	}
+	sessionTransferTotalsMu.Unlock()
+
+	counter.add(direction, int64(n))
+	transferTotalsGlobal.add(direction, int64(n))
+}
+
+// setGlobalTransferTotals replaces the global totals, as when the metrics file is loaded or reset
+func setGlobalTransferTotals(totals TransferTotals) {
+	transferTotalsGlobal.uploaded.Store(totals.Uploaded)
+	transferTotalsGlobal.downloaded.Store(totals.Downloaded)
+}
+
+// releaseTransferTotals drops a closed session's totals
+func releaseTransferTotals(sessionID string) {
+	sessionTransferTotalsMu.Lock()
+	defer sessionTransferTotalsMu.Unlock()
+	delete(sessionTransferTotals, sessionID)
+}
+
+// GetTransferTotals returns the bytes uploaded and downloaded by a session's transfers since it
+// opened. With an empty session ID it returns the totals across all sessions, kept across
+// restarts until the metrics are reset.
+func (a *App) GetTransferTotals(sessionID string) (TransferTotals, error) {
+	if sessionID == "" {
+		return transferTotalsGlobal.totals(), nil
+	}
+
+	sessionTransferTotalsMu.Lock()
+	defer sessionTransferTotalsMu.Unlock()
+	if counter, exists := sessionTransferTotals[sessionID]; exists {
+		return counter.totals(), nil
+	}
+	// A session that hasn't transferred anything yet
+	return TransferTotals{}, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestTransferTotalsPerSession(t *testing.T) {
+	app := NewApp()
+	defer setGlobalTransferTotals(transferTotalsGlobal.totals())
+	setGlobalTransferTotals(TransferTotals{Uploaded: 1000, Downloaded: 2000})
+	defer app.ReleaseSession("session_totals_a")
+	defer app.ReleaseSession("session_totals_b")
+
+	recordTransferBytes("session_totals_a", "upload", 100)
+	recordTransferBytes("session_totals_a", "upload", 50)
+	recordTransferBytes("session_totals_a", "download", 7)
+	recordTransferBytes("session_totals_b", "download", 300)
+
+	if got, err := app.GetTransferTotals("session_totals_a"); err != nil || got != (TransferTotals{Uploaded: 150, Downloaded: 7}) {
+		t.Errorf("GetTransferTotals(a) = %+v, %v; want 150 up, 7 down", got, err)
+	}
+	if got, _ := app.GetTransferTotals("session_totals_b"); got != (TransferTotals{Downloaded: 300}) {
+		t.Errorf("GetTransferTotals(b) = %+v, want 300 down", got)
+	}
+	if got, _ := app.GetTransferTotals(""); got != (TransferTotals{Uploaded: 1150, Downloaded: 2307}) {
+		t.Errorf("GetTransferTotals(\"\") = %+v, want the earlier totals plus both sessions", got)
+	}
+
+	// A closed session's totals are dropped; the global ones stay
+	app.ReleaseSession("session_totals_a")
+	if got, _ := app.GetTransferTotals("session_totals_a"); got != (TransferTotals{}) {
+		t.Errorf("GetTransferTotals() after release = %+v, want zero", got)
+	}
+	if got, _ := app.GetTransferTotals(""); got.Uploaded != 1150 {
+		t.Errorf("global uploaded = %d after a session closed, want 1150", got.Uploaded)
+	}
+}
+
+func TestTransferTotalsSurviveRestart(t *testing.T) {
+	defer setGlobalTransferTotals(transferTotalsGlobal.totals())
+	app := NewApp()
+	app.config.config.ProfilesPath = t.TempDir()
+
+	setGlobalTransferTotals(TransferTotals{Uploaded: 12, Downloaded: 34})
+	if err := app.saveMetrics(); err != nil {
+		t.Fatalf("saveMetrics() returned error: %v", err)
+	}
+
+	setGlobalTransferTotals(TransferTotals{})
+	restarted := NewApp()
+	restarted.config.config.ProfilesPath = app.config.config.ProfilesPath
+	if err := restarted.loadMetrics(); err != nil {
+		t.Fatalf("loadMetrics() returned error: %v", err)
+	}
+	if got, _ := restarted.GetTransferTotals(""); got != (TransferTotals{Uploaded: 12, Downloaded: 34}) {
+		t.Errorf("GetTransferTotals(\"\") after loading metrics = %+v, want the saved totals", got)
+	}
+}
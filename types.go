@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -46,15 +47,18 @@ func (cs ConnectionStatus) String() string {
 
 // Profile type constants
 const (
-	ProfileTypeLocal  = "local"
-	ProfileTypeSSH    = "ssh"
-	ProfileTypeCustom = "custom"
+	ProfileTypeLocal     = "local"
+	ProfileTypeSSH       = "ssh"
+	ProfileTypeCustom    = "custom"
+	ProfileTypeNomadExec = "nomad-exec"
 )
 
 // Connection type constants
 const (
-	ConnectionTypeLocal = "local"
-	ConnectionTypeSSH   = "ssh"
+	ConnectionTypeLocal     = "local"
+	ConnectionTypeSSH       = "ssh"
+	ConnectionTypeNomadExec = "nomad-exec"
+	ConnectionTypeReplay    = "replay" // Plays back a session recording; no PTY or SSH behind it
 )
 
 // Virtual folder type constants
@@ -168,8 +172,22 @@ type TerminalManager struct {
 	sessions        map[string]*TerminalSession
 	tabs            map[string]*Tab
 	activeTabId     string
+	sessionTags     map[string][]string // Tags per tab ID, for filtering and grouping tabs
 	mutex           sync.RWMutex
 	resourceManager *ResourceManager
+
+	// Tab status event debouncing (guarded by statusMutex)
+	statusDebouncer map[string]*time.Timer // Pending debounced emit per session
+	lastStatus      map[string]string      // Last status emitted per session
+	statusMutex     sync.Mutex
+
+	// Inline image protocol negotiation (guarded by imageMutex)
+	imageProtocols []string                      // Protocols the frontend renderer reported it can draw
+	imageFilters   map[string]*inlineImageFilter // Per-session output filter, only for sessions with images enabled
+	imageMutex     sync.Mutex
+	// Recently closed tabs (guarded by mutex)
+	recentlyClosed []*ClosedTab // Most recent first, at most MaxRecentlyClosedTabs
+	closingApp     bool         // Set by shutdown so the tabs it closes aren't remembered
 }
 
 // ProfileManager handles profile and folder management
@@ -182,15 +200,55 @@ type ProfileManager struct {
 	fileHistory     *BoundedSlice[*FileHistoryEntry]
 	mutex           sync.RWMutex
 	resourceManager *ResourceManager
+
+	// Source tracking for profiles and folders loaded from non-primary sources (keyed by ID)
+	profileSources   map[string]ProfileSource
+	folderSources    map[string]ProfileSource
+	sourceCollisions []ProfileSourceCollision
+	loadErrors       []ProfileLoadError // Files the last LoadProfiles couldn't read or parse
+
+	// GetGlobalProfileStats cache
+	globalStats      *GlobalProfileStats
+	globalStatsTime  time.Time
+	globalStatsMutex sync.Mutex
+}
+
+// ProfileStore is the read-only view of loaded profiles that services depend on.
+// Implemented by ProfileManager; returned profiles are copies.
+type ProfileStore interface {
+	Profile(id string) (*Profile, bool)
+	Profiles() []*Profile
+	FolderCount() int
+}
+
+// SessionStore is the view of connected sessions that services depend on.
+// Implemented by SSHManager.
+type SessionStore interface {
+	SSHSession(sessionID string) (*SSHSession, bool)
+	SFTPClient(sessionID string) (*sftp.Client, bool)
+	// InvalidateDirectoryCache drops a session's cached listings after remote files change
+	InvalidateDirectoryCache(sessionID string)
 }
 
 // SSHManager handles SSH connections and SFTP operations
 type SSHManager struct {
-	sshSessions      map[string]*SSHSession
-	sftpClients      map[string]*sftp.Client
-	sshSessionsMutex sync.RWMutex // Dedicated mutex for SSH sessions
-	sftpClientsMutex sync.RWMutex
-	resourceManager  *ResourceManager
+	sshSessions             map[string]*SSHSession
+	sftpClients             map[string]*sftp.Client
+	sftpPools               map[string][]*sftp.Client               // Extra SFTP channels parallel transfers spread over, guarded by sftpClientsMutex
+	directoryCache          map[string]map[string]*directoryListing // Session -> remote path -> listing
+	sftpBufferSizes         map[string]*sftpBufferTune              // Buffer sizes measured per session when AutoTuneBuffer is on
+	sftpSessionConfigs      map[string]SFTPConfig                   // Per-session tuning overrides set with SetSessionSFTPConfig
+	sshSessionsMutex        sync.RWMutex                            // Dedicated mutex for SSH sessions
+	sftpClientsMutex        sync.RWMutex
+	directoryCacheMutex     sync.Mutex
+	sftpBufferMutex         sync.Mutex
+	sftpSessionConfigsMutex sync.RWMutex
+	resourceManager         *ResourceManager
+}
+
+// StatsCollector records monitoring samples. Implemented by MonitoringManager.
+type StatsCollector interface {
+	RecordMetric(sessionID, metricName string, value float64)
 }
 
 // MonitoringManager handles system metrics history and update rates
@@ -198,6 +256,9 @@ type MonitoringManager struct {
 	sessionHistories map[string]*SessionMetrics // Per-session metric histories
 	updateRates      map[string]int             // Per-session update rates (milliseconds)
 	diskIOTracking   map[string]*DiskIOState    // Track previous disk I/O for rate calculation
+	cpuSamples       map[string]*CPUSampleState // Previous CPU counters for usage calculation
+	diskAlerts       map[string]*DiskAlert      // Disk full alerts by alert ID
+	leakDetectors    map[string]*LeakDetector   // Memory leak detectors by detector ID
 	mutex            sync.RWMutex
 	resourceManager  *ResourceManager
 }
@@ -232,12 +293,41 @@ type DiskIOState struct {
 	Timestamp  int64 // Unix timestamp in milliseconds
 }
 
+// CPUCounters are cumulative CPU times: busy and total (busy plus idle and iowait)
+type CPUCounters struct {
+	Busy  float64
+	Total float64
+}
+
+// CPUSampleState tracks the previous CPU counters so usage is measured over the poll interval
+type CPUSampleState struct {
+	Total     CPUCounters
+	PerCore   []CPUCounters
+	LastUsage float64 // Usage computed at the previous poll
+	Ready     bool    // LastUsage holds a measurement
+}
+
+// RemoteMemoryStats is a detailed memory breakdown of a remote host, in megabytes
+type RemoteMemoryStats struct {
+	TotalMB     float64 `json:"totalMB"`
+	UsedMB      float64 `json:"usedMB"` // Total minus available, matching what `free` reports as used
+	FreeMB      float64 `json:"freeMB"`
+	BuffersMB   float64 `json:"buffersMB"`
+	CachedMB    float64 `json:"cachedMB"`
+	AvailableMB float64 `json:"availableMB"`
+	SwapTotalMB float64 `json:"swapTotalMB"`
+	SwapUsedMB  float64 `json:"swapUsedMB"`
+	SwapPercent float64 `json:"swapPercent"` // 0 when the host has no swap
+}
+
 // ConfigManager handles application configuration
 type ConfigManager struct {
 	config          *AppConfig
 	configDirty     bool
 	debounceTimer   *time.Timer
-	mutex           sync.RWMutex
+	loadOnce        sync.Once    // Config is loaded before the window is created, not again in startup
+	setMutex        sync.Mutex   // Serializes ConfigSet calls; taken before mutex, never while holding it
+	mutex           sync.RWMutex // Guards config fields, configDirty and debounceTimer
 	resourceManager *ResourceManager
 }
 
@@ -251,10 +341,21 @@ type App struct {
 	messages        *MessageManager
 	ai              *AIManager
 	monitoring      *MonitoringManager
+	privacy         *PrivacyLockManager
+	probes          *ProfileProbeManager
+	network         *NetworkMonitor
+	registry        *SessionRegistry
+	sftp            *SFTPService // SFTP file operations; the bound methods delegate to it
 	resourceManager *ResourceManager
 	mutex           sync.RWMutex
 }
 
+// Emitter sends events to the frontend. Services take one instead of the Wails context
+// so tests can record what they emit.
+type Emitter interface {
+	Emit(eventName string, data ...interface{})
+}
+
 // Close implements the Cleanup interface for App
 func (a *App) Close() error {
 	// Stop all managers in reverse order
@@ -329,17 +430,24 @@ func (ts *TerminalSession) Close() error {
 
 // Tab represents a terminal tab
 type Tab struct {
-	ID             string     `json:"id"`
-	Title          string     `json:"title"`
-	SessionID      string     `json:"sessionId"`
-	Shell          string     `json:"shell"`
-	IsActive       bool       `json:"isActive"`
-	ConnectionType string     `json:"connectionType"` // "local" or "ssh"
-	SSHConfig      *SSHConfig `json:"sshConfig,omitempty"`
-	ProfileID      string     `json:"profileId,omitempty"` // ID of the profile this tab was created from
-	Created        time.Time  `json:"created"`
-	Status         string     `json:"status"`                 // "connecting", "connected", "failed", "disconnected"
-	ErrorMessage   string     `json:"errorMessage,omitempty"` // Store error details for failed connections
+	ID             string             `json:"id"`
+	Title          string             `json:"title"`
+	SessionID      string             `json:"sessionId"`
+	Shell          string             `json:"shell"`
+	IsActive       bool               `json:"isActive"`
+	ConnectionType string             `json:"connectionType"` // "local", "ssh" or "nomad-exec"
+	SSHConfig      *SSHConfig         `json:"sshConfig,omitempty"`
+	NomadConfig    *NomadConfig       `json:"nomadConfig,omitempty"`
+	ProfileID      string             `json:"profileId,omitempty"` // ID of the profile this tab was created from
+	Tags           []string           `json:"tags,omitempty"`      // Copy of the session's tags in TerminalManager.sessionTags
+	Created        time.Time          `json:"created"`
+	Status         string             `json:"status"`                 // "connecting", "connected", "failed", "disconnected"
+	ErrorMessage   string             `json:"errorMessage,omitempty"` // Store error details for failed connections
+	TitleFormat    string             `json:"titleFormat,omitempty"`  // Overrides the connection's TitleFormat (see UpdateTabTitle)
+	Maintenance    *MaintenanceBanner `json:"maintenance,omitempty"`  // Set while a maintenance window covers the tab
+
+	customTitle        bool   // Renamed by the user; the title is no longer generated from a format
+	profileTitleFormat string // The profile's TitleFormat when the tab was created from one
 }
 
 // Validate implements the Validator interface for Tab
@@ -353,7 +461,7 @@ func (t *Tab) Validate() error {
 	if t.SessionID == "" {
 		return fmt.Errorf("tab session ID cannot be empty")
 	}
-	if t.ConnectionType != ConnectionTypeLocal && t.ConnectionType != ConnectionTypeSSH {
+	if t.ConnectionType != ConnectionTypeLocal && t.ConnectionType != ConnectionTypeSSH && t.ConnectionType != ConnectionTypeReplay {
 		return fmt.Errorf("invalid connection type: %s", t.ConnectionType)
 	}
 	if t.ConnectionType == ConnectionTypeSSH && t.SSHConfig == nil {
@@ -370,6 +478,13 @@ type SSHConfig struct {
 	Password              string `json:"password,omitempty"`              // Optional, prefer key auth
 	KeyPath               string `json:"keyPath,omitempty"`               // Path to SSH private key
 	AllowKeyAutoDiscovery bool   `json:"allowKeyAutoDiscovery,omitempty"` // Allow automatic SSH key discovery
+	CertPath              string `json:"certPath,omitempty"`              // OpenSSH certificate (defaults to <KeyPath>-cert.pub)
+	// Proxy overrides the global proxy setting for this connection (nil or empty mode = global)
+	Proxy *ProxyConfig `yaml:"proxy,omitempty" json:"proxy,omitempty"`
+	// TitleFormat builds the tab title, e.g. "{username}@{hostname}:{port}" (empty = DefaultTabTitleFormat)
+	TitleFormat string `yaml:"title_format,omitempty" json:"titleFormat,omitempty"`
+	// JumpHosts are connected through in order before the destination, each with its own credentials
+	JumpHosts []JumpHostConfig `yaml:"jump_hosts,omitempty" json:"jumpHosts,omitempty"`
 }
 
 // Validate implements the Validator interface for SSHConfig
@@ -383,6 +498,39 @@ func (ssh *SSHConfig) Validate() error {
 	if ssh.Username == "" {
 		return fmt.Errorf("SSH username cannot be empty")
 	}
+	if err := validateJumpHosts(ssh); err != nil {
+		return err
+	}
+	if ssh.Proxy != nil {
+		return ssh.Proxy.Validate()
+	}
+	return nil
+}
+
+// NomadConfig identifies a Nomad allocation task to exec into
+type NomadConfig struct {
+	Address  string `yaml:"address,omitempty" json:"address,omitempty"` // Nomad API address, falls back to NOMAD_ADDR
+	JobID    string `yaml:"job_id" json:"jobId"`
+	AllocID  string `yaml:"alloc_id" json:"allocId"`
+	TaskName string `yaml:"task_name" json:"taskName"`
+	Token    string `yaml:"token,omitempty" json:"token,omitempty"` // ACL token, falls back to NOMAD_TOKEN
+}
+
+// Validate implements the Validator interface for NomadConfig
+func (n *NomadConfig) Validate() error {
+	if n.AllocID == "" {
+		return fmt.Errorf("Nomad allocation ID cannot be empty")
+	}
+	if n.TaskName == "" {
+		return fmt.Errorf("Nomad task name cannot be empty")
+	}
+	// Both end up as nomad CLI arguments, where a leading dash would be read as a flag
+	if strings.HasPrefix(n.AllocID, "-") {
+		return fmt.Errorf("Nomad allocation ID cannot start with '-'")
+	}
+	if strings.HasPrefix(n.TaskName, "-") {
+		return fmt.Errorf("Nomad task name cannot start with '-'")
+	}
 	return nil
 }
 
@@ -400,11 +548,12 @@ type Profile struct {
 	ID           string            `yaml:"id" json:"id"`
 	Name         string            `yaml:"name" json:"name"`
 	Icon         string            `yaml:"icon" json:"icon"`
-	Type         string            `yaml:"type" json:"type"` // "local", "ssh", "custom"
+	Type         string            `yaml:"type" json:"type"` // "local", "ssh", "custom", "nomad-exec"
 	Shell        string            `yaml:"shell" json:"shell"`
 	WorkingDir   string            `yaml:"working_dir" json:"workingDir"`
 	Environment  map[string]string `yaml:"environment" json:"environment"`
 	SSHConfig    *SSHConfig        `yaml:"ssh_config,omitempty" json:"sshConfig,omitempty"`
+	NomadConfig  *NomadConfig      `yaml:"nomad_config,omitempty" json:"nomadConfig,omitempty"`
 	FolderID     string            `yaml:"folder_id,omitempty" json:"folderId,omitempty"` // Direct reference to parent folder by ID
 	SortOrder    int               `yaml:"sort_order" json:"sortOrder"`
 	Created      time.Time         `yaml:"created" json:"created"`
@@ -418,6 +567,22 @@ type Profile struct {
 	IsFavorite  bool                `yaml:"is_favorite,omitempty" json:"isFavorite,omitempty"`   // Quick access
 	Shortcuts   map[string]string   `yaml:"shortcuts,omitempty" json:"shortcuts,omitempty"`      // Custom key bindings
 	FileHistory []*FileHistoryEntry `yaml:"file_history,omitempty" json:"fileHistory,omitempty"` // Remote file access history
+	// Terminal behaviour
+	DisableInlineImages bool   `yaml:"disable_inline_images,omitempty" json:"disableInlineImages,omitempty"` // Pass image escape sequences through untouched and don't advertise image support
+	TitleFormat         string `yaml:"title_format,omitempty" json:"titleFormat,omitempty"`                  // Default title format of the profile's tabs (see tabTitleFormat)
+	// Commands run around connecting
+	// Local hooks, like the commands below, only run when AllowProfileConnectCommands is set
+	PreConnectHooks  []ConnectHook `yaml:"pre_connect_hooks,omitempty" json:"preConnectHooks,omitempty"`   // Run locally before dialing
+	PostConnectHooks []ConnectHook `yaml:"post_connect_hooks,omitempty" json:"postConnectHooks,omitempty"` // Run locally or remotely once the session is ready
+	// Shorthand for a single local hook
+	PreConnectCommand  string `yaml:"pre_connect_command,omitempty" json:"preConnectCommand,omitempty"`   // Run before the other pre-connect hooks; failure aborts the connection
+	PostConnectCommand string `yaml:"post_connect_command,omitempty" json:"postConnectCommand,omitempty"` // Run after the other post-connect hooks; failure only warns
+	// Profiles opened one after another once this profile's tab is open (see ConnectionStep)
+	ConnectionSequence []ConnectionStep `yaml:"connection_sequence,omitempty" json:"connectionSequence,omitempty"`
+	// Reachability badge
+	ProbeEnabled bool `yaml:"probe_enabled,omitempty" json:"probeEnabled,omitempty"` // Periodically check the host accepts TCP connections
+	// Set on profiles created from a discovery provider, so re-runs update them instead of duplicating
+	Discovery *DiscoveryInfo `yaml:"discovery,omitempty" json:"discovery,omitempty"`
 }
 
 // Validate implements the Validator interface for Profile
@@ -428,7 +593,7 @@ func (p *Profile) Validate() error {
 	if p.Name == "" {
 		return fmt.Errorf("profile name cannot be empty")
 	}
-	if p.Type != ProfileTypeLocal && p.Type != ProfileTypeSSH && p.Type != ProfileTypeCustom {
+	if p.Type != ProfileTypeLocal && p.Type != ProfileTypeSSH && p.Type != ProfileTypeCustom && p.Type != ProfileTypeNomadExec {
 		return fmt.Errorf("invalid profile type: %s", p.Type)
 	}
 	if p.Type == ProfileTypeSSH && p.SSHConfig != nil {
@@ -436,12 +601,35 @@ func (p *Profile) Validate() error {
 			return fmt.Errorf("invalid SSH config: %w", err)
 		}
 	}
+	if p.Type == ProfileTypeNomadExec {
+		if p.NomadConfig == nil {
+			return fmt.Errorf("Nomad profile requires a Nomad config")
+		}
+		if err := p.NomadConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid Nomad config: %w", err)
+		}
+	}
 	if len(p.Tags) > MaxTagsPerProfile {
 		return fmt.Errorf("too many tags: %d, maximum allowed: %d", len(p.Tags), MaxTagsPerProfile)
 	}
 	if len(p.FileHistory) > MaxFileHistory {
 		return fmt.Errorf("too many file history entries: %d, maximum allowed: %d", len(p.FileHistory), MaxFileHistory)
 	}
+	if err := validateConnectHooks(HookPhasePreConnect, p.PreConnectHooks); err != nil {
+		return err
+	}
+	if err := validateConnectHooks(HookPhasePostConnect, p.PostConnectHooks); err != nil {
+		return err
+	}
+	if err := validateConnectCommand(HookPhasePreConnect, p.PreConnectCommand); err != nil {
+		return err
+	}
+	if err := validateConnectCommand(HookPhasePostConnect, p.PostConnectCommand); err != nil {
+		return err
+	}
+	if err := validateConnectionSequence(p.ID, p.ConnectionSequence); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -473,11 +661,13 @@ type ProfileTreeNode struct {
 	Children []*ProfileTreeNode `json:"children,omitempty"`
 	Profile  *Profile           `json:"profile,omitempty"`
 	Expanded bool               `json:"expanded"`
+	Source   string             `json:"source,omitempty"` // Label of the profile source the node was loaded from
 }
 
 // ProfileWatcher handles file system watching for profile changes
 type ProfileWatcher struct {
 	watchDir      string
+	watchDirs     []string // All watched source directories, primary first
 	stopChan      chan bool
 	doneChan      chan struct{} // Signals the goroutine has exited
 	updatesChan   chan ProfileUpdate
@@ -505,12 +695,12 @@ type VirtualFolder struct {
 
 // VirtualFilter defines the criteria for virtual folders
 type VirtualFilter struct {
-	Type      string `json:"type"`      // "favorite", "recent", "tag", "type", "search"
+	Type      string `json:"type"`      // "favorite", "recent", "tag", "type", "search", "cert-expiring"
 	Value     string `json:"value"`     // tag name, search term, etc.
 	Limit     int    `json:"limit"`     // max items to show
 	SortBy    string `json:"sortBy"`    // "name", "lastUsed", "usage", "created"
 	SortOrder string `json:"sortOrder"` // "asc", "desc"
-	DateRange int    `json:"dateRange"` // days for recent items
+	DateRange int    `json:"dateRange"` // days for recent items, or warning window for cert-expiring
 }
 
 // ProfileMetrics for analytics and smart features - saved as separate YAML file
@@ -522,6 +712,18 @@ type ProfileMetrics struct {
 	FavoriteProfiles []string       `yaml:"favorite_profiles" json:"favoriteProfiles"`
 	TagUsage         map[string]int `yaml:"tag_usage" json:"tagUsage"`
 	LastSync         time.Time      `yaml:"last_sync" json:"lastSync"`
+	// Usage for profiles from read-only sources, which can't be written back to their files
+	ReadOnlyUsage map[string]*ProfileUsage `yaml:"read_only_usage,omitempty" json:"readOnlyUsage,omitempty"`
+	// Daily reachability probe counters per profile, keyed by profile ID then YYYY-MM-DD
+	ProbeHistory map[string]map[string]*ProbeDayStats `yaml:"probe_history,omitempty" json:"probeHistory,omitempty"`
+	// Bytes moved by all SFTP transfers
+	TransferTotals TransferTotals `yaml:"transfer_totals" json:"transferTotals"`
+}
+
+// ProfileUsage holds usage counters tracked outside the profile file
+type ProfileUsage struct {
+	LastUsed   time.Time `yaml:"last_used" json:"lastUsed"`
+	UsageCount int       `yaml:"usage_count" json:"usageCount"`
 }
 
 // WSLDistribution represents a WSL distribution
@@ -542,6 +744,9 @@ type RemoteFileEntry struct {
 	Size          int64     `json:"size"`                    // Size in bytes
 	Mode          string    `json:"mode"`                    // File mode string (e.g., "drwxr-xr-x")
 	ModifiedTime  time.Time `json:"modifiedTime"`            // Last modification time
+	MountPoint    bool      `json:"mountPoint,omitempty"`    // True if a filesystem is mounted on this path
+	FSType        string    `json:"fsType,omitempty"`        // Filesystem type, set for mount points and entries on special filesystems
+	FSKind        string    `json:"fsKind,omitempty"`        // FilesystemKindVirtual or FilesystemKindNetwork on special filesystems
 }
 
 // Config constants
@@ -565,7 +770,11 @@ func NewApp() *App {
 		sessions:        make(map[string]*TerminalSession),
 		tabs:            make(map[string]*Tab),
 		activeTabId:     "",
+		sessionTags:     make(map[string][]string),
 		resourceManager: terminalRM,
+		statusDebouncer: make(map[string]*time.Timer),
+		lastStatus:      make(map[string]string),
+		imageFilters:    make(map[string]*inlineImageFilter),
 	}
 	mainRM.Register(terminal.resourceManager)
 
@@ -577,6 +786,8 @@ func NewApp() *App {
 		virtualFolders:  make([]*VirtualFolder, 0),
 		metrics:         &ProfileMetrics{},
 		fileHistory:     NewBoundedSlice[*FileHistoryEntry](MaxFileHistory),
+		profileSources:  make(map[string]ProfileSource),
+		folderSources:   make(map[string]ProfileSource),
 		resourceManager: profileRM,
 	}
 	mainRM.Register(profiles.resourceManager)
@@ -584,9 +795,13 @@ func NewApp() *App {
 	// Create SSH manager with resource management
 	sshRM := NewResourceManager()
 	ssh := &SSHManager{
-		sshSessions:     make(map[string]*SSHSession),
-		sftpClients:     make(map[string]*sftp.Client),
-		resourceManager: sshRM,
+		sshSessions:        make(map[string]*SSHSession),
+		sftpClients:        make(map[string]*sftp.Client),
+		sftpPools:          make(map[string][]*sftp.Client),
+		directoryCache:     make(map[string]map[string]*directoryListing),
+		sftpBufferSizes:    make(map[string]*sftpBufferTune),
+		sftpSessionConfigs: make(map[string]SFTPConfig),
+		resourceManager:    sshRM,
 	}
 	mainRM.Register(ssh.resourceManager)
 
@@ -604,6 +819,9 @@ func NewApp() *App {
 		sessionHistories: make(map[string]*SessionMetrics),
 		updateRates:      make(map[string]int),
 		diskIOTracking:   make(map[string]*DiskIOState),
+		cpuSamples:       make(map[string]*CPUSampleState),
+		diskAlerts:       make(map[string]*DiskAlert),
+		leakDetectors:    make(map[string]*LeakDetector),
 		resourceManager:  monitoringRM,
 	}
 	mainRM.Register(monitoring.resourceManager)
@@ -624,6 +842,26 @@ func NewApp() *App {
 	// Create AI manager with default config
 	app.ai = NewAIManager(&config.config.AI)
 
+	// Create privacy lock (requires app reference for emitting output)
+	app.privacy = NewPrivacyLockManager(app.emitTerminalOutputDirect)
+	mainRM.Register(app.privacy)
+
+	// Create the profile reachability prober
+	app.probes = NewProfileProbeManager()
+	mainRM.Register(app.probes)
+
+	// Create the network change monitor
+	app.network = NewNetworkMonitor()
+	mainRM.Register(app.network)
+
+	// Create the SFTP service over the session store
+	app.sftp = NewSFTPService(ssh, appEmitter{app}, app.getSFTPConfig)
+
+	// Create session registry once every manager with per-session state exists
+	app.registry = NewSessionRegistry()
+	app.registerSessionStateSources()
+	mainRM.Register(app.registry)
+
 	return app
 }
 
@@ -820,6 +1058,12 @@ func (mm *MonitoringManager) Close() error {
 	mm.sessionHistories = make(map[string]*SessionMetrics)
 	mm.updateRates = make(map[string]int)
 	mm.diskIOTracking = make(map[string]*DiskIOState)
+	mm.cpuSamples = make(map[string]*CPUSampleState)
+	mm.diskAlerts = make(map[string]*DiskAlert)
+	for _, detector := range mm.leakDetectors {
+		detector.stop()
+	}
+	mm.leakDetectors = make(map[string]*LeakDetector)
 
 	return nil
 }
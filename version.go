@@ -10,6 +10,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"runtime/debug"
 	"strings"
 	"time"
 
@@ -35,18 +36,30 @@ type VersionInfo struct {
 
 // UpdateInfo represents update information from GitHub
 type UpdateInfo struct {
-	Available      bool   `json:"available"`
-	LatestVersion  string `json:"latestVersion"`
-	CurrentVersion string `json:"currentVersion"`
-	DownloadURL    string `json:"downloadUrl"`
-	ReleaseNotes   string `json:"releaseNotes"`
-	Size           int64  `json:"size"`
+	Available      bool      `json:"available"`
+	LatestVersion  string    `json:"latestVersion"`
+	CurrentVersion string    `json:"currentVersion"`
+	DownloadURL    string    `json:"downloadUrl"`
+	ReleaseURL     string    `json:"releaseUrl"`
+	ReleaseNotes   string    `json:"releaseNotes"`
+	Size           int64     `json:"size"`
+	CheckedAt      time.Time `json:"checkedAt"`
+	Disabled       bool      `json:"disabled"` // Update checks are turned off in settings
 }
 
+// Update check constants
+const (
+	UpdateCheckURL      = "https://api.github.com/repos/yzhelezko/thermic/releases/latest"
+	UpdateCheckTimeout  = 10 * time.Second
+	UpdateCacheTTL      = 24 * time.Hour
+	UpdateCacheFileName = "update-check.json"
+)
+
 // GitHubRelease represents GitHub release API response
 type GitHubRelease struct {
 	TagName    string `json:"tag_name"`
 	Name       string `json:"name"`
+	HTMLURL    string `json:"html_url"`
 	Body       string `json:"body"`
 	Draft      bool   `json:"draft"`
 	Prerelease bool   `json:"prerelease"`
@@ -69,16 +82,77 @@ func (a *App) GetVersionInfo() *VersionInfo {
 	}
 }
 
-// CheckForUpdates checks GitHub releases for newer versions
+// wailsModulePath is the module path used to look up the linked Wails version
+const wailsModulePath = "github.com/wailsapp/wails/v2"
+
+// GetAppInfo returns version and build details for the About dialog and bug reports.
+// Version, GitCommit and BuildDate are injected via ldflags (see the release workflows).
+func (a *App) GetAppInfo() map[string]interface{} {
+	return map[string]interface{}{
+		"version":      Version,
+		"gitCommit":    GitCommit,
+		"buildDate":    BuildDate,
+		"goVersion":    runtime.Version(),
+		"os":           runtime.GOOS,
+		"arch":         runtime.GOARCH,
+		"wailsVersion": getWailsVersion(),
+	}
+}
+
+// getWailsVersion reads the Wails module version from the embedded build info
+func getWailsVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == wailsModulePath {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
+
+// CheckForUpdates checks GitHub releases for newer versions.
+// Results are cached on disk for UpdateCacheTTL so launches don't hit the API every time.
 func (a *App) CheckForUpdates() (*UpdateInfo, error) {
-	const repoURL = "https://api.github.com/repos/yzhelezko/thermic/releases/latest"
+	if a.updateChecksDisabled() {
+		return &UpdateInfo{CurrentVersion: Version, Disabled: true}, nil
+	}
 
+	if cached := a.loadCachedUpdateInfo(); cached != nil {
+		return cached, nil
+	}
+
+	return a.CheckForUpdatesNow()
+}
+
+// CheckForUpdatesNow queries the release feed, bypassing the cache (for manual checks)
+func (a *App) CheckForUpdatesNow() (*UpdateInfo, error) {
+	if a.updateChecksDisabled() {
+		return &UpdateInfo{CurrentVersion: Version, Disabled: true}, nil
+	}
+
+	updateInfo, err := fetchLatestRelease()
+	if err != nil {
+		return nil, err
+	}
+
+	a.saveCachedUpdateInfo(updateInfo)
+	return updateInfo, nil
+}
+
+// fetchLatestRelease reads the latest GitHub release and compares it with the running version
+func fetchLatestRelease() (*UpdateInfo, error) {
 	// Create HTTP client with timeout
 	client := &http.Client{
-		Timeout: 10 * time.Second,
+		Timeout: UpdateCheckTimeout,
 	}
 
-	resp, err := client.Get(repoURL)
+	resp, err := client.Get(UpdateCheckURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch release info: %w", err)
 	}
@@ -99,13 +173,16 @@ func (a *App) CheckForUpdates() (*UpdateInfo, error) {
 			Available:      false,
 			CurrentVersion: Version,
 			LatestVersion:  Version,
+			CheckedAt:      time.Now(),
 		}, nil
 	}
 
 	updateInfo := &UpdateInfo{
 		CurrentVersion: Version,
 		LatestVersion:  release.TagName,
+		ReleaseURL:     release.HTMLURL,
 		ReleaseNotes:   release.Body,
+		CheckedAt:      time.Now(),
 	}
 
 	// Compare versions using semantic versioning
@@ -130,6 +207,69 @@ func (a *App) CheckForUpdates() (*UpdateInfo, error) {
 	return updateInfo, nil
 }
 
+// updateChecksDisabled reports whether the user turned off update checks
+func (a *App) updateChecksDisabled() bool {
+	if a.config == nil {
+		return false
+	}
+	a.config.mutex.RLock()
+	defer a.config.mutex.RUnlock()
+	return a.config.config != nil && a.config.config.DisableUpdateCheck
+}
+
+// getUpdateCachePath returns the path of the cached update check result
+func (a *App) getUpdateCachePath() (string, error) {
+	configPath, err := a.getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), UpdateCacheFileName), nil
+}
+
+// loadCachedUpdateInfo returns the cached result if it is fresh and was made for the running version
+func (a *App) loadCachedUpdateInfo() *UpdateInfo {
+	cachePath, err := a.getUpdateCachePath()
+	if err != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil
+	}
+
+	var cached UpdateInfo
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil
+	}
+
+	if cached.CurrentVersion != Version || time.Since(cached.CheckedAt) > UpdateCacheTTL {
+		return nil
+	}
+	return &cached
+}
+
+// saveCachedUpdateInfo stores an update check result; failures only cost a future API call
+func (a *App) saveCachedUpdateInfo(info *UpdateInfo) {
+	if err := a.ensureConfigDir(); err != nil {
+		return
+	}
+
+	cachePath, err := a.getUpdateCachePath()
+	if err != nil {
+		return
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return
+	}
+
+	if err := os.WriteFile(cachePath, data, 0600); err != nil {
+		fmt.Printf("Warning: Failed to cache update check result: %v\n", err)
+	}
+}
+
 // DownloadAndInstallUpdate downloads and installs the update
 func (a *App) DownloadAndInstallUpdate(downloadURL string) error {
 	if downloadURL == "" {
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"fmt"
+
+	"github.com/wailsapp/wails/v2/pkg/options"
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
@@ -36,3 +39,109 @@ func (a *App) GetWindowMaximizedState() bool {
 	}
 	return a.config.config.WindowMaximized
 }
+
+// captureWindowPosition stores the window's current position in the config and reports whether it changed.
+// A minimized window reports a parking position (e.g. -32000 on Windows), so it is ignored.
+// Wails positions are relative to the display the window is on, so that display is stored too.
+func (a *App) captureWindowPosition() bool {
+	if a.ctx == nil || a.config == nil || a.config.config == nil {
+		return false
+	}
+	if wailsRuntime.WindowIsMinimised(a.ctx) {
+		return false
+	}
+
+	x, y := wailsRuntime.WindowGetPosition(a.ctx)
+	screen := ""
+	if screens, err := wailsRuntime.ScreenGetAll(a.ctx); err == nil {
+		if current, ok := currentScreen(screens); ok {
+			screen = screenIdentity(current)
+		}
+	}
+
+	a.config.mutex.Lock()
+	defer a.config.mutex.Unlock()
+
+	cfg := a.config.config
+	if cfg.WindowX != nil && cfg.WindowY != nil && *cfg.WindowX == x && *cfg.WindowY == y && cfg.WindowScreen == screen {
+		return false
+	}
+
+	cfg.WindowX = &x
+	cfg.WindowY = &y
+	cfg.WindowScreen = screen
+	fmt.Printf("Window position updated to %d,%d on display %s\n", x, y, screen)
+	return true
+}
+
+// restoreWindowPosition moves the window to its saved position, or centers it when no
+// position was saved or the saved one is no longer on a visible display
+func (a *App) restoreWindowPosition() {
+	if a.ctx == nil || a.config == nil || a.config.config == nil {
+		return
+	}
+
+	cfg := a.config.config
+	if cfg.WindowX == nil || cfg.WindowY == nil {
+		wailsRuntime.WindowCenter(a.ctx)
+		return
+	}
+
+	screens, err := wailsRuntime.ScreenGetAll(a.ctx)
+	if err != nil {
+		fmt.Printf("Warning: could not enumerate displays, centering window: %v\n", err)
+		wailsRuntime.WindowCenter(a.ctx)
+		return
+	}
+
+	if !isWindowPositionVisible(*cfg.WindowX, *cfg.WindowY, cfg.WindowWidth, cfg.WindowScreen, screens) {
+		fmt.Printf("Saved window position %d,%d on display %s can't be restored, centering window\n", *cfg.WindowX, *cfg.WindowY, cfg.WindowScreen)
+		wailsRuntime.WindowCenter(a.ctx)
+		return
+	}
+
+	wailsRuntime.WindowSetPosition(a.ctx, *cfg.WindowX, *cfg.WindowY)
+	fmt.Printf("Window position restored to %d,%d\n", *cfg.WindowX, *cfg.WindowY)
+}
+
+// currentScreen returns the display the window is on
+func currentScreen(screens []wailsRuntime.Screen) (wailsRuntime.Screen, bool) {
+	for _, screen := range screens {
+		if screen.IsCurrent {
+			return screen, true
+		}
+	}
+	return wailsRuntime.Screen{}, false
+}
+
+// screenIdentity names a display by its resolution and whether it is the primary one. Wails
+// has no display IDs, so two identical secondary displays look the same.
+func screenIdentity(screen wailsRuntime.Screen) string {
+	identity := fmt.Sprintf("%dx%d", screen.PhysicalSize.Width, screen.PhysicalSize.Height)
+	if screen.IsPrimary {
+		identity += " primary"
+	}
+	return identity
+}
+
+// isWindowPositionVisible reports whether a saved position can be restored: the window must
+// still be on the display it was saved on, since Wails positions are relative to it and can't
+// reach another one, and enough of its title bar must land on that display to grab it.
+func isWindowPositionVisible(x, y, width int, savedScreen string, screens []wailsRuntime.Screen) bool {
+	current, ok := currentScreen(screens)
+	if !ok || savedScreen != screenIdentity(current) {
+		return false
+	}
+
+	visibleWidth := min(x+width, current.Size.Width) - max(x, 0)
+	return visibleWidth >= MinVisibleWindowPixels && y >= 0 && y <= current.Size.Height-MinVisibleWindowPixels
+}
+
+// windowStartState returns the state the window is created in. A maximized window with a saved
+// position starts normal so startup can move it to the right display before maximizing it.
+func windowStartState(cfg *AppConfig) options.WindowStartState {
+	if cfg.WindowMaximized && (cfg.WindowX == nil || cfg.WindowY == nil) {
+		return options.Maximised
+	}
+	return options.Normal
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+func TestIsWindowPositionVisible(t *testing.T) {
+	primary := testScreen(1920, 1080)
+	primary.IsPrimary = true
+	secondary := testScreen(2560, 1440)
+	onPrimary := []wailsRuntime.Screen{withCurrent(primary), secondary}
+	onSecondary := []wailsRuntime.Screen{primary, withCurrent(secondary)}
+	saved := screenIdentity(secondary)
+
+	tests := []struct {
+		name    string
+		x, y    int
+		screen  string
+		screens []wailsRuntime.Screen
+		want    bool
+	}{
+		{"inside the saved display", 200, 100, saved, onSecondary, true},
+		{"mostly off the left edge", -700, 100, saved, onSecondary, true},
+		{"off the left edge", -750, 100, saved, onSecondary, false},
+		{"below the display", 200, 1400, saved, onSecondary, false},
+		{"above the display", 200, -10, saved, onSecondary, false},
+		// Positions are relative to the display, so one saved on another display means nothing here
+		{"saved on another display", 200, 100, saved, onPrimary, false},
+		{"saved without a display", 200, 100, "", onSecondary, false},
+		{"no current display", 200, 100, saved, []wailsRuntime.Screen{primary, secondary}, false},
+	}
+	for _, tt := range tests {
+		if got := isWindowPositionVisible(tt.x, tt.y, 800, tt.screen, tt.screens); got != tt.want {
+			t.Errorf("%s: isWindowPositionVisible(%d, %d) = %v, want %v", tt.name, tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func testScreen(width, height int) wailsRuntime.Screen {
+	var screen wailsRuntime.Screen
+	screen.Size.Width, screen.Size.Height = width, height
+	screen.PhysicalSize = screen.Size
+	return screen
+}
+
+func withCurrent(screen wailsRuntime.Screen) wailsRuntime.Screen {
+	screen.IsCurrent = true
+	return screen
+}